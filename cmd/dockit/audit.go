@@ -127,7 +127,7 @@ func (c AuditCommand) auditFunction(view grid.View) error {
 		}
 		tbl.Rows = append(tbl.Rows, row)
 	}
-	rd := cli.NewTableRenderer(os.Stdout)
+	rd := createRenderer(os.Stdout)
 	rd.Render(tbl)
 	return nil
 }
@@ -172,7 +172,7 @@ func (c AuditCommand) audit(view grid.View) error {
 		{"complexity", strconv.Itoa(stats.Complexity)},
 	}
 
-	rd := cli.NewTableRenderer(os.Stdout)
+	rd := createRenderer(os.Stdout)
 	rd.Render(tbl1)
 	rd.Empty()
 	if len(tbl2.Rows) > 0 {
@@ -12,19 +12,25 @@ import (
 	"github.com/midbel/dockit/formula/eval"
 	"github.com/midbel/dockit/formula/repr"
 	"github.com/midbel/dockit/formula/runtime"
+	"github.com/midbel/dockit/formula/stdlib"
+	"github.com/midbel/dockit/value"
 )
 
 var runCmd = cli.Command{
 	Name:    "run",
 	Summary: "Execute given script",
-	Usage:   "run [-g] [-d <dir>] <script.dk>",
+	Usage:   "run [-g] [-F] [-d <dir>] [-c <cache>] [-I <dir>] [-e <path>] <script.dk> [<arg>...]",
 	Handler: &RunCommand{},
 }
 
 type RunCommand struct {
 	Debug        bool
+	Freeze       bool
 	Dialect      string
 	ContextDir   string
+	CacheDir     string
+	IncludePath  string
+	ExternalPath string
 	DateFormat   string
 	NumberFormat string
 }
@@ -32,7 +38,11 @@ type RunCommand struct {
 func (c RunCommand) Run(args []string) error {
 	set := cli.NewFlagSet("run")
 	set.BoolVar(&c.Debug, "g", false, "print debug")
+	set.BoolVar(&c.Freeze, "F", false, "Keep the first value of volatile formulas")
 	set.StringVar(&c.ContextDir, "d", ".", "Context directory")
+	set.StringVar(&c.CacheDir, "c", "", "Cache directory for imported files")
+	set.StringVar(&c.IncludePath, "I", "", "Directory with modules overriding the standard library")
+	set.StringVar(&c.ExternalPath, "e", "", "Directories where workbooks referenced by formulas are searched")
 	if err := set.Parse(args); err != nil {
 		return err
 	}
@@ -52,16 +62,115 @@ func (c RunCommand) Run(args []string) error {
 	)
 	ev.Define("env", runtime.NewEnvValue())
 	ev.Define("flag", runtime.NewFlagValue(name, args))
+	ev.Define("args", runtime.NewArgsValue(args))
+	ev.Define("argc", value.Float(len(args)))
 
 	engine := eval.NewEngine()
 	engine.SetPrintDebug(c.Debug)
+	engine.SetFreeze(c.Freeze)
+	engine.SetPrintPlain(plainOutput)
 	engine.SetContextDir(c.ContextDir)
+	engine.SetCacheDir(c.CacheDir)
+	engine.SetIncludePath(c.IncludePath)
+	engine.SetExternalPath(c.ExternalPath)
 	engine.SetNumberFormat(c.NumberFormat)
 	engine.SetDateFormat(c.DateFormat)
 	_, err = engine.Exec(r, ev)
 	return err
 }
 
+var cacheClearCmd = cli.Command{
+	Name:    "clear",
+	Summary: "Invalidate entries of the import cache",
+	Usage:   "cache clear [-s <size>] <dir>",
+	Handler: &CacheClearCommand{},
+}
+
+type CacheClearCommand struct {
+	Size int64
+}
+
+func (c CacheClearCommand) Run(args []string) error {
+	set := cli.NewFlagSet("clear")
+	set.Int64Var(&c.Size, "s", 0, "Only remove least recently used entries until cache fits in size")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	if set.NArg() != 1 {
+		return cli.ErrUsage
+	}
+	cache := eval.NewImportCache(set.Arg(0), c.Size)
+	if c.Size > 0 {
+		return cache.Trim(c.Size)
+	}
+	return cache.Clear()
+}
+
+var stdlibCmd = cli.Command{
+	Name:    "stdlib",
+	Summary: "List modules of the standard library",
+	Help: `Arguments:
+  name    module's name to print its content
+
+Options:
+  -I <dir>    directory with modules overriding the standard library`,
+	Usage:   "stdlib [-I <dir>] [<name>]",
+	Handler: &StdlibCommand{},
+}
+
+type StdlibCommand struct {
+	IncludePath string
+}
+
+func (c StdlibCommand) Run(args []string) error {
+	set := cli.NewFlagSet("stdlib")
+	set.StringVar(&c.IncludePath, "I", "", "Directory with modules overriding the standard library")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	lib := stdlib.New(c.IncludePath)
+	if set.NArg() >= 1 {
+		return c.printModule(lib, set.Arg(0))
+	}
+	return c.printList(lib)
+}
+
+func (c StdlibCommand) printModule(lib *stdlib.Library, name string) error {
+	if !stdlib.IsStd(name) {
+		name = stdlib.Prefix + name
+	}
+	r, err := lib.Open(name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(cli.Stdout, r)
+	return err
+}
+
+func (c StdlibCommand) printList(lib *stdlib.Library) error {
+	list, err := lib.List()
+	if err != nil {
+		return err
+	}
+	var tbl cli.Table
+	tbl.Headers = []string{"Name", "Description", "User"}
+	for _, m := range list {
+		r := []string{
+			m.Name,
+			m.Desc,
+			cli.MarkBool(m.User),
+		}
+		tbl.Rows = append(tbl.Rows, r)
+	}
+	if plainOutput {
+		return NewPlainRenderer(cli.Stdout).Render(tbl)
+	}
+	rd := cli.NewTableRenderer(cli.Stdout)
+	rd.Render(tbl)
+	return nil
+}
+
 var dumpCmd = cli.Command{
 	Name:    "dump",
 	Alias:   []string{"inspect"},
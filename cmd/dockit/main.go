@@ -9,6 +9,7 @@ import (
 	"github.com/midbel/cli"
 	"github.com/midbel/dockit/flat"
 	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/internal/locale"
 	"github.com/midbel/dockit/internal/slx"
 	"github.com/midbel/dockit/ods"
 	"github.com/midbel/dockit/oxml"
@@ -17,6 +18,10 @@ import (
 
 var errFail = errors.New("fail")
 
+// plainOutput disables the tables drawn by the renderers in favor of tab
+// separated values.
+var plainOutput bool
+
 var (
 	summary = "Dockit transforms the way you handle spreadsheets by moving manual data tasks into your terminal"
 	help    = `Dockit CLI is a data processing tool designed to manipulate, transform, and export tabular data directly from your terminal. 
@@ -43,18 +48,25 @@ func init() {
 func main() {
 	var (
 		set  = cli.NewFlagSet("dockit")
-		root = prepare()
+		lang string
 	)
-	if err := set.Parse(os.Args[1:]); err != nil {
+	set.StringVar(&lang, "l", locale.Detect(), "language of messages")
+	set.BoolVar(&plainOutput, "plain", false, "print tab separated values with row numbers")
+	err := set.Parse(os.Args[1:])
+	if !locale.Set(lang) {
+		fmt.Fprintln(os.Stderr, locale.Sprintf("unsupported language %q", lang))
+	}
+	root := prepare()
+	if err != nil {
 		if errors.Is(err, flag.ErrHelp) {
 			root.Help()
 			os.Exit(2)
 		}
 	}
-	err := root.Execute(set.Args())
+	err = root.Execute(set.Args())
 	if err != nil {
 		if s, ok := err.(cli.SuggestionError); ok && len(s.Others) > 0 {
-			fmt.Fprintln(os.Stderr, "similar command(s)")
+			fmt.Fprintln(os.Stderr, locale.Text("similar command(s)"))
 			for _, n := range s.Others {
 				fmt.Fprintln(os.Stderr, "-", n)
 			}
@@ -68,34 +80,49 @@ func main() {
 
 func prepare() *cli.CommandTrie {
 	root := cli.New()
-	root.SetSummary(summary)
-	root.SetHelp(help)
-
-	root.Register(slx.One("info"), &infoCmd)
-	root.Register(slx.One("merge"), &mergeCmd)
-	root.Register(slx.One("format"), &formatCmd)
-	root.Register(slx.One("run"), &runCmd)
-	root.Register(slx.One("dump"), &dumpCmd)
-	root.Register(slx.One("lock"), &lockCmd)
-	root.Register(slx.One("unlock"), &unlockCmd)
-	root.Register(slx.One("add"), &addCmd)
-	root.Register(slx.One("join"), &joinCmd)
-	root.Register(slx.One("group"), &groupCmd)
-	root.Register(slx.One("transpose"), &transposeCmd)
-	root.Register(slx.One("drop"), &dropCmd)
-	root.Register(slx.One("rename"), &renameCmd)
-	root.Register(slx.One("copy"), &copyCmd)
-	root.Register(slx.One("print"), &printCmd)
-	root.Register(slx.One("audit"), &auditCmd)
-	root.Register(slx.Make("audit", "stats"), &auditStatsCmd)
-	root.Register(slx.Make("audit", "formula"), &auditFormulaCmd)
-	root.Register(slx.Make("audit", "deps"), &auditDepsCmd)
-	root.Register(slx.Make("audit", "graph"), &auditGraphCmd)
-	root.Register(slx.One("builtins"), &builtinsCmd)
+	root.SetSummary(locale.Text(summary))
+	root.SetHelp(locale.Text(help))
+
+	register(root, slx.One("info"), &infoCmd)
+	register(root, slx.One("doctor"), &doctorCmd)
+	register(root, slx.One("merge"), &mergeCmd)
+	register(root, slx.One("extract"), &extractCmd)
+	register(root, slx.One("format"), &formatCmd)
+	register(root, slx.One("run"), &runCmd)
+	register(root, slx.One("dump"), &dumpCmd)
+	register(root, slx.Make("cache", "clear"), &cacheClearCmd)
+	register(root, slx.One("session"), &sessionCmd)
+	register(root, slx.One("repl"), &replCmd)
+	register(root, slx.One("lock"), &lockCmd)
+	register(root, slx.One("unlock"), &unlockCmd)
+	register(root, slx.One("add"), &addCmd)
+	register(root, slx.One("join"), &joinCmd)
+	register(root, slx.One("group"), &groupCmd)
+	register(root, slx.One("transpose"), &transposeCmd)
+	register(root, slx.One("drop"), &dropCmd)
+	register(root, slx.One("rename"), &renameCmd)
+	register(root, slx.One("reorder"), &reorderCmd)
+	register(root, slx.One("page"), &pageCmd)
+	register(root, slx.One("copy"), &copyCmd)
+	register(root, slx.One("print"), &printCmd)
+	register(root, slx.One("render"), &renderCmd)
+	register(root, slx.One("audit"), &auditCmd)
+	register(root, slx.Make("audit", "stats"), &auditStatsCmd)
+	register(root, slx.Make("audit", "formula"), &auditFormulaCmd)
+	register(root, slx.Make("audit", "deps"), &auditDepsCmd)
+	register(root, slx.Make("audit", "graph"), &auditGraphCmd)
+	register(root, slx.One("builtins"), &builtinsCmd)
+	register(root, slx.One("stdlib"), &stdlibCmd)
 
 	return root
 }
 
+func register(root *cli.CommandTrie, path []string, cmd *cli.Command) {
+	cmd.Summary = locale.Text(cmd.Summary)
+	cmd.Help = locale.Text(cmd.Help)
+	root.Register(path, cmd)
+}
+
 func withSheet(path, name string, fn func(grid.View) error) error {
 	wb, err := workbook.Open(path)
 	if err != nil {
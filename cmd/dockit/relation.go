@@ -53,7 +53,7 @@ func (c TransposeCommand) writeView(view grid.View) error {
 	if c.OutFile != "" {
 		return workbook.WriteView(view, c.OutFile)
 	}
-	rd := cli.NewTableRenderer(cli.Stdout)
+	rd := createRenderer(cli.Stdout)
 	rd.Render(sheet2Table(view, false))
 	return nil
 }
@@ -61,9 +61,8 @@ func (c TransposeCommand) writeView(view grid.View) error {
 func (c TransposeCommand) createView(view grid.View) grid.View {
 	if c.Columns != nil {
 		view = grid.NewProjectView(view, c.Columns)
-		view = grid.NewTransposedView(view)
 	}
-	return view
+	return grid.NewTransposedView(view)
 }
 
 var groupCmd = cli.Command{
@@ -100,7 +99,7 @@ func (c GroupCommand) Run(args []string) error {
 			return err
 		}
 
-		rd := cli.NewTableRenderer(cli.Stdout)
+		rd := createRenderer(cli.Stdout)
 		rd.Render(sheet2Table(v, false))
 		return nil
 	})
@@ -177,7 +176,7 @@ func (c JoinCommand) writeView(view grid.View) error {
 	if c.OutFile != "" {
 		return workbook.WriteView(view, c.OutFile)
 	}
-	rd := cli.NewTableRenderer(cli.Stdout)
+	rd := createRenderer(cli.Stdout)
 	rd.Render(sheet2Table(view, false))
 	return nil
 }
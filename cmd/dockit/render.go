@@ -1,8 +1,11 @@
 package main
 
 import (
+	"bufio"
 	"io"
 	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/midbel/cli"
 	"github.com/midbel/dockit/csv"
@@ -41,6 +44,60 @@ func (r *CsvRenderer) Render(tbl cli.Table) error {
 	return nil
 }
 
+// PlainRenderer writes tables as tab separated values. Each line starts with
+// its row number, the headers being on row 0.
+type PlainRenderer struct {
+	out io.Writer
+}
+
+func NewPlainRenderer(w io.Writer) *PlainRenderer {
+	return &PlainRenderer{
+		out: w,
+	}
+}
+
+func (r *PlainRenderer) Render(tbl cli.Table) error {
+	ws := bufio.NewWriter(r.out)
+	if tbl.Title != "" {
+		ws.WriteString(tbl.Title)
+		ws.WriteByte('\n')
+	}
+	if len(tbl.Headers) > 0 {
+		r.writeRow(ws, 0, tbl.Headers)
+	}
+	for i, row := range tbl.Rows {
+		r.writeRow(ws, i+1, row)
+	}
+	return ws.Flush()
+}
+
+var plainReplacer = strings.NewReplacer("\t", "\\t", "\n", "\\n", "\r", "\\r")
+
+func (r *PlainRenderer) writeRow(ws *bufio.Writer, lino int, row []string) {
+	ws.WriteString(strconv.Itoa(lino))
+	for i := range row {
+		ws.WriteByte('\t')
+		ws.WriteString(plainReplacer.Replace(row[i]))
+	}
+	ws.WriteByte('\n')
+}
+
+func (r *PlainRenderer) Empty() {
+	io.WriteString(r.out, "\n")
+}
+
+type tableRenderer interface {
+	cli.Renderer
+	Empty()
+}
+
+func createRenderer(w io.Writer) tableRenderer {
+	if plainOutput {
+		return NewPlainRenderer(w)
+	}
+	return cli.NewTableRenderer(w)
+}
+
 func sheet2Table(sheet grid.View, skipErr bool) cli.Table {
 	var (
 		t cli.Table
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/midbel/cli"
+	"github.com/midbel/dockit/formula/env"
+	"github.com/midbel/dockit/formula/eval"
+	"github.com/midbel/dockit/formula/runtime"
+)
+
+var sessionCmd = cli.Command{
+	Name:    "session",
+	Summary: "Open a workbook once and run commands on it",
+	Usage:   "session [-d <dir>] [-c <cache>] [-s <socket>] <file>",
+	Help: `Session opens the given file once and reads commands from stdin or, with -s,
+from clients connected to a unix socket. Each command is a single line:
+
+* print <expr>: print the result of the expression
+* export <expr> [using <format>] to <file>: export a file or a view
+* eval <statements>: run statements of the script language and print their values
+* quit: end the session (or close the connection)
+
+The output of a command is followed by a line "ok" or by a line "error: <message>".`,
+	Handler: &SessionCommand{},
+}
+
+type SessionCommand struct {
+	ContextDir string
+	CacheDir   string
+	Socket     string
+
+	mu   sync.Mutex
+	sess *eval.Session
+}
+
+func (c *SessionCommand) Run(args []string) error {
+	set := cli.NewFlagSet("session")
+	set.StringVar(&c.ContextDir, "d", ".", "Context directory")
+	set.StringVar(&c.CacheDir, "c", "", "Cache directory for imported files")
+	set.StringVar(&c.Socket, "s", "", "Unix socket to listen on instead of stdin")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	if set.NArg() != 1 {
+		return cli.ErrUsage
+	}
+	ev := env.Empty()
+	ev.Define("env", runtime.NewEnvValue())
+
+	engine := eval.NewEngine()
+	engine.SetPrintPlain(plainOutput)
+	engine.SetContextDir(c.ContextDir)
+	engine.SetCacheDir(c.CacheDir)
+
+	sess, err := engine.NewSession(ev)
+	if err != nil {
+		return err
+	}
+	if err := sess.Import(set.Arg(0), ""); err != nil {
+		return err
+	}
+	c.sess = sess
+	if c.Socket == "" {
+		c.serve(os.Stdin, os.Stdout)
+		return nil
+	}
+	return c.listen()
+}
+
+func (c *SessionCommand) listen() error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	ln, err := net.Listen("unix", c.Socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(c.Socket)
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			c.serve(conn, conn)
+		}()
+	}
+}
+
+func (c *SessionCommand) serve(r io.Reader, w io.Writer) {
+	scan := bufio.NewScanner(r)
+	for scan.Scan() {
+		line := strings.TrimSpace(scan.Text())
+		if line == "" {
+			continue
+		}
+		cmd, rest, _ := strings.Cut(line, " ")
+		if cmd == "quit" {
+			return
+		}
+		if err := c.execute(cmd, rest, w); err != nil {
+			fmt.Fprintf(w, "error: %s\n", err)
+		} else {
+			fmt.Fprintln(w, "ok")
+		}
+	}
+}
+
+func (c *SessionCommand) execute(cmd, rest string, w io.Writer) error {
+	var script string
+	switch cmd {
+	case "print", "export":
+		script = cmd + " " + rest
+	case "eval":
+		script = rest
+	default:
+		return fmt.Errorf("%s: unknown command", cmd)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.sess.SetOutput(w); err != nil {
+		return err
+	}
+	if cmd == "eval" {
+		return evalStatements(c.sess, script)
+	}
+	_, err := c.sess.Exec(strings.NewReader(script))
+	return err
+}
+
+var replCmd = cli.Command{
+	Name:    "repl",
+	Summary: "Run statements of the script language interactively",
+	Usage:   "repl [-d <dir>] [-c <cache>] [<file>...]",
+	Help: `Repl reads statements from stdin and runs them in the same context, printing
+the value of each expression. The given files are imported before the first
+statement, the last one being the default file.
+
+A line ending with a backslash continues on the next line. The repl ends at the
+end of the input or with quit.`,
+	Handler: &ReplCommand{},
+}
+
+type ReplCommand struct {
+	ContextDir string
+	CacheDir   string
+}
+
+func (c ReplCommand) Run(args []string) error {
+	set := cli.NewFlagSet("repl")
+	set.StringVar(&c.ContextDir, "d", ".", "Context directory")
+	set.StringVar(&c.CacheDir, "c", "", "Cache directory for imported files")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	ev := env.Empty()
+	ev.Define("env", runtime.NewEnvValue())
+
+	engine := eval.NewEngine()
+	engine.SetPrintPlain(plainOutput)
+	engine.SetContextDir(c.ContextDir)
+	engine.SetCacheDir(c.CacheDir)
+
+	sess, err := engine.NewSession(ev)
+	if err != nil {
+		return err
+	}
+	for _, file := range set.Args() {
+		if err := sess.Import(file, ""); err != nil {
+			return err
+		}
+	}
+	var (
+		scan = bufio.NewScanner(os.Stdin)
+		stmt strings.Builder
+	)
+	fmt.Fprint(os.Stdout, "> ")
+	for scan.Scan() {
+		line := scan.Text()
+		if rest, ok := strings.CutSuffix(line, "\\"); ok {
+			stmt.WriteString(rest)
+			stmt.WriteString("\n")
+			fmt.Fprint(os.Stdout, "... ")
+			continue
+		}
+		stmt.WriteString(line)
+		script := strings.TrimSpace(stmt.String())
+		stmt.Reset()
+		if script == "quit" {
+			return nil
+		}
+		if script != "" {
+			if err := evalStatements(sess, script); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			}
+		}
+		fmt.Fprint(os.Stdout, "> ")
+	}
+	fmt.Fprintln(os.Stdout)
+	return scan.Err()
+}
+
+// evalStatements runs statements in the session and prints their values.
+func evalStatements(sess *eval.Session, script string) error {
+	list, err := sess.Eval(script)
+	for _, v := range list {
+		if err := sess.Print(v); err != nil {
+			return err
+		}
+	}
+	return err
+}
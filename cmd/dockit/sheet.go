@@ -2,33 +2,53 @@ package main
 
 import (
 	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/midbel/cli"
+	"github.com/midbel/dockit/csv"
 	"github.com/midbel/dockit/flat"
 	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/grid/render"
 	"github.com/midbel/dockit/internal/slx"
 	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/oxml"
+	"github.com/midbel/dockit/value"
 	"github.com/midbel/dockit/workbook"
 )
 
 var lockCmd = cli.Command{
 	Name:    "lock",
 	Summary: "Lock one or more sheets from a spreadsheet",
-	Usage:   "lock <file> [<sheet>]",
+	Help: `Arguments:
+  file     path to input file.
+  sheet    name of the sheet(s) to lock. All sheets are locked when omitted.
+
+Options:
+  -p <password>    protect the sheet(s) with the given password`,
+	Usage:   "lock [-p <password>] <file> [<sheet>]",
 	Handler: &LockCommand{},
 }
 
-type LockCommand struct{}
+type LockCommand struct {
+	Password string
+}
 
 func (c LockCommand) Run(args []string) error {
 	set := cli.NewFlagSet("lock")
+	set.StringVar(&c.Password, "p", "", "password")
 	if err := set.Parse(args); err != nil {
 		return err
 	}
 	return updateFile(set.Arg(0), func(wb grid.File) error {
 		var err error
 		if set.NArg() <= 1 {
-			c.lockFile(wb)
+			err = c.lockFile(wb)
 		} else {
 			args := set.Args()
 			err = c.lockSheets(wb, args[1:])
@@ -37,15 +57,37 @@ func (c LockCommand) Run(args []string) error {
 	})
 }
 
-func (c LockCommand) lockFile(wb grid.File) {
+func (c LockCommand) lockFile(wb grid.File) error {
+	if c.Password != "" {
+		k, ok := wb.(interface{ LockWithPassword(string) error })
+		if !ok {
+			return fmt.Errorf("password protection %w", grid.ErrSupported)
+		}
+		return k.LockWithPassword(c.Password)
+	}
 	k, ok := wb.(interface{ Lock() })
 	if !ok {
-		return
+		return nil
 	}
 	k.Lock()
+	return nil
 }
 
 func (c LockCommand) lockSheets(wb grid.File, sheets []string) error {
+	if c.Password != "" {
+		k, ok := wb.(interface {
+			LockSheetWithPassword(string, string) error
+		})
+		if !ok {
+			return fmt.Errorf("password protection %w", grid.ErrSupported)
+		}
+		for _, sh := range sheets {
+			if err := k.LockSheetWithPassword(sh, c.Password); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	k, ok := wb.(interface{ LockSheet(string) error })
 	if !ok {
 		return nil
@@ -62,21 +104,30 @@ func (c LockCommand) lockSheets(wb grid.File, sheets []string) error {
 var unlockCmd = cli.Command{
 	Name:    "unlock",
 	Summary: "Unlock one or more sheets from a spreadsheet",
-	Usage:   "unlock <file> [<sheet>]",
+	Help: `Arguments:
+  file     path to input file.
+  sheet    name of the sheet(s) to unlock. All sheets are unlocked when omitted.
+
+Options:
+  -p <password>    password protecting the sheet(s)`,
+	Usage:   "unlock [-p <password>] <file> [<sheet>]",
 	Handler: &UnlockCommand{},
 }
 
-type UnlockCommand struct{}
+type UnlockCommand struct {
+	Password string
+}
 
 func (c UnlockCommand) Run(args []string) error {
 	set := cli.NewFlagSet("unlock")
+	set.StringVar(&c.Password, "p", "", "password")
 	if err := set.Parse(args); err != nil {
 		return err
 	}
 	return updateFile(set.Arg(0), func(wb grid.File) error {
 		var err error
 		if set.NArg() <= 1 {
-			c.unlockFile(wb)
+			err = c.unlockFile(wb)
 		} else {
 			args := set.Args()
 			err = c.unlockSheets(wb, args[1:])
@@ -85,15 +136,29 @@ func (c UnlockCommand) Run(args []string) error {
 	})
 }
 
-func (c UnlockCommand) unlockFile(wb grid.File) {
+func (c UnlockCommand) unlockFile(wb grid.File) error {
+	if k, ok := wb.(interface{ UnlockWithPassword(string) error }); ok {
+		return k.UnlockWithPassword(c.Password)
+	}
 	k, ok := wb.(interface{ Unlock() })
 	if !ok {
-		return
+		return nil
 	}
 	k.Unlock()
+	return nil
 }
 
 func (c UnlockCommand) unlockSheets(wb grid.File, sheets []string) error {
+	if k, ok := wb.(interface {
+		UnlockSheetWithPassword(string, string) error
+	}); ok {
+		for _, sh := range sheets {
+			if err := k.UnlockSheetWithPassword(sh, c.Password); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	k, ok := wb.(interface{ UnlockSheet(string) error })
 	if !ok {
 		return nil
@@ -175,22 +240,45 @@ var copyCmd = cli.Command{
 	Name:    "copy",
 	Alias:   slx.Make("cp"),
 	Summary: "Duplicate a sheet within its original file",
-	Usage:   "copy <file> <sheet>",
+	Help: `Arguments:
+  file     path to input file.
+  sheet    name of the sheet to copy
+  target   name of the new sheet
+
+Options:
+  -m <mode>  what to copy: value, formula, style or all (default all)`,
+	Usage:   "copy [-m <mode>] <file> <sheet> <target>",
 	Handler: &CopyCommand{},
 }
 
-type CopyCommand struct{}
+type CopyCommand struct {
+	Mode string
+}
 
 func (c CopyCommand) Run(args []string) error {
 	set := cli.NewFlagSet("copy")
+	set.StringVar(&c.Mode, "m", "", "copy mode")
 	if err := set.Parse(args); err != nil {
 		return err
 	}
 	if set.NArg() != 3 {
 		return cli.ErrUsage
 	}
+	mode, err := grid.CopyModeFromString(c.Mode)
+	if err != nil {
+		return err
+	}
 	return updateFile(set.Arg(0), func(wb grid.File) error {
-		return wb.Copy(set.Arg(1), set.Arg(2))
+		if mode == grid.CopyAll {
+			return wb.Copy(set.Arg(1), set.Arg(2))
+		}
+		k, ok := wb.(interface {
+			CopySheet(string, string, grid.CopyMode) error
+		})
+		if !ok {
+			return fmt.Errorf("copy mode %w", grid.ErrSupported)
+		}
+		return k.CopySheet(set.Arg(1), set.Arg(2), mode)
 	})
 }
 
@@ -216,10 +304,201 @@ func (c RenameCommand) Run(args []string) error {
 	})
 }
 
+var reorderCmd = cli.Command{
+	Name:    "reorder",
+	Alias:   slx.Make("move", "mv"),
+	Summary: "Change the position of one or more sheets within a file",
+	Help: `Arguments:
+  file     path to input file.
+  sheet    name of the sheets to move, in the order they should appear
+
+Options:
+  -i <index>  position of the first sheet, starting at 0 (default 0). Negative
+              values count from the end, -1 being the last position`,
+	Usage:   "reorder [-i <index>] <file> <sheet> [<sheet>...]",
+	Handler: &ReorderCommand{},
+}
+
+type ReorderCommand struct {
+	Index int
+}
+
+func (c ReorderCommand) Run(args []string) error {
+	set := cli.NewFlagSet("reorder")
+	set.IntVar(&c.Index, "i", 0, "position of the first sheet")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	if set.NArg() <= 1 {
+		return cli.ErrUsage
+	}
+	return updateFile(set.Arg(0), func(wb grid.File) error {
+		m, ok := wb.(interface{ MoveSheet(string, int) error })
+		if !ok {
+			return fmt.Errorf("sheet reordering %w", grid.ErrSupported)
+		}
+		for i, name := range set.Args()[1:] {
+			// sheets moved at the same negative index keep their order
+			index := c.Index
+			if index >= 0 {
+				index += i
+			}
+			if err := m.MoveSheet(name, index); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+var pageCmd = cli.Command{
+	Name:    "page",
+	Summary: "Configure how a sheet is printed",
+	Help: `Arguments:
+  file     path to input file.
+  sheet    name of the sheet to configure (default to active sheet)
+
+Options:
+  -p <paper>        paper size given by its name (a4, letter,...) or its code
+  -o <orientation>  orientation of the pages: portrait or landscape
+  -s <scale>        scale of the printed pages in percent
+  -m <margins>      margins in inches: one value for all sides or four values
+                    separated by commas (top, right, bottom, left)
+  -a <range>        range of cells to print. Use an empty range to print the
+                    whole sheet
+  --header <text>   header of the pages. Use &L, &C and &R to start the left,
+                    center and right sections
+  --footer <text>   footer of the pages, using the same codes as the header`,
+	Usage:   "page [-p <paper>] [-o <orientation>] [-s <scale>] [-m <margins>] [-a <range>] [--header <text>] [--footer <text>] <file> [<sheet>]",
+	Handler: &PageCommand{},
+}
+
+type PageCommand struct {
+	Paper       string
+	Orientation string
+	Scale       int
+	Margins     string
+	Area        string
+	Header      string
+	Footer      string
+}
+
+func (c PageCommand) Run(args []string) error {
+	set := cli.NewFlagSet("page")
+	set.StringVar(&c.Paper, "p", "", "paper size")
+	set.StringVar(&c.Orientation, "o", "", "orientation")
+	set.IntVar(&c.Scale, "s", 0, "scale")
+	set.StringVar(&c.Margins, "m", "", "margins")
+	set.StringVar(&c.Area, "a", "", "print area")
+	set.StringVar(&c.Header, "header", "", "header")
+	set.StringVar(&c.Footer, "footer", "", "footer")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	if set.NArg() < 1 || set.NArg() > 2 {
+		return cli.ErrUsage
+	}
+	var area bool
+	set.Visit(func(f *flag.Flag) {
+		area = area || f.Name == "a"
+	})
+	return updateFile(set.Arg(0), func(wb grid.File) error {
+		var (
+			view grid.View
+			err  error
+		)
+		if name := set.Arg(1); name == "" {
+			view, err = wb.ActiveSheet()
+		} else {
+			view, err = wb.Sheet(name)
+		}
+		if err != nil {
+			return err
+		}
+		sh, ok := view.(*oxml.Sheet)
+		if !ok {
+			return fmt.Errorf("page setup %w", grid.ErrSupported)
+		}
+		if err := c.configure(sh); err != nil {
+			return err
+		}
+		if !area {
+			return nil
+		}
+		if c.Area == "" {
+			return sh.SetPrintArea(nil)
+		}
+		return sh.SetPrintArea(layout.RangeFromString(c.Area))
+	})
+}
+
+func (c PageCommand) configure(sh *oxml.Sheet) error {
+	if c.Paper != "" {
+		size, err := oxml.ParsePaperSize(c.Paper)
+		if err != nil {
+			return err
+		}
+		sh.SetPaperSize(size)
+	}
+	if c.Orientation != "" {
+		orient, err := oxml.ParseOrientation(c.Orientation)
+		if err != nil {
+			return err
+		}
+		sh.SetOrientation(orient)
+	}
+	if c.Scale != 0 {
+		if err := sh.SetPrintScale(c.Scale); err != nil {
+			return err
+		}
+	}
+	if c.Header != "" {
+		sh.SetHeader(oxml.ParseHeaderText(c.Header))
+	}
+	if c.Footer != "" {
+		sh.SetFooter(oxml.ParseHeaderText(c.Footer))
+	}
+	if c.Margins == "" {
+		return nil
+	}
+	margins := oxml.DefaultMargins()
+	if m := sh.Page.Margins; m != nil {
+		margins = *m
+	}
+	var list []float64
+	for _, str := range strings.Split(c.Margins, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+		if err != nil {
+			return fmt.Errorf("%s: invalid margin", str)
+		}
+		list = append(list, v)
+	}
+	switch len(list) {
+	case 1:
+		margins.Top, margins.Right, margins.Bottom, margins.Left = list[0], list[0], list[0], list[0]
+	case 4:
+		margins.Top, margins.Right, margins.Bottom, margins.Left = list[0], list[1], list[2], list[3]
+	default:
+		return fmt.Errorf("%s: one or four margins expected", c.Margins)
+	}
+	return sh.SetMargins(margins)
+}
+
 var printCmd = cli.Command{
 	Name:    "print",
 	Summary: "Print content of a sheet on stdout",
-	Usage:   "print [-c <columns>] [-n <count>] <file> [<sheet>]",
+	Help: `Arguments:
+  file     path to input file.
+  sheet    name of the sheet to print (default to active sheet)
+
+Options:
+  -c <columns>     print only the selected columns
+  -n <count>       print only the first (or last if negative) rows
+  -q               quote all the fields
+  --ignore-errors  skip rows having error values
+  --stream         decode rows of xlsx files while printing them as CSV
+                   instead of loading the whole workbook first`,
+	Usage:   "print [-c <columns>] [-n <count>] [--stream] <file> [<sheet>]",
 	Handler: &PrintCommand{},
 }
 
@@ -230,6 +509,7 @@ type PrintCommand struct {
 	Count   int
 	Quoted  bool
 	SkipErr bool
+	Stream  bool
 }
 
 func (c PrintCommand) Run(args []string) error {
@@ -239,6 +519,7 @@ func (c PrintCommand) Run(args []string) error {
 	set.IntVar(&c.Count, "n", 0, "number of rows")
 	set.BoolVar(&c.Quoted, "q", false, "quoted")
 	set.BoolVar(&c.SkipErr, "ignore-errors", false, "skip rows having error values")
+	set.BoolVar(&c.Stream, "stream", false, "decode rows while printing them")
 	set.Func("c", "selected columns", func(str string) error {
 		sel, err := layout.SelectionFromString(str)
 		if err == nil {
@@ -249,6 +530,9 @@ func (c PrintCommand) Run(args []string) error {
 	if err := set.Parse(args); err != nil {
 		return err
 	}
+	if ext := strings.ToLower(filepath.Ext(set.Arg(0))); c.Stream && c.Count >= 0 && (ext == ".xlsx" || ext == ".xlsm") {
+		return c.streamSheet(set.Arg(0), set.Arg(1))
+	}
 	sheet, err := c.openSheet(set.Arg(0), set.Arg(1))
 	if err != nil {
 		return err
@@ -263,13 +547,66 @@ func (c PrintCommand) Run(args []string) error {
 		r.Quoted = c.Quoted
 		rd = r
 	} else {
-		rd = cli.NewTableRenderer(cli.Stdout)
+		rd = createRenderer(cli.Stdout)
 
 	}
 	rd.Render(sheet2Table(sheet, c.SkipErr))
 	return nil
 }
 
+func (c PrintCommand) streamSheet(file, name string) error {
+	s, err := oxml.OpenStream(file)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	if name == "" {
+		name = s.ActiveSheet()
+	}
+	var (
+		ws    = csv.NewWriter(cli.Stdout)
+		ft    = createFormatter()
+		count int
+	)
+	ws.ForceQuote = c.Quoted
+	defer ws.Flush()
+	for r := range s.Rows(name) {
+		if c.Count > 0 && count >= c.Count {
+			break
+		}
+		if c.Columns != nil && len(r) > 0 {
+			rg := layout.NewRange(layout.NewPosition(1, 1), layout.NewPosition(1, int64(len(r))))
+			var list []value.ScalarValue
+			for _, ix := range c.Columns.Indices(rg) {
+				if ix >= 0 && ix < int64(len(r)) {
+					list = append(list, r[ix])
+				}
+			}
+			r = list
+		}
+		var (
+			row     = make([]string, 0, len(r))
+			discard bool
+		)
+		for _, v := range r {
+			if value.IsError(v) && c.SkipErr {
+				discard = true
+				break
+			}
+			str, _ := ft.Format(v)
+			row = append(row, str)
+		}
+		if discard {
+			continue
+		}
+		if err := ws.Write(row); err != nil {
+			return err
+		}
+		count++
+	}
+	return s.Err()
+}
+
 func (c PrintCommand) openSheet(file, name string) (grid.View, error) {
 	wb, err := c.openFile(file)
 	if err != nil {
@@ -314,3 +651,76 @@ func (c PrintCommand) openFile(file string) (grid.File, error) {
 	}
 	return workbook.OpenFormat(file, c.Format)
 }
+
+var renderCmd = cli.Command{
+	Name:    "render",
+	Summary: "Draw content of a sheet as a table in a PNG or SVG image",
+	Help: `Arguments:
+  file     path to input file.
+  sheet    name of the sheet to render (default to active sheet)
+
+Options:
+  -r <range>    render only the given range of the sheet
+  -o <file>     write image to given file (.png or .svg)
+  -s <scale>    scale of the font used in PNG images
+  --header      emphasize the first row`,
+	Usage:   "render [-r <range>] [-s <scale>] [--header] -o <image> <file> [<sheet>]",
+	Handler: &RenderCommand{},
+}
+
+type RenderCommand struct {
+	Range  string
+	Output string
+	Scale  int
+	Header bool
+}
+
+func (c RenderCommand) Run(args []string) error {
+	set := cli.NewFlagSet("render")
+	set.StringVar(&c.Range, "r", "", "range to render")
+	set.StringVar(&c.Output, "o", "", "image file")
+	set.IntVar(&c.Scale, "s", 2, "scale of the font")
+	set.BoolVar(&c.Header, "header", false, "emphasize first row")
+
+	var rest []string
+	for {
+		if err := set.Parse(args); err != nil {
+			return err
+		}
+		if set.NArg() == 0 {
+			break
+		}
+		rest = append(rest, set.Arg(0))
+		args = set.Args()[1:]
+	}
+	if len(rest) == 0 || len(rest) > 2 || c.Output == "" {
+		return cli.ErrUsage
+	}
+	var draw func(io.Writer, grid.View, render.Options) error
+	switch ext := strings.ToLower(filepath.Ext(c.Output)); ext {
+	case ".png":
+		draw = render.PNG
+	case ".svg":
+		draw = render.SVG
+	default:
+		return fmt.Errorf("%s: unsupported image format", ext)
+	}
+	rest = append(rest, "")
+	return withSheet(rest[0], rest[1], func(sheet grid.View) error {
+		if c.Range != "" {
+			sheet = grid.NewBoundedView(sheet, layout.RangeFromString(c.Range))
+		}
+		w, err := os.Create(c.Output)
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+
+		opts := render.Options{
+			Header:    c.Header,
+			Formatter: createFormatter(),
+			Scale:     c.Scale,
+		}
+		return draw(w, sheet, opts)
+	})
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"compress/flate"
 	"errors"
 	"fmt"
 	"os"
@@ -10,9 +11,13 @@ import (
 	"strings"
 
 	"github.com/midbel/cli"
+	"github.com/midbel/dockit/driver"
 	"github.com/midbel/dockit/flat"
 	"github.com/midbel/dockit/grid"
 	"github.com/midbel/dockit/grid/builtins"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/oxml"
+	"github.com/midbel/dockit/value"
 	"github.com/midbel/dockit/workbook"
 	"github.com/midbel/textwrap"
 )
@@ -26,12 +31,19 @@ var mergeCmd = cli.Command{
 Options:
   -f <file>    write merge result to given file
   -r           remove input file(s)
-  -c           resync values before merge`,
-	Usage:   "merge [-f <file>] [-r] [-c] <file...>",
+  -c           resync values before merge
+  -m <cells>   maximum number of cells kept in memory before spilling to disk
+  -t <dir>     directory where spilled sheets are stored
+  -z <level>   compression of the result: store, fast, default, best or 0-9`,
+	Usage:   "merge [-f <file>] [-r] [-c] [-m <cells>] [-t <dir>] [-z <level>] <file...>",
 	Handler: &MergeCommand{},
 }
 
-type MergeCommand struct{}
+type MergeCommand struct {
+	MaxCells    int64
+	SpillDir    string
+	Compression string
+}
 
 func (c MergeCommand) Run(args []string) error {
 	var (
@@ -40,6 +52,9 @@ func (c MergeCommand) Run(args []string) error {
 		remove = set.Bool("r", false, "remove files merged")
 		reload = set.Bool("c", false, "recompute all values in final file")
 	)
+	set.Int64Var(&c.MaxCells, "m", 0, "maximum number of cells kept in memory")
+	set.StringVar(&c.SpillDir, "t", "", "directory where spilled sheets are stored")
+	set.StringVar(&c.Compression, "z", "", "compression level")
 	if err := set.Parse(args); err != nil {
 		return err
 	}
@@ -63,19 +78,177 @@ func (c MergeCommand) Run(args []string) error {
 }
 
 func (c MergeCommand) mergeFiles(file string, sources []string) (grid.File, error) {
-	return workbook.Merge(filepath.Ext(file), sources)
+	if c.MaxCells <= 0 {
+		return workbook.Merge(filepath.Ext(file), sources)
+	}
+	budget := grid.NewBudget(c.MaxCells, c.SpillDir)
+	return workbook.MergeWithBudget(filepath.Ext(file), sources, budget)
 }
 
 func (c MergeCommand) writeFile(wb grid.File, file string) error {
+	if c.Compression != "" {
+		level, err := parseCompression(c.Compression)
+		if err != nil {
+			return err
+		}
+		if z, ok := wb.(driver.Compressor); ok {
+			if err := z.SetCompression(level); err != nil {
+				return err
+			}
+		}
+	}
 	return workbook.WriteFile(wb, file)
 }
 
+func parseCompression(str string) (int, error) {
+	switch strings.ToLower(str) {
+	case "store":
+		return flate.NoCompression, nil
+	case "fast":
+		return flate.BestSpeed, nil
+	case "default":
+		return flate.DefaultCompression, nil
+	case "best":
+		return flate.BestCompression, nil
+	default:
+		level, err := strconv.Atoi(str)
+		if err != nil {
+			return 0, fmt.Errorf("%s: invalid compression level", str)
+		}
+		return level, nil
+	}
+}
+
 func (c MergeCommand) removeFiles(files []string) {
 	for _, f := range files {
 		os.Remove(f)
 	}
 }
 
+var extractCmd = cli.Command{
+	Name:    "extract",
+	Summary: "Export sheets of a spreadsheet file into separate files",
+	Help: `Arguments:
+  file    path to input file.
+
+Options:
+  -d <dir>          directory where files are written
+  -f <format>       format of the files written (default csv). xlsx files
+                    are written row by row
+  --match <glob>    only extract sheets whose name matches the glob
+  --skip-empty      skip sheets without data
+  --min-rows <n>    skip sheets with less than n rows of data`,
+	Usage:   "extract [-d <dir>] [-f <format>] [--match <glob>] [--skip-empty] [--min-rows <n>] <file>",
+	Handler: &ExtractCommand{},
+}
+
+type ExtractCommand struct {
+	Dir       string
+	Format    string
+	Match     string
+	SkipEmpty bool
+	MinRows   int
+}
+
+func (c ExtractCommand) Run(args []string) error {
+	set := cli.NewFlagSet("extract")
+	set.StringVar(&c.Dir, "d", ".", "directory where files are written")
+	set.StringVar(&c.Format, "f", "csv", "format of the files written")
+	set.StringVar(&c.Match, "match", "", "only extract sheets whose name matches the glob")
+	set.BoolVar(&c.SkipEmpty, "skip-empty", false, "skip sheets without data")
+	set.IntVar(&c.MinRows, "min-rows", 0, "skip sheets with less than n rows of data")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	if set.NArg() != 1 {
+		return cli.ErrUsage
+	}
+	if c.Match != "" {
+		if _, err := filepath.Match(c.Match, ""); err != nil {
+			return fmt.Errorf("%s: invalid glob", c.Match)
+		}
+	}
+	wb, err := workbook.Open(set.Arg(0))
+	if err != nil {
+		return err
+	}
+	ext := "." + strings.TrimPrefix(c.Format, ".")
+	for _, sh := range wb.Sheets() {
+		if !c.accept(sh) {
+			continue
+		}
+		file := filepath.Join(c.Dir, sh.Name()+ext)
+		if ext == ".xlsx" {
+			err = streamView(sh, file)
+		} else {
+			err = workbook.WriteView(sh, file)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamView writes the rows of the view to the file as they are read instead
+// of copying the whole view in a new workbook first.
+func streamView(sh grid.View, file string) error {
+	ws, err := oxml.CreateStream(file)
+	if err != nil {
+		return err
+	}
+	if err := ws.AddSheet(sh.Name()); err != nil {
+		ws.Close()
+		return err
+	}
+	bd := sh.Bounds()
+	for lino := int64(1); lino <= bd.Ends.Line; lino++ {
+		var list []value.ScalarValue
+		if lino >= bd.Starts.Line {
+			list = make([]value.ScalarValue, bd.Ends.Column)
+			for col := bd.Starts.Column; col <= bd.Ends.Column; col++ {
+				c, err := sh.Cell(layout.NewPosition(lino, col))
+				if err != nil {
+					continue
+				}
+				list[col-1], _ = c.Value().(value.ScalarValue)
+			}
+		}
+		if err := ws.WriteRow(list); err != nil {
+			ws.Close()
+			return err
+		}
+	}
+	return ws.Close()
+}
+
+func (c ExtractCommand) accept(sh grid.View) bool {
+	if c.Match != "" {
+		if ok, _ := filepath.Match(c.Match, sh.Name()); !ok {
+			return false
+		}
+	}
+	limit := c.MinRows
+	if c.SkipEmpty {
+		limit = max(limit, 1)
+	}
+	if limit <= 0 {
+		return true
+	}
+	var count int
+	for _, row := range sh.Rows() {
+		if slices.ContainsFunc(row, func(v value.Value) bool {
+			return !value.IsBlank(v)
+		}) {
+			count++
+		}
+		if count >= limit {
+			return true
+		}
+	}
+	return false
+}
+
 var formatCmd = cli.Command{
 	Name:    "format",
 	Summary: "List supported spreadsheet formats",
@@ -127,7 +300,7 @@ func (c GetInfoCommand) Run(args []string) error {
 	}
 	var (
 		tbl cli.Table
-		rd  = cli.NewTableRenderer(os.Stdout)
+		rd  = createRenderer(os.Stdout)
 	)
 	tbl.Headers = []string{"sheet", "active", "locked", "visible", "rows", "columns"}
 	for _, i := range file.Infos() {
@@ -141,6 +314,61 @@ func (c GetInfoCommand) Run(args []string) error {
 		}
 		tbl.Rows = append(tbl.Rows, r)
 	}
+	if err := rd.Render(tbl); err != nil {
+		return err
+	}
+	if x, ok := file.(*oxml.File); ok {
+		if err := c.printStructure(rd, x); err != nil {
+			return err
+		}
+		return c.printPivots(rd, x.PivotTables())
+	}
+	return nil
+}
+
+func (c GetInfoCommand) printStructure(rd tableRenderer, file *oxml.File) error {
+	var tbl cli.Table
+	tbl.Headers = []string{"structure", "password"}
+	tbl.Rows = [][]string{
+		{
+			cli.MarkBool(file.IsLock()),
+			cli.MarkBool(file.HasPassword()),
+		},
+	}
+	return rd.Render(tbl)
+}
+
+func (c GetInfoCommand) printPivots(rd tableRenderer, list []*oxml.PivotTable) error {
+	if len(list) == 0 {
+		return nil
+	}
+	var tbl cli.Table
+	tbl.Headers = []string{"pivot", "location", "source", "rows", "columns", "values"}
+	for _, p := range list {
+		var (
+			source = p.SourceName
+			values []string
+		)
+		if p.Source != nil {
+			source = p.Source.String()
+		}
+		for _, d := range p.Data {
+			values = append(values, fmt.Sprintf("%s(%s)", d.Function, d.Field))
+		}
+		var location string
+		if p.Location != nil {
+			location = p.Location.String()
+		}
+		r := []string{
+			p.Name,
+			location,
+			source,
+			strings.Join(p.Rows, ", "),
+			strings.Join(p.Columns, ", "),
+			strings.Join(values, ", "),
+		}
+		tbl.Rows = append(tbl.Rows, r)
+	}
 	return rd.Render(tbl)
 }
 
@@ -151,6 +379,49 @@ func (c GetInfoCommand) openFile(file string) (grid.File, error) {
 	return workbook.OpenFormat(file, c.Format)
 }
 
+var doctorCmd = cli.Command{
+	Name:    "doctor",
+	Summary: "List the problems found in the parts of a spreadsheet file",
+	Help: `Arguments:
+  file    path to input file.
+
+The parts of xlsx files that can not be read are listed with the offset at
+which the problem was found. The reading goes on with the next part when the
+rest of the workbook does not depend on it.`,
+	Usage:   "doctor [-h|--help] <file>",
+	Handler: &DoctorCommand{},
+}
+
+type DoctorCommand struct{}
+
+func (c DoctorCommand) Run(args []string) error {
+	set := cli.NewFlagSet("doctor")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	var (
+		file = set.Arg(0)
+		list []error
+		err  error
+	)
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".xlsx", ".xlsm":
+		list, err = oxml.Diagnose(file)
+	default:
+		_, err = workbook.Open(file)
+	}
+	if err != nil {
+		list = append(list, err)
+	}
+	for _, e := range list {
+		fmt.Fprintln(os.Stdout, e)
+	}
+	if len(list) > 0 {
+		return errFail
+	}
+	return nil
+}
+
 var builtinsCmd = cli.Command{
 	Name:    "builtins",
 	Summary: "Display list of supported builtins",
@@ -211,6 +482,9 @@ func (c GetBuiltinCommand) printList() error {
 		}
 		tbl.Rows = append(tbl.Rows, r)
 	}
+	if plainOutput {
+		return NewPlainRenderer(cli.Stdout).Render(tbl)
+	}
 	rd := cli.NewTableRenderer(cli.Stdout)
 	rd.WithLineNumbers = true
 	rd.Render(tbl)
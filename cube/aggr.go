@@ -0,0 +1,101 @@
+package cube
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/midbel/dockit/value"
+)
+
+// Lookup gives the aggregate function with the given name.
+func Lookup(name string) (AggrFunc, error) {
+	switch strings.ToLower(name) {
+	case "sum":
+		return Sum, nil
+	case "count":
+		return Count, nil
+	case "avg", "average":
+		return Average, nil
+	case "min":
+		return Min, nil
+	case "max":
+		return Max, nil
+	default:
+		return nil, fmt.Errorf("%s: aggregate function not found", name)
+	}
+}
+
+// Sum gives the sum of the values that can be used as numbers.
+func Sum(values []value.ScalarValue) (value.ScalarValue, error) {
+	var total float64
+	for _, f := range numbers(values) {
+		total += f
+	}
+	return value.Float(total), nil
+}
+
+// Count gives the number of values that are not blank.
+func Count(values []value.ScalarValue) (value.ScalarValue, error) {
+	var count int
+	for _, v := range values {
+		if _, ok := v.(value.Blank); ok || v == nil {
+			continue
+		}
+		count++
+	}
+	return value.Float(float64(count)), nil
+}
+
+// Average gives the mean of the values that can be used as numbers.
+func Average(values []value.ScalarValue) (value.ScalarValue, error) {
+	list := numbers(values)
+	if len(list) == 0 {
+		return value.ErrDiv0, nil
+	}
+	var total float64
+	for _, f := range list {
+		total += f
+	}
+	return value.Float(total / float64(len(list))), nil
+}
+
+// Min gives the smallest of the values that can be used as numbers.
+func Min(values []value.ScalarValue) (value.ScalarValue, error) {
+	list := numbers(values)
+	if len(list) == 0 {
+		return value.Float(0), nil
+	}
+	res := list[0]
+	for _, f := range list[1:] {
+		res = min(res, f)
+	}
+	return value.Float(res), nil
+}
+
+// Max gives the largest of the values that can be used as numbers.
+func Max(values []value.ScalarValue) (value.ScalarValue, error) {
+	list := numbers(values)
+	if len(list) == 0 {
+		return value.Float(0), nil
+	}
+	res := list[0]
+	for _, f := range list[1:] {
+		res = max(res, f)
+	}
+	return value.Float(res), nil
+}
+
+func numbers(values []value.ScalarValue) []float64 {
+	var list []float64
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		f, err := value.CastToFloat(v)
+		if err != nil {
+			continue
+		}
+		list = append(list, float64(f))
+	}
+	return list
+}
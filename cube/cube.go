@@ -1,8 +1,11 @@
 package cube
 
 import (
+	"cmp"
 	"fmt"
 	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/midbel/dockit/value"
 )
@@ -29,9 +32,9 @@ func (c *Cube) AggregateAll(measure string, aggr AggrFunc, keep FilterFunc) (val
 	if !ok {
 		return nil, fmt.Errorf("%s: measurement not found", measure)
 	}
-	values := c.measures[i].Data
+	values := c.measures[ix].Data
 	if keep == nil {
-		return agg(values)
+		return aggr(values)
 	}
 	var list []value.ScalarValue
 	for _, v := range values {
@@ -43,6 +46,90 @@ func (c *Cube) AggregateAll(measure string, aggr AggrFunc, keep FilterFunc) (val
 	return aggr(list)
 }
 
+// Aggregate gives the function used to aggregate the values of a measure.
+type Aggregate struct {
+	Measure string
+	Func    AggrFunc
+}
+
+// Group is a combination of values of dimensions with the aggregated values
+// of the measures of the rows having these values. Groups of subtotals given
+// by Rollup have less keys than dimensions.
+type Group struct {
+	Keys   []string
+	Values []value.ScalarValue
+
+	ids []int
+}
+
+// GroupBy aggregates the rows of the cube having the same values for the
+// given dimensions. Groups are ordered by the values of the dimensions in the
+// order they have been registered.
+func (c *Cube) GroupBy(dims []string, aggrs []Aggregate) ([]Group, error) {
+	ds, err := c.lookupDimensions(dims)
+	if err != nil {
+		return nil, err
+	}
+	ms, err := c.lookupMeasures(aggrs)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		groups []Group
+		rows   [][]int
+		index  = make(map[string]int)
+	)
+	for r := range c.rows {
+		ids := make([]int, len(ds))
+		for i, d := range ds {
+			ids[i] = d.Column[r]
+		}
+		key := groupKey(ids)
+		ix, ok := index[key]
+		if !ok {
+			ix = len(groups)
+			index[key] = ix
+			groups = append(groups, Group{ids: ids})
+			rows = append(rows, nil)
+		}
+		rows[ix] = append(rows[ix], r)
+	}
+	for i := range groups {
+		for j, d := range ds {
+			groups[i].Keys = append(groups[i].Keys, d.Dict[groups[i].ids[j]])
+		}
+		for j, m := range ms {
+			values := make([]value.ScalarValue, 0, len(rows[i]))
+			for _, r := range rows[i] {
+				values = append(values, m.Data[r])
+			}
+			res, err := aggrs[j].Func(values)
+			if err != nil {
+				return nil, err
+			}
+			groups[i].Values = append(groups[i].Values, res)
+		}
+	}
+	slices.SortStableFunc(groups, compareGroups)
+	return groups, nil
+}
+
+// Rollup aggregates the rows of the cube like GroupBy with the subtotals of
+// each level of the dimensions and the grand total. Subtotals are given after
+// the groups they summarize.
+func (c *Cube) Rollup(dims []string, aggrs []Aggregate) ([]Group, error) {
+	var all []Group
+	for i := len(dims); i >= 0; i-- {
+		groups, err := c.GroupBy(dims[:i], aggrs)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, groups...)
+	}
+	slices.SortStableFunc(all, compareGroups)
+	return all, nil
+}
+
 func (c *Cube) AddRow(dims []string, measures []value.ScalarValue) error {
 	if len(c.dimensions) == 0 {
 		return fmt.Errorf("cube has no dimension")
@@ -54,7 +141,7 @@ func (c *Cube) AddRow(dims []string, measures []value.ScalarValue) error {
 		return fmt.Errorf("invalid number of measurements given")
 	}
 	for i := range c.dimensions {
-		c.dimensions[i].RegisterValue(dims[i])
+		c.dimensions[i].Append(dims[i])
 	}
 	for i := range c.measures {
 		c.measures[i].Append(measures[i])
@@ -74,7 +161,7 @@ func (c *Cube) RegisterMeasure(name string) error {
 }
 
 func (c *Cube) RegisterDimension(name string, values []string) error {
-	ix := slices.IndexFunc(c.dimensions, func(d Dimension) bool {
+	ix := slices.IndexFunc(c.dimensions, func(d *Dimension) bool {
 		return d.Name == name
 	})
 	if ix >= 0 {
@@ -85,6 +172,52 @@ func (c *Cube) RegisterDimension(name string, values []string) error {
 	return nil
 }
 
+func (c *Cube) lookupDimensions(names []string) ([]*Dimension, error) {
+	var list []*Dimension
+	for _, n := range names {
+		ix := slices.IndexFunc(c.dimensions, func(d *Dimension) bool {
+			return d.Name == n
+		})
+		if ix < 0 {
+			return nil, fmt.Errorf("%s: dimension not found", n)
+		}
+		list = append(list, c.dimensions[ix])
+	}
+	return list, nil
+}
+
+func (c *Cube) lookupMeasures(aggrs []Aggregate) ([]*Measure, error) {
+	var list []*Measure
+	for _, a := range aggrs {
+		ix, ok := c.measIndex[a.Measure]
+		if !ok {
+			return nil, fmt.Errorf("%s: measurement not found", a.Measure)
+		}
+		list = append(list, c.measures[ix])
+	}
+	return list, nil
+}
+
+func groupKey(ids []int) string {
+	var str strings.Builder
+	for i := range ids {
+		if i > 0 {
+			str.WriteByte(',')
+		}
+		str.WriteString(strconv.Itoa(ids[i]))
+	}
+	return str.String()
+}
+
+func compareGroups(a, b Group) int {
+	for i := range min(len(a.ids), len(b.ids)) {
+		if c := cmp.Compare(a.ids[i], b.ids[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(b.ids), len(a.ids))
+}
+
 type Dimension struct {
 	Name string
 
@@ -105,13 +238,22 @@ func NewDimension(name string, values []string) *Dimension {
 	return &d
 }
 
-func (d *Dimension) RegisterValue(value string) {
-	_, ok := d.Index[v]
+// RegisterValue adds a value to the dictionary of the dimension and gives its
+// id.
+func (d *Dimension) RegisterValue(v string) int {
+	id, ok := d.Index[v]
 	if ok {
-		return
+		return id
 	}
-	d.Index[v] = len(d.Dict)
+	id = len(d.Dict)
+	d.Index[v] = id
 	d.Dict = append(d.Dict, v)
+	return id
+}
+
+// Append adds the value of the dimension for a new row.
+func (d *Dimension) Append(v string) {
+	d.Column = append(d.Column, d.RegisterValue(v))
 }
 
 type Measure struct {
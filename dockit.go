@@ -0,0 +1,123 @@
+// Package dockit describes the capabilities of the Dockit packages.
+//
+// Programs embedding Dockit can use Capabilities to check which formats,
+// builtins and features are available, and Require to make sure the API they
+// were written for is still supported before using the other packages.
+//
+// Entry points that are kept for compatibility report a warning the first
+// time they are used. The warnings are written on stderr by default and can be
+// redirected or disabled with SetDeprecationOutput.
+package dockit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/midbel/dockit/grid/builtins"
+	"github.com/midbel/dockit/internal/deprecate"
+	"github.com/midbel/dockit/workbook"
+)
+
+// APIVersion is the version of the public API. Its major number changes when
+// documented entry points are removed or changed in an incompatible way.
+const APIVersion = "1.0"
+
+var ErrIncompatible = errors.New("incompatible api version")
+
+const (
+	FeatureDeferredFormula = "deferred-formula"
+	FeatureFormulaExport   = "formula-export"
+	FeatureImportCache     = "import-cache"
+	FeatureParallel        = "parallel"
+	FeatureRichText        = "rich-text"
+	FeatureStdlib          = "stdlib"
+	FeatureStreamRead      = "stream-read"
+	FeatureStreamWrite     = "stream-write"
+)
+
+var features = []string{
+	FeatureDeferredFormula,
+	FeatureFormulaExport,
+	FeatureImportCache,
+	FeatureParallel,
+	FeatureRichText,
+	FeatureStdlib,
+	FeatureStreamRead,
+	FeatureStreamWrite,
+}
+
+type CapabilitySet struct {
+	Version  string
+	Formats  []string
+	Builtins []string
+	Features []string
+}
+
+// Capabilities gives what the current build of Dockit supports. Formats
+// depend on the loaders registered in the workbook package.
+func Capabilities() CapabilitySet {
+	set := CapabilitySet{
+		Version:  APIVersion,
+		Formats:  workbook.Formats(),
+		Features: slices.Clone(features),
+	}
+	for _, b := range builtins.List() {
+		set.Builtins = append(set.Builtins, b.Name)
+	}
+	slices.Sort(set.Builtins)
+	return set
+}
+
+func (c CapabilitySet) Has(feature string) bool {
+	return slices.Contains(c.Features, feature)
+}
+
+func (c CapabilitySet) Supports(format string) bool {
+	return slices.Contains(c.Formats, format)
+}
+
+func (c CapabilitySet) Builtin(name string) bool {
+	_, ok := slices.BinarySearch(c.Builtins, strings.ToLower(name))
+	return ok
+}
+
+// Require checks that a program written for the given version of the API can
+// use the current one: the major numbers should be equal and the minor number
+// should not be greater than the current one.
+func Require(version string) error {
+	wantMajor, wantMinor, err := parseVersion(version)
+	if err != nil {
+		return err
+	}
+	major, minor, _ := parseVersion(APIVersion)
+	if wantMajor != major || wantMinor > minor {
+		return fmt.Errorf("%w: %s requested, %s available", ErrIncompatible, version, APIVersion)
+	}
+	return nil
+}
+
+// SetDeprecationOutput changes where the deprecation warnings are written. A
+// nil writer disables them.
+func SetDeprecationOutput(w io.Writer) {
+	deprecate.SetOutput(w)
+}
+
+func parseVersion(str string) (int, int, error) {
+	major, minor, _ := strings.Cut(strings.TrimPrefix(str, "v"), ".")
+	x, err := strconv.Atoi(major)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: invalid version", str)
+	}
+	if minor == "" {
+		return x, 0, nil
+	}
+	y, err := strconv.Atoi(minor)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: invalid version", str)
+	}
+	return x, y, nil
+}
@@ -0,0 +1,121 @@
+package dockit
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/midbel/dockit/driver"
+	"github.com/midbel/dockit/flat"
+	"github.com/midbel/dockit/formula/env"
+	"github.com/midbel/dockit/formula/eval"
+	"github.com/midbel/dockit/formula/format"
+	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/formula/stdlib"
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/ods"
+	"github.com/midbel/dockit/oxml"
+	"github.com/midbel/dockit/value"
+	"github.com/midbel/dockit/workbook"
+)
+
+// the documented entry points: the test does not compile anymore when one of
+// them is removed or when its signature changes.
+var (
+	_ func(driver.Loader)                                                  = workbook.Register
+	_ func(string) (grid.File, error)                                      = workbook.Open
+	_ func(string, string) (grid.File, error)                              = workbook.OpenFormat
+	_ func(grid.File, string) error                                        = workbook.WriteFile
+	_ func(grid.View, string) error                                        = workbook.WriteView
+	_ func() []string                                                      = workbook.Formats
+	_ func(string) (parse.Expr, error)                                     = parse.ParseOxmlFormula
+	_ func(string) (parse.Expr, error)                                     = parse.ParseOdsFormula
+	_ func(parse.Expr) (string, error)                                     = format.FormatOxml
+	_ func(parse.Expr) (string, error)                                     = format.FormatOds
+	_ func() *eval.Engine                                                  = eval.NewEngine
+	_ func(*eval.Engine, io.Reader, *env.Environment) (value.Value, error) = (*eval.Engine).Exec
+	_ func(string) *stdlib.Library                                         = stdlib.New
+	_ func() *oxml.File                                                    = oxml.NewFile
+	_ func(string) (*oxml.File, error)                                     = oxml.Open
+	_ func(string) (*oxml.Stream, error)                                   = oxml.OpenStream
+	_ func(string) (*oxml.SheetStreamWriter, error)                        = oxml.CreateStream
+	_ func() *ods.File                                                     = ods.NewFile
+	_ func(string) (*ods.File, error)                                      = ods.Open
+	_ func(string) (*flat.File, error)                                     = flat.OpenCsv
+	_ func() driver.Loader                                                 = flat.NewCommaLoader
+	_ func() driver.Loader                                                 = oxml.NewLoader
+	_ func() driver.Loader                                                 = ods.NewLoader
+
+	_ grid.File = (*oxml.File)(nil)
+	_ grid.File = (*ods.File)(nil)
+	_ grid.File = (*flat.File)(nil)
+)
+
+func TestRequire(t *testing.T) {
+	tests := []struct {
+		Version string
+		Valid   bool
+	}{
+		{Version: APIVersion, Valid: true},
+		{Version: "v" + APIVersion, Valid: true},
+		{Version: "1", Valid: true},
+		{Version: "1.99", Valid: false},
+		{Version: "0.9", Valid: false},
+		{Version: "2.0", Valid: false},
+	}
+	for _, c := range tests {
+		err := Require(c.Version)
+		if c.Valid && err != nil {
+			t.Errorf("%s: unexpected error: %s", c.Version, err)
+		}
+		if !c.Valid && !errors.Is(err, ErrIncompatible) {
+			t.Errorf("%s: expected incompatible version, got %v", c.Version, err)
+		}
+	}
+	if err := Require("latest"); err == nil || errors.Is(err, ErrIncompatible) {
+		t.Errorf("invalid version should be rejected")
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	workbook.Register(oxml.NewLoader())
+
+	set := Capabilities()
+	if set.Version != APIVersion {
+		t.Errorf("version mismatched! want %s, got %s", APIVersion, set.Version)
+	}
+	for _, f := range features {
+		if !set.Has(f) {
+			t.Errorf("%s: feature not available", f)
+		}
+	}
+	if set.Has("unknown") {
+		t.Errorf("unknown feature should not be available")
+	}
+	if !set.Supports("openxml") {
+		t.Errorf("openxml should be supported")
+	}
+	for _, name := range []string{"sum", "IF", "average"} {
+		if !set.Builtin(name) {
+			t.Errorf("%s: builtin not available", name)
+		}
+	}
+}
+
+func TestDeprecation(t *testing.T) {
+	var buf bytes.Buffer
+	SetDeprecationOutput(&buf)
+	defer SetDeprecationOutput(nil)
+
+	for range 2 {
+		if _, err := parse.ParseFormula("1+1"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	got := buf.String()
+	if strings.Count(got, "parse.ParseFormula") != 1 {
+		t.Errorf("warning should be written once! got %q", got)
+	}
+}
@@ -2,6 +2,7 @@ package driver
 
 import (
 	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/layout"
 )
 
 type Loader interface {
@@ -16,3 +17,31 @@ type Loader interface {
 type Merger interface {
 	Merge(grid.File) error
 }
+
+type Spiller interface {
+	SetBudget(*grid.Budget)
+}
+
+// Compressor is implemented by the files written in a compressed archive. The
+// level is one of the levels of compress/flate.
+type Compressor interface {
+	SetCompression(int) error
+}
+
+// Iterative is implemented by the files whose formulas involved in circular
+// references can be evaluated iteratively.
+type Iterative interface {
+	SetIteration(grid.Iteration)
+}
+
+// Freezable is implemented by the files whose volatile formulas can keep the
+// value given by their first evaluation.
+type Freezable interface {
+	SetFreeze(bool)
+}
+
+// Namer is implemented by the files keeping names referring to ranges of their
+// cells.
+type Namer interface {
+	DefineName(string, *layout.Range) error
+}
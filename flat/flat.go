@@ -153,6 +153,15 @@ func (f *File) AppendSheet(view grid.View) error {
 	return nil
 }
 
+func (f *File) Merge(other grid.File) error {
+	for _, s := range other.Sheets() {
+		if err := f.AppendSheet(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (f *File) RemoveSheet(name string) error {
 	return nil
 }
@@ -12,16 +12,17 @@ import (
 
 var registry = map[string]gbs.Builtin{}
 
-func Lookup(ident string) (gbs.BuiltinFunc, error) {
-	fn, err := gbs.Lookup(ident)
+// Lookup gives the function of the builtin with the given name. Builtins
+// using their context are called with ctx.
+func Lookup(ident string, ctx value.Context) (gbs.BuiltinFunc, error) {
+	b, err := gbs.Get(ident)
 	if err == nil {
-		return fn, nil
+		return b.Bind(ctx), nil
 	}
-	b, err := Get(ident)
-	if err != nil {
+	if b, err = Get(ident); err != nil {
 		return nil, err
 	}
-	return b.Make(), nil
+	return b.Bind(ctx), nil
 }
 
 func Get(ident string) (gbs.Builtin, error) {
@@ -31,7 +32,9 @@ func Get(ident string) (gbs.Builtin, error) {
 	}
 	vs := List()
 	ix := slices.IndexFunc(vs, func(b gbs.Builtin) bool {
-		return slices.Contains(b.Alias, ident)
+		return slices.ContainsFunc(b.Alias, func(alias string) bool {
+			return strings.EqualFold(alias, ident)
+		})
 	})
 	if ix < 0 {
 		return gbs.Builtin{}, fmt.Errorf("%s undefined builtin", ident)
@@ -48,6 +51,8 @@ func init() {
 	registerBuiltins(sheetBuiltins)
 	registerBuiltins(relationBuiltins)
 	registerBuiltins(numberBuiltins)
+	registerBuiltins(textBuiltins)
+	registerBuiltins(valueBuiltins)
 }
 
 func registerBuiltins(list []gbs.Builtin) {
@@ -37,20 +37,6 @@ func Join(args []value.Value) value.Value {
 	})
 }
 
-var groupBuiltin = gbs.Builtin{
-	Name:     "group",
-	Desc:     "",
-	Category: "relation",
-	Params: []gbs.Param{
-		gbs.Object("sheet", "", value.TypeAny),
-	},
-	Func: Group,
-}
-
-func Group(args []value.Value) value.Value {
-	return value.ErrValue
-}
-
 var unionBuiltin = gbs.Builtin{
 	Name:     "union",
 	Desc:     "",
@@ -96,6 +82,102 @@ func Except(args []value.Value) value.Value {
 	return combineViews(args[0], args[1], gridx.Except)
 }
 
+var transposeBuiltin = gbs.Builtin{
+	Name:     "transpose",
+	Desc:     "",
+	Category: "relation",
+	Params: []gbs.Param{
+		gbs.Object("sheet", "", value.TypeAny),
+	},
+	Func: Transpose,
+}
+
+func Transpose(args []value.Value) value.Value {
+	view, ok := args[0].(*runtime.View)
+	if !ok {
+		return value.ErrValue
+	}
+	return view.TransposeView()
+}
+
+var headBuiltin = gbs.Builtin{
+	Name:     "head",
+	Desc:     "",
+	Category: "relation",
+	Params: []gbs.Param{
+		gbs.Object("sheet", "", value.TypeAny),
+		gbs.Scalar("count", "", value.TypeNumber),
+	},
+	Func: Head,
+}
+
+func Head(args []value.Value) value.Value {
+	return limitRows(args[0], args[1], false)
+}
+
+var tailBuiltin = gbs.Builtin{
+	Name:     "tail",
+	Desc:     "",
+	Category: "relation",
+	Params: []gbs.Param{
+		gbs.Object("sheet", "", value.TypeAny),
+		gbs.Scalar("count", "", value.TypeNumber),
+	},
+	Func: Tail,
+}
+
+func Tail(args []value.Value) value.Value {
+	return limitRows(args[0], args[1], true)
+}
+
+// limitRows gives the count first rows of a view or its count last rows when
+// last is true. The whole view is given when it has less rows than count.
+func limitRows(v value.Value, count value.Value, last bool) value.Value {
+	if err := value.HasErrors(count); err != nil {
+		return err
+	}
+	view, ok := v.(*runtime.View)
+	if !ok {
+		return value.ErrValue
+	}
+	n := int64(asFloat(count))
+	if n < 1 {
+		return value.ErrValue
+	}
+	var (
+		bd     = view.View().Bounds()
+		height = bd.Height()
+		width  = bd.Width()
+		start  = int64(1)
+	)
+	if n >= height {
+		return view
+	}
+	if last {
+		start = height - n + 1
+	}
+	rg := layout.NewRange(layout.NewPosition(start, 1), layout.NewPosition(start+n-1, width))
+	return view.BoundedView(rg)
+}
+
+var headerBuiltin = gbs.Builtin{
+	Name:     "header",
+	Desc:     "",
+	Category: "relation",
+	Params: []gbs.Param{
+		gbs.Object("sheet", "", value.TypeAny),
+	},
+	Func: Header,
+}
+
+func Header(args []value.Value) value.Value {
+	view, ok := args[0].(*runtime.View)
+	if !ok {
+		return value.ErrValue
+	}
+	return view.WithHeader()
+}
+
 type combineFunc func(grid.View, grid.View) (grid.View, error)
 
 func combineViews(v1 value.Value, v2 value.Value, fn combineFunc) value.Value {
@@ -116,8 +198,11 @@ func combineViews(v1 value.Value, v2 value.Value, fn combineFunc) value.Value {
 
 var relationBuiltins = []gbs.Builtin{
 	joinBuiltin,
-	groupBuiltin,
 	unionBuiltin,
 	intersectBuiltin,
 	exceptBuiltin,
+	transposeBuiltin,
+	headBuiltin,
+	tailBuiltin,
+	headerBuiltin,
 }
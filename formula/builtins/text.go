@@ -0,0 +1,98 @@
+package builtins
+
+import (
+	"regexp"
+
+	gbs "github.com/midbel/dockit/grid/builtins"
+	"github.com/midbel/dockit/value"
+)
+
+var regexMatchBuiltin = gbs.Builtin{
+	Name: "regexmatch",
+	Desc: "",
+	Params: []gbs.Param{
+		gbs.Scalar("str", "", value.TypeText),
+		gbs.Scalar("pattern", "", value.TypeText),
+	},
+	Category: "text",
+	Func:     RegexMatch,
+}
+
+func RegexMatch(args []value.Value) value.Value {
+	if err := value.HasErrors(args...); err != nil {
+		return err
+	}
+	re, err := regexp.Compile(asString(args[1]))
+	if err != nil {
+		return value.ErrValue
+	}
+	return value.Boolean(re.MatchString(asString(args[0])))
+}
+
+var regexReplaceBuiltin = gbs.Builtin{
+	Name: "regexreplace",
+	Desc: "",
+	Params: []gbs.Param{
+		gbs.Scalar("str", "", value.TypeText),
+		gbs.Scalar("pattern", "", value.TypeText),
+		gbs.Scalar("repl", "", value.TypeText),
+	},
+	Category: "text",
+	Func:     RegexReplace,
+}
+
+// RegexReplace replaces all the matches of the pattern. The replacement can
+// refer to the groups of the pattern with $1, $2...
+func RegexReplace(args []value.Value) value.Value {
+	if err := value.HasErrors(args...); err != nil {
+		return err
+	}
+	re, err := regexp.Compile(asString(args[1]))
+	if err != nil {
+		return value.ErrValue
+	}
+	str := re.ReplaceAllString(asString(args[0]), asString(args[2]))
+	return value.Text(str)
+}
+
+var regexExtractBuiltin = gbs.Builtin{
+	Name: "regexextract",
+	Desc: "",
+	Params: []gbs.Param{
+		gbs.Scalar("str", "", value.TypeText),
+		gbs.Scalar("pattern", "", value.TypeText),
+		gbs.Opt(gbs.Scalar("group", "", value.TypeNumber)),
+	},
+	Category: "text",
+	Func:     RegexExtract,
+}
+
+// RegexExtract gives the text of the first match of the pattern or of one of
+// its groups. Without group, the whole match is given.
+func RegexExtract(args []value.Value) value.Value {
+	if err := value.HasErrors(args...); err != nil {
+		return err
+	}
+	re, err := regexp.Compile(asString(args[1]))
+	if err != nil {
+		return value.ErrValue
+	}
+	var group int
+	if len(args) > 2 {
+		group = int(asFloat(args[2]))
+	}
+	if group < 0 || group > re.NumSubexp() {
+		return value.ErrValue
+	}
+	parts := re.FindStringSubmatch(asString(args[0]))
+	if parts == nil {
+		return value.ErrNA
+	}
+	return value.Text(parts[group])
+}
+
+var textBuiltins = []gbs.Builtin{
+	regexMatchBuiltin,
+	regexReplaceBuiltin,
+	regexExtractBuiltin,
+}
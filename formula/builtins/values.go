@@ -0,0 +1,61 @@
+package builtins
+
+import (
+	"os"
+
+	gbs "github.com/midbel/dockit/grid/builtins"
+	"github.com/midbel/dockit/value"
+)
+
+var coalesceBuiltin = gbs.Builtin{
+	Name: "coalesce",
+	Desc: "",
+	Params: []gbs.Param{
+		gbs.Var(gbs.Scalar("value", "", value.TypeAny)),
+	},
+	Category: "util",
+	Func:     Coalesce,
+}
+
+// Coalesce gives the first of its arguments that is neither blank nor an
+// empty text. It gives a blank when all of them are.
+func Coalesce(args []value.Value) value.Value {
+	for _, a := range args {
+		if !value.IsEmpty(a) {
+			return a
+		}
+	}
+	return value.Empty()
+}
+
+var envBuiltin = gbs.Builtin{
+	Name: "env",
+	Desc: "",
+	Params: []gbs.Param{
+		gbs.Scalar("name", "", value.TypeText),
+		gbs.Opt(gbs.Scalar("default", "", value.TypeAny)),
+	},
+	Category: "util",
+	Func:     Env,
+}
+
+// Env gives the value of an environment variable. The default value, or an
+// empty text without it, is given when the variable is not set.
+func Env(args []value.Value) value.Value {
+	if err := value.HasErrors(args...); err != nil {
+		return err
+	}
+	str, ok := os.LookupEnv(asString(args[0]))
+	if ok {
+		return value.Text(str)
+	}
+	if len(args) > 1 {
+		return args[1]
+	}
+	return value.Text("")
+}
+
+var valueBuiltins = []gbs.Builtin{
+	coalesceBuiltin,
+	envBuiltin,
+}
@@ -7,7 +7,8 @@
 // ranges, and inspection records as runtime values. Package builtins registers
 // script-level helper functions, while grid/builtins contains many
 // spreadsheet-style formula functions. Package format renders parsed formula
-// expressions back to OXML or ODS syntax.
+// expressions back to OXML or ODS syntax. Package stdlib embeds the script
+// modules of the standard library.
 //
 // The formula tree is still evolving. Some syntax is parsed before the
 // evaluator implements it completely, so callers should treat the package set
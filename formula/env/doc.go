@@ -5,6 +5,12 @@
 // formula error model. Define replaces existing bindings unless the existing
 // value implements ImmutableValue and reports that it is immutable.
 //
+// Enclosed environments resolve the names they do not define through their
+// parent. They are used for the variables of loops: updates of names of the
+// parent are kept once the loop is done. Isolated environments are used for
+// the functions defined by scripts: the names they define are lost once the
+// function returns.
+//
 // The package is intentionally minimal; higher-level behavior such as default
 // workbooks, active views, loaders, printers, and configuration lives in
 // formula/eval.
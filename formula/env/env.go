@@ -9,7 +9,9 @@ type ImmutableValue interface {
 }
 
 type Environment struct {
-	values map[string]value.Value
+	values   map[string]value.Value
+	parent   *Environment
+	isolated bool
 }
 
 func Empty() *Environment {
@@ -19,15 +21,41 @@ func Empty() *Environment {
 	return &ctx
 }
 
+// Enclosed gives an environment whose undefined names are resolved by its
+// parent.
+func Enclosed(parent *Environment) *Environment {
+	ctx := Empty()
+	ctx.parent = parent
+	return ctx
+}
+
+// Isolated gives an environment whose undefined names are resolved by its
+// parent but where names are always defined in the environment itself.
+func Isolated(parent *Environment) *Environment {
+	ctx := Enclosed(parent)
+	ctx.isolated = true
+	return ctx
+}
+
 func (c *Environment) Resolve(ident string) value.Value {
 	v, ok := c.values[ident]
 	if ok {
 		return v
 	}
+	if c.parent != nil {
+		return c.parent.Resolve(ident)
+	}
 	return value.ErrRef
 }
 
+// Define binds a value to a name. Names defined by a parent are updated in
+// the parent unless the environment is isolated, other names are defined in
+// the environment itself.
 func (c *Environment) Define(ident string, val value.Value) {
+	if _, ok := c.values[ident]; !ok && !c.isolated && c.parent != nil && c.parent.Has(ident) {
+		c.parent.Define(ident, val)
+		return
+	}
 	v, ok := c.values[ident]
 	if ok {
 		i, ok := v.(ImmutableValue)
@@ -37,3 +65,18 @@ func (c *Environment) Define(ident string, val value.Value) {
 	}
 	c.values[ident] = val
 }
+
+// Declare binds a value to a name in the environment itself, hiding the
+// name defined by a parent.
+func (c *Environment) Declare(ident string, val value.Value) {
+	c.values[ident] = val
+}
+
+// Has reports whether a name is defined by the environment or one of its
+// parents.
+func (c *Environment) Has(ident string) bool {
+	if _, ok := c.values[ident]; ok {
+		return true
+	}
+	return c.parent != nil && c.parent.Has(ident)
+}
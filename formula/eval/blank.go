@@ -0,0 +1,166 @@
+package eval
+
+import (
+	"github.com/midbel/dockit/formula/op"
+	gbs "github.com/midbel/dockit/grid/builtins"
+	"github.com/midbel/dockit/internal/locale"
+	"github.com/midbel/dockit/value"
+)
+
+// blankMode tells how the blank values are used by the operators and by the
+// aggregations. Empty texts, as the empty cells of csv files, are blanks when
+// they are used with a value that is not a text.
+type blankMode int8
+
+const (
+	// blankDefault follows the spreadsheets: blanks are zeros for the
+	// operators and are skipped by the aggregations.
+	blankDefault blankMode = iota
+	// blankZero uses blanks as zeros, also in the aggregations.
+	blankZero
+	// blankSkip gives a blank for the operators using a blank and skips them
+	// in the aggregations.
+	blankSkip
+	// blankError gives #VALUE! whenever a blank is used.
+	blankError
+)
+
+func parseBlankMode(str string) (blankMode, error) {
+	switch str {
+	case "", "default":
+		return blankDefault, nil
+	case "zero":
+		return blankZero, nil
+	case "skip":
+		return blankSkip, nil
+	case "error":
+		return blankError, nil
+	default:
+		return blankDefault, locale.Errorf("%s: unknown blank mode", str)
+	}
+}
+
+// aggregateBuiltins are the builtins whose arguments are changed by the blank
+// mode.
+var aggregateBuiltins = map[string]struct{}{
+	"sum":     {},
+	"average": {},
+	"min":     {},
+	"max":     {},
+	"product": {},
+	"median":  {},
+	"mode":    {},
+	"stdev":   {},
+	"stdevp":  {},
+	"var":     {},
+	"varp":    {},
+}
+
+// blankOperands gives the operands of a binary operator once their blanks
+// are replaced. The result is given directly when the operator can not be
+// applied.
+func (m blankMode) blankOperands(left, right value.Value, oper op.Op) (value.Value, value.Value, value.Value) {
+	if oper == op.Concat {
+		return left, right, nil
+	}
+	var (
+		lb = isMissing(left, right)
+		rb = isMissing(right, left)
+	)
+	if !lb && !rb {
+		return left, right, nil
+	}
+	switch m {
+	case blankSkip:
+		return left, right, value.Empty()
+	case blankError:
+		return left, right, value.ErrValue
+	}
+	if lb {
+		left = zeroOf(right)
+	}
+	if rb {
+		right = zeroOf(left)
+	}
+	return left, right, nil
+}
+
+// blankArgs gives the arguments of an aggregation once their blanks are
+// replaced. Scalars given directly are also replaced.
+func (m blankMode) blankArgs(name string, args []value.Value) []value.Value {
+	if m == blankDefault || m == blankSkip {
+		return args
+	}
+	if b, err := gbs.Get(name); err == nil {
+		name = b.Name
+	}
+	if _, ok := aggregateBuiltins[name]; !ok {
+		return args
+	}
+	list := make([]value.Value, 0, len(args))
+	for _, a := range args {
+		if x, ok := a.(interface{ AsArray() value.ArrayValue }); ok && !value.IsArray(a) {
+			// views are aggregated as the array of their values
+			list = append(list, m.blankArray(x.AsArray()))
+			continue
+		}
+		if arr, ok := a.(value.ArrayValue); ok {
+			list = append(list, m.blankArray(arr))
+			continue
+		}
+		if value.IsEmpty(a) {
+			a = m.blankValue()
+		}
+		list = append(list, a)
+	}
+	return list
+}
+
+func (m blankMode) blankArray(arr value.ArrayValue) value.Value {
+	var (
+		dim  = arr.Dimension()
+		data = make([][]value.Value, dim.Lines)
+	)
+	for i := range dim.Lines {
+		data[i] = make([]value.Value, dim.Columns)
+		for j := range dim.Columns {
+			v := arr.At(int(i), int(j))
+			if value.IsEmpty(v) {
+				if m == blankError {
+					return value.ErrValue
+				}
+				v = m.blankValue()
+			}
+			data[i][j] = v
+		}
+	}
+	return value.NewArray(data)
+}
+
+func (m blankMode) blankValue() value.Value {
+	if m == blankError {
+		return value.ErrValue
+	}
+	return value.Float(0)
+}
+
+// isMissing reports whether val is blank or an empty text used with a value
+// that is not a text.
+func isMissing(val, other value.Value) bool {
+	if value.IsBlank(val) {
+		return true
+	}
+	return value.IsEmpty(val) && !value.IsText(other)
+}
+
+// zeroOf gives the value replacing a blank used with other.
+func zeroOf(other value.Value) value.Value {
+	switch other.(type) {
+	case value.Text:
+		return value.Text("")
+	case value.Boolean:
+		return value.Boolean(false)
+	default:
+		return value.Float(0)
+	}
+}
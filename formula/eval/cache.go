@@ -0,0 +1,250 @@
+package eval
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/midbel/dockit/flat"
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+var ErrCache = errors.New("invalid cache entry")
+
+const (
+	cacheMagic = "DKC1"
+	cacheExt   = ".dkc"
+)
+
+// ImportCache keeps the values of imported files on disk. Entries are keyed by
+// the hash of the content of the file and the options given to its loader so
+// that a file modified is never served from the cache.
+//
+// Only the values are cached: formulas are lost and files opened from the
+// cache behave like csv files.
+type ImportCache struct {
+	dir   string
+	limit int64
+}
+
+// NewImportCache creates a cache in the given directory. The limit is the
+// maximum size in bytes of the cache. Zero means no limit.
+func NewImportCache(dir string, limit int64) *ImportCache {
+	return &ImportCache{
+		dir:   dir,
+		limit: limit,
+	}
+}
+
+func (c *ImportCache) Dir() string {
+	return c.dir
+}
+
+func (c *ImportCache) Open(file string, opts LoaderOptions, loader Loader) (grid.File, error) {
+	key, err := c.key(file, opts)
+	if err != nil {
+		return nil, err
+	}
+	entry := filepath.Join(c.dir, key+cacheExt)
+	if f, err := c.load(entry); err == nil {
+		now := time.Now()
+		os.Chtimes(entry, now, now)
+		return f, nil
+	}
+	f, err := loader.Open(file, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.store(entry, f); err != nil {
+		return f, nil
+	}
+	return f, c.Trim(c.limit)
+}
+
+// Clear removes all the entries of the cache.
+func (c *ImportCache) Clear() error {
+	return c.Trim(-1)
+}
+
+// Trim removes the least recently used entries of the cache until its size
+// does not exceed the given number of bytes. A negative size removes all the
+// entries and zero means no limit.
+func (c *ImportCache) Trim(size int64) error {
+	if size == 0 {
+		return nil
+	}
+	es, err := os.ReadDir(c.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var (
+		list  []os.FileInfo
+		total int64
+	)
+	for _, e := range es {
+		if e.IsDir() || filepath.Ext(e.Name()) != cacheExt {
+			continue
+		}
+		i, err := e.Info()
+		if err != nil {
+			continue
+		}
+		list = append(list, i)
+		total += i.Size()
+	}
+	slices.SortFunc(list, func(a, b os.FileInfo) int {
+		return a.ModTime().Compare(b.ModTime())
+	})
+	for _, i := range list {
+		if size > 0 && total <= size {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, i.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		total -= i.Size()
+	}
+	return nil
+}
+
+func (c *ImportCache) key(file string, opts LoaderOptions) (string, error) {
+	r, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	sum := sha256.New()
+	if _, err := io.Copy(sum, r); err != nil {
+		return "", err
+	}
+	for _, k := range slices.Sorted(maps.Keys(opts)) {
+		fmt.Fprintf(sum, "%s=%v;", k, opts[k])
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// store writes the sheets of the file column by column.
+func (c *ImportCache) store(entry string, file grid.File) error {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, ".dockit_cache*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	var (
+		ws  = bufio.NewWriter(tmp)
+		buf []byte
+	)
+	buf = append(buf, cacheMagic...)
+	buf = binary.AppendUvarint(buf, uint64(len(file.Sheets())))
+	for _, sh := range file.Sheets() {
+		bd := sh.Bounds()
+		buf = binary.AppendUvarint(buf, uint64(len(sh.Name())))
+		buf = append(buf, sh.Name()...)
+		buf = binary.AppendUvarint(buf, uint64(bd.Height()))
+		buf = binary.AppendUvarint(buf, uint64(bd.Width()))
+		for col := bd.Starts.Column; col <= bd.Ends.Column; col++ {
+			for line := bd.Starts.Line; line <= bd.Ends.Line; line++ {
+				var val value.Value = value.Empty()
+				if c, err := sh.Cell(layout.NewPosition(line, col)); err == nil {
+					val = c.Value()
+				}
+				buf = grid.AppendValue(buf, val)
+			}
+			if _, err := ws.Write(buf); err != nil {
+				tmp.Close()
+				return err
+			}
+			buf = buf[:0]
+		}
+	}
+	if _, err := ws.Write(buf); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := ws.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), entry)
+}
+
+func (c *ImportCache) load(entry string) (grid.File, error) {
+	buf, err := os.ReadFile(entry)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(buf, []byte(cacheMagic)) {
+		return nil, ErrCache
+	}
+	buf = buf[len(cacheMagic):]
+
+	readInt := func() (int, error) {
+		n, z := binary.Uvarint(buf)
+		if z <= 0 {
+			return 0, ErrCache
+		}
+		buf = buf[z:]
+		return int(n), nil
+	}
+	count, err := readInt()
+	if err != nil {
+		return nil, err
+	}
+	var sheets []*flat.Sheet
+	for range count {
+		size, err := readInt()
+		if err != nil || size > len(buf) {
+			return nil, ErrCache
+		}
+		name := string(buf[:size])
+		buf = buf[size:]
+
+		lines, err := readInt()
+		if err != nil {
+			return nil, err
+		}
+		cols, err := readInt()
+		if err != nil {
+			return nil, err
+		}
+		rows := make([][]value.Value, lines)
+		for i := range rows {
+			rows[i] = make([]value.Value, cols)
+		}
+		for col := range cols {
+			for line := range lines {
+				val, n, err := grid.DecodeValue(buf)
+				if err != nil {
+					return nil, ErrCache
+				}
+				rows[line][col] = val
+				buf = buf[n:]
+			}
+		}
+		sheets = append(sheets, flat.NewSheet(name, rows))
+	}
+	return flat.NewFileFromSheets(sheets...), nil
+}
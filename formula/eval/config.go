@@ -1,12 +1,15 @@
 package eval
 
 import (
-	"fmt"
-	"os"
+	"io"
+	"strings"
 
 	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/formula/stdlib"
+	"github.com/midbel/dockit/grid"
 	"github.com/midbel/dockit/grid/format"
 	"github.com/midbel/dockit/internal/ds"
+	"github.com/midbel/dockit/internal/locale"
 	"github.com/midbel/dockit/internal/slx"
 )
 
@@ -15,15 +18,34 @@ var (
 	ConfigPrintDebug       = slx.Make("print", "debug")
 	ConfigPrintCols        = slx.Make("print", "cols")
 	ConfigPrintRows        = slx.Make("print", "rows")
+	ConfigPrintPlain       = slx.Make("print", "plain")
 	ConfigFormatNumber     = slx.Make("format", "number")
 	ConfigFormatDate       = slx.Make("format", "date")
 	ConfigFormatBool       = slx.Make("format", "bool")
+	ConfigFormatPrecision  = slx.Make("format", "precision")
+	ConfigDateSystem       = slx.Make("date", "system")
 	ConfigImportLogPattern = slx.Make("import", "log", "pattern")
 	ConfigImportCsvDelim   = slx.Make("import", "csv", "delimiter")
 	ConfigImportCsvQuoted  = slx.Make("import", "csv", "quoted")
+	ConfigImportCacheDir   = slx.Make("import", "cache", "dir")
+	ConfigImportCacheSize  = slx.Make("import", "cache", "size")
+	ConfigIncludePath      = slx.Make("include", "path")
+	ConfigExternalPath     = slx.Make("external", "path")
 	ConfigAssertMode       = slx.Make("assert", "mode")
 	ConfigExportFormat     = slx.Make("export", "format")
+	ConfigExportNames      = slx.Make("export", "names")
+	ConfigExportCsvDelim   = slx.Make("export", "csv", "delimiter")
 	ConfigCopyMode         = slx.Make("copy", "mode")
+	ConfigMemoryCells      = slx.Make("memory", "cells")
+	ConfigMemoryDir        = slx.Make("memory", "dir")
+	ConfigCalcIterate      = slx.Make("calc", "iterate")
+	ConfigCalcIterations   = slx.Make("calc", "iterations")
+	ConfigCalcDelta        = slx.Make("calc", "delta")
+	ConfigCalcFreeze       = slx.Make("calc", "freeze")
+	ConfigLoopLimit        = slx.Make("loop", "limit")
+	ConfigBlankMode        = slx.Make("blank", "mode")
+	ConfigScriptMode       = slx.Make("script", "mode")
+	ConfigErrorMode        = slx.Make("error", "mode")
 )
 
 var defaultConfig = []struct {
@@ -46,6 +68,10 @@ var defaultConfig = []struct {
 		Key:   ConfigPrintRows,
 		Value: float64(maxRows),
 	},
+	{
+		Key:   ConfigPrintPlain,
+		Value: false,
+	},
 	{
 		Key:   ConfigFormatNumber,
 		Value: format.DefaultNumberPattern,
@@ -58,6 +84,14 @@ var defaultConfig = []struct {
 		Key:   ConfigFormatBool,
 		Value: "",
 	},
+	{
+		Key:   ConfigFormatPrecision,
+		Value: float64(-1),
+	},
+	{
+		Key:   ConfigDateSystem,
+		Value: "1900",
+	},
 	{
 		Key:   ConfigImportCsvDelim,
 		Value: "comma",
@@ -66,6 +100,22 @@ var defaultConfig = []struct {
 		Key:   ConfigImportCsvQuoted,
 		Value: true,
 	},
+	{
+		Key:   ConfigImportCacheDir,
+		Value: "",
+	},
+	{
+		Key:   ConfigImportCacheSize,
+		Value: float64(0),
+	},
+	{
+		Key:   ConfigIncludePath,
+		Value: "",
+	},
+	{
+		Key:   ConfigExternalPath,
+		Value: "",
+	},
 	{
 		Key:   ConfigAssertMode,
 		Value: "fail",
@@ -74,10 +124,58 @@ var defaultConfig = []struct {
 		Key:   ConfigExportFormat,
 		Value: "oxml",
 	},
+	{
+		Key:   ConfigExportNames,
+		Value: false,
+	},
+	{
+		Key:   ConfigExportCsvDelim,
+		Value: "comma",
+	},
 	{
 		Key:   ConfigCopyMode,
 		Value: false,
 	},
+	{
+		Key:   ConfigMemoryCells,
+		Value: float64(0),
+	},
+	{
+		Key:   ConfigMemoryDir,
+		Value: "",
+	},
+	{
+		Key:   ConfigCalcIterate,
+		Value: false,
+	},
+	{
+		Key:   ConfigCalcIterations,
+		Value: float64(grid.DefaultIteration().Count),
+	},
+	{
+		Key:   ConfigCalcDelta,
+		Value: grid.DefaultIteration().Delta,
+	},
+	{
+		Key:   ConfigCalcFreeze,
+		Value: false,
+	},
+	{
+		Key:   ConfigLoopLimit,
+		Value: float64(DefaultLoopLimit),
+	},
+	{
+		Key:   ConfigBlankMode,
+		Value: "default",
+	},
+	{
+		Key:   ConfigScriptMode,
+		Value: "lenient",
+	},
+	{
+		Key:   ConfigErrorMode,
+		Value: "fail",
+	},
 }
 
 type EngineConfig struct {
@@ -97,23 +195,28 @@ func (c *EngineConfig) ContextDir() string {
 	return dir.(string)
 }
 
-func (c *EngineConfig) Printer() (Printer, error) {
+func (c *EngineConfig) Printer(w io.Writer) (Printer, error) {
 	debug, _ := c.registry.Get(ConfigPrintDebug)
 	cols, _ := c.registry.Get(ConfigPrintCols)
 	rows, _ := c.registry.Get(ConfigPrintRows)
 
 	maxCols, ok := cols.(float64)
-	if !ok {
-		return nil, fmt.Errorf("columns should be a number")
+	if !ok || maxCols < 1 {
+		return nil, locale.Errorf("columns should be a positive number")
 	}
 	maxRows, ok := rows.(float64)
-	if !ok {
-		return nil, fmt.Errorf("rows should be a number")
+	if !ok || maxRows < 1 {
+		return nil, locale.Errorf("rows should be a positive number")
+	}
+	if p, ok := c.registry.Get(ConfigPrintPlain); ok {
+		if b, ok := p.(bool); ok && b {
+			return PlainValue(w), nil
+		}
 	}
 	if d, ok := debug.(bool); ok && d {
-		return DebugValue(os.Stdout, int(maxRows), int(maxCols)), nil
+		return DebugValue(w, int(maxRows), int(maxCols)), nil
 	}
-	return PrintValue(os.Stdout, int(maxRows), int(maxCols)), nil
+	return PrintValue(w, int(maxRows), int(maxCols)), nil
 }
 
 func (c *EngineConfig) Formatter() (format.Formatter, error) {
@@ -121,7 +224,15 @@ func (c *EngineConfig) Formatter() (format.Formatter, error) {
 	if num, ok := c.registry.Get(ConfigFormatNumber); ok {
 		str, ok := num.(string)
 		if !ok {
-			return nil, fmt.Errorf("number pattern should be a literal")
+			return nil, locale.Errorf("number pattern should be a literal")
+		}
+		prec, _ := c.registry.Get(ConfigFormatPrecision)
+		n, ok := prec.(float64)
+		if !ok {
+			return nil, locale.Errorf("precision should be a number")
+		}
+		if n >= 0 {
+			str = withPrecision(str, int(n))
 		}
 		if err := vf.Number(str); err != nil {
 			return nil, err
@@ -130,7 +241,7 @@ func (c *EngineConfig) Formatter() (format.Formatter, error) {
 	if date, ok := c.registry.Get(ConfigFormatDate); ok {
 		str, ok := date.(string)
 		if !ok {
-			return nil, fmt.Errorf("date pattern should be a literal")
+			return nil, locale.Errorf("date pattern should be a literal")
 		}
 		if err := vf.Date(str); err != nil {
 			return nil, err
@@ -139,7 +250,7 @@ func (c *EngineConfig) Formatter() (format.Formatter, error) {
 	if mode, ok := c.registry.Get(ConfigFormatBool); ok {
 		str, ok := mode.(string)
 		if !ok {
-			return nil, fmt.Errorf("boolean pattern should be a literal")
+			return nil, locale.Errorf("boolean pattern should be a literal")
 		}
 		if err := vf.Bool(str); err != nil {
 			return nil, err
@@ -148,6 +259,132 @@ func (c *EngineConfig) Formatter() (format.Formatter, error) {
 	return vf, nil
 }
 
+func (c *EngineConfig) Budget() (*grid.Budget, error) {
+	cells, _ := c.registry.Get(ConfigMemoryCells)
+	dir, _ := c.registry.Get(ConfigMemoryDir)
+
+	limit, ok := cells.(float64)
+	if !ok {
+		return nil, locale.Errorf("memory cells should be a number")
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+	str, ok := dir.(string)
+	if !ok {
+		return nil, locale.Errorf("memory directory should be a literal")
+	}
+	return grid.NewBudget(int64(limit), str), nil
+}
+
+// Iteration gives the settings of the iterative calculation of the formulas
+// involved in circular references. The count is zero when it is disabled.
+func (c *EngineConfig) Iteration() (grid.Iteration, error) {
+	var it grid.Iteration
+	enabled, _ := c.registry.Get(ConfigCalcIterate)
+	if b, ok := enabled.(bool); !ok || !b {
+		return it, nil
+	}
+	count, _ := c.registry.Get(ConfigCalcIterations)
+	delta, _ := c.registry.Get(ConfigCalcDelta)
+
+	n, ok := count.(float64)
+	if !ok || n < 1 {
+		return it, locale.Errorf("iterations should be a positive number")
+	}
+	d, ok := delta.(float64)
+	if !ok || d < 0 {
+		return it, locale.Errorf("delta should be a positive number")
+	}
+	it.Count = int(n)
+	it.Delta = d
+	return it, nil
+}
+
+// Frozen reports whether the volatile formulas of the workbooks keep the value
+// given by their first evaluation.
+func (c *EngineConfig) Frozen() bool {
+	freeze, _ := c.registry.Get(ConfigCalcFreeze)
+	b, ok := freeze.(bool)
+	return ok && b
+}
+
+// BlankMode gives how the blank values are used by the operators and the
+// aggregations of the scripts.
+func (c *EngineConfig) BlankMode() (blankMode, error) {
+	mode, _ := c.registry.Get(ConfigBlankMode)
+	str, ok := mode.(string)
+	if !ok {
+		return blankDefault, locale.Errorf("blank mode should be a literal")
+	}
+	return parseBlankMode(str)
+}
+
+// Date1904 reports whether the serial numbers of the dates use the 1904 date
+// system instead of the 1900 date system.
+func (c *EngineConfig) Date1904() (bool, error) {
+	system, _ := c.registry.Get(ConfigDateSystem)
+	switch system {
+	case "1900", float64(1900):
+		return false, nil
+	case "1904", float64(1904):
+		return true, nil
+	default:
+		return false, locale.Errorf("date system should be 1900 or 1904")
+	}
+}
+
+// Strict reports whether the scripts fail when they read a name that is not
+// defined instead of getting #REF!.
+func (c *EngineConfig) Strict() (bool, error) {
+	mode, _ := c.registry.Get(ConfigScriptMode)
+	switch mode {
+	case "lenient":
+		return false, nil
+	case "strict":
+		return true, nil
+	default:
+		return false, locale.Errorf("script mode should be strict or lenient")
+	}
+}
+
+// ErrorMode gives what is done when a statement of a script fails.
+func (c *EngineConfig) ErrorMode() (errorMode, error) {
+	mode, _ := c.registry.Get(ConfigErrorMode)
+	str, ok := mode.(string)
+	if !ok {
+		return errorFail, locale.Errorf("error mode should be a literal")
+	}
+	return parseErrorMode(str)
+}
+
+func (c *EngineConfig) Library() (*stdlib.Library, error) {
+	dir, _ := c.registry.Get(ConfigIncludePath)
+	str, ok := dir.(string)
+	if !ok {
+		return nil, locale.Errorf("include path should be a literal")
+	}
+	return stdlib.New(str), nil
+}
+
+func (c *EngineConfig) ImportCache() (*ImportCache, error) {
+	dir, _ := c.registry.Get(ConfigImportCacheDir)
+	size, _ := c.registry.Get(ConfigImportCacheSize)
+
+	str, ok := dir.(string)
+	if !ok {
+		return nil, locale.Errorf("cache directory should be a literal")
+	}
+	if str == "" {
+		return nil, nil
+	}
+	limit, ok := size.(float64)
+	if !ok {
+		return nil, locale.Errorf("cache size should be a number")
+	}
+	return NewImportCache(str, int64(limit)), nil
+}
+
 func (c *EngineConfig) Set(ident []string, val any) error {
 	if val == nil {
 		return nil
@@ -171,6 +408,41 @@ func (c *EngineConfig) Merge(other *EngineConfig) error {
 	return c.registry.Merge(other.registry)
 }
 
+// withPrecision replaces the decimals of a number pattern by the given number
+// of decimals.
+func withPrecision(pattern string, n int) string {
+	left, _, _ := strings.Cut(pattern, ".")
+	if n == 0 {
+		return left
+	}
+	return left + "." + strings.Repeat("0", n)
+}
+
+// errorMode tells what is done when a statement of a script fails.
+type errorMode int8
+
+const (
+	// errorFail stops the script.
+	errorFail errorMode = iota
+	// errorWarn writes the error and runs the following statements.
+	errorWarn
+	// errorIgnore runs the following statements silently.
+	errorIgnore
+)
+
+func parseErrorMode(str string) (errorMode, error) {
+	switch str {
+	case "", "fail":
+		return errorFail, nil
+	case "warn":
+		return errorWarn, nil
+	case "ignore":
+		return errorIgnore, nil
+	default:
+		return errorFail, locale.Errorf("%s: unknown error mode", str)
+	}
+}
+
 func csvDelimiter(value string) string {
 	switch value {
 	default:
@@ -1,15 +1,22 @@
 package eval
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/midbel/dockit/driver"
 	"github.com/midbel/dockit/flat"
 	"github.com/midbel/dockit/formula/env"
 	"github.com/midbel/dockit/formula/runtime"
+	"github.com/midbel/dockit/formula/stdlib"
 	"github.com/midbel/dockit/grid"
 	"github.com/midbel/dockit/grid/format"
+	"github.com/midbel/dockit/internal/locale"
 	"github.com/midbel/dockit/internal/slx"
 	"github.com/midbel/dockit/layout"
 	"github.com/midbel/dockit/ods"
@@ -23,10 +30,28 @@ type EngineContext struct {
 	env          *env.Environment
 	currentValue value.Value
 
+	stdout     io.Writer
+	stderr     io.Writer
 	printer    Printer
 	formatter  format.Formatter
 	contextDir string
 	config     *EngineConfig
+	budget     *grid.Budget
+	iterate    grid.Iteration
+	frozen     bool
+	blanks     blankMode
+	date1904   bool
+	strict     bool
+	errors     errorMode
+	cache      *ImportCache
+	library    *stdlib.Library
+	report     *runReport
+	books      *externalBooks
+	tracker    *tracker
+	names      *definedNames
+	sources    *sourceFiles
+	defaults   *defaultStack
+	modules    []string
 
 	depth int
 }
@@ -53,7 +78,10 @@ func (c *EngineContext) Configure(cfg *EngineConfig) error {
 	}
 	c.formatter = f
 
-	p, err := cfg.Printer()
+	if c.stdout == nil {
+		c.stdout = os.Stdout
+	}
+	p, err := cfg.Printer(c.stdout)
 	if err != nil {
 		return err
 	}
@@ -61,9 +89,122 @@ func (c *EngineContext) Configure(cfg *EngineConfig) error {
 
 	c.contextDir = cfg.ContextDir()
 
+	b, err := cfg.Budget()
+	if err != nil {
+		return err
+	}
+	c.budget = b
+
+	it, err := cfg.Iteration()
+	if err != nil {
+		return err
+	}
+	c.iterate = it
+	c.frozen = cfg.Frozen()
+
+	bm, err := cfg.BlankMode()
+	if err != nil {
+		return err
+	}
+	c.blanks = bm
+
+	if c.date1904, err = cfg.Date1904(); err != nil {
+		return err
+	}
+	if c.strict, err = cfg.Strict(); err != nil {
+		return err
+	}
+	if c.errors, err = cfg.ErrorMode(); err != nil {
+		return err
+	}
+	if c.stderr == nil {
+		c.stderr = os.Stderr
+	}
+
+	ic, err := cfg.ImportCache()
+	if err != nil {
+		return err
+	}
+	c.cache = ic
+
+	lib, err := cfg.Library()
+	if err != nil {
+		return err
+	}
+	c.library = lib
+
 	return nil
 }
 
+// handleError gives the error stopping the script according to the error
+// mode of the context. Aborted scripts are always stopped.
+func (c *EngineContext) handleError(err error) error {
+	var abort AbortError
+	if errors.As(err, &abort) {
+		return err
+	}
+	switch c.errors {
+	case errorWarn:
+		fmt.Fprintf(c.stderr, "warning: %s\n", err)
+		return nil
+	case errorIgnore:
+		return nil
+	default:
+		return err
+	}
+}
+
+// parseFormatter gives the formatter of a print pattern using the date system
+// of the context.
+func (c *EngineContext) parseFormatter(pattern string) (format.Formatter, error) {
+	f, err := format.ParseFormatter(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if c.date1904 {
+		f = format.Date1904(f)
+	}
+	return f, nil
+}
+
+// formatPattern formats a value of a template with the date system of the
+// context.
+func (c *EngineContext) formatPattern(val value.Value, pattern string) (string, error) {
+	if _, ok := val.(value.Boolean); ok || !c.date1904 {
+		return format.FormatPattern(val, pattern)
+	}
+	f, err := c.parseFormatter(pattern)
+	if err != nil {
+		return "", err
+	}
+	return f.Format(val)
+}
+
+// OpenModule gives the content of a script to include. Modules of the
+// standard library are resolved by the library, others are relative to the
+// script including them or to the context directory for the main script.
+func (c *EngineContext) OpenModule(name string) (io.ReadCloser, error) {
+	name = c.resolveModule(name)
+	if stdlib.IsStd(name) {
+		if c.library == nil {
+			c.library = stdlib.New("")
+		}
+		return c.library.Open(name)
+	}
+	return os.Open(name)
+}
+
+func (c *EngineContext) resolveModule(name string) string {
+	if stdlib.IsStd(name) || filepath.IsAbs(name) {
+		return name
+	}
+	dir := c.contextDir
+	if n := len(c.modules); n > 0 && !stdlib.IsStd(c.modules[n-1]) {
+		dir = filepath.Dir(c.modules[n-1])
+	}
+	return filepath.Join(dir, name)
+}
+
 func (c *EngineContext) GetOption(key []string) any {
 	return c.config.Get(key)
 }
@@ -85,35 +226,145 @@ func (c *EngineContext) Link() bool {
 }
 
 func (c *EngineContext) Open(file string, opts LoaderOptions) (grid.File, error) {
+	return c.openFile(filepath.Join(c.contextDir, file), opts)
+}
+
+func (c *EngineContext) openFile(file string, opts LoaderOptions) (grid.File, error) {
 	ext := filepath.Ext(file)
 	loader, ok := c.loaders[ext]
 	if !ok {
-		return nil, fmt.Errorf("file %s can not be loaded", ext)
+		return nil, locale.Errorf("file %s can not be loaded", ext)
+	}
+	c.report.imported(file)
+
+	var (
+		wb  grid.File
+		err error
+	)
+	if c.cache != nil {
+		wb, err = c.cache.Open(file, opts, loader)
+	} else {
+		wb, err = loader.Open(file, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.iterate.Count > 0 {
+		// settings of the workbook are kept unless given to the engine
+		c.setIteration(wb)
+	}
+	c.setFreeze(wb)
+	c.tracker.track(wb)
+	c.sources.add(wb, file)
+	return wb, nil
+}
+
+func (c *EngineContext) setIteration(file grid.File) {
+	if it, ok := file.(driver.Iterative); ok {
+		it.SetIteration(c.iterate)
 	}
-	file = filepath.Join(c.contextDir, file)
-	return loader.Open(file, opts)
 }
 
-func (c *EngineContext) Export(val value.Value, out, format string) error {
+func (c *EngineContext) setFreeze(file grid.File) {
+	if fz, ok := file.(driver.Freezable); ok {
+		fz.SetFreeze(c.frozen)
+	}
+}
+
+// loaderOptions completes the options given to the loader of the given format
+// with its specifier or, if empty, with the default of the configuration.
+func (c *EngineContext) loaderOptions(format, spec string, options LoaderOptions) LoaderOptions {
+	if options == nil {
+		options = make(LoaderOptions)
+	}
+	switch format {
+	case "csv":
+		if spec == "" {
+			spec = c.GetOptionString(ConfigImportCsvDelim)
+		}
+		options["delimiter"] = csvDelimiter(spec)
+	case "log":
+		if spec == "" {
+			spec = c.GetOptionString(ConfigImportLogPattern)
+		}
+		options["pattern"] = spec
+	case "json":
+		options["query"] = spec
+	case "xml":
+		options["query"] = spec
+	default:
+	}
+	return options
+}
+
+// Export writes a value to a file with the writer registered for the format,
+// given by the extension of the file when empty. Ranges are written with the
+// values of their cells.
+func (c *EngineContext) Export(val value.Value, out, format string, options LoaderOptions) error {
 	if f, ok := val.(interface{ Sync() error }); ok {
 		if err := f.Sync(); err != nil {
 			return err
 		}
 	}
+	return c.exportValue(val, out, format, options)
+}
+
+// ExportTarget is a file written by an export and the format used to write
+// it. An empty format means the format is taken from the extension of the
+// file.
+type ExportTarget struct {
+	File   string
+	Format string
+}
+
+// ExportAll writes a value to several targets. The value is synced once and
+// the same data is written to each target.
+func (c *EngineContext) ExportAll(val value.Value, targets []ExportTarget, options LoaderOptions) error {
+	if f, ok := val.(interface{ Sync() error }); ok {
+		if err := f.Sync(); err != nil {
+			return err
+		}
+	}
+	for _, t := range targets {
+		if err := c.exportValue(val, t.File, t.Format, options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *EngineContext) exportValue(val value.Value, out, format string, options LoaderOptions) error {
 	if format == "" {
 		ext := filepath.Ext(out)
 		format = strings.TrimPrefix(ext, ".")
 	}
+	if format == "" {
+		format = c.GetOptionString(ConfigExportFormat)
+	}
+	w, ok := c.writers[writerExt(format)]
+	if !ok {
+		return locale.Errorf("file can not be exported to format %s", format)
+	}
+	options = c.writerOptions(format, options)
+
 	wb, err := c.createFile(format)
 	if err != nil {
 		return err
 	}
+	if rg, ok := val.(*runtime.Range); ok {
+		val = c.Range(rg.Range().Starts, rg.Range().Ends)
+	}
 	switch val := val.(type) {
 	case *runtime.File:
+		if _, ok := options["sheet"]; !ok {
+			// sheets are copied but the active sheet of the file is exported
+			if sh, err := val.File().ActiveSheet(); err == nil {
+				options["sheet"] = sh.Name()
+			}
+		}
 		err = wb.Merge(val)
 	case *runtime.View:
 		err = wb.Append(val)
-	case *runtime.Range:
 	case value.ScalarValue:
 		sh := runtime.NewViewValue(NewScalarView(val))
 		err = wb.Append(sh.(*runtime.View))
@@ -126,17 +377,58 @@ func (c *EngineContext) Export(val value.Value, out, format string) error {
 	if err != nil {
 		return err
 	}
+	if c.exportNamesEnabled() {
+		if err := c.exportNames(wb.File()); err != nil {
+			return err
+		}
+	}
 	file := filepath.Join(c.contextDir, out)
-	return wb.WriteFile(file)
+	if err := w.Write(file, wb.File(), options); err != nil {
+		return err
+	}
+	c.report.exported(wb.File(), file)
+	return nil
+}
+
+func (c *EngineContext) writerOptions(format string, options LoaderOptions) LoaderOptions {
+	// options of the statement are updated for each export
+	options = maps.Clone(options)
+	if options == nil {
+		options = make(LoaderOptions)
+	}
+	if _, ok := options["delimiter"]; !ok {
+		switch format {
+		case "tsv":
+			options["delimiter"] = "tab"
+		case "csv":
+			options["delimiter"] = c.GetOptionString(ConfigExportCsvDelim)
+		}
+	}
+	return options
+}
+
+// writerExt gives the extension under which the writer of a format is
+// registered.
+func writerExt(format string) string {
+	if format == "oxml" {
+		format = "xlsx"
+	}
+	return "." + strings.ToLower(format)
 }
 
 func (c *EngineContext) Print(v value.Value) error {
+	return c.PrintWith(v, c.formatter)
+}
+
+// PrintWith prints a value with the given formatter instead of the formatter
+// of the engine.
+func (c *EngineContext) PrintWith(v value.Value, f format.Formatter) error {
 	if s, ok := v.(interface{ Sync() error }); ok {
 		if err := s.Sync(); err != nil {
 			return err
 		}
 	}
-	c.printer.Format(v, c.formatter)
+	c.printer.Format(v, f)
 	return nil
 }
 
@@ -147,9 +439,23 @@ func (c *EngineContext) Resolve(ident string) value.Value {
 			return val
 		}
 	}
+	if val, ok := c.resolveName(ident); ok {
+		return val
+	}
 	return c.env.Resolve(ident)
 }
 
+// Defined reports whether a name can be resolved by the context.
+func (c *EngineContext) Defined(ident string) bool {
+	if obj, ok := c.currentValue.(value.ObjectValue); ok && !value.IsError(obj.Get(ident)) {
+		return true
+	}
+	if _, ok := c.resolveName(ident); ok {
+		return true
+	}
+	return c.env.Has(ident)
+}
+
 func (c *EngineContext) Define(ident string, value value.Value) {
 	c.env.Define(ident, value)
 }
@@ -173,16 +479,28 @@ func (c *EngineContext) NewSheet(name, data, target value.Value) (value.Value, e
 		return nil, err
 	}
 	sh := flat.NewSheet(name.String(), arr)
-	if target != nil {
-
+	if target == nil {
+		return runtime.NewViewValue(sh), nil
+	}
+	file, ok := target.(*runtime.File)
+	if !ok {
+		return nil, locale.Errorf("sheet can only be added to a file")
 	}
-	return runtime.NewViewValue(sh), nil
+	if file.ReadOnly() {
+		return nil, locale.Errorf("sheet can not be added to read only file")
+	}
+	if err := file.File().AppendSheet(sh); err != nil {
+		return nil, err
+	}
+	// names of sheets can be changed by the file when appended
+	all := file.File().Sheets()
+	return file.Sheet(all[len(all)-1].Name())
 }
 
 func (c *EngineContext) InsertRows(sheet, count, index value.Value) (*runtime.WritableRange, runtime.Mutation, error) {
 	view, ok := sheet.(*runtime.View)
 	if !ok {
-		return nil, runtime.Mutation{}, fmt.Errorf("view expected")
+		return nil, runtime.Mutation{}, locale.Errorf("view expected")
 	}
 	var (
 		rows float64
@@ -192,7 +510,7 @@ func (c *EngineContext) InsertRows(sheet, count, index value.Value) (*runtime.Wr
 		if c, ok := count.(value.Float); ok {
 			rows = float64(c)
 		} else {
-			return nil, runtime.Mutation{}, fmt.Errorf("count: number expected")
+			return nil, runtime.Mutation{}, locale.Errorf("count: number expected")
 		}
 	} else {
 		rows = 1
@@ -202,7 +520,7 @@ func (c *EngineContext) InsertRows(sheet, count, index value.Value) (*runtime.Wr
 		if o, ok := index.(value.Float); ok {
 			off = float64(o)
 		} else {
-			return nil, runtime.Mutation{}, fmt.Errorf("index: number expected")
+			return nil, runtime.Mutation{}, locale.Errorf("index: number expected")
 		}
 	} else {
 		off = float64(b.Height())
@@ -213,7 +531,7 @@ func (c *EngineContext) InsertRows(sheet, count, index value.Value) (*runtime.Wr
 func (c *EngineContext) InsertColumns(sheet, count, index value.Value) (*runtime.WritableRange, runtime.Mutation, error) {
 	view, ok := sheet.(*runtime.View)
 	if !ok {
-		return nil, runtime.Mutation{}, fmt.Errorf("view expected")
+		return nil, runtime.Mutation{}, locale.Errorf("view expected")
 	}
 	var (
 		cols float64
@@ -223,7 +541,7 @@ func (c *EngineContext) InsertColumns(sheet, count, index value.Value) (*runtime
 		if c, ok := count.(value.Float); ok {
 			cols = float64(c)
 		} else {
-			return nil, runtime.Mutation{}, fmt.Errorf("count: number expected")
+			return nil, runtime.Mutation{}, locale.Errorf("count: number expected")
 		}
 	} else {
 		cols = 1
@@ -232,7 +550,7 @@ func (c *EngineContext) InsertColumns(sheet, count, index value.Value) (*runtime
 		if o, ok := index.(value.Float); ok {
 			off = float64(o)
 		} else {
-			return nil, runtime.Mutation{}, fmt.Errorf("index: number expected")
+			return nil, runtime.Mutation{}, locale.Errorf("index: number expected")
 		}
 	} else {
 		b := view.Bounds()
@@ -244,7 +562,7 @@ func (c *EngineContext) InsertColumns(sheet, count, index value.Value) (*runtime
 func (c *EngineContext) RemoveRows(sheet, count, index value.Value) (runtime.Mutation, error) {
 	view, ok := sheet.(*runtime.View)
 	if !ok {
-		return runtime.Mutation{}, fmt.Errorf("view expected")
+		return runtime.Mutation{}, locale.Errorf("view expected")
 	}
 	var (
 		rows float64
@@ -254,7 +572,7 @@ func (c *EngineContext) RemoveRows(sheet, count, index value.Value) (runtime.Mut
 		if c, ok := count.(value.Float); ok {
 			rows = float64(c)
 		} else {
-			return runtime.Mutation{}, fmt.Errorf("count: number expected")
+			return runtime.Mutation{}, locale.Errorf("count: number expected")
 		}
 	} else {
 		rows = 1
@@ -264,7 +582,7 @@ func (c *EngineContext) RemoveRows(sheet, count, index value.Value) (runtime.Mut
 		if o, ok := index.(value.Float); ok {
 			off = float64(o)
 		} else {
-			return runtime.Mutation{}, fmt.Errorf("index: number expected")
+			return runtime.Mutation{}, locale.Errorf("index: number expected")
 		}
 	} else {
 		off = float64(b.Height())
@@ -275,7 +593,7 @@ func (c *EngineContext) RemoveRows(sheet, count, index value.Value) (runtime.Mut
 func (c *EngineContext) RemoveColumns(sheet, count, index value.Value) (runtime.Mutation, error) {
 	view, ok := sheet.(*runtime.View)
 	if !ok {
-		return runtime.Mutation{}, fmt.Errorf("view expected")
+		return runtime.Mutation{}, locale.Errorf("view expected")
 	}
 	var (
 		cols float64
@@ -285,7 +603,7 @@ func (c *EngineContext) RemoveColumns(sheet, count, index value.Value) (runtime.
 		if c, ok := count.(value.Float); ok {
 			cols = float64(c)
 		} else {
-			return runtime.Mutation{}, fmt.Errorf("count: number expected")
+			return runtime.Mutation{}, locale.Errorf("count: number expected")
 		}
 	} else {
 		cols = 1
@@ -294,7 +612,7 @@ func (c *EngineContext) RemoveColumns(sheet, count, index value.Value) (runtime.
 		if o, ok := index.(value.Float); ok {
 			off = float64(o)
 		} else {
-			return runtime.Mutation{}, fmt.Errorf("index: number expected")
+			return runtime.Mutation{}, locale.Errorf("index: number expected")
 		}
 	} else {
 		b := view.Bounds()
@@ -349,7 +667,7 @@ func (c *EngineContext) getView(name string) (*runtime.View, error) {
 	if v, ok := c.Default().(*runtime.View); ok {
 		return v, nil
 	}
-	return nil, fmt.Errorf("%s: view can not be found", name)
+	return nil, locale.Errorf("%s: view can not be found", name)
 }
 
 func (c *EngineContext) getViewFromFile(file *runtime.File, name string) (*runtime.View, error) {
@@ -375,20 +693,27 @@ func (c *EngineContext) getViewFromFile(file *runtime.File, name string) (*runti
 func (c *EngineContext) createFile(format string) (*runtime.File, error) {
 	var file grid.File
 	switch format {
-	case "oxml", "xlsx":
+	case "oxml", "xlsx", "xlsm":
 		file = oxml.NewFile()
 	case "ods":
 		file = ods.NewFile()
-	case "csv", "tsv":
+	case "csv", "tsv", "json", "xml":
+		file = flat.NewFile()
 	case "":
 		format = c.GetOptionString(slx.Make("export", "format"))
 		if format == "" {
-			return nil, fmt.Errorf("empty file can not be created for format %s", format)
+			return nil, locale.Errorf("empty file can not be created for format %s", format)
 		}
 		return c.createFile(format)
 	default:
-		return nil, fmt.Errorf("empty file can not be created for format %s", format)
+		return nil, locale.Errorf("empty file can not be created for format %s", format)
+	}
+	if sp, ok := file.(driver.Spiller); ok && c.budget != nil {
+		sp.SetBudget(c.budget)
 	}
+	c.setIteration(file)
+	c.setFreeze(file)
+	c.tracker.track(file)
 	tmp := runtime.NewFileValue(file, false)
 	return tmp.(*runtime.File), nil
 }
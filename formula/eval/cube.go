@@ -0,0 +1,165 @@
+package eval
+
+import (
+	"github.com/midbel/dockit/cube"
+	"github.com/midbel/dockit/flat"
+	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/formula/runtime"
+	"github.com/midbel/dockit/internal/locale"
+	"github.com/midbel/dockit/value"
+)
+
+func (v *evaluator) VisitCube(expr parse.Cube) error {
+	val, err := v.visitNormalize(expr.Source())
+	if err != nil {
+		return err
+	}
+	view, ok := val.(*runtime.View)
+	if !ok {
+		return locale.Errorf("cube can only be built from a view")
+	}
+	cb, err := buildCube(view, expr)
+	if err != nil {
+		return err
+	}
+	var aggrs []cube.Aggregate
+	for _, m := range expr.Measures() {
+		fn, err := cube.Lookup(m.Func)
+		if err != nil {
+			return err
+		}
+		aggrs = append(aggrs, cube.Aggregate{
+			Measure: m.Name,
+			Func:    fn,
+		})
+	}
+	for _, out := range expr.Outputs() {
+		var rows [][]value.Value
+		switch out.Kind {
+		case parse.CubeRollup:
+			rows, err = rollupRows(cb, out, expr.Measures(), aggrs)
+		case parse.CubePivot:
+			rows, err = pivotRows(cb, out, aggrs)
+		default:
+			err = locale.Errorf("unsupported cube output")
+		}
+		if err != nil {
+			return err
+		}
+		sheet := flat.NewSheet(out.Ident, rows)
+		v.ctx.Define(out.Ident, runtime.NewViewValue(sheet))
+	}
+	return nil
+}
+
+// buildCube registers the values of the columns used as dimensions and
+// measures of each row of the view.
+func buildCube(view *runtime.View, expr parse.Cube) (*cube.Cube, error) {
+	cb := cube.New()
+	for _, d := range expr.Dimensions() {
+		if err := cb.RegisterDimension(d.Name, nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range expr.Measures() {
+		if err := cb.RegisterMeasure(m.Name); err != nil {
+			return nil, err
+		}
+	}
+	for _, row := range view.View().Rows() {
+		var (
+			dims     []string
+			measures []value.ScalarValue
+		)
+		for _, d := range expr.Dimensions() {
+			dims = append(dims, columnAt(row, d.Column).String())
+		}
+		for _, m := range expr.Measures() {
+			val, ok := columnAt(row, m.Column).(value.ScalarValue)
+			if !ok {
+				val = value.ErrValue
+			}
+			measures = append(measures, val)
+		}
+		if err := cb.AddRow(dims, measures); err != nil {
+			return nil, err
+		}
+	}
+	return cb, nil
+}
+
+func rollupRows(cb *cube.Cube, out parse.CubeOutput, measures []parse.CubeMeasure, aggrs []cube.Aggregate) ([][]value.Value, error) {
+	groups, err := cb.Rollup(out.Dimensions, aggrs)
+	if err != nil {
+		return nil, err
+	}
+	var header []value.Value
+	for _, d := range out.Dimensions {
+		header = append(header, value.Text(d))
+	}
+	for _, m := range measures {
+		header = append(header, value.Text(m.Name))
+	}
+	rows := [][]value.Value{header}
+	for _, g := range groups {
+		row := make([]value.Value, 0, len(header))
+		for i := range out.Dimensions {
+			if i < len(g.Keys) {
+				row = append(row, value.Text(g.Keys[i]))
+			} else {
+				row = append(row, value.Empty())
+			}
+		}
+		for _, val := range g.Values {
+			row = append(row, val)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func pivotRows(cb *cube.Cube, out parse.CubeOutput, aggrs []cube.Aggregate) ([][]value.Value, error) {
+	var aggr []cube.Aggregate
+	for _, a := range aggrs {
+		if a.Measure == out.Measure {
+			aggr = append(aggr, a)
+		}
+	}
+	if len(aggr) == 0 {
+		return nil, locale.Errorf("%s: measure not found", out.Measure)
+	}
+	var (
+		lines   = out.Dimensions[:1]
+		columns = out.Dimensions[1:]
+	)
+	heads, err := cb.GroupBy(columns, nil)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := cb.GroupBy(out.Dimensions, aggr)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		header = []value.Value{value.Text(lines[0])}
+		index  = make(map[string]int)
+	)
+	for i, h := range heads {
+		header = append(header, value.Text(h.Keys[0]))
+		index[h.Keys[0]] = i + 1
+	}
+	rows := [][]value.Value{header}
+	for _, g := range groups {
+		last := rows[len(rows)-1]
+		if len(rows) == 1 || last[0].String() != g.Keys[0] {
+			last = make([]value.Value, len(header))
+			last[0] = value.Text(g.Keys[0])
+			for i := 1; i < len(last); i++ {
+				last[i] = value.Empty()
+			}
+			rows = append(rows, last)
+		}
+		last[index[g.Keys[1]]] = g.Values[0]
+	}
+	return rows, nil
+}
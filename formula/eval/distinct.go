@@ -0,0 +1,31 @@
+package eval
+
+import (
+	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/formula/runtime"
+	"github.com/midbel/dockit/gridx"
+	"github.com/midbel/dockit/internal/locale"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+// distinctForm removes the duplicate rows of a view. The rows are compared on
+// all their columns or only on the given columns, eg distinct(data, A;C).
+type distinctForm struct{}
+
+func (distinctForm) Run(eg Runnable, args []parse.Expr, ctx *EngineContext) (value.Value, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return value.ErrValue, locale.Errorf("distinct: view and optional columns expected")
+	}
+	view, err := viewArg(eg, "distinct", args[0])
+	if err != nil {
+		return value.ErrValue, err
+	}
+	var cols layout.Selection
+	if len(args) == 2 {
+		if cols, err = selectionArg("distinct", args[1]); err != nil {
+			return value.ErrValue, err
+		}
+	}
+	return runtime.NewViewValue(gridx.Distinct(view.View(), cols)), nil
+}
@@ -9,6 +9,10 @@ import (
 
 	"github.com/midbel/dockit/formula/env"
 	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/formula/runtime"
+	"github.com/midbel/dockit/grid"
+	gbs "github.com/midbel/dockit/grid/builtins"
+	"github.com/midbel/dockit/internal/locale"
 	"github.com/midbel/dockit/value"
 )
 
@@ -42,6 +46,8 @@ type Engine struct {
 
 	loaders map[string]Loader
 	writers map[string]Writer
+	views   map[string]grid.View
+	tracker *tracker
 }
 
 func NewEngine() *Engine {
@@ -50,15 +56,25 @@ func NewEngine() *Engine {
 		Stderr:  os.Stderr,
 		loaders: make(map[string]Loader),
 		writers: make(map[string]Writer),
+		views:   make(map[string]grid.View),
 		config:  NewConfig(),
+		tracker: newTracker(),
 	}
 	e.RegisterLoader(".csv", CsvLoader())
 	e.RegisterLoader(".xlsx", XlsxLoader())
+	e.RegisterLoader(".xlsm", XlsxLoader())
 	e.RegisterLoader(".ods", OdsLoader())
 	e.RegisterLoader(".log", LogLoader())
 	e.RegisterLoader(".json", JsonLoader())
 	e.RegisterLoader(".json5", Json5Loader())
 	e.RegisterLoader(".xml", XmlLoader())
+	e.RegisterWriter(".csv", CsvWriter())
+	e.RegisterWriter(".tsv", CsvWriter())
+	e.RegisterWriter(".json", JsonWriter())
+	e.RegisterWriter(".xml", XmlWriter())
+	e.RegisterWriter(".xlsx", XlsxWriter())
+	e.RegisterWriter(".xlsm", XlsxWriter())
+	e.RegisterWriter(".ods", OdsWriter())
 	return &e
 }
 
@@ -83,10 +99,66 @@ func (e *Engine) SetDateFormat(format string) {
 	e.config.Set(ConfigFormatDate, format)
 }
 
+func (e *Engine) SetCacheDir(dir string) {
+	if dir == "" {
+		return
+	}
+	e.config.Set(ConfigImportCacheDir, dir)
+}
+
+func (e *Engine) SetIncludePath(dir string) {
+	if dir == "" {
+		return
+	}
+	e.config.Set(ConfigIncludePath, dir)
+}
+
+// SetExternalPath gives the directories, separated like in PATH, where the
+// workbooks referenced by formulas are searched when they are not imported.
+func (e *Engine) SetExternalPath(path string) {
+	if path == "" {
+		return
+	}
+	e.config.Set(ConfigExternalPath, path)
+}
+
+// SetIteration enables the iterative calculation of the formulas involved in
+// circular references. A zero count disables it.
+func (e *Engine) SetIteration(it grid.Iteration) {
+	e.config.Set(ConfigCalcIterate, it.Count > 0)
+	if it.Count > 0 {
+		e.config.Set(ConfigCalcIterations, float64(it.Count))
+		e.config.Set(ConfigCalcDelta, it.Delta)
+	}
+}
+
+// SetFreeze makes the volatile formulas, like NOW or RAND, keep the value given
+// by their first evaluation so that the exported files are the same at each
+// run of a script.
+func (e *Engine) SetFreeze(freeze bool) {
+	e.config.Set(ConfigCalcFreeze, freeze)
+}
+
+// SetLoopLimit gives the number of iterations after which a while loop is
+// stopped with an error. A zero limit lets while loops run without limit.
+func (e *Engine) SetLoopLimit(limit int) {
+	e.config.Set(ConfigLoopLimit, float64(max(limit, 0)))
+}
+
+// SetExportNames makes the names defined by the scripts part of the workbooks
+// they export, when the format of the workbooks supports them.
+func (e *Engine) SetExportNames(export bool) {
+	e.config.Set(ConfigExportNames, export)
+}
+
 func (e *Engine) SetPrintDebug(debug bool) {
 	e.config.Set(ConfigPrintDebug, debug)
 }
 
+func (e *Engine) SetPrintPlain(plain bool) {
+	e.config.Set(ConfigPrintPlain, plain)
+}
+
 func (e *Engine) RegisterLoader(kind string, loader Loader) {
 	e.loaders[kind] = loader
 }
@@ -95,30 +167,88 @@ func (e *Engine) RegisterWriter(kind string, writer Writer) {
 	e.writers[kind] = writer
 }
 
+// RegisterView makes the view available to the scripts under the given alias.
+// Combined with grid.NewVirtualView, it gives scripts access to the data of
+// the application embedding the engine.
+func (e *Engine) RegisterView(alias string, view grid.View) {
+	e.views[alias] = view
+}
+
+// RegisterFunction makes a Go function callable by the scripts and by the
+// formulas of the cells under the given name. The function receives the values
+// of its arguments and the context of the caller. A negative arity accepts any
+// number of arguments.
+//
+// Functions are shared by all the engines: names of builtins or of functions
+// already registered can not be used.
+func (e *Engine) RegisterFunction(name string, arity int, fn gbs.ContextFunc) error {
+	var params []gbs.Param
+	if arity < 0 {
+		params = append(params, gbs.Var(gbs.ScalarArray("args", "", value.TypeAny)))
+	}
+	for i := range arity {
+		params = append(params, gbs.ScalarArray(fmt.Sprintf("arg%d", i+1), "", value.TypeAny))
+	}
+	b := gbs.Builtin{
+		Name:     name,
+		Category: "user",
+		Params:   params,
+		Handler:  fn,
+		Dialect:  gbs.MainDialect,
+	}
+	return gbs.Register(b)
+}
+
 func (e *Engine) Exec(r io.Reader, environ *env.Environment) (value.Value, error) {
+	// only the workbooks of the last script are tracked
+	e.tracker.reset()
+	ctx := e.newContext(environ)
+	return e.exec(r, ctx)
+}
+
+func (e *Engine) newContext(environ *env.Environment) *EngineContext {
 	ctx := NewEngineContext()
 	ctx.loaders = maps.Clone(e.loaders)
 	ctx.writers = maps.Clone(e.writers)
+	ctx.stdout = e.Stdout
+	ctx.stderr = e.Stderr
+	ctx.report = newReport()
+	ctx.books = newExternalBooks(e.Stderr)
+	ctx.tracker = e.tracker
+	ctx.names = newDefinedNames()
+	ctx.sources = newSourceFiles()
+	ctx.defaults = new(defaultStack)
 	ctx.setEnv(environ)
+	for alias, view := range e.views {
+		ctx.Define(alias, runtime.NewViewValue(view))
+	}
+	return ctx
+}
+
+func (e *Engine) exec(r io.Reader, ctx *EngineContext) (value.Value, error) {
+	expr, err := e.parse(r, ctx, e.config)
+	if err != nil {
+		return nil, err
+	}
+	return evalScript(ctx).Run(expr)
+}
 
-	ps, err := e.bootstrap(r, ctx)
+// parse configures the context with the configuration given and the pragmas
+// of the script before parsing it.
+func (e *Engine) parse(r io.Reader, ctx *EngineContext, base *EngineConfig) (parse.Expr, error) {
+	ps, err := e.bootstrap(r, ctx, base)
 	if err != nil {
 		return nil, err
 	}
 	switch ps.Mode() {
-	case parse.ModeScript:
-		expr, err := ps.Parse()
-		if err != nil {
-			return nil, err
-		}
-		eval := evalScript(ctx)
-		return eval.Run(expr)
+	case parse.ModeScript, parse.ModeCube:
+		return ps.Parse()
 	default:
-		return nil, fmt.Errorf("%s: unuspported mode", ps.Mode())
+		return nil, locale.Errorf("%s: unsupported mode", ps.Mode())
 	}
 }
 
-func (e *Engine) bootstrap(r io.Reader, ctx *EngineContext) (*parse.Parser, error) {
+func (e *Engine) bootstrap(r io.Reader, ctx *EngineContext, base *EngineConfig) (*parse.Parser, error) {
 	scan, err := parse.ScanScript(r)
 	if err != nil {
 		return nil, err
@@ -132,7 +262,7 @@ func (e *Engine) bootstrap(r io.Reader, ctx *EngineContext) (*parse.Parser, erro
 		return nil, err
 	}
 	cfg := NewConfig()
-	cfg.Merge(e.config)
+	cfg.Merge(base)
 	for _, e := range entries {
 		cfg.Set(e.Path, e.Value)
 	}
@@ -0,0 +1,101 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/internal/locale"
+)
+
+// externalBooks keeps the workbooks referenced by formulas, eg
+// [Book2.xlsx]Sheet1!A1. Files imported by the script are known by their base
+// name. Other workbooks are loaded from the search path the first time they
+// are referenced. Workbooks that can not be found are reported once.
+type externalBooks struct {
+	mu     sync.Mutex
+	files  map[string]grid.File
+	failed map[string]error
+	stderr io.Writer
+}
+
+func newExternalBooks(stderr io.Writer) *externalBooks {
+	return &externalBooks{
+		files:  make(map[string]grid.File),
+		failed: make(map[string]error),
+		stderr: stderr,
+	}
+}
+
+func (b *externalBooks) add(name string, file grid.File) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	name = filepath.Base(name)
+	b.files[name] = file
+	delete(b.failed, name)
+}
+
+func (b *externalBooks) get(name string, load func(string) (grid.File, error)) (grid.File, error) {
+	if b == nil {
+		return load(name)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if f, ok := b.files[name]; ok {
+		return f, nil
+	}
+	if err, ok := b.failed[name]; ok {
+		return nil, err
+	}
+	f, err := load(name)
+	if err != nil {
+		b.failed[name] = err
+		if b.stderr != nil {
+			fmt.Fprintf(b.stderr, "warning: %s: references give #REF!\n", err)
+		}
+		return nil, err
+	}
+	b.files[name] = f
+	return f, nil
+}
+
+// Book gives the workbook referenced by the external references of formulas.
+func (c *EngineContext) Book(name string) (grid.File, error) {
+	return c.books.get(name, c.openExternal)
+}
+
+// openExternal loads a workbook from the first directory of the search path
+// where it is found. The search path defaults to the context directory.
+func (c *EngineContext) openExternal(name string) (grid.File, error) {
+	if filepath.Base(name) != name {
+		return nil, locale.Errorf("%s: invalid name for external workbook", name)
+	}
+	var dirs []string
+	if c.config != nil {
+		dirs = filepath.SplitList(c.GetOptionString(ConfigExternalPath))
+	}
+	if len(dirs) == 0 {
+		dirs = append(dirs, c.contextDir)
+	}
+	for _, dir := range dirs {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(c.contextDir, dir)
+		}
+		file := filepath.Join(dir, name)
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		format := strings.TrimPrefix(filepath.Ext(name), ".")
+		return c.openFile(file, c.loaderOptions(format, "", nil))
+	}
+	return nil, locale.Errorf("%s: external workbook can not be found", name)
+}
@@ -0,0 +1,24 @@
+package eval
+
+import (
+	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/internal/locale"
+	"github.com/midbel/dockit/value"
+)
+
+// filterForm keeps the rows of a view matching a predicate. The predicate is
+// written as in the slices of views, eg filter(data, C > 0), or is the name of
+// a filter or of a function.
+type filterForm struct{}
+
+func (filterForm) Run(eg Runnable, args []parse.Expr, ctx *EngineContext) (value.Value, error) {
+	if len(args) != 2 {
+		return value.ErrValue, locale.Errorf("filter: view and predicate expected")
+	}
+	switch args[1].(type) {
+	case parse.Binary, parse.And, parse.Or, parse.Not, parse.Call, parse.Identifier:
+	default:
+		return value.ErrValue, locale.Errorf("filter: predicate expected")
+	}
+	return eg.Run(parse.NewSlice(args[0], args[1]))
+}
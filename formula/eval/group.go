@@ -0,0 +1,56 @@
+package eval
+
+import (
+	"github.com/midbel/dockit/flat"
+	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/formula/runtime"
+	"github.com/midbel/dockit/gridx"
+	"github.com/midbel/dockit/internal/locale"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+// VisitGroupBy gives a view whose first row has the names of the key columns
+// and of the aggregates followed by a row for each group in the order of
+// their first row.
+func (v *evaluator) VisitGroupBy(expr parse.GroupBy) error {
+	val, err := v.visitNormalize(expr.View())
+	if err != nil {
+		return err
+	}
+	if file, ok := val.(*runtime.File); ok {
+		val, _ = file.Active()
+	}
+	view, ok := val.(*runtime.View)
+	if !ok {
+		return locale.Errorf("group: view expected")
+	}
+	var (
+		header []value.Value
+		keys   []layout.Selection
+		aggrs  []gridx.Aggr
+	)
+	for _, k := range expr.Keys() {
+		keys = append(keys, layout.SelectSingle(k.Column))
+		header = append(header, value.Text(k.Name))
+	}
+	for _, a := range expr.Aggregates() {
+		fn, err := gridx.NewAggregator(a.Func)
+		if err != nil {
+			return err
+		}
+		aggrs = append(aggrs, *gridx.NewAggr(a.Column, fn))
+		header = append(header, value.Text(a.Name))
+	}
+	grouped, err := gridx.Group(view.View(), layout.Combine(keys...), aggrs)
+	if err != nil {
+		return err
+	}
+	rows := [][]value.Value{header}
+	for _, row := range grouped.Rows() {
+		rows = append(rows, row)
+	}
+	sheet := flat.NewSheet(view.View().Name(), rows)
+	v.pushValue(runtime.NewViewValue(sheet))
+	return nil
+}
@@ -0,0 +1,86 @@
+package eval
+
+import (
+	"slices"
+
+	"github.com/midbel/dockit/formula/env"
+	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/internal/locale"
+	"github.com/midbel/dockit/value"
+)
+
+// module gives access to the names defined by a script included with an
+// alias.
+type module struct {
+	name string
+	env  *env.Environment
+}
+
+func (*module) Type() string {
+	return "module"
+}
+
+func (*module) Kind() value.ValueKind {
+	return value.KindObject
+}
+
+func (m *module) String() string {
+	return m.name
+}
+
+func (m *module) Get(ident string) value.Value {
+	return m.env.Resolve(ident)
+}
+
+func (v *evaluator) VisitIncludeFile(expr parse.IncludeFile) error {
+	if !expr.Isolated() {
+		return v.include(expr.File(), v.ctx.env)
+	}
+	mod := module{
+		name: expr.Alias(),
+		env:  env.Empty(),
+	}
+	if err := v.include(expr.File(), mod.env); err != nil {
+		return err
+	}
+	v.ctx.Define(mod.name, &mod)
+	return nil
+}
+
+// include executes the script of the given file in the given environment.
+// Scripts already being included are rejected to break cycles.
+func (v *evaluator) include(file string, environ *env.Environment) error {
+	path := v.ctx.resolveModule(file)
+	if slices.Contains(v.ctx.modules, path) {
+		return locale.Errorf("%s: include cycle detected", file)
+	}
+	r, err := v.ctx.OpenModule(file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	scan, err := parse.ScanScript(r)
+	if err != nil {
+		return err
+	}
+	ps, err := parse.NewParser(scan)
+	if err != nil {
+		return err
+	}
+	expr, err := ps.Parse()
+	if err != nil {
+		return locale.Errorf("%s: %w", file, err)
+	}
+
+	parent := v.ctx.env
+	v.ctx.modules = append(v.ctx.modules, path)
+	v.ctx.setEnv(environ)
+	defer func() {
+		v.ctx.modules = v.ctx.modules[:len(v.ctx.modules)-1]
+		v.ctx.setEnv(parent)
+	}()
+
+	_, err = evalScript(v.ctx).Run(expr)
+	return err
+}
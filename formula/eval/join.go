@@ -0,0 +1,95 @@
+package eval
+
+import (
+	"strings"
+
+	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/formula/runtime"
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/gridx"
+	"github.com/midbel/dockit/internal/locale"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+// joinForm combines the rows of two views having the same keys. The options
+// are given as assignments after the views:
+//
+//	join(left, right, on := A, mode := left)
+//	join(left, right, left := A, right := B)
+//
+// on gives the key columns of both views, left and right give the key columns
+// of each view when they differ. mode is inner (the default) or left to keep
+// the rows of the left view without any match.
+type joinForm struct{}
+
+func (joinForm) Run(eg Runnable, args []parse.Expr, ctx *EngineContext) (value.Value, error) {
+	if len(args) < 3 {
+		return value.ErrValue, locale.Errorf("join: views and keys expected")
+	}
+	left, err := viewArg(eg, "join", args[0])
+	if err != nil {
+		return value.ErrValue, err
+	}
+	right, err := viewArg(eg, "join", args[1])
+	if err != nil {
+		return value.ErrValue, err
+	}
+	var (
+		leftcols  layout.Selection
+		rightcols layout.Selection
+		outer     bool
+	)
+	for _, a := range args[2:] {
+		name, expr, err := optionArg("join", a)
+		if err != nil {
+			return value.ErrValue, err
+		}
+		switch name {
+		case "on":
+			leftcols, err = selectionArg("join", expr)
+			rightcols = leftcols
+		case "left":
+			leftcols, err = selectionArg("join", expr)
+		case "right":
+			rightcols, err = selectionArg("join", expr)
+		case "mode":
+			outer, err = joinMode(expr)
+		default:
+			return value.ErrValue, locale.Errorf("join: %s: unknown option", name)
+		}
+		if err != nil {
+			return value.ErrValue, err
+		}
+	}
+	if leftcols == nil || rightcols == nil {
+		return value.ErrValue, locale.Errorf("join: key columns expected")
+	}
+	var view grid.View
+	if outer {
+		view = gridx.LeftJoin(left.View(), right.View(), leftcols, rightcols)
+	} else {
+		view = gridx.Join(left.View(), right.View(), leftcols, rightcols)
+	}
+	return runtime.NewViewValue(view), nil
+}
+
+func joinMode(expr parse.Expr) (bool, error) {
+	var str string
+	switch e := expr.(type) {
+	case parse.Identifier:
+		str = e.Ident()
+	case parse.Literal:
+		str = e.Text()
+	default:
+		return false, locale.Errorf("join: %s: invalid mode", expr)
+	}
+	switch strings.ToLower(str) {
+	case "inner":
+		return false, nil
+	case "left":
+		return true, nil
+	default:
+		return false, locale.Errorf("join: %s: invalid mode", str)
+	}
+}
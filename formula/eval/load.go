@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 
 	"github.com/midbel/codecs/json"
 	"github.com/midbel/codecs/xml"
 	"github.com/midbel/dockit/csv"
 	"github.com/midbel/dockit/flat"
 	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/internal/locale"
 	"github.com/midbel/dockit/ods"
 	"github.com/midbel/dockit/oxml"
 	"github.com/midbel/dockit/value"
@@ -31,8 +33,18 @@ func (o LoaderOptions) getAsString(key string) string {
 	return s
 }
 
+func (o LoaderOptions) getAsBool(key string, def bool) bool {
+	b, err := strconv.ParseBool(o.getAsString(key))
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// Writer writes a file in a given format. Options are given by the with
+// clause of the export statement.
 type Writer interface {
-	Write(string, grid.File) error
+	Write(string, grid.File, LoaderOptions) error
 }
 
 type logLoader struct{}
@@ -52,8 +64,44 @@ func CsvLoader() Loader {
 	return csvLoader{}
 }
 
-func (c csvLoader) Write(out string, file grid.File) error {
-	return nil
+func CsvWriter() Writer {
+	return csvLoader{}
+}
+
+// Write writes the cells of the active sheet of the file, or of the sheet
+// given by the options.
+func (c csvLoader) Write(out string, file grid.File, opts LoaderOptions) error {
+	sh, err := exportedSheet(file, opts)
+	if err != nil {
+		return err
+	}
+	w, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	ws := csv.NewWriter(w)
+	if delim := csvDelimiter(opts.getAsString("delimiter")); len(delim) == 1 {
+		ws.Comma = delim[0]
+	} else {
+		return locale.Errorf("%s: invalid delimiter", delim)
+	}
+	ws.ForceQuote = opts.getAsBool("quoted", false)
+	for _, row := range sh.Rows() {
+		line := make([]string, 0, len(row))
+		for _, v := range row {
+			if v == nil {
+				v = value.Empty()
+			}
+			line = append(line, v.String())
+		}
+		if err := ws.Write(line); err != nil {
+			return err
+		}
+	}
+	ws.Flush()
+	return ws.Error()
 }
 
 func (c csvLoader) Open(file string, opts LoaderOptions) (grid.File, error) {
@@ -87,7 +135,7 @@ func (c csvLoader) createReader(file string, opts LoaderOptions) (*csv.Reader, e
 		}
 		rs.Comma = delim
 	default:
-		return nil, fmt.Errorf("unsupported csv delimiter %q", delim)
+		return nil, locale.Errorf("unsupported csv delimiter %q", delim)
 	}
 	return rs, nil
 }
@@ -127,7 +175,7 @@ func (j structuredLoader) Open(file string, opts LoaderOptions) (grid.File, erro
 	for i, set := range result.Sets {
 		arr, ok := set.([]any)
 		if !ok {
-			return nil, fmt.Errorf("expected array")
+			return nil, locale.Errorf("expected array")
 		}
 		values, err := j.processData(arr)
 		if err != nil {
@@ -144,7 +192,7 @@ func (structuredLoader) processData(arr []any) ([][]value.Value, error) {
 	for i := range arr {
 		vs, ok := arr[i].([]any)
 		if !ok {
-			return nil, fmt.Errorf("array expected")
+			return nil, locale.Errorf("array expected")
 		}
 		row := make([]value.Value, 0, len(vs))
 		for j := range vs {
@@ -159,7 +207,7 @@ func (structuredLoader) processData(arr []any) ([][]value.Value, error) {
 			case nil:
 				x = value.ErrNA
 			default:
-				return nil, fmt.Errorf("unexpected type from json array")
+				return nil, locale.Errorf("unexpected type from json array")
 			}
 			row = append(row, x)
 		}
@@ -205,13 +253,21 @@ func XlsxLoader() Loader {
 	return xlsxLoader{}
 }
 
-func (x xlsxLoader) Write(out string, file grid.File) error {
-	w, err := os.Create(out)
-	if err != nil {
-		return err
+func XlsxWriter() Writer {
+	return xlsxLoader{}
+}
+
+// Write writes the file as a xlsx workbook. Sheets of files in other formats
+// are copied in a new workbook.
+func (x xlsxLoader) Write(out string, file grid.File, _ LoaderOptions) error {
+	wb, ok := file.(*oxml.File)
+	if !ok {
+		wb = oxml.NewFile()
+		if err := wb.Merge(file); err != nil {
+			return err
+		}
 	}
-	defer w.Close()
-	return nil
+	return wb.WriteFile(out)
 }
 
 func (xlsxLoader) Open(file string, opts LoaderOptions) (grid.File, error) {
@@ -224,13 +280,21 @@ func OdsLoader() Loader {
 	return odsLoader{}
 }
 
-func (odsLoader) Write(out string, file grid.File) error {
-	w, err := os.Create(out)
-	if err != nil {
-		return err
+func OdsWriter() Writer {
+	return odsLoader{}
+}
+
+// Write writes the file as an ods document. Sheets of files in other formats
+// are copied in a new document.
+func (odsLoader) Write(out string, file grid.File, _ LoaderOptions) error {
+	doc, ok := file.(*ods.File)
+	if !ok {
+		doc = ods.NewFile()
+		if err := doc.Merge(file); err != nil {
+			return err
+		}
 	}
-	defer w.Close()
-	return nil
+	return doc.WriteFile(out)
 }
 
 func (odsLoader) Open(file string, opts LoaderOptions) (grid.File, error) {
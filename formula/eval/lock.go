@@ -0,0 +1,148 @@
+package eval
+
+import (
+	"github.com/midbel/dockit/formula/runtime"
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/internal/locale"
+	"github.com/midbel/dockit/oxml"
+	"github.com/midbel/dockit/value"
+)
+
+// protectedOperations gives the operations that can be left unprotected by
+// the options of the lock statement, e.g. lock data with (sort := "false").
+var protectedOperations = map[string]oxml.SheetProtection{
+	"format":  oxml.ProtectedFormatCells | oxml.ProtectedFormatColumns | oxml.ProtectedFormatRows,
+	"rows":    oxml.ProtectedInsertRows | oxml.ProtectedDeleteRows,
+	"columns": oxml.ProtectedInsertColumns | oxml.ProtectedDeleteColumns,
+	"sort":    oxml.ProtectedSort,
+}
+
+type protection struct {
+	password string
+	flags    oxml.SheetProtection
+	partial  bool
+}
+
+func protectionOf(opts LoaderOptions) protection {
+	p := protection{
+		password: opts.getAsString("password"),
+		flags:    oxml.ProtectedAll - 1,
+	}
+	for key, flag := range protectedOperations {
+		if !opts.getAsBool(key, true) {
+			p.flags &^= flag
+			p.partial = true
+		}
+	}
+	return p
+}
+
+// Lock protects all the sheets of a file or a single view. Files are locked
+// like the lock command does: their structure is protected too.
+func (c *EngineContext) Lock(val value.Value, opts LoaderOptions) error {
+	p := protectionOf(opts)
+	switch v := val.(type) {
+	case *runtime.File:
+		if v.ReadOnly() {
+			return locale.Errorf("workbook imported as read only can not be locked")
+		}
+		return lockFile(v.File(), p)
+	case *runtime.View:
+		return lockView(v.View(), p)
+	default:
+		return locale.Errorf("value can not be locked")
+	}
+}
+
+// Unlock removes the protection of all the sheets of a file or of a single
+// view. The password is checked when the protection has one.
+func (c *EngineContext) Unlock(val value.Value, opts LoaderOptions) error {
+	password := opts.getAsString("password")
+	switch v := val.(type) {
+	case *runtime.File:
+		if v.ReadOnly() {
+			return locale.Errorf("workbook imported as read only can not be unlocked")
+		}
+		return unlockFile(v.File(), password)
+	case *runtime.View:
+		return unlockView(v.View(), password)
+	default:
+		return locale.Errorf("value can not be unlocked")
+	}
+}
+
+func lockFile(file grid.File, p protection) error {
+	if p.partial {
+		for _, sh := range file.Sheets() {
+			if err := lockView(sh, p); err != nil {
+				return err
+			}
+		}
+		if k, ok := file.(interface{ LockStructure(string) error }); ok {
+			return k.LockStructure(p.password)
+		}
+		return nil
+	}
+	if p.password != "" {
+		k, ok := file.(interface{ LockWithPassword(string) error })
+		if !ok {
+			return locale.Errorf("password protection %w", grid.ErrSupported)
+		}
+		return k.LockWithPassword(p.password)
+	}
+	k, ok := file.(interface{ Lock() })
+	if !ok {
+		return locale.Errorf("workbook can not be locked")
+	}
+	k.Lock()
+	return nil
+}
+
+func lockView(view grid.View, p protection) error {
+	if p.partial {
+		k, ok := view.(interface {
+			Protect(oxml.SheetProtection, string) error
+		})
+		if !ok {
+			return locale.Errorf("partial protection %w", grid.ErrSupported)
+		}
+		return k.Protect(p.flags, p.password)
+	}
+	if p.password != "" {
+		k, ok := view.(interface{ LockWithPassword(string) error })
+		if !ok {
+			return locale.Errorf("password protection %w", grid.ErrSupported)
+		}
+		return k.LockWithPassword(p.password)
+	}
+	k, ok := view.(interface{ Lock() })
+	if !ok {
+		return locale.Errorf("%s: view can not be locked", view.Name())
+	}
+	k.Lock()
+	return nil
+}
+
+func unlockFile(file grid.File, password string) error {
+	if k, ok := file.(interface{ UnlockWithPassword(string) error }); ok {
+		return k.UnlockWithPassword(password)
+	}
+	k, ok := file.(interface{ Unlock() })
+	if !ok {
+		return locale.Errorf("workbook can not be unlocked")
+	}
+	k.Unlock()
+	return nil
+}
+
+func unlockView(view grid.View, password string) error {
+	if k, ok := view.(interface{ UnlockWithPassword(string) error }); ok {
+		return k.UnlockWithPassword(password)
+	}
+	k, ok := view.(interface{ Unlock() })
+	if !ok {
+		return locale.Errorf("%s: view can not be unlocked", view.Name())
+	}
+	k.Unlock()
+	return nil
+}
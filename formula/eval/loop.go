@@ -0,0 +1,85 @@
+package eval
+
+import (
+	"errors"
+	"iter"
+
+	"github.com/midbel/dockit/formula/runtime"
+	"github.com/midbel/dockit/internal/locale"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+// DefaultLoopLimit is the number of iterations after which a while loop is
+// stopped unless another limit is configured.
+const DefaultLoopLimit = 100000
+
+var (
+	errBreak    = errors.New("break statement outside of a loop")
+	errContinue = errors.New("continue statement outside of a loop")
+)
+
+// loopLimit gives the maximum number of iterations of a while loop. A zero
+// limit means that loops are not limited.
+func (c *EngineContext) loopLimit() int {
+	n, _ := c.GetOption(ConfigLoopLimit).(float64)
+	return int(n)
+}
+
+// loopValues gives the items a for loop iterates over: the rows of a view,
+// each as a view of a single row, the sheets of a file or the values of an
+// array from left to right and from top to bottom.
+func loopValues(val value.Value) (iter.Seq[value.Value], error) {
+	switch v := val.(type) {
+	case *runtime.View:
+		return viewRows(v), nil
+	case *runtime.File:
+		return fileSheets(v), nil
+	case value.ArrayValue:
+		return arrayValues(v), nil
+	default:
+		return nil, locale.Errorf("%s: value can not be iterated", val)
+	}
+}
+
+func viewRows(view *runtime.View) iter.Seq[value.Value] {
+	return func(yield func(value.Value) bool) {
+		bd := view.Bounds()
+		for line := bd.Starts.Line; line <= bd.Ends.Line; line++ {
+			var (
+				start = layout.NewPosition(line, bd.Starts.Column)
+				end   = layout.NewPosition(line, bd.Ends.Column)
+			)
+			if !yield(view.BoundedView(layout.NewRange(start, end))) {
+				return
+			}
+		}
+	}
+}
+
+func fileSheets(file *runtime.File) iter.Seq[value.Value] {
+	return func(yield func(value.Value) bool) {
+		for _, sh := range file.File().Sheets() {
+			v, err := file.Sheet(sh.Name())
+			if err != nil {
+				continue
+			}
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func arrayValues(arr value.ArrayValue) iter.Seq[value.Value] {
+	return func(yield func(value.Value) bool) {
+		dim := arr.Dimension()
+		for i := range int(dim.Lines) {
+			for j := range int(dim.Columns) {
+				if !yield(arr.At(i, j)) {
+					return
+				}
+			}
+		}
+	}
+}
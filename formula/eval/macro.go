@@ -0,0 +1,115 @@
+package eval
+
+import (
+	"strings"
+
+	"github.com/midbel/dockit/formula/builtins"
+	"github.com/midbel/dockit/formula/env"
+	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/internal/locale"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+// userFunc is a function defined by a script with the def statement. It is
+// stored in the environment of the script like any other value.
+//
+// Its body is executed in its own scope, enclosed by the environment where
+// the function is defined. The value of the last expression evaluated by the
+// body is the result of the function.
+type userFunc struct {
+	def   parse.Macro
+	scope *env.Environment
+	eval  *evaluator
+}
+
+func (*userFunc) Type() string {
+	return "function"
+}
+
+func (*userFunc) Kind() value.ValueKind {
+	return value.KindFunction
+}
+
+func (m *userFunc) String() string {
+	return m.def.Name()
+}
+
+// Call executes the body of the function with the given arguments.
+func (m *userFunc) Call(args []value.Value) (value.Value, error) {
+	params := m.def.Params()
+	if len(args) != len(params) {
+		return value.ErrValue, locale.Errorf("%s: %d arguments expected, got %d", m.def.Name(), len(params), len(args))
+	}
+	var (
+		parent = m.eval.ctx.env
+		local  = env.Isolated(m.scope)
+		size   = m.eval.stack.Len()
+		result value.Value
+	)
+	defer m.eval.ctx.setEnv(parent)
+
+	for i := range params {
+		local.Declare(params[i], args[i])
+	}
+	m.eval.ctx.setEnv(local)
+	for _, e := range m.def.Body() {
+		if err := m.eval.visitExpr(e); err != nil {
+			return value.ErrValue, err
+		}
+		for m.eval.stack.Len() > size {
+			val, err := m.eval.normalize(m.eval.popValue())
+			if err != nil {
+				return value.ErrValue, err
+			}
+			result = val
+		}
+	}
+	if result == nil {
+		result = value.Empty()
+	}
+	return result, nil
+}
+
+// Test makes functions usable as predicates of slices. The parameters of the
+// function are given the values of the columns of the row in order.
+func (m *userFunc) Test(ctx value.Context) bool {
+	var args []value.Value
+	for i := range m.def.Params() {
+		pos := layout.NewPosition(1, int64(i+1))
+		args = append(args, ctx.At(pos))
+	}
+	val, err := m.Call(args)
+	return err == nil && value.True(val)
+}
+
+func (v *evaluator) callUserFunc(fn *userFunc, list []parse.Expr) error {
+	var args []value.Value
+	for _, a := range list {
+		arg, err := v.visitNormalize(a)
+		if err != nil {
+			return err
+		}
+		args = append(args, arg)
+	}
+	val, err := fn.Call(args)
+	v.pushValue(val)
+	return err
+}
+
+func (v *evaluator) VisitMacro(expr parse.Macro) error {
+	name := expr.Name()
+	if _, ok := specials[strings.ToLower(name)]; ok {
+		return locale.Errorf("%s: builtin already defined", name)
+	}
+	if _, err := builtins.Lookup(name, v.ctx); err == nil {
+		return locale.Errorf("%s: builtin already defined", name)
+	}
+	fn := userFunc{
+		def:   expr,
+		scope: v.ctx.env,
+		eval:  v,
+	}
+	v.ctx.Define(name, &fn)
+	return nil
+}
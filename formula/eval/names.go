@@ -0,0 +1,114 @@
+package eval
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/midbel/dockit/driver"
+	"github.com/midbel/dockit/formula/runtime"
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/internal/locale"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+// definedNames keeps the ranges named by the define statements of a script.
+// Names refer to the cells of the view where they are defined and take
+// precedence over the variables with the same name.
+type definedNames struct {
+	mu     sync.Mutex
+	ranges map[string]namedRange
+}
+
+type namedRange struct {
+	view *runtime.View
+	rg   *layout.Range
+}
+
+func newDefinedNames() *definedNames {
+	return &definedNames{
+		ranges: make(map[string]namedRange),
+	}
+}
+
+func (n *definedNames) define(name string, nr namedRange) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.ranges[name] = nr
+}
+
+func (n *definedNames) get(name string) (namedRange, bool) {
+	if n == nil {
+		return namedRange{}, false
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	nr, ok := n.ranges[name]
+	return nr, ok
+}
+
+func (n *definedNames) all() map[string]namedRange {
+	if n == nil {
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return maps.Clone(n.ranges)
+}
+
+// DefineName gives a name to a range of cells of the view.
+func (c *EngineContext) DefineName(name string, view *runtime.View, rg *layout.Range) error {
+	if c.names == nil {
+		return locale.Errorf("%s: names can not be defined", name)
+	}
+	if layout.IsAddress(name) {
+		return locale.Errorf("%s: address can not be used as name", name)
+	}
+	nr := namedRange{
+		view: view,
+		rg:   layout.NewRange(rg.Starts.WithoutSheet(), rg.Ends.WithoutSheet()),
+	}
+	c.names.define(name, nr)
+	return nil
+}
+
+func (c *EngineContext) resolveName(name string) (value.Value, bool) {
+	nr, ok := c.names.get(name)
+	if !ok {
+		return nil, false
+	}
+	if nr.rg.Starts.Equal(nr.rg.Ends) {
+		return nr.view.At(nr.rg.Starts), true
+	}
+	return nr.view.Range(nr.rg.Starts, nr.rg.Ends), true
+}
+
+// exportNames adds the names referring to the sheets of the file to its
+// defined names when it supports them.
+func (c *EngineContext) exportNames(file grid.File) error {
+	namer, ok := file.(driver.Namer)
+	if !ok {
+		return nil
+	}
+	names := c.names.all()
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		var (
+			nr    = names[name]
+			sheet = nr.view.Name()
+		)
+		if _, err := file.Sheet(sheet); err != nil {
+			continue
+		}
+		rg := layout.NewRange(nr.rg.Starts.WithSheet(sheet), nr.rg.Ends.WithSheet(sheet))
+		if err := namer.DefineName(name, rg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *EngineContext) exportNamesEnabled() bool {
+	b, _ := c.GetOption(ConfigExportNames).(bool)
+	return b
+}
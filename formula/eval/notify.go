@@ -0,0 +1,155 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/internal/locale"
+)
+
+const notifyTimeout = 10 * time.Second
+
+// runReport records what a script has done. It is shared by all the contexts
+// created while running the script and can be updated by parallel blocks.
+type runReport struct {
+	mu sync.Mutex
+
+	started  time.Time
+	imports  []string
+	outputs  []string
+	rows     int64
+	asserts  int
+	failures int
+}
+
+func newReport() *runReport {
+	return &runReport{
+		started: time.Now(),
+	}
+}
+
+func (r *runReport) imported(file string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.imports = append(r.imports, file)
+}
+
+func (r *runReport) exported(file grid.File, target string) {
+	if r == nil {
+		return
+	}
+	var rows int64
+	for _, v := range file.Sheets() {
+		rows += v.Bounds().Height()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outputs = append(r.outputs, target)
+	r.rows += rows
+}
+
+func (r *runReport) asserted(ok bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.asserts++
+	if !ok {
+		r.failures++
+	}
+}
+
+type reportSummary struct {
+	Elapsed    string   `json:"elapsed"`
+	Imports    []string `json:"imports"`
+	Outputs    []string `json:"outputs"`
+	Rows       int64    `json:"rows"`
+	Assertions int      `json:"assertions"`
+	Failures   int      `json:"failures"`
+}
+
+func (r *runReport) summary() reportSummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return reportSummary{
+		Elapsed:    time.Since(r.started).Round(time.Millisecond).String(),
+		Imports:    append([]string{}, r.imports...),
+		Outputs:    append([]string{}, r.outputs...),
+		Rows:       r.rows,
+		Assertions: r.asserts,
+		Failures:   r.failures,
+	}
+}
+
+func (s reportSummary) String() string {
+	var str strings.Builder
+	fmt.Fprintf(&str, "dockit: %d row(s) written to %d output(s)", s.Rows, len(s.Outputs))
+	if len(s.Outputs) > 0 {
+		fmt.Fprintf(&str, " (%s)", strings.Join(s.Outputs, ", "))
+	}
+	fmt.Fprintf(&str, ", %d assertion(s)", s.Assertions)
+	if s.Failures > 0 {
+		fmt.Fprintf(&str, ", %d failed", s.Failures)
+	}
+	fmt.Fprintf(&str, " in %s", s.Elapsed)
+	return str.String()
+}
+
+// Notify sends a message to the given target. The summary of the run is
+// added to the message when requested.
+func (c *EngineContext) Notify(kind, target, msg string, summary bool) error {
+	switch kind {
+	case "webhook":
+	default:
+		return locale.Errorf("%s: unsupported notification", kind)
+	}
+	payload := struct {
+		Text    string         `json:"text"`
+		Summary *reportSummary `json:"summary,omitempty"`
+	}{
+		Text: msg,
+	}
+	if summary && c.report != nil {
+		s := c.report.summary()
+		payload.Summary = &s
+		if payload.Text == "" {
+			payload.Text = s.String()
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return postWebhook(target, body)
+}
+
+func postWebhook(url string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return locale.Errorf("%s: webhook replied with %s", url, res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package eval
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/internal/locale"
+	"github.com/midbel/dockit/value"
+)
+
+// parallelTask is one statement of a parallel block. Its operands are
+// evaluated before the block starts so that only the IO bound part (opening
+// or writing a file) runs concurrently.
+type parallelTask struct {
+	stmt parse.Expr
+	// key identifies the value read or written by the task. Tasks sharing a
+	// key are run one after the other.
+	key string
+
+	// import
+	name    string
+	alias   string
+	options LoaderOptions
+	file    value.Value
+
+	// export
+	value   value.Value
+	targets []ExportTarget
+	opts    LoaderOptions
+}
+
+func (t *parallelTask) run(ctx *EngineContext) error {
+	switch stmt := t.stmt.(type) {
+	case parse.ImportFile:
+		file, err := ctx.Open(t.name, t.options)
+		if err != nil {
+			return err
+		}
+		t.file = importedFile(file, stmt.ReadOnly(), t.options)
+		return nil
+	case parse.ExportFile:
+		return ctx.ExportAll(t.value, t.targets, t.opts)
+	default:
+		return locale.Errorf("%s: statement can not be run in parallel", t.stmt)
+	}
+}
+
+func (v *evaluator) VisitParallel(expr parse.Parallel) error {
+	tasks, err := v.prepareParallel(expr.Body())
+	if err != nil {
+		return err
+	}
+	var (
+		groups = make(map[string][]*parallelTask)
+		order  []string
+	)
+	for _, t := range tasks {
+		if _, ok := groups[t.key]; !ok {
+			order = append(order, t.key)
+		}
+		groups[t.key] = append(groups[t.key], t)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, key := range order {
+		wg.Add(1)
+		go func(list []*parallelTask) {
+			defer wg.Done()
+			for _, t := range list {
+				if err := t.run(v.ctx); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+					return
+				}
+			}
+		}(groups[key])
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		stmt, ok := t.stmt.(parse.ImportFile)
+		if !ok {
+			continue
+		}
+		v.ctx.Define(t.alias, t.file)
+		if stmt.Default() {
+			v.ctx.SetDefault(t.file)
+		}
+	}
+	return nil
+}
+
+// prepareParallel evaluates the operands of each statement of the block and
+// rejects the blocks where statements depend on each other: an export can not
+// use a file imported in the same block, aliases of imports should be unique
+// and the same file can not be written twice.
+func (v *evaluator) prepareParallel(body []parse.Expr) ([]*parallelTask, error) {
+	var (
+		tasks   []*parallelTask
+		aliases = make(map[string]struct{})
+		targets = make(map[string]struct{})
+		usedef  bool
+	)
+	for _, e := range body {
+		switch stmt := e.(type) {
+		case parse.ImportFile:
+			name, alias, options, err := v.prepareImport(stmt)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := aliases[alias]; ok {
+				return nil, locale.Errorf("%s: alias already imported in parallel block", alias)
+			}
+			if stmt.Default() {
+				if usedef {
+					return nil, locale.Errorf("only one default file can be imported in parallel block")
+				}
+				usedef = true
+			}
+			aliases[alias] = struct{}{}
+			t := parallelTask{
+				stmt:    stmt,
+				key:     "import:" + alias,
+				name:    name,
+				alias:   alias,
+				options: options,
+			}
+			tasks = append(tasks, &t)
+		case parse.ExportFile:
+			list, err := v.exportTargets(stmt)
+			if err != nil {
+				return nil, err
+			}
+			for _, target := range list {
+				if _, ok := targets[target.File]; ok {
+					return nil, locale.Errorf("%s: file already exported in parallel block", target.File)
+				}
+				targets[target.File] = struct{}{}
+			}
+
+			t := parallelTask{
+				stmt:    stmt,
+				key:     "export:" + rootIdent(stmt.Expr()),
+				targets: list,
+				opts:    stmt.Options(),
+			}
+			tasks = append(tasks, &t)
+		default:
+			return nil, locale.Errorf("%s: statement can not be run in parallel", e)
+		}
+	}
+	for _, t := range tasks {
+		stmt, ok := t.stmt.(parse.ExportFile)
+		if !ok {
+			continue
+		}
+		root := rootIdent(stmt.Expr())
+		if _, ok := aliases[root]; ok || (root == "" && usedef) {
+			return nil, locale.Errorf("%s: export depends on a file imported in the same parallel block", stmt)
+		}
+		if err := v.visitExpr(stmt.Expr()); err != nil {
+			return nil, err
+		}
+		t.value = v.popValue()
+	}
+	return tasks, nil
+}
+
+// rootIdent gives the name of the identifier an expression reads from. An
+// empty string is returned for expressions using the default file.
+func rootIdent(expr parse.Expr) string {
+	switch e := expr.(type) {
+	case parse.Identifier:
+		return e.Ident()
+	case parse.Access:
+		return rootIdent(e.Object())
+	case parse.SpecialAccess:
+		return rootIdent(e.Object())
+	case parse.Slice:
+		return rootIdent(e.View())
+	case parse.CellAccess:
+		return rootIdent(e.Expr())
+	default:
+		return ""
+	}
+}
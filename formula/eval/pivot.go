@@ -0,0 +1,190 @@
+package eval
+
+import (
+	"github.com/midbel/dockit/cube"
+	"github.com/midbel/dockit/flat"
+	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/formula/runtime"
+	"github.com/midbel/dockit/internal/locale"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+// pivotForm summarizes a view in a new view having a line for each value of
+// the rows column and a column for each value of the cols column, eg
+// pivot(data, rows := A, cols := B, value := sum(C)).
+type pivotForm struct{}
+
+func (pivotForm) Run(eg Runnable, args []parse.Expr, ctx *EngineContext) (value.Value, error) {
+	if len(args) < 4 {
+		return value.ErrValue, locale.Errorf("pivot: view, rows, cols and value expected")
+	}
+	view, err := viewArg(eg, "pivot", args[0])
+	if err != nil {
+		return value.ErrValue, err
+	}
+	var (
+		rows    int64
+		cols    int64
+		measure int64
+		aggr    string
+	)
+	for _, a := range args[1:] {
+		name, expr, err := optionArg("pivot", a)
+		if err != nil {
+			return value.ErrValue, err
+		}
+		switch name {
+		case "rows":
+			rows, err = columnArg("pivot", expr)
+		case "cols":
+			cols, err = columnArg("pivot", expr)
+		case "value":
+			aggr, measure, err = pivotValue(expr)
+		default:
+			return value.ErrValue, locale.Errorf("pivot: %s: unknown option", name)
+		}
+		if err != nil {
+			return value.ErrValue, err
+		}
+	}
+	if rows == 0 || cols == 0 || aggr == "" {
+		return value.ErrValue, locale.Errorf("pivot: rows, cols and value expected")
+	}
+	if rows == cols {
+		return value.ErrValue, locale.Errorf("pivot: rows and cols should be different columns")
+	}
+	fn, err := cube.Lookup(aggr)
+	if err != nil {
+		return value.ErrValue, err
+	}
+	out := parse.CubeOutput{
+		Kind:       parse.CubePivot,
+		Dimensions: []string{layout.IndexToString(rows), layout.IndexToString(cols)},
+		Measure:    aggr,
+	}
+	cb := cube.New()
+	for _, d := range out.Dimensions {
+		if err := cb.RegisterDimension(d, nil); err != nil {
+			return value.ErrValue, err
+		}
+	}
+	if err := cb.RegisterMeasure(out.Measure); err != nil {
+		return value.ErrValue, err
+	}
+	for _, row := range view.View().Rows() {
+		var (
+			dims = []string{
+				columnAt(row, rows).String(),
+				columnAt(row, cols).String(),
+			}
+			val value.ScalarValue = value.ErrValue
+		)
+		if v, ok := columnAt(row, measure).(value.ScalarValue); ok {
+			val = v
+		}
+		if err := cb.AddRow(dims, []value.ScalarValue{val}); err != nil {
+			return value.ErrValue, err
+		}
+	}
+	aggrs := []cube.Aggregate{
+		{
+			Measure: out.Measure,
+			Func:    fn,
+		},
+	}
+	matrix, err := pivotRows(cb, out, aggrs)
+	if err != nil {
+		return value.ErrValue, err
+	}
+	return runtime.NewViewValue(flat.NewSheet(view.View().Name(), matrix)), nil
+}
+
+func pivotValue(expr parse.Expr) (string, int64, error) {
+	call, ok := expr.(parse.Call)
+	if !ok {
+		return "", 0, locale.Errorf("pivot: %s: aggregate expected", expr)
+	}
+	id, ok := call.Name().(parse.Identifier)
+	if !ok || len(call.Args()) != 1 {
+		return "", 0, locale.Errorf("pivot: %s: aggregate expected", expr)
+	}
+	col, err := columnArg("pivot", call.Args()[0])
+	return id.Ident(), col, err
+}
+
+// unpivotForm gives a line for each column of each line of a view except the
+// kept columns, eg unpivot(data, keep := A). The first line of the view gives
+// the names of its columns.
+type unpivotForm struct{}
+
+func (unpivotForm) Run(eg Runnable, args []parse.Expr, ctx *EngineContext) (value.Value, error) {
+	if len(args) < 2 {
+		return value.ErrValue, locale.Errorf("unpivot: view and keep expected")
+	}
+	view, err := viewArg(eg, "unpivot", args[0])
+	if err != nil {
+		return value.ErrValue, err
+	}
+	var keep layout.Selection
+	for _, a := range args[1:] {
+		name, expr, err := optionArg("unpivot", a)
+		if err != nil {
+			return value.ErrValue, err
+		}
+		if name != "keep" {
+			return value.ErrValue, locale.Errorf("unpivot: %s: unknown option", name)
+		}
+		if keep, err = selectionArg("unpivot", expr); err != nil {
+			return value.ErrValue, err
+		}
+	}
+	if keep == nil {
+		return value.ErrValue, locale.Errorf("unpivot: keep expected")
+	}
+	var (
+		kept   = keep.Indices(view.View().Bounds())
+		names  []value.Value
+		result [][]value.Value
+	)
+	for _, row := range view.View().Rows() {
+		if names == nil {
+			names = row
+			header := make([]value.Value, 0, len(kept)+2)
+			for _, ix := range kept {
+				header = append(header, columnAt(row, ix+1))
+			}
+			header = append(header, value.Text("name"), value.Text("value"))
+			result = append(result, header)
+			continue
+		}
+		for col := range row {
+			if isKept(kept, col) {
+				continue
+			}
+			line := make([]value.Value, 0, len(kept)+2)
+			for _, ix := range kept {
+				line = append(line, columnAt(row, ix+1))
+			}
+			line = append(line, columnAt(names, int64(col+1)), columnAt(row, int64(col+1)))
+			result = append(result, line)
+		}
+	}
+	return runtime.NewViewValue(flat.NewSheet(view.View().Name(), result)), nil
+}
+
+func isKept(kept []int64, col int) bool {
+	for _, k := range kept {
+		if k == int64(col) {
+			return true
+		}
+	}
+	return false
+}
+
+func columnAt(row []value.Value, col int64) value.Value {
+	if col < 1 || col > int64(len(row)) || row[col-1] == nil {
+		return value.Empty()
+	}
+	return row[col-1]
+}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/midbel/dockit/formula/parse"
 	"github.com/midbel/dockit/formula/runtime"
 	"github.com/midbel/dockit/grid/format"
 	"github.com/midbel/dockit/value"
@@ -23,6 +24,7 @@ type PrintMode int
 const (
 	PrintDefault PrintMode = 1 << iota
 	PrintDebug
+	PrintPlain
 )
 
 type Printer interface {
@@ -46,6 +48,15 @@ func DebugValue(w io.Writer, rows, cols int) Printer {
 	}
 }
 
+// PlainValue creates a Printer writing views and arrays as tab separated
+// values prefixed by their row number. Nothing is truncated nor aligned, so
+// the output can be read by screen readers or piped into other tools.
+func PlainValue(w io.Writer) Printer {
+	return plainPrinter{
+		w: w,
+	}
+}
+
 type valueFormatter struct{}
 
 func (f valueFormatter) Format(v value.Value) (string, error) {
@@ -73,6 +84,8 @@ func (p valuePrinter) Format(v value.Value, f format.Formatter) {
 		p.printView(v, f)
 	case *runtime.InspectValue:
 		p.printInspect(v)
+	case parse.Deferred:
+		fmt.Fprintln(p.w, v.String())
 	default:
 	}
 }
@@ -309,6 +322,79 @@ func (p debugPrinter) printInspect(v *runtime.InspectValue) {
 	writer.Flush()
 }
 
+type plainPrinter struct {
+	w io.Writer
+}
+
+func (p plainPrinter) Print(v value.Value) {
+	var vf valueFormatter
+	p.Format(v, vf)
+}
+
+func (p plainPrinter) Format(v value.Value, f format.Formatter) {
+	if f == nil {
+		f = valueFormatter{}
+	}
+	writer := bufio.NewWriter(p.w)
+	defer writer.Flush()
+
+	switch v := v.(type) {
+	case value.ScalarValue:
+		str, err := f.Format(v)
+		if err != nil {
+			str = value.ErrNA.String()
+		}
+		io.WriteString(writer, plainText(str))
+		io.WriteString(writer, "\n")
+	case value.ArrayValue:
+		dim := v.Dimension()
+		for i := range dim.Lines {
+			row := make([]string, 0, dim.Columns)
+			for j := range dim.Columns {
+				str, _ := f.Format(v.At(int(i), int(j)))
+				row = append(row, str)
+			}
+			writePlainRow(writer, i+1, row)
+		}
+	case *runtime.View:
+		var lino int64
+		for _, r := range v.View().Rows() {
+			lino++
+			row := make([]string, 0, len(r))
+			for i := range r {
+				str, _ := f.Format(r[i])
+				row = append(row, str)
+			}
+			writePlainRow(writer, lino, row)
+		}
+	case *runtime.InspectValue:
+		io.WriteString(writer, v.Type())
+		for n, v := range v.Values() {
+			io.WriteString(writer, "\t")
+			io.WriteString(writer, plainText(n))
+			io.WriteString(writer, "=")
+			io.WriteString(writer, plainText(v.String()))
+		}
+		io.WriteString(writer, "\n")
+	default:
+	}
+}
+
+func writePlainRow(writer io.Writer, lino int64, row []string) {
+	io.WriteString(writer, strconv.FormatInt(lino, 10))
+	for i := range row {
+		io.WriteString(writer, "\t")
+		io.WriteString(writer, plainText(row[i]))
+	}
+	io.WriteString(writer, "\n")
+}
+
+var plainReplacer = strings.NewReplacer("\t", "\\t", "\n", "\\n", "\r", "\\r")
+
+func plainText(str string) string {
+	return plainReplacer.Replace(str)
+}
+
 func writeValue(writer io.Writer, str string, size int) {
 	io.WriteString(writer, str)
 	for range size - len(str) {
@@ -0,0 +1,85 @@
+package eval
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/midbel/dockit/formula/runtime"
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/internal/locale"
+	"github.com/midbel/dockit/value"
+)
+
+// sourceFiles keeps the paths of the workbooks opened by a script. Saving a
+// workbook without target writes it back to its path.
+type sourceFiles struct {
+	mu    sync.Mutex
+	paths map[grid.File]string
+}
+
+func newSourceFiles() *sourceFiles {
+	return &sourceFiles{
+		paths: make(map[grid.File]string),
+	}
+}
+
+func (s *sourceFiles) add(file grid.File, path string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paths[file] = path
+}
+
+func (s *sourceFiles) get(file grid.File) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path, ok := s.paths[file]
+	return path, ok
+}
+
+// Save writes a workbook to the given file, or to the file it was imported
+// from. Workbooks imported as read only can only be written to other files.
+// The format of the file is given by its extension.
+func (c *EngineContext) Save(val value.Value, out string) error {
+	var file *runtime.File
+	switch v := val.(type) {
+	case *runtime.File:
+		file = v
+	case *runtime.View:
+		file = v.File()
+	}
+	if file == nil {
+		return locale.Errorf("only workbooks can be saved")
+	}
+	if out == "" {
+		if file.ReadOnly() {
+			return locale.Errorf("workbook imported as read only can not be saved")
+		}
+		path, ok := c.sources.get(file.File())
+		if !ok {
+			return locale.Errorf("workbook without file can not be saved")
+		}
+		out = path
+	} else {
+		out = filepath.Join(c.contextDir, out)
+	}
+	ext := strings.ToLower(filepath.Ext(out))
+	w, ok := c.writers[ext]
+	if !ok {
+		return locale.Errorf("file %s can not be written", ext)
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	if err := w.Write(out, file.File(), nil); err != nil {
+		return err
+	}
+	c.report.exported(file.File(), out)
+	return nil
+}
@@ -0,0 +1,61 @@
+package eval
+
+import (
+	"github.com/midbel/dockit/formula/runtime"
+	"github.com/midbel/dockit/internal/locale"
+	"github.com/midbel/dockit/value"
+)
+
+// defaultStack keeps the defaults replaced by the push statements of a script
+// to restore them when the matching pop is executed.
+type defaultStack struct {
+	values []value.Value
+}
+
+func (s *defaultStack) push(val value.Value) {
+	s.values = append(s.values, val)
+}
+
+func (s *defaultStack) pop() (value.Value, bool) {
+	if s == nil || len(s.values) == 0 {
+		return nil, false
+	}
+	n := len(s.values) - 1
+	val := s.values[n]
+	s.values = s.values[:n]
+	return val, true
+}
+
+// PushDefault makes a file or a view the default, keeping the current one to
+// be restored by PopDefault. Pushed as read only, the cells of the file or of
+// the view can not be updated while it is the default.
+func (c *EngineContext) PushDefault(val value.Value, ro bool) error {
+	if c.defaults == nil {
+		return locale.Errorf("default can not be pushed")
+	}
+	switch v := val.(type) {
+	case *runtime.File:
+		if ro && !v.ReadOnly() {
+			val = runtime.NewFileValue(v.File(), true)
+		}
+	case *runtime.View:
+		if ro {
+			val = v.ReadOnly()
+		}
+	default:
+		return locale.Errorf("only file or view can be pushed")
+	}
+	c.defaults.push(c.currentValue)
+	c.currentValue = val
+	return nil
+}
+
+// PopDefault restores the default replaced by the last call to PushDefault.
+func (c *EngineContext) PopDefault() error {
+	val, ok := c.defaults.pop()
+	if !ok {
+		return locale.Errorf("no default to pop")
+	}
+	c.currentValue = val
+	return nil
+}
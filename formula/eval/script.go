@@ -1,18 +1,24 @@
 package eval
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/midbel/dockit/formula/builtins"
+	"github.com/midbel/dockit/formula/env"
 	"github.com/midbel/dockit/formula/op"
 	"github.com/midbel/dockit/formula/parse"
 	"github.com/midbel/dockit/formula/runtime"
 	"github.com/midbel/dockit/grid"
 	gbs "github.com/midbel/dockit/grid/builtins"
+	"github.com/midbel/dockit/grid/format"
 	"github.com/midbel/dockit/internal/ds"
+	"github.com/midbel/dockit/internal/locale"
 	"github.com/midbel/dockit/internal/slx"
 	"github.com/midbel/dockit/layout"
 	"github.com/midbel/dockit/value"
@@ -40,10 +46,56 @@ func (v *evaluator) Run(expr parse.Expr) (value.Value, error) {
 	return v.normalize(val)
 }
 
+// Values runs the statements of a script one by one and gives the values of
+// the statements leaving one, such as expressions, in their order.
+func (v *evaluator) Values(expr parse.Expr) ([]value.Value, error) {
+	script, ok := expr.(parse.Script)
+	if !ok {
+		val, err := v.Run(expr)
+		if err != nil {
+			return nil, err
+		}
+		return []value.Value{val}, nil
+	}
+	for i := range script.Includes {
+		if err := v.visitExpr(script.Includes[i]); err != nil {
+			return nil, err
+		}
+	}
+	var list []value.Value
+	for i := range script.Body {
+		size := v.stack.Len()
+		if err := v.visitExpr(script.Body[i]); err != nil {
+			if err = v.ctx.handleError(err); err != nil {
+				return list, err
+			}
+		}
+		if v.stack.Len() <= size {
+			continue
+		}
+		val, err := v.normalize(v.popValue())
+		if err != nil {
+			return list, err
+		}
+		list = append(list, val)
+		for v.stack.Len() > size {
+			v.popValue()
+		}
+	}
+	return list, nil
+}
+
 func (v *evaluator) VisitScript(expr parse.Script) error {
+	for i := range expr.Includes {
+		if err := v.visitExpr(expr.Includes[i]); err != nil {
+			return err
+		}
+	}
 	for i := range expr.Body {
 		if err := v.visitExpr(expr.Body[i]); err != nil {
-			return err
+			if err = v.ctx.handleError(err); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -58,39 +110,37 @@ func (v *evaluator) VisitUseRef(expr parse.UseRef) error {
 	case *runtime.File:
 		v.ctx.SetDefault(val)
 	default:
-		return fmt.Errorf("only file can be used as default")
+		return locale.Errorf("only file can be used as default")
 	}
 	return nil
 }
 
-func (v *evaluator) VisitIncludeFile(expr parse.IncludeFile) error {
-	return nil
+func (v *evaluator) VisitPush(expr parse.Push) error {
+	val, err := v.visitNormalize(expr.Expr())
+	if err != nil {
+		return err
+	}
+	return v.ctx.PushDefault(val, expr.ReadOnly())
+}
+
+func (v *evaluator) VisitPop(expr parse.Pop) error {
+	return v.ctx.PopDefault()
 }
 
 func (v *evaluator) VisitLock(expr parse.Lock) error {
 	val, err := v.visitNormalize(expr.Ident())
 	if err != nil {
-		return nil
-	}
-	lock, ok := val.(interface{ Lock() })
-	if !ok {
-		return fmt.Errorf("value can not be locked")
+		return err
 	}
-	lock.Lock()
-	return nil
+	return v.ctx.Lock(val, expr.Options())
 }
 
 func (v *evaluator) VisitUnlock(expr parse.Unlock) error {
 	val, err := v.visitNormalize(expr.Ident())
 	if err != nil {
-		return nil
-	}
-	lock, ok := val.(interface{ Unlock() })
-	if !ok {
-		return fmt.Errorf("value can not be unlocked")
+		return err
 	}
-	lock.Unlock()
-	return nil
+	return v.ctx.Unlock(val, expr.Options())
 }
 
 func (v *evaluator) VisitRename(expr parse.Rename) error {
@@ -104,15 +154,193 @@ func (v *evaluator) VisitRename(expr parse.Rename) error {
 	}
 	view, ok := val.(interface{ Rename(string) })
 	if !ok {
-		return fmt.Errorf("value can not be renamed")
+		return locale.Errorf("value can not be renamed")
 	}
 	view.Rename(name.String())
 	return nil
 }
 
+func (v *evaluator) VisitDefine(expr parse.Define) error {
+	var (
+		target = expr.Expr()
+		view   *runtime.View
+	)
+	if a, ok := target.(parse.CellAccess); ok {
+		val, err := v.visitNormalize(a.Expr())
+		if err != nil {
+			return err
+		}
+		switch x := val.(type) {
+		case *runtime.View:
+			view = x
+		case *runtime.File:
+			if view, err = v.ctx.getViewFromFile(x, ""); err != nil {
+				return err
+			}
+		default:
+			return locale.Errorf("%s: only cells and ranges of views can be named", expr.Ident())
+		}
+		target = a.Addr()
+	}
+	var rg *layout.Range
+	switch e := target.(type) {
+	case parse.CellAddr:
+		rg = layout.NewRange(e.Position, e.Position)
+	case parse.RangeAddr:
+		rg = layout.NewRange(e.StartAt().Position, e.EndAt().Position)
+	default:
+		return locale.Errorf("%s: only cells and ranges can be named", expr.Ident())
+	}
+	if view == nil {
+		var err error
+		if view, err = v.ctx.getView(rg.Starts.Sheet); err != nil {
+			return err
+		}
+	}
+	return v.ctx.DefineName(expr.Ident(), view, rg.Normalize())
+}
+
 func (v *evaluator) VisitSheet(expr parse.Sheet) error {
+	ident, sheet, err := v.createSheet(expr)
+	if err != nil {
+		return err
+	}
+	v.ctx.Define(ident, sheet)
+	return nil
+}
+
+func (v *evaluator) VisitWithSheet(expr parse.WithSheet) error {
+	ident, sheet, err := v.createSheet(expr.Sheet())
+	if err != nil {
+		return err
+	}
+	if err := v.ctx.PushDefault(sheet, false); err != nil {
+		return err
+	}
+	defer v.ctx.PopDefault()
+	for _, e := range expr.Body() {
+		if err := v.visitExpr(e); err != nil {
+			return err
+		}
+	}
+	v.ctx.Define(ident, sheet)
+	return nil
+}
+
+func (v *evaluator) VisitIf(expr parse.If) error {
+	cond, err := v.visitNormalize(expr.Cond())
+	if err != nil {
+		return err
+	}
+	if value.IsError(cond) {
+		return locale.Errorf("%s: invalid condition (%s)", expr.Cond(), cond)
+	}
+	body := expr.Else()
+	if value.True(cond) {
+		body = expr.Body()
+	}
+	for _, e := range body {
+		if err := v.visitExpr(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *evaluator) VisitFor(expr parse.For) error {
+	val, err := v.visitNormalize(expr.Iter())
+	if err != nil {
+		return err
+	}
+	items, err := loopValues(val)
+	if err != nil {
+		return err
+	}
+	parent := v.ctx.env
+	defer v.ctx.setEnv(parent)
+
+	local := env.Enclosed(parent)
+	v.ctx.setEnv(local)
+	for item := range items {
+		local.Declare(expr.Ident(), item)
+		stop, err := v.visitLoopBody(expr.Body())
+		if err != nil {
+			return err
+		}
+		if stop {
+			break
+		}
+	}
+	return nil
+}
+
+func (v *evaluator) VisitWhile(expr parse.While) error {
+	limit := v.ctx.loopLimit()
+	for i := 0; ; i++ {
+		cond, err := v.visitNormalize(expr.Cond())
+		if err != nil {
+			return err
+		}
+		if value.IsError(cond) {
+			return locale.Errorf("%s: invalid condition (%s)", expr.Cond(), cond)
+		}
+		if !value.True(cond) {
+			break
+		}
+		if limit > 0 && i >= limit {
+			return locale.Errorf("%s: loop stopped after %d iterations", expr.Cond(), limit)
+		}
+		stop, err := v.visitLoopBody(expr.Body())
+		if err != nil {
+			return err
+		}
+		if stop {
+			break
+		}
+	}
+	return nil
+}
+
+func (v *evaluator) VisitBreak(expr parse.Break) error {
+	return errBreak
+}
+
+func (v *evaluator) VisitContinue(expr parse.Continue) error {
+	return errContinue
+}
+
+// visitLoopBody executes the statements of the body of a loop. It tells
+// whether the loop is stopped by a break statement.
+func (v *evaluator) visitLoopBody(body []parse.Expr) (bool, error) {
+	for _, e := range body {
+		err := v.visitExpr(e)
+		switch {
+		case err == nil:
+		case errors.Is(err, errBreak):
+			return true, nil
+		case errors.Is(err, errContinue):
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+func (v *evaluator) VisitWithFilter(expr parse.WithFilter) error {
+	var list []value.Predicate
+	for _, e := range expr.Body() {
+		list = append(list, runtime.NewExprPredicate(grid.NewFormula(e)))
+	}
+	v.ctx.Define(expr.Ident(), runtime.NewFilter(list...))
+	return nil
+}
+
+// createSheet gives the sheet created by a sheet statement and the name of
+// the variable it is assigned to.
+func (v *evaluator) createSheet(expr parse.Sheet) (string, value.Value, error) {
 	if expr.Name() == nil && expr.Ident() == nil {
-		return fmt.Errorf("unnamed sheet")
+		return "", nil, locale.Errorf("unnamed sheet")
 	}
 	var (
 		name  value.Value
@@ -122,29 +350,22 @@ func (v *evaluator) VisitSheet(expr parse.Sheet) error {
 		err   error
 	)
 	if n := expr.Name(); n != nil {
-		name, err = v.visitNormalize(n)
-		if err != nil {
-			return err
-		}
-	} else if n := expr.Ident(); n != nil {
-		name, err = v.visitNormalize(n)
-		if err != nil {
-			return err
-		}
+		name, err = v.sheetName(n)
+	} else {
+		name, err = v.sheetName(expr.Ident())
+	}
+	if err != nil {
+		return "", nil, err
 	}
 	if n := expr.Ident(); n != nil {
-		ident, err = v.visitNormalize(n)
-		if err != nil {
-			return err
-		}
-		if id, ok := n.(parse.Identifier); ok && ident == value.ErrRef {
-			ident = value.Text(id.Ident())
+		if ident, err = v.sheetName(n); err != nil {
+			return "", nil, err
 		}
 	}
 	if d := expr.Data(); d != nil {
 		data, err = v.visitNormalize(d)
 		if err != nil {
-			return err
+			return "", nil, err
 		}
 	} else {
 		var (
@@ -159,17 +380,34 @@ func (v *evaluator) VisitSheet(expr parse.Sheet) error {
 			cols = 1
 		}
 		data = value.ScalarToArray(value.Empty(), rows, cols)
-
+	}
+	if f := expr.File(); f != nil {
+		file, err = v.visitNormalize(f)
+		if err != nil {
+			return "", nil, err
+		}
 	}
 	sheet, err := v.ctx.NewSheet(name, data, file)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 	if ident == nil {
 		ident = name
 	}
-	v.ctx.Define(ident.String(), sheet)
-	return nil
+	return ident.String(), sheet, nil
+}
+
+// sheetName gives the value of the name of a sheet. Undefined identifiers
+// give their own name.
+func (v *evaluator) sheetName(expr parse.Expr) (value.Value, error) {
+	val, err := v.visitNormalize(expr)
+	if err != nil {
+		return nil, err
+	}
+	if id, ok := expr.(parse.Identifier); ok && value.IsError(val) {
+		val = value.Text(id.Ident())
+	}
+	return val, nil
 }
 
 func (v *evaluator) captureCells(sheet *runtime.View, data value.Value) (value.Value, error) {
@@ -182,10 +420,10 @@ func (v *evaluator) captureCells(sheet *runtime.View, data value.Value) (value.V
 		for _, c := range cs {
 			xc, ok := c.(*runtime.Cell)
 			if !ok {
-				return nil, fmt.Errorf("not a runtime cell")
+				return nil, locale.Errorf("not a runtime cell")
 			}
 			if !xc.BelongsTo(sheet) {
-				return nil, fmt.Errorf("cross workbook links not allowed")
+				return nil, locale.Errorf("cross workbook links not allowed")
 			}
 		}
 	}
@@ -200,7 +438,7 @@ func (v *evaluator) VisitInsert(expr parse.Insert) error {
 		err   error
 	)
 	if expr.Ident() == nil {
-		return fmt.Errorf("target sheet should be specified")
+		return locale.Errorf("target sheet should be specified")
 	}
 	sheet, err = v.visitNormalize(expr.Ident())
 	if err != nil {
@@ -233,7 +471,7 @@ func (v *evaluator) VisitInsert(expr parse.Insert) error {
 		ix--
 	case parse.AnchorAfter:
 	default:
-		return fmt.Errorf("invalid anchor for insert statement")
+		return locale.Errorf("invalid anchor for insert statement")
 	}
 	var wrg *runtime.WritableRange
 	switch expr.Type() {
@@ -254,9 +492,9 @@ func (v *evaluator) VisitInsert(expr parse.Insert) error {
 
 func (v *evaluator) VisitRemove(expr parse.Remove) error {
 	if k := expr.Target().Kind; k == parse.TargetFirst && expr.Anchor == parse.AnchorBefore {
-		return fmt.Errorf("row/column can not be removed before first row/column")
+		return locale.Errorf("row/column can not be removed before first row/column")
 	} else if k == parse.TargetLast && expr.Anchor == parse.AnchorAfter {
-		return fmt.Errorf("row/column can not be removed after last row/column")
+		return locale.Errorf("row/column can not be removed after last row/column")
 	}
 	var (
 		sheet value.Value
@@ -264,7 +502,7 @@ func (v *evaluator) VisitRemove(expr parse.Remove) error {
 		err   error
 	)
 	if expr.Ident() == nil {
-		return fmt.Errorf("target sheet should be specified")
+		return locale.Errorf("target sheet should be specified")
 	}
 	sheet, err = v.visitNormalize(expr.Ident())
 	if err != nil {
@@ -287,7 +525,7 @@ func (v *evaluator) VisitRemove(expr parse.Remove) error {
 		ix--
 	case parse.AnchorAt:
 	default:
-		return fmt.Errorf("invalid anchor for remove statement")
+		return locale.Errorf("invalid anchor for remove statement")
 	}
 	switch expr.Type() {
 	case parse.Column:
@@ -302,7 +540,7 @@ func (v *evaluator) VisitRemove(expr parse.Remove) error {
 func (v *evaluator) resolveTarget(source value.Value, target parse.Target, kind parse.Colrow) (int64, error) {
 	view, ok := source.(*runtime.View)
 	if !ok {
-		return 0, fmt.Errorf("expected view")
+		return 0, locale.Errorf("expected view")
 	}
 
 	var max int64
@@ -330,11 +568,11 @@ func (v *evaluator) resolveTarget(source value.Value, target parse.Target, kind
 		}
 		n, ok := val.(value.Float)
 		if !ok {
-			return 0, fmt.Errorf("target: number expected")
+			return 0, locale.Errorf("target: number expected")
 		}
 		index = int64(n)
 	default:
-		return 0, fmt.Errorf("invalid target")
+		return 0, locale.Errorf("invalid target")
 	}
 	if index < 0 {
 		index = 0
@@ -343,46 +581,17 @@ func (v *evaluator) resolveTarget(source value.Value, target parse.Target, kind
 }
 
 func (v *evaluator) VisitImportFile(expr parse.ImportFile) error {
-	options := expr.Options()
-	switch spec := expr.Specifier(); expr.Format() {
-	case "csv":
-		if spec == "" {
-			spec = v.ctx.GetOptionString(ConfigImportCsvDelim)
-		}
-		options["delimiter"] = csvDelimiter(spec)
-	case "log":
-		if spec == "" {
-			spec = v.ctx.GetOptionString(ConfigImportLogPattern)
-		}
-		options["pattern"] = spec
-	case "json":
-		options["query"] = spec
-	case "xml":
-		options["query"] = spec
-	default:
-	}
-	source, err := v.visitNormalize(expr.File())
+	name, alias, options, err := v.prepareImport(expr)
 	if err != nil {
 		return err
 	}
-	name := source.String()
 	file, err := v.ctx.Open(name, options)
 	if err != nil {
 		return err
 	}
-	alias := expr.Alias()
-	if alias == "" {
-		name = filepath.Base(name)
-		for {
-			ext := filepath.Ext(name)
-			if ext == "" {
-				break
-			}
-			name = strings.TrimSuffix(name, ext)
-		}
-		alias = name
-	}
-	wb := runtime.NewFileValue(file, expr.ReadOnly())
+	v.ctx.books.add(name, file)
+
+	wb := importedFile(file, expr.ReadOnly(), options)
 	v.ctx.Define(alias, wb)
 	if expr.Default() {
 		v.ctx.SetDefault(wb)
@@ -390,11 +599,79 @@ func (v *evaluator) VisitImportFile(expr parse.ImportFile) error {
 	return nil
 }
 
+// importedFile gives the value of an imported file. With the header option,
+// the first line of its sheets gives the names of their columns.
+func importedFile(file grid.File, readonly bool, options LoaderOptions) value.Value {
+	wb := runtime.NewFileValue(file, readonly)
+	if options.getAsBool("header", false) {
+		wb.(*runtime.File).UseHeader()
+	}
+	return wb
+}
+
+func (v *evaluator) prepareImport(expr parse.ImportFile) (string, string, LoaderOptions, error) {
+	options := v.ctx.loaderOptions(expr.Format(), expr.Specifier(), expr.Options())
+	source, err := v.visitNormalize(v.expandPath(expr.File()))
+	if err != nil {
+		return "", "", nil, err
+	}
+	name := source.String()
+	alias := expr.Alias()
+	if alias == "" {
+		alias = fileAlias(name)
+	}
+	return name, alias, options, nil
+}
+
+// expandPath replaces the placeholders of a path naming an environment
+// variable by the value of this variable, eg "${HOME}/data.csv". Variables of
+// the script take precedence over the environment.
+func (v *evaluator) expandPath(expr parse.Expr) parse.Expr {
+	tpl, ok := expr.(parse.Template)
+	if !ok {
+		return expr
+	}
+	parts := slices.Clone(tpl.Parts())
+	for i, e := range parts {
+		id, ok := e.(parse.Identifier)
+		if !ok || !value.IsError(v.ctx.Resolve(id.Ident())) {
+			continue
+		}
+		if str, ok := os.LookupEnv(id.Ident()); ok {
+			parts[i] = parse.NewLiteral(str)
+		}
+	}
+	return parse.NewTemplate(parts)
+}
+
+// fileAlias gives the name of a file without its directory and extensions.
+func fileAlias(file string) string {
+	alias := filepath.Base(file)
+	for {
+		ext := filepath.Ext(alias)
+		if ext == "" {
+			break
+		}
+		alias = strings.TrimSuffix(alias, ext)
+	}
+	return alias
+}
+
 func (v *evaluator) VisitPrintRef(expr parse.PrintRef) error {
 	val, err := v.visitNormalize(expr.Expr())
 	if err != nil {
 		return err
 	}
+	if pattern := expr.Pattern(); pattern != "" {
+		if p, ok := format.PatternNames[pattern]; ok {
+			pattern = p
+		}
+		f, err := v.ctx.parseFormatter(pattern)
+		if err != nil {
+			return err
+		}
+		return v.ctx.PrintWith(val, f)
+	}
 	return v.ctx.Print(val)
 }
 
@@ -404,18 +681,104 @@ func (v *evaluator) VisitExportFile(expr parse.ExportFile) error {
 	}
 	val := v.popValue()
 
-	target, err := v.visitNormalize(expr.File())
+	targets, err := v.exportTargets(expr)
 	if err != nil {
 		return err
 	}
-	return v.ctx.Export(val, target.String(), expr.Format())
+	return v.ctx.ExportAll(val, targets, expr.Options())
 }
 
-func (v *evaluator) VisitCellAccess(expr parse.CellAccess) error {
-	if err := v.visitExpr(expr.Expr()); err != nil {
+// exportTargets gives the files written by an export. With a single file and
+// several formats, the file is written once per format with the extension of
+// the format replacing its own extension.
+func (v *evaluator) exportTargets(expr parse.ExportFile) ([]ExportTarget, error) {
+	var (
+		files   []string
+		formats = expr.Formats()
+	)
+	for _, f := range expr.Files() {
+		target, err := v.visitNormalize(f)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, target.String())
+	}
+	var list []ExportTarget
+	switch {
+	case len(formats) <= 1:
+		for _, f := range files {
+			list = append(list, ExportTarget{
+				File:   f,
+				Format: expr.Format(),
+			})
+		}
+	case len(files) == 1:
+		base := strings.TrimSuffix(files[0], filepath.Ext(files[0]))
+		for _, f := range formats {
+			list = append(list, ExportTarget{
+				File:   base + writerExt(f),
+				Format: f,
+			})
+		}
+	case len(files) == len(formats):
+		for i := range files {
+			list = append(list, ExportTarget{
+				File:   files[i],
+				Format: formats[i],
+			})
+		}
+	default:
+		return nil, locale.Errorf("number of files and formats mismatched")
+	}
+	return list, nil
+}
+
+func (v *evaluator) VisitSaveRef(expr parse.SaveRef) error {
+	val, err := v.visitNormalize(expr.Expr())
+	if err != nil {
 		return err
 	}
-	val := v.popValue()
+	var out string
+	if expr.File() != nil {
+		target, err := v.visitNormalize(expr.File())
+		if err != nil {
+			return err
+		}
+		out = target.String()
+	}
+	return v.ctx.Save(val, out)
+}
+
+func (v *evaluator) VisitNotify(expr parse.Notify) error {
+	target, err := v.visitNormalize(expr.Target())
+	if err != nil {
+		return err
+	}
+	var msg string
+	if e := expr.Message(); e != nil {
+		val, err := v.visitNormalize(e)
+		if err != nil {
+			return err
+		}
+		msg = val.String()
+	}
+	return v.ctx.Notify(expr.Kind(), target.String(), msg, expr.Summary())
+}
+
+func (v *evaluator) VisitCellAccess(expr parse.CellAccess) error {
+	var val value.Value
+	if ext, ok := expr.Expr().(parse.ExternalSheet); ok {
+		val = v.externalSheet(ext)
+		if value.IsError(val) {
+			v.pushValue(val)
+			return nil
+		}
+	} else {
+		if err := v.visitExpr(expr.Expr()); err != nil {
+			return err
+		}
+		val = v.popValue()
+	}
 	switch x := val.(type) {
 	case value.ScalarValue:
 		val = runtime.NewViewValue(NewScalarView(x))
@@ -438,6 +801,22 @@ func (v *evaluator) VisitCellAccess(expr parse.CellAccess) error {
 	return err
 }
 
+// externalSheet gives the sheet of another workbook referenced by a formula.
+// The workbook is either imported by the script or loaded from the search
+// path.
+func (v *evaluator) externalSheet(ext parse.ExternalSheet) value.Value {
+	file, err := v.ctx.Book(ext.Book())
+	if err != nil {
+		return value.ErrRef
+	}
+	wb := runtime.NewFileValue(file, true).(*runtime.File)
+	sheet, err := wb.Sheet(ext.Sheet())
+	if err != nil || value.IsError(sheet) {
+		return value.ErrRef
+	}
+	return sheet
+}
+
 func (v *evaluator) VisitSpecial(expr parse.SpecialAccess) error {
 	var target value.Value
 	if src := expr.Object(); src != nil {
@@ -451,11 +830,11 @@ func (v *evaluator) VisitSpecial(expr parse.SpecialAccess) error {
 	}
 	obj, ok := target.(*runtime.File)
 	if !ok {
-		return fmt.Errorf("expected file")
+		return locale.Errorf("expected file")
 	}
 	id, ok := expr.Property().(parse.Identifier)
 	if !ok {
-		return fmt.Errorf("expected identifier")
+		return locale.Errorf("expected identifier")
 	}
 	val := obj.Get(id.Ident())
 	v.pushValue(val)
@@ -474,14 +853,14 @@ func (v *evaluator) VisitAccess(expr parse.Access) error {
 	}
 	obj, ok := obj.(value.ObjectValue)
 	if !ok {
-		return fmt.Errorf("expected file/view")
+		return locale.Errorf("expected file/view")
 	}
 	var val value.Value
 	switch prop := expr.Property().(type) {
 	case parse.Identifier:
 		val = evalAccess(obj, prop)
 	default:
-		return fmt.Errorf("unexpected property type")
+		return locale.Errorf("unexpected property type")
 	}
 	v.pushValue(val)
 	return nil
@@ -505,11 +884,23 @@ func evalAccess(obj value.Value, prop parse.Identifier) value.Value {
 func (v *evaluator) VisitTemplate(expr parse.Template) error {
 	var str strings.Builder
 	for _, e := range expr.Parts() {
+		f, ok := e.(parse.Formatted)
+		if ok {
+			e = f.Expr()
+		}
 		if err := v.visitExpr(e); err != nil {
 			return err
 		}
 		val := v.popValue()
-		str.WriteString(val.String())
+		if !ok {
+			str.WriteString(val.String())
+			continue
+		}
+		res, err := v.ctx.formatPattern(val, f.Pattern())
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Pattern(), err)
+		}
+		str.WriteString(res)
 	}
 	v.pushValue(value.Text(str.String()))
 	return nil
@@ -549,7 +940,7 @@ func (v *evaluator) VisitAssignment(expr parse.Assignment) error {
 	case parse.Identifier:
 		v.ctx.Define(e.Ident(), val)
 	default:
-		err = fmt.Errorf("target value is not assignable")
+		err = locale.Errorf("target value is not assignable")
 	}
 	return err
 }
@@ -559,6 +950,7 @@ func (v *evaluator) VisitAssert(expr parse.Assert) error {
 		return err
 	}
 	ok := value.True(v.popValue())
+	v.ctx.report.asserted(ok)
 	if !ok {
 		mode := expr.Type()
 		if mode == parse.AssertUnknown {
@@ -570,7 +962,7 @@ func (v *evaluator) VisitAssert(expr parse.Assert) error {
 		case parse.AssertFail:
 			msg := expr.Failure()
 			if msg == "" {
-				msg = fmt.Sprintf("assertion failed: %s", expr.Expr())
+				msg = locale.Sprintf("assertion failed: %s", expr.Expr())
 			}
 			return Abort(msg)
 		case parse.AssertWarn:
@@ -595,8 +987,16 @@ func (v *evaluator) VisitBinary(expr parse.Binary) error {
 		v.pushValue(value.ErrValue)
 		return err
 	}
+	if err := value.HasErrors(left, right); err != nil {
+		v.pushValue(err)
+		return nil
+	}
 	var val value.Value
 	switch {
+	case expr.Op() == op.Union && !(value.IsObject(left) && value.IsObject(right)):
+		// views are combined as a new view, other values as the union of
+		// their areas
+		val = value.NewComposite(left, right)
 	case value.IsScalar(left) && value.IsScalar(right):
 		val, err = v.evalScalarBinary(left, right, expr.Op())
 	case (value.IsArray(right) || value.IsObject(right)) && value.IsScalar(left):
@@ -618,6 +1018,10 @@ func (v *evaluator) evalScalarBinary(left, right value.Value, oper op.Op) (value
 	if err := value.HasErrors(left, right); err != nil {
 		return err, nil
 	}
+	left, right, res := v.ctx.blanks.blankOperands(left, right, oper)
+	if res != nil {
+		return res, nil
+	}
 	var ret value.Value
 	switch oper {
 	case op.Add:
@@ -745,12 +1149,12 @@ func (v *evaluator) evalViewBinary(left, right value.Value, oper op.Op) (value.V
 		return v.evalArrayBinary(lv.AsArray(), rv.AsArray(), oper)
 	case op.Union:
 		if d1.Width() != d2.Width() {
-			return value.ErrValue, fmt.Errorf("view can not be combined - number of columns mismatched")
+			return value.ErrValue, locale.Errorf("view can not be combined - number of columns mismatched")
 		}
 		view = grid.VerticalView(v1, v2)
 	case op.Concat:
 		if d1.Height() != d2.Height() {
-			return value.ErrValue, fmt.Errorf("view can not be combined - number of lines mismatched")
+			return value.ErrValue, locale.Errorf("view can not be combined - number of lines mismatched")
 		}
 		view = grid.HorizontalView(v1, v2)
 	default:
@@ -825,6 +1229,10 @@ func (v *evaluator) VisitUnary(expr parse.Unary) error {
 	if err != nil {
 		return err
 	}
+	if value.IsError(val) {
+		v.pushValue(val)
+		return nil
+	}
 	x, err := value.CastToFloat(val)
 	switch expr.Op() {
 	case op.Add:
@@ -857,15 +1265,20 @@ func (v *evaluator) VisitNumber(expr parse.Number) error {
 	return nil
 }
 
+func (v *evaluator) VisitDate(expr parse.Date) error {
+	v.pushValue(value.Date(expr.Time()))
+	return nil
+}
+
 func (v *evaluator) VisitCall(expr parse.Call) error {
 	v.enterPhase(phaseCall)
 	defer v.leavePhase()
 
 	id, ok := expr.Name().(parse.Identifier)
 	if !ok {
-		return fmt.Errorf("identifier expected")
+		return locale.Errorf("identifier expected")
 	}
-	if fn, ok := specials[id.Ident()]; ok {
+	if fn, ok := specials[strings.ToLower(id.Ident())]; ok {
 		val, err := fn.Run(v, expr.Args(), v.ctx)
 		if err != nil {
 			val = value.ErrValue
@@ -873,26 +1286,68 @@ func (v *evaluator) VisitCall(expr parse.Call) error {
 		v.pushValue(val)
 		return err
 	}
-	fn, err := builtins.Lookup(id.Ident())
+	if fn, ok := v.ctx.Resolve(id.Ident()).(*userFunc); ok {
+		return v.callUserFunc(fn, expr.Args())
+	}
+	fn, err := builtins.Lookup(id.Ident(), v.ctx)
 	if err != nil {
-		return fmt.Errorf("%s: builtin undefined", id.Ident())
+		return locale.Errorf("%s: builtin undefined", id.Ident())
 	}
-	if ok := expr.Vectorizable(); ok {
+	if ok := vectorizable(expr); ok {
 		return v.vectorizeCall(fn, expr.Args())
 	}
-	var args []value.Value
-	for _, a := range expr.Args() {
+	var (
+		args  []value.Value
+		bt, _ = gbs.Get(id.Ident())
+	)
+	for i, a := range expr.Args() {
+		if bt.Deferred(i) {
+			// arguments of deferrable parameters, eg the branches of if, are
+			// only evaluated when the builtin uses them
+			args = append(args, gbs.Defer(func() value.Value {
+				arg, err := v.visitNormalize(a)
+				if err != nil {
+					return value.ErrValue
+				}
+				return arg
+			}))
+			continue
+		}
 		arg, err := v.visitNormalize(a)
 		if err != nil {
 			return err
 		}
 		args = append(args, arg)
 	}
-	val := fn(args)
+	val := fn(v.ctx.blanks.blankArgs(id.Ident(), args))
 	v.pushValue(val)
 	return nil
 }
 
+// vectorizable reports whether a call is applied to each line of its column
+// arguments. The columns given to special forms, eg sort(view, B), are not
+// values and do not make the call vectorizable.
+func vectorizable(expr parse.Call) bool {
+	for _, a := range expr.Args() {
+		if c, ok := a.(parse.Call); ok {
+			id, ok := c.Name().(parse.Identifier)
+			if ok {
+				if _, ok := specials[strings.ToLower(id.Ident())]; ok {
+					continue
+				}
+			}
+			if vectorizable(c) {
+				return true
+			}
+			continue
+		}
+		if x, ok := a.(interface{ Vectorizable() bool }); ok && x.Vectorizable() {
+			return true
+		}
+	}
+	return false
+}
+
 func (v *evaluator) vectorizeCall(fn gbs.BuiltinFunc, args []parse.Expr) error {
 	var (
 		count  int
@@ -945,33 +1400,148 @@ func (v *evaluator) VisitSlice(expr parse.Slice) error {
 	if err != nil {
 		return err
 	}
+	if file, ok := val.(*runtime.File); ok {
+		val, _ = file.Active()
+	}
+	if arr, ok := val.(value.Array); ok {
+		val, err := indexArray(arr, expr.Expr())
+		if err != nil {
+			return err
+		}
+		v.pushValue(val)
+		return nil
+	}
 	view, ok := val.(*runtime.View)
 	if !ok {
-		return fmt.Errorf("slice can only be used on view")
+		return locale.Errorf("slice can only be used on view")
+	}
+	sliced := expr.Expr()
+	if view.Names() != nil && !v.isPredicate(sliced) {
+		sliced = parse.ResolveNames(sliced, view.ColumnIndex)
 	}
-	switch e := expr.Expr().(type) {
+	switch e := sliced.(type) {
 	case parse.RangeAddr:
 		view = view.BoundedView(e.Range())
+	case parse.ColumnAddr:
+		view = view.ProjectView(layout.SelectSingle(e.Column))
 	case parse.IntervalList:
 		sel, err := e.Selection()
 		if err != nil {
 			return err
 		}
 		view = view.ProjectView(sel)
-	case parse.Binary, parse.And, parse.Or, parse.Not:
+	case parse.RowWindow:
+		view, err = windowView(view, e)
+		if err != nil {
+			return err
+		}
+	case parse.Binary, parse.And, parse.Or, parse.Not, parse.Call:
 		p := runtime.NewExprPredicate(grid.NewFormula(e))
 		view = view.FilterView(p)
 	case parse.Identifier:
+		val, err := v.resolve(e.Ident())
+		if err != nil {
+			return err
+		}
+		switch f := val.(type) {
+		case *runtime.Filter:
+			view = view.FilterView(f)
+		case *userFunc:
+			view = view.FilterView(f)
+		}
 	default:
-		return fmt.Errorf("invalid slice expression")
+		return locale.Errorf("invalid slice expression")
 	}
 	v.pushValue(view)
 	return nil
 }
 
+// indexArray gives the value at the given index of an array of a single line
+// or column. Indexes start at zero and #REF! is given for an index after the
+// end of the array.
+func indexArray(arr value.Array, expr parse.Expr) (value.Value, error) {
+	n, ok := expr.(parse.Number)
+	if !ok {
+		return nil, locale.Errorf("array can only be indexed by number")
+	}
+	dim := arr.Dimension()
+	if dim.Lines > 1 && dim.Columns > 1 {
+		return nil, locale.Errorf("only array of a single line or column can be indexed")
+	}
+	ix := int64(n.Float())
+	if ix < 0 || ix >= dim.Lines*dim.Columns {
+		return value.ErrRef, nil
+	}
+	if dim.Lines == 1 {
+		return arr.At(0, int(ix)), nil
+	}
+	return arr.At(int(ix), 0), nil
+}
+
+// isPredicate reports whether expr is the name of a filter or of a function
+// used to select the rows of a view.
+func (v *evaluator) isPredicate(expr parse.Expr) bool {
+	id, ok := expr.(parse.Identifier)
+	if !ok {
+		return false
+	}
+	val, _ := v.resolve(id.Ident())
+	switch val.(type) {
+	case *runtime.Filter, *userFunc:
+		return true
+	default:
+		return false
+	}
+}
+
+// windowView bounds view to the lines of the window before selecting its
+// columns. The last line is the last line of the view when it is not given or
+// when it is after it.
+func windowView(view *runtime.View, w parse.RowWindow) (*runtime.View, error) {
+	from, to, err := w.Lines()
+	if err != nil {
+		return nil, err
+	}
+	bd := view.View().Bounds()
+	if from == 0 {
+		from = 1
+	}
+	if to == 0 || to > bd.Height() {
+		to = bd.Height()
+	}
+	if from > to {
+		return nil, locale.Errorf("slice: line %d after the last line of the view", from)
+	}
+	var (
+		start = layout.NewPosition(from, 1)
+		end   = layout.NewPosition(to, bd.Width())
+	)
+	view = view.BoundedView(layout.NewRange(start, end))
+
+	cols, ok := w.Columns().(parse.Selectable)
+	if !ok {
+		return nil, locale.Errorf("invalid slice expression")
+	}
+	sel, err := cols.Selection()
+	if err != nil {
+		return nil, err
+	}
+	return view.ProjectView(sel), nil
+}
+
 func (v *evaluator) VisitIdentifier(expr parse.Identifier) error {
-	val, _ := v.resolve(expr.Ident())
-	v.pushValue(val)
+	switch expr.Ident() {
+	case "true":
+		v.pushValue(value.Boolean(true))
+	case "false":
+		v.pushValue(value.Boolean(false))
+	default:
+		if v.ctx.strict && !v.ctx.Defined(expr.Ident()) {
+			return locale.Errorf("%s: undefined variable", expr.Ident())
+		}
+		val, _ := v.resolve(expr.Ident())
+		v.pushValue(val)
+	}
 	return nil
 }
 
@@ -0,0 +1,97 @@
+package eval
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/midbel/dockit/formula/env"
+	"github.com/midbel/dockit/formula/runtime"
+	"github.com/midbel/dockit/value"
+)
+
+// Session runs scripts one after the other in the same context. Files imported
+// by a script remain available to the following scripts without being read
+// again, as the variables, the defaults and the pragmas set by the previous
+// scripts.
+//
+// A Session is not safe for concurrent use.
+type Session struct {
+	engine *Engine
+	ctx    *EngineContext
+	eval   *evaluator
+}
+
+func (e *Engine) NewSession(environ *env.Environment) (*Session, error) {
+	ctx := e.newContext(environ)
+	s := Session{
+		engine: e,
+		ctx:    ctx,
+		eval:   evalScript(ctx),
+	}
+	if err := s.ctx.Configure(e.config); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SetOutput changes the writer used by the print statements of the following
+// scripts.
+func (s *Session) SetOutput(w io.Writer) error {
+	s.ctx.stdout = w
+	p, err := s.ctx.config.Printer(w)
+	if err != nil {
+		return err
+	}
+	s.ctx.printer = p
+	return nil
+}
+
+// Import opens the given file and makes it the default file of the session.
+// The file is available in scripts under the given alias or, if empty, under
+// its name without extension.
+func (s *Session) Import(file, alias string) error {
+	var (
+		format  = strings.TrimPrefix(filepath.Ext(file), ".")
+		options = s.ctx.loaderOptions(format, "", nil)
+	)
+	f, err := s.ctx.Open(file, options)
+	if err != nil {
+		return err
+	}
+	if alias == "" {
+		alias = fileAlias(file)
+	}
+	val := runtime.NewFileValue(f, false)
+	s.ctx.Define(alias, val)
+	s.ctx.SetDefault(val)
+	return nil
+}
+
+func (s *Session) Exec(r io.Reader) (value.Value, error) {
+	expr, err := s.engine.parse(r, s.ctx, s.ctx.config)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.eval.Values(expr)
+	if err != nil || len(list) == 0 {
+		return value.ErrValue, err
+	}
+	return list[len(list)-1], nil
+}
+
+// Eval runs the given statements and gives the values of those leaving one,
+// as expressions do, so that hosts such as a REPL can show them. Statements
+// like assignments or prints give no value.
+func (s *Session) Eval(stmt string) ([]value.Value, error) {
+	expr, err := s.engine.parse(strings.NewReader(stmt), s.ctx, s.ctx.config)
+	if err != nil {
+		return nil, err
+	}
+	return s.eval.Values(expr)
+}
+
+// Print writes a value with the printer of the session.
+func (s *Session) Print(v value.Value) error {
+	return s.ctx.Print(v)
+}
@@ -0,0 +1,66 @@
+package eval
+
+import (
+	"strings"
+
+	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/formula/runtime"
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/internal/locale"
+	"github.com/midbel/dockit/value"
+)
+
+// sortForm orders the rows of a view by one or more columns. Each column can
+// be followed by asc or desc to give the direction of the sort, eg
+// sort(data, C, desc, A).
+type sortForm struct{}
+
+func (sortForm) Run(eg Runnable, args []parse.Expr, ctx *EngineContext) (value.Value, error) {
+	if len(args) < 2 {
+		return value.ErrValue, locale.Errorf("sort: view and columns expected")
+	}
+	val, err := eg.Run(args[0])
+	if err != nil {
+		return value.ErrValue, err
+	}
+	if file, ok := val.(*runtime.File); ok {
+		val, _ = file.Active()
+	}
+	view, ok := val.(*runtime.View)
+	if !ok {
+		return value.ErrValue, locale.Errorf("sort: view expected")
+	}
+	var keys []grid.SortKey
+	for _, a := range args[1:] {
+		if dir, ok := sortDirection(a); ok {
+			if len(keys) == 0 {
+				return value.ErrValue, locale.Errorf("sort: column expected before %s", a)
+			}
+			keys[len(keys)-1].Desc = dir
+			continue
+		}
+		col, err := columnArg("sort", a)
+		if err != nil {
+			return value.ErrValue, err
+		}
+		keys = append(keys, grid.SortKey{
+			Column: col,
+		})
+	}
+	return view.SortView(keys), nil
+}
+
+func sortDirection(expr parse.Expr) (bool, bool) {
+	id, ok := expr.(parse.Identifier)
+	if !ok {
+		return false, false
+	}
+	switch strings.ToLower(id.Ident()) {
+	case "asc":
+		return false, true
+	case "desc":
+		return true, true
+	default:
+		return false, false
+	}
+}
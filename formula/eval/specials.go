@@ -1,9 +1,13 @@
 package eval
 
 import (
+	"strings"
+
 	"github.com/midbel/dockit/formula/parse"
 	"github.com/midbel/dockit/formula/runtime"
+	"github.com/midbel/dockit/internal/locale"
 	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/oxml"
 	"github.com/midbel/dockit/value"
 )
 
@@ -14,8 +18,17 @@ type SpecialForm interface {
 type SpecialFunction func(Runnable, []parse.Expr, *EngineContext) (value.Value, error)
 
 var specials = map[string]SpecialForm{
-	"inspect": inspectForm{},
-	"kindof":  kindofForm{},
+	"inspect":     inspectForm{},
+	"kindof":      kindofForm{},
+	"formula_of":  formulaOfForm{},
+	"add_table":   addTableForm{},
+	"table_style": tableStyleForm{},
+	"sort":        sortForm{},
+	"filter":      filterForm{},
+	"join":        joinForm{},
+	"pivot":       pivotForm{},
+	"unpivot":     unpivotForm{},
+	"distinct":    distinctForm{},
 }
 
 type inspectForm struct{}
@@ -118,6 +131,11 @@ func (i inspectForm) inspectSlice(eg Runnable, expr parse.Slice, ctx *EngineCont
 	case parse.IntervalList:
 		iv.Set("type", value.Text("column"))
 		iv.Set("count", value.Float(e.Count()))
+	case parse.RowWindow:
+		iv.Set("type", value.Text("window"))
+		from, to, _ := e.Lines()
+		iv.Set("from", value.Float(from))
+		iv.Set("to", value.Float(to))
 	case parse.Binary, parse.And, parse.Or, parse.Not:
 		iv.Set("type", value.Text("logical"))
 	default:
@@ -169,3 +187,229 @@ func (kindofForm) Run(eg Runnable, args []parse.Expr, ctx *EngineContext) (value
 	}
 	return value.Text(name), nil
 }
+
+// formulaOfForm gives the formula stored in a cell as a deferred expression.
+// Its relative references stay relative to the cell it comes from so that
+// assigning it elsewhere shift them accordingly.
+type formulaOfForm struct{}
+
+func (f formulaOfForm) Run(eg Runnable, args []parse.Expr, ctx *EngineContext) (value.Value, error) {
+	if len(args) != 1 {
+		return value.ErrValue, nil
+	}
+	var (
+		view *runtime.View
+		addr parse.Expr
+	)
+	switch a := args[0].(type) {
+	case parse.CellAddr:
+		view, addr = ctx.CurrentActiveView(), a
+	case parse.CellAccess:
+		val, err := eg.Run(a.Expr())
+		if err != nil {
+			return value.ErrValue, err
+		}
+		if file, ok := val.(*runtime.File); ok {
+			val, _ = file.Active()
+		}
+		view, _ = val.(*runtime.View)
+		addr = a.Addr()
+	default:
+		return value.ErrValue, nil
+	}
+	cell, ok := addr.(parse.CellAddr)
+	if !ok || view == nil {
+		return value.ErrValue, nil
+	}
+	fm, ok := view.FormulaAt(cell.Position).(interface{ Expr() parse.Expr })
+	if !ok {
+		return value.ErrNA, nil
+	}
+	return parse.NewDeferredAt(fm.Expr(), cell.Position.WithoutSheet()), nil
+}
+
+// addTableForm creates a table on a range of a sheet of a xlsx file. The range
+// is given as an address and is not evaluated:
+//
+//	add_table(data, A1:D10, "sales", "TableStyleMedium9")
+type addTableForm struct{}
+
+func (addTableForm) Run(eg Runnable, args []parse.Expr, ctx *EngineContext) (value.Value, error) {
+	if len(args) < 3 || len(args) > 4 {
+		return value.ErrValue, nil
+	}
+	val, err := eg.Run(args[0])
+	if err != nil {
+		return value.ErrValue, err
+	}
+	if file, ok := val.(*runtime.File); ok {
+		val, _ = file.Active()
+	}
+	view, ok := val.(*runtime.View)
+	if !ok {
+		return value.ErrValue, locale.Errorf("expected view")
+	}
+	addr, ok := args[1].(parse.RangeAddr)
+	if !ok {
+		return value.ErrValue, locale.Errorf("range expected")
+	}
+	strs, err := runStrings(eg, args[2:])
+	if err != nil {
+		return value.ErrValue, err
+	}
+	if ro, _ := view.Get("readonly").(value.Boolean); ro {
+		return value.ErrValue, runtime.ErrReadOnly
+	}
+	sheet, ok := view.View().(*oxml.Sheet)
+	if !ok {
+		return value.ErrValue, locale.Errorf("tables can only be added to xlsx sheets")
+	}
+	var style string
+	if len(strs) > 1 {
+		style = strs[1]
+	}
+	t, err := sheet.AddTable(strs[0], addr.Range(), style)
+	if err != nil {
+		return value.ErrValue, err
+	}
+	return value.Text(t.Name), nil
+}
+
+// tableStyleForm defines a custom table style in a xlsx file. It is followed
+// by pairs of table elements and the properties of their format:
+//
+//	table_style(data, "report", "headerRow", "bold fill=#1F4E78 color=#FFFFFF")
+type tableStyleForm struct{}
+
+func (tableStyleForm) Run(eg Runnable, args []parse.Expr, ctx *EngineContext) (value.Value, error) {
+	if len(args) < 4 || len(args)%2 != 0 {
+		return value.ErrValue, nil
+	}
+	val, err := eg.Run(args[0])
+	if err != nil {
+		return value.ErrValue, err
+	}
+	if view, ok := val.(*runtime.View); ok && view.File() != nil {
+		val = view.File()
+	}
+	file, ok := val.(*runtime.File)
+	if !ok {
+		return value.ErrValue, locale.Errorf("expected file/view")
+	}
+	strs, err := runStrings(eg, args[1:])
+	if err != nil {
+		return value.ErrValue, err
+	}
+	if ro, _ := file.Get("readonly").(value.Boolean); ro {
+		return value.ErrValue, runtime.ErrReadOnly
+	}
+	wb, ok := file.File().(*oxml.File)
+	if !ok {
+		return value.ErrValue, locale.Errorf("table styles can only be defined in xlsx files")
+	}
+	elements := make(map[string]oxml.DiffStyle)
+	for i := 1; i < len(strs); i += 2 {
+		ds, err := oxml.ParseDiffStyle(strs[i+1])
+		if err != nil {
+			return value.ErrValue, err
+		}
+		elements[strs[i]] = ds
+	}
+	if err := wb.DefineTableStyle(strs[0], elements); err != nil {
+		return value.ErrValue, err
+	}
+	return value.Text(strs[0]), nil
+}
+
+func runStrings(eg Runnable, args []parse.Expr) ([]string, error) {
+	var list []string
+	for _, a := range args {
+		val, err := eg.Run(a)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, val.String())
+	}
+	return list, nil
+}
+
+// columnArg gives the index of the column given as argument of the special
+// form name: a column, a number or the letter of a column.
+func columnArg(name string, expr parse.Expr) (int64, error) {
+	var str string
+	switch e := expr.(type) {
+	case parse.ColumnAddr:
+		return e.Column, nil
+	case parse.Number:
+		if n := int64(e.Float()); n >= 1 {
+			return n, nil
+		}
+		return 0, locale.Errorf("%s: %s: invalid column", name, e)
+	case parse.Identifier:
+		str = e.Ident()
+	case parse.Literal:
+		str = e.Text()
+	default:
+		return 0, locale.Errorf("%s: %s: invalid column", name, expr)
+	}
+	col, size := layout.ParseIndex(str)
+	if size == 0 || size != len(str) {
+		return 0, locale.Errorf("%s: %s: invalid column", name, str)
+	}
+	return col, nil
+}
+
+// viewArg gives the view given as argument of the special form name. The active
+// sheet is used when the argument is a file.
+func viewArg(eg Runnable, name string, expr parse.Expr) (*runtime.View, error) {
+	val, err := eg.Run(expr)
+	if err != nil {
+		return nil, err
+	}
+	if file, ok := val.(*runtime.File); ok {
+		val, _ = file.Active()
+	}
+	view, ok := val.(*runtime.View)
+	if !ok {
+		return nil, locale.Errorf("%s: view expected", name)
+	}
+	return view, nil
+}
+
+// selectionArg gives the columns given as argument of the special form name:
+// a column, a list of columns separated by semicolons or a text with the same
+// list.
+func selectionArg(name string, expr parse.Expr) (layout.Selection, error) {
+	var str string
+	switch e := expr.(type) {
+	case parse.ColumnAddr:
+		return layout.SelectSingle(e.Column), nil
+	case parse.Identifier:
+		str = e.Ident()
+	case parse.Literal:
+		str = e.Text()
+	case parse.IntervalList:
+		return e.Selection()
+	default:
+		return nil, locale.Errorf("%s: %s: invalid columns", name, expr)
+	}
+	sel, err := layout.SelectionFromString(str)
+	if err != nil {
+		return nil, locale.Errorf("%s: %s: invalid columns", name, str)
+	}
+	return sel, nil
+}
+
+// optionArg gives the name and the value of an option given as assignment to
+// the special form name, eg on := A.
+func optionArg(name string, expr parse.Expr) (string, parse.Expr, error) {
+	opt, ok := expr.(parse.Assignment)
+	if !ok {
+		return "", nil, locale.Errorf("%s: %s: option expected", name, expr)
+	}
+	id, ok := opt.Ident().(parse.Identifier)
+	if !ok {
+		return "", nil, locale.Errorf("%s: %s: option expected", name, expr)
+	}
+	return strings.ToLower(id.Ident()), opt.Expr(), nil
+}
@@ -0,0 +1,83 @@
+package eval
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/layout"
+)
+
+// tracker keeps the workbooks opened or created by the scripts of an engine.
+// Their formulas are evaluated again when cells changed: workbooks keeping
+// the dependencies of their formulas only evaluate the formulas affected by
+// the changes.
+type tracker struct {
+	mu    sync.Mutex
+	files []grid.File
+}
+
+func newTracker() *tracker {
+	return new(tracker)
+}
+
+func (t *tracker) track(file grid.File) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !slices.Contains(t.files, file) {
+		t.files = append(t.files, file)
+	}
+}
+
+func (t *tracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.files = nil
+}
+
+func (t *tracker) invalidate(rg *layout.Range) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var found bool
+	for _, f := range t.files {
+		// workbooks without the sheet are not affected
+		if err := grid.Invalidate(f, rg); err == nil {
+			found = true
+		}
+	}
+	if !found {
+		return grid.ErrFound
+	}
+	return nil
+}
+
+func (t *tracker) recalc() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, f := range t.files {
+		if err := f.Sync(); err != nil && !errors.Is(err, grid.ErrSupported) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Invalidate marks the cells of the given range as changed in the workbooks
+// used by the scripts of the engine. Ranges without sheet refer to the active
+// sheet of each workbook. The formulas depending on the cells are evaluated
+// by the next call to Recalc.
+func (e *Engine) Invalidate(rg *layout.Range) error {
+	return e.tracker.invalidate(rg)
+}
+
+// Recalc evaluates the formulas of the workbooks used by the scripts of the
+// engine that depend on cells changed since the last evaluation.
+func (e *Engine) Recalc() error {
+	return e.tracker.recalc()
+}
@@ -0,0 +1,187 @@
+package eval
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/value"
+)
+
+// exportedSheet gives the sheet written by the writers of flat formats: the
+// sheet named by the sheet option or the active sheet of the file.
+func exportedSheet(file grid.File, opts LoaderOptions) (grid.View, error) {
+	if name := opts.getAsString("sheet"); name != "" {
+		return file.Sheet(name)
+	}
+	return file.ActiveSheet()
+}
+
+// exportedRows gives the rows of the sheet. With the header option, the first
+// row gives the names of the fields of the other rows.
+func exportedRows(file grid.File, opts LoaderOptions) ([]string, [][]value.Value, error) {
+	sh, err := exportedSheet(file, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		header = opts.getAsBool("header", true)
+		fields []string
+		rows   [][]value.Value
+	)
+	for _, cells := range sh.Rows() {
+		row := make([]value.Value, len(cells))
+		for i, v := range cells {
+			if v == nil {
+				v = value.Empty()
+			}
+			row[i] = v
+		}
+		if header && fields == nil {
+			fields = make([]string, 0, len(row))
+			for _, v := range row {
+				fields = append(fields, fmt.Sprint(v))
+			}
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return fields, rows, nil
+}
+
+// fieldName gives the name of the field at the given index. Fields without
+// name are named after their position.
+func fieldName(fields []string, ix int) string {
+	if ix < len(fields) && fields[ix] != "" {
+		return fields[ix]
+	}
+	return fmt.Sprintf("field%d", ix+1)
+}
+
+type jsonWriter struct{}
+
+func JsonWriter() Writer {
+	return jsonWriter{}
+}
+
+// Write writes the rows of a sheet as an array of objects or, without header,
+// as an array of arrays.
+func (jsonWriter) Write(out string, file grid.File, opts LoaderOptions) error {
+	fields, rows, err := exportedRows(file, opts)
+	if err != nil {
+		return err
+	}
+	w, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	list := make([]any, 0, len(rows))
+	for _, row := range rows {
+		if !opts.getAsBool("header", true) {
+			arr := make([]any, 0, len(row))
+			for _, v := range row {
+				arr = append(arr, scalarOf(v))
+			}
+			list = append(list, arr)
+			continue
+		}
+		obj := make(map[string]any)
+		for i, v := range row {
+			obj[fieldName(fields, i)] = scalarOf(v)
+		}
+		list = append(list, obj)
+	}
+	enc := json.NewEncoder(w)
+	if opts.getAsBool("indent", false) {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(list)
+}
+
+type xmlWriter struct{}
+
+func XmlWriter() Writer {
+	return xmlWriter{}
+}
+
+// Write writes the rows of a sheet as elements of a root element. Names of
+// the elements are given by the root and record options.
+func (xmlWriter) Write(out string, file grid.File, opts LoaderOptions) error {
+	fields, rows, err := exportedRows(file, opts)
+	if err != nil {
+		return err
+	}
+	w, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	var (
+		root = xmlName(opts.getAsString("root"), "rows")
+		elem = xmlName(opts.getAsString("record"), "row")
+	)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if opts.getAsBool("indent", false) {
+		enc.Indent("", "  ")
+	}
+	start := xml.StartElement{Name: xml.Name{Local: root}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		el := xml.StartElement{Name: xml.Name{Local: elem}}
+		if err := enc.EncodeToken(el); err != nil {
+			return err
+		}
+		for i, v := range row {
+			field := xml.StartElement{Name: xml.Name{Local: xmlName(fieldName(fields, i), "field")}}
+			if err := enc.EncodeElement(fmt.Sprint(v), field); err != nil {
+				return err
+			}
+		}
+		if err := enc.EncodeToken(el.End()); err != nil {
+			return err
+		}
+	}
+	if err := enc.EncodeToken(start.End()); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// xmlName replaces the characters not allowed in the names of elements by
+// underscores.
+func xmlName(name, def string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '.' || r == '_' {
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return def
+	}
+	if r, _ := utf8.DecodeRuneInString(name); !unicode.IsLetter(r) && r != '_' {
+		name = "_" + name
+	}
+	return name
+}
+
+func scalarOf(v value.Value) any {
+	if s, ok := v.(value.ScalarValue); ok {
+		return s.Scalar()
+	}
+	return nil
+}
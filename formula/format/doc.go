@@ -4,7 +4,7 @@
 // Format walks formula/parse AST nodes and writes a textual formula using a
 // DialectFormat. The package currently provides Oxml and Ods dialects, exposed
 // through FormatOxml and FormatOds helpers. The dialect controls formula
-// prefixes, argument separators, and address/range formatting.
+// prefixes, argument separators, function names and address/range formatting.
 //
 // The formatter covers the core expression nodes used by formula parsing:
 // identifiers, literals, numbers, calls, unary and binary operators, postfix
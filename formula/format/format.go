@@ -1,17 +1,24 @@
 package format
 
 import (
+	"fmt"
 	"io"
 	"strconv"
 	"strings"
 
 	"github.com/midbel/dockit/formula/op"
 	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/grid/builtins"
 )
 
+var ErrFormat = fmt.Errorf("expression can not be formatted")
+
 type DialectFormat interface {
 	Prefix() string
 	ArgSeparator() string
+	UnionOperator() string
+	IntersectOperator() string
+	FormatFunc(string) string
 	FormatCell(parse.CellAddr) (string, error)
 	FormatRange(parse.RangeAddr) (string, error)
 }
@@ -26,9 +33,22 @@ func (odsFormatter) ArgSeparator() string {
 	return ";"
 }
 
+func (odsFormatter) UnionOperator() string {
+	return "~"
+}
+
+func (odsFormatter) IntersectOperator() string {
+	return "!"
+}
+
+func (odsFormatter) FormatFunc(name string) string {
+	return functionName(name)
+}
+
 func (odsFormatter) FormatCell(expr parse.CellAddr) (string, error) {
 	var str strings.Builder
 	str.WriteString("[")
+	writeOdsSheet(&str, expr.Sheet)
 	str.WriteString(".")
 	writeCellAddr(&str, expr)
 	str.WriteString("]")
@@ -38,6 +58,7 @@ func (odsFormatter) FormatCell(expr parse.CellAddr) (string, error) {
 func (odsFormatter) FormatRange(expr parse.RangeAddr) (string, error) {
 	var str strings.Builder
 	str.WriteString("[")
+	writeOdsSheet(&str, expr.StartAt().Sheet)
 	str.WriteString(".")
 	writeCellAddr(&str, expr.StartAt())
 	io.WriteString(&str, ":")
@@ -57,14 +78,45 @@ func (oxmlFormatter) ArgSeparator() string {
 	return ","
 }
 
+func (oxmlFormatter) UnionOperator() string {
+	return ","
+}
+
+func (oxmlFormatter) IntersectOperator() string {
+	return " "
+}
+
+// functions added to Excel after 2007 are written with the prefix expected by
+// Excel, otherwise they are evaluated as #NAME?.
+var futureFunctions = map[string]struct{}{
+	"CONCAT":   {},
+	"IFNA":     {},
+	"IFS":      {},
+	"MAXIFS":   {},
+	"MINIFS":   {},
+	"SWITCH":   {},
+	"TEXTJOIN": {},
+	"XOR":      {},
+}
+
+func (oxmlFormatter) FormatFunc(name string) string {
+	name = functionName(name)
+	if _, ok := futureFunctions[name]; ok {
+		name = "_xlfn." + name
+	}
+	return name
+}
+
 func (oxmlFormatter) FormatCell(expr parse.CellAddr) (string, error) {
 	var str strings.Builder
+	writeOxmlSheet(&str, expr.Sheet)
 	writeCellAddr(&str, expr)
 	return str.String(), nil
 }
 
 func (f oxmlFormatter) FormatRange(expr parse.RangeAddr) (string, error) {
 	var str strings.Builder
+	writeOxmlSheet(&str, expr.StartAt().Sheet)
 	writeCellAddr(&str, expr.StartAt())
 	io.WriteString(&str, ":")
 	writeCellAddr(&str, expr.EndAt())
@@ -99,6 +151,9 @@ func Format(expr parse.Expr, dialect DialectFormat) (string, error) {
 func formatExpr(w io.Writer, expr parse.Expr, dialect DialectFormat) error {
 	switch expr := expr.(type) {
 	default:
+		return fmt.Errorf("%s: %w", expr, ErrFormat)
+	case parse.Deferred:
+		return formatExpr(w, expr.Expr(), dialect)
 	case parse.CellAddr:
 		str, err := dialect.FormatCell(expr)
 		if err != nil {
@@ -111,64 +166,286 @@ func formatExpr(w io.Writer, expr parse.Expr, dialect DialectFormat) error {
 			return err
 		}
 		io.WriteString(w, str)
+	case parse.CellAccess:
+		return formatCellAccess(w, expr, dialect)
+	case parse.TableRef:
+		if dialect != Oxml {
+			return fmt.Errorf("%s: %w", expr, ErrFormat)
+		}
+		io.WriteString(w, expr.String())
 	case parse.Identifier:
-		io.WriteString(w, expr.Ident())
+		ident := expr.Ident()
+		if strings.EqualFold(ident, "true") || strings.EqualFold(ident, "false") {
+			ident = strings.ToUpper(ident)
+		}
+		io.WriteString(w, ident)
 	case parse.Literal:
 		io.WriteString(w, "\"")
-		io.WriteString(w, expr.Text())
+		io.WriteString(w, strings.ReplaceAll(expr.Text(), "\"", "\"\""))
 		io.WriteString(w, "\"")
 	case parse.Number:
 		io.WriteString(w, expr.String())
+	case parse.Date:
+		return formatDate(w, expr, dialect)
 	case parse.Call:
-		if err := formatExpr(w, expr.Name(), dialect); err != nil {
-			return err
+		id, ok := expr.Name().(parse.Identifier)
+		if !ok {
+			return fmt.Errorf("%s: %w", expr, ErrFormat)
 		}
-		io.WriteString(w, "(")
-		for i, a := range expr.Args() {
-			if i > 0 {
-				io.WriteString(w, dialect.ArgSeparator())
-				io.WriteString(w, " ")
-			}
-			if err := formatExpr(w, a, dialect); err != nil {
-				return err
-			}
-		}
-		io.WriteString(w, ")")
+		return formatCall(w, dialect.FormatFunc(id.Ident()), expr.Args(), dialect)
+	case parse.Not:
+		return formatCall(w, dialect.FormatFunc("not"), []parse.Expr{expr.Expr()}, dialect)
+	case parse.And:
+		return formatCall(w, dialect.FormatFunc("and"), []parse.Expr{expr.Left(), expr.Right()}, dialect)
+	case parse.Or:
+		return formatCall(w, dialect.FormatFunc("or"), []parse.Expr{expr.Left(), expr.Right()}, dialect)
 	case parse.Binary:
-		if err := formatExpr(w, expr.Left(), dialect); err != nil {
+		if expr.Op() == op.Union || expr.Op() == op.Intersect {
+			return formatReference(w, expr, dialect)
+		}
+		pow := precedence(expr.Op())
+		if err := formatOperand(w, expr.Left(), pow, dialect); err != nil {
 			return err
 		}
 		io.WriteString(w, " ")
 		io.WriteString(w, op.Symbol(expr.Op()))
 		io.WriteString(w, " ")
-		if err := formatExpr(w, expr.Right(), dialect); err != nil {
+		if err := formatOperand(w, expr.Right(), pow+1, dialect); err != nil {
 			return err
 		}
 	case parse.Unary:
 		io.WriteString(w, op.Symbol(expr.Op()))
-		if err := formatExpr(w, expr.Expr(), dialect); err != nil {
+		if err := formatOperand(w, expr.Expr(), powUnary, dialect); err != nil {
 			return err
 		}
 	case parse.Postfix:
-		if err := formatExpr(w, expr.Expr(), dialect); err != nil {
+		if err := formatOperand(w, expr.Expr(), powPercent, dialect); err != nil {
 			return err
 		}
 		io.WriteString(w, op.Symbol(expr.Op()))
-	case parse.CellAccess:
 	}
 	return nil
 }
 
+// formatDate writes a date literal of the scripts as a call to DATE. The time
+// of the date, if any, is added as a fraction of day.
+func formatDate(w io.Writer, expr parse.Date, dialect DialectFormat) error {
+	when := expr.Time()
+	args := []parse.Expr{
+		parse.NewNumber(float64(when.Year())),
+		parse.NewNumber(float64(when.Month())),
+		parse.NewNumber(float64(when.Day())),
+	}
+	if err := formatCall(w, dialect.FormatFunc("date"), args, dialect); err != nil {
+		return err
+	}
+	secs := when.Hour()*3600 + when.Minute()*60 + when.Second()
+	if secs == 0 {
+		return nil
+	}
+	io.WriteString(w, "+")
+	io.WriteString(w, parse.NewNumber(float64(secs)/86400).String())
+	return nil
+}
+
+func formatCall(w io.Writer, name string, args []parse.Expr, dialect DialectFormat) error {
+	io.WriteString(w, name)
+	io.WriteString(w, "(")
+	for i, a := range args {
+		if i > 0 {
+			io.WriteString(w, dialect.ArgSeparator())
+			io.WriteString(w, " ")
+		}
+		if err := formatExpr(w, a, dialect); err != nil {
+			return err
+		}
+	}
+	io.WriteString(w, ")")
+	return nil
+}
+
+// formatReference writes the union of references between parenthesis and
+// the intersection of references with the operator of the dialect.
+func formatReference(w io.Writer, expr parse.Binary, dialect DialectFormat) error {
+	if expr.Op() == op.Intersect {
+		if err := formatOperand(w, expr.Left(), powReference, dialect); err != nil {
+			return err
+		}
+		io.WriteString(w, dialect.IntersectOperator())
+		return formatOperand(w, expr.Right(), powReference, dialect)
+	}
+	io.WriteString(w, "(")
+	for i, e := range unionAreas(expr) {
+		if i > 0 {
+			io.WriteString(w, dialect.UnionOperator())
+		}
+		if err := formatOperand(w, e, powReference, dialect); err != nil {
+			return err
+		}
+	}
+	io.WriteString(w, ")")
+	return nil
+}
+
+func unionAreas(expr parse.Expr) []parse.Expr {
+	b, ok := expr.(parse.Binary)
+	if !ok || b.Op() != op.Union {
+		return []parse.Expr{expr}
+	}
+	return append(unionAreas(b.Left()), unionAreas(b.Right())...)
+}
+
+func formatCellAccess(w io.Writer, expr parse.CellAccess, dialect DialectFormat) error {
+	var sheet string
+	switch e := expr.Expr().(type) {
+	case parse.Identifier:
+		sheet = e.Ident()
+	case parse.Literal:
+		sheet = e.Text()
+	case parse.SheetSpan:
+		if dialect != Oxml {
+			return fmt.Errorf("%s: %w", expr, ErrFormat)
+		}
+		sheet = e.String()
+	case parse.ExternalSheet:
+		if dialect != Oxml {
+			return fmt.Errorf("%s: %w", expr, ErrFormat)
+		}
+		sheet = e.String()
+	default:
+		return fmt.Errorf("%s: %w", expr, ErrFormat)
+	}
+	switch a := expr.Addr().(type) {
+	case parse.CellAddr:
+		a.Sheet = sheet
+		return formatExpr(w, a, dialect)
+	case parse.RangeAddr:
+		start, end := a.StartAt(), a.EndAt()
+		start.Sheet = sheet
+		return formatExpr(w, parse.NewRangeAddr(start, end), dialect)
+	default:
+		return fmt.Errorf("%s: %w", expr, ErrFormat)
+	}
+}
+
+const (
+	powLowest = iota
+	powCmp
+	powConcat
+	powAdd
+	powMul
+	powPow
+	powUnary
+	powPercent
+	powReference
+)
+
+func precedence(oper op.Op) int {
+	switch oper {
+	case op.Eq, op.Ne, op.Lt, op.Le, op.Gt, op.Ge:
+		return powCmp
+	case op.Concat:
+		return powConcat
+	case op.Add, op.Sub:
+		return powAdd
+	case op.Mul, op.Div:
+		return powMul
+	case op.Pow:
+		return powPow
+	case op.Union, op.Intersect:
+		return powReference
+	default:
+		return powLowest
+	}
+}
+
+// formatOperand writes the operand of an operator, between parenthesis when
+// it binds less tightly than the operator itself.
+func formatOperand(w io.Writer, expr parse.Expr, pow int, dialect DialectFormat) error {
+	var group bool
+	switch e := expr.(type) {
+	case parse.Binary:
+		group = precedence(e.Op()) < pow
+	case parse.Unary:
+		group = powUnary < pow
+	}
+	if group {
+		io.WriteString(w, "(")
+	}
+	if err := formatExpr(w, expr, dialect); err != nil {
+		return err
+	}
+	if group {
+		io.WriteString(w, ")")
+	}
+	return nil
+}
+
+// functionName gives the name of the builtin as known by spreadsheet
+// applications, resolving the aliases only available to scripts.
+func functionName(name string) string {
+	if b, err := builtins.Get(name); err == nil {
+		name = b.Name
+	}
+	return strings.ToUpper(name)
+}
+
+func writeOxmlSheet(w io.Writer, sheet string) {
+	if sheet == "" {
+		return
+	}
+	writeSheetName(w, sheet)
+	io.WriteString(w, "!")
+}
+
+func writeOdsSheet(w io.Writer, sheet string) {
+	if sheet == "" {
+		return
+	}
+	io.WriteString(w, "$")
+	writeSheetName(w, sheet)
+}
+
+// writeSheetName writes the name of a sheet or of a span of sheets, eg
+// Sheet1:Sheet3, quoted when needed. Sheets can not have colons in their
+// names.
+func writeSheetName(w io.Writer, sheet string) {
+	var quote bool
+	for part := range strings.SplitSeq(sheet, ":") {
+		quote = quote || part == "" || (part[0] >= '0' && part[0] <= '9')
+	}
+	for _, r := range sheet {
+		if r != '_' && r != ':' && !('a' <= r && r <= 'z') && !('A' <= r && r <= 'Z') && !('0' <= r && r <= '9') {
+			quote = true
+			break
+		}
+	}
+	if !quote {
+		io.WriteString(w, sheet)
+		return
+	}
+	io.WriteString(w, "'")
+	io.WriteString(w, strings.ReplaceAll(sheet, "'", "''"))
+	io.WriteString(w, "'")
+}
+
 func writeCellAddr(w io.Writer, expr parse.CellAddr) {
 	if expr.AbsCol {
 		io.WriteString(w, "$")
 	}
-	column := expr.Column
+	var (
+		column  = expr.Column
+		letters []byte
+	)
 	for column > 0 {
 		column--
-		io.WriteString(w, string(rune('A')+rune(column%26)))
+		letters = append([]byte{byte('A' + column%26)}, letters...)
 		column /= 26
 	}
+	w.Write(letters)
+	if expr.Line == 0 {
+		return
+	}
 	if expr.AbsRow {
 		io.WriteString(w, "$")
 	}
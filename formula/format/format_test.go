@@ -10,6 +10,70 @@ func TestFormat(t *testing.T) {
 	t.Run("ods", testOds)
 	t.Run("oxml", testOxml)
 	t.Run("cross-format", testCrossFormat)
+	t.Run("translate", testTranslate)
+}
+
+func testTranslate(t *testing.T) {
+	tests := []struct {
+		Expr string
+		Want string
+	}{
+		{
+			Expr: "=avg(A1:A10)",
+			Want: "=AVERAGE(A1:A10)",
+		},
+		{
+			Expr: "=(1 + 2) * 3",
+			Want: "=(1 + 2) * 3",
+		},
+		{
+			Expr: "=1 - (2 - 3)",
+			Want: "=1 - (2 - 3)",
+		},
+		{
+			Expr: "=-(A1 + B1)",
+			Want: "=-(A1 + B1)",
+		},
+		{
+			Expr: "=sum(data!A1:B2, 'my sheet'!AB1)",
+			Want: "=SUM(data!A1:B2, 'my sheet'!AB1)",
+		},
+		{
+			Expr: "=sum(data:'my sheet'!A1, Q1:Q4!B1:B2)",
+			Want: "=SUM('data:my sheet'!A1, Q1:Q4!B1:B2)",
+		},
+		{
+			Expr: "=[Book2.xlsx]Sheet1!A1*2",
+			Want: "='[Book2.xlsx]Sheet1'!A1 * 2",
+		},
+		{
+			Expr: "=sum(Sales[[#Data],[Price]:[Amount]])",
+			Want: "=SUM(Sales[[#Data],[Price]:[Amount]])",
+		},
+		{
+			Expr: "=sum((A1:A2, C1:C2, E1), A1:C3 B2)",
+			Want: "=SUM((A1:A2,C1:C2,E1), A1:C3 B2)",
+		},
+		{
+			Expr: "=ifs(A1 > 1, \"say \"\"hello\"\"\", true, \"\")",
+			Want: "=_xlfn.IFS(A1 > 1, \"say \"\"hello\"\"\", TRUE, \"\")",
+		},
+	}
+	for _, c := range tests {
+		e, err := parse.ParseOxmlFormula(c.Expr)
+		if err != nil {
+			t.Errorf("%s: parse expression error: %s", c.Expr, err)
+			continue
+		}
+		got, err := FormatOxml(e)
+		if err != nil {
+			t.Errorf("%s: error formatting expression: %s", c.Expr, err)
+			continue
+		}
+		if c.Want != got {
+			t.Errorf("%s: results mismatched! want %s, got %s", c.Expr, c.Want, got)
+		}
+	}
 }
 
 func testCrossFormat(t *testing.T) {
@@ -53,7 +117,7 @@ func testOds(t *testing.T) {
 	tests := []string{
 		"of:=[.A1]",
 		"of:=[.A1] & \"test\"",
-		"of:=sum(1; 2; 3; [.A1:.A100])",
+		"of:=SUM(1; 2; 3; [.A1:.A100])",
 	}
 	for _, c := range tests {
 		e, err := parse.ParseOdsFormula(c)
@@ -75,7 +139,7 @@ func testOds(t *testing.T) {
 func testOxml(t *testing.T) {
 	tests := []string{
 		"=A1",
-		"=sum(1, 2, 3, A1:A100)",
+		"=SUM(1, 2, 3, A1:A100)",
 	}
 	for _, c := range tests {
 		e, err := parse.ParseOxmlFormula(c)
@@ -16,6 +16,7 @@ const (
 	Column
 	Number
 	Literal
+	Date
 	Comment
 	Assign
 	AddAssign
@@ -32,7 +33,9 @@ const (
 	Pow
 	Concat
 	Union
+	Intersect
 	Arrow
+	Pipe
 	Eq
 	Ne
 	Lt
@@ -56,23 +59,25 @@ const (
 	RangeRef
 	SheetRef
 	Special
+	TableRef
 )
 
 var mapping = map[Op]string{
-	Add:     "+",
-	Sub:     "-",
-	Mul:     "*",
-	Pow:     "^",
-	Div:     "/",
-	Percent: "%",
-	Concat:  "&",
-	Union:   "|",
-	Eq:      "=",
-	Ne:      "<>",
-	Lt:      "<",
-	Le:      "<=",
-	Gt:      ">",
-	Ge:      ">=",
+	Add:       "+",
+	Sub:       "-",
+	Mul:       "*",
+	Pow:       "^",
+	Div:       "/",
+	Percent:   "%",
+	Concat:    "&",
+	Union:     "|",
+	Intersect: " ",
+	Eq:        "=",
+	Ne:        "<>",
+	Lt:        "<",
+	Le:        "<=",
+	Gt:        ">",
+	Ge:        ">=",
 }
 
 func Symbol(oper Op) string {
@@ -7,7 +7,7 @@ import (
 )
 
 func formatCellAddr(addr CellAddr) string {
-	if addr.Column == 0 {
+	if addr.Column == 0 && addr.Line == 0 {
 		return ""
 	}
 	var (
@@ -24,7 +24,7 @@ func formatCellAddr(addr CellAddr) string {
 		parts = append(parts, addr.Sheet)
 		parts = append(parts, "!")
 	}
-	if addr.AbsCol {
+	if addr.AbsCol && addr.Column != 0 {
 		parts = append(parts, "$")
 	}
 	parts = append(parts, result)
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/midbel/dockit/formula/op"
 	"github.com/midbel/dockit/layout"
@@ -56,10 +57,13 @@ func (s Script) Accept(v Visitor) error {
 	return v.VisitScript(s)
 }
 
+// Macro defines a function with the def statement. Its body is executed each
+// time the function is called.
 type Macro struct {
 	name string
 	args []Expr
 	body []Expr
+	Position
 }
 
 func NewMacro(name string, args, body []Expr) Expr {
@@ -70,10 +74,33 @@ func NewMacro(name string, args, body []Expr) Expr {
 	}
 }
 
+func (m Macro) Name() string {
+	return m.name
+}
+
+// Params gives the names of the parameters of the function.
+func (m Macro) Params() []string {
+	var list []string
+	for _, a := range m.args {
+		if id, ok := a.(Identifier); ok {
+			list = append(list, id.Ident())
+		}
+	}
+	return list
+}
+
+func (m Macro) Body() []Expr {
+	return m.body
+}
+
 func (m Macro) String() string {
 	return m.name
 }
 
+func (m Macro) Accept(v Visitor) error {
+	return v.VisitMacro(m)
+}
+
 type AliasRef struct {
 	ident  string
 	target Expr
@@ -120,6 +147,48 @@ func (u UseRef) Accept(v Visitor) error {
 	return v.VisitUseRef(u)
 }
 
+// Push makes a file or a view the default of the statements following it
+// until the matching pop. Pushed as read only, its cells can not be updated.
+type Push struct {
+	expr     Expr
+	readOnly bool
+
+	Position
+}
+
+func (p Push) Expr() Expr {
+	return p.expr
+}
+
+func (p Push) ReadOnly() bool {
+	return p.readOnly
+}
+
+func (p Push) String() string {
+	return fmt.Sprintf("push(%s, ro: %t)", p.expr, p.readOnly)
+}
+
+func (p Push) Accept(v Visitor) error {
+	return v.VisitPush(p)
+}
+
+// Pop restores the default replaced by the last push.
+type Pop struct {
+	Position
+}
+
+func (Pop) String() string {
+	return "pop"
+}
+
+func (p Pop) Accept(v Visitor) error {
+	return v.VisitPop(p)
+}
+
+// IncludeFile executes another script. Without alias, the names defined by
+// the included script are defined in the environment of the script including
+// it. With an alias, the included script has its own environment and its
+// names are given as properties of the alias.
 type IncludeFile struct {
 	file  string
 	alias string
@@ -136,6 +205,11 @@ func (i IncludeFile) File() string {
 	return i.file
 }
 
+// Isolated reports whether the included script has its own environment.
+func (i IncludeFile) Isolated() bool {
+	return i.alias != ""
+}
+
 func (i IncludeFile) Alias() string {
 	if i.alias != "" {
 		return i.alias
@@ -205,8 +279,11 @@ func (e Sheet) Accept(v Visitor) error {
 	return v.VisitSheet(e)
 }
 
+// Lock protects a file or a view. Options give the password and the
+// operations that are protected.
 type Lock struct {
-	ident Expr
+	ident   Expr
+	options map[string]any
 }
 
 func newLock(ident Expr) Expr {
@@ -219,6 +296,13 @@ func (e Lock) Ident() Expr {
 	return e.ident
 }
 
+func (e Lock) Options() map[string]any {
+	if e.options == nil {
+		return make(map[string]any)
+	}
+	return e.options
+}
+
 func (e Lock) String() string {
 	return fmt.Sprintf("lock(%s)", e.ident)
 }
@@ -227,8 +311,11 @@ func (e Lock) Accept(v Visitor) error {
 	return v.VisitLock(e)
 }
 
+// Unlock removes the protection of a file or a view. The password option is
+// required when the protection has a password.
 type Unlock struct {
-	ident Expr
+	ident   Expr
+	options map[string]any
 }
 
 func newUnlock(ident Expr) Expr {
@@ -241,6 +328,13 @@ func (e Unlock) Ident() Expr {
 	return e.ident
 }
 
+func (e Unlock) Options() map[string]any {
+	if e.options == nil {
+		return make(map[string]any)
+	}
+	return e.options
+}
+
 func (e Unlock) String() string {
 	return fmt.Sprintf("unlock(%s)", e.ident)
 }
@@ -277,6 +371,35 @@ func (e Rename) Accept(v Visitor) error {
 	return v.VisitRename(e)
 }
 
+// Define gives a name to a cell or to a range of cells.
+type Define struct {
+	ident string
+	expr  Expr
+}
+
+func NewDefine(ident string, expr Expr) Expr {
+	return Define{
+		ident: ident,
+		expr:  expr,
+	}
+}
+
+func (e Define) Ident() string {
+	return e.ident
+}
+
+func (e Define) Expr() Expr {
+	return e.expr
+}
+
+func (e Define) String() string {
+	return fmt.Sprintf("define(%s, %s)", e.ident, e.expr)
+}
+
+func (e Define) Accept(v Visitor) error {
+	return v.VisitDefine(e)
+}
+
 type Colrow int8
 
 const (
@@ -515,8 +638,8 @@ func (p PrintRef) Accept(v Visitor) error {
 type ExportFile struct {
 	expr Expr
 
-	file    Expr
-	format  string // using
+	files   []Expr
+	formats []string // using or as
 	options map[string]any
 
 	Position
@@ -526,12 +649,36 @@ func (e ExportFile) Expr() Expr {
 	return e.expr
 }
 
+// File gives the first file of the statement.
 func (e ExportFile) File() Expr {
-	return e.file
+	if len(e.files) == 0 {
+		return nil
+	}
+	return e.files[0]
+}
+
+func (e ExportFile) Files() []Expr {
+	return e.files
 }
 
+// Format gives the first format of the statement or an empty string when the
+// formats are taken from the extension of the files.
 func (e ExportFile) Format() string {
-	return e.format
+	if len(e.formats) == 0 {
+		return ""
+	}
+	return e.formats[0]
+}
+
+func (e ExportFile) Formats() []string {
+	return e.formats
+}
+
+func (e ExportFile) Options() map[string]any {
+	if e.options == nil {
+		return make(map[string]any)
+	}
+	return e.options
 }
 
 func (e ExportFile) String() string {
@@ -542,6 +689,360 @@ func (e ExportFile) Accept(v Visitor) error {
 	return v.VisitExportFile(e)
 }
 
+// SaveRef writes a workbook back to the file it was imported from, or to
+// another file when given.
+type SaveRef struct {
+	expr Expr
+	file Expr
+
+	Position
+}
+
+func (e SaveRef) Expr() Expr {
+	return e.expr
+}
+
+func (e SaveRef) File() Expr {
+	return e.file
+}
+
+func (e SaveRef) String() string {
+	if e.file == nil {
+		return fmt.Sprintf("save %s", e.expr)
+	}
+	return fmt.Sprintf("save %s to %s", e.expr, e.file)
+}
+
+func (e SaveRef) Accept(v Visitor) error {
+	return v.VisitSaveRef(e)
+}
+
+// Notify sends a message to an external service, e.g. a webhook. When summary
+// is set, the message is a report of what the script has done so far.
+type Notify struct {
+	kind    string
+	target  Expr
+	message Expr
+	summary bool
+
+	Position
+}
+
+func (n Notify) Kind() string {
+	return n.kind
+}
+
+func (n Notify) Target() Expr {
+	return n.target
+}
+
+func (n Notify) Message() Expr {
+	return n.message
+}
+
+func (n Notify) Summary() bool {
+	return n.summary
+}
+
+func (n Notify) String() string {
+	return fmt.Sprintf("notify %s %s", n.kind, n.target)
+}
+
+func (n Notify) Accept(v Visitor) error {
+	return v.VisitNotify(n)
+}
+
+// Parallel groups import and export statements that can be executed
+// concurrently.
+type Parallel struct {
+	body []Expr
+	Position
+}
+
+func NewParallel(body []Expr) Expr {
+	return Parallel{
+		body: body,
+	}
+}
+
+func (p Parallel) Body() []Expr {
+	return p.body
+}
+
+func (p Parallel) String() string {
+	return fmt.Sprintf("parallel(%d)", len(p.body))
+}
+
+func (p Parallel) Accept(v Visitor) error {
+	return v.VisitParallel(p)
+}
+
+// WithSheet creates a new sheet and makes it the default of the statements
+// of its body.
+type WithSheet struct {
+	sheet Sheet
+	body  []Expr
+	Position
+}
+
+func (w WithSheet) Sheet() Sheet {
+	return w.sheet
+}
+
+func (w WithSheet) Body() []Expr {
+	return w.body
+}
+
+func (w WithSheet) String() string {
+	return fmt.Sprintf("with(%s, %d)", w.sheet, len(w.body))
+}
+
+func (w WithSheet) Accept(v Visitor) error {
+	return v.VisitWithSheet(w)
+}
+
+// WithFilter defines a filter made of the conditions of its body. Rows of a
+// view are kept by the filter when all the conditions are true.
+type WithFilter struct {
+	ident string
+	body  []Expr
+	Position
+}
+
+func (w WithFilter) Ident() string {
+	return w.ident
+}
+
+func (w WithFilter) Body() []Expr {
+	return w.body
+}
+
+func (w WithFilter) String() string {
+	return fmt.Sprintf("with(filter(%s), %d)", w.ident, len(w.body))
+}
+
+func (w WithFilter) Accept(v Visitor) error {
+	return v.VisitWithFilter(w)
+}
+
+// If executes the statements of its body when its condition is true, else the
+// statements of its else branch.
+type If struct {
+	cond Expr
+	csq  []Expr
+	alt  []Expr
+	Position
+}
+
+func (i If) Cond() Expr {
+	return i.cond
+}
+
+func (i If) Body() []Expr {
+	return i.csq
+}
+
+func (i If) Else() []Expr {
+	return i.alt
+}
+
+func (i If) String() string {
+	return fmt.Sprintf("if(%s, %d, %d)", i.cond, len(i.csq), len(i.alt))
+}
+
+func (i If) Accept(v Visitor) error {
+	return v.VisitIf(i)
+}
+
+// For executes the statements of its body for each item of a value: the rows
+// of a view, the sheets of a file or the values of a range.
+type For struct {
+	ident string
+	iter  Expr
+	body  []Expr
+	Position
+}
+
+func (f For) Ident() string {
+	return f.ident
+}
+
+func (f For) Iter() Expr {
+	return f.iter
+}
+
+func (f For) Body() []Expr {
+	return f.body
+}
+
+func (f For) String() string {
+	return fmt.Sprintf("for(%s, %s, %d)", f.ident, f.iter, len(f.body))
+}
+
+func (f For) Accept(v Visitor) error {
+	return v.VisitFor(f)
+}
+
+// While executes the statements of its body as long as its condition is
+// true.
+type While struct {
+	cond Expr
+	body []Expr
+	Position
+}
+
+func (w While) Cond() Expr {
+	return w.cond
+}
+
+func (w While) Body() []Expr {
+	return w.body
+}
+
+func (w While) String() string {
+	return fmt.Sprintf("while(%s, %d)", w.cond, len(w.body))
+}
+
+func (w While) Accept(v Visitor) error {
+	return v.VisitWhile(w)
+}
+
+// CubeDimension gives the column of the view of a cube used as dimension.
+type CubeDimension struct {
+	Name   string
+	Column int64
+}
+
+// CubeMeasure gives the column of the view of a cube used as measure and the
+// function aggregating its values.
+type CubeMeasure struct {
+	Name   string
+	Func   string
+	Column int64
+}
+
+type CubeOutputKind int8
+
+const (
+	CubeRollup CubeOutputKind = iota
+	CubePivot
+)
+
+// CubeOutput describes a sheet given by a cube. Rollup sheets give the
+// aggregated measures for each combination of dimensions with subtotals.
+// Pivot sheets give a measure with the values of the first dimension as rows
+// and the values of the second as columns.
+type CubeOutput struct {
+	Kind       CubeOutputKind
+	Dimensions []string
+	Measure    string
+	Ident      string
+}
+
+// Cube aggregates the rows of a view by the values of some of its columns.
+type Cube struct {
+	source     Expr
+	dimensions []CubeDimension
+	measures   []CubeMeasure
+	outputs    []CubeOutput
+	Position
+}
+
+func (c Cube) Source() Expr {
+	return c.source
+}
+
+func (c Cube) Dimensions() []CubeDimension {
+	return c.dimensions
+}
+
+func (c Cube) Measures() []CubeMeasure {
+	return c.measures
+}
+
+func (c Cube) Outputs() []CubeOutput {
+	return c.outputs
+}
+
+func (c Cube) String() string {
+	return fmt.Sprintf("cube(%s, %d, %d)", c.source, len(c.dimensions), len(c.measures))
+}
+
+func (c Cube) Accept(v Visitor) error {
+	return v.VisitCube(c)
+}
+
+// GroupKey gives a column of the view used to group its rows and the name of
+// the column in the grouped view.
+type GroupKey struct {
+	Column int64
+	Name   string
+}
+
+// GroupAggr gives the function aggregating the values of a column of the rows
+// of each group. Aggregates without column have a zero column.
+type GroupAggr struct {
+	Func   string
+	Column int64
+	Name   string
+}
+
+// GroupBy gives a view with a row for each group of rows of a view having the
+// same values in the key columns.
+type GroupBy struct {
+	view  Expr
+	keys  []GroupKey
+	aggrs []GroupAggr
+	Position
+}
+
+func (g GroupBy) View() Expr {
+	return g.view
+}
+
+func (g GroupBy) Keys() []GroupKey {
+	return g.keys
+}
+
+func (g GroupBy) Aggregates() []GroupAggr {
+	return g.aggrs
+}
+
+func (g GroupBy) String() string {
+	return fmt.Sprintf("group(%s, %d, %d)", g.view, len(g.keys), len(g.aggrs))
+}
+
+func (g GroupBy) Accept(v Visitor) error {
+	return v.VisitGroupBy(g)
+}
+
+// Break stops the loop executing it.
+type Break struct {
+	Position
+}
+
+func (Break) String() string {
+	return "break"
+}
+
+func (b Break) Accept(v Visitor) error {
+	return v.VisitBreak(b)
+}
+
+// Continue skips the remaining statements of the body of the loop executing
+// it.
+type Continue struct {
+	Position
+}
+
+func (Continue) String() string {
+	return "continue"
+}
+
+func (c Continue) Accept(v Visitor) error {
+	return v.VisitContinue(c)
+}
+
 // <source>!(<addr|range>)
 type CellAccess struct {
 	expr Expr
@@ -570,6 +1071,9 @@ func (a CellAccess) Addr() Expr {
 }
 
 func (a CellAccess) String() string {
+	if x, ok := a.expr.(Literal); ok {
+		return fmt.Sprintf("'%s'!%s", strings.ReplaceAll(x.Text(), "'", "''"), a.addr)
+	}
 	return fmt.Sprintf("%s!%s", a.expr, a.addr)
 }
 
@@ -581,6 +1085,153 @@ func (a CellAccess) Accept(v Visitor) error {
 	return v.VisitCellAccess(a)
 }
 
+// SheetSpan is the span of sheets of a 3D reference, eg Sheet1:Sheet3!A1. The
+// sheets are the ones found between first and last in the workbook.
+type SheetSpan struct {
+	first string
+	last  string
+	Position
+}
+
+func NewSheetSpan(first, last string) Expr {
+	return SheetSpan{
+		first: first,
+		last:  last,
+	}
+}
+
+func (s SheetSpan) First() string {
+	return s.first
+}
+
+func (s SheetSpan) Last() string {
+	return s.last
+}
+
+func (s SheetSpan) String() string {
+	return fmt.Sprintf("%s:%s", s.first, s.last)
+}
+
+func (SheetSpan) KindOf() string {
+	return "span"
+}
+
+// ExternalSheet is a sheet of another workbook, eg [Book2.xlsx]Sheet1!A1.
+type ExternalSheet struct {
+	book  string
+	sheet string
+	Position
+}
+
+func NewExternalSheet(book, sheet string) Expr {
+	return ExternalSheet{
+		book:  book,
+		sheet: sheet,
+	}
+}
+
+func (s ExternalSheet) Book() string {
+	return s.book
+}
+
+func (s ExternalSheet) Sheet() string {
+	return s.sheet
+}
+
+func (s ExternalSheet) String() string {
+	return fmt.Sprintf("[%s]%s", s.book, s.sheet)
+}
+
+func (ExternalSheet) KindOf() string {
+	return "external"
+}
+
+// Special items of the structured references to tables.
+const (
+	TableAll     = "#All"
+	TableData    = "#Data"
+	TableHeaders = "#Headers"
+	TableTotals  = "#Totals"
+	TableThisRow = "#This Row"
+)
+
+// TableRef is a structured reference to the cells of a table, eg
+// Table1[Amount], Table1[[#Totals],[Amount]] or Table1[[Price]:[Amount]].
+// Without special item, the reference is to the data of the table.
+type TableRef struct {
+	table string
+	items []string
+	first string
+	last  string
+	Position
+}
+
+func NewTableRef(table string, items []string, first, last string) Expr {
+	if last == "" {
+		last = first
+	}
+	return TableRef{
+		table: table,
+		items: items,
+		first: first,
+		last:  last,
+	}
+}
+
+func (t TableRef) Table() string {
+	return t.table
+}
+
+// Items gives the special items of the reference, eg #Headers or #Totals.
+func (t TableRef) Items() []string {
+	return t.items
+}
+
+// Columns gives the names of the first and of the last columns referenced.
+// They are empty when the reference is to all the columns.
+func (t TableRef) Columns() (string, string) {
+	return t.first, t.last
+}
+
+func (t TableRef) String() string {
+	var parts []string
+	for _, i := range t.items {
+		parts = append(parts, "["+i+"]")
+	}
+	if t.first != "" {
+		col := "[" + escapeColumn(t.first) + "]"
+		if t.last != t.first {
+			col += ":[" + escapeColumn(t.last) + "]"
+		}
+		parts = append(parts, col)
+	}
+	switch {
+	case len(parts) == 0:
+		return t.table + "[]"
+	case len(parts) == 1 && t.first == t.last:
+		return t.table + parts[0]
+	default:
+		return t.table + "[" + strings.Join(parts, ",") + "]"
+	}
+}
+
+func (TableRef) KindOf() string {
+	return "table"
+}
+
+// escapeColumn escapes the characters of the name of a column that are
+// special in structured references.
+func escapeColumn(name string) string {
+	var str strings.Builder
+	for _, c := range name {
+		if strings.ContainsRune("[]#'", c) {
+			str.WriteRune('\'')
+		}
+		str.WriteRune(c)
+	}
+	return str.String()
+}
+
 // [<source>]@property
 type SpecialAccess struct {
 	expr Expr
@@ -679,8 +1330,36 @@ func (t Template) Accept(v Visitor) error {
 	return v.VisitTemplate(t)
 }
 
+// Formatted is a part of a template string given with a format pattern such
+// as ${total:#,##0.00}.
+type Formatted struct {
+	expr    Expr
+	pattern string
+	Position
+}
+
+func NewFormatted(expr Expr, pattern string) Expr {
+	return Formatted{
+		expr:    expr,
+		pattern: pattern,
+	}
+}
+
+func (f Formatted) Expr() Expr {
+	return f.expr
+}
+
+func (f Formatted) Pattern() string {
+	return f.pattern
+}
+
+func (f Formatted) String() string {
+	return fmt.Sprintf("%s:%s", f.expr, f.pattern)
+}
+
 type Deferred struct {
-	expr Expr
+	expr   Expr
+	anchor *layout.Position
 	Position
 }
 
@@ -690,10 +1369,28 @@ func NewDeferred(expr Expr) Expr {
 	}
 }
 
+// NewDeferredAt creates a deferred expression whose relative references are
+// relative to the given cell.
+func NewDeferredAt(expr Expr, anchor layout.Position) Deferred {
+	return Deferred{
+		expr:   expr,
+		anchor: &anchor,
+	}
+}
+
 func (d Deferred) Expr() Expr {
 	return d.expr
 }
 
+// Anchor gives the cell the relative references of the expression are
+// relative to, if any.
+func (d Deferred) Anchor() (layout.Position, bool) {
+	if d.anchor == nil {
+		return layout.Position{}, false
+	}
+	return *d.anchor, true
+}
+
 func (d Deferred) Type() string {
 	return d.KindOf()
 }
@@ -1035,6 +1732,36 @@ func (n Number) Accept(v Visitor) error {
 	return v.VisitNumber(n)
 }
 
+type Date struct {
+	value time.Time
+	Position
+}
+
+func NewDate(value time.Time) Expr {
+	return Date{
+		value: value,
+	}
+}
+
+func (d Date) Time() time.Time {
+	return d.value
+}
+
+func (d Date) String() string {
+	if d.value.Hour() == 0 && d.value.Minute() == 0 && d.value.Second() == 0 {
+		return fmt.Sprintf("d'%s'", d.value.Format(time.DateOnly))
+	}
+	return fmt.Sprintf("d'%s'", d.value.Format("2006-01-02T15:04:05"))
+}
+
+func (Date) KindOf() string {
+	return "primitive"
+}
+
+func (d Date) Accept(v Visitor) error {
+	return v.VisitDate(d)
+}
+
 type ChainCall struct {
 	expr Expr
 	next Expr
@@ -1138,6 +1865,59 @@ func (Slice) KindOf() string {
 	return "slice"
 }
 
+// RowWindow selects the lines of a view between two line numbers and then
+// some of its columns, eg view[10:200, A:C]. Both line numbers are optional.
+type RowWindow struct {
+	from    Expr
+	to      Expr
+	columns Expr
+	Position
+}
+
+func NewRowWindow(from, to, columns Expr) Expr {
+	return RowWindow{
+		from:    from,
+		to:      to,
+		columns: columns,
+	}
+}
+
+// Lines gives the first and the last line of the window. They are 0 when they
+// are not given.
+func (w RowWindow) Lines() (int64, int64, error) {
+	from, err := windowLine(w.from)
+	if err != nil {
+		return 0, 0, err
+	}
+	to, err := windowLine(w.to)
+	if err != nil {
+		return 0, 0, err
+	}
+	if from > 0 && to > 0 && from > to {
+		return 0, 0, fmt.Errorf("first line after last line")
+	}
+	return from, to, nil
+}
+
+func (w RowWindow) Columns() Expr {
+	return w.columns
+}
+
+func (w RowWindow) String() string {
+	return fmt.Sprintf("window(%v, %v, %s)", w.from, w.to, w.columns)
+}
+
+func windowLine(expr Expr) (int64, error) {
+	if expr == nil {
+		return 0, nil
+	}
+	n, ok := expr.(Number)
+	if !ok || n.Float() < 1 {
+		return 0, fmt.Errorf("%s: line number expected", expr)
+	}
+	return int64(n.Float()), nil
+}
+
 func (s Slice) Accept(v Visitor) error {
 	return v.VisitSlice(s)
 }
@@ -1316,10 +2096,11 @@ func (CellAddr) KindOf() string {
 
 func (a CellAddr) CloneWithOffset(pos layout.Position) Expr {
 	x := a
-	if !x.AbsRow {
+	// whole columns and whole rows keep their missing line or column
+	if !x.AbsRow && x.Line != 0 {
 		x.Line += pos.Line
 	}
-	if !x.AbsCol {
+	if !x.AbsCol && x.Column != 0 {
 		x.Column += pos.Column
 	}
 	return x
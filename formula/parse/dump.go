@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"time"
 
 	"github.com/midbel/dockit/formula/op"
 )
@@ -35,6 +36,10 @@ func dumpExpr(w io.Writer, expr Expr) {
 		io.WriteString(w, "number(")
 		io.WriteString(w, strconv.FormatFloat(e.value, 'f', -1, 64))
 		io.WriteString(w, ")")
+	case Date:
+		io.WriteString(w, "date(")
+		io.WriteString(w, e.value.Format(time.RFC3339))
+		io.WriteString(w, ")")
 	case Template:
 		io.WriteString(w, "template(")
 		for i := range e.expr {
@@ -44,6 +49,12 @@ func dumpExpr(w io.Writer, expr Expr) {
 			dumpExpr(w, e.expr[i])
 		}
 		io.WriteString(w, ")")
+	case Formatted:
+		io.WriteString(w, "formatted(")
+		dumpExpr(w, e.expr)
+		io.WriteString(w, ", ")
+		io.WriteString(w, e.pattern)
+		io.WriteString(w, ")")
 	case Binary:
 		io.WriteString(w, "binary(")
 		dumpExpr(w, e.left)
@@ -151,6 +162,18 @@ func dumpExpr(w io.Writer, expr Expr) {
 		io.WriteString(w, ", ")
 		dumpExpr(w, e.expr)
 		io.WriteString(w, ")")
+	case RowWindow:
+		io.WriteString(w, "window(")
+		if e.from != nil {
+			dumpExpr(w, e.from)
+		}
+		io.WriteString(w, ", ")
+		if e.to != nil {
+			dumpExpr(w, e.to)
+		}
+		io.WriteString(w, ", ")
+		dumpExpr(w, e.columns)
+		io.WriteString(w, ")")
 	case IntervalList:
 		io.WriteString(w, "interval(")
 		for i := range e.items {
@@ -243,6 +266,27 @@ func dumpExpr(w io.Writer, expr Expr) {
 		dumpExpr(w, e.expr)
 		io.WriteString(w, ")")
 	case ExportFile:
+	case Notify:
+		io.WriteString(w, "notify(")
+		io.WriteString(w, e.kind)
+		io.WriteString(w, ", ")
+		dumpExpr(w, e.target)
+		if e.summary {
+			io.WriteString(w, ", summary")
+		} else if e.message != nil {
+			io.WriteString(w, ", ")
+			dumpExpr(w, e.message)
+		}
+		io.WriteString(w, ")")
+	case Parallel:
+		io.WriteString(w, "parallel(")
+		for i := range e.body {
+			if i > 0 {
+				io.WriteString(w, ", ")
+			}
+			dumpExpr(w, e.body[i])
+		}
+		io.WriteString(w, ")")
 	default:
 		io.WriteString(w, fmt.Sprintf("unknown(%T)", e))
 	}
@@ -10,6 +10,52 @@ import (
 func TestParseFormula(t *testing.T) {
 	t.Run("oxml", testParseOxmlFormula)
 	t.Run("ods", testParseOdsFormula)
+	t.Run("locale", testParseLocaleFormula)
+}
+
+func testParseLocaleFormula(t *testing.T) {
+	tests := []struct {
+		Expr string
+		Want Expr
+	}{
+		{
+			Expr: "=1,5+2",
+			Want: NewBinary(
+				NewNumber(1.5),
+				NewNumber(2),
+				op.Add,
+			),
+		},
+		{
+			Expr: "=round(A1;2)",
+			Want: NewCall(
+				NewIdentifier("round"),
+				[]Expr{
+					NewCellAddr(layout.NewPosition(1, 1), false, false),
+					NewNumber(2),
+				},
+			),
+		},
+		{
+			Expr: "=sum(0,25; 1,5E2; A1)",
+			Want: NewCall(
+				NewIdentifier("sum"),
+				[]Expr{
+					NewNumber(0.25),
+					NewNumber(150),
+					NewCellAddr(layout.NewPosition(1, 1), false, false),
+				},
+			),
+		},
+	}
+	for _, c := range tests {
+		f, err := ParseOxmlLocaleFormula(c.Expr)
+		if err != nil {
+			t.Errorf("%s: error parsing OXML formumla: %s", c.Expr, err)
+			continue
+		}
+		assertEqualExpr(t, c.Want, f)
+	}
 }
 
 func testParseOdsFormula(t *testing.T) {
@@ -160,6 +206,122 @@ func testParseOxmlFormula(t *testing.T) {
 				op.Add,
 			),
 		},
+		{
+			Expr: "=LOG10(A1)",
+			Want: NewCall(
+				NewIdentifier("LOG10"),
+				[]Expr{
+					NewCellAddr(layout.NewPosition(1, 1), false, false),
+				},
+			),
+		},
+		{
+			Expr: "=sum((A1, B1:B2))",
+			Want: NewCall(
+				NewIdentifier("sum"),
+				[]Expr{
+					NewBinary(
+						NewCellAddr(layout.NewPosition(1, 1), false, false),
+						NewRangeAddr(
+							NewCellAddr(layout.NewPosition(1, 2), false, false),
+							NewCellAddr(layout.NewPosition(2, 2), false, false),
+						),
+						op.Union,
+					),
+				},
+			),
+		},
+		{
+			Expr: "=A1:B2 B2 + 1",
+			Want: NewBinary(
+				NewBinary(
+					NewRangeAddr(
+						NewCellAddr(layout.NewPosition(1, 1), false, false),
+						NewCellAddr(layout.NewPosition(2, 2), false, false),
+					),
+					NewCellAddr(layout.NewPosition(2, 2), false, false),
+					op.Intersect,
+				),
+				NewNumber(1),
+				op.Add,
+			),
+		},
+		{
+			Expr: "=sum(Sheet1:Sheet3!A1:A2)",
+			Want: NewCall(
+				NewIdentifier("sum"),
+				[]Expr{
+					NewCellAccess(
+						NewSheetSpan("Sheet1", "Sheet3"),
+						NewRangeAddr(
+							NewCellAddr(layout.NewPosition(1, 1), false, false),
+							NewCellAddr(layout.NewPosition(2, 1), false, false),
+						),
+					),
+				},
+			),
+		},
+		{
+			Expr: "='my sheet:other'!B1",
+			Want: NewCellAccess(
+				NewSheetSpan("my sheet", "other"),
+				NewCellAddr(layout.NewPosition(1, 2), false, false),
+			),
+		},
+		{
+			Expr: "=[Book2.xlsx]Sheet1!$A$1",
+			Want: NewCellAccess(
+				NewExternalSheet("Book2.xlsx", "Sheet1"),
+				NewCellAddr(layout.NewPosition(1, 1), true, true),
+			),
+		},
+		{
+			Expr: "=SUM('[my book.xlsx]my sheet'!A1:A2)",
+			Want: NewCall(
+				NewIdentifier("SUM"),
+				[]Expr{
+					NewCellAccess(
+						NewExternalSheet("my book.xlsx", "my sheet"),
+						NewRangeAddr(
+							NewCellAddr(layout.NewPosition(1, 1), false, false),
+							NewCellAddr(layout.NewPosition(2, 1), false, false),
+						),
+					),
+				},
+			),
+		},
+		{
+			Expr: "=SUM(Sales[Amount])",
+			Want: NewCall(
+				NewIdentifier("SUM"),
+				[]Expr{
+					NewTableRef("Sales", nil, "Amount", ""),
+				},
+			),
+		},
+		{
+			Expr: "=Table1[[#Totals],[Amount]]",
+			Want: NewTableRef("Table1", []string{TableTotals}, "Amount", ""),
+		},
+		{
+			Expr: "=Table1[[#headers],[Price]:[Amount]]",
+			Want: NewTableRef("Table1", []string{TableHeaders}, "Price", "Amount"),
+		},
+		{
+			Expr: "=T1[#All]",
+			Want: NewTableRef("T1", []string{TableAll}, "", ""),
+		},
+		{
+			Expr: "=Table1[Unit'[EUR']]",
+			Want: NewTableRef("Table1", nil, "Unit[EUR]", ""),
+		},
+		{
+			Expr: "='it''s'!A2",
+			Want: NewCellAccess(
+				NewLiteral("it's"),
+				NewCellAddr(layout.NewPosition(2, 1), false, false),
+			),
+		},
 	}
 	for _, c := range tests {
 		f, err := ParseOxmlFormula(c.Expr)
@@ -170,3 +332,61 @@ func testParseOxmlFormula(t *testing.T) {
 		assertEqualExpr(t, c.Want, f)
 	}
 }
+
+func TestRelocate(t *testing.T) {
+	shift := func(start, end layout.Position) (layout.Position, layout.Position, bool) {
+		if start.Sheet != "" && start.Sheet != "sheet1" {
+			return start, end, true
+		}
+		if start.Line == 2 && end.Line == 2 {
+			return start, end, false
+		}
+		if start.Line > 2 {
+			start.Line += 2
+		}
+		if end.Line > 2 {
+			end.Line += 2
+		}
+		return start, end, true
+	}
+	tests := []struct {
+		Expr string
+		Want string
+	}{
+		{
+			Expr: "=A1+A3",
+			Want: "A1 + A5",
+		},
+		{
+			Expr: "=SUM(A1:A3)",
+			Want: "SUM(A1:A5)",
+		},
+		{
+			Expr: "=$B$4*2",
+			Want: "$B$6 * 2",
+		},
+		{
+			Expr: "=sheet1!A3+sheet2!A3",
+			Want: "sheet1!A5 + sheet2!A3",
+		},
+		{
+			Expr: "=A2*sheet1!B2",
+			Want: "#REF! * #REF!",
+		},
+		{
+			Expr: "='sheet1'!A3&'it''s'!A3",
+			Want: "'sheet1'!A5 & 'it''s'!A3",
+		},
+	}
+	for _, c := range tests {
+		expr, err := ParseOxmlFormula(c.Expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.Expr, err)
+			continue
+		}
+		got := Relocate(expr, shift).String()
+		if got != c.Want {
+			t.Errorf("%s: results mismatched! want %s, got %s", c.Expr, c.Want, got)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 const (
 	powLowest = iota
 	powAssign
+	powPipe
 	powLogical
 	powEq
 	powCmp
@@ -21,6 +22,7 @@ const (
 	powPow
 	powUnary
 	powPercent
+	powIntersect
 	powList
 	powSheet
 	powRange
@@ -43,6 +45,7 @@ var defaultBindings = map[op.Op]int{
 	op.Percent:      powPercent,
 	op.Pow:          powPow,
 	op.Union:        powUnion,
+	op.Intersect:    powIntersect,
 	op.Concat:       powConcat,
 	op.Eq:           powEq,
 	op.Ne:           powEq,
@@ -60,6 +63,7 @@ var defaultBindings = map[op.Op]int{
 	op.Or:           powLogical,
 	op.Not:          powUnary,
 	op.Arrow:        powCall,
+	op.Pipe:         powPipe,
 }
 
 type GrammarScope int
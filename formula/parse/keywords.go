@@ -1,45 +1,60 @@
 package parse
 
 const (
-	kwAlias   = "alias"
-	kwImport  = "import"
-	kwExport  = "export"
-	kwRename  = "rename"
-	kwLock    = "lock"
-	kwUnlock  = "unlock"
-	kwRow     = "row"
-	kwRows    = "rows"
-	kwColumn  = "column"
-	kwColumns = "columns"
-	kwFirst   = "first"
-	kwLast    = "last"
-	kwSheet   = "sheet"
-	kwInsert  = "insert"
-	kwRemove  = "remove"
-	kwUse     = "use"
-	kwUsing   = "using"
-	kwWith    = "with"
-	kwPrint   = "print"
-	kwDefault = "default"
-	kwFrom    = "from"
-	kwIn      = "in"
-	kwAs      = "as"
-	kwTo      = "to"
-	kwInto    = "into"
-	kwRo      = "ro"
-	kwRw      = "rw"
-	kwAnd     = "and"
-	kwOr      = "or"
-	kwNot     = "not"
-	kwAssert  = "assert"
-	kwElse    = "else"
-	kwInclude = "include"
-	kwMacro   = "macro"
-	kwEnd     = "end"
-	kwBefore  = "before"
-	kwAfter   = "after"
-	kwAt      = "at"
-	kwLinked  = "linked"
+	kwAlias    = "alias"
+	kwImport   = "import"
+	kwExport   = "export"
+	kwRename   = "rename"
+	kwLock     = "lock"
+	kwUnlock   = "unlock"
+	kwRow      = "row"
+	kwRows     = "rows"
+	kwColumn   = "column"
+	kwColumns  = "columns"
+	kwFirst    = "first"
+	kwLast     = "last"
+	kwSheet    = "sheet"
+	kwInsert   = "insert"
+	kwRemove   = "remove"
+	kwUse      = "use"
+	kwUsing    = "using"
+	kwWith     = "with"
+	kwPrint    = "print"
+	kwDefault  = "default"
+	kwFrom     = "from"
+	kwIn       = "in"
+	kwAs       = "as"
+	kwTo       = "to"
+	kwInto     = "into"
+	kwRo       = "ro"
+	kwRw       = "rw"
+	kwAnd      = "and"
+	kwOr       = "or"
+	kwNot      = "not"
+	kwAssert   = "assert"
+	kwElse     = "else"
+	kwInclude  = "include"
+	kwDef      = "def"
+	kwEnd      = "end"
+	kwBefore   = "before"
+	kwAfter    = "after"
+	kwAt       = "at"
+	kwLinked   = "linked"
+	kwParallel = "parallel"
+	kwNotify   = "notify"
+	kwDefine   = "define"
+	kwSave     = "save"
+	kwPush     = "push"
+	kwPop      = "pop"
+	kwIf       = "if"
+	kwThen     = "then"
+	kwFor      = "for"
+	kwDo       = "do"
+	kwWhile    = "while"
+	kwBreak    = "break"
+	kwContinue = "continue"
+	kwCube     = "cube"
+	kwGroup    = "group"
 )
 
 func isReserved(str string) bool {
@@ -77,6 +92,15 @@ func isKeyword(str string) bool {
 	case kwInsert:
 	case kwRemove:
 	case kwElse:
+	case kwIf:
+	case kwThen:
+	case kwFor:
+	case kwDo:
+	case kwWhile:
+	case kwBreak:
+	case kwContinue:
+	case kwCube:
+	case kwGroup:
 	case kwUse:
 	case kwLinked:
 	case kwUsing:
@@ -90,6 +114,12 @@ func isKeyword(str string) bool {
 	case kwTo:
 	case kwInto:
 	case kwEnd:
+	case kwParallel:
+	case kwNotify:
+	case kwDefine:
+	case kwSave:
+	case kwPush:
+	case kwPop:
 	case kwRo:
 	case kwRw:
 	case kwAnd:
@@ -98,8 +128,8 @@ func isKeyword(str string) bool {
 	case kwBefore:
 	case kwAfter:
 	case kwAt:
-	// case kwMacro:
-	// case kwInclude:
+	case kwDef:
+	case kwInclude:
 	default:
 		return false
 	}
@@ -3,10 +3,14 @@ package parse
 import (
 	"fmt"
 	"io"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/midbel/dockit/formula/op"
+	"github.com/midbel/dockit/internal/deprecate"
+	"github.com/midbel/dockit/layout"
 )
 
 type AddressContext int8
@@ -44,13 +48,14 @@ func FormulaGrammar() *Grammar {
 	g.RegisterPrefix(op.Sub, parseUnary)
 	g.RegisterPrefix(op.Add, parseUnary)
 	g.RegisterPrefix(op.Ident, parseIdentifier)
-	g.RegisterPrefix(op.BegGrp, parseGroup)
+	g.RegisterPrefix(op.BegGrp, parseFormulaGroup)
 
 	g.RegisterPostfix(op.SheetRef, func(p *Parser, expr Expr) (Expr, error) {
 		return p.dialect.ParseQualifiedAddress(p, expr)
 	})
 	g.RegisterPostfix(op.BegGrp, parseCall)
 	g.RegisterPostfix(op.Percent, parsePercent)
+	g.RegisterPostfix(op.TableRef, parseTableRef)
 
 	g.RegisterInfix(op.RangeRef, func(p *Parser, expr Expr) (Expr, error) {
 		return p.dialect.ParseRangeAddress(p, expr)
@@ -67,6 +72,7 @@ func FormulaGrammar() *Grammar {
 	g.RegisterInfix(op.Le, parseBinary)
 	g.RegisterInfix(op.Gt, parseBinary)
 	g.RegisterInfix(op.Ge, parseBinary)
+	g.RegisterInfix(op.Intersect, parseBinary)
 
 	return g
 }
@@ -78,6 +84,7 @@ func ScriptGrammar() *Grammar {
 	g.terminators = []op.Op{op.EOF, op.Eol, op.Semi}
 
 	g.RegisterPrefix(op.Eq, parseDeferred)
+	g.RegisterPrefix(op.Date, parseDate)
 	g.RegisterPrefix(op.Ident, parseIdentifier)
 	g.RegisterPrefix(op.Column, parseColumn)
 	g.RegisterPrefix(op.BegProp, parseSlicePrefix)
@@ -92,6 +99,7 @@ func ScriptGrammar() *Grammar {
 	g.RegisterPostfix(op.Arrow, parseArrow)
 
 	g.RegisterInfix(op.Union, parseBinary)
+	g.RegisterInfix(op.Pipe, parsePipe)
 	g.RegisterInfix(op.Assign, parseAssignment)
 	g.RegisterInfix(op.AddAssign, parseAssignment)
 	g.RegisterInfix(op.SubAssign, parseAssignment)
@@ -103,18 +111,42 @@ func ScriptGrammar() *Grammar {
 	g.RegisterPrefixKeyword(kwAssert, parseAssert)
 	g.RegisterPrefixKeyword(kwAlias, parseAlias)
 	g.RegisterPrefixKeyword(kwUse, parseUse)
+	g.RegisterPrefixKeyword(kwPush, parsePush)
+	g.RegisterPrefixKeyword(kwPop, parsePop)
 	g.RegisterPrefixKeyword(kwImport, parseImport)
 	g.RegisterPrefixKeyword(kwPrint, parsePrint)
 	g.RegisterPrefixKeyword(kwExport, parseExport)
+	g.RegisterPrefixKeyword(kwSave, parseSave)
+	g.RegisterPrefixKeyword(kwNotify, parseNotify)
 	g.RegisterPrefixKeyword(kwLock, parseLock)
 	g.RegisterPrefixKeyword(kwUnlock, parseUnlock)
 	g.RegisterPrefixKeyword(kwRename, parseRename)
+	g.RegisterPrefixKeyword(kwDefine, parseDefine)
 	g.RegisterPrefixKeyword(kwInsert, parseInsert)
 	g.RegisterPrefixKeyword(kwRemove, parseRemove)
 	g.RegisterPrefixKeyword(kwSheet, parseSheet)
-	// g.RegisterPrefixKeyword(kwInclude, parseInclude)
-	// g.RegisterPrefixKeyword(kwMacro, parseMacro)
+	g.RegisterPrefixKeyword(kwParallel, parseParallel)
+	g.RegisterPrefixKeyword(kwWith, parseWith)
+	g.RegisterPrefixKeyword(kwIf, parseIf)
+	g.RegisterPrefixKeyword(kwFor, parseFor)
+	g.RegisterPrefixKeyword(kwWhile, parseWhile)
+	g.RegisterPrefixKeyword(kwBreak, parseBreak)
+	g.RegisterPrefixKeyword(kwContinue, parseContinue)
+	g.RegisterPrefixKeyword(kwDef, parseMacro)
+	g.RegisterPrefixKeyword(kwGroup, parseGroupBy)
+	g.RegisterPrefixKeyword(kwRow, parseKeywordIdentifier)
+	g.RegisterPrefixKeyword(kwRows, parseKeywordIdentifier)
+	g.RegisterPrefixKeyword(kwInclude, parseInclude)
+
+	return g
+}
 
+// CubeGrammar gives the grammar of the scripts of the cube mode: the grammar
+// of the scripts with the cube statement.
+func CubeGrammar() *Grammar {
+	g := ScriptGrammar()
+	g.name = "cube"
+	g.RegisterPrefixKeyword(kwCube, parseCube)
 	return g
 }
 
@@ -122,6 +154,7 @@ func LambdaGrammar() *Grammar {
 	g := FormulaGrammar()
 	g.name = "lambda"
 	g.scope = GrammarIsolated
+	g.RegisterPrefix(op.Date, parseDate)
 	g.RegisterPostfix(op.BegProp, parseSlice)
 	return g
 }
@@ -138,6 +171,7 @@ func SliceGrammar() *Grammar {
 	g.RegisterPrefix(op.Column, parseColumn)
 	g.RegisterPrefix(op.Number, parseNumber)
 	g.RegisterPrefix(op.Literal, parseLiteral)
+	g.RegisterPrefix(op.Date, parseDate)
 	g.RegisterPrefix(op.RangeRef, parseOpenSelectedColumns)
 	g.RegisterPrefix(op.BegGrp, parseGroup)
 	g.RegisterPrefix(op.Not, parseNot)
@@ -187,7 +221,12 @@ type Parser struct {
 	aliases map[string]Expr
 }
 
+// ParseFormula parses a formula written with the syntax of Excel.
+//
+// Deprecated: the dialect of the formula is implicit. Use ParseOxmlFormula or
+// ParseOdsFormula instead.
 func ParseFormula(str string) (Expr, error) {
+	deprecate.Warn("parse.ParseFormula", "parse.ParseOxmlFormula")
 	return ParseOxmlFormula(str)
 }
 
@@ -215,6 +254,21 @@ func ParseOdsFormula(str string) (Expr, error) {
 	return p.Parse()
 }
 
+// ParseOxmlLocaleFormula parses a formula written with the syntax of Excel by
+// locales using semicolons between the arguments of functions and commas as
+// decimal mark, eg =ROUND(1,5;0).
+func ParseOxmlLocaleFormula(str string) (Expr, error) {
+	scan, err := ScanOxmlLocale(strings.NewReader(str))
+	if err != nil {
+		return nil, err
+	}
+	p, err := NewParser(scan)
+	if err != nil {
+		return nil, err
+	}
+	return p.Parse()
+}
+
 func NewParser(scan Scanner) (*Parser, error) {
 	p := Parser{
 		scan:    scan,
@@ -241,6 +295,8 @@ func NewParser(scan Scanner) (*Parser, error) {
 		case "", ModeScript:
 			mode = ModeScript
 			p.pushGrammar(ScriptGrammar())
+		case ModeCube:
+			p.pushGrammar(CubeGrammar())
 		default:
 			return nil, fmt.Errorf("%s: mode not yet supported", mode)
 		}
@@ -289,7 +345,7 @@ func (p *Parser) ExtractConfigEntries() ([]ConfigEntry, error) {
 			if p.is(op.Dot) {
 				continue
 			}
-			if p.is(op.Assign) {
+			if p.is(op.Assign) || p.is(op.Eq) {
 				break
 			}
 			if !p.is(op.Ident) && !p.is(op.Keyword) {
@@ -297,7 +353,7 @@ func (p *Parser) ExtractConfigEntries() ([]ConfigEntry, error) {
 			}
 			entry.Path = append(entry.Path, p.currentLiteral())
 		}
-		if !p.is(op.Assign) {
+		if !p.is(op.Assign) && !p.is(op.Eq) {
 			return nil, fmt.Errorf("assignment operator is expected")
 		}
 		p.next()
@@ -406,7 +462,7 @@ func (p *Parser) parseIncludes() ([]Expr, error) {
 		}
 		p.skipTerminator()
 	}
-	p.currGrammar().UnregisterPrefixKeyword(kwInclude)
+	p.currGrammar().RegisterPrefixKeyword(kwInclude, parseMisplacedInclude)
 	return list, nil
 }
 
@@ -579,6 +635,12 @@ func parseCall(p *Parser, expr Expr) (Expr, error) {
 		if err != nil {
 			return nil, err
 		}
+		if p.is(op.Semi) && p.dialect.Separator() != op.Semi {
+			// selection of columns given as argument, eg distinct(view, A;B)
+			if arg, err = parseSelectedColumns(p, arg); err != nil {
+				return nil, err
+			}
+		}
 		switch p.curr.Type {
 		case op.Eol:
 			p.skipEOL()
@@ -643,6 +705,30 @@ func parseGroup(p *Parser) (Expr, error) {
 	return expr, nil
 }
 
+// parseFormulaGroup parses an expression between parenthesis. References
+// separated by commas inside the parenthesis are the union of these
+// references, eg SUM((A1:A3,C1:C3)).
+func parseFormulaGroup(p *Parser) (Expr, error) {
+	p.next()
+	expr, err := p.parse(powLowest)
+	if err != nil {
+		return nil, err
+	}
+	for p.is(op.Comma) {
+		p.next()
+		right, err := p.parse(powLowest)
+		if err != nil {
+			return nil, err
+		}
+		expr = NewBinary(expr, right, op.Union)
+	}
+	if !p.is(op.EndGrp) {
+		return nil, p.makeError("missing ')' at end of expression")
+	}
+	p.next()
+	return expr, nil
+}
+
 func parseArray(p *Parser) (Expr, error) {
 	p.next()
 	var list []Expr
@@ -677,6 +763,26 @@ func parseNumber(p *Parser) (Expr, error) {
 	return NewNumber(x), nil
 }
 
+// dateLayouts are the layouts accepted by the date literals.
+var dateLayouts = []string{
+	time.DateOnly,
+	"2006-01-02T15:04:05",
+	time.DateTime,
+}
+
+func parseDate(p *Parser) (Expr, error) {
+	defer p.next()
+
+	str := p.currentLiteral()
+	for _, pattern := range dateLayouts {
+		when, err := time.Parse(pattern, str)
+		if err == nil {
+			return NewDate(when), nil
+		}
+	}
+	return nil, p.makeError(fmt.Sprintf("%s: invalid date literal", str))
+}
+
 func parseLiteral(p *Parser) (Expr, error) {
 	lit := p.currentLiteral()
 	p.next()
@@ -697,10 +803,17 @@ func parseLiteral(p *Parser) (Expr, error) {
 		if ix = strings.Index(lit[offset:], "}"); ix <= 0 {
 			return nil, p.makeError("invalid template string")
 		}
-		expr, err := parseExprFromString(lit[offset : offset+ix])
+		str, pattern, ok := cutTemplatePattern(lit[offset : offset+ix])
+		expr, err := parseExprFromString(str)
 		if err != nil {
 			return nil, err
 		}
+		if ok {
+			if pattern == "" {
+				return nil, p.makeError("missing format pattern in template string")
+			}
+			expr = NewFormatted(expr, pattern)
+		}
 		list = append(list, expr)
 		offset += ix + 1
 	}
@@ -710,6 +823,35 @@ func parseLiteral(p *Parser) (Expr, error) {
 	return NewTemplate(list), nil
 }
 
+// cutTemplatePattern splits the content of a template placeholder at its first
+// colon that is not enclosed in parentheses, brackets or quotes.
+func cutTemplatePattern(str string) (string, string, bool) {
+	var (
+		depth int
+		quote byte
+	)
+	for i := 0; i < len(str); i++ {
+		switch c := str[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == ':' && depth == 0:
+			if i+1 < len(str) && str[i+1] == '=' {
+				continue
+			}
+			return str[:i], str[i+1:], true
+		}
+	}
+	return str, "", false
+}
+
 func parseAlias(p *Parser) (Expr, error) {
 	p.next()
 	expr, err := p.parse(powLowest)
@@ -760,35 +902,93 @@ func parseRangeAddress(p *Parser, left Expr) (Expr, error) {
 	if err != nil {
 		return nil, err
 	}
+	if access, ok := addr.(CellAccess); ok {
+		// Sheet1:Sheet3!A1 is parsed as Sheet1 and Sheet3!A1
+		first, ok1 := sheetName(left)
+		last, ok2 := sheetName(access.Expr())
+		if !ok1 || !ok2 {
+			return nil, p.makeError("sheet span: sheet names expected")
+		}
+		return NewCellAccess(NewSheetSpan(first, last), access.Addr()), nil
+	}
+	start, err := rangeBound(left)
+	if err != nil {
+		return nil, fmt.Errorf("range: address/identfier/number expected")
+	}
+	end, err := rangeBound(addr)
+	if err != nil {
+		return nil, p.makeError("range (right): address expected")
+	}
+	return NewRangeAddr(start, end), nil
+}
 
-	var start CellAddr
-	switch a := left.(type) {
+// rangeBound gives the address of one of the bounds of a range. Whole columns
+// (A:C) and whole rows (1:3) are given as identifiers or numbers and their
+// addresses have no line or no column.
+func rangeBound(expr Expr) (CellAddr, error) {
+	switch a := expr.(type) {
 	case CellAddr:
-		start = a
+		return a, nil
+	case ColumnAddr:
+		addr := CellAddr{
+			Position: a.Position,
+			AbsCol:   a.Absolute,
+		}
+		addr.Line = 0
+		return addr, nil
 	case Identifier:
 		addr, err := parseCellAddr(a.Ident())
-		if err != nil {
-			return nil, err
+		if err == nil && addr.Column == 0 && addr.AbsCol {
+			// $1:$3
+			addr.AbsCol, addr.AbsRow = false, true
 		}
-		start = addr
+		return addr, err
 	case Number:
-		addr, err := parseCellAddr(a.String())
-		if err != nil {
-			return nil, err
-		}
-		start = addr
+		return parseCellAddr(a.String())
 	default:
-		return nil, fmt.Errorf("range: address/identfier/number expected")
+		return CellAddr{}, fmt.Errorf("address expected")
 	}
-	end, ok := addr.(CellAddr)
-	if !ok {
-		return nil, p.makeError("range (right): address expected")
+}
+
+// sheetName gives the name of a sheet given as one of the bounds of a span of
+// sheets.
+func sheetName(expr Expr) (string, bool) {
+	switch e := expr.(type) {
+	case Identifier:
+		return e.Ident(), true
+	case Literal:
+		return e.Text(), true
+	case Number:
+		return e.String(), true
+	case CellAddr:
+		// sheets named like cells, eg Q1:Q4!A1
+		return e.String(), !e.AbsCol && !e.AbsRow
+	default:
+		return "", false
 	}
+}
 
-	return NewRangeAddr(start, end), nil
+// externalSheet splits the name of a sheet of another workbook, eg
+// [Book2.xlsx]Sheet1, into the names of the workbook and of the sheet.
+func externalSheet(str string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(str, "[")
+	if !ok {
+		return "", "", false
+	}
+	book, sheet, ok := strings.Cut(rest, "]")
+	return book, sheet, ok && book != "" && sheet != ""
 }
 
 func parseQualifiedAddress(p *Parser, left Expr) (Expr, error) {
+	if x, ok := left.(Literal); ok {
+		// sheet names can not have colons: 'Sheet 1:Sheet 3'!A1 is a span of
+		// sheets
+		if book, sheet, ok := externalSheet(x.Text()); ok {
+			left = NewExternalSheet(book, sheet)
+		} else if first, last, ok := strings.Cut(x.Text(), ":"); ok {
+			left = NewSheetSpan(first, last)
+		}
+	}
 	p.next()
 	right, err := p.parse(powSheet)
 	if err != nil {
@@ -812,7 +1012,114 @@ func parseQualifiedAddress(p *Parser, left Expr) (Expr, error) {
 	return NewCellAccess(left, right), nil
 }
 
+// parseTableRef parses the specifier of a structured reference following the
+// name of a table, eg Table1[Amount] or Table1[[#Totals],[Amount]].
+func parseTableRef(p *Parser, left Expr) (Expr, error) {
+	var table string
+	switch e := left.(type) {
+	case Identifier:
+		table = e.Ident()
+	case CellAddr:
+		// tables named like cells, eg T1
+		if e.AbsCol || e.AbsRow {
+			return nil, p.makeError("table name expected")
+		}
+		table = e.String()
+	default:
+		return nil, p.makeError("table name expected")
+	}
+	items, cols, err := tableSpecifier(p.currentLiteral())
+	if err != nil {
+		return nil, p.makeError(err.Error())
+	}
+	p.next()
+	var first, last string
+	if len(cols) > 0 {
+		first, last = cols[0], cols[len(cols)-1]
+	}
+	return NewTableRef(table, items, first, last), nil
+}
+
+var tableItems = []string{
+	TableAll,
+	TableData,
+	TableHeaders,
+	TableTotals,
+	TableThisRow,
+}
+
+// tableSpecifier splits the specifier of a structured reference into its
+// special items and the names of its first and last columns.
+func tableSpecifier(str string) ([]string, []string, error) {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return nil, nil, nil
+	}
+	if !strings.HasPrefix(str, "[") {
+		str = "[" + str + "]"
+	}
+	var (
+		items []string
+		cols  []string
+		sep   byte
+	)
+	for str != "" {
+		part, rest, err := tablePart(str)
+		if err != nil {
+			return nil, nil, err
+		}
+		if strings.HasPrefix(part, "#") {
+			ix := slices.IndexFunc(tableItems, func(i string) bool {
+				return strings.EqualFold(i, part)
+			})
+			if ix < 0 || sep == ':' || len(cols) > 0 {
+				return nil, nil, fmt.Errorf("%s: invalid item of table reference", part)
+			}
+			items = append(items, tableItems[ix])
+		} else {
+			if len(cols) > 0 && sep != ':' || len(cols) == 2 {
+				return nil, nil, fmt.Errorf("%s: unexpected column in table reference", part)
+			}
+			cols = append(cols, part)
+		}
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			break
+		}
+		if sep = rest[0]; sep != ',' && sep != ':' {
+			return nil, nil, fmt.Errorf("invalid separator in table reference")
+		}
+		str = strings.TrimSpace(rest[1:])
+	}
+	if sep == ':' && len(cols) != 2 {
+		return nil, nil, fmt.Errorf("range of columns expected in table reference")
+	}
+	return items, cols, nil
+}
+
+// tablePart gives the name between the brackets at the start of str and the
+// rest of str. Apostrophes escape the characters following them.
+func tablePart(str string) (string, string, error) {
+	var part strings.Builder
+	for i := 1; i < len(str); i++ {
+		switch str[i] {
+		case '\'':
+			if i++; i < len(str) {
+				part.WriteByte(str[i])
+			}
+		case ']':
+			return part.String(), str[i+1:], nil
+		default:
+			part.WriteByte(str[i])
+		}
+	}
+	return "", "", fmt.Errorf("missing closing bracket in table reference")
+}
+
 func parseColumn(p *Parser) (Expr, error) {
+	if fn, ok := parseFunctionName(p); ok {
+		return fn, nil
+	}
 	addr, err := parseColumnAddr(p.currentLiteral())
 	if err != nil {
 		return nil, err
@@ -822,6 +1129,9 @@ func parseColumn(p *Parser) (Expr, error) {
 }
 
 func parseAddress(p *Parser) (Expr, error) {
+	if fn, ok := parseFunctionName(p); ok {
+		return fn, nil
+	}
 	addr, err := parseCellAddr(p.currentLiteral())
 	if err != nil {
 		return nil, err
@@ -830,6 +1140,17 @@ func parseAddress(p *Parser) (Expr, error) {
 	return addr, nil
 }
 
+// parseFunctionName gives the name of a function called with a name that
+// looks like a column or a cell address, eg SUM(...) or LOG10(...).
+func parseFunctionName(p *Parser) (Expr, bool) {
+	if p.peek.Type != op.BegGrp {
+		return nil, false
+	}
+	ident := NewIdentifier(p.currentLiteral())
+	p.next()
+	return ident, true
+}
+
 func parseDeferred(p *Parser) (Expr, error) {
 	g := LambdaGrammar()
 	if err := p.pushGrammar(g); err != nil {
@@ -927,11 +1248,9 @@ func parseExport(p *Parser) (Expr, error) {
 	}
 	if p.is(op.Keyword) && p.currentLiteral() == kwUsing {
 		p.next()
-		if !p.is(op.Ident) {
-			return nil, p.makeError("identifier expected")
+		if stmt.formats, err = parseExportFormats(p); err != nil {
+			return nil, err
 		}
-		stmt.format = p.currentLiteral()
-		p.next()
 	}
 	if p.is(op.Keyword) && p.currentLiteral() == kwWith {
 		p.next()
@@ -944,15 +1263,119 @@ func parseExport(p *Parser) (Expr, error) {
 		} else {
 			return nil, p.makeError("literal or key/value pair expected")
 		}
+		p.next()
 	}
-	if !p.is(op.Keyword) && p.currentLiteral() != kwTo {
+	if !p.is(op.Keyword) || p.currentLiteral() != kwTo {
 		return nil, p.makeError("keyword 'to' expected")
 	}
 	p.next()
-	stmt.file, err = p.parse(powLowest)
-	if err != nil {
+	for {
+		file, err := p.parse(powLowest)
+		if err != nil {
+			return nil, err
+		}
+		stmt.files = append(stmt.files, file)
+		if !p.is(op.Comma) {
+			break
+		}
+		p.next()
+	}
+	if p.is(op.Keyword) && p.currentLiteral() == kwAs {
+		if len(stmt.formats) > 0 {
+			return nil, p.makeError("format already given with 'using'")
+		}
+		p.next()
+		if stmt.formats, err = parseExportFormats(p); err != nil {
+			return nil, err
+		}
+	}
+	if len(stmt.files) > 1 && len(stmt.formats) > 1 && len(stmt.files) != len(stmt.formats) {
+		return nil, p.makeError("number of files and formats mismatched")
+	}
+	return stmt, nil
+}
+
+// parseExportFormats parses the format of an export, given as an identifier or
+// as a list of identifiers between parentheses.
+func parseExportFormats(p *Parser) ([]string, error) {
+	if p.is(op.Ident) {
+		format := p.currentLiteral()
+		p.next()
+		return []string{format}, nil
+	}
+	if !p.is(op.BegGrp) {
+		return nil, p.makeError("identifier expected")
+	}
+	p.next()
+	var list []string
+	for !p.done() && !p.is(op.EndGrp) {
+		if !p.is(op.Ident) {
+			return nil, p.makeError("identifier expected")
+		}
+		list = append(list, p.currentLiteral())
+		p.next()
+		switch {
+		case p.is(op.Comma):
+			p.next()
+			if p.is(op.EndGrp) {
+				return nil, p.makeError("unexpected ')' after ','")
+			}
+		case p.is(op.EndGrp):
+		default:
+			return nil, p.makeError("')' or ',' expected")
+		}
+	}
+	if !p.is(op.EndGrp) {
+		return nil, p.makeError("')' expected")
+	}
+	if len(list) == 0 {
+		return nil, p.makeError("identifier expected")
+	}
+	p.next()
+	return list, nil
+}
+
+func parseSave(p *Parser) (Expr, error) {
+	p.next()
+	var (
+		stmt SaveRef
+		err  error
+	)
+	if stmt.expr, err = p.parse(powLowest); err != nil {
+		return nil, err
+	}
+	if p.is(op.Keyword) && p.currentLiteral() == kwTo {
+		p.next()
+		if stmt.file, err = p.parse(powLowest); err != nil {
+			return nil, err
+		}
+	}
+	return stmt, nil
+}
+
+func parseNotify(p *Parser) (Expr, error) {
+	p.next()
+	if !p.is(op.Ident) {
+		return nil, p.expectedIdent()
+	}
+	var (
+		stmt Notify
+		err  error
+	)
+	stmt.kind = p.currentLiteral()
+	p.next()
+	if stmt.target, err = p.parse(powLowest); err != nil {
 		return nil, err
 	}
+	if p.is(op.Keyword) && p.currentLiteral() == kwWith {
+		p.next()
+		if p.is(op.Ident) && p.currentLiteral() == "summary" {
+			stmt.summary = true
+			p.next()
+		} else if stmt.message, err = p.parse(powLowest); err != nil {
+			return nil, err
+		}
+	}
 	return stmt, nil
 }
 
@@ -973,6 +1396,26 @@ func parseUse(p *Parser) (Expr, error) {
 	return stmt, nil
 }
 
+func parsePush(p *Parser) (Expr, error) {
+	p.next()
+	var (
+		stmt Push
+		err  error
+	)
+	if stmt.expr, err = p.parse(powLowest); err != nil {
+		return nil, err
+	}
+	if stmt.readOnly, err = parseReadonly(p); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+func parsePop(p *Parser) (Expr, error) {
+	p.next()
+	return Pop{}, nil
+}
+
 func parseKeyValuePairs(p *Parser) (map[string]any, error) {
 	p.next()
 	kvs := make(map[string]any)
@@ -1056,7 +1499,6 @@ func parseMacro(p *Parser) (Expr, error) {
 	var (
 		name = p.currentLiteral()
 		args []Expr
-		body []Expr
 	)
 	p.next()
 	if !p.is(op.BegGrp) {
@@ -1091,12 +1533,24 @@ func parseMacro(p *Parser) (Expr, error) {
 	if !p.is(op.EndGrp) {
 		return nil, p.makeError("unexpected character in function call")
 	}
+	p.next()
+	body, err := parseBlockBody(p, "def", func(p *Parser) (Expr, error) {
+		return p.parse(powLowest)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewMacro(name, args, body), nil
+}
+
+func parseParallel(p *Parser) (Expr, error) {
 	p.next()
 	if !p.isTerminator() {
 		return nil, p.expectedEOL()
 	}
 	p.skipTerminator()
 
+	var body []Expr
 	for !p.done() && !(p.is(op.Keyword) && p.currentLiteral() == kwEnd) {
 		p.skipComment()
 		if p.done() {
@@ -1106,48 +1560,582 @@ func parseMacro(p *Parser) (Expr, error) {
 		if err != nil {
 			return nil, err
 		}
+		switch e.(type) {
+		case ImportFile, ExportFile:
+		default:
+			return nil, p.makeError("only import and export statements are allowed in parallel block")
+		}
 		body = append(body, e)
 		if !p.isTerminator() {
 			return nil, p.expectedEOL()
 		}
 		p.skipTerminator()
 	}
-	if !p.is(op.Keyword) && p.currentLiteral() != kwEnd {
-		return nil, p.makeError("end keyword expected at end of macro")
+	if !p.is(op.Keyword) || p.currentLiteral() != kwEnd {
+		return nil, p.makeError("end keyword expected at end of parallel block")
 	}
 	p.next()
-	return NewMacro(name, args, body), nil
-}
-
-func parseInclude(p *Parser) (Expr, error) {
+	return NewParallel(body), nil
+}
+
+// cube from <expr>
+//
+//	dimension <ident> := <column>
+//	measure <ident> := <func>(<column>)
+//	rollup <dimension>, ... as <ident>
+//	pivot <measure> by <dimension>, <dimension> as <ident>
+//
+// end
+func parseCube(p *Parser) (Expr, error) {
 	p.next()
-	if !p.is(op.Literal) {
-		msg := fmt.Sprintf("literal expected instead of %s", p.curr)
-		return nil, p.makeError(msg)
+	if !p.is(op.Keyword) || p.currentLiteral() != kwFrom {
+		return nil, p.makeError("keyword 'from' expected")
 	}
+	p.next()
+
 	var (
-		file  = p.currentLiteral()
-		alias string
+		stmt Cube
+		err  error
 	)
-	p.next()
-	if p.is(op.Keyword) && p.currentLiteral() == "as" {
-		p.next()
-		if !p.is(op.Ident) {
-			return nil, p.expectedIdent()
-		}
-		alias = p.currentLiteral()
-		p.next()
+	if stmt.source, err = p.parse(powLowest); err != nil {
+		return nil, err
+	}
+	if !p.isTerminator() {
+		return nil, p.expectedEOL()
+	}
+	p.skipTerminator()
+
+	for !p.done() && !(p.is(op.Keyword) && p.currentLiteral() == kwEnd) {
+		p.skipComment()
+		if p.isTerminator() {
+			p.skipTerminator()
+			continue
+		}
+		if !p.is(op.Ident) {
+			return nil, p.makeError("dimension, measure, rollup or pivot expected")
+		}
+		switch p.currentLiteral() {
+		case "dimension":
+			err = parseCubeDimension(p, &stmt)
+		case "measure":
+			err = parseCubeMeasure(p, &stmt)
+		case "rollup":
+			err = parseCubeRollup(p, &stmt)
+		case "pivot":
+			err = parseCubePivot(p, &stmt)
+		default:
+			msg := fmt.Sprintf("%s: unknown cube statement", p.currentLiteral())
+			err = p.makeError(msg)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !p.isTerminator() {
+			return nil, p.expectedEOL()
+		}
+		p.skipTerminator()
+	}
+	if !p.is(op.Keyword) || p.currentLiteral() != kwEnd {
+		return nil, p.makeError("end keyword expected at end of cube block")
+	}
+	p.next()
+	if len(stmt.dimensions) == 0 {
+		return nil, p.makeError("cube without dimension")
+	}
+	return stmt, nil
+}
+
+func parseCubeDimension(p *Parser, stmt *Cube) error {
+	p.next()
+	name, err := parseCubeName(p)
+	if err != nil {
+		return err
+	}
+	col, err := parseColumnIndex(p)
+	if err != nil {
+		return err
+	}
+	stmt.dimensions = append(stmt.dimensions, CubeDimension{
+		Name:   name,
+		Column: col,
+	})
+	return nil
+}
+
+func parseCubeMeasure(p *Parser, stmt *Cube) error {
+	p.next()
+	name, err := parseCubeName(p)
+	if err != nil {
+		return err
+	}
+	if !p.is(op.Ident) {
+		return p.expectedIdent()
+	}
+	fn := p.currentLiteral()
+	p.next()
+	if !p.is(op.BegGrp) {
+		return p.makeError("expected '(' after aggregate function")
+	}
+	p.next()
+	col, err := parseColumnIndex(p)
+	if err != nil {
+		return err
+	}
+	if !p.is(op.EndGrp) {
+		return p.makeError("expected ')' after column")
+	}
+	p.next()
+	stmt.measures = append(stmt.measures, CubeMeasure{
+		Name:   name,
+		Func:   fn,
+		Column: col,
+	})
+	return nil
+}
+
+func parseCubeRollup(p *Parser, stmt *Cube) error {
+	p.next()
+	out := CubeOutput{
+		Kind: CubeRollup,
+	}
+	dims, err := parseCubeList(p)
+	if err != nil {
+		return err
+	}
+	out.Dimensions = dims
+	if out.Ident, err = parseCubeAlias(p); err != nil {
+		return err
+	}
+	stmt.outputs = append(stmt.outputs, out)
+	return nil
+}
+
+func parseCubePivot(p *Parser, stmt *Cube) error {
+	p.next()
+	if !p.is(op.Ident) {
+		return p.expectedIdent()
+	}
+	out := CubeOutput{
+		Kind:    CubePivot,
+		Measure: p.currentLiteral(),
+	}
+	p.next()
+	if !p.is(op.Ident) || p.currentLiteral() != "by" {
+		return p.makeError("by expected after measure")
+	}
+	p.next()
+	dims, err := parseCubeList(p)
+	if err != nil {
+		return err
+	}
+	if len(dims) != 2 {
+		return p.makeError("pivot expects two dimensions")
+	}
+	out.Dimensions = dims
+	if out.Ident, err = parseCubeAlias(p); err != nil {
+		return err
+	}
+	stmt.outputs = append(stmt.outputs, out)
+	return nil
+}
+
+func parseCubeName(p *Parser) (string, error) {
+	if !p.is(op.Ident) {
+		return "", p.expectedIdent()
+	}
+	name := p.currentLiteral()
+	p.next()
+	if !p.is(op.Assign) {
+		return "", p.makeError("expected ':=' after name")
+	}
+	p.next()
+	return name, nil
+}
+
+func parseColumnIndex(p *Parser) (int64, error) {
+	str := p.currentLiteral()
+	if !p.is(op.Ident) && !p.is(op.Column) {
+		return 0, p.makeError("column expected")
+	}
+	col, size := layout.ParseIndex(str)
+	if size == 0 || size != len(str) {
+		return 0, p.makeError(fmt.Sprintf("%s: invalid column", str))
+	}
+	p.next()
+	return col, nil
+}
+
+// group <expr> by <column> [as <ident>], ... [aggregate <func>([<column>]) [as <ident>], ...]
+func parseGroupBy(p *Parser) (Expr, error) {
+	p.next()
+	var (
+		stmt GroupBy
+		err  error
+	)
+	if stmt.view, err = p.parse(powLowest); err != nil {
+		return nil, err
+	}
+	if !p.is(op.Ident) || p.currentLiteral() != "by" {
+		return nil, p.makeError("by expected after view")
+	}
+	p.next()
+	for {
+		key := GroupKey{
+			Name: p.currentLiteral(),
+		}
+		if key.Column, err = parseColumnIndex(p); err != nil {
+			return nil, err
+		}
+		if key.Name, err = parseOptionalAlias(p, key.Name); err != nil {
+			return nil, err
+		}
+		stmt.keys = append(stmt.keys, key)
+		if !p.is(op.Comma) {
+			break
+		}
+		p.next()
+	}
+	if !p.is(op.Ident) || p.currentLiteral() != "aggregate" {
+		return stmt, nil
+	}
+	p.next()
+	for {
+		if !p.is(op.Ident) {
+			return nil, p.expectedIdent()
+		}
+		aggr := GroupAggr{
+			Func: p.currentLiteral(),
+		}
+		p.next()
+		if !p.is(op.BegGrp) {
+			return nil, p.makeError("expected '(' after aggregate function")
+		}
+		p.next()
+		name := aggr.Func
+		if !p.is(op.EndGrp) {
+			name = fmt.Sprintf("%s(%s)", aggr.Func, p.currentLiteral())
+			if aggr.Column, err = parseColumnIndex(p); err != nil {
+				return nil, err
+			}
+		}
+		if !p.is(op.EndGrp) {
+			return nil, p.makeError("expected ')' after column")
+		}
+		p.next()
+		if aggr.Name, err = parseOptionalAlias(p, name); err != nil {
+			return nil, err
+		}
+		stmt.aggrs = append(stmt.aggrs, aggr)
+		if !p.is(op.Comma) {
+			break
+		}
+		p.next()
+	}
+	return stmt, nil
+}
+
+func parseOptionalAlias(p *Parser, name string) (string, error) {
+	if !p.is(op.Keyword) || p.currentLiteral() != kwAs {
+		return name, nil
+	}
+	p.next()
+	if !p.is(op.Ident) {
+		return "", p.expectedIdent()
+	}
+	defer p.next()
+	return p.currentLiteral(), nil
+}
+
+func parseCubeList(p *Parser) ([]string, error) {
+	var list []string
+	for {
+		if !p.is(op.Ident) {
+			return nil, p.expectedIdent()
+		}
+		list = append(list, p.currentLiteral())
+		p.next()
+		if !p.is(op.Comma) {
+			break
+		}
+		p.next()
+	}
+	return list, nil
+}
+
+func parseCubeAlias(p *Parser) (string, error) {
+	if !p.is(op.Keyword) || p.currentLiteral() != kwAs {
+		return "", p.makeError("keyword 'as' expected")
+	}
+	p.next()
+	if !p.is(op.Ident) {
+		return "", p.expectedIdent()
+	}
+	defer p.next()
+	return p.currentLiteral(), nil
+}
+
+func parseWith(p *Parser) (Expr, error) {
+	p.next()
+	switch {
+	case p.is(op.Keyword) && p.currentLiteral() == kwSheet:
+		return parseWithSheet(p)
+	case p.is(op.Ident) && p.currentLiteral() == "filter":
+		return parseWithFilter(p)
+	case p.is(op.Ident) && (p.currentLiteral() == "chart" || p.currentLiteral() == "pivot"):
+		msg := fmt.Sprintf("with %s: block not supported", p.currentLiteral())
+		return nil, p.makeError(msg)
+	default:
+		return nil, p.makeError("sheet or filter expected after with")
+	}
+}
+
+func parseWithSheet(p *Parser) (Expr, error) {
+	expr, err := parseSheet(p)
+	if err != nil {
+		return nil, err
+	}
+	sheet := expr.(Sheet)
+	if sheet.data != nil {
+		return nil, p.makeError("data can not be given to sheet of with block")
+	}
+	body, err := parseBlockBody(p, "with", func(p *Parser) (Expr, error) {
+		return p.parse(powLowest)
+	})
+	if err != nil {
+		return nil, err
+	}
+	stmt := WithSheet{
+		sheet: sheet,
+		body:  body,
+	}
+	return stmt, nil
+}
+
+func parseWithFilter(p *Parser) (Expr, error) {
+	p.next()
+	if !p.is(op.Ident) {
+		return nil, p.expectedIdent()
+	}
+	stmt := WithFilter{
+		ident: p.currentLiteral(),
+	}
+	p.next()
+	body, err := parseBlockBody(p, "with", func(p *Parser) (Expr, error) {
+		if err := p.pushGrammar(SliceGrammar()); err != nil {
+			return nil, err
+		}
+		defer p.popGrammar()
+		return p.parse(powLowest)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		return nil, p.makeError("filter without condition")
+	}
+	stmt.body = body
+	return stmt, nil
+}
+
+// parseBlockBody parses the statements of a block up to its end keyword.
+func parseBlockBody(p *Parser, block string, parse func(*Parser) (Expr, error)) ([]Expr, error) {
+	if !p.isTerminator() {
+		return nil, p.expectedEOL()
+	}
+	p.skipTerminator()
+
+	body, err := parseStatements(p, parse, kwEnd)
+	if err != nil {
+		return nil, err
+	}
+	if !p.is(op.Keyword) || p.currentLiteral() != kwEnd {
+		msg := fmt.Sprintf("end keyword expected at end of %s block", block)
+		return nil, p.makeError(msg)
+	}
+	p.next()
+	return body, nil
+}
+
+// parseStatements parses statements until one of the given keywords. The
+// keyword is not consumed.
+func parseStatements(p *Parser, parse func(*Parser) (Expr, error), stops ...string) ([]Expr, error) {
+	var body []Expr
+	for !p.done() && !(p.is(op.Keyword) && slices.Contains(stops, p.currentLiteral())) {
+		p.skipComment()
+		if p.done() {
+			break
+		}
+		if p.isTerminator() {
+			p.skipTerminator()
+			continue
+		}
+		e, err := parse(p)
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, e)
+		if !p.isTerminator() {
+			return nil, p.expectedEOL()
+		}
+		p.skipTerminator()
+	}
+	return body, nil
+}
+
+func parseIf(p *Parser) (Expr, error) {
+	p.next()
+	var (
+		stmt If
+		err  error
+	)
+	if stmt.cond, err = p.parse(powLowest); err != nil {
+		return nil, err
+	}
+	if !p.is(op.Keyword) || p.currentLiteral() != kwThen {
+		return nil, p.makeError("keyword 'then' expected")
+	}
+	p.next()
+	if !p.isTerminator() {
+		return nil, p.expectedEOL()
+	}
+	p.skipTerminator()
+
+	parse := func(p *Parser) (Expr, error) {
+		return p.parse(powLowest)
+	}
+	if stmt.csq, err = parseStatements(p, parse, kwElse, kwEnd); err != nil {
+		return nil, err
+	}
+	if p.is(op.Keyword) && p.currentLiteral() == kwElse {
+		p.next()
+		if p.is(op.Keyword) && p.currentLiteral() == kwIf {
+			// else if shares the end keyword of the first if
+			alt, err := parseIf(p)
+			if err != nil {
+				return nil, err
+			}
+			stmt.alt = []Expr{alt}
+			return stmt, nil
+		}
+		if !p.isTerminator() {
+			return nil, p.expectedEOL()
+		}
+		p.skipTerminator()
+		if stmt.alt, err = parseStatements(p, parse, kwEnd); err != nil {
+			return nil, err
+		}
+	}
+	if !p.is(op.Keyword) || p.currentLiteral() != kwEnd {
+		return nil, p.makeError("end keyword expected at end of if block")
+	}
+	p.next()
+	return stmt, nil
+}
+
+func parseFor(p *Parser) (Expr, error) {
+	p.next()
+	if !p.is(op.Ident) && !(p.is(op.Keyword) && p.currentLiteral() == kwRow) {
+		return nil, p.expectedIdent()
+	}
+	var (
+		stmt = For{
+			ident: p.currentLiteral(),
+		}
+		err error
+	)
+	p.next()
+	if !p.is(op.Keyword) || p.currentLiteral() != kwIn {
+		return nil, p.makeError("keyword 'in' expected")
+	}
+	p.next()
+	if stmt.iter, err = p.parse(powLowest); err != nil {
+		return nil, err
+	}
+	if !p.is(op.Keyword) || p.currentLiteral() != kwDo {
+		return nil, p.makeError("keyword 'do' expected")
+	}
+	p.next()
+	stmt.body, err = parseBlockBody(p, "for", func(p *Parser) (Expr, error) {
+		return p.parse(powLowest)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+func parseWhile(p *Parser) (Expr, error) {
+	p.next()
+	var (
+		stmt While
+		err  error
+	)
+	if stmt.cond, err = p.parse(powLowest); err != nil {
+		return nil, err
+	}
+	if !p.is(op.Keyword) || p.currentLiteral() != kwDo {
+		return nil, p.makeError("keyword 'do' expected")
+	}
+	p.next()
+	stmt.body, err = parseBlockBody(p, "while", func(p *Parser) (Expr, error) {
+		return p.parse(powLowest)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+func parseBreak(p *Parser) (Expr, error) {
+	p.next()
+	return Break{}, nil
+}
+
+func parseContinue(p *Parser) (Expr, error) {
+	p.next()
+	return Continue{}, nil
+}
+
+// parseKeywordIdentifier parses a keyword used as the name of a variable,
+// e.g. the row variable of a for loop or the rows option of pivot.
+func parseKeywordIdentifier(p *Parser) (Expr, error) {
+	defer p.next()
+	return NewIdentifier(p.currentLiteral()), nil
+}
+
+func parseInclude(p *Parser) (Expr, error) {
+	p.next()
+	if !p.is(op.Literal) {
+		msg := fmt.Sprintf("literal expected instead of %s", p.curr)
+		return nil, p.makeError(msg)
+	}
+	var (
+		file  = p.currentLiteral()
+		alias string
+	)
+	p.next()
+	if p.is(op.Keyword) && p.currentLiteral() == "as" {
+		p.next()
+		if !p.is(op.Ident) {
+			return nil, p.expectedIdent()
+		}
+		alias = p.currentLiteral()
+		p.next()
 	}
 	return NewInclude(file, alias), nil
 }
 
+func parseMisplacedInclude(p *Parser) (Expr, error) {
+	return nil, p.makeError("include only allowed at the start of a script")
+}
+
 func parseSheet(p *Parser) (Expr, error) {
 	p.next()
 	var (
 		stmt Sheet
 		err  error
 	)
-	if p.is(op.Ident) {
+	if p.is(op.Ident) || p.is(op.Literal) {
 		stmt.name, err = p.parse(powLowest)
 		if err != nil {
 			return nil, err
@@ -1201,7 +2189,13 @@ func parseLock(p *Parser) (Expr, error) {
 	if err != nil {
 		return nil, err
 	}
-	return newLock(ident), nil
+	opts, err := parseLockOptions(p)
+	if err != nil {
+		return nil, err
+	}
+	stmt := newLock(ident).(Lock)
+	stmt.options = opts
+	return stmt, nil
 }
 
 func parseUnlock(p *Parser) (Expr, error) {
@@ -1210,7 +2204,29 @@ func parseUnlock(p *Parser) (Expr, error) {
 	if err != nil {
 		return nil, err
 	}
-	return newUnlock(ident), nil
+	opts, err := parseLockOptions(p)
+	if err != nil {
+		return nil, err
+	}
+	stmt := newUnlock(ident).(Unlock)
+	stmt.options = opts
+	return stmt, nil
+}
+
+func parseLockOptions(p *Parser) (map[string]any, error) {
+	if !p.is(op.Keyword) || p.currentLiteral() != kwWith {
+		return nil, nil
+	}
+	p.next()
+	if !p.is(op.BegGrp) {
+		return nil, p.makeError("key/value pair expected")
+	}
+	opts, err := parseKeyValuePairs(p)
+	if err != nil {
+		return nil, err
+	}
+	p.next()
+	return opts, nil
 }
 
 func parseRename(p *Parser) (Expr, error) {
@@ -1230,6 +2246,29 @@ func parseRename(p *Parser) (Expr, error) {
 	return NewRename(ident, name), nil
 }
 
+func parseDefine(p *Parser) (Expr, error) {
+	p.next()
+	if !p.is(op.Ident) {
+		return nil, p.makeError("name expected after define")
+	}
+	ident := p.currentLiteral()
+	p.next()
+	if !p.is(op.Assign) {
+		return nil, p.makeError("':=' expected after name")
+	}
+	p.next()
+	expr, err := p.parse(powLowest)
+	if err != nil {
+		return nil, err
+	}
+	switch expr.(type) {
+	case CellAddr, RangeAddr, CellAccess:
+	default:
+		return nil, p.makeError("only cell/range addresses can be named")
+	}
+	return NewDefine(ident, expr), nil
+}
+
 func parseRowOrColumn(p *Parser) (Colrow, error) {
 	if !p.is(op.Keyword) {
 		return 0, p.makeError("row/rows/column/columns keyword expected")
@@ -1475,6 +2514,26 @@ func parseArrow(p *Parser, left Expr) (Expr, error) {
 	return NewChainCall(left, next), nil
 }
 
+// parsePipe parses the call given after |>. The left side is given as first
+// argument of the call, so data |> head(10) is the same as head(data, 10). A
+// name alone is called with the left side as its only argument.
+func parsePipe(p *Parser, left Expr) (Expr, error) {
+	p.next()
+	right, err := p.parse(powPipe)
+	if err != nil {
+		return nil, err
+	}
+	switch e := right.(type) {
+	case Call:
+		e.args = append([]Expr{left}, e.args...)
+		return e, nil
+	case Identifier:
+		return NewCall(e, []Expr{left}), nil
+	default:
+		return nil, p.makeError("function call expected after |>")
+	}
+}
+
 func parseSlice(p *Parser, left Expr) (Expr, error) {
 	g := SliceGrammar()
 	if err := p.pushGrammar(g); err != nil {
@@ -1488,6 +2547,11 @@ func parseSlice(p *Parser, left Expr) (Expr, error) {
 	if err != nil {
 		return nil, err
 	}
+	if p.is(op.Comma) {
+		if expr, err = parseRowWindow(p, expr); err != nil {
+			return nil, err
+		}
+	}
 	if !p.is(op.EndProp) {
 		return nil, p.makeError("expected ] at end of slice expression")
 	}
@@ -1500,13 +2564,49 @@ func parseSlice(p *Parser, left Expr) (Expr, error) {
 	return NewSlice(left, expr), nil
 }
 
+// parseRowWindow parses the columns given after the lines of a slice, eg
+// view[10:200, A:C]. The lines are a single line or an interval of lines.
+func parseRowWindow(p *Parser, lines Expr) (Expr, error) {
+	var from, to Expr
+	switch e := lines.(type) {
+	case Number:
+		from, to = e, e
+	case IntervalExpr:
+		if e.step != nil {
+			return nil, p.makeError("step not allowed in lines of slice")
+		}
+		from, to = e.from, e.to
+	default:
+		return nil, p.makeError("lines expected before ',' in slice")
+	}
+	p.next()
+	columns, err := p.parse(powLowest)
+	if err != nil {
+		return nil, err
+	}
+	switch columns.(type) {
+	case IntervalExpr:
+		columns = IntervalList{
+			items: []Expr{columns},
+		}
+	case IntervalList, ColumnAddr:
+	default:
+		return nil, p.makeError("columns expected after ',' in slice")
+	}
+	window := NewRowWindow(from, to, columns)
+	if _, _, err := window.(RowWindow).Lines(); err != nil {
+		return nil, p.makeError(err.Error())
+	}
+	return window, nil
+}
+
 func parseOpenSelectedColumns(p *Parser) (Expr, error) {
 	p.next()
 	var (
 		expr IntervalExpr
 		err  error
 	)
-	if !p.is(op.EndProp) && !p.is(op.Semi) {
+	if !p.is(op.EndProp) && !p.is(op.Semi) && !p.is(op.Comma) {
 		expr.to, err = p.parse(powList)
 	}
 	return expr, err
@@ -1518,7 +2618,7 @@ func parseRangeColumns(p *Parser, left Expr) (Expr, error) {
 		right Expr
 		err   error
 	)
-	if !p.is(op.EndProp) && !p.is(op.Semi) && !p.is(op.RangeRef) {
+	if !p.is(op.EndProp) && !p.is(op.Semi) && !p.is(op.RangeRef) && !p.is(op.Comma) {
 		right, err = p.parse(powRange)
 	}
 	if err != nil {
@@ -0,0 +1,170 @@
+package parse
+
+import (
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+// RelocateFunc gives the new bounds of the cells referenced by a formula. A
+// single cell is given with the same start and end. ok is false when the cells
+// no longer exist.
+type RelocateFunc func(start, end layout.Position) (layout.Position, layout.Position, bool)
+
+// Relocate gives a copy of expr where the cells referenced are moved to the
+// position given by fn. Unlike CloneWithOffset, absolute references are also
+// moved: it is used to keep formulas pointing at the same cells once rows or
+// columns are inserted in a sheet. References to cells that no longer exist
+// are replaced by #REF!.
+func Relocate(expr Expr, fn RelocateFunc) Expr {
+	switch e := expr.(type) {
+	case Binary:
+		return Binary{
+			left:  Relocate(e.left, fn),
+			right: Relocate(e.right, fn),
+			op:    e.op,
+		}
+	case Unary:
+		return Unary{
+			expr: Relocate(e.expr, fn),
+			op:   e.op,
+		}
+	case Postfix:
+		return Postfix{
+			expr: Relocate(e.expr, fn),
+			op:   e.op,
+		}
+	case Call:
+		x := Call{
+			ident: e.ident,
+		}
+		for _, a := range e.args {
+			x.args = append(x.args, Relocate(a, fn))
+		}
+		return x
+	case CellAddr:
+		pos, _, ok := fn(e.Position, e.Position)
+		if !ok {
+			return refError()
+		}
+		e.Position = pos
+		return e
+	case RangeAddr:
+		start, end, ok := fn(e.startAddr.Position, e.endAddr.Position)
+		if !ok {
+			return refError()
+		}
+		e.startAddr.Position = start
+		e.endAddr.Position = end
+		return e
+	case ColumnAddr:
+		pos, _, ok := fn(e.Position, e.Position)
+		if !ok {
+			return refError()
+		}
+		e.Position = pos
+		return e
+	case CellAccess:
+		var sheet string
+		switch x := e.expr.(type) {
+		case Identifier:
+			sheet = x.Ident()
+		case Literal:
+			sheet = x.Text()
+		default:
+			return expr
+		}
+		addr := Relocate(e.addr, func(start, end layout.Position) (layout.Position, layout.Position, bool) {
+			start, end, ok := fn(start.WithSheet(sheet), end.WithSheet(sheet))
+			return start.WithoutSheet(), end.WithoutSheet(), ok
+		})
+		if _, ok := addr.(Identifier); ok {
+			return addr
+		}
+		e.addr = addr
+		return e
+	default:
+		return expr
+	}
+}
+
+func refError() Expr {
+	return NewIdentifier(value.ErrRef.String())
+}
+
+// LookupFunc gives the index of the column with the given name. ok is false
+// when the view has no column with this name.
+type LookupFunc func(name string) (int64, bool)
+
+// ResolveNames gives a copy of the expression of a slice where the names of
+// columns are replaced by the address of their column. Identifiers and
+// literals are names of columns when fn finds them, eg 'price' > 100 or
+// name;age.
+func ResolveNames(expr Expr, fn LookupFunc) Expr {
+	column := func(name string) (Expr, bool) {
+		ix, ok := fn(name)
+		if !ok {
+			return nil, false
+		}
+		return NewColumnAddr(layout.NewPosition(0, ix), false), true
+	}
+	switch e := expr.(type) {
+	case Identifier:
+		if c, ok := column(e.name); ok {
+			return c
+		}
+		return expr
+	case Literal:
+		if c, ok := column(e.value); ok {
+			return c
+		}
+		return expr
+	case Binary:
+		e.left = ResolveNames(e.left, fn)
+		e.right = ResolveNames(e.right, fn)
+		return e
+	case Unary:
+		e.expr = ResolveNames(e.expr, fn)
+		return e
+	case Not:
+		e.expr = ResolveNames(e.expr, fn)
+		return e
+	case And:
+		e.left = ResolveNames(e.left, fn)
+		e.right = ResolveNames(e.right, fn)
+		return e
+	case Or:
+		e.left = ResolveNames(e.left, fn)
+		e.right = ResolveNames(e.right, fn)
+		return e
+	case Call:
+		x := Call{
+			ident:    e.ident,
+			Position: e.Position,
+		}
+		for _, a := range e.args {
+			x.args = append(x.args, ResolveNames(a, fn))
+		}
+		return x
+	case IntervalList:
+		x := IntervalList{
+			Position: e.Position,
+		}
+		for _, i := range e.items {
+			x.items = append(x.items, ResolveNames(i, fn))
+		}
+		return x
+	case IntervalExpr:
+		if e.from != nil {
+			e.from = ResolveNames(e.from, fn)
+		}
+		if e.to != nil {
+			e.to = ResolveNames(e.to, fn)
+		}
+		return e
+	case RowWindow:
+		e.columns = ResolveNames(e.columns, fn)
+		return e
+	default:
+		return expr
+	}
+}
@@ -58,6 +58,12 @@ func ScanOds(r io.Reader) (Scanner, error) {
 	return ScanDialect(r, Ods)
 }
 
+// ScanOxmlLocale gives a scanner for Excel formulas written by locales using
+// a semicolon as separator of arguments and a comma as decimal mark.
+func ScanOxmlLocale(r io.Reader) (Scanner, error) {
+	return ScanDialect(r, OxmlLocale)
+}
+
 type ScriptLexer struct {
 	*reader
 }
@@ -104,6 +110,8 @@ func (x *ScriptLexer) Scan() Token {
 		x.scanLiteral(&tok)
 	case isDigit(x.char):
 		x.scanNumber(&tok)
+	case x.char == 'd' && x.peek() == squote:
+		x.scanDate(&tok)
 	default:
 		x.scanIdent(&tok)
 	}
@@ -197,7 +205,8 @@ func (x *ScriptLexer) scanIdent(tok *Token) {
 		tok.Type = op.Column
 	}
 
-	if isKeyword(tok.Literal) {
+	// if followed by a parenthesis is the name of the IF function
+	if isKeyword(tok.Literal) && !(tok.Literal == kwIf && x.char == lparen) {
 		tok.Type = op.Keyword
 		if tok.Literal == kwAnd {
 			tok.Type = op.And
@@ -243,7 +252,7 @@ func (x *ScriptLexer) scanNumber(tok *Token) {
 func (x *ScriptLexer) scanLiteral(tok *Token) {
 	quote := x.char
 	x.read()
-	for !x.done() && !isQuote(x.char) {
+	for !x.done() && x.char != quote {
 		x.write()
 		x.read()
 		if x.char == dquote && x.peek() == x.char {
@@ -261,6 +270,16 @@ func (x *ScriptLexer) scanLiteral(tok *Token) {
 	}
 }
 
+// scanDate scans a date literal written as a quoted text prefixed by d, eg
+// d'2024-01-31'.
+func (x *ScriptLexer) scanDate(tok *Token) {
+	x.read()
+	x.scanLiteral(tok)
+	if tok.Type == op.Literal {
+		tok.Type = op.Date
+	}
+}
+
 func (x *ScriptLexer) scanOperator(tok *Token) {
 	tok.Type = op.Invalid
 	switch x.char {
@@ -270,6 +289,10 @@ func (x *ScriptLexer) scanOperator(tok *Token) {
 		tok.Type = op.Dot
 	case pipe:
 		tok.Type = op.Union
+		if x.peek() == rangle {
+			x.read()
+			tok.Type = op.Pipe
+		}
 	case amper:
 		tok.Type = op.Concat
 		if x.peek() == equal {
@@ -439,6 +462,14 @@ func (d oxmlDialect) ScanOperator(sc *FormulaLexer, tok *Token) {
 }
 
 func (d oxmlDialect) ScanDelimiter(sc *FormulaLexer, tok *Token) {
+	if sc.is(lsquare) && (sc.last == op.Ident || sc.last == op.Cell) {
+		sc.scanTable(tok)
+		return
+	}
+	if sc.is(lsquare) {
+		sc.scanExternal(tok)
+		return
+	}
 	sc.scanDelimiter(tok)
 }
 
@@ -447,7 +478,7 @@ func (d oxmlDialect) ScanLiteral(sc *FormulaLexer, tok *Token) {
 }
 
 func (d oxmlDialect) ScanNumber(sc *FormulaLexer, tok *Token) {
-	sc.scanNumber(tok)
+	sc.scanNumber(tok, dot)
 }
 
 func (d oxmlDialect) ScanIdentifier(sc *FormulaLexer, tok *Token) {
@@ -537,7 +568,7 @@ func (d odsDialect) ScanLiteral(sc *FormulaLexer, tok *Token) {
 }
 
 func (d odsDialect) ScanNumber(sc *FormulaLexer, tok *Token) {
-	sc.scanNumber(tok)
+	sc.scanNumber(tok, dot)
 }
 
 func (d odsDialect) ScanIdentifier(sc *FormulaLexer, tok *Token) {
@@ -552,19 +583,46 @@ func (d odsDialect) ScanAddress(sc *FormulaLexer, tok *Token) {
 
 }
 
+// localeDialect is the dialect of Excel formulas saved with the separators
+// of locales where the comma is the decimal mark: arguments are separated by
+// semicolons. Tokens are the same as the ones of the oxml dialect.
+type localeDialect struct {
+	oxmlDialect
+}
+
+func (d localeDialect) ScanDelimiter(sc *FormulaLexer, tok *Token) {
+	if sc.is(semi) {
+		tok.Type = op.Comma
+		sc.read()
+		return
+	}
+	d.oxmlDialect.ScanDelimiter(sc, tok)
+}
+
+func (d localeDialect) ScanNumber(sc *FormulaLexer, tok *Token) {
+	sc.scanNumber(tok, comma)
+}
+
 var (
-	Ods  = odsDialect{}
-	Oxml = oxmlDialect{}
+	Ods        = odsDialect{}
+	Oxml       = oxmlDialect{}
+	OxmlLocale = localeDialect{}
 )
 
 type FormulaLexer struct {
 	dialect Dialect
 	*reader
+
+	// type of the last token scanned
+	last op.Op
 }
 
 func (s *FormulaLexer) Peek() Token {
-	currState := s.Save()
-	defer s.Restore(currState)
+	currState, last := s.Save(), s.last
+	defer func() {
+		s.Restore(currState)
+		s.last = last
+	}()
 	return s.Scan()
 }
 
@@ -577,6 +635,7 @@ func (s *FormulaLexer) Type() DialectType {
 }
 
 func (s *FormulaLexer) Scan() Token {
+	blank := isBlank(s.char)
 	s.skipBlanks()
 
 	var tok Token
@@ -585,8 +644,13 @@ func (s *FormulaLexer) Scan() Token {
 		tok.Type = op.EOF
 		return tok
 	}
-	defer s.reset()
+	defer func() {
+		s.last = tok.Type
+		s.reset()
+	}()
 	switch {
+	case blank && s.intersect():
+		tok.Type = op.Intersect
 	case isNL(s.char):
 		s.SkipNL()
 		return s.Scan()
@@ -608,6 +672,21 @@ func (s *FormulaLexer) Scan() Token {
 	return tok
 }
 
+// intersect reports whether the blanks just skipped are the intersection
+// operator of Excel, eg A1:C3 B2:D4: blanks between a reference and the start
+// of another one.
+func (s *FormulaLexer) intersect() bool {
+	if s.dialect.Type() != TypeOxml {
+		return false
+	}
+	switch s.last {
+	case op.Cell, op.Ident, op.Number, op.EndGrp, op.TableRef:
+	default:
+		return false
+	}
+	return isLetter(s.char) || isDigit(s.char) || s.char == dollar || s.char == squote
+}
+
 func (s *FormulaLexer) scanError(tok *Token) {
 	s.write()
 	s.read()
@@ -637,15 +716,17 @@ func (s *FormulaLexer) scanIdent(tok *Token) {
 	}
 }
 
-func (s *FormulaLexer) scanNumber(tok *Token) {
+// scanNumber scans a number whose decimal part follows the given mark. The
+// literal of the token always uses a dot as decimal mark.
+func (s *FormulaLexer) scanNumber(tok *Token, mark rune) {
 	tok.Type = op.Number
 	for !s.done() && isDigit(s.char) {
 		s.write()
 		s.read()
 	}
 	tok.Literal = s.literal()
-	if s.char == dot {
-		s.write()
+	if s.char == mark && (mark == dot || isDigit(s.peek())) {
+		s.buf.WriteRune(dot)
 		s.read()
 		for !s.done() && isDigit(s.char) {
 			s.write()
@@ -671,24 +752,76 @@ func (s *FormulaLexer) scanNumber(tok *Token) {
 func (s *FormulaLexer) scanLiteral(tok *Token) {
 	quote := s.char
 	s.read()
-	for !s.done() && !isQuote(s.char) {
-		s.write()
-		s.read()
-		if s.char == dquote && s.peek() == s.char {
-			s.write()
-			s.read()
+	for !s.done() {
+		if s.char == quote {
+			// a doubled quote stands for the quote itself: 'it''s'!A1
+			if s.peek() != quote {
+				break
+			}
 			s.read()
 		}
+		s.write()
+		s.read()
 	}
 	tok.Type = op.Literal
 	tok.Literal = s.literal()
-	if isQuote(s.char) && quote == s.char {
+	if s.char == quote {
 		s.read()
 	} else {
 		tok.Type = op.Invalid
 	}
 }
 
+// scanExternal scans the name of a sheet of another workbook, eg
+// [Book2.xlsx]Sheet1. It is given as a literal like the quoted names of
+// sheets: '[Book 2.xlsx]Sheet 1'.
+func (s *FormulaLexer) scanExternal(tok *Token) {
+	tok.Type = op.Invalid
+	for !s.done() && s.char != rsquare {
+		s.write()
+		s.read()
+	}
+	if s.char != rsquare {
+		return
+	}
+	s.write()
+	s.read()
+	for !s.done() && (isLetter(s.char) || isDigit(s.char) || s.char == dot) {
+		s.write()
+		s.read()
+	}
+	tok.Type = op.Literal
+	tok.Literal = s.literal()
+}
+
+// scanTable scans the specifier of a structured reference following the name
+// of a table, eg [Amount] or [[#Totals],[Amount]]. The literal of the token is
+// the specifier without its outer brackets. Apostrophes escape the brackets
+// and the other special characters of the names of columns.
+func (s *FormulaLexer) scanTable(tok *Token) {
+	tok.Type = op.Invalid
+	s.read()
+	for depth := 0; !s.done(); {
+		switch s.char {
+		case squote:
+			s.write()
+			s.read()
+		case lsquare:
+			depth++
+		case rsquare:
+			if depth == 0 {
+				s.read()
+				tok.Type = op.TableRef
+				tok.Literal = s.literal()
+				return
+			}
+			depth--
+		}
+		s.write()
+		s.read()
+	}
+}
+
 func (s *FormulaLexer) scanOperator(tok *Token) {
 	tok.Type = op.Invalid
 	switch s.char {
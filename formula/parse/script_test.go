@@ -2,15 +2,97 @@ package parse
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/midbel/dockit/formula/op"
 	"github.com/midbel/dockit/layout"
 )
 
 func TestExportStmt(t *testing.T) {
-	t.SkipNow()
+	tests := []struct {
+		Expr    string
+		Format  string
+		Files   int
+		Formats int
+		Options map[string]any
+	}{
+		{
+			Expr:  "export data to \"out.xlsx\"",
+			Files: 1,
+		},
+		{
+			Expr:    "export data using csv to \"out.txt\"",
+			Format:  "csv",
+			Files:   1,
+			Formats: 1,
+		},
+		{
+			Expr:    "export data with (delimiter := 'tab') to \"out\" as csv",
+			Format:  "csv",
+			Files:   1,
+			Formats: 1,
+			Options: map[string]any{"delimiter": "tab"},
+		},
+		{
+			Expr:  "export data to \"out.csv\", \"out.json\"",
+			Files: 2,
+		},
+		{
+			Expr:    "export data to \"out\" as (csv, json, xlsx)",
+			Format:  "csv",
+			Files:   1,
+			Formats: 3,
+		},
+		{
+			Expr:    "export data using (csv, json) to \"out\", \"res\"",
+			Format:  "csv",
+			Files:   2,
+			Formats: 2,
+		},
+	}
+	for _, c := range tests {
+		expr, err := parseExpr(c.Expr)
+		if err != nil {
+			t.Errorf("%s: fail to parse expr: %s", c.Expr, err)
+			continue
+		}
+		stmt, ok := unwrapScriptExpr(expr).(ExportFile)
+		if !ok {
+			t.Errorf("%s: expected ExportFile statement, got %T", c.Expr, expr)
+			continue
+		}
+		if stmt.Format() != c.Format {
+			t.Errorf("%s: format mismatched! want %s, got %s", c.Expr, c.Format, stmt.Format())
+		}
+		if len(stmt.Files()) != c.Files || len(stmt.Formats()) != c.Formats {
+			t.Errorf("%s: targets mismatched! want %d/%d, got %d/%d", c.Expr, c.Files, c.Formats, len(stmt.Files()), len(stmt.Formats()))
+		}
+		opts := stmt.Options()
+		if len(opts) != len(c.Options) {
+			t.Errorf("%s: options mismatched! want %v, got %v", c.Expr, c.Options, opts)
+			continue
+		}
+		for k, v := range c.Options {
+			if opts[k] != v {
+				t.Errorf("%s: option %s mismatched! want %v, got %v", c.Expr, k, v, opts[k])
+			}
+		}
+	}
+	invalid := []string{
+		"export data",
+		"export data using csv to \"out\" as json",
+		"export data to \"out\" as ()",
+		"export data to \"out\" as (csv,)",
+		"export data to \"a\", \"b\", \"c\" as (csv, json)",
+	}
+	for _, str := range invalid {
+		if _, err := parseExpr(str); err == nil {
+			t.Errorf("%s: expected error parsing export statement", str)
+		}
+	}
 }
 
 func TestClearStmt(t *testing.T) {
@@ -151,6 +233,34 @@ func assertImportRef(t *testing.T, expr string, got ImportFile, want importExpec
 	}
 }
 
+func assertEqualBody(t *testing.T, want, got []Expr) {
+	t.Helper()
+	if len(want) != len(got) {
+		t.Errorf("number of statements mismatched! want %d, got %d", len(want), len(got))
+		return
+	}
+	for i := range want {
+		assertEqualExpr(t, want[i], got[i])
+	}
+}
+
+func assertEqualOptions(t *testing.T, want, got map[string]any) {
+	t.Helper()
+	if len(want) != len(got) {
+		t.Errorf("number of options mismatched! want %d, got %d", len(want), len(got))
+	}
+	for k, v := range want {
+		other, ok := got[k]
+		if !ok {
+			t.Errorf("option %s not set", k)
+			continue
+		}
+		if v != other {
+			t.Errorf("value of option %s mismatched! want %s, got %s", k, v, other)
+		}
+	}
+}
+
 func TestUnlock(t *testing.T) {
 	tests := []struct {
 		Expr string
@@ -160,6 +270,15 @@ func TestUnlock(t *testing.T) {
 			Expr: "unlock mysheet",
 			Want: newUnlock(NewIdentifier("mysheet")),
 		},
+		{
+			Expr: "unlock mysheet with (password := 'secret')",
+			Want: Unlock{
+				ident: NewIdentifier("mysheet"),
+				options: map[string]any{
+					"password": "secret",
+				},
+			},
+		},
 	}
 	for _, c := range tests {
 		expr, err := parseExpr(c.Expr)
@@ -185,6 +304,16 @@ func TestLock(t *testing.T) {
 			Expr: "lock mysheet",
 			Want: newLock(NewIdentifier("mysheet")),
 		},
+		{
+			Expr: "lock mysheet with (password := 'secret', rows := 'false')",
+			Want: Lock{
+				ident: NewIdentifier("mysheet"),
+				options: map[string]any{
+					"password": "secret",
+					"rows":     "false",
+				},
+			},
+		},
 	}
 	for _, c := range tests {
 		expr, err := parseExpr(c.Expr)
@@ -257,6 +386,113 @@ func TestRename(t *testing.T) {
 	}
 }
 
+func TestDefine(t *testing.T) {
+	tests := []struct {
+		Expr string
+		Want Expr
+	}{
+		{
+			Expr: "define area := A1:C10",
+			Want: NewDefine(
+				"area",
+				NewRangeAddr(
+					NewCellAddr(layout.NewPosition(1, 1), false, false),
+					NewCellAddr(layout.NewPosition(10, 3), false, false),
+				),
+			),
+		},
+		{
+			Expr: "define top := B2",
+			Want: NewDefine("top", NewCellAddr(layout.NewPosition(2, 2), false, false)),
+		},
+	}
+	for _, c := range tests {
+		expr, err := parseExpr(c.Expr)
+		if err != nil {
+			t.Errorf("%s: fail to parse expr: %s", c.Expr, err)
+			continue
+		}
+		pr, ok := unwrapScriptExpr(expr).(Define)
+		if !ok {
+			t.Errorf("%s: expected Define statement, got %T", c.Want, expr)
+			continue
+		}
+		assertEqualExpr(t, c.Want, pr)
+	}
+	for _, str := range []string{"define area", "define := A1", "define area := 1 + 2"} {
+		if _, err := parseExpr(str); err == nil {
+			t.Errorf("%s: expected error parsing define statement", str)
+		}
+	}
+}
+
+func TestSave(t *testing.T) {
+	tests := []struct {
+		Expr string
+		Want Expr
+	}{
+		{
+			Expr: "save data",
+			Want: SaveRef{
+				expr: NewIdentifier("data"),
+			},
+		},
+		{
+			Expr: "save data to \"out.xlsx\"",
+			Want: SaveRef{
+				expr: NewIdentifier("data"),
+				file: NewLiteral("out.xlsx"),
+			},
+		},
+	}
+	for _, c := range tests {
+		expr, err := parseExpr(c.Expr)
+		if err != nil {
+			t.Errorf("%s: fail to parse expr: %s", c.Expr, err)
+			continue
+		}
+		pr, ok := unwrapScriptExpr(expr).(SaveRef)
+		if !ok {
+			t.Errorf("%s: expected SaveRef statement, got %T", c.Want, expr)
+			continue
+		}
+		assertEqualExpr(t, c.Want, pr)
+	}
+}
+
+func TestPush(t *testing.T) {
+	tests := []struct {
+		Expr string
+		Want Expr
+	}{
+		{
+			Expr: "push data",
+			Want: Push{
+				expr: NewIdentifier("data"),
+			},
+		},
+		{
+			Expr: "push data ro",
+			Want: Push{
+				expr:     NewIdentifier("data"),
+				readOnly: true,
+			},
+		},
+		{
+			Expr: "pop",
+			Want: Pop{},
+		},
+	}
+	for _, c := range tests {
+		expr, err := parseExpr(c.Expr)
+		if err != nil {
+			t.Errorf("%s: fail to parse expr: %s", c.Expr, err)
+			continue
+		}
+		assertEqualExpr(t, c.Want, unwrapScriptExpr(expr))
+	}
+}
+
 func TestInsert(t *testing.T) {
 	tests := []struct {
 		Expr string
@@ -440,28 +676,414 @@ func testRemoveValid(t *testing.T) {
 			},
 		},
 		{
-			Expr: "remove column before last from sh",
-			Want: Remove{
-				ident: NewIdentifier("sh"),
-				target: Target{
-					Kind: TargetLast,
+			Expr: "remove column before last from sh",
+			Want: Remove{
+				ident: NewIdentifier("sh"),
+				target: Target{
+					Kind: TargetLast,
+				},
+				count:  NewNumber(1),
+				Colrow: Column,
+				Anchor: AnchorBefore,
+			},
+		},
+		{
+			Expr: "remove column at 10 from sh",
+			Want: Remove{
+				ident: NewIdentifier("sh"),
+				target: Target{
+					Kind: TargetIndex,
+					Expr: NewNumber(10),
+				},
+				count:  NewNumber(1),
+				Colrow: Column,
+				Anchor: AnchorAt,
+			},
+		},
+	}
+	for _, c := range tests {
+		expr, err := parseExpr(c.Expr)
+		if err != nil {
+			t.Errorf("%s: fail to parse expr: %s", c.Expr, err)
+			continue
+		}
+		pr, ok := unwrapScriptExpr(expr).(Remove)
+		if !ok {
+			t.Errorf("%s: expected Remove statement, got %T", c.Want, expr)
+			continue
+		}
+		assertEqualExpr(t, c.Want, pr)
+	}
+}
+
+func TestSheet(t *testing.T) {
+	tests := []struct {
+		Expr string
+		Want Expr
+	}{}
+	for _, c := range tests {
+		expr, err := parseExpr(c.Expr)
+		if err != nil {
+			t.Errorf("%s: fail to parse expr: %s", c.Expr, err)
+			continue
+		}
+		pr, ok := unwrapScriptExpr(expr).(Sheet)
+		if !ok {
+			t.Errorf("%s: expected Sheet statement, got %T", c.Want, expr)
+			continue
+		}
+		assertEqualExpr(t, c.Want, pr)
+	}
+}
+
+func TestIf(t *testing.T) {
+	tests := []struct {
+		Expr string
+		Want Expr
+	}{
+		{
+			Expr: "if A1 > 1 then\nx := 1\nend",
+			Want: If{
+				cond: NewBinary(NewCellAddr(layout.NewPosition(1, 1), false, false), NewNumber(1), op.Gt),
+				csq: []Expr{
+					NewAssignment(NewIdentifier("x"), NewNumber(1)),
+				},
+			},
+		},
+		{
+			Expr: "if x then\ny := 1\nelse if z then\ny := 2\nelse\ny := 3\nend",
+			Want: If{
+				cond: NewIdentifier("x"),
+				csq: []Expr{
+					NewAssignment(NewIdentifier("y"), NewNumber(1)),
+				},
+				alt: []Expr{
+					If{
+						cond: NewIdentifier("z"),
+						csq: []Expr{
+							NewAssignment(NewIdentifier("y"), NewNumber(2)),
+						},
+						alt: []Expr{
+							NewAssignment(NewIdentifier("y"), NewNumber(3)),
+						},
+					},
+				},
+			},
+		},
+		{
+			Expr: "x := if(A1, 1, 2)",
+			Want: NewAssignment(NewIdentifier("x"), NewCall(NewIdentifier("if"), []Expr{
+				NewCellAddr(layout.NewPosition(1, 1), false, false),
+				NewNumber(1),
+				NewNumber(2),
+			})),
+		},
+	}
+	for _, c := range tests {
+		expr, err := parseExpr(c.Expr)
+		if err != nil {
+			t.Errorf("%s: fail to parse expr: %s", c.Expr, err)
+			continue
+		}
+		assertEqualExpr(t, c.Want, unwrapScriptExpr(expr))
+	}
+
+	invalid := []string{
+		"if x\ny := 1\nend",
+		"if x then\ny := 1",
+		"if x then\ny := 1\nelse\ny := 2",
+	}
+	for _, str := range invalid {
+		if _, err := parseExpr(str); err == nil {
+			t.Errorf("%s: expected error but got none", str)
+		}
+	}
+}
+
+func TestFor(t *testing.T) {
+	tests := []struct {
+		Expr string
+		Want Expr
+	}{
+		{
+			Expr: "for row in data do\nx := row\nend",
+			Want: For{
+				ident: "row",
+				iter:  NewIdentifier("data"),
+				body: []Expr{
+					NewAssignment(NewIdentifier("x"), NewIdentifier("row")),
+				},
+			},
+		},
+		{
+			Expr: "for c in A1:A10 do\nend",
+			Want: For{
+				ident: "c",
+				iter: NewRangeAddr(
+					NewCellAddr(layout.NewPosition(1, 1), false, false),
+					NewCellAddr(layout.NewPosition(10, 1), false, false),
+				),
+			},
+		},
+	}
+	for _, c := range tests {
+		expr, err := parseExpr(c.Expr)
+		if err != nil {
+			t.Errorf("%s: fail to parse expr: %s", c.Expr, err)
+			continue
+		}
+		assertEqualExpr(t, c.Want, unwrapScriptExpr(expr))
+	}
+
+	invalid := []string{
+		"for x data do\nend",
+		"for x in data\nend",
+		"for x in data do\ny := 1",
+	}
+	for _, str := range invalid {
+		if _, err := parseExpr(str); err == nil {
+			t.Errorf("%s: expected error but got none", str)
+		}
+	}
+}
+
+func TestInclude(t *testing.T) {
+	str := "include \"std/dates\"\ninclude \"lib/common.dk\" as common\nx := 1"
+	expr, err := parseExpr(str)
+	if err != nil {
+		t.Fatalf("fail to parse script: %s", err)
+	}
+	script, ok := expr.(Script)
+	if !ok {
+		t.Fatalf("script expected but got %T", expr)
+	}
+	want := []IncludeFile{
+		{file: "std/dates"},
+		{file: "lib/common.dk", alias: "common"},
+	}
+	if len(script.Includes) != len(want) {
+		t.Fatalf("includes mismatched! want %d, got %d", len(want), len(script.Includes))
+	}
+	for i := range want {
+		got, ok := script.Includes[i].(IncludeFile)
+		if !ok {
+			t.Errorf("include expected but got %T", script.Includes[i])
+			continue
+		}
+		if got != want[i] {
+			t.Errorf("include mismatched! want %s, got %s", want[i], got)
+		}
+	}
+	if len(script.Body) != 1 {
+		t.Errorf("body mismatched! want 1 statement, got %d", len(script.Body))
+	}
+
+	invalid := []string{
+		"include dates",
+		"include \"std/dates\" as",
+		"x := 1\ninclude \"std/dates\"",
+	}
+	for _, str := range invalid {
+		if _, err := parseExpr(str); err == nil {
+			t.Errorf("%s: expected error but got none", str)
+		}
+	}
+}
+
+func TestCube(t *testing.T) {
+	str := `#!cube
+cube from @active[A2:D7]
+	dimension region := A
+	dimension year := B
+	measure total := sum(D)
+	rollup region, year as summary
+	pivot total by region, year as matrix
+end`
+	expr, err := parseExpr(str)
+	if err != nil {
+		t.Fatalf("fail to parse cube: %s", err)
+	}
+	stmt, ok := unwrapScriptExpr(expr).(Cube)
+	if !ok {
+		t.Fatalf("cube expected but got %T", unwrapScriptExpr(expr))
+	}
+	dims := []CubeDimension{
+		{Name: "region", Column: 1},
+		{Name: "year", Column: 2},
+	}
+	if !slices.Equal(dims, stmt.Dimensions()) {
+		t.Errorf("dimensions mismatched! want %v, got %v", dims, stmt.Dimensions())
+	}
+	measures := []CubeMeasure{
+		{Name: "total", Func: "sum", Column: 4},
+	}
+	if !slices.Equal(measures, stmt.Measures()) {
+		t.Errorf("measures mismatched! want %v, got %v", measures, stmt.Measures())
+	}
+	outputs := []CubeOutput{
+		{Kind: CubeRollup, Dimensions: []string{"region", "year"}, Ident: "summary"},
+		{Kind: CubePivot, Dimensions: []string{"region", "year"}, Measure: "total", Ident: "matrix"},
+	}
+	if got := stmt.Outputs(); len(got) != len(outputs) {
+		t.Errorf("outputs mismatched! want %d, got %d", len(outputs), len(got))
+	} else {
+		for i := range outputs {
+			w, g := outputs[i], got[i]
+			if w.Kind != g.Kind || w.Measure != g.Measure || w.Ident != g.Ident || !slices.Equal(w.Dimensions, g.Dimensions) {
+				t.Errorf("output mismatched! want %v, got %v", w, g)
+			}
+		}
+	}
+
+	invalid := []string{
+		"cube from data\ndimension region := A\nend",
+		"#!cube\ncube from data\nend",
+		"#!cube\ncube data\ndimension region := A\nend",
+		"#!cube\ncube from data\ndimension region := A1\nend",
+		"#!cube\ncube from data\ndimension region := A\nmeasure total := sum D\nend",
+		"#!cube\ncube from data\ndimension region := A\npivot total by region as matrix\nend",
+		"#!cube\ncube from data\ndimension region := A\nrollup region\nend",
+		"#!cube\ncube from data\ndimension region := A\nslice region\nend",
+		"#!cube\ncube from data\ndimension region := A",
+	}
+	for _, str := range invalid {
+		if _, err := parseExpr(str); err == nil {
+			t.Errorf("%s: expected error but got none", str)
+		}
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	tests := []struct {
+		Expr  string
+		Keys  []GroupKey
+		Aggrs []GroupAggr
+	}{
+		{
+			Expr: "group data by A",
+			Keys: []GroupKey{
+				{Column: 1, Name: "A"},
+			},
+		},
+		{
+			Expr: "x := group data by A as lang, C aggregate sum(C) as total, count() as n, avg(D)",
+			Keys: []GroupKey{
+				{Column: 1, Name: "lang"},
+				{Column: 3, Name: "C"},
+			},
+			Aggrs: []GroupAggr{
+				{Func: "sum", Column: 3, Name: "total"},
+				{Func: "count", Name: "n"},
+				{Func: "avg", Column: 4, Name: "avg(D)"},
+			},
+		},
+	}
+	for _, c := range tests {
+		expr, err := parseExpr(c.Expr)
+		if err != nil {
+			t.Errorf("%s: fail to parse expr: %s", c.Expr, err)
+			continue
+		}
+		expr = unwrapScriptExpr(expr)
+		if a, ok := expr.(Assignment); ok {
+			expr = a.Expr()
+		}
+		stmt, ok := expr.(GroupBy)
+		if !ok {
+			t.Errorf("%s: group expected but got %T", c.Expr, expr)
+			continue
+		}
+		if !slices.Equal(c.Keys, stmt.Keys()) {
+			t.Errorf("%s: keys mismatched! want %v, got %v", c.Expr, c.Keys, stmt.Keys())
+		}
+		if !slices.Equal(c.Aggrs, stmt.Aggregates()) {
+			t.Errorf("%s: aggregates mismatched! want %v, got %v", c.Expr, c.Aggrs, stmt.Aggregates())
+		}
+	}
+
+	invalid := []string{
+		"group data A",
+		"group data by",
+		"group data by A1",
+		"group data by A aggregate sum C",
+		"group data by A aggregate sum(C) as",
+	}
+	for _, str := range invalid {
+		if _, err := parseExpr(str); err == nil {
+			t.Errorf("%s: expected error but got none", str)
+		}
+	}
+}
+
+func TestDef(t *testing.T) {
+	tests := []struct {
+		Expr string
+		Want Expr
+	}{
+		{
+			Expr: "def twice(x)\nx * 2\nend",
+			Want: NewMacro("twice", []Expr{NewIdentifier("x")}, []Expr{
+				NewBinary(NewIdentifier("x"), NewNumber(2), op.Mul),
+			}),
+		},
+		{
+			Expr: "def total(a, b)\nc := a + b\nc\nend",
+			Want: NewMacro("total", []Expr{NewIdentifier("a"), NewIdentifier("b")}, []Expr{
+				NewAssignment(NewIdentifier("c"), NewBinary(NewIdentifier("a"), NewIdentifier("b"), op.Add)),
+				NewIdentifier("c"),
+			}),
+		},
+		{
+			Expr: "def answer()\n42\nend",
+			Want: NewMacro("answer", nil, []Expr{
+				NewNumber(42),
+			}),
+		},
+	}
+	for _, c := range tests {
+		expr, err := parseExpr(c.Expr)
+		if err != nil {
+			t.Errorf("%s: fail to parse expr: %s", c.Expr, err)
+			continue
+		}
+		assertEqualExpr(t, c.Want, unwrapScriptExpr(expr))
+	}
+
+	invalid := []string{
+		"def twice x\nend",
+		"def twice(x,)\nend",
+		"def twice(x) x * 2 end",
+		"def twice(x)\nx * 2",
+	}
+	for _, str := range invalid {
+		if _, err := parseExpr(str); err == nil {
+			t.Errorf("%s: expected error but got none", str)
+		}
+	}
+}
+
+func TestWhile(t *testing.T) {
+	tests := []struct {
+		Expr string
+		Want Expr
+	}{
+		{
+			Expr: "while x < 10 do\nx := x + 1\nend",
+			Want: While{
+				cond: NewBinary(NewIdentifier("x"), NewNumber(10), op.Lt),
+				body: []Expr{
+					NewAssignment(NewIdentifier("x"), NewBinary(NewIdentifier("x"), NewNumber(1), op.Add)),
 				},
-				count:  NewNumber(1),
-				Colrow: Column,
-				Anchor: AnchorBefore,
 			},
 		},
 		{
-			Expr: "remove column at 10 from sh",
-			Want: Remove{
-				ident: NewIdentifier("sh"),
-				target: Target{
-					Kind: TargetIndex,
-					Expr: NewNumber(10),
+			Expr: "while x do\nbreak\ncontinue\nend",
+			Want: While{
+				cond: NewIdentifier("x"),
+				body: []Expr{
+					Break{},
+					Continue{},
 				},
-				count:  NewNumber(1),
-				Colrow: Column,
-				Anchor: AnchorAt,
 			},
 		},
 	}
@@ -471,32 +1093,67 @@ func testRemoveValid(t *testing.T) {
 			t.Errorf("%s: fail to parse expr: %s", c.Expr, err)
 			continue
 		}
-		pr, ok := unwrapScriptExpr(expr).(Remove)
-		if !ok {
-			t.Errorf("%s: expected Remove statement, got %T", c.Want, expr)
-			continue
+		assertEqualExpr(t, c.Want, unwrapScriptExpr(expr))
+	}
+
+	invalid := []string{
+		"while x\nend",
+		"while x do\ny := 1",
+	}
+	for _, str := range invalid {
+		if _, err := parseExpr(str); err == nil {
+			t.Errorf("%s: expected error but got none", str)
 		}
-		assertEqualExpr(t, c.Want, pr)
 	}
 }
 
-func TestSheet(t *testing.T) {
+func TestWith(t *testing.T) {
 	tests := []struct {
 		Expr string
 		Want Expr
-	}{}
+	}{
+		{
+			Expr: "with sheet summary into data\nA1 := 1\nend",
+			Want: WithSheet{
+				sheet: Sheet{
+					ident: NewIdentifier("summary"),
+					name:  NewIdentifier("summary"),
+					file:  NewIdentifier("data"),
+				},
+				body: []Expr{
+					NewAssignment(NewCellAddr(layout.NewPosition(1, 1), false, false), NewNumber(1)),
+				},
+			},
+		},
+		{
+			Expr: "with filter popular\nB > 10\n\nD = \"Go\"\nend",
+			Want: WithFilter{
+				ident: "popular",
+				body: []Expr{
+					NewBinary(NewColumnAddr(layout.NewPosition(0, 2), false), NewNumber(10), op.Gt),
+					NewBinary(NewColumnAddr(layout.NewPosition(0, 4), false), NewLiteral("Go"), op.Eq),
+				},
+			},
+		},
+	}
 	for _, c := range tests {
 		expr, err := parseExpr(c.Expr)
 		if err != nil {
 			t.Errorf("%s: fail to parse expr: %s", c.Expr, err)
 			continue
 		}
-		pr, ok := unwrapScriptExpr(expr).(Sheet)
-		if !ok {
-			t.Errorf("%s: expected Sheet statement, got %T", c.Want, expr)
-			continue
+		assertEqualExpr(t, c.Want, unwrapScriptExpr(expr))
+	}
+
+	invalid := []string{
+		"with sheet summary\nA1 := 1",
+		"with filter popular\nend",
+		"with chart sales\nend",
+	}
+	for _, str := range invalid {
+		if _, err := parseExpr(str); err == nil {
+			t.Errorf("%s: expected error but got none", str)
 		}
-		assertEqualExpr(t, c.Want, pr)
 	}
 }
 
@@ -589,11 +1246,30 @@ func TestPrintStmt(t *testing.T) {
 				NewLiteral("!"),
 			}),
 		},
+		{
+			Expr: "print \"total: ${sum(A1:A3):#,##0.00} on ${today():YYYY-0MM-0DD}\"",
+			Value: NewTemplate([]Expr{
+				NewLiteral("total: "),
+				NewFormatted(NewCall(NewIdentifier("sum"), []Expr{
+					NewRangeAddr(
+						NewCellAddr(layout.NewPosition(1, 1), false, false),
+						NewCellAddr(layout.NewPosition(3, 1), false, false),
+					),
+				}), "#,##0.00"),
+				NewLiteral(" on "),
+				NewFormatted(NewCall(NewIdentifier("today"), nil), "YYYY-0MM-0DD"),
+			}),
+		},
 		{
 			Expr:    "print 3.14 '###.###'",
 			Value:   NewNumber(3.14),
 			Pattern: "###.###",
 		},
+		{
+			Expr:    `print x '0.00;"neg";@'`,
+			Value:   NewIdentifier("x"),
+			Pattern: `0.00;"neg";@`,
+		},
 		{
 			Expr:  "print x * y",
 			Value: NewBinary(NewIdentifier("x"), NewIdentifier("y"), op.Mul),
@@ -853,6 +1529,93 @@ func TestExpr(t *testing.T) {
 				),
 			),
 		},
+		{
+			Expr: "distinct(view, A;C)",
+			Want: NewCall(
+				NewIdentifier("distinct"),
+				[]Expr{
+					NewIdentifier("view"),
+					NewIntervalList([]Expr{
+						NewColumnAddr(layout.NewPosition(0, 1), false),
+						NewColumnAddr(layout.NewPosition(0, 3), false),
+					}),
+				},
+			),
+		},
+		{
+			Expr: "d'2024-01-31' + 7",
+			Want: NewBinary(
+				NewDate(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)),
+				NewNumber(7),
+				op.Add,
+			),
+		},
+		{
+			Expr: "d'2024-01-31T10:30:00' - d'2024-01-01'",
+			Want: NewBinary(
+				NewDate(time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC)),
+				NewDate(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+				op.Sub,
+			),
+		},
+	}
+	for _, c := range tests {
+		expr, err := parseExpr(c.Expr)
+		if err != nil {
+			t.Errorf("%s: fail to parse expr: %s", c.Expr, err)
+			continue
+		}
+		assertEqualExpr(t, c.Want, unwrapScriptExpr(expr))
+	}
+
+	invalid := []string{
+		"d'2024-02-31'",
+		"d'yesterday'",
+		"d'2024-01-31",
+	}
+	for _, str := range invalid {
+		_, err := parseExpr(str)
+		if err == nil {
+			t.Errorf("%s: expected error parsing invalid date", str)
+		}
+	}
+}
+
+func TestPipe(t *testing.T) {
+	tests := []struct {
+		Expr string
+		Want Expr
+	}{
+		{
+			Expr: "data |> head(10)",
+			Want: NewCall(NewIdentifier("head"), []Expr{
+				NewIdentifier("data"),
+				NewNumber(10),
+			}),
+		},
+		{
+			Expr: "data |> transpose",
+			Want: NewCall(NewIdentifier("transpose"), []Expr{
+				NewIdentifier("data"),
+			}),
+		},
+		{
+			Expr: "data |> filter(star > 10) |> head(20)",
+			Want: NewCall(NewIdentifier("head"), []Expr{
+				NewCall(NewIdentifier("filter"), []Expr{
+					NewIdentifier("data"),
+					NewBinary(NewIdentifier("star"), NewNumber(10), op.Gt),
+				}),
+				NewNumber(20),
+			}),
+		},
+		{
+			Expr: "top := data |> head(3)",
+			Want: NewAssignment(NewIdentifier("top"), NewCall(NewIdentifier("head"), []Expr{
+				NewIdentifier("data"),
+				NewNumber(3),
+			})),
+		},
 	}
 	for _, c := range tests {
 		expr, err := parseExpr(c.Expr)
@@ -862,6 +1625,17 @@ func TestExpr(t *testing.T) {
 		}
 		assertEqualExpr(t, c.Want, unwrapScriptExpr(expr))
 	}
+
+	invalid := []string{
+		"data |> 10",
+		"data |>",
+		"data |> x + 1",
+	}
+	for _, str := range invalid {
+		if _, err := parseExpr(str); err == nil {
+			t.Errorf("%s: expected error parsing pipe", str)
+		}
+	}
 }
 
 func TestSlices(t *testing.T) {
@@ -986,6 +1760,48 @@ func TestSlices(t *testing.T) {
 				}),
 			),
 		},
+		{
+			Expr: "view9[10:200, A:C]",
+			Want: NewSlice(
+				NewIdentifier("view9"),
+				NewRowWindow(
+					NewNumber(10),
+					NewNumber(200),
+					NewIntervalList([]Expr{
+						NewInterval(
+							NewColumnAddr(layout.NewPosition(0, 1), false),
+							NewColumnAddr(layout.NewPosition(0, 3), false),
+							nil,
+						),
+					}),
+				),
+			),
+		},
+		{
+			Expr: "view10[:50, B;D]",
+			Want: NewSlice(
+				NewIdentifier("view10"),
+				NewRowWindow(
+					nil,
+					NewNumber(50),
+					NewIntervalList([]Expr{
+						NewColumnAddr(layout.NewPosition(0, 2), false),
+						NewColumnAddr(layout.NewPosition(0, 4), false),
+					}),
+				),
+			),
+		},
+		{
+			Expr: "view11[5, C]",
+			Want: NewSlice(
+				NewIdentifier("view11"),
+				NewRowWindow(
+					NewNumber(5),
+					NewNumber(5),
+					NewColumnAddr(layout.NewPosition(0, 3), false),
+				),
+			),
+		},
 	}
 	for _, c := range tests {
 		expr, err := parseExpr(c.Expr)
@@ -1002,6 +1818,50 @@ func TestSlices(t *testing.T) {
 	}
 }
 
+func TestResolveNames(t *testing.T) {
+	lookup := func(name string) (int64, bool) {
+		switch name {
+		case "name":
+			return 1, true
+		case "price":
+			return 3, true
+		default:
+			return 0, false
+		}
+	}
+	tests := []struct {
+		Expr Expr
+		Want Expr
+	}{
+		{
+			Expr: NewBinary(NewLiteral("price"), NewNumber(100), op.Gt),
+			Want: NewBinary(NewColumnAddr(layout.NewPosition(0, 3), false), NewNumber(100), op.Gt),
+		},
+		{
+			Expr: NewBinary(NewIdentifier("name"), NewLiteral("foo"), op.Eq),
+			Want: NewBinary(NewColumnAddr(layout.NewPosition(0, 1), false), NewLiteral("foo"), op.Eq),
+		},
+		{
+			Expr: NewIntervalList([]Expr{
+				NewIdentifier("name"),
+				NewIdentifier("price"),
+			}),
+			Want: NewIntervalList([]Expr{
+				NewColumnAddr(layout.NewPosition(0, 1), false),
+				NewColumnAddr(layout.NewPosition(0, 3), false),
+			}),
+		},
+		{
+			Expr: NewIdentifier("other"),
+			Want: NewIdentifier("other"),
+		},
+	}
+	for _, c := range tests {
+		got := ResolveNames(c.Expr, lookup)
+		assertEqualExpr(t, c.Want, got)
+	}
+}
+
 func TestScript(t *testing.T) {
 	tests := []struct {
 		Expr string
@@ -1075,6 +1935,7 @@ func assertEqualExpr(t *testing.T, want, got Expr) {
 			return
 		}
 		assertEqualExpr(t, w.ident, g.ident)
+		assertEqualOptions(t, w.options, g.options)
 	case Lock:
 		g, ok := got.(Lock)
 		if !ok {
@@ -1082,6 +1943,7 @@ func assertEqualExpr(t *testing.T, want, got Expr) {
 			return
 		}
 		assertEqualExpr(t, w.ident, g.ident)
+		assertEqualOptions(t, w.options, g.options)
 	case Rename:
 		g, ok := got.(Rename)
 		if !ok {
@@ -1090,6 +1952,44 @@ func assertEqualExpr(t *testing.T, want, got Expr) {
 		}
 		assertEqualExpr(t, w.ident, g.ident)
 		assertEqualExpr(t, w.name, g.name)
+	case SaveRef:
+		g, ok := got.(SaveRef)
+		if !ok {
+			t.Errorf("SaveRef statement expected but got %T", got)
+			return
+		}
+		assertEqualExpr(t, w.expr, g.expr)
+		if w.file == nil || g.file == nil {
+			if w.file != g.file {
+				t.Errorf("target mismatched! want %v, got %v", w.file, g.file)
+			}
+			return
+		}
+		assertEqualExpr(t, w.file, g.file)
+	case Push:
+		g, ok := got.(Push)
+		if !ok {
+			t.Errorf("Push statement expected but got %T", got)
+			return
+		}
+		if w.readOnly != g.readOnly {
+			t.Errorf("readonly mismatched! want %t, got %t", w.readOnly, g.readOnly)
+		}
+		assertEqualExpr(t, w.expr, g.expr)
+	case Pop:
+		if _, ok := got.(Pop); !ok {
+			t.Errorf("Pop statement expected but got %T", got)
+		}
+	case Define:
+		g, ok := got.(Define)
+		if !ok {
+			t.Errorf("Define statement expected but got %T", got)
+			return
+		}
+		if w.ident != g.ident {
+			t.Errorf("name mismatched! want %s, got %s", w.ident, g.ident)
+		}
+		assertEqualExpr(t, w.expr, g.expr)
 	case Sheet:
 		g, ok := got.(Sheet)
 		if !ok {
@@ -1097,6 +1997,81 @@ func assertEqualExpr(t *testing.T, want, got Expr) {
 			return
 		}
 		_ = g
+	case WithSheet:
+		g, ok := got.(WithSheet)
+		if !ok {
+			t.Errorf("WithSheet statement expected but got %T", got)
+			return
+		}
+		assertEqualExpr(t, w.sheet.ident, g.sheet.ident)
+		assertEqualExpr(t, w.sheet.name, g.sheet.name)
+		if w.sheet.file == nil || g.sheet.file == nil {
+			if w.sheet.file != g.sheet.file {
+				t.Errorf("file mismatched! want %v, got %v", w.sheet.file, g.sheet.file)
+			}
+		} else {
+			assertEqualExpr(t, w.sheet.file, g.sheet.file)
+		}
+		assertEqualBody(t, w.body, g.body)
+	case Macro:
+		g, ok := got.(Macro)
+		if !ok {
+			t.Errorf("Macro statement expected but got %T", got)
+			return
+		}
+		if w.name != g.name {
+			t.Errorf("name mismatched! want %s, got %s", w.name, g.name)
+		}
+		if !slices.Equal(w.Params(), g.Params()) {
+			t.Errorf("params mismatched! want %v, got %v", w.Params(), g.Params())
+		}
+		assertEqualBody(t, w.body, g.body)
+	case While:
+		g, ok := got.(While)
+		if !ok {
+			t.Errorf("While statement expected but got %T", got)
+			return
+		}
+		assertEqualExpr(t, w.cond, g.cond)
+		assertEqualBody(t, w.body, g.body)
+	case Break:
+		if _, ok := got.(Break); !ok {
+			t.Errorf("Break statement expected but got %T", got)
+		}
+	case Continue:
+		if _, ok := got.(Continue); !ok {
+			t.Errorf("Continue statement expected but got %T", got)
+		}
+	case For:
+		g, ok := got.(For)
+		if !ok {
+			t.Errorf("For statement expected but got %T", got)
+			return
+		}
+		if w.ident != g.ident {
+			t.Errorf("identifier mismatched! want %s, got %s", w.ident, g.ident)
+		}
+		assertEqualExpr(t, w.iter, g.iter)
+		assertEqualBody(t, w.body, g.body)
+	case If:
+		g, ok := got.(If)
+		if !ok {
+			t.Errorf("If statement expected but got %T", got)
+			return
+		}
+		assertEqualExpr(t, w.cond, g.cond)
+		assertEqualBody(t, w.csq, g.csq)
+		assertEqualBody(t, w.alt, g.alt)
+	case WithFilter:
+		g, ok := got.(WithFilter)
+		if !ok {
+			t.Errorf("WithFilter statement expected but got %T", got)
+			return
+		}
+		if w.ident != g.ident {
+			t.Errorf("identifier mismatched! want %s, got %s", w.ident, g.ident)
+		}
+		assertEqualBody(t, w.body, g.body)
 	case Remove:
 		g, ok := got.(Remove)
 		if !ok {
@@ -1269,6 +2244,15 @@ func assertEqualExpr(t *testing.T, want, got Expr) {
 		if w.value != g.value {
 			t.Errorf("literal value mismatched! want %s, got %s", w.value, g.value)
 		}
+	case Date:
+		g, ok := got.(Date)
+		if !ok {
+			t.Errorf("date expected but got %T", got)
+			return
+		}
+		if !w.value.Equal(g.value) {
+			t.Errorf("date value mismatched! want %s, got %s", w.value, g.value)
+		}
 	case Template:
 		g, ok := got.(Template)
 		if !ok {
@@ -1282,6 +2266,16 @@ func assertEqualExpr(t *testing.T, want, got Expr) {
 		for i := range w.expr {
 			assertEqualExpr(t, w.expr[i], g.expr[i])
 		}
+	case Formatted:
+		g, ok := got.(Formatted)
+		if !ok {
+			t.Errorf("formatted expected but got %T", got)
+			return
+		}
+		if w.pattern != g.pattern {
+			t.Errorf("pattern mismatched! want %s, got %s", w.pattern, g.pattern)
+		}
+		assertEqualExpr(t, w.expr, g.expr)
 	case Access:
 		g, ok := got.(Access)
 		if !ok {
@@ -1299,6 +2293,33 @@ func assertEqualExpr(t *testing.T, want, got Expr) {
 		}
 		assertEqualExpr(t, w.expr, g.expr)
 		assertEqualExpr(t, w.addr, g.addr)
+	case SheetSpan:
+		g, ok := got.(SheetSpan)
+		if !ok {
+			t.Errorf("sheet span expected but got %T", got)
+			return
+		}
+		if w.first != g.first || w.last != g.last {
+			t.Errorf("sheet span mismatched! want %s, got %s", w, g)
+		}
+	case ExternalSheet:
+		g, ok := got.(ExternalSheet)
+		if !ok {
+			t.Errorf("external sheet expected but got %T", got)
+			return
+		}
+		if w.book != g.book || w.sheet != g.sheet {
+			t.Errorf("external sheet mismatched! want %s, got %s", w, g)
+		}
+	case TableRef:
+		g, ok := got.(TableRef)
+		if !ok {
+			t.Errorf("table reference expected but got %T", got)
+			return
+		}
+		if w.String() != g.String() {
+			t.Errorf("table reference mismatched! want %s, got %s", w, g)
+		}
 	case Identifier:
 		g, ok := got.(Identifier)
 		if !ok {
@@ -1328,6 +2349,19 @@ func assertEqualExpr(t *testing.T, want, got Expr) {
 		for i := range w.items {
 			assertEqualExpr(t, w.items[i], g.items[i])
 		}
+	case RowWindow:
+		g, ok := got.(RowWindow)
+		if !ok {
+			t.Errorf("row window expected but got %T", got)
+			return
+		}
+		if w.from != nil || g.from != nil {
+			assertEqualExpr(t, w.from, g.from)
+		}
+		if w.to != nil || g.to != nil {
+			assertEqualExpr(t, w.to, g.to)
+		}
+		assertEqualExpr(t, w.columns, g.columns)
 	case IntervalExpr:
 		g, ok := got.(IntervalExpr)
 		if !ok {
@@ -48,10 +48,16 @@ func (t Token) String() string {
 		str = "number"
 	case op.Literal:
 		str = "literal"
+	case op.Date:
+		str = "date"
+	case op.TableRef:
+		str = "table"
 	case op.Comment:
 		str = "comment"
 	case op.Arrow:
 		return "<arrow>"
+	case op.Pipe:
+		return "<pipe>"
 	case op.Assign:
 		return "<assignment>"
 	case op.Add:
@@ -70,6 +76,8 @@ func (t Token) String() string {
 		return "<concat>"
 	case op.Union:
 		return "<union>"
+	case op.Intersect:
+		return "<intersect>"
 	case op.Eq:
 		return "<equal>"
 	case op.Ne:
@@ -6,19 +6,36 @@ type Visitor interface {
 	VisitIncludeFile(IncludeFile) error
 	VisitImportFile(ImportFile) error
 	VisitExportFile(ExportFile) error
+	VisitSaveRef(SaveRef) error
 	VisitPrintRef(PrintRef) error
+	VisitNotify(Notify) error
 	VisitUseRef(UseRef) error
+	VisitPush(Push) error
+	VisitPop(Pop) error
 	VisitLock(Lock) error
 	VisitUnlock(Unlock) error
 	VisitRename(Rename) error
+	VisitDefine(Define) error
 	VisitInsert(Insert) error
 	VisitRemove(Remove) error
 	VisitSheet(Sheet) error
+	VisitParallel(Parallel) error
+	VisitWithSheet(WithSheet) error
+	VisitWithFilter(WithFilter) error
+	VisitIf(If) error
+	VisitFor(For) error
+	VisitMacro(Macro) error
+	VisitCube(Cube) error
+	VisitGroupBy(GroupBy) error
+	VisitWhile(While) error
+	VisitBreak(Break) error
+	VisitContinue(Continue) error
 
 	VisitIdentifier(Identifier) error
 	VisitAliasRef(AliasRef) error
 	VisitLiteral(Literal) error
 	VisitNumber(Number) error
+	VisitDate(Date) error
 	VisitArray(Array) error
 	VisitCellAddr(CellAddr) error
 	VisitColumnAddr(ColumnAddr) error
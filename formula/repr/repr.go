@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/midbel/dockit/formula/op"
@@ -178,6 +179,16 @@ func (v astVisitor) VisitRename(expr parse.Rename) error {
 	return nil
 }
 
+func (v astVisitor) VisitDefine(expr parse.Define) error {
+	node := v.newStmt("define", expr)
+	node.Params = []Param{
+		createParam("name", expr.Ident()),
+		createParam("target", expr.Expr().String()),
+	}
+	v.pushNode(node)
+	return nil
+}
+
 func (v astVisitor) VisitInsert(expr parse.Insert) error {
 	node := v.newStmt("insert", expr)
 	c := expr.Count()
@@ -260,12 +271,188 @@ func (v astVisitor) VisitSheet(expr parse.Sheet) error {
 	return nil
 }
 
+func (v astVisitor) VisitParallel(expr parse.Parallel) error {
+	node := v.newStmt("parallel", expr)
+	v.stack.Push(node)
+	for _, e := range expr.Body() {
+		if err := v.visitExpr(e); err != nil {
+			return err
+		}
+	}
+	v.stack.Pop()
+	v.pushNode(node)
+	return nil
+}
+
+func (v astVisitor) VisitWithSheet(expr parse.WithSheet) error {
+	node := v.newStmt("with-sheet", expr)
+	v.stack.Push(node)
+	for _, e := range expr.Body() {
+		if err := v.visitExpr(e); err != nil {
+			return err
+		}
+	}
+	v.stack.Pop()
+	v.pushNode(node)
+	return nil
+}
+
+func (v astVisitor) VisitWithFilter(expr parse.WithFilter) error {
+	node := v.newStmt("with-filter", expr)
+	node.Params = []Param{
+		createParam("identifier", expr.Ident()),
+	}
+	v.stack.Push(node)
+	for _, e := range expr.Body() {
+		if err := v.visitExpr(e); err != nil {
+			return err
+		}
+	}
+	v.stack.Pop()
+	v.pushNode(node)
+	return nil
+}
+
+func (v astVisitor) VisitIf(expr parse.If) error {
+	node := v.newStmt("if", expr)
+	node.Params = []Param{
+		createParam("condition", expr.Cond().String()),
+	}
+	v.stack.Push(node)
+	for _, e := range slices.Concat(expr.Body(), expr.Else()) {
+		if err := v.visitExpr(e); err != nil {
+			return err
+		}
+	}
+	v.stack.Pop()
+	v.pushNode(node)
+	return nil
+}
+
+func (v astVisitor) VisitFor(expr parse.For) error {
+	node := v.newStmt("for", expr)
+	node.Params = []Param{
+		createParam("identifier", expr.Ident()),
+		createParam("iter", expr.Iter().String()),
+	}
+	v.stack.Push(node)
+	for _, e := range expr.Body() {
+		if err := v.visitExpr(e); err != nil {
+			return err
+		}
+	}
+	v.stack.Pop()
+	v.pushNode(node)
+	return nil
+}
+
+func (v astVisitor) VisitMacro(expr parse.Macro) error {
+	node := v.newStmt("def", expr)
+	node.Params = []Param{
+		createParam("name", expr.Name()),
+		createParam("params", strings.Join(expr.Params(), ", ")),
+	}
+	v.stack.Push(node)
+	for _, e := range expr.Body() {
+		if err := v.visitExpr(e); err != nil {
+			return err
+		}
+	}
+	v.stack.Pop()
+	v.pushNode(node)
+	return nil
+}
+
+func (v astVisitor) VisitCube(expr parse.Cube) error {
+	node := v.newStmt("cube", expr)
+	node.Params = []Param{
+		createParam("source", expr.Source().String()),
+	}
+	for _, d := range expr.Dimensions() {
+		node.Params = append(node.Params, createParam("dimension", d.Name))
+	}
+	for _, m := range expr.Measures() {
+		node.Params = append(node.Params, createParam("measure", fmt.Sprintf("%s(%s)", m.Func, m.Name)))
+	}
+	for _, o := range expr.Outputs() {
+		kind := "rollup"
+		if o.Kind == parse.CubePivot {
+			kind = "pivot"
+		}
+		node.Params = append(node.Params, createParam(kind, o.Ident))
+	}
+	v.pushNode(node)
+	return nil
+}
+
+func (v astVisitor) VisitGroupBy(expr parse.GroupBy) error {
+	node := v.newStmt("group", expr)
+	node.Params = []Param{
+		createParam("view", expr.View().String()),
+	}
+	for _, k := range expr.Keys() {
+		node.Params = append(node.Params, createParam("key", k.Name))
+	}
+	for _, a := range expr.Aggregates() {
+		node.Params = append(node.Params, createParam("aggregate", a.Name))
+	}
+	v.pushNode(node)
+	return nil
+}
+
+func (v astVisitor) VisitWhile(expr parse.While) error {
+	node := v.newStmt("while", expr)
+	node.Params = []Param{
+		createParam("condition", expr.Cond().String()),
+	}
+	v.stack.Push(node)
+	for _, e := range expr.Body() {
+		if err := v.visitExpr(e); err != nil {
+			return err
+		}
+	}
+	v.stack.Pop()
+	v.pushNode(node)
+	return nil
+}
+
+func (v astVisitor) VisitBreak(expr parse.Break) error {
+	v.pushNode(v.newStmt("break", expr))
+	return nil
+}
+
+func (v astVisitor) VisitContinue(expr parse.Continue) error {
+	v.pushNode(v.newStmt("continue", expr))
+	return nil
+}
+
 func (v astVisitor) VisitExportFile(expr parse.ExportFile) error {
 	node := v.newStmt("export", expr)
 	v.pushNode(node)
 	return nil
 }
 
+func (v astVisitor) VisitSaveRef(expr parse.SaveRef) error {
+	node := v.newStmt("save", expr)
+	if file := expr.File(); file != nil {
+		node.Params = []Param{
+			createParam("file", file.String()),
+		}
+	}
+	v.pushNode(node)
+	return nil
+}
+
+func (v astVisitor) VisitNotify(expr parse.Notify) error {
+	node := v.newStmt("notify", expr)
+	node.Params = []Param{
+		createParam("kind", expr.Kind()),
+		createParam("summary", expr.Summary()),
+	}
+	v.pushNode(node)
+	return nil
+}
+
 func (v astVisitor) VisitPrintRef(expr parse.PrintRef) error {
 	node := v.newStmt("print", expr)
 	v.stack.Push(node)
@@ -288,6 +475,21 @@ func (v astVisitor) VisitUseRef(expr parse.UseRef) error {
 	return nil
 }
 
+func (v astVisitor) VisitPush(expr parse.Push) error {
+	node := v.newStmt("push", expr)
+	node.Params = []Param{
+		createParam("target", expr.Expr().String()),
+		createParam("readonly", expr.ReadOnly()),
+	}
+	v.pushNode(node)
+	return nil
+}
+
+func (v astVisitor) VisitPop(expr parse.Pop) error {
+	v.pushNode(v.newStmt("pop", expr))
+	return nil
+}
+
 func (v astVisitor) VisitIdentifier(expr parse.Identifier) error {
 	node := v.newValue("identifier", expr)
 	node.Params = []Param{
@@ -325,6 +527,16 @@ func (v astVisitor) VisitNumber(expr parse.Number) error {
 	return nil
 }
 
+func (v astVisitor) VisitDate(expr parse.Date) error {
+	node := v.newValue("date", expr)
+	node.Value = expr.Time()
+	node.Params = []Param{
+		createParam("value", expr.Time()),
+	}
+	v.pushNode(node)
+	return nil
+}
+
 func (v astVisitor) VisitColumnAddr(expr parse.ColumnAddr) error {
 	node := v.newValue("address", expr)
 	node.Value = expr.String()
@@ -366,6 +578,9 @@ func (v astVisitor) VisitTemplate(expr parse.Template) error {
 	node := v.newValue("template", expr)
 	v.stack.Push(node)
 	for _, e := range expr.Parts() {
+		if f, ok := e.(parse.Formatted); ok {
+			e = f.Expr()
+		}
 		if err := v.visitExpr(e); err != nil {
 			return err
 		}
@@ -56,6 +56,15 @@ func (v flagValue) Get(name string) value.Value {
 	}
 }
 
+// NewArgsValue gives the arguments of a script as an array of a single line.
+func NewArgsValue(args []string) value.Value {
+	row := make([]value.Value, 0, len(args))
+	for _, a := range args {
+		row = append(row, value.Text(a))
+	}
+	return value.NewArray([][]value.Value{row})
+}
+
 type envValue struct{}
 
 func NewEnvValue() value.Value {
@@ -10,6 +10,9 @@ import (
 type File struct {
 	file grid.File
 	ro   bool
+	// header is true when the first line of the sheets gives the names of
+	// their columns.
+	header bool
 }
 
 func NewFileValue(file grid.File, readonly bool) value.Value {
@@ -100,6 +103,12 @@ func (c *File) Unlock() error {
 	return nil
 }
 
+// UseHeader makes the views of the sheets of the file use their first line as
+// the names of their columns.
+func (c *File) UseHeader() {
+	c.header = true
+}
+
 func (c *File) Active() (value.Value, error) {
 	return c.Sheet("")
 }
@@ -120,10 +129,17 @@ func (c *File) Sheet(ident string) (value.Value, error) {
 	v := newView(sh, grid.FileContext(c.file), c.ro)
 	if v, ok := v.(*View); ok {
 		v.setFile(c)
+		if c.header {
+			return v.WithHeader(), nil
+		}
 	}
 	return v, nil
 }
 
+func (c *File) ReadOnly() bool {
+	return c.ro
+}
+
 func (c *File) File() grid.File {
 	return c.file
 }
@@ -21,3 +21,36 @@ func (p ExprPredicate) Test(ctx value.Context) bool {
 	val := p.expr.Eval(ctx)
 	return value.True(val)
 }
+
+// Filter is a predicate made of other predicates. Rows are kept by the filter
+// when all its predicates are true.
+type Filter struct {
+	predicates []value.Predicate
+}
+
+func NewFilter(list ...value.Predicate) *Filter {
+	return &Filter{
+		predicates: list,
+	}
+}
+
+func (*Filter) Type() string {
+	return "filter"
+}
+
+func (*Filter) Kind() value.ValueKind {
+	return value.KindFunction
+}
+
+func (f *Filter) String() string {
+	return "filter"
+}
+
+func (f *Filter) Test(ctx value.Context) bool {
+	for _, p := range f.predicates {
+		if !p.Test(ctx) {
+			return false
+		}
+	}
+	return len(f.predicates) > 0
+}
@@ -50,7 +50,7 @@ func getBroadcastMode(target *layout.Range, val value.ArrayValue) (broadcastMode
 
 func reLock(src, target any) {
 	is, ok := src.(interface{ IsLock() bool })
-	if !ok && !is.IsLock() {
+	if !ok || !is.IsLock() {
 		return
 	}
 	k, ok := target.(interface{ Lock() })
@@ -3,6 +3,8 @@ package runtime
 import (
 	"errors"
 	"fmt"
+	"iter"
+	"strings"
 
 	"github.com/midbel/dockit/formula/parse"
 	"github.com/midbel/dockit/grid"
@@ -15,6 +17,9 @@ type View struct {
 	ro   bool
 	file *File
 	ctx  value.Context
+	// names of the columns given by the header of the view. They are kept by
+	// the views derived from the view.
+	names []string
 }
 
 func NewViewValue(view grid.View) value.Value {
@@ -36,6 +41,48 @@ func (v *View) File() *File {
 	return v.file
 }
 
+// WithHeader gives a view using the first line of the view as the names of its
+// columns.
+func (v *View) WithHeader() *View {
+	hv := grid.NewHeaderView(v.view)
+	x := createView(hv, v.ctx, v.ro)
+	x.file = v.file
+	if n, ok := hv.(interface{ Names() []string }); ok {
+		x.names = n.Names()
+	}
+	return x
+}
+
+// Names gives the names of the columns of a view having a header.
+func (v *View) Names() []string {
+	return v.names
+}
+
+// ColumnIndex gives the index of the column with the given name. Names are
+// compared without case.
+func (v *View) ColumnIndex(name string) (int64, bool) {
+	for i, n := range v.names {
+		if strings.EqualFold(n, name) {
+			return int64(i + 1), true
+		}
+	}
+	return 0, false
+}
+
+// derive gives a view built from v with the given names of columns.
+func (v *View) derive(view grid.View, names []string) *View {
+	x := createView(view, v.ctx, false)
+	x.names = names
+	return x
+}
+
+// ReadOnly gives a copy of the view whose cells can not be updated.
+func (v *View) ReadOnly() *View {
+	x := *v
+	x.ro = true
+	return &x
+}
+
 func (v *View) Name() string {
 	return v.view.Name()
 }
@@ -110,17 +157,52 @@ func (c *View) FilterView(predicate value.Predicate) *View {
 		ctx  = grid.EnclosedContext(c.ctx, grid.SheetContext(c.view))
 		view = grid.FilterView(c.view, ctx, predicate)
 	)
+	return c.derive(view, c.names)
+}
+
+func (c *View) TransposeView() *View {
+	view := grid.NewTransposedView(c.view)
 	return createView(view, c.ctx, false)
 }
 
+func (c *View) SortView(keys []grid.SortKey) *View {
+	view := grid.NewSortedView(c.view, keys)
+	return c.derive(view, c.names)
+}
+
 func (c *View) ProjectView(sel layout.Selection) *View {
 	view := grid.NewProjectView(c.view, sel)
-	return createView(view, c.ctx, false)
+	if c.names == nil {
+		return createView(view, c.ctx, false)
+	}
+	var names []string
+	for _, ix := range sel.Indices(c.view.Bounds()) {
+		if ix >= 0 && ix < int64(len(c.names)) {
+			names = append(names, c.names[ix])
+		} else {
+			names = append(names, "")
+		}
+	}
+	return c.derive(view, names)
 }
 
 func (c *View) BoundedView(rg *layout.Range) *View {
 	view := grid.NewBoundedView(c.view, rg)
-	return createView(view, c.ctx, false)
+	if c.names == nil {
+		return createView(view, c.ctx, false)
+	}
+	var (
+		part  = rg.Normalize()
+		names []string
+	)
+	for col := part.Starts.Column; col <= part.Ends.Column; col++ {
+		if col >= 1 && col <= int64(len(c.names)) {
+			names = append(names, c.names[col-1])
+		} else {
+			names = append(names, "")
+		}
+	}
+	return c.derive(view, names)
 }
 
 func (c *View) Rename(name string) {
@@ -171,7 +253,7 @@ func (c *View) InsertColumns(offset, count int64) (*WritableRange, Mutation, err
 		start  = layout.NewPosition(1, offset+1)
 		end    = layout.NewPosition(bounds.Height(), offset+count)
 	)
-	return NewWritableRange(c, start, end), InsertRows(offset, count), nil
+	return NewWritableRange(c, start, end), InsertColumns(offset, count), nil
 }
 
 func (c *View) InsertRows(offset, count int64) (*WritableRange, Mutation, error) {
@@ -198,7 +280,7 @@ func (c *View) InsertRows(offset, count int64) (*WritableRange, Mutation, error)
 		start.Line = 1
 		end.Line = 1
 	}
-	return NewWritableRange(c, start, end), InsertColumns(offset, count), nil
+	return NewWritableRange(c, start, end), InsertRows(offset, count), nil
 }
 
 func (c *View) RemoveColumns(offset, count int64) (Mutation, error) {
@@ -257,10 +339,8 @@ func (c *View) SetAt(pos layout.Position, val value.Value) error {
 	switch f := val.(type) {
 	case parse.Deferred:
 		fm := grid.NewFormula(f.Expr())
-		if c, ok := fm.(interface {
-			Clone(layout.Position) value.Formula
-		}); ok {
-			fm = c.Clone(pos)
+		if anchor, ok := f.Anchor(); ok {
+			fm = grid.Rebase(fm, anchor, pos.WithoutSheet())
 		}
 		return mv.SetFormula(pos, fm)
 	case value.Formula:
@@ -275,7 +355,7 @@ func (c *View) SetAt(pos layout.Position, val value.Value) error {
 }
 
 func (c *View) Range(start, end layout.Position) value.Value {
-	rg := layout.NewRange(start.WithoutSheet(), end.WithoutSheet())
+	rg := grid.UsedRange(c.view, start.WithoutSheet(), end.WithoutSheet())
 	for pos := range rg.Positions() {
 		cell, err := c.view.Cell(pos)
 		if err != nil {
@@ -301,8 +381,12 @@ func (c *View) SetRange(start, end layout.Position, val value.Value) error {
 	switch v := val.(type) {
 	case parse.Deferred:
 		fm := grid.NewFormula(v.Expr())
+		anchor, ok := v.Anchor()
+		if !ok {
+			anchor = start
+		}
 		for pos := range rg.Positions() {
-			rb := grid.Rebase(fm, start, pos)
+			rb := grid.Rebase(fm, anchor, pos)
 			if err := c.SetAt(pos, rb); err != nil {
 				return err
 			}
@@ -473,6 +557,19 @@ func (v *array) At(row, col int) value.Value {
 	return c.Value()
 }
 
+func (v *array) Values() iter.Seq[value.Value] {
+	return func(yield func(value.Value) bool) {
+		dim := v.Dimension()
+		for row := range dim.Lines {
+			for col := range dim.Columns {
+				if !yield(v.At(int(row), int(col))) {
+					return
+				}
+			}
+		}
+	}
+}
+
 func (v *array) Cells() [][]grid.Cell {
 	var (
 		bs  = v.view.Bounds()
@@ -0,0 +1,10 @@
+// Package stdlib holds the script modules shipped with Dockit.
+//
+// The modules are embedded in the binary so that they are always available
+// and match the version of the language in use. They are named with the std/
+// prefix, like std/dates, and are meant to be loaded by the include statement.
+//
+// A Library resolves the modules. When it is created with a directory, the
+// modules found in that directory take precedence over the embedded ones: a
+// file <dir>/std/dates.dk replaces the std/dates module.
+package stdlib
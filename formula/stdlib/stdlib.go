@@ -0,0 +1,124 @@
+package stdlib
+
+import (
+	"bufio"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	Prefix = "std/"
+	Ext    = ".dk"
+)
+
+var ErrModule = errors.New("module not found")
+
+//go:embed std/*.dk
+var modules embed.FS
+
+// IsStd reports whether name refers to a module of the standard library.
+func IsStd(name string) bool {
+	return strings.HasPrefix(name, Prefix)
+}
+
+type Module struct {
+	Name string
+	Desc string
+	User bool
+}
+
+type Library struct {
+	dir string
+}
+
+// New gives a Library looking first for the modules in dir. An empty dir
+// gives a Library using only the embedded modules.
+func New(dir string) *Library {
+	return &Library{
+		dir: dir,
+	}
+}
+
+// Open gives the content of the module with the given name.
+func (b *Library) Open(name string) (io.ReadCloser, error) {
+	if !IsStd(name) {
+		return nil, fmt.Errorf("%s: %w", name, ErrModule)
+	}
+	file := strings.TrimSuffix(name, Ext) + Ext
+	if b.dir != "" {
+		r, err := os.Open(filepath.Join(b.dir, filepath.FromSlash(file)))
+		if err == nil {
+			return r, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	r, err := modules.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, ErrModule)
+	}
+	return r, nil
+}
+
+// List gives the modules available sorted by name.
+func (b *Library) List() ([]Module, error) {
+	var list []Module
+	files, err := fs.Glob(modules, Prefix+"*"+Ext)
+	if err != nil {
+		return nil, err
+	}
+	if b.dir != "" {
+		others, err := filepath.Glob(filepath.Join(b.dir, "std", "*"+Ext))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range others {
+			f = Prefix + filepath.Base(f)
+			if !slices.Contains(files, f) {
+				files = append(files, f)
+			}
+		}
+	}
+	for _, f := range files {
+		mod, err := b.describe(strings.TrimSuffix(f, Ext))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, mod)
+	}
+	slices.SortFunc(list, func(m1, m2 Module) int {
+		return strings.Compare(m1.Name, m2.Name)
+	})
+	return list, nil
+}
+
+func (b *Library) describe(name string) (Module, error) {
+	mod := Module{
+		Name: name,
+	}
+	if b.dir != "" {
+		_, err := os.Stat(filepath.Join(b.dir, filepath.FromSlash(name+Ext)))
+		mod.User = err == nil
+	}
+	r, err := b.Open(name)
+	if err != nil {
+		return mod, err
+	}
+	defer r.Close()
+
+	scan := bufio.NewScanner(r)
+	if scan.Scan() {
+		if line := scan.Text(); strings.HasPrefix(line, "#") {
+			mod.Desc = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		}
+	}
+	return mod, scan.Err()
+}
@@ -0,0 +1,72 @@
+package stdlib_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/midbel/dockit/formula/env"
+	"github.com/midbel/dockit/formula/eval"
+	"github.com/midbel/dockit/formula/stdlib"
+)
+
+func TestModules(t *testing.T) {
+	list, err := stdlib.New("").List()
+	if err != nil {
+		t.Fatalf("error listing modules: %s", err)
+	}
+	if len(list) == 0 {
+		t.Fatalf("no modules embedded")
+	}
+	for _, m := range list {
+		t.Run(m.Name, func(t *testing.T) {
+			if m.Desc == "" {
+				t.Errorf("module has no description")
+			}
+			r, err := stdlib.New("").Open(m.Name)
+			if err != nil {
+				t.Fatalf("error opening module: %s", err)
+			}
+			defer r.Close()
+
+			eg := eval.NewEngine()
+			eg.Stdout = bytes.NewBuffer(nil)
+			eg.Stderr = bytes.NewBuffer(nil)
+			if _, err := eg.Exec(r, env.Empty()); err != nil {
+				t.Fatalf("error executing module: %s", err)
+			}
+		})
+	}
+}
+
+func TestOverride(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "std"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	err := os.WriteFile(filepath.Join(dir, "std", "dates.dk"), []byte("# user dates\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := stdlib.New(dir).List()
+	if err != nil {
+		t.Fatalf("error listing modules: %s", err)
+	}
+	for _, m := range list {
+		want := m.Name == "std/dates"
+		if m.User != want {
+			t.Errorf("%s: user module mismatched! want %t, got %t", m.Name, want, m.User)
+		}
+		if want && m.Desc != "user dates" {
+			t.Errorf("%s: description mismatched! got %q", m.Name, m.Desc)
+		}
+	}
+	if _, err := stdlib.New(dir).Open("std/unknown"); !errors.Is(err, stdlib.ErrModule) {
+		t.Errorf("unknown module should not be found")
+	}
+	if _, err := stdlib.New(dir).Open("dates"); !errors.Is(err, stdlib.ErrModule) {
+		t.Errorf("module outside of the standard library should not be found")
+	}
+}
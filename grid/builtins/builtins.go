@@ -5,21 +5,51 @@ import (
 	"maps"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/midbel/dockit/value"
 )
 
-var registry = map[string]Builtin{}
+var (
+	registry = map[string]Builtin{}
+	regMu    sync.RWMutex
+)
+
+// Register adds a builtin to the registry. Builtins already defined, by their
+// name or one of their aliases, can not be replaced.
+func Register(b Builtin) error {
+	if b.Name == "" {
+		return fmt.Errorf("builtin without name")
+	}
+	if b.Func == nil && b.Handler == nil {
+		return fmt.Errorf("%s: builtin without function", b.Name)
+	}
+	for _, name := range append([]string{b.Name}, b.Alias...) {
+		if _, err := Get(name); err == nil {
+			return fmt.Errorf("%s: builtin already defined", name)
+		}
+	}
+	regMu.Lock()
+	defer regMu.Unlock()
+
+	b.Name = strings.ToLower(b.Name)
+	registry[b.Name] = b
+	return nil
+}
 
 func Get(ident string) (Builtin, error) {
+	regMu.RLock()
 	fn, ok := registry[strings.ToLower(ident)]
+	regMu.RUnlock()
 	if ok {
 		return fn, nil
 	}
 	vs := List()
 	ix := slices.IndexFunc(vs, func(b Builtin) bool {
-		return slices.Contains(b.Alias, ident)
+		return slices.ContainsFunc(b.Alias, func(alias string) bool {
+			return strings.EqualFold(alias, ident)
+		})
 	})
 	if ix < 0 {
 		return Builtin{}, fmt.Errorf("%s undefined builtin", ident)
@@ -36,6 +66,9 @@ func Lookup(ident string) (BuiltinFunc, error) {
 }
 
 func List() []Builtin {
+	regMu.RLock()
+	defer regMu.RUnlock()
+
 	vs := maps.Values(registry)
 	return slices.Collect(vs)
 }
@@ -46,6 +79,10 @@ type Evaluable interface {
 
 type BuiltinFunc func([]value.Value) value.Value
 
+// ContextFunc is a builtin using the context of the formula or of the script
+// calling it, eg to read the values of other cells.
+type ContextFunc func([]value.Value, value.Context) value.Value
+
 type Dialect int8
 
 const (
@@ -62,9 +99,14 @@ type Builtin struct {
 	Alias    []string
 	Params   []Param
 	Func     BuiltinFunc
+	// Handler replaces Func for the builtins using their context.
+	Handler ContextFunc
 
 	Dialect   Dialect
 	Vectorize bool
+	// Volatile builtins give another value at each evaluation: the formulas
+	// using them are evaluated whenever the workbook is recalculated.
+	Volatile bool
 }
 
 func (b Builtin) OxmlSupported() bool {
@@ -84,7 +126,17 @@ func (b Builtin) OdsOnly() bool {
 }
 
 func (b Builtin) Make() BuiltinFunc {
-	return Make(b.Params, b.Func)
+	return b.Bind(nil)
+}
+
+// Bind gives the function of the builtin called with the given context.
+func (b Builtin) Bind(ctx value.Context) BuiltinFunc {
+	if b.Handler == nil {
+		return Make(b.Params, b.Func)
+	}
+	return Make(b.Params, func(args []value.Value) value.Value {
+		return b.Handler(args, ctx)
+	})
 }
 
 type deferrableValue struct {
@@ -104,6 +156,53 @@ func (d deferrableValue) Eval() value.Value {
 	return value.ErrValue
 }
 
+// lazyValue is an argument given to a deferrable parameter. Its expression is
+// only evaluated once the builtin uses it.
+type lazyValue struct {
+	eval func() value.Value
+	val  value.Value
+}
+
+// Defer gives an argument whose value is computed by fn the first time it is
+// needed.
+func Defer(fn func() value.Value) value.Value {
+	return &lazyValue{
+		eval: fn,
+	}
+}
+
+func (v *lazyValue) Eval() value.Value {
+	if v.val == nil {
+		v.val = v.eval()
+	}
+	return v.val
+}
+
+func (v *lazyValue) Kind() value.ValueKind {
+	return v.Eval().Kind()
+}
+
+func (v *lazyValue) Type() string {
+	return v.Eval().Type()
+}
+
+func (v *lazyValue) String() string {
+	return v.Eval().String()
+}
+
+// Deferred reports whether the argument at the given index is given to a
+// deferrable parameter of the builtin.
+func (b Builtin) Deferred(ix int) bool {
+	if len(b.Params) == 0 || ix < 0 {
+		return false
+	}
+	if ix >= len(b.Params) {
+		last := b.Params[len(b.Params)-1]
+		return last.Variadic && last.Deferrable
+	}
+	return b.Params[ix].Deferrable
+}
+
 type Param struct {
 	Name       string
 	Desc       string
@@ -282,7 +381,7 @@ func asFloat(arg value.Value) float64 {
 
 func asFloatArray(args []value.Value) []float64 {
 	arr := value.Collect[float64](args, func(v value.Value) (float64, bool) {
-		if value.IsError(v) {
+		if value.IsError(v) || value.IsBlank(v) {
 			return 0, false
 		}
 		return asFloat(v), true
@@ -291,8 +390,19 @@ func asFloatArray(args []value.Value) []float64 {
 }
 
 func asString(arg value.Value) string {
-	v, _ := value.CastToText(arg)
-	return string(v)
+	v, err := value.CastToText(arg)
+	if err == nil {
+		return string(v)
+	}
+	// other values are written as in a cell
+	switch arg.Type() {
+	case value.TypeBool:
+		return strings.ToUpper(arg.String())
+	case value.TypeNumber, value.TypeDate:
+		return arg.String()
+	default:
+		return ""
+	}
 }
 
 func asBool(arg value.Value) bool {
@@ -311,6 +421,7 @@ func init() {
 	registerBuiltins(indexBuiltins)
 	registerBuiltins(textBuiltins)
 	registerBuiltins(numberBuiltins)
+	registerBuiltins(financeBuiltins)
 }
 
 func registerBuiltins(list []Builtin) {
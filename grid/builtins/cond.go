@@ -2,6 +2,7 @@ package builtins
 
 import (
 	"math"
+	"slices"
 
 	"github.com/midbel/dockit/value"
 )
@@ -40,22 +41,39 @@ var ifBuiltin = Builtin{
 	Params: []Param{
 		Scalar("value", "", value.TypeAny),
 		Deferrable(Scalar("csq", "", value.TypeAny)),
-		Deferrable(Scalar("alt", "", value.TypeAny)),
+		Opt(Deferrable(Scalar("alt", "", value.TypeAny))),
 	},
 	Func:    If,
 	Dialect: MainDialect,
 }
 
+// If only gives the branch selected by the condition so that the other one is
+// never evaluated when it is deferred.
 func If(args []value.Value) value.Value {
-	if err := value.HasErrors(args[:3]...); err != nil {
+	if err := value.HasErrors(args[0]); err != nil {
 		return err
 	}
 	if value.True(args[0]) {
 		return args[1]
 	}
+	if len(args) < 3 {
+		return value.Boolean(false)
+	}
 	return args[2]
 }
 
+var iifBuiltin = Builtin{
+	Name:     "iif",
+	Desc:     "Returns one of two values depending on the condition",
+	Category: "conditional",
+	Params: []Param{
+		Scalar("value", "", value.TypeAny),
+		Deferrable(Scalar("csq", "", value.TypeAny)),
+		Deferrable(Scalar("alt", "", value.TypeAny)),
+	},
+	Func: If,
+}
+
 var ifErrorBuiltin = Builtin{
 	Name:     "iferror",
 	Desc:     "Returns a fallback value if the expression results in an error",
@@ -99,22 +117,23 @@ var andBuiltin = Builtin{
 	Desc:     "Returns true if all conditions are true",
 	Category: "conditional",
 	Params: []Param{
-		ScalarArray("value1", "", value.TypeAny),
-		ScalarArray("value2", "", value.TypeAny),
+		Var(ScalarArray("value", "", value.TypeAny)),
 	},
 	Func:    And,
 	Dialect: MainDialect,
 }
 
 func And(args []value.Value) value.Value {
-	if err := value.HasErrors(args[:2]...); err != nil {
+	list, err := logicalValues(args)
+	if err != nil {
 		return err
 	}
-	var (
-		ok1 = value.True(args[0])
-		ok2 = value.True(args[1])
-	)
-	return value.Boolean(ok1 && ok2)
+	for _, ok := range list {
+		if !ok {
+			return value.Boolean(false)
+		}
+	}
+	return value.Boolean(true)
 }
 
 var orBuiltin = Builtin{
@@ -122,22 +141,51 @@ var orBuiltin = Builtin{
 	Desc:     "Returns true if at least one condition is true",
 	Category: "conditional",
 	Params: []Param{
-		ScalarArray("value1", "", value.TypeAny),
-		ScalarArray("value2", "", value.TypeAny),
+		Var(ScalarArray("value", "", value.TypeAny)),
 	},
 	Func:    Or,
 	Dialect: MainDialect,
 }
 
 func Or(args []value.Value) value.Value {
-	if err := value.HasErrors(args[:2]...); err != nil {
+	list, err := logicalValues(args)
+	if err != nil {
 		return err
 	}
-	var (
-		ok1 = value.True(args[0])
-		ok2 = value.True(args[1])
-	)
-	return value.Boolean(ok1 || ok2)
+	return value.Boolean(slices.Contains(list, true))
+}
+
+// logicalValues gives the conditions given to AND and OR. Text and blank
+// values found in ranges are ignored while text given directly is an error as
+// is the lack of any condition.
+func logicalValues(args []value.Value) ([]bool, value.Value) {
+	var list []bool
+	for _, a := range args {
+		if value.IsError(a) {
+			return nil, a
+		}
+		if value.IsScalar(a) {
+			if _, ok := a.(value.Text); ok {
+				return nil, value.ErrValue
+			}
+			if !value.IsBlank(a) {
+				list = append(list, value.True(a))
+			}
+			continue
+		}
+		if err := value.Each([]value.Value{a}, func(v value.Value) {
+			if value.IsText(v) || value.IsBlank(v) {
+				return
+			}
+			list = append(list, value.True(v))
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if len(list) == 0 {
+		return nil, value.ErrValue
+	}
+	return list, nil
 }
 
 var xorBuiltin = Builtin{
@@ -250,6 +298,7 @@ func Switch(args []value.Value) value.Value {
 var condBuiltins = []Builtin{
 	ifsBuiltin,
 	ifBuiltin,
+	iifBuiltin,
 	ifErrorBuiltin,
 	ifNaBuiltin,
 	andBuiltin,
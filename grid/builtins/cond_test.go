@@ -103,6 +103,21 @@ func testIf(t *testing.T) {
 			},
 			Want: value.ErrValue,
 		},
+		{
+			Args: []value.Value{
+				value.Boolean(false),
+				value.Float(42),
+			},
+			Want: value.Boolean(false),
+		},
+		{
+			Args: []value.Value{
+				value.Boolean(false),
+				value.Float(42),
+				value.ErrDiv0,
+			},
+			Want: value.ErrDiv0,
+		},
 	}
 	testBuiltin(t, If, tests)
 }
@@ -177,6 +192,27 @@ func testAnd(t *testing.T) {
 			},
 			Want: value.Boolean(false),
 		},
+		{
+			Args: []value.Value{
+				value.Boolean(true),
+				value.Boolean(true),
+				value.Float(1),
+			},
+			Want: value.Boolean(true),
+		},
+		{
+			Args: []value.Value{
+				value.Text("foo"),
+			},
+			Want: value.ErrValue,
+		},
+		{
+			Args: []value.Value{
+				value.Boolean(true),
+				value.ErrNA,
+			},
+			Want: value.ErrNA,
+		},
 	}
 	testBuiltin(t, And, tests)
 }
@@ -0,0 +1,202 @@
+package builtins
+
+import (
+	"github.com/midbel/dockit/grid/calc"
+	"github.com/midbel/dockit/value"
+)
+
+var npvBuiltin = Builtin{
+	Name:     "npv",
+	Desc:     "Returns the net present value of cash flows given a discount rate",
+	Category: "financial",
+	Params: []Param{
+		Scalar("rate", "discount rate of one period", value.TypeNumber),
+		Var(ScalarArray("values", "cash flows at the end of each period", value.TypeNumber)),
+	},
+	Func:    Npv,
+	Dialect: MainDialect,
+}
+
+func Npv(args []value.Value) value.Value {
+	rate, err := numberArgs(args[:1])
+	if err != nil {
+		return err
+	}
+	flows, err := numericValues(args[1:])
+	if err != nil {
+		return err
+	}
+	if rate[0] == -1 {
+		return value.ErrDiv0
+	}
+	return checkFloat(calc.Npv(rate[0], flows))
+}
+
+var irrBuiltin = Builtin{
+	Name:     "irr",
+	Desc:     "Returns the internal rate of return of cash flows",
+	Category: "financial",
+	Params: []Param{
+		ScalarArray("values", "cash flows with at least one payment and one income", value.TypeNumber),
+		Opt(Scalar("guess", "rate to start the search from, 0.1 by default", value.TypeNumber)),
+	},
+	Func:    Irr,
+	Dialect: MainDialect,
+}
+
+func Irr(args []value.Value) value.Value {
+	flows, err := numericValues(args[:1])
+	if err != nil {
+		return err
+	}
+	guess := 0.1
+	if len(args) > 1 {
+		list, err := numberArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		guess = list[0]
+	}
+	var pos, neg bool
+	for _, f := range flows {
+		pos = pos || f > 0
+		neg = neg || f < 0
+	}
+	if !pos || !neg {
+		return value.ErrNum
+	}
+	rate, ok := calc.Irr(flows, guess)
+	if !ok {
+		return value.ErrNum
+	}
+	return value.Float(rate)
+}
+
+var pmtBuiltin = Builtin{
+	Name:     "pmt",
+	Desc:     "Returns the payment of each period of a loan with constant payments and interest rate",
+	Category: "financial",
+	Params: []Param{
+		Scalar("rate", "interest rate of one period", value.TypeNumber),
+		Scalar("nper", "number of payments", value.TypeNumber),
+		Scalar("pv", "present value", value.TypeNumber),
+		Opt(Scalar("fv", "future value, 0 by default", value.TypeNumber)),
+		Opt(Scalar("type", "1 when payments are due at the start of the periods", value.TypeNumber)),
+	},
+	Func:    Pmt,
+	Dialect: MainDialect,
+}
+
+func Pmt(args []value.Value) value.Value {
+	list, err := financeArgs(args, 5)
+	if err != nil {
+		return err
+	}
+	if list[1] == 0 {
+		return value.ErrNum
+	}
+	return checkFloat(calc.Pmt(list[0], list[1], list[2], list[3], list[4] != 0))
+}
+
+var fvBuiltin = Builtin{
+	Name:     "fv",
+	Desc:     "Returns the future value of an investment with constant payments and interest rate",
+	Category: "financial",
+	Params: []Param{
+		Scalar("rate", "interest rate of one period", value.TypeNumber),
+		Scalar("nper", "number of payments", value.TypeNumber),
+		Scalar("pmt", "payment made each period", value.TypeNumber),
+		Opt(Scalar("pv", "present value, 0 by default", value.TypeNumber)),
+		Opt(Scalar("type", "1 when payments are due at the start of the periods", value.TypeNumber)),
+	},
+	Func:    Fv,
+	Dialect: MainDialect,
+}
+
+func Fv(args []value.Value) value.Value {
+	list, err := financeArgs(args, 5)
+	if err != nil {
+		return err
+	}
+	return checkFloat(calc.Fv(list[0], list[1], list[2], list[3], list[4] != 0))
+}
+
+var pvBuiltin = Builtin{
+	Name:     "pv",
+	Desc:     "Returns the present value of an investment with constant payments and interest rate",
+	Category: "financial",
+	Params: []Param{
+		Scalar("rate", "interest rate of one period", value.TypeNumber),
+		Scalar("nper", "number of payments", value.TypeNumber),
+		Scalar("pmt", "payment made each period", value.TypeNumber),
+		Opt(Scalar("fv", "future value, 0 by default", value.TypeNumber)),
+		Opt(Scalar("type", "1 when payments are due at the start of the periods", value.TypeNumber)),
+	},
+	Func:    Pv,
+	Dialect: MainDialect,
+}
+
+func Pv(args []value.Value) value.Value {
+	list, err := financeArgs(args, 5)
+	if err != nil {
+		return err
+	}
+	return checkFloat(calc.Pv(list[0], list[1], list[2], list[3], list[4] != 0))
+}
+
+var rateBuiltin = Builtin{
+	Name:     "rate",
+	Desc:     "Returns the interest rate of one period of an annuity",
+	Category: "financial",
+	Params: []Param{
+		Scalar("nper", "number of payments", value.TypeNumber),
+		Scalar("pmt", "payment made each period", value.TypeNumber),
+		Scalar("pv", "present value", value.TypeNumber),
+		Opt(Scalar("fv", "future value, 0 by default", value.TypeNumber)),
+		Opt(Scalar("type", "1 when payments are due at the start of the periods", value.TypeNumber)),
+		Opt(Scalar("guess", "rate to start the search from, 0.1 by default", value.TypeNumber)),
+	},
+	Func:    Rate,
+	Dialect: MainDialect,
+}
+
+func Rate(args []value.Value) value.Value {
+	list, err := financeArgs(args, 6)
+	if err != nil {
+		return err
+	}
+	if list[0] <= 0 {
+		return value.ErrNum
+	}
+	guess := 0.1
+	if len(args) > 5 {
+		guess = list[5]
+	}
+	rate, ok := calc.Rate(list[0], list[1], list[2], list[3], list[4] != 0, guess)
+	if !ok {
+		return value.ErrNum
+	}
+	return value.Float(rate)
+}
+
+// financeArgs converts the arguments to numbers, the optional ones that are
+// missing being zero.
+func financeArgs(args []value.Value, size int) ([]float64, value.Value) {
+	list, err := numberArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	for len(list) < size {
+		list = append(list, 0)
+	}
+	return list, nil
+}
+
+var financeBuiltins = []Builtin{
+	npvBuiltin,
+	irrBuiltin,
+	pmtBuiltin,
+	fvBuiltin,
+	pvBuiltin,
+	rateBuiltin,
+}
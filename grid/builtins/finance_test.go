@@ -0,0 +1,124 @@
+package builtins
+
+import (
+	"testing"
+
+	"github.com/midbel/dockit/value"
+)
+
+func TestFinance(t *testing.T) {
+	t.Run("npv", testNpv)
+	t.Run("irr", testIrr)
+	t.Run("pmt", testPmt)
+	t.Run("fv", testFv)
+	t.Run("pv", testPv)
+	t.Run("rate", testRate)
+}
+
+// testRounded checks the results of fn rounded to the given number of digits.
+func testRounded(t *testing.T, fn BuiltinFunc, digits int, args []BuiltinTestCase) {
+	t.Helper()
+	testBuiltin(t, func(args []value.Value) value.Value {
+		got := fn(args)
+		if value.IsError(got) {
+			return got
+		}
+		return Round([]value.Value{got, value.Float(digits)})
+	}, args)
+}
+
+func testNpv(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(0.1), value.Float(-10000), value.Float(3000), value.Float(4200), value.Float(6800)},
+			Want: value.Float(1188.44),
+		},
+		{
+			Args: []value.Value{value.Float(0.08), value.NewArray([][]value.Value{{value.Float(8000), value.Float(9200)}, {value.Text("n/a"), value.Float(10000)}})},
+			Want: value.Float(23233.25),
+		},
+	}
+	testRounded(t, Npv, 2, tests)
+}
+
+func testIrr(t *testing.T) {
+	flows := value.NewArray([][]value.Value{
+		{value.Float(-70000)},
+		{value.Float(12000)},
+		{value.Float(15000)},
+		{value.Float(18000)},
+		{value.Float(21000)},
+		{value.Float(26000)},
+	})
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{flows},
+			Want: value.Float(0.0866),
+		},
+		{
+			Args: []value.Value{value.NewArray([][]value.Value{{value.Float(100), value.Float(200)}})},
+			Want: value.ErrNum,
+		},
+	}
+	testRounded(t, Irr, 4, tests)
+}
+
+func testPmt(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(0.08 / 12), value.Float(10), value.Float(10000)},
+			Want: value.Float(-1037.03),
+		},
+		{
+			Args: []value.Value{value.Float(0.08 / 12), value.Float(10), value.Float(10000), value.Float(0), value.Float(1)},
+			Want: value.Float(-1030.16),
+		},
+		{
+			Args: []value.Value{value.Float(0), value.Float(10), value.Float(1000)},
+			Want: value.Float(-100),
+		},
+		{
+			Args: []value.Value{value.Float(0.1), value.Float(0), value.Float(1000)},
+			Want: value.ErrNum,
+		},
+	}
+	testRounded(t, Pmt, 2, tests)
+}
+
+func testFv(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(0.06 / 12), value.Float(10), value.Float(-200), value.Float(-500), value.Float(1)},
+			Want: value.Float(2581.40),
+		},
+		{
+			Args: []value.Value{value.Float(0), value.Float(12), value.Float(-100)},
+			Want: value.Float(1200),
+		},
+	}
+	testRounded(t, Fv, 2, tests)
+}
+
+func testPv(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(0.08 / 12), value.Float(12 * 20), value.Float(500)},
+			Want: value.Float(-59777.15),
+		},
+	}
+	testRounded(t, Pv, 2, tests)
+}
+
+func testRate(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(48), value.Float(-200), value.Float(8000)},
+			Want: value.Float(0.0077),
+		},
+		{
+			Args: []value.Value{value.Float(10), value.Float(-100), value.Float(1000)},
+			Want: value.Float(0),
+		},
+	}
+	testRounded(t, Rate, 4, tests)
+}
@@ -60,6 +60,12 @@ func Help(w io.Writer, ident string) error {
 		io.WriteString(ws, "\n")
 	}
 
+	if b.Volatile {
+		io.WriteString(ws, "Volatile: evaluated at each recalculation")
+		io.WriteString(ws, "\n")
+		io.WriteString(ws, "\n")
+	}
+
 	io.WriteString(ws, "Parameters:")
 	io.WriteString(ws, "\n")
 	for _, p := range b.Params {
@@ -1,19 +1,31 @@
 package builtins
 
 import (
+	"cmp"
 	"math"
+	"regexp"
+	"slices"
+	"strings"
 
+	"github.com/midbel/dockit/grid/criteria"
 	"github.com/midbel/dockit/value"
 )
 
+// modes used to find a value in a list
+const (
+	matchExact   = 0
+	matchSmaller = -1
+	matchLarger  = 1
+)
+
 var matchBuiltin = Builtin{
 	Name:     "match",
-	Desc:     "",
+	Desc:     "Give the relative position of a value in a row or a column",
 	Category: "conditional",
 	Params: []Param{
-		Scalar("value", "", value.TypeAny),
-		Array("array", "", value.TypeArray),
-		Scalar("mode", "", value.TypeNumber),
+		Scalar("value", "value to find", value.TypeAny),
+		Array("array", "row or column to search", value.TypeArray),
+		Opt(Scalar("mode", "1 for the largest value lower than value in ascending lists, 0 for an exact match with the wildcards ? and *, -1 for the smallest value greater than value in descending lists", value.TypeNumber)),
 	},
 	Func:    Match,
 	Dialect: MainDialect,
@@ -23,17 +35,44 @@ func Match(args []value.Value) value.Value {
 	if err := value.HasErrors(args...); err != nil {
 		return err
 	}
-	return value.FindIndex(args[1:], args[0])
+	list, ok := vectorOf(args[1])
+	if !ok {
+		return value.ErrNA
+	}
+	mode := matchLarger
+	if len(args) >= 3 {
+		switch n := asFloat(args[2]); {
+		case n > 0:
+			mode = matchLarger
+		case n < 0:
+			mode = matchSmaller
+		default:
+			mode = matchExact
+		}
+	}
+	var ix int
+	switch mode {
+	case matchExact:
+		ix = findExact(list, args[0])
+	case matchLarger:
+		ix = findSorted(list, args[0], false)
+	default:
+		ix = findSorted(list, args[0], true)
+	}
+	if ix < 0 {
+		return value.ErrNA
+	}
+	return value.Float(ix + 1)
 }
 
 var indexBuiltin = Builtin{
 	Name:     "index",
-	Desc:     "",
+	Desc:     "Give the value at the given row and column of an array",
 	Category: "conditional",
 	Params: []Param{
-		Array("array", "", value.TypeAny),
-		Scalar("row", "", value.TypeAny),
-		Opt(Scalar("col", "", value.TypeAny)),
+		Array("array", "array of values", value.TypeAny),
+		Scalar("row", "row of the value starting at 1", value.TypeAny),
+		Opt(Scalar("col", "column of the value starting at 1", value.TypeAny)),
 	},
 	Func:    Index,
 	Dialect: MainDialect,
@@ -43,43 +82,392 @@ func Index(args []value.Value) value.Value {
 	if err := value.HasErrors(args...); err != nil {
 		return err
 	}
-	arr, ok := args[0].(value.ArrayValue)
-	if !ok {
-		return value.ErrValue
-	}
+	arr := arrayOf(args[0])
 	var (
-		row = asFloat(args[1])
-		col = 1.0
+		dim = arr.Dimension()
+		row = int(math.Floor(asFloat(args[1])))
+		col = 1
 	)
 	if len(args) >= 3 {
-		col = asFloat(args[2])
+		col = int(math.Floor(asFloat(args[2])))
+	} else if dim.Lines == 1 {
+		// a single index selects a column of an array with one row
+		row, col = 1, row
+	}
+	if row < 0 || col < 0 || int64(row) > dim.Lines || int64(col) > dim.Columns {
+		return value.ErrRef
+	}
+	switch {
+	case row == 0 && col == 0:
+		return arr
+	case row == 0:
+		return columnOf(arr, col-1)
+	case col == 0:
+		return rowOf(arr, row-1)
+	default:
+		return arr.At(row-1, col-1)
+	}
+}
+
+var offsetBuiltin = Builtin{
+	Name:     "offset",
+	Desc:     "Give the cells of a reference moved by the given number of rows and columns",
+	Category: "conditional",
+	Params: []Param{
+		ScalarArray("reference", "cell or range of cells to move", value.TypeAny),
+		Scalar("rows", "number of rows to move the reference", value.TypeNumber),
+		Scalar("cols", "number of columns to move the reference", value.TypeNumber),
+		Opt(Scalar("height", "number of rows of the result", value.TypeNumber)),
+		Opt(Scalar("width", "number of columns of the result", value.TypeNumber)),
+	},
+	Func:     Offset,
+	Dialect:  MainDialect,
+	Volatile: true,
+}
+
+// Offset needs the reference of its first argument and not its values: it is
+// only evaluated by the formulas of the cells.
+func Offset(args []value.Value) value.Value {
+	if err := value.HasErrors(args...); err != nil {
+		return err
 	}
-	return arr.At(int(math.Floor(row)-1), int(math.Floor(col)-1))
+	return value.ErrRef
 }
 
 var vlookupBuiltin = Builtin{
 	Name:     "vlookup",
-	Desc:     "",
+	Desc:     "Find a value in the first column of a table and give the value of the same row in another column",
 	Category: "conditional",
-	Params:   []Param{},
-	Func:     VLookup,
-	Dialect:  MainDialect,
+	Params: []Param{
+		Scalar("value", "value to find", value.TypeAny),
+		Array("table", "table of values", value.TypeArray),
+		Scalar("index", "column of the table starting at 1 with the value to return", value.TypeNumber),
+		Opt(Scalar("approximate", "find the largest value lower than value in a sorted column (default) or an exact match with the wildcards ? and *", value.TypeBool)),
+	},
+	Func:    VLookup,
+	Dialect: MainDialect,
 }
 
 func VLookup(args []value.Value) value.Value {
-	return nil
+	if err := value.HasErrors(args...); err != nil {
+		return err
+	}
+	var (
+		arr = arrayOf(args[1])
+		dim = arr.Dimension()
+	)
+	col, err := lookupIndex(args[2], dim.Columns)
+	if err != nil {
+		return err
+	}
+	ix := lookupFirst(arr, args[0], args[3:], columnOf)
+	if ix < 0 {
+		return value.ErrNA
+	}
+	return arr.At(ix, col)
+}
+
+var hlookupBuiltin = Builtin{
+	Name:     "hlookup",
+	Desc:     "Find a value in the first row of a table and give the value of the same column in another row",
+	Category: "conditional",
+	Params: []Param{
+		Scalar("value", "value to find", value.TypeAny),
+		Array("table", "table of values", value.TypeArray),
+		Scalar("index", "row of the table starting at 1 with the value to return", value.TypeNumber),
+		Opt(Scalar("approximate", "find the largest value lower than value in a sorted row (default) or an exact match with the wildcards ? and *", value.TypeBool)),
+	},
+	Func:    HLookup,
+	Dialect: MainDialect,
 }
 
 func HLookup(args []value.Value) value.Value {
-	return nil
+	if err := value.HasErrors(args...); err != nil {
+		return err
+	}
+	var (
+		arr = arrayOf(args[1])
+		dim = arr.Dimension()
+	)
+	row, err := lookupIndex(args[2], dim.Lines)
+	if err != nil {
+		return err
+	}
+	ix := lookupFirst(arr, args[0], args[3:], rowOf)
+	if ix < 0 {
+		return value.ErrNA
+	}
+	return arr.At(row, ix)
+}
+
+var xlookupBuiltin = Builtin{
+	Name:     "xlookup",
+	Desc:     "Find a value in a row or a column and give the value at the same position in another row or column",
+	Category: "conditional",
+	Params: []Param{
+		Scalar("value", "value to find", value.TypeAny),
+		Array("lookup", "row or column to search", value.TypeArray),
+		Array("result", "row or column with the values to return", value.TypeArray),
+		Opt(Scalar("missing", "value given when value is not found", value.TypeAny)),
+		Opt(Scalar("mode", "0 for an exact match (default), -1 for the next smaller value, 1 for the next larger value", value.TypeNumber)),
+		Opt(Scalar("search", "1 to search from the first value (default), -1 to search from the last value", value.TypeNumber)),
+	},
+	Func:    XLookup,
+	Dialect: MainDialect,
 }
 
 func XLookup(args []value.Value) value.Value {
-	return nil
+	if err := value.HasErrors(args[:3]...); err != nil {
+		return err
+	}
+	list, ok := vectorOf(args[1])
+	if !ok {
+		return value.ErrValue
+	}
+	var (
+		mode    = matchExact
+		reverse bool
+	)
+	if len(args) >= 5 && !value.IsBlank(args[4]) {
+		switch n := asFloat(args[4]); n {
+		case matchExact, matchSmaller, matchLarger:
+			mode = int(n)
+		default:
+			return value.ErrValue
+		}
+	}
+	if len(args) >= 6 && !value.IsBlank(args[5]) {
+		reverse = asFloat(args[5]) < 0
+	}
+	ix := findNearest(list, args[0], mode, reverse)
+	if ix < 0 {
+		if len(args) >= 4 && !value.IsBlank(args[3]) {
+			return args[3]
+		}
+		return value.ErrNA
+	}
+	var (
+		res  = arrayOf(args[2])
+		dim  = res.Dimension()
+		vert = arrayOf(args[1]).Dimension().Lines > 1
+	)
+	if vert {
+		if int64(ix) >= dim.Lines {
+			return value.ErrValue
+		}
+		if dim.Columns == 1 {
+			return res.At(ix, 0)
+		}
+		return rowOf(res, ix)
+	}
+	if int64(ix) >= dim.Columns {
+		return value.ErrValue
+	}
+	if dim.Lines == 1 {
+		return res.At(0, ix)
+	}
+	return columnOf(res, ix)
+}
+
+// lookupIndex gives the zero based index of the row or the column of a table
+// with the value to return.
+func lookupIndex(arg value.Value, size int64) (int, value.Value) {
+	n := int(math.Floor(asFloat(arg)))
+	if n < 1 {
+		return 0, value.ErrValue
+	}
+	if int64(n) > size {
+		return 0, value.ErrRef
+	}
+	return n - 1, nil
+}
+
+// lookupFirst finds value in the first row or column of a table. The search is
+// approximate unless the optional argument says otherwise.
+func lookupFirst(arr value.ArrayValue, val value.Value, opts []value.Value, first func(value.ArrayValue, int) value.ArrayValue) int {
+	approx := true
+	if len(opts) > 0 && !value.IsBlank(opts[0]) {
+		approx = asBool(opts[0])
+	}
+	list, _ := vectorOf(first(arr, 0))
+	if approx {
+		return findSorted(list, val, false)
+	}
+	return findExact(list, val)
+}
+
+// findExact gives the index of the first value of list equal to val or -1.
+// Texts may have the wildcards ? and *.
+func findExact(list []value.Value, val value.Value) int {
+	if re := wildcardOf(val); re != nil {
+		return slices.IndexFunc(list, func(v value.Value) bool {
+			return v.Type() == value.TypeText && re.MatchString(v.String())
+		})
+	}
+	for i, v := range list {
+		if c, ok := compareLookup(v, val); ok && c == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// findSorted gives the index of the largest value lower or equal to val in a
+// list sorted in ascending order or of the smallest value greater or equal to
+// val in a list sorted in descending order. Values of other types are skipped.
+func findSorted(list []value.Value, val value.Value, desc bool) int {
+	ix := -1
+	for i, v := range list {
+		c, ok := compareLookup(v, val)
+		if !ok {
+			continue
+		}
+		if desc {
+			c = -c
+		}
+		if c > 0 {
+			break
+		}
+		ix = i
+	}
+	return ix
+}
+
+// findNearest gives the index of the value of list equal to val or, depending
+// on the mode, of the next smaller or larger one. The list does not need to be
+// sorted.
+func findNearest(list []value.Value, val value.Value, mode int, reverse bool) int {
+	var (
+		ix   = -1
+		best value.Value
+	)
+	for i := range list {
+		if reverse {
+			i = len(list) - 1 - i
+		}
+		c, ok := compareLookup(list[i], val)
+		if !ok {
+			continue
+		}
+		if c == 0 {
+			return i
+		}
+		if mode == matchExact || c != mode {
+			continue
+		}
+		if best != nil {
+			if d, _ := compareLookup(list[i], best); d != -mode {
+				continue
+			}
+		}
+		ix, best = i, list[i]
+	}
+	return ix
+}
+
+// wildcardOf gives the regular expression matching the texts like val, where ?
+// matches any character and * any sequence of characters. A wildcard preceded
+// by ~ matches itself. It is nil when val is not a text with wildcards.
+func wildcardOf(val value.Value) *regexp.Regexp {
+	if val.Type() != value.TypeText || !strings.ContainsAny(val.String(), "?*~") {
+		return nil
+	}
+	re, err := regexp.Compile("(?is)^" + criteria.WildcardPattern(val.String()) + "$")
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+// compareLookup compares two values the way spreadsheets do when looking for
+// a value: texts are compared without regard to case and values of different
+// types can not be compared.
+func compareLookup(v1, v2 value.Value) (int, bool) {
+	switch v1.Type() {
+	case value.TypeNumber, value.TypeDate:
+		if t := v2.Type(); t != value.TypeNumber && t != value.TypeDate {
+			return 0, false
+		}
+		return cmp.Compare(asFloat(v1), asFloat(v2)), true
+	case value.TypeText:
+		if v2.Type() != value.TypeText {
+			return 0, false
+		}
+		return strings.Compare(strings.ToLower(v1.String()), strings.ToLower(v2.String())), true
+	case value.TypeBool:
+		if v2.Type() != value.TypeBool {
+			return 0, false
+		}
+		b1, b2 := asBool(v1), asBool(v2)
+		if b1 == b2 {
+			return 0, true
+		}
+		if b2 {
+			return -1, true
+		}
+		return 1, true
+	default:
+		return 0, false
+	}
+}
+
+// arrayOf gives the array of a range or a single value as an array.
+func arrayOf(v value.Value) value.ArrayValue {
+	if arr, ok := v.(value.ArrayValue); ok {
+		return arr
+	}
+	return value.NewArray([][]value.Value{{v}})
+}
+
+// vectorOf gives the values of an array with a single row or a single column.
+func vectorOf(v value.Value) ([]value.Value, bool) {
+	var (
+		arr  = arrayOf(v)
+		dim  = arr.Dimension()
+		list []value.Value
+	)
+	switch {
+	case dim.Lines == 1:
+		for i := range dim.Columns {
+			list = append(list, arr.At(0, int(i)))
+		}
+	case dim.Columns == 1:
+		for i := range dim.Lines {
+			list = append(list, arr.At(int(i), 0))
+		}
+	default:
+		return nil, false
+	}
+	return list, true
+}
+
+func rowOf(arr value.ArrayValue, row int) value.ArrayValue {
+	var (
+		dim  = arr.Dimension()
+		data = make([]value.Value, dim.Columns)
+	)
+	for i := range data {
+		data[i] = arr.At(row, i)
+	}
+	return value.NewArray([][]value.Value{data})
+}
+
+func columnOf(arr value.ArrayValue, col int) value.ArrayValue {
+	var (
+		dim  = arr.Dimension()
+		data = make([][]value.Value, dim.Lines)
+	)
+	for i := range data {
+		data[i] = []value.Value{arr.At(i, col)}
+	}
+	return value.NewArray(data)
 }
 
 var indexBuiltins = []Builtin{
 	matchBuiltin,
 	indexBuiltin,
-	// vlookupBuiltin,
+	offsetBuiltin,
+	vlookupBuiltin,
+	hlookupBuiltin,
+	xlookupBuiltin,
 }
@@ -0,0 +1,219 @@
+package builtins
+
+import (
+	"testing"
+
+	"github.com/midbel/dockit/value"
+)
+
+func TestLookup(t *testing.T) {
+	t.Run("match", testMatch)
+	t.Run("index", testIndex)
+	t.Run("vlookup", testVLookup)
+	t.Run("hlookup", testHLookup)
+	t.Run("xlookup", testXLookup)
+}
+
+func lookupTable() value.Value {
+	return value.NewArray([][]value.Value{
+		{value.Float(10), value.Text("apple"), value.Float(1.5)},
+		{value.Float(20), value.Text("banana"), value.Float(0.5)},
+		{value.Float(30), value.Text("cherry"), value.Float(4)},
+		{value.Float(40), value.Text("date"), value.Float(3)},
+	})
+}
+
+func lookupColumn(values ...value.Value) value.Value {
+	var data [][]value.Value
+	for _, v := range values {
+		data = append(data, []value.Value{v})
+	}
+	return value.NewArray(data)
+}
+
+func testMatch(t *testing.T) {
+	var (
+		asc  = lookupColumn(value.Float(10), value.Float(20), value.Float(30))
+		desc = lookupColumn(value.Float(30), value.Float(20), value.Float(10))
+		text = value.NewArray([][]value.Value{
+			{value.Text("north"), value.Text("south"), value.Text("east")},
+		})
+	)
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(25), asc},
+			Want: value.Float(2),
+		},
+		{
+			Args: []value.Value{value.Float(30), asc, value.Float(1)},
+			Want: value.Float(3),
+		},
+		{
+			Args: []value.Value{value.Float(5), asc},
+			Want: value.ErrNA,
+		},
+		{
+			Args: []value.Value{value.Float(20), asc, value.Float(0)},
+			Want: value.Float(2),
+		},
+		{
+			Args: []value.Value{value.Float(25), asc, value.Float(0)},
+			Want: value.ErrNA,
+		},
+		{
+			Args: []value.Value{value.Float(25), desc, value.Float(-1)},
+			Want: value.Float(1),
+		},
+		{
+			Args: []value.Value{value.Text("SOUTH"), text, value.Float(0)},
+			Want: value.Float(2),
+		},
+		{
+			Args: []value.Value{value.Float(1), lookupTable(), value.Float(0)},
+			Want: value.ErrNA,
+		},
+		{
+			Args: []value.Value{value.Text("*st"), text, value.Float(0)},
+			Want: value.Float(3),
+		},
+		{
+			Args: []value.Value{value.Text("?orth"), text, value.Float(0)},
+			Want: value.Float(1),
+		},
+		{
+			Args: []value.Value{value.Text("nor~*"), text, value.Float(0)},
+			Want: value.ErrNA,
+		},
+	}
+	testBuiltin(t, Match, tests)
+}
+
+func testIndex(t *testing.T) {
+	row := value.NewArray([][]value.Value{
+		{value.Float(1), value.Float(2), value.Float(3)},
+	})
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{lookupTable(), value.Float(3), value.Float(2)},
+			Want: value.Text("cherry"),
+		},
+		{
+			Args: []value.Value{lookupTable(), value.Float(2)},
+			Want: value.Float(20),
+		},
+		{
+			Args: []value.Value{row, value.Float(3)},
+			Want: value.Float(3),
+		},
+		{
+			Args: []value.Value{lookupTable(), value.Float(5), value.Float(1)},
+			Want: value.ErrRef,
+		},
+		{
+			Args: []value.Value{lookupTable(), value.Float(1), value.Float(4)},
+			Want: value.ErrRef,
+		},
+	}
+	testBuiltin(t, Index, tests)
+}
+
+func testVLookup(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(30), lookupTable(), value.Float(2), value.Boolean(false)},
+			Want: value.Text("cherry"),
+		},
+		{
+			Args: []value.Value{value.Float(35), lookupTable(), value.Float(3)},
+			Want: value.Float(4),
+		},
+		{
+			Args: []value.Value{value.Float(35), lookupTable(), value.Float(3), value.Boolean(false)},
+			Want: value.ErrNA,
+		},
+		{
+			Args: []value.Value{value.Float(5), lookupTable(), value.Float(2)},
+			Want: value.ErrNA,
+		},
+		{
+			Args: []value.Value{value.Float(10), lookupTable(), value.Float(4)},
+			Want: value.ErrRef,
+		},
+		{
+			Args: []value.Value{value.Float(10), lookupTable(), value.Float(0)},
+			Want: value.ErrValue,
+		},
+		{
+			Args: []value.Value{value.Text("ch*"), lookupColumn(value.Text("apple"), value.Text("cherry")), value.Float(1), value.Boolean(false)},
+			Want: value.Text("cherry"),
+		},
+		{
+			Args: []value.Value{value.Text("b?n*"), value.NewArray([][]value.Value{
+				{value.Text("apple"), value.Float(1)},
+				{value.Text("banana"), value.Float(2)},
+			}), value.Float(2), value.Boolean(false)},
+			Want: value.Float(2),
+		},
+	}
+	testBuiltin(t, VLookup, tests)
+}
+
+func testHLookup(t *testing.T) {
+	table := value.NewArray([][]value.Value{
+		{value.Text("q1"), value.Text("q2"), value.Text("q3")},
+		{value.Float(100), value.Float(200), value.Float(300)},
+	})
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Text("Q2"), table, value.Float(2), value.Boolean(false)},
+			Want: value.Float(200),
+		},
+		{
+			Args: []value.Value{value.Text("q4"), table, value.Float(2), value.Boolean(false)},
+			Want: value.ErrNA,
+		},
+		{
+			Args: []value.Value{value.Text("q1"), table, value.Float(3), value.Boolean(false)},
+			Want: value.ErrRef,
+		},
+	}
+	testBuiltin(t, HLookup, tests)
+}
+
+func testXLookup(t *testing.T) {
+	var (
+		keys   = lookupColumn(value.Float(30), value.Float(10), value.Float(20), value.Float(10))
+		result = lookupColumn(value.Text("c"), value.Text("a"), value.Text("b"), value.Text("d"))
+	)
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(20), keys, result},
+			Want: value.Text("b"),
+		},
+		{
+			Args: []value.Value{value.Float(25), keys, result},
+			Want: value.ErrNA,
+		},
+		{
+			Args: []value.Value{value.Float(25), keys, result, value.Text("none")},
+			Want: value.Text("none"),
+		},
+		{
+			Args: []value.Value{value.Float(25), keys, result, value.Blank{}, value.Float(-1)},
+			Want: value.Text("b"),
+		},
+		{
+			Args: []value.Value{value.Float(25), keys, result, value.Blank{}, value.Float(1)},
+			Want: value.Text("c"),
+		},
+		{
+			Args: []value.Value{value.Float(10), keys, result, value.Blank{}, value.Float(0), value.Float(-1)},
+			Want: value.Text("d"),
+		},
+		{
+			Args: []value.Value{value.Float(10), keys, result, value.Blank{}, value.Float(2)},
+			Want: value.ErrValue,
+		},
+	}
+	testBuiltin(t, XLookup, tests)
+}
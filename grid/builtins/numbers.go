@@ -2,10 +2,12 @@ package builtins
 
 import (
 	"math"
+	"strconv"
 
 	"github.com/midbel/dockit/grid/calc"
 	"github.com/midbel/dockit/grid/criteria"
 	"github.com/midbel/dockit/internal/slx"
+	"github.com/midbel/dockit/layout"
 	"github.com/midbel/dockit/value"
 )
 
@@ -78,14 +80,11 @@ var minBuiltin = Builtin{
 }
 
 func Min(args []value.Value) value.Value {
-	if err := value.HasErrors(args...); err != nil {
+	arr, err := numericValues(args)
+	if err != nil {
 		return err
 	}
-	var (
-		arr = asFloatArray(args)
-		ret = calc.Min(arr)
-	)
-	return value.Float(ret)
+	return value.Float(calc.Min(arr))
 }
 
 var maxBuiltin = Builtin{
@@ -100,14 +99,11 @@ var maxBuiltin = Builtin{
 }
 
 func Max(args []value.Value) value.Value {
-	if err := value.HasErrors(args...); err != nil {
+	arr, err := numericValues(args)
+	if err != nil {
 		return err
 	}
-	var (
-		arr = asFloatArray(args)
-		ret = calc.Max(arr)
-	)
-	return value.Float(ret)
+	return value.Float(calc.Max(arr))
 }
 
 var sumBuiltin = Builtin{
@@ -122,14 +118,11 @@ var sumBuiltin = Builtin{
 }
 
 func Sum(args []value.Value) value.Value {
-	if err := value.HasErrors(args...); err != nil {
+	arr, err := numericValues(args)
+	if err != nil {
 		return err
 	}
-	var (
-		arr = asFloatArray(args)
-		ret = calc.Sum(arr)
-	)
-	return value.Float(ret)
+	return value.Float(calc.Sum(arr))
 }
 
 var sumifBuiltin = Builtin{
@@ -137,28 +130,42 @@ var sumifBuiltin = Builtin{
 	Desc:     "Sums values that match a condition",
 	Category: "miscel",
 	Params: []Param{
-		Array("value", "", value.TypeAny),
-		Scalar("predicate", "", value.TypeText),
+		Array("range", "", value.TypeAny),
+		Scalar("criteria", "", value.TypeAny),
+		Opt(Array("sum", "values to sum instead of the ones of range", value.TypeAny)),
 	},
 	Func:    SumIf,
 	Dialect: MainDialect,
 }
 
 func SumIf(args []value.Value) value.Value {
-	if err := value.HasErrors(args...); err != nil {
-		return err
+	target := args[0]
+	if len(args) >= 3 {
+		target = args[2]
 	}
-	f, err := criteria.New(asString(args[1]))
+	return SumIfs(append([]value.Value{target}, args[:2]...))
+}
+
+var sumifsBuiltin = Builtin{
+	Name:     "sumifs",
+	Desc:     "Sums values whose ranges match all conditions",
+	Category: "miscel",
+	Params: []Param{
+		Array("sum", "", value.TypeAny),
+		Array("range", "", value.TypeAny),
+		Scalar("criteria", "", value.TypeAny),
+		Var(ScalarArray("more", "other ranges and criteria", value.TypeAny)),
+	},
+	Func:    SumIfs,
+	Dialect: MainDialect,
+}
+
+func SumIfs(args []value.Value) value.Value {
+	list, err := criteriaValues(args[0], args[1:])
 	if err != nil {
-		return value.ErrValue
+		return err
 	}
-	total := value.Reduce[float64](args, 0, func(acc float64, v value.Value) float64 {
-		if ok := f.Keep(v); ok {
-			acc += asFloat(v)
-		}
-		return acc
-	})
-	return value.Float(total)
+	return value.Float(calc.Sum(list))
 }
 
 var avgBuiltin = Builtin{
@@ -174,118 +181,224 @@ var avgBuiltin = Builtin{
 }
 
 func Avg(args []value.Value) value.Value {
-	if err := value.HasErrors(args...); err != nil {
+	arr, err := numericValues(args)
+	if err != nil {
 		return err
 	}
-	var (
-		arr = asFloatArray(args)
-		ret = calc.Avg(arr)
-	)
-	return value.Float(ret)
+	if len(arr) == 0 {
+		return value.ErrDiv0
+	}
+	return value.Float(calc.Avg(arr))
 }
 
 var avgifBuiltin = Builtin{
 	Name:     "averageif",
-	Desc:     "",
+	Desc:     "Returns the average of the values that match a condition",
 	Category: "miscel",
 	Params: []Param{
-		Array("value", "", value.TypeAny),
-		Scalar("predicate", "", value.TypeText),
+		Array("range", "", value.TypeAny),
+		Scalar("criteria", "", value.TypeAny),
+		Opt(Array("average", "values to average instead of the ones of range", value.TypeAny)),
 	},
 	Func:    AvgIf,
 	Dialect: MainDialect,
 }
 
 func AvgIf(args []value.Value) value.Value {
-	if err := value.HasErrors(args...); err != nil {
-		return err
+	target := args[0]
+	if len(args) >= 3 {
+		target = args[2]
 	}
-	f, err := criteria.New(asString(args[1]))
+	return AvgIfs(append([]value.Value{target}, args[:2]...))
+}
+
+var avgifsBuiltin = Builtin{
+	Name:     "averageifs",
+	Desc:     "Returns the average of the values whose ranges match all conditions",
+	Category: "miscel",
+	Params: []Param{
+		Array("average", "", value.TypeAny),
+		Array("range", "", value.TypeAny),
+		Scalar("criteria", "", value.TypeAny),
+		Var(ScalarArray("more", "other ranges and criteria", value.TypeAny)),
+	},
+	Func:    AvgIfs,
+	Dialect: MainDialect,
+}
+
+func AvgIfs(args []value.Value) value.Value {
+	list, err := criteriaValues(args[0], args[1:])
 	if err != nil {
-		return value.ErrValue
+		return err
 	}
-	vs := value.Collect[float64](args, func(v value.Value) (float64, bool) {
-		return asFloat(v), f.Keep(v)
-	})
-	ret := calc.Avg(vs)
-	return value.Float(ret)
+	if len(list) == 0 {
+		return value.ErrDiv0
+	}
+	return value.Float(calc.Avg(list))
 }
 
 var stdevBuiltin = Builtin{
 	Name:     "stdev",
-	Desc:     "",
+	Desc:     "Returns the standard deviation of a sample",
 	Category: "math",
-	Func:     Stdev,
-	Dialect:  MainDialect,
+	Params: []Param{
+		Var(ScalarArray("number", "", value.TypeNumber)),
+	},
+	Func:    Stdev,
+	Dialect: MainDialect,
 }
 
 func Stdev(args []value.Value) value.Value {
-	if err := value.HasErrors(args...); err != nil {
+	arr, err := numericValues(args)
+	if err != nil {
 		return err
 	}
-	var (
-		arr = asFloatArray(args)
-		ret = calc.Stdev(arr)
-	)
-	return value.Float(ret)
+	if len(arr) < 2 {
+		return value.ErrDiv0
+	}
+	return value.Float(calc.Stdev(arr))
 }
 
 var varianceBuiltin = Builtin{
 	Name:     "var",
 	Alias:    slx.Make("variance"),
-	Desc:     "",
+	Desc:     "Returns the variance of a sample",
 	Category: "math",
-	Func:     Variance,
-	Dialect:  MainDialect,
+	Params: []Param{
+		Var(ScalarArray("number", "", value.TypeNumber)),
+	},
+	Func:    Variance,
+	Dialect: MainDialect,
 }
 
 func Variance(args []value.Value) value.Value {
-	if err := value.HasErrors(args...); err != nil {
+	arr, err := numericValues(args)
+	if err != nil {
 		return err
 	}
-	var (
-		arr = asFloatArray(args)
-		ret = calc.Var(arr)
-	)
-	return value.Float(ret)
+	if len(arr) < 2 {
+		return value.ErrDiv0
+	}
+	return value.Float(calc.Var(arr))
 }
 
 var modeBuiltin = Builtin{
 	Name:     "mode",
-	Desc:     "",
+	Desc:     "Returns the most frequent value",
 	Category: "math",
-	Func:     Mode,
-	Dialect:  MainDialect,
+	Params: []Param{
+		Var(ScalarArray("number", "", value.TypeNumber)),
+	},
+	Func:    Mode,
+	Dialect: MainDialect,
 }
 
 func Mode(args []value.Value) value.Value {
-	if err := value.HasErrors(args...); err != nil {
+	arr, err := numericValues(args)
+	if err != nil {
 		return err
 	}
-	var (
-		arr = asFloatArray(args)
-		ret = calc.Mode(arr)
-	)
+	ret, ok := calc.Mode(arr)
+	if !ok {
+		return value.ErrNA
+	}
 	return value.Float(ret)
 }
 
 var medianBuiltin = Builtin{
 	Name:     "median",
-	Desc:     "",
+	Desc:     "Returns the median of the given values",
 	Category: "math",
-	Func:     Median,
-	Dialect:  MainDialect,
+	Params: []Param{
+		Var(ScalarArray("number", "", value.TypeNumber)),
+	},
+	Func:    Median,
+	Dialect: MainDialect,
 }
 
 func Median(args []value.Value) value.Value {
-	if err := value.HasErrors(args...); err != nil {
+	arr, err := numericValues(args)
+	if err != nil {
 		return err
 	}
-	var (
-		arr = asFloatArray(args)
-		ret = calc.Median(arr)
-	)
-	return value.Float(ret)
+	if len(arr) == 0 {
+		return value.ErrNum
+	}
+	return value.Float(calc.Median(arr))
+}
+
+var stdevpBuiltin = Builtin{
+	Name:     "stdevp",
+	Desc:     "Returns the standard deviation of a whole population",
+	Category: "math",
+	Params: []Param{
+		Var(ScalarArray("number", "", value.TypeNumber)),
+	},
+	Func:    StdevP,
+	Dialect: MainDialect,
+}
+
+func StdevP(args []value.Value) value.Value {
+	arr, err := numericValues(args)
+	if err != nil {
+		return err
+	}
+	if len(arr) == 0 {
+		return value.ErrDiv0
+	}
+	return value.Float(calc.StdevP(arr))
+}
+
+var varpBuiltin = Builtin{
+	Name:     "varp",
+	Desc:     "Returns the variance of a whole population",
+	Category: "math",
+	Params: []Param{
+		Var(ScalarArray("number", "", value.TypeNumber)),
+	},
+	Func:    VarianceP,
+	Dialect: MainDialect,
+}
+
+func VarianceP(args []value.Value) value.Value {
+	arr, err := numericValues(args)
+	if err != nil {
+		return err
+	}
+	if len(arr) == 0 {
+		return value.ErrDiv0
+	}
+	return value.Float(calc.VarP(arr))
+}
+
+var percentileBuiltin = Builtin{
+	Name:     "percentile",
+	Desc:     "Returns the k-th percentile of the given values",
+	Category: "math",
+	Params: []Param{
+		ScalarArray("number", "", value.TypeNumber),
+		Scalar("k", "percentile between 0 and 1", value.TypeNumber),
+	},
+	Func:    Percentile,
+	Dialect: MainDialect,
+}
+
+func Percentile(args []value.Value) value.Value {
+	if err := value.HasErrors(args[1]); err != nil {
+		return err
+	}
+	arr, err := numericValues(args[:1])
+	if err != nil {
+		return err
+	}
+	k, err1 := numericValue(args[1])
+	if err1 != nil {
+		return value.ErrValue
+	}
+	if len(arr) == 0 || k < 0 || k > 1 {
+		return value.ErrNum
+	}
+	return value.Float(calc.Percentile(arr, k))
 }
 
 var countBuiltin = Builtin{
@@ -300,9 +413,20 @@ var countBuiltin = Builtin{
 }
 
 func Count(args []value.Value) value.Value {
-	count := value.Reduce[float64](args, 0, func(acc float64, v value.Value) float64 {
-		return acc + 1
-	})
+	var count int
+	for _, a := range args {
+		if value.IsScalar(a) {
+			if _, err := numericValue(a); err == nil {
+				count++
+			}
+			continue
+		}
+		value.Each([]value.Value{a}, func(v value.Value) {
+			if isNumeric(v) {
+				count++
+			}
+		})
+	}
 	return value.Float(count)
 }
 
@@ -311,28 +435,108 @@ var countifBuiltin = Builtin{
 	Desc:     "Counts values that match a condition",
 	Category: "miscel",
 	Params: []Param{
-		Array("value", "", value.TypeAny),
-		Scalar("predicate", "", value.TypeText),
+		Array("range", "", value.TypeAny),
+		Scalar("criteria", "", value.TypeAny),
 	},
 	Func:    CountIf,
 	Dialect: MainDialect,
 }
 
 func CountIf(args []value.Value) value.Value {
-	if err := value.HasErrors(args...); err != nil {
+	return CountIfs(args)
+}
+
+var countifsBuiltin = Builtin{
+	Name:     "countifs",
+	Desc:     "Counts the positions whose ranges match all conditions",
+	Category: "miscel",
+	Params: []Param{
+		Array("range", "", value.TypeAny),
+		Scalar("criteria", "", value.TypeAny),
+		Var(ScalarArray("more", "other ranges and criteria", value.TypeAny)),
+	},
+	Func:    CountIfs,
+	Dialect: MainDialect,
+}
+
+func CountIfs(args []value.Value) value.Value {
+	pos, err := matchCriteria(args)
+	if err != nil {
 		return err
 	}
-	f, err := criteria.New(asString(args[1]))
+	return value.Float(len(pos))
+}
+
+// matchCriteria gives the positions in the ranges where the values of every
+// range match their criteria. Ranges and criteria are given by pairs and all
+// ranges have the same size.
+func matchCriteria(args []value.Value) ([][2]int, value.Value) {
+	if len(args) == 0 || len(args)%2 != 0 {
+		return nil, value.ErrValue
+	}
+	var (
+		dim     layout.Dimension
+		ranges  []value.ArrayValue
+		filters []criteria.Filter
+	)
+	for i := 0; i < len(args); i += 2 {
+		if err := value.HasErrors(args[i], args[i+1]); err != nil {
+			return nil, err
+		}
+		arr := arrayOf(args[i])
+		if i == 0 {
+			dim = arr.Dimension()
+		} else if arr.Dimension() != dim {
+			return nil, value.ErrValue
+		}
+		f, err := criteria.FromValue(args[i+1])
+		if err != nil {
+			return nil, value.ErrValue
+		}
+		ranges = append(ranges, arr)
+		filters = append(filters, f)
+	}
+	var list [][2]int
+	for row := range int(dim.Lines) {
+		for col := range int(dim.Columns) {
+			keep := true
+			for i := range ranges {
+				if keep = filters[i].Keep(ranges[i].At(row, col)); !keep {
+					break
+				}
+			}
+			if keep {
+				list = append(list, [2]int{row, col})
+			}
+		}
+	}
+	return list, nil
+}
+
+// criteriaValues gives the numbers of target at the positions where the
+// ranges match their criteria. Values that are not numbers are skipped.
+func criteriaValues(target value.Value, args []value.Value) ([]float64, value.Value) {
+	if value.IsError(target) {
+		return nil, target
+	}
+	pos, err := matchCriteria(args)
 	if err != nil {
-		return value.ErrValue
+		return nil, err
 	}
-	count := value.Reduce[float64](args, 0, func(acc float64, v value.Value) float64 {
-		if ok := f.Keep(v); ok {
-			acc += 1
+	var (
+		arr  = arrayOf(target)
+		list []float64
+	)
+	for _, p := range pos {
+		v := arr.At(p[0], p[1])
+		if value.IsError(v) {
+			return nil, v
 		}
-		return acc
-	})
-	return value.Float(count)
+		if isNumeric(v) {
+			list = append(list, asFloat(v))
+		}
+	}
+	return list, nil
 }
 
 var countaBuiltin = Builtin{
@@ -342,13 +546,13 @@ var countaBuiltin = Builtin{
 	Params: []Param{
 		Var(ScalarArray("value", "", value.TypeAny)),
 	},
-	Func:    Count,
+	Func:    Counta,
 	Dialect: MainDialect,
 }
 
 func Counta(args []value.Value) value.Value {
 	count := value.Reduce[float64](args, 0, func(acc float64, v value.Value) float64 {
-		if value.IsBlank(v) || asString(v) == "" {
+		if value.IsBlank(v) {
 			return acc
 		}
 		return acc + 1
@@ -358,69 +562,126 @@ func Counta(args []value.Value) value.Value {
 
 var roundBuiltin = Builtin{
 	Name:     "round",
-	Desc:     "Rounds a number to the nearest integer",
+	Desc:     "Rounds a number to a given number of digits",
 	Category: "math",
 	Params: []Param{
 		Scalar("number", "", value.TypeNumber),
+		Opt(Scalar("digits", "number of decimal digits, negative to round to the left of the decimal point", value.TypeNumber)),
 	},
 	Func:    Round,
 	Dialect: MainDialect,
 }
 
 func Round(args []value.Value) value.Value {
-	if err := value.HasErrors(args[0]); err != nil {
-		return err
-	}
-	f := asFloat(args[0])
-	ret := math.Round(f)
-	return value.Float(ret)
+	return roundDigits(args, math.Round)
 }
 
-var floorBuiltin = Builtin{
+var roundDownBuiltin = Builtin{
 	Name:     "rounddown",
-	Alias:    slx.Make("floor"),
-	Desc:     "",
+	Desc:     "Rounds a number toward zero",
+	Category: "math",
+	Params: []Param{
+		Scalar("number", "", value.TypeNumber),
+		Opt(Scalar("digits", "number of decimal digits, negative to round to the left of the decimal point", value.TypeNumber)),
+	},
+	Func:    RoundDown,
+	Dialect: MainDialect,
+}
+
+func RoundDown(args []value.Value) value.Value {
+	return roundDigits(args, math.Trunc)
+}
+
+var roundUpBuiltin = Builtin{
+	Name:     "roundup",
+	Desc:     "Rounds a number away from zero",
+	Category: "math",
+	Params: []Param{
+		Scalar("number", "", value.TypeNumber),
+		Opt(Scalar("digits", "number of decimal digits, negative to round to the left of the decimal point", value.TypeNumber)),
+	},
+	Func:    RoundUp,
+	Dialect: MainDialect,
+}
+
+func RoundUp(args []value.Value) value.Value {
+	return roundDigits(args, func(f float64) float64 {
+		if f < 0 {
+			return math.Floor(f)
+		}
+		return math.Ceil(f)
+	})
+}
+
+var floorBuiltin = Builtin{
+	Name:     "floor",
+	Desc:     "Rounds a number down to the nearest multiple of significance",
 	Category: "math",
 	Params: []Param{
 		Scalar("number", "", value.TypeNumber),
+		Opt(Scalar("significance", "multiple to round to, 1 by default", value.TypeNumber)),
 	},
 	Func:    Floor,
 	Dialect: MainDialect,
 }
 
 func Floor(args []value.Value) value.Value {
-	if err := value.HasErrors(args[0]); err != nil {
+	list, err := numberArgs(args)
+	if err != nil {
 		return err
 	}
-	f := asFloat(args[0])
-	ret := math.Floor(f)
+	f, sig := list[0], 1.0
+	if len(list) > 1 {
+		sig = list[1]
+	}
+	if sig == 0 {
+		if f == 0 {
+			return value.Float(0)
+		}
+		return value.ErrDiv0
+	}
+	if f > 0 && sig < 0 {
+		return value.ErrNum
+	}
+	ret := math.Floor(normalizeFloat(f/sig)) * sig
 	return value.Float(ret)
 }
 
 var ceilBuiltin = Builtin{
-	Name:     "roundup",
+	Name:     "ceiling",
 	Alias:    slx.Make("ceil"),
-	Desc:     "",
+	Desc:     "Rounds a number up to the nearest multiple of significance",
 	Category: "math",
 	Params: []Param{
 		Scalar("number", "", value.TypeNumber),
+		Opt(Scalar("significance", "multiple to round to, 1 by default", value.TypeNumber)),
 	},
 	Func:    Ceil,
 	Dialect: MainDialect,
 }
 
 func Ceil(args []value.Value) value.Value {
-	if err := value.HasErrors(args[0]); err != nil {
+	list, err := numberArgs(args)
+	if err != nil {
 		return err
 	}
-	f := asFloat(args[0])
-	ret := math.Ceil(f)
+	f, sig := list[0], 1.0
+	if len(list) > 1 {
+		sig = list[1]
+	}
+	if sig == 0 {
+		return value.Float(0)
+	}
+	if f > 0 && sig < 0 {
+		return value.ErrNum
+	}
+	ret := math.Ceil(normalizeFloat(f/sig)) * sig
 	return value.Float(ret)
 }
 
 var sqrtBuiltin = Builtin{
 	Name:     "sqrt",
-	Desc:     "",
+	Desc:     "Returns the square root of a number",
 	Category: "math",
 	Params: []Param{
 		Scalar("number", "", value.TypeNumber),
@@ -430,20 +691,20 @@ var sqrtBuiltin = Builtin{
 }
 
 func Sqrt(args []value.Value) value.Value {
-	if err := value.HasErrors(args[0]); err != nil {
+	list, err := numberArgs(args)
+	if err != nil {
 		return err
 	}
-	f := asFloat(args[0])
-	if f < 0 {
-		return value.ErrValue
+	if list[0] < 0 {
+		return value.ErrNum
 	}
-	ret := math.Sqrt(f)
+	ret := math.Sqrt(list[0])
 	return value.Float(ret)
 }
 
 var absBuiltin = Builtin{
 	Name:     "abs",
-	Desc:     "",
+	Desc:     "Returns the absolute value of a number",
 	Category: "math",
 	Params: []Param{
 		Scalar("number", "", value.TypeNumber),
@@ -453,38 +714,39 @@ var absBuiltin = Builtin{
 }
 
 func Abs(args []value.Value) value.Value {
-	if err := value.HasErrors(args[0]); err != nil {
+	list, err := numberArgs(args)
+	if err != nil {
 		return err
 	}
-	f := asFloat(args[0])
-	ret := math.Abs(f)
+	ret := math.Abs(list[0])
 	return value.Float(ret)
 }
 
 var modBuiltin = Builtin{
 	Name:     "mod",
-	Desc:     "Returns the remainder after division",
+	Desc:     "Returns the remainder after division with the sign of the divisor",
 	Category: "math",
 	Params: []Param{
 		Scalar("number", "", value.TypeNumber),
-		Scalar("number", "", value.TypeNumber),
+		Scalar("divisor", "", value.TypeNumber),
 	},
 	Func:    Mod,
 	Dialect: MainDialect,
 }
 
 func Mod(args []value.Value) value.Value {
-	if err := value.HasErrors(args[:2]...); err != nil {
+	list, err := numberArgs(args)
+	if err != nil {
 		return err
 	}
-	var (
-		f = asFloat(args[0])
-		d = asFloat(args[1])
-	)
+	f, d := list[0], list[1]
 	if d == 0 {
 		return value.ErrDiv0
 	}
 	ret := math.Mod(f, d)
+	if ret != 0 && (ret < 0) != (d < 0) {
+		ret += d
+	}
 	return value.Float(ret)
 }
 
@@ -495,27 +757,53 @@ var powBuiltin = Builtin{
 	Category: "math",
 	Params: []Param{
 		Scalar("number", "", value.TypeNumber),
-		Scalar("number", "", value.TypeNumber),
+		Scalar("power", "", value.TypeNumber),
 	},
 	Func:    Pow,
 	Dialect: MainDialect,
 }
 
 func Pow(args []value.Value) value.Value {
-	if err := value.HasErrors(args[:2]...); err != nil {
+	list, err := numberArgs(args)
+	if err != nil {
 		return err
 	}
-	var (
-		f = asFloat(args[0])
-		e = asFloat(args[1])
-	)
-	ret := math.Pow(f, e)
-	return value.Float(ret)
+	f, e := list[0], list[1]
+	if f == 0 && e < 0 {
+		return value.ErrDiv0
+	}
+	return checkFloat(math.Pow(f, e))
+}
+
+var productBuiltin = Builtin{
+	Name:     "product",
+	Desc:     "Multiplies all the numbers given",
+	Category: "math",
+	Params: []Param{
+		Var(ScalarArray("number", "", value.TypeNumber)),
+	},
+	Func:    Product,
+	Dialect: MainDialect,
+}
+
+func Product(args []value.Value) value.Value {
+	list, err := numericValues(args)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return value.Float(0)
+	}
+	ret := 1.0
+	for _, f := range list {
+		ret *= f
+	}
+	return checkFloat(ret)
 }
 
 var intBuiltin = Builtin{
 	Name:     "int",
-	Desc:     "",
+	Desc:     "Rounds a number down to the nearest integer",
 	Category: "math",
 	Params: []Param{
 		Scalar("number", "", value.TypeNumber),
@@ -525,11 +813,11 @@ var intBuiltin = Builtin{
 }
 
 func Int(args []value.Value) value.Value {
-	if err := value.HasErrors(args[0]); err != nil {
+	list, err := numberArgs(args)
+	if err != nil {
 		return err
 	}
-	f := asFloat(args[0])
-	return value.Float(int(f))
+	return value.Float(math.Floor(list[0]))
 }
 
 var randBuiltin = Builtin{
@@ -539,6 +827,7 @@ var randBuiltin = Builtin{
 	Params:   []Param{},
 	Func:     Rand,
 	Dialect:  MainDialect,
+	Volatile: true,
 }
 
 func Rand(args []value.Value) value.Value {
@@ -728,26 +1017,56 @@ func Pi(args []value.Value) value.Value {
 	return value.Float(pi)
 }
 
+var logBuiltin = Builtin{
+	Name:     "log",
+	Desc:     "Returns the logarithm of a number to a given base",
+	Category: "math",
+	Params: []Param{
+		Scalar("number", "", value.TypeNumber),
+		Opt(Scalar("base", "base of the logarithm, 10 by default", value.TypeNumber)),
+	},
+	Func:    Log,
+	Dialect: MainDialect,
+}
+
+func Log(args []value.Value) value.Value {
+	list, err := numberArgs(args)
+	if err != nil {
+		return err
+	}
+	f, base := list[0], 10.0
+	if len(list) > 1 {
+		base = list[1]
+	}
+	if f <= 0 || base <= 0 {
+		return value.ErrNum
+	}
+	if base == 1 {
+		return value.ErrDiv0
+	}
+	return value.Float(math.Log(f) / math.Log(base))
+}
+
 var log10Builtin = Builtin{
 	Name:     "log10",
 	Desc:     "Returns the base-10 logarithm of a number",
 	Category: "math",
 	Params: []Param{
-		Var(ScalarArray("number", "", value.TypeNumber)),
+		Scalar("number", "", value.TypeNumber),
 	},
 	Func:    Log10,
 	Dialect: MainDialect,
 }
 
 func Log10(args []value.Value) value.Value {
-	if err := value.HasErrors(args...); err != nil {
+	list, err := numberArgs(args)
+	if err != nil {
 		return err
 	}
-	var (
-		f = asFloat(args[0])
-		r = math.Log10(f)
-	)
-	return value.Float(r)
+	if list[0] <= 0 {
+		return value.ErrNum
+	}
+	return value.Float(math.Log10(list[0]))
 }
 
 var lnBuiltin = Builtin{
@@ -755,43 +1074,40 @@ var lnBuiltin = Builtin{
 	Desc:     "Returns the natural logarithm of a number",
 	Category: "math",
 	Params: []Param{
-		Var(ScalarArray("number", "", value.TypeNumber)),
+		Scalar("number", "", value.TypeNumber),
 	},
 	Func:    Ln,
 	Dialect: MainDialect,
 }
 
 func Ln(args []value.Value) value.Value {
-	if err := value.HasErrors(args...); err != nil {
+	list, err := numberArgs(args)
+	if err != nil {
 		return err
 	}
-	var (
-		f = asFloat(args[0])
-		r = math.Log(f)
-	)
-	return value.Float(r)
+	if list[0] <= 0 {
+		return value.ErrNum
+	}
+	return value.Float(math.Log(list[0]))
 }
 
 var expBuiltin = Builtin{
 	Name:     "exp",
-	Desc:     "",
+	Desc:     "Returns e raised to the power of a number",
 	Category: "math",
 	Params: []Param{
-		Var(ScalarArray("number", "", value.TypeNumber)),
+		Scalar("number", "", value.TypeNumber),
 	},
 	Func:    Exp,
 	Dialect: MainDialect,
 }
 
 func Exp(args []value.Value) value.Value {
-	if err := value.HasErrors(args...); err != nil {
+	list, err := numberArgs(args)
+	if err != nil {
 		return err
 	}
-	var (
-		f = asFloat(args[0])
-		r = math.Exp(f)
-	)
-	return value.Float(r)
+	return checkFloat(math.Exp(list[0]))
 }
 
 var eBuiltin = Builtin{
@@ -807,6 +1123,115 @@ func E(args []value.Value) value.Value {
 	return value.Float(e)
 }
 
+// numericValues gives the numbers of the arguments the way spreadsheets do:
+// values given directly are converted to numbers while the blanks, the
+// booleans and the texts that are not numbers found in ranges are skipped.
+func numericValues(args []value.Value) ([]float64, value.Value) {
+	var list []float64
+	for _, a := range args {
+		if value.IsError(a) {
+			return nil, a
+		}
+		if value.IsBlank(a) {
+			continue
+		}
+		if value.IsScalar(a) {
+			f, err := numericValue(a)
+			if err != nil {
+				return nil, value.ErrValue
+			}
+			list = append(list, f)
+			continue
+		}
+		err := value.Each([]value.Value{a}, func(v value.Value) {
+			if isNumeric(v) {
+				list = append(list, asFloat(v))
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func numericValue(v value.Value) (float64, error) {
+	f, err := value.CastToFloat(v)
+	return float64(f), err
+}
+
+// isNumeric reports whether a value of a range is counted as a number. Texts
+// are counted when they are numbers since values imported from text files are
+// kept as texts.
+func isNumeric(v value.Value) bool {
+	switch v.Type() {
+	case value.TypeNumber, value.TypeDate:
+		return true
+	case value.TypeText:
+		_, err := numericValue(v)
+		return err == nil
+	default:
+		return false
+	}
+}
+
+// numberArgs converts the arguments given to a function expecting numbers.
+// Blanks are zero and texts that are not numbers give #VALUE!.
+func numberArgs(args []value.Value) ([]float64, value.Value) {
+	list := make([]float64, 0, len(args))
+	for _, a := range args {
+		if value.IsError(a) {
+			return nil, a
+		}
+		if value.IsBlank(a) {
+			list = append(list, 0)
+			continue
+		}
+		f, err := numericValue(a)
+		if err != nil {
+			return nil, value.ErrValue
+		}
+		list = append(list, f)
+	}
+	return list, nil
+}
+
+// roundDigits rounds the first argument to the number of digits given by the
+// second one with the rounding function fn.
+func roundDigits(args []value.Value, fn func(float64) float64) value.Value {
+	list, err := numberArgs(args)
+	if err != nil {
+		return err
+	}
+	f, digits := list[0], 0.0
+	if len(list) > 1 {
+		digits = math.Trunc(list[1])
+	}
+	scale := math.Pow(10, math.Abs(digits))
+	if digits < 0 {
+		return value.Float(fn(normalizeFloat(f/scale)) * scale)
+	}
+	return value.Float(fn(normalizeFloat(f*scale)) / scale)
+}
+
+// normalizeFloat keeps the 15 significant digits spreadsheets work with so
+// that, eg, 2.675*100 is rounded as 267.5 and not as 267.49999999999997.
+func normalizeFloat(f float64) float64 {
+	n, err := strconv.ParseFloat(strconv.FormatFloat(f, 'g', 15, 64), 64)
+	if err != nil {
+		return f
+	}
+	return n
+}
+
+// checkFloat gives #NUM! for results that can not be represented.
+func checkFloat(f float64) value.Value {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return value.ErrNum
+	}
+	return value.Float(f)
+}
+
 var numberBuiltins = []Builtin{
 	signBuiltin,
 	isOddBuiltin,
@@ -815,22 +1240,31 @@ var numberBuiltins = []Builtin{
 	maxBuiltin,
 	sumBuiltin,
 	sumifBuiltin,
+	sumifsBuiltin,
 	avgBuiltin,
 	avgifBuiltin,
+	avgifsBuiltin,
 	stdevBuiltin,
 	varianceBuiltin,
+	stdevpBuiltin,
+	varpBuiltin,
 	modeBuiltin,
 	medianBuiltin,
+	percentileBuiltin,
 	countBuiltin,
 	countifBuiltin,
+	countifsBuiltin,
 	countaBuiltin,
 	roundBuiltin,
+	roundDownBuiltin,
+	roundUpBuiltin,
 	floorBuiltin,
 	ceilBuiltin,
 	sqrtBuiltin,
 	absBuiltin,
 	modBuiltin,
 	powBuiltin,
+	productBuiltin,
 	intBuiltin,
 	randBuiltin,
 	sinBuiltin,
@@ -842,6 +1276,7 @@ var numberBuiltins = []Builtin{
 	degBuiltin,
 	radBuiltin,
 	piBuiltin,
+	logBuiltin,
 	log10Builtin,
 	lnBuiltin,
 	expBuiltin,
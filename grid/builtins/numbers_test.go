@@ -1,6 +1,7 @@
 package builtins
 
 import (
+	"math"
 	"testing"
 
 	"github.com/midbel/dockit/value"
@@ -9,6 +10,188 @@ import (
 func TestNumbers(t *testing.T) {
 	t.Run("isOdd", testIsOdd)
 	t.Run("isEven", testIsEven)
+	t.Run("sum", testSum)
+	t.Run("average", testAverage)
+	t.Run("median", testMedian)
+	t.Run("mode", testMode)
+	t.Run("stdev", testStdev)
+	t.Run("var", testVariance)
+	t.Run("percentile", testPercentile)
+	t.Run("count", testCount)
+	t.Run("countif", testCountIf)
+	t.Run("sumif", testSumIf)
+	t.Run("round", testRound)
+	t.Run("ceiling", testCeiling)
+	t.Run("floor", testFloor)
+	t.Run("mod", testMod)
+	t.Run("product", testProduct)
+	t.Run("log", testLog)
+}
+
+func statsSample() value.Value {
+	return value.NewArray([][]value.Value{
+		{value.Float(2), value.Float(4), value.Text("n/a")},
+		{value.Float(4), value.Float(4), value.Blank{}},
+		{value.Float(5), value.Float(5), value.Boolean(true)},
+		{value.Float(7), value.Float(9), value.Text("")},
+	})
+}
+
+func testSum(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{statsSample()},
+			Want: value.Float(40),
+		},
+		{
+			Args: []value.Value{statsSample(), value.Text("2"), value.Boolean(true)},
+			Want: value.Float(43),
+		},
+		{
+			Args: []value.Value{value.Float(1), value.Text("foo")},
+			Want: value.ErrValue,
+		},
+		{
+			Args: []value.Value{value.NewArray([][]value.Value{{value.Float(1), value.ErrNA}})},
+			Want: value.ErrNA,
+		},
+	}
+	testBuiltin(t, Sum, tests)
+}
+
+func testAverage(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{statsSample()},
+			Want: value.Float(5),
+		},
+		{
+			Args: []value.Value{value.NewArray([][]value.Value{{value.Text("foo")}})},
+			Want: value.ErrDiv0,
+		},
+	}
+	testBuiltin(t, Avg, tests)
+}
+
+func testMedian(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{statsSample()},
+			Want: value.Float(4.5),
+		},
+		{
+			Args: []value.Value{value.Float(3), value.Float(1), value.Float(2)},
+			Want: value.Float(2),
+		},
+		{
+			Args: []value.Value{value.NewArray([][]value.Value{{value.Blank{}}})},
+			Want: value.ErrNum,
+		},
+	}
+	testBuiltin(t, Median, tests)
+}
+
+func testMode(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{statsSample()},
+			Want: value.Float(4),
+		},
+		{
+			Args: []value.Value{value.Float(3), value.Float(1), value.Float(1), value.Float(3)},
+			Want: value.Float(3),
+		},
+		{
+			Args: []value.Value{value.Float(3), value.Float(1), value.Float(2)},
+			Want: value.ErrNA,
+		},
+	}
+	testBuiltin(t, Mode, tests)
+}
+
+func testStdev(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{statsSample()},
+			Want: value.Float(math.Sqrt(32.0 / 7)),
+		},
+		{
+			Args: []value.Value{value.Float(1)},
+			Want: value.ErrDiv0,
+		},
+	}
+	testBuiltin(t, Stdev, tests)
+	tests = []BuiltinTestCase{
+		{
+			Args: []value.Value{statsSample()},
+			Want: value.Float(2),
+		},
+	}
+	testBuiltin(t, StdevP, tests)
+}
+
+func testVariance(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{statsSample()},
+			Want: value.Float(32.0 / 7),
+		},
+	}
+	testBuiltin(t, Variance, tests)
+	tests = []BuiltinTestCase{
+		{
+			Args: []value.Value{statsSample()},
+			Want: value.Float(4),
+		},
+	}
+	testBuiltin(t, VarianceP, tests)
+}
+
+func testPercentile(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{statsSample(), value.Float(0)},
+			Want: value.Float(2),
+		},
+		{
+			Args: []value.Value{statsSample(), value.Float(1)},
+			Want: value.Float(9),
+		},
+		{
+			Args: []value.Value{statsSample(), value.Float(0.5)},
+			Want: value.Float(4.5),
+		},
+		{
+			Args: []value.Value{statsSample(), value.Float(0.9)},
+			Want: value.Float(7.6),
+		},
+		{
+			Args: []value.Value{statsSample(), value.Float(1.5)},
+			Want: value.ErrNum,
+		},
+	}
+	testBuiltin(t, Percentile, tests)
+}
+
+func testCount(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{statsSample()},
+			Want: value.Float(8),
+		},
+		{
+			Args: []value.Value{statsSample(), value.Text("foo"), value.Float(1)},
+			Want: value.Float(9),
+		},
+	}
+	testBuiltin(t, Count, tests)
+	tests = []BuiltinTestCase{
+		{
+			Args: []value.Value{statsSample()},
+			Want: value.Float(11),
+		},
+	}
+	testBuiltin(t, Counta, tests)
 }
 
 func testIsEven(t *testing.T) {
@@ -54,3 +237,250 @@ func testIsOdd(t *testing.T) {
 	}
 	testBuiltin(t, IsOdd, tests)
 }
+
+func testCountIf(t *testing.T) {
+	list := value.NewArray([][]value.Value{
+		{value.Text("a*c")},
+		{value.Text("abc")},
+		{value.Blank{}},
+		{value.Float(10)},
+		{value.Boolean(true)},
+	})
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{list, value.Text("a*c")},
+			Want: value.Float(2),
+		},
+		{
+			Args: []value.Value{list, value.Text("a~*c")},
+			Want: value.Float(1),
+		},
+		{
+			Args: []value.Value{list, value.Text("")},
+			Want: value.Float(1),
+		},
+		{
+			Args: []value.Value{list, value.Text("<>")},
+			Want: value.Float(4),
+		},
+		{
+			Args: []value.Value{list, value.Text(">=10")},
+			Want: value.Float(1),
+		},
+		{
+			Args: []value.Value{list, value.Text("<>10")},
+			Want: value.Float(4),
+		},
+		{
+			Args: []value.Value{list, value.Text("true")},
+			Want: value.Float(1),
+		},
+	}
+	testBuiltin(t, CountIf, tests)
+}
+
+func testSumIf(t *testing.T) {
+	var (
+		names = value.NewArray([][]value.Value{
+			{value.Text("apple")},
+			{value.Text("banana")},
+			{value.Text("blueberry")},
+			{value.Text("what?")},
+		})
+		amounts = value.NewArray([][]value.Value{
+			{value.Float(1)},
+			{value.Float(2)},
+			{value.Float(4)},
+			{value.Float(8)},
+		})
+	)
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{names, value.Text("b*"), amounts},
+			Want: value.Float(6),
+		},
+		{
+			Args: []value.Value{names, value.Text("?pple"), amounts},
+			Want: value.Float(1),
+		},
+		{
+			Args: []value.Value{names, value.Text("<>b*"), amounts},
+			Want: value.Float(9),
+		},
+		{
+			Args: []value.Value{names, value.Text("*~?"), amounts},
+			Want: value.Float(8),
+		},
+		{
+			Args: []value.Value{names, value.Text("?"), amounts},
+			Want: value.Float(0),
+		},
+	}
+	testBuiltin(t, SumIf, tests)
+}
+
+func testRound(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(2.675), value.Float(2)},
+			Want: value.Float(2.68),
+		},
+		{
+			Args: []value.Value{value.Float(-2.5)},
+			Want: value.Float(-3),
+		},
+		{
+			Args: []value.Value{value.Float(1234.5), value.Float(-2)},
+			Want: value.Float(1200),
+		},
+		{
+			Args: []value.Value{value.Text("abc")},
+			Want: value.ErrValue,
+		},
+	}
+	testBuiltin(t, Round, tests)
+
+	tests = []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(3.14159), value.Float(3)},
+			Want: value.Float(3.142),
+		},
+		{
+			Args: []value.Value{value.Float(-3.14159), value.Float(1)},
+			Want: value.Float(-3.2),
+		},
+		{
+			Args: []value.Value{value.Float(0.1 + 0.2), value.Float(1)},
+			Want: value.Float(0.3),
+		},
+	}
+	testBuiltin(t, RoundUp, tests)
+
+	tests = []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(3.14159), value.Float(3)},
+			Want: value.Float(3.141),
+		},
+		{
+			Args: []value.Value{value.Float(-3.9)},
+			Want: value.Float(-3),
+		},
+	}
+	testBuiltin(t, RoundDown, tests)
+}
+
+func testCeiling(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(2.5), value.Float(1)},
+			Want: value.Float(3),
+		},
+		{
+			Args: []value.Value{value.Float(-2.5), value.Float(2)},
+			Want: value.Float(-2),
+		},
+		{
+			Args: []value.Value{value.Float(-2.5), value.Float(-2)},
+			Want: value.Float(-4),
+		},
+		{
+			Args: []value.Value{value.Float(1.5), value.Float(0.1)},
+			Want: value.Float(1.5),
+		},
+		{
+			Args: []value.Value{value.Float(2.5), value.Float(-2)},
+			Want: value.ErrNum,
+		},
+	}
+	testBuiltin(t, Ceil, tests)
+}
+
+func testFloor(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(3.7), value.Float(2)},
+			Want: value.Float(2),
+		},
+		{
+			Args: []value.Value{value.Float(-2.5), value.Float(2)},
+			Want: value.Float(-4),
+		},
+		{
+			Args: []value.Value{value.Float(-2.5), value.Float(-2)},
+			Want: value.Float(-2),
+		},
+		{
+			Args: []value.Value{value.Float(3.7), value.Float(0)},
+			Want: value.ErrDiv0,
+		},
+	}
+	testBuiltin(t, Floor, tests)
+
+	tests = []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(-1.5)},
+			Want: value.Float(-2),
+		},
+	}
+	testBuiltin(t, Int, tests)
+}
+
+func testMod(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(-3), value.Float(2)},
+			Want: value.Float(1),
+		},
+		{
+			Args: []value.Value{value.Float(3), value.Float(-2)},
+			Want: value.Float(-1),
+		},
+		{
+			Args: []value.Value{value.Float(3), value.Float(0)},
+			Want: value.ErrDiv0,
+		},
+	}
+	testBuiltin(t, Mod, tests)
+}
+
+func testProduct(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{statsSample()},
+			Want: value.Float(2 * 4 * 5 * 7 * 4 * 4 * 5 * 9),
+		},
+		{
+			Args: []value.Value{value.Float(2), value.Text("3")},
+			Want: value.Float(6),
+		},
+	}
+	testBuiltin(t, Product, tests)
+}
+
+func testLog(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(100)},
+			Want: value.Float(2),
+		},
+		{
+			Args: []value.Value{value.Float(8), value.Float(2)},
+			Want: value.Float(3),
+		},
+		{
+			Args: []value.Value{value.Float(0)},
+			Want: value.ErrNum,
+		},
+		{
+			Args: []value.Value{value.Float(8), value.Float(1)},
+			Want: value.ErrDiv0,
+		},
+	}
+	testBuiltin(t, Log, tests)
+	testBuiltin(t, Sqrt, []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Float(-1)},
+			Want: value.ErrNum,
+		},
+	})
+}
@@ -1,9 +1,12 @@
 package builtins
 
 import (
+	"regexp"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
+	"github.com/midbel/dockit/grid/criteria"
 	"github.com/midbel/dockit/grid/format"
 	"github.com/midbel/dockit/internal/slx"
 	"github.com/midbel/dockit/value"
@@ -50,21 +53,15 @@ func Left(args []value.Value) value.Value {
 	if err := value.HasErrors(args...); err != nil {
 		return err
 	}
-	var chars int
-	if len(args) == 2 {
-		c := asFloat(args[1])
-		if c <= 0 {
-			return value.ErrValue
-		}
-		chars = int(c)
-	} else {
-		chars++
+	chars, ok := countChars(args, 1)
+	if !ok {
+		return value.ErrValue
 	}
-	str := asString(args[0])
+	str := []rune(asString(args[0]))
 	if chars > len(str) {
-		return args[0]
+		chars = len(str)
 	}
-	return value.Text(str[:chars])
+	return value.Text(string(str[:chars]))
 }
 
 var rightBuiltin = Builtin{
@@ -83,21 +80,28 @@ func Right(args []value.Value) value.Value {
 	if err := value.HasErrors(args...); err != nil {
 		return err
 	}
-	var chars int
-	if len(args) == 2 {
-		c := asFloat(args[1])
-		if c <= 0 {
-			return value.ErrValue
-		}
-		chars = int(c)
-	} else {
-		chars++
+	chars, ok := countChars(args, 1)
+	if !ok {
+		return value.ErrValue
 	}
-	str := asString(args[0])
+	str := []rune(asString(args[0]))
 	if chars > len(str) {
-		return args[0]
+		chars = len(str)
 	}
-	return value.Text(str[len(str)-chars:])
+	return value.Text(string(str[len(str)-chars:]))
+}
+
+// countChars gives the number of characters given as the optional argument at
+// the given index. It is 1 when the argument is missing.
+func countChars(args []value.Value, ix int) (int, bool) {
+	if len(args) <= ix {
+		return 1, true
+	}
+	c := asFloat(args[ix])
+	if c < 0 {
+		return 0, false
+	}
+	return int(c), true
 }
 
 var midBuiltin = Builtin{
@@ -118,24 +122,22 @@ func Mid(args []value.Value) value.Value {
 	if err := value.HasErrors(args...); err != nil {
 		return err
 	}
-	ix := asFloat(args[1])
-	if ix <= 0 {
-		return value.ErrValue
-	}
-	ix -= 1
-	ch := asFloat(args[2])
-	if ch <= 0 {
+	var (
+		ix = int(asFloat(args[1]))
+		ch = int(asFloat(args[2]))
+	)
+	if ix <= 0 || ch < 0 {
 		return value.ErrValue
 	}
-	str := asString(args[0])
-	if int(ix) >= len(str) {
-		return args[0]
+	str := []rune(asString(args[0]))
+	if ix > len(str) {
+		return value.Text("")
 	}
-	str = str[int(ix):]
-	if int(ch) >= len(str) {
-		return value.Text(str)
+	str = str[ix-1:]
+	if ch < len(str) {
+		str = str[:ch]
 	}
-	return value.Text(str[:int(ch)])
+	return value.Text(string(str))
 }
 
 var lenBuiltin = Builtin{
@@ -154,7 +156,7 @@ func Len(args []value.Value) value.Value {
 	if err := value.HasErrors(args...); err != nil {
 		return err
 	}
-	ret := len(asString(args[0]))
+	ret := utf8.RuneCountInString(asString(args[0]))
 	return value.Float(ret)
 }
 
@@ -266,9 +268,9 @@ var searchBuiltin = Builtin{
 	Desc:     "Search the position of text (case-insensitive). Supports * and ?",
 	Category: "text",
 	Params: []Param{
-		Scalar("str", "", value.TypeText),
 		Scalar("find", "", value.TypeText),
-		Opt(Scalar("offset", "", value.TypeNumber)),
+		Scalar("str", "", value.TypeText),
+		Opt(Scalar("start", "", value.TypeNumber)),
 	},
 	Func:    Search,
 	Dialect: MainDialect,
@@ -279,29 +281,22 @@ func Search(args []value.Value) value.Value {
 		return err
 	}
 	var (
-		str    = asString(args[0])
-		find   = asString(args[1])
-		offset = 1
+		find = asString(args[0])
+		str  = asString(args[1])
 	)
-	if len(args) >= 3 {
-		ix, _ := value.CastToFloat(args[2])
-		offset = int(ix)
-		offset++
+	start, ok := startChar(args, 2, str)
+	if !ok {
+		return value.ErrValue
 	}
-	if offset >= len(str) {
+	re, err := regexp.Compile("(?i)" + criteria.WildcardPattern(find))
+	if err != nil {
 		return value.ErrValue
 	}
-
-	var (
-		in     = strings.ToLower(str)
-		needle = strings.ToLower(find)
-	)
-
-	ix := strings.Index(in[offset:], needle)
-	if ix < 0 {
+	loc := re.FindStringIndex(str[start:])
+	if loc == nil {
 		return value.ErrValue
 	}
-	return value.Float(float64(ix + 1))
+	return value.Float(utf8.RuneCountInString(str[:start+loc[0]]) + 1)
 }
 
 var findBuiltin = Builtin{
@@ -309,9 +304,9 @@ var findBuiltin = Builtin{
 	Desc:     "Finds the position of text (case-sensitive)",
 	Category: "text",
 	Params: []Param{
-		Scalar("str", "", value.TypeText),
 		Scalar("find", "", value.TypeText),
-		Opt(Scalar("offset", "", value.TypeNumber)),
+		Scalar("str", "", value.TypeText),
+		Opt(Scalar("start", "", value.TypeNumber)),
 	},
 	Func:    Find,
 	Dialect: MainDialect,
@@ -322,24 +317,36 @@ func Find(args []value.Value) value.Value {
 		return err
 	}
 	var (
-		str    = asString(args[0])
-		find   = asString(args[1])
-		offset = 1
+		find = asString(args[0])
+		str  = asString(args[1])
 	)
-	if len(args) >= 3 {
-		ix, _ := value.CastToFloat(args[2])
-		offset = int(ix)
-		offset++
-	}
-	if offset >= len(str) {
+	start, ok := startChar(args, 2, str)
+	if !ok {
 		return value.ErrValue
 	}
-
-	ix := strings.Index(str[offset:], find)
+	ix := strings.Index(str[start:], find)
 	if ix < 0 {
 		return value.ErrValue
 	}
-	return value.Float(float64(ix + 1))
+	return value.Float(utf8.RuneCountInString(str[:start+ix]) + 1)
+}
+
+// startChar gives the byte offset in str of the position, starting at 1, given
+// as the optional argument at the given index.
+func startChar(args []value.Value, ix int, str string) (int, bool) {
+	if len(args) <= ix {
+		return 0, true
+	}
+	pos := int(asFloat(args[ix]))
+	if pos <= 0 || pos > utf8.RuneCountInString(str) {
+		return 0, false
+	}
+	var offset int
+	for i := 1; i < pos; i++ {
+		_, z := utf8.DecodeRuneInString(str[offset:])
+		offset += z
+	}
+	return offset, true
 }
 
 var replaceBuiltin = Builtin{
@@ -377,7 +384,7 @@ func Replace(args []value.Value) value.Value {
 }
 
 var substituteBuiltin = Builtin{
-	Name:     "substitue",
+	Name:     "substitute",
 	Desc:     "Replaces occurrences of text with new text",
 	Category: "text",
 	Params: []Param{
@@ -398,34 +405,39 @@ func Substitute(args []value.Value) value.Value {
 		str  = asString(args[0])
 		old  = asString(args[1])
 		repl = asString(args[2])
-		num  = 1
 	)
-	if len(args) >= 4 {
-		c := asFloat(args[3])
-		if c <= 0 {
-			return value.ErrValue
-		}
-		num = int(c)
+	if old == "" {
+		return value.Text(str)
+	}
+	if len(args) < 4 {
+		return value.Text(strings.ReplaceAll(str, old, repl))
+	}
+	num := int(asFloat(args[3]))
+	if num <= 0 {
+		return value.ErrValue
 	}
 	var offset int
-	for i := 0; i < num; i++ {
+	for i := 1; ; i++ {
 		ix := strings.Index(str[offset:], old)
 		if ix < 0 {
 			break
 		}
 		offset += ix
-		str = str[:offset] + repl + str[offset+len(old):]
-		offset += len(repl)
+		if i == num {
+			str = str[:offset] + repl + str[offset+len(old):]
+			break
+		}
+		offset += len(old)
 	}
 	return value.Text(str)
 }
 
 var textBuiltin = Builtin{
 	Name:     "text",
-	Desc:     "Converts numeric values to text string",
+	Desc:     "Converts numbers and dates to text with a format pattern",
 	Category: "text",
 	Params: []Param{
-		Scalar("str", "", value.TypeText),
+		Scalar("value", "", value.TypeAny),
 		Scalar("pattern", "", value.TypeText),
 	},
 	Func:    Text,
@@ -437,26 +449,66 @@ func Text(args []value.Value) value.Value {
 		return err
 	}
 	var (
+		val = args[0]
 		str = asString(args[1])
-		ft  format.Formatter
-		err error
 	)
-	switch args[0].Type() {
+	if val.Type() == value.TypeText {
+		n, err := value.CastToFloat(val)
+		if err != nil {
+			// texts are given as they are
+			return val
+		}
+		val = n
+	}
+	switch val.Type() {
 	case value.TypeNumber:
-		ft, err = format.ParseNumberFormatter(str)
+		str, err := formatNumber(asFloat(val), str)
+		if err != nil {
+			return value.ErrValue
+		}
+		return value.Text(str)
 	case value.TypeDate:
-		ft, err = format.ParseDateFormatter(str)
+		ft, err := format.ParseDateFormatter(str)
+		if err != nil {
+			return value.ErrValue
+		}
+		if str, err = ft.Format(val); err != nil {
+			return value.ErrValue
+		}
+		return value.Text(str)
 	default:
 		return value.ErrValue
 	}
+}
+
+// formatNumber formats a number with a pattern made of a number pattern with
+// optional literal texts around it. A % after the number pattern gives the
+// number as a percentage.
+func formatNumber(num float64, pattern string) (string, error) {
+	var (
+		start = strings.IndexAny(pattern, "0#")
+		end   = strings.LastIndexAny(pattern, "0#")
+	)
+	if start < 0 {
+		return pattern, nil
+	}
+	prefix, core, suffix := pattern[:start], pattern[start:end+1], pattern[end+1:]
+	if strings.HasSuffix(prefix, "+") || strings.HasSuffix(prefix, "-") {
+		core = prefix[len(prefix)-1:] + core
+		prefix = prefix[:len(prefix)-1]
+	}
+	if strings.Contains(prefix, "%") || strings.Contains(suffix, "%") {
+		num *= 100
+	}
+	ft, err := format.ParseNumberFormatter(core)
 	if err != nil {
-		return value.ErrValue
+		return "", err
 	}
-	str, err = ft.Format(args[0])
+	str, err := ft.Format(value.Float(num))
 	if err != nil {
-		return value.ErrValue
+		return "", err
 	}
-	return value.Text(str)
+	return prefix + str + suffix, nil
 }
 
 var valueBuiltin = Builtin{
@@ -488,7 +540,7 @@ var textjoinBuiltin = Builtin{
 	Params: []Param{
 		Scalar("delimiter", "", value.TypeText),
 		Scalar("ignore", "", value.TypeBool),
-		Var(Scalar("str", "", value.TypeText)),
+		Var(ScalarArray("str", "", value.TypeText)),
 	},
 	Func:    Textjoin,
 	Dialect: MainDialect,
@@ -503,12 +555,15 @@ func Textjoin(args []value.Value) value.Value {
 		ignore = asBool(args[1])
 		parts  = make([]string, 0, len(args))
 	)
-	for i := 2; i < len(args); i++ {
-		str := asString(args[i])
+	err := value.Each(args[2:], func(v value.Value) {
+		str := asString(v)
 		if str == "" && ignore {
-			continue
+			return
 		}
 		parts = append(parts, str)
+	})
+	if err != nil {
+		return err
 	}
 	str := strings.Join(parts, delim)
 	return value.Text(str)
@@ -38,6 +38,10 @@ func testLen(t *testing.T) {
 			Args: []value.Value{value.Text("")},
 			Want: value.Float(0),
 		},
+		{
+			Args: []value.Value{value.Text("déjà")},
+			Want: value.Float(4),
+		},
 		{
 			Args: []value.Value{value.ErrValue},
 			Want: value.ErrValue,
@@ -124,6 +128,18 @@ func testLeft(t *testing.T) {
 			Args: []value.Value{value.Text("foo"), value.Float(7)},
 			Want: value.Text("foo"),
 		},
+		{
+			Args: []value.Value{value.Text("foo"), value.Float(0)},
+			Want: value.Text(""),
+		},
+		{
+			Args: []value.Value{value.Text("éte"), value.Float(2)},
+			Want: value.Text("ét"),
+		},
+		{
+			Args: []value.Value{value.Text("foo"), value.Float(-1)},
+			Want: value.ErrValue,
+		},
 	}
 	testBuiltin(t, Left, tests)
 }
@@ -168,6 +184,14 @@ func testMid(t *testing.T) {
 			Args: []value.Value{value.Text("foobar"), value.Float(3), value.Float(1)},
 			Want: value.Text("o"),
 		},
+		{
+			Args: []value.Value{value.Text("foobar"), value.Float(10), value.Float(1)},
+			Want: value.Text(""),
+		},
+		{
+			Args: []value.Value{value.Text("foobar"), value.Float(0), value.Float(1)},
+			Want: value.ErrValue,
+		},
 	}
 	testBuiltin(t, Mid, tests)
 }
@@ -203,44 +227,59 @@ func testSearch(t *testing.T) {
 	tests := []BuiltinTestCase{
 		{
 			Args: []value.Value{
+				value.Text("QUICK"),
 				value.Text(quick),
-				value.Text("quick"),
 			},
-			Want: value.Float(4),
+			Want: value.Float(5),
 		},
 		{
 			Args: []value.Value{
-				value.Text(quick),
 				value.Text("the * brown"),
+				value.Text(quick),
 			},
 			Want: value.Float(1),
 		},
 		{
 			Args: []value.Value{
-				value.Text(quick),
 				value.Text("f?x"),
+				value.Text(quick),
 			},
-			Want: value.Float(16),
+			Want: value.Float(17),
 		},
 		{
 			Args: []value.Value{
+				value.Text("the"),
 				value.Text(quick),
-				value.Text("quick"),
-				value.Float(4),
+				value.Float(2),
 			},
-			Want: value.Float(1),
+			Want: value.Float(30),
+		},
+		{
+			Args: []value.Value{
+				value.Text("~?"),
+				value.Text("what?"),
+			},
+			Want: value.Float(5),
 		},
 		{
 			Args: []value.Value{
-				value.Text(quick),
 				value.Text("foobar"),
+				value.Text(quick),
 			},
 			Want: value.ErrValue,
 		},
 		{
 			Args: []value.Value{
-				value.Text(quick),
 				value.Text("f??b*r"),
+				value.Text(quick),
+			},
+			Want: value.ErrValue,
+		},
+		{
+			Args: []value.Value{
+				value.Text("the"),
+				value.Text(quick),
+				value.Float(0),
 			},
 			Want: value.ErrValue,
 		},
@@ -249,34 +288,49 @@ func testSearch(t *testing.T) {
 }
 
 func testFind(t *testing.T) {
-	quick := "the Quick brown fox jumps of the lazy dog"
+	quick := "the Quick brown fox jumps of the lazy dög"
 	tests := []BuiltinTestCase{
 		{
 			Args: []value.Value{
-				value.Text(quick),
 				value.Text("Quick"),
+				value.Text(quick),
 			},
-			Want: value.Float(4),
+			Want: value.Float(5),
 		},
 		{
 			Args: []value.Value{
-				value.Text(quick),
 				value.Text("the quick"),
+				value.Text(quick),
 			},
 			Want: value.ErrValue,
 		},
 		{
 			Args: []value.Value{
+				value.Text("the"),
 				value.Text(quick),
-				value.Text("Quick"),
 				value.Float(4),
 			},
-			Want: value.Float(1),
+			Want: value.Float(30),
 		},
 		{
 			Args: []value.Value{
+				value.Text("g"),
 				value.Text(quick),
+			},
+			Want: value.Float(41),
+		},
+		{
+			Args: []value.Value{
 				value.Text("foobar"),
+				value.Text(quick),
+			},
+			Want: value.ErrValue,
+		},
+		{
+			Args: []value.Value{
+				value.Text("the"),
+				value.Text(quick),
+				value.Float(50),
 			},
 			Want: value.ErrValue,
 		},
@@ -344,6 +398,23 @@ func testSubstitute(t *testing.T) {
 			},
 			Want: value.Text("the quick brown fox jumps of the lazy dog"),
 		},
+		{
+			Args: []value.Value{
+				value.Text("the quick brown dog jumps of the lazy dog"),
+				value.Text("dog"),
+				value.Text("fox"),
+				value.Float(2),
+			},
+			Want: value.Text("the quick brown dog jumps of the lazy fox"),
+		},
+		{
+			Args: []value.Value{
+				value.Text("the quick brown dog jumps of the lazy dog"),
+				value.Text("dog"),
+				value.Text("fox"),
+			},
+			Want: value.Text("the quick brown fox jumps of the lazy fox"),
+		},
 	}
 	testBuiltin(t, Substitute, tests)
 }
@@ -357,6 +428,27 @@ func testText(t *testing.T) {
 			},
 			Want: value.Text("42"),
 		},
+		{
+			Args: []value.Value{
+				value.Float(0.256),
+				value.Text("0.0%"),
+			},
+			Want: value.Text("25.6%"),
+		},
+		{
+			Args: []value.Value{
+				value.Float(1234.5),
+				value.Text("$#,##0.00"),
+			},
+			Want: value.Text("$1,234.50"),
+		},
+		{
+			Args: []value.Value{
+				value.Text("foobar"),
+				value.Text("0.00"),
+			},
+			Want: value.Text("foobar"),
+		},
 	}
 	testBuiltin(t, Text, tests)
 }
@@ -418,6 +510,17 @@ func testTextJoin(t *testing.T) {
 			},
 			Want: value.Text("foo-bar"),
 		},
+		{
+			Args: []value.Value{
+				value.Text(", "),
+				value.Boolean(true),
+				value.NewArray([][]value.Value{
+					{value.Text("foo"), value.Blank{}},
+					{value.Text("bar"), value.Float(1)},
+				}),
+			},
+			Want: value.Text("foo, bar, 1"),
+		},
 	}
 	testBuiltin(t, Textjoin, tests)
 }
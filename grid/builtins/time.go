@@ -1,6 +1,7 @@
 package builtins
 
 import (
+	"strings"
 	"time"
 
 	"github.com/midbel/dockit/grid/temporal"
@@ -13,24 +14,32 @@ var nowBuiltin = Builtin{
 	Category: "time",
 	Func:     Now,
 	Dialect:  MainDialect,
+	Volatile: true,
 }
 
 func Now(args []value.Value) value.Value {
-	n := time.Now()
-	return value.Date(n)
+	var (
+		n = time.Now()
+		t = time.Date(n.Year(), n.Month(), n.Day(), n.Hour(), n.Minute(), n.Second(), 0, time.UTC)
+	)
+	return value.Date(t)
 }
 
 var todayBuiltin = Builtin{
 	Name:     "today",
-	Desc:     "Returns the current date and time",
+	Desc:     "Returns the current date",
 	Category: "time",
 	Func:     Today,
 	Dialect:  MainDialect,
+	Volatile: true,
 }
 
 func Today(args []value.Value) value.Value {
-	n := time.Now().Truncate(time.Hour * 24)
-	return value.Date(n)
+	var (
+		n = time.Now()
+		t = time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, time.UTC)
+	)
+	return value.Date(t)
 }
 
 var dateBuiltin = Builtin{
@@ -51,11 +60,18 @@ func Date(args []value.Value) value.Value {
 		return err
 	}
 	var (
-		year  = asFloat(args[0])
-		month = asFloat(args[1])
-		day   = asFloat(args[2])
+		year  = int(asFloat(args[0]))
+		month = int(asFloat(args[1]))
+		day   = int(asFloat(args[2]))
 	)
-	n := time.Date(int(year), time.Month(month), int(day), 0, 0, 0, 0, time.UTC)
+	if year < 0 || year >= 10000 {
+		return value.ErrNum
+	}
+	if year < 1900 {
+		// years are given from 1900 as spreadsheets do
+		year += 1900
+	}
+	n := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
 	return value.Date(n)
 }
 
@@ -71,10 +87,10 @@ var yearBuiltin = Builtin{
 }
 
 func Year(args []value.Value) value.Value {
-	if err := value.HasErrors(args[0]); err != nil {
+	t, err := dateArg(args[0])
+	if err != nil {
 		return err
 	}
-	t := asTime(args[0])
 	return value.Float(t.Year())
 }
 
@@ -90,10 +106,10 @@ var monthBuiltin = Builtin{
 }
 
 func Month(args []value.Value) value.Value {
-	if err := value.HasErrors(args[0]); err != nil {
+	t, err := dateArg(args[0])
+	if err != nil {
 		return err
 	}
-	t := asTime(args[0])
 	return value.Float(t.Month())
 }
 
@@ -109,10 +125,10 @@ var dayBuiltin = Builtin{
 }
 
 func Day(args []value.Value) value.Value {
-	if err := value.HasErrors(args[0]); err != nil {
+	t, err := dateArg(args[0])
+	if err != nil {
 		return err
 	}
-	t := asTime(args[0])
 	return value.Float(t.Day())
 }
 
@@ -128,10 +144,10 @@ var yeardayBuiltin = Builtin{
 }
 
 func YearDay(args []value.Value) value.Value {
-	if err := value.HasErrors(args[0]); err != nil {
+	t, err := dateArg(args[0])
+	if err != nil {
 		return err
 	}
-	t := asTime(args[0])
 	return value.Float(t.YearDay())
 }
 
@@ -147,10 +163,10 @@ var hourBuiltin = Builtin{
 }
 
 func Hour(args []value.Value) value.Value {
-	if err := value.HasErrors(args[0]); err != nil {
+	t, err := dateArg(args[0])
+	if err != nil {
 		return err
 	}
-	t := asTime(args[0])
 	return value.Float(t.Hour())
 }
 
@@ -166,10 +182,10 @@ var minuteBuiltin = Builtin{
 }
 
 func Minute(args []value.Value) value.Value {
-	if err := value.HasErrors(args[0]); err != nil {
+	t, err := dateArg(args[0])
+	if err != nil {
 		return err
 	}
-	t := asTime(args[0])
 	return value.Float(t.Minute())
 }
 
@@ -185,38 +201,98 @@ var secondBuiltin = Builtin{
 }
 
 func Second(args []value.Value) value.Value {
-	if err := value.HasErrors(args[0]); err != nil {
+	t, err := dateArg(args[0])
+	if err != nil {
 		return err
 	}
-	t := asTime(args[0])
 	return value.Float(t.Second())
 }
 
 var weekdayBuiltin = Builtin{
 	Name:     "weekday",
-	Desc:     "",
+	Desc:     "Returns the day of the week of a date",
 	Category: "time",
 	Params: []Param{
 		Scalar("date", "", value.TypeDate),
+		Opt(Scalar("type", "1 when weeks start on sunday (default), 2 when they start on monday, 3 when they start on monday at 0", value.TypeNumber)),
 	},
 	Func:    Weekday,
 	Dialect: MainDialect,
 }
 
 func Weekday(args []value.Value) value.Value {
-	if err := value.HasErrors(args[0]); err != nil {
+	t, err := dateArg(args[0])
+	if err != nil {
 		return err
 	}
-	t := asTime(args[0])
-	return value.Float(t.Weekday())
+	kind := 1
+	if len(args) >= 2 {
+		kind = int(asFloat(args[1]))
+	}
+	day := int(t.Weekday())
+	switch kind {
+	case 1:
+		// sunday is 1 and saturday is 7
+		day++
+	case 2:
+		// monday is 1 and sunday is 7
+		day = (day+6)%7 + 1
+	case 3:
+		// monday is 0 and sunday is 6
+		day = (day + 6) % 7
+	default:
+		return value.ErrNum
+	}
+	return value.Float(day)
+}
+
+var edateBuiltin = Builtin{
+	Name:     "edate",
+	Desc:     "Returns the date a number of months before or after a date",
+	Category: "time",
+	Params: []Param{
+		Scalar("date", "", value.TypeDate),
+		Scalar("months", "", value.TypeNumber),
+	},
+	Func:    Edate,
+	Dialect: MainDialect,
 }
 
 func Edate(args []value.Value) value.Value {
-	return nil
+	t, err := dateArg(args[0])
+	if err != nil {
+		return err
+	}
+	if err := value.HasErrors(args[1]); err != nil {
+		return err
+	}
+	t = addMonths(t, int(asFloat(args[1])))
+	return value.Date(t)
+}
+
+var eomonthBuiltin = Builtin{
+	Name:     "eomonth",
+	Desc:     "Returns the last day of the month a number of months before or after a date",
+	Category: "time",
+	Params: []Param{
+		Scalar("date", "", value.TypeDate),
+		Scalar("months", "", value.TypeNumber),
+	},
+	Func:    EoMonth,
+	Dialect: MainDialect,
 }
 
 func EoMonth(args []value.Value) value.Value {
-	return nil
+	t, err := dateArg(args[0])
+	if err != nil {
+		return err
+	}
+	if err := value.HasErrors(args[1]); err != nil {
+		return err
+	}
+	months := int(asFloat(args[1]))
+	t = time.Date(t.Year(), t.Month()+time.Month(months)+1, 0, 0, 0, 0, 0, time.UTC)
+	return value.Date(t)
 }
 
 var datediffBuiltin = Builtin{
@@ -233,37 +309,117 @@ var datediffBuiltin = Builtin{
 }
 
 func DateDiff(args []value.Value) value.Value {
-	var (
-		dtstart = asTime(args[0])
-		dtend   = asTime(args[1])
-		unit    = asString(args[2])
-		delta   float64
-	)
+	dtstart, err := dateArg(args[0])
+	if err != nil {
+		return err
+	}
+	dtend, err := dateArg(args[1])
+	if err != nil {
+		return err
+	}
 	if dtstart.After(dtend) {
 		return value.ErrNum
 	}
-	switch unit {
+	var delta int
+	switch unit := strings.ToUpper(asString(args[2])); unit {
 	case "Y":
-		diff := temporal.YearsBetween(dtend, dtstart)
-		delta = float64(diff)
+		delta = temporal.YearsBetween(dtend, dtstart)
 	case "M":
-		diff := temporal.MonthsBetween(dtend, dtstart)
-		delta = float64(diff)
+		delta = temporal.MonthsBetween(dtend, dtstart)
 	case "D":
-		diff := temporal.DaysBetween(dtend, dtstart)
-		delta = float64(diff)
+		delta = temporal.DaysBetween(dtend, dtstart)
 	case "YD":
-		diff := temporal.CountDays(dtend, dtstart)
-		delta = float64(diff)
+		delta = temporal.CountDays(dtend, dtstart)
 	case "YM":
-		diff := temporal.CountMonths(dtend, dtstart)
-		delta = float64(diff)
+		delta = temporal.CountMonths(dtend, dtstart)
 	case "MD":
+		delta = temporal.CountMonthDays(dtend, dtstart)
 	default:
+		return value.ErrNum
 	}
 	return value.Float(delta)
 }
 
+var networkdaysBuiltin = Builtin{
+	Name:     "networkdays",
+	Desc:     "Returns the number of working days between two dates",
+	Category: "time",
+	Params: []Param{
+		Scalar("fromDate", "", value.TypeDate),
+		Scalar("toDate", "", value.TypeDate),
+		Opt(ScalarArray("holidays", "", value.TypeDate)),
+	},
+	Func:    NetworkDays,
+	Dialect: MainDialect,
+}
+
+func NetworkDays(args []value.Value) value.Value {
+	dtstart, err := dateArg(args[0])
+	if err != nil {
+		return err
+	}
+	dtend, err := dateArg(args[1])
+	if err != nil {
+		return err
+	}
+	holidays := make(map[time.Time]struct{})
+	if len(args) >= 3 {
+		err := value.Each(args[2:], func(v value.Value) {
+			if value.IsBlank(v) {
+				return
+			}
+			if t, err := dateArg(v); err == nil {
+				holidays[t.Truncate(24*time.Hour)] = struct{}{}
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	sign := 1
+	if dtstart.After(dtend) {
+		dtstart, dtend = dtend, dtstart
+		sign = -1
+	}
+	var (
+		count int
+		curr  = dtstart.Truncate(24 * time.Hour)
+		last  = dtend.Truncate(24 * time.Hour)
+	)
+	for ; !curr.After(last); curr = curr.AddDate(0, 0, 1) {
+		if day := curr.Weekday(); day == time.Saturday || day == time.Sunday {
+			continue
+		}
+		if _, ok := holidays[curr]; ok {
+			continue
+		}
+		count++
+	}
+	return value.Float(sign * count)
+}
+
+// dateArg gives the time of a date given as a date, as a serial number or as
+// a text.
+func dateArg(arg value.Value) (time.Time, value.Value) {
+	if value.IsError(arg) {
+		return time.Time{}, arg
+	}
+	d, err := value.CastToDate(arg)
+	if err != nil {
+		return time.Time{}, value.ErrValue
+	}
+	return time.Time(d).UTC(), nil
+}
+
+// addMonths adds months to a date and keeps the day in the month found.
+func addMonths(t time.Time, months int) time.Time {
+	var (
+		first = time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+		last  = first.AddDate(0, 1, -1).Day()
+	)
+	return first.AddDate(0, 0, min(t.Day(), last)-1)
+}
+
 var timeBuiltins = []Builtin{
 	nowBuiltin,
 	todayBuiltin,
@@ -276,5 +432,8 @@ var timeBuiltins = []Builtin{
 	minuteBuiltin,
 	secondBuiltin,
 	weekdayBuiltin,
+	edateBuiltin,
+	eomonthBuiltin,
 	datediffBuiltin,
+	networkdaysBuiltin,
 }
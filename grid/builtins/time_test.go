@@ -20,6 +20,8 @@ func TestDates(t *testing.T) {
 	t.Run("second", testSecond)
 	t.Run("weekday", testWeekDay)
 	t.Run("datediff", testDateDiff)
+	t.Run("eomonth", testEoMonth)
+	t.Run("networkdays", testNetworkDays)
 }
 
 func testDate(t *testing.T) {
@@ -110,12 +112,98 @@ func testWeekDay(t *testing.T) {
 	tests := []BuiltinTestCase{
 		{
 			Args: []value.Value{value.Date(today)},
+			Want: value.Float(7),
+		},
+		{
+			Args: []value.Value{value.Date(today), value.Float(2)},
 			Want: value.Float(6),
 		},
+		{
+			Args: []value.Value{value.Date(today), value.Float(3)},
+			Want: value.Float(5),
+		},
+		{
+			Args: []value.Value{value.Float(46179)},
+			Want: value.Float(7),
+		},
 	}
 	testBuiltin(t, Weekday, tests)
 }
 
 func testDateDiff(t *testing.T) {
-	t.SkipNow()
+	var (
+		start = value.Date(time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC))
+		end   = value.Date(time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC))
+	)
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{start, end, value.Text("Y")},
+			Want: value.Float(5),
+		},
+		{
+			Args: []value.Value{start, end, value.Text("M")},
+			Want: value.Float(70),
+		},
+		{
+			Args: []value.Value{start, end, value.Text("D")},
+			Want: value.Float(2158),
+		},
+		{
+			Args: []value.Value{start, end, value.Text("YM")},
+			Want: value.Float(10),
+		},
+		{
+			Args: []value.Value{start, end, value.Text("YD")},
+			Want: value.Float(332),
+		},
+		{
+			Args: []value.Value{start, end, value.Text("MD")},
+			Want: value.Float(26),
+		},
+		{
+			Args: []value.Value{end, start, value.Text("D")},
+			Want: value.ErrNum,
+		},
+	}
+	testBuiltin(t, DateDiff, tests)
+}
+
+func testEoMonth(t *testing.T) {
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{value.Date(today), value.Float(0)},
+			Want: value.Date(time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)),
+		},
+		{
+			Args: []value.Value{value.Date(today), value.Float(-4)},
+			Want: value.Date(time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+	testBuiltin(t, EoMonth, tests)
+}
+
+func testNetworkDays(t *testing.T) {
+	var (
+		start    = value.Date(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC))
+		end      = value.Date(time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC))
+		holidays = value.NewArray([][]value.Value{
+			{value.Date(time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC))},
+			{value.Date(time.Date(2026, 6, 13, 0, 0, 0, 0, time.UTC))},
+		})
+	)
+	tests := []BuiltinTestCase{
+		{
+			Args: []value.Value{start, end},
+			Want: value.Float(22),
+		},
+		{
+			Args: []value.Value{start, end, holidays},
+			Want: value.Float(21),
+		},
+		{
+			Args: []value.Value{end, start},
+			Want: value.Float(-22),
+		},
+	}
+	testBuiltin(t, NetworkDays, tests)
 }
@@ -3,7 +3,7 @@ package calc
 import (
 	"math"
 	"math/rand"
-	"sort"
+	"slices"
 )
 
 func Sign(value float64) float64 {
@@ -36,6 +36,10 @@ func Min(values []float64) float64 {
 func Max(values []float64) float64 {
 	var ret float64
 	for i := 0; i < len(values); i++ {
+		if i == 0 {
+			ret = values[i]
+			continue
+		}
 		ret = max(ret, values[i])
 	}
 	return ret
@@ -50,23 +54,17 @@ func Sum(values []float64) float64 {
 }
 
 func Median(values []float64) float64 {
-	sort.Float64s(values)
+	values = slices.Clone(values)
+	slices.Sort(values)
 
 	size := len(values)
-	switch size {
-	case 0:
+	if size == 0 {
 		return 0
-	case 1:
-		return values[0]
-	default:
 	}
-	ix := len(values) / 2
-	if ix%2 == 0 {
-		return values[ix]
+	if size%2 == 1 {
+		return values[size/2]
 	}
-	fd := values[(size/2)-1]
-	td := values[size/2]
-	return (td - fd) / 2
+	return (values[size/2-1] + values[size/2]) / 2
 }
 
 func Avg(values []float64) float64 {
@@ -77,24 +75,25 @@ func Avg(values []float64) float64 {
 	return total / float64(len(values))
 }
 
-func Mode(values []float64) float64 {
-	vs := make(map[float64]int)
-	for _, f := range values {
-		vs[f]++
-	}
-	if len(vs) == 0 {
-		return 0
-	}
+// Mode gives the most frequent value. The first one in values is given when
+// several values are as frequent. It reports false when no value is repeated.
+func Mode(values []float64) (float64, bool) {
 	var (
-		ms = make(map[int][]float64)
-		mx int
+		counts = make(map[float64]int)
+		ret    float64
+		most   int
 	)
-	for v, c := range vs {
-		mx = max(c, mx)
-		ms[c] = append(ms[c], v)
+	for _, f := range values {
+		counts[f]++
+		most = max(most, counts[f])
+	}
+	for _, f := range values {
+		if counts[f] == most {
+			ret = f
+			break
+		}
 	}
-	rs := ms[mx]
-	return rs[0]
+	return ret, most > 1
 }
 
 func Stdev(values []float64) float64 {
@@ -102,20 +101,57 @@ func Stdev(values []float64) float64 {
 	return math.Sqrt(v)
 }
 
+func StdevP(values []float64) float64 {
+	v := VarP(values)
+	return math.Sqrt(v)
+}
+
+// Var gives the variance of a sample.
 func Var(values []float64) float64 {
 	z := len(values)
 	if z < 2 {
 		return 0
 	}
+	return squares(values) / float64(z-1)
+}
+
+// VarP gives the variance of a whole population.
+func VarP(values []float64) float64 {
+	z := len(values)
+	if z == 0 {
+		return 0
+	}
+	return squares(values) / float64(z)
+}
+
+func squares(values []float64) float64 {
 	var (
 		avg = Avg(values)
 		sum float64
 	)
 	for _, f := range values {
-		x := (f - avg) * (f - avg)
-		sum += x
+		sum += (f - avg) * (f - avg)
+	}
+	return sum
+}
+
+// Percentile gives the k-th percentile of values, k being between 0 and 1.
+// Values between two ranks are interpolated.
+func Percentile(values []float64, k float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	values = slices.Clone(values)
+	slices.Sort(values)
+
+	var (
+		rank = k * float64(len(values)-1)
+		low  = int(math.Floor(rank))
+	)
+	if low+1 >= len(values) {
+		return values[low]
 	}
-	return sum / float64(z)
+	return values[low] + (rank-float64(low))*(values[low+1]-values[low])
 }
 
 func Deg(value float64) float64 {
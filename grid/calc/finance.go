@@ -0,0 +1,100 @@
+package calc
+
+import (
+	"math"
+)
+
+const (
+	maxIterations = 100
+	precision     = 1e-10
+)
+
+// Npv gives the net present value of cash flows occurring at the end of each
+// period.
+func Npv(rate float64, flows []float64) float64 {
+	var sum float64
+	for i, f := range flows {
+		sum += f / math.Pow(1+rate, float64(i+1))
+	}
+	return sum
+}
+
+// Irr gives the rate for which the net present value of the cash flows is
+// zero, the first flow occurring at the start of the first period. It reports
+// false when no rate is found.
+func Irr(flows []float64, guess float64) (float64, bool) {
+	return solve(guess, func(rate float64) float64 {
+		var sum float64
+		for i, f := range flows {
+			sum += f / math.Pow(1+rate, float64(i))
+		}
+		return sum
+	})
+}
+
+// Pv gives the present value of a series of nper payments. Payments are made
+// at the start of each period when due is true.
+func Pv(rate, nper, pmt, fv float64, due bool) float64 {
+	if rate == 0 {
+		return -(fv + pmt*nper)
+	}
+	return -(fv + annuity(rate, nper, pmt, due)) / math.Pow(1+rate, nper)
+}
+
+// Fv gives the future value of an investment after nper payments.
+func Fv(rate, nper, pmt, pv float64, due bool) float64 {
+	if rate == 0 {
+		return -(pv + pmt*nper)
+	}
+	return -(pv*math.Pow(1+rate, nper) + annuity(rate, nper, pmt, due))
+}
+
+// Pmt gives the payment of each period to go from pv to fv in nper periods.
+func Pmt(rate, nper, pv, fv float64, due bool) float64 {
+	if rate == 0 {
+		return -(pv + fv) / nper
+	}
+	return -(pv*math.Pow(1+rate, nper) + fv) / annuity(rate, nper, 1, due)
+}
+
+// Rate gives the interest rate per period of an annuity. It reports false
+// when no rate is found.
+func Rate(nper, pmt, pv, fv float64, due bool, guess float64) (float64, bool) {
+	return solve(guess, func(rate float64) float64 {
+		if rate == 0 {
+			return pv + pmt*nper + fv
+		}
+		return pv*math.Pow(1+rate, nper) + annuity(rate, nper, pmt, due) + fv
+	})
+}
+
+// annuity gives the value at the end of the last period of nper payments.
+func annuity(rate, nper, pmt float64, due bool) float64 {
+	val := pmt * (math.Pow(1+rate, nper) - 1) / rate
+	if due {
+		val *= 1 + rate
+	}
+	return val
+}
+
+// solve finds a root of fn near guess with the method of Newton, the
+// derivative being approximated.
+func solve(guess float64, fn func(float64) float64) (float64, bool) {
+	x := guess
+	for range maxIterations {
+		var (
+			y     = fn(x)
+			delta = max(math.Abs(x), 1) * 1e-7
+			dy    = (fn(x+delta) - fn(x-delta)) / (2 * delta)
+		)
+		if math.IsNaN(y) || math.IsInf(y, 0) || dy == 0 {
+			return 0, false
+		}
+		next := x - y/dy
+		if math.Abs(next-x) < precision {
+			return next, next > -1
+		}
+		x = next
+	}
+	return 0, false
+}
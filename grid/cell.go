@@ -2,6 +2,8 @@ package grid
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/midbel/dockit/internal/id"
 	"github.com/midbel/dockit/layout"
@@ -43,13 +45,38 @@ func (c CopyMode) Formula() bool {
 	return c == CopyAll || (c&CopyFormula != 0)
 }
 
+func (c CopyMode) Style() bool {
+	return c == CopyAll || (c&CopyStyle != 0)
+}
+
 const (
-	CopyValue = iota << 1
+	CopyValue = 1 << iota
 	CopyFormula
 	CopyStyle
 	CopyAll = CopyValue | CopyFormula | CopyStyle
 )
 
+// RawNumber gives the number of a cell as it is written in the file it comes
+// from. Formatting the value of the cell instead loses the digits that do not
+// fit in a float64 and the notation used by the file. ok is false when the cell
+// does not hold a number or when its value has been changed.
+func RawNumber(cell Cell) (string, bool) {
+	n, ok := cell.Value().(value.Float)
+	if !ok {
+		return "", false
+	}
+	d, ok := cell.(interface{ Display() string })
+	if !ok {
+		return "", false
+	}
+	raw := strings.TrimSpace(d.Display())
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil || f != float64(n) {
+		return "", false
+	}
+	return raw, true
+}
+
 type Row interface {
 	Values() []value.ScalarValue
 	Sparse() bool
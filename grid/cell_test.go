@@ -0,0 +1,38 @@
+package grid_test
+
+import (
+	"testing"
+
+	"github.com/midbel/dockit/grid"
+)
+
+func TestCopyMode(t *testing.T) {
+	tests := []struct {
+		Input   string
+		Value   bool
+		Formula bool
+		Style   bool
+	}{
+		{Input: "value", Value: true},
+		{Input: "formula", Formula: true},
+		{Input: "style", Style: true},
+		{Input: "all", Value: true, Formula: true, Style: true},
+		{Input: "", Value: true, Formula: true, Style: true},
+	}
+	for _, c := range tests {
+		mode, err := grid.CopyModeFromString(c.Input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.Input, err)
+			continue
+		}
+		if !mode.Valid() {
+			t.Errorf("%s: mode should be valid", c.Input)
+		}
+		if mode.Value() != c.Value || mode.Formula() != c.Formula || mode.Style() != c.Style {
+			t.Errorf("%s: mode mismatched! value: %t, formula: %t, style: %t", c.Input, mode.Value(), mode.Formula(), mode.Style())
+		}
+	}
+	if _, err := grid.CopyModeFromString("other"); err == nil {
+		t.Errorf("invalid mode should be rejected")
+	}
+}
@@ -93,3 +93,39 @@ type ChartOptions struct {
 	SmoothLines bool
 	DataLabels  DataLabel
 }
+
+// NewChart creates a chart of the given kind with its legend shown on the
+// right side.
+func NewChart(kind ChartType, title string) *Chart {
+	return &Chart{
+		Type:  kind,
+		Title: title,
+		Legend: Legend{
+			Visible:  true,
+			Position: LegendRight,
+		},
+	}
+}
+
+// AddSeries adds a series of values to the chart. The categories are the
+// labels of the values; they are optional.
+func (c *Chart) AddSeries(name string, categories, values *layout.Range) {
+	s := Series{
+		Name: name,
+	}
+	if categories != nil {
+		s.Categories = *categories.Normalize()
+	}
+	if values != nil {
+		s.Values = *values.Normalize()
+	}
+	c.Series = append(c.Series, s)
+}
+
+// Place anchors the chart on the cells between from and to, both included.
+func (c *Chart) Place(from, to layout.Position) {
+	c.Anchor = Anchor{
+		From: from,
+		To:   to,
+	}
+}
@@ -5,6 +5,18 @@ import (
 	"github.com/midbel/dockit/value"
 )
 
+// spanContext is implemented by the contexts giving access to the sheets of
+// a file, to resolve the 3D references of formulas.
+type spanContext interface {
+	SheetSpan(first, last string) ([]string, error)
+}
+
+// bookContext is implemented by the contexts giving access to other
+// workbooks, to resolve the external references of formulas.
+type bookContext interface {
+	Book(name string) (File, error)
+}
+
 type rowContext struct {
 	rows []value.Value
 }
@@ -19,11 +31,13 @@ func (c rowContext) Resolve(string) value.Value {
 	return value.ErrName
 }
 
+// At gives the value of a column of the row. The position of a column
+// reference has no line.
 func (c rowContext) At(pos layout.Position) value.Value {
-	if pos.Column < 0 || pos.Column >= int64(len(c.rows)) {
+	if pos.Column < 1 || pos.Column > int64(len(c.rows)) {
 		return value.ErrNA
 	}
-	if pos.Line != 1 {
+	if pos.Line > 1 {
 		return value.ErrNA
 	}
 	return c.rows[pos.Column-1]
@@ -49,7 +63,7 @@ func (c sheetContext) Resolve(string) value.Value {
 
 func (c sheetContext) Range(start, end layout.Position) value.Value {
 	if start.Sheet == "" || start.Sheet == c.view.Name() {
-		rg := layout.NewRange(start, end)
+		rg := UsedRange(c.view, start.WithoutSheet(), end.WithoutSheet())
 		return ArrayView(NewBoundedView(c.view, rg))
 	}
 	return value.ErrRef
@@ -67,7 +81,7 @@ func (c sheetContext) At(pos layout.Position) value.Value {
 		if err != nil || cell == nil {
 			return value.ErrRef
 		}
-		if f := cell.Formula(); f != nil {
+		if f := cell.Formula(); f != nil && cell.Dirty() {
 			return f
 		}
 		return cell.Value()
@@ -112,7 +126,18 @@ func (c fileContext) At(pos layout.Position) value.Value {
 	if err != nil {
 		return value.ErrRef
 	}
-	return SheetContext(sh).At(pos)
+	var (
+		ctx = SheetContext(sh)
+		val = ctx.At(pos)
+	)
+	if f, ok := val.(value.Formula); ok {
+		// references of the formula are relative to the sheet of the cell
+		val, err = Eval(f, EnclosedContext(c, ctx))
+		if err != nil {
+			return value.ErrValue
+		}
+	}
+	return val
 }
 
 func (c fileContext) Range(start, end layout.Position) value.Value {
@@ -126,6 +151,39 @@ func (c fileContext) Range(start, end layout.Position) value.Value {
 	return SheetContext(sh).Range(start, end)
 }
 
+// SheetSpan gives the names of the sheets found between first and last in
+// the file, both included.
+func (c fileContext) SheetSpan(first, last string) ([]string, error) {
+	var (
+		names  []string
+		x1, x2 = -1, -1
+	)
+	for i, sh := range c.file.Sheets() {
+		names = append(names, sh.Name())
+		if sh.Name() == first {
+			x1 = i
+		}
+		if sh.Name() == last {
+			x2 = i
+		}
+	}
+	if x1 < 0 || x2 < 0 {
+		return nil, ErrFound
+	}
+	if x1 > x2 {
+		x1, x2 = x2, x1
+	}
+	return names[x1 : x2+1], nil
+}
+
+// TableInfo gives the table with the given name when the file has tables.
+func (c fileContext) TableInfo(name string) (TableInfo, error) {
+	if tc, ok := c.file.(tableContext); ok {
+		return tc.TableInfo(name)
+	}
+	return TableInfo{}, ErrFound
+}
+
 func (c fileContext) sheet(name string) (View, error) {
 	if name == "" {
 		return c.file.ActiveSheet()
@@ -162,6 +220,37 @@ func (c evalContext) At(pos layout.Position) value.Value {
 	return value.ErrValue
 }
 
+func (c evalContext) SheetSpan(first, last string) ([]string, error) {
+	for _, ctx := range []value.Context{c.child, c.parent} {
+		if sc, ok := ctx.(spanContext); ok {
+			return sc.SheetSpan(first, last)
+		}
+	}
+	return nil, ErrFound
+}
+
+func (c evalContext) Book(name string) (File, error) {
+	for _, ctx := range []value.Context{c.child, c.parent} {
+		if bc, ok := ctx.(bookContext); ok {
+			return bc.Book(name)
+		}
+	}
+	return nil, ErrFound
+}
+
+func (c evalContext) TableInfo(name string) (TableInfo, error) {
+	for _, ctx := range []value.Context{c.child, c.parent} {
+		tc, ok := ctx.(tableContext)
+		if !ok {
+			continue
+		}
+		if t, err := tc.TableInfo(name); err == nil {
+			return t, nil
+		}
+	}
+	return TableInfo{}, ErrFound
+}
+
 func (c evalContext) Range(start, end layout.Position) value.Value {
 	if c.child != nil {
 		val := c.child.Range(start, end)
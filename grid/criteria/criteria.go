@@ -1,6 +1,9 @@
 package criteria
 
 import (
+	"cmp"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/midbel/dockit/value"
@@ -10,34 +13,53 @@ type Filter interface {
 	Keep(value.Value) bool
 }
 
+// operators of criteria, the longest ones first
+var operators = []string{"<>", ">=", "<=", "=", ">", "<"}
+
+// New gives the filter of a criteria as written in spreadsheets: an optional
+// comparison operator followed by a number, a date, a boolean or a text. Texts
+// are compared without regard to case and may contain the wildcards ? and *
+// when they are compared for equality.
 func New(predicate string) (Filter, error) {
-	predicate = strings.TrimSpace(predicate)
+	var (
+		op      = "="
+		operand = predicate
+	)
+	for _, o := range operators {
+		if rest, ok := strings.CutPrefix(predicate, o); ok {
+			op, operand = o, rest
+			break
+		}
+	}
+	f := valFilter{
+		op:    op,
+		value: parseOperand(operand),
+	}
+	if t, ok := f.value.(value.Text); ok && (op == "=" || op == "<>") {
+		re, err := regexp.Compile("(?is)^" + WildcardPattern(string(t)) + "$")
+		if err != nil {
+			return nil, err
+		}
+		f.pattern = re
+	}
+	return f, nil
+}
 
-	var f valFilter
-	switch {
-	case strings.HasPrefix(predicate, "="):
-		f.value = value.Text(predicate[1:])
-		f.cmp = value.Eq
-	case strings.HasPrefix(predicate, "<>"):
-		f.value = value.Text(predicate[2:])
-		f.cmp = value.Ne
-	case strings.HasPrefix(predicate, ">"):
-		f.value = value.Text(predicate[1:])
-		f.cmp = value.Gt
-	case strings.HasPrefix(predicate, ">="):
-		f.value = value.Text(predicate[2:])
-		f.cmp = value.Ge
-	case strings.HasPrefix(predicate, "<"):
-		f.value = value.Text(predicate[1:])
-		f.cmp = value.Lt
-	case strings.HasPrefix(predicate, "<="):
-		f.value = value.Text(predicate[2:])
-		f.cmp = value.Le
+// FromValue gives the filter of a criteria given as a value. Criteria that are
+// not texts keep the values equal to them.
+func FromValue(val value.Value) (Filter, error) {
+	switch val.Type() {
+	case value.TypeText:
+		return New(val.String())
+	case value.TypeBlank:
+		return New("")
 	default:
-		f.value = value.Text(predicate)
-		f.cmp = value.Eq
+		f := valFilter{
+			op:    "=",
+			value: val,
+		}
+		return f, nil
 	}
-	return f, nil
 }
 
 func Match(val value.Value, predicate string) bool {
@@ -48,32 +70,134 @@ func Match(val value.Value, predicate string) bool {
 	return f.Keep(val)
 }
 
+// WildcardPattern gives the regular expression matching the same texts as a
+// pattern with wildcards: ? matches any character and * any sequence of
+// characters. A wildcard preceded by ~ matches itself.
+func WildcardPattern(str string) string {
+	var (
+		pat    strings.Builder
+		escape bool
+	)
+	for _, c := range str {
+		switch {
+		case escape:
+			pat.WriteString(regexp.QuoteMeta(string(c)))
+			escape = false
+		case c == '~':
+			escape = true
+		case c == '?':
+			pat.WriteString(".")
+		case c == '*':
+			pat.WriteString(".*?")
+		default:
+			pat.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if escape {
+		pat.WriteString("~")
+	}
+	return pat.String()
+}
+
+func parseOperand(str string) value.Value {
+	if str == "" {
+		return value.Empty()
+	}
+	if n, err := strconv.ParseFloat(strings.TrimSpace(str), 64); err == nil {
+		return value.Float(n)
+	}
+	switch strings.ToUpper(str) {
+	case "TRUE":
+		return value.Boolean(true)
+	case "FALSE":
+		return value.Boolean(false)
+	}
+	if d, err := value.CastToDate(value.Text(str)); err == nil {
+		return d
+	}
+	return value.Text(str)
+}
+
 type valFilter struct {
-	value value.Value
-	cmp   func(value.Value, value.Value) value.Value
+	op      string
+	value   value.Value
+	pattern *regexp.Regexp
 }
 
 func (f valFilter) Keep(val value.Value) bool {
-	var (
-		other value.Value
-		err   error
-	)
-	switch val.Type() {
-	case value.TypeNumber:
-		other, err = value.CastToFloat(f.value)
-	case value.TypeText:
-		other, err = value.CastToText(f.value)
-	case value.TypeDate:
-		other, err = value.CastToDate(f.value)
-	case value.TypeBool:
-		t := value.True(f.value)
-		other = value.Boolean(t)
-	default:
+	if value.IsError(val) {
 		return false
 	}
-	if err != nil {
+	cmp, ok := f.compare(val)
+	if !ok {
+		// values of other types are only kept when they must be different
+		return f.op == "<>"
+	}
+	switch f.op {
+	case "=":
+		return cmp == 0
+	case "<>":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
 		return false
 	}
-	res := f.cmp(val, other)
-	return value.True(res)
+}
+
+// compare compares val with the operand of the criteria. It reports false when
+// they can not be compared.
+func (f valFilter) compare(val value.Value) (int, bool) {
+	switch x := f.value.(type) {
+	case value.Blank:
+		if value.IsBlank(val) || val.String() == "" {
+			return 0, true
+		}
+		return 1, true
+	case value.Float, value.Date:
+		if value.IsBlank(val) {
+			return 0, false
+		}
+		n, err := value.CastToFloat(val)
+		if err != nil || val.Type() == value.TypeBool {
+			return 0, false
+		}
+		return cmp.Compare(float64(n), asFloat(x)), true
+	case value.Boolean:
+		b, ok := val.(value.Boolean)
+		if !ok {
+			return 0, false
+		}
+		if b == x {
+			return 0, true
+		}
+		if b {
+			return 1, true
+		}
+		return -1, true
+	case value.Text:
+		if val.Type() != value.TypeText {
+			return 0, false
+		}
+		if f.pattern != nil {
+			if f.pattern.MatchString(val.String()) {
+				return 0, true
+			}
+			return 1, true
+		}
+		return strings.Compare(strings.ToLower(val.String()), strings.ToLower(x.String())), true
+	default:
+		return 0, false
+	}
+}
+
+func asFloat(val value.Value) float64 {
+	n, _ := value.CastToFloat(val)
+	return float64(n)
 }
@@ -2,6 +2,7 @@ package grid
 
 import (
 	"fmt"
+	"math"
 	"strings"
 
 	"github.com/midbel/dockit/formula/op"
@@ -49,14 +50,20 @@ func eval(expr parse.Expr, ctx value.Context) value.Value {
 		return value.Text(e.Text())
 	case parse.Number:
 		return value.Float(e.Float())
+	case parse.Date:
+		return value.Date(e.Time())
 	case parse.Call:
 		return evalCall(e, ctx)
 	case parse.CellAddr:
 		return evalCellAddr(e, ctx)
+	case parse.ColumnAddr:
+		return ctx.At(e.Position)
 	case parse.RangeAddr:
 		return evalRangeAddr(e, ctx)
 	case parse.CellAccess:
 		return evalCellAccess(e, ctx)
+	case parse.TableRef:
+		return evalTableRef(e, ctx)
 	default:
 		return value.ErrNA
 	}
@@ -82,6 +89,8 @@ func evalIdentifier(e parse.Identifier, ctx value.Context) value.Value {
 		return value.ErrNum
 	case value.ErrNA.String():
 		return value.ErrNA
+	case value.ErrSpill.String():
+		return value.ErrSpill
 	default:
 		col, _ := layout.ParseIndex(e.Ident())
 		return ctx.At(layout.NewPosition(0, col))
@@ -89,6 +98,9 @@ func evalIdentifier(e parse.Identifier, ctx value.Context) value.Value {
 }
 
 func evalBinary(e parse.Binary, ctx value.Context) value.Value {
+	if e.Op() == op.Intersect {
+		return evalIntersect(e, ctx)
+	}
 	var (
 		left  = eval(e.Left(), ctx)
 		right = eval(e.Right(), ctx)
@@ -96,8 +108,157 @@ func evalBinary(e parse.Binary, ctx value.Context) value.Value {
 	if err := value.HasErrors(left, right); err != nil {
 		return err
 	}
+	if e.Op() == op.Union {
+		return value.NewComposite(left, right)
+	}
+	if value.IsArray(left) || value.IsArray(right) {
+		return evalArrayBinary(e.Op(), left, right)
+	}
+	return evalScalarBinary(e.Op(), left, right)
+}
 
-	switch e.Op() {
+// evalIntersect gives the cells shared by two references. References that
+// do not overlap give #NULL!.
+func evalIntersect(e parse.Binary, ctx value.Context) value.Value {
+	rg, err := referenceOf(e)
+	if err != nil {
+		return err
+	}
+	if rg.Starts.Equal(rg.Ends) {
+		return eval(parse.NewCellAddr(rg.Starts, false, false), ctx)
+	}
+	return ctx.Range(rg.Starts, rg.Ends)
+}
+
+// intersectBound gives the bound of the intersection of two ranges. Whole
+// columns and whole rows have no bound for their lines or their columns.
+func intersectBound(b1, b2 int64, end bool) int64 {
+	switch {
+	case b1 == 0:
+		return b2
+	case b2 == 0:
+		return b1
+	case end:
+		return min(b1, b2)
+	default:
+		return max(b1, b2)
+	}
+}
+
+// referenceOf gives the range of cells referenced by an expression.
+func referenceOf(expr parse.Expr) (*layout.Range, value.Value) {
+	switch e := expr.(type) {
+	case parse.CellAddr:
+		return layout.NewRange(e.Position, e.Position), nil
+	case parse.RangeAddr:
+		return layout.NewRange(e.StartAt().Position, e.EndAt().Position).Normalize(), nil
+	case parse.CellAccess:
+		var sheet string
+		switch ident := e.Expr().(type) {
+		case parse.Identifier:
+			sheet = ident.Ident()
+		case parse.Literal:
+			sheet = ident.Text()
+		default:
+			return nil, value.ErrValue
+		}
+		rg, err := referenceOf(e.Addr())
+		if err != nil {
+			return nil, err
+		}
+		rg.Starts.Sheet = sheet
+		rg.Ends.Sheet = sheet
+		return rg, nil
+	case parse.Binary:
+		if e.Op() != op.Intersect {
+			return nil, value.ErrValue
+		}
+		left, err := referenceOf(e.Left())
+		if err != nil {
+			return nil, err
+		}
+		right, err := referenceOf(e.Right())
+		if err != nil {
+			return nil, err
+		}
+		if left.Starts.Sheet != right.Starts.Sheet {
+			return nil, value.ErrNull
+		}
+		rg := layout.NewRange(left.Starts, left.Ends)
+		rg.Starts.Line = intersectBound(left.Starts.Line, right.Starts.Line, false)
+		rg.Starts.Column = intersectBound(left.Starts.Column, right.Starts.Column, false)
+		rg.Ends.Line = intersectBound(left.Ends.Line, right.Ends.Line, true)
+		rg.Ends.Column = intersectBound(left.Ends.Column, right.Ends.Column, true)
+		if rg.Starts.Line > rg.Ends.Line && rg.Ends.Line != 0 {
+			return nil, value.ErrNull
+		}
+		if rg.Starts.Column > rg.Ends.Column && rg.Ends.Column != 0 {
+			return nil, value.ErrNull
+		}
+		return rg, nil
+	default:
+		return nil, value.ErrValue
+	}
+}
+
+// evalArrayBinary applies an operator to each value of the arrays given. A
+// scalar, an array of one line or an array of one column is repeated to
+// match the size of the other array. Values missing from the smaller array
+// give #N/A.
+func evalArrayBinary(oper op.Op, left, right value.Value) value.Value {
+	var (
+		ld, lv = arrayOperand(left)
+		rd, rv = arrayOperand(right)
+		lines  = max(ld.Lines, rd.Lines)
+		cols   = max(ld.Columns, rd.Columns)
+		data   = make([][]value.Value, lines)
+	)
+	for i := range data {
+		data[i] = make([]value.Value, cols)
+		for j := range data[i] {
+			var (
+				x = lv(i, j)
+				y = rv(i, j)
+			)
+			if err := value.HasErrors(x, y); err != nil {
+				data[i][j] = err
+				continue
+			}
+			data[i][j] = evalScalarBinary(oper, x, y)
+		}
+	}
+	return value.NewArray(data)
+}
+
+func arrayOperand(val value.Value) (layout.Dimension, func(int, int) value.Value) {
+	arr, ok := val.(value.ArrayValue)
+	if !ok {
+		dim := layout.Dimension{
+			Lines:   1,
+			Columns: 1,
+		}
+		return dim, func(int, int) value.Value {
+			return val
+		}
+	}
+	dim := arr.Dimension()
+	get := func(row, col int) value.Value {
+		if dim.Lines == 1 {
+			row = 0
+		}
+		if dim.Columns == 1 {
+			col = 0
+		}
+		if int64(row) >= dim.Lines || int64(col) >= dim.Columns {
+			return value.ErrNA
+		}
+		return arr.At(row, col)
+	}
+	return dim, get
+}
+
+func evalScalarBinary(oper op.Op, left, right value.Value) value.Value {
+	switch oper {
 	case op.Add:
 		return value.Add(left, right)
 	case op.Sub:
@@ -167,15 +328,68 @@ func evalCall(e parse.Call, ctx value.Context) value.Value {
 	if !ok {
 		return value.ErrName
 	}
+	b, err := builtins.Get(id.Ident())
+	if err != nil {
+		return value.ErrName
+	}
+	if b.Name == "offset" {
+		return evalOffset(e, ctx)
+	}
 	var args []value.Value
-	for _, e := range e.Args() {
+	for i, e := range e.Args() {
+		if b.Deferred(i) {
+			args = append(args, builtins.Defer(func() value.Value {
+				return eval(e, ctx)
+			}))
+			continue
+		}
 		args = append(args, eval(e, ctx))
 	}
-	fn, err := builtins.Lookup(id.Ident())
+	return b.Bind(ctx)(args)
+}
+
+// evalOffset gives the cells of a reference moved by a number of rows and
+// columns. The result has the size of the reference unless a height and a
+// width are given. References moved outside of the sheet give #REF!.
+func evalOffset(e parse.Call, ctx value.Context) value.Value {
+	args := e.Args()
+	if len(args) < 3 || len(args) > 5 {
+		return value.ErrValue
+	}
+	rg, err := referenceOf(args[0])
 	if err != nil {
-		return value.ErrName
+		return err
+	}
+	if rg.Starts.Line == 0 || rg.Starts.Column == 0 {
+		// whole columns and whole rows can not be moved
+		return value.ErrRef
+	}
+	sizes := []int64{0, 0, rg.Height(), rg.Width()}
+	for i, a := range args[1:] {
+		v := eval(a, ctx)
+		if value.IsError(v) {
+			return v
+		}
+		n, err := value.CastToFloat(v)
+		if err != nil {
+			return value.ErrValue
+		}
+		sizes[i] = int64(math.Floor(float64(n)))
 	}
-	return fn(args)
+	if sizes[2] < 1 || sizes[3] < 1 {
+		return value.ErrRef
+	}
+	var (
+		starts = rg.Starts.Offset(sizes[0], sizes[1])
+		ends   = starts.Offset(sizes[2]-1, sizes[3]-1)
+	)
+	if starts.Line < 1 || starts.Column < 1 {
+		return value.ErrRef
+	}
+	if starts.Equal(ends) {
+		return eval(parse.NewCellAddr(starts, false, false), ctx)
+	}
+	return ctx.Range(starts, ends)
 }
 
 func evalCellAccess(e parse.CellAccess, ctx value.Context) value.Value {
@@ -185,6 +399,10 @@ func evalCellAccess(e parse.CellAccess, ctx value.Context) value.Value {
 		sheet = ident.Ident()
 	case parse.Literal:
 		sheet = ident.Text()
+	case parse.SheetSpan:
+		return evalSheetSpan(ident, e.Addr(), ctx)
+	case parse.ExternalSheet:
+		return evalExternal(ident, e.Addr(), ctx)
 	default:
 		return value.ErrValue
 	}
@@ -207,6 +425,40 @@ func evalCellAccess(e parse.CellAccess, ctx value.Context) value.Value {
 	return eval(expr, ctx)
 }
 
+// evalSheetSpan gives the union of the values found at the same address in
+// each sheet of a span of sheets.
+func evalSheetSpan(span parse.SheetSpan, addr parse.Expr, ctx value.Context) value.Value {
+	sc, ok := ctx.(spanContext)
+	if !ok {
+		return value.ErrRef
+	}
+	names, err := sc.SheetSpan(span.First(), span.Last())
+	if err != nil {
+		return value.ErrRef
+	}
+	var areas []value.Value
+	for _, n := range names {
+		access := parse.NewCellAccess(parse.NewLiteral(n), addr)
+		areas = append(areas, eval(access, ctx))
+	}
+	return value.NewComposite(areas...)
+}
+
+// evalExternal gives the value found at an address in a sheet of another
+// workbook. References to workbooks that are not available are errors.
+func evalExternal(ext parse.ExternalSheet, addr parse.Expr, ctx value.Context) value.Value {
+	bc, ok := ctx.(bookContext)
+	if !ok {
+		return value.ErrRef
+	}
+	file, err := bc.Book(ext.Book())
+	if err != nil {
+		return value.ErrRef
+	}
+	access := parse.NewCellAccess(parse.NewLiteral(ext.Sheet()), addr)
+	return eval(access, FileContext(file))
+}
+
 func evalCellAddr(e parse.CellAddr, ctx value.Context) value.Value {
 	val := ctx.At(e.Position)
 	if f, ok := val.(value.Formula); ok {
@@ -231,6 +483,14 @@ func ParseOxmlFormula(str string) (value.Formula, error) {
 	return NewFormula(expr), nil
 }
 
+func ParseOxmlLocaleFormula(str string) (value.Formula, error) {
+	expr, err := parse.ParseOxmlLocaleFormula(str)
+	if err != nil {
+		return nil, err
+	}
+	return NewFormula(expr), nil
+}
+
 func ParseOdsFormula(str string) (value.Formula, error) {
 	expr, err := parse.ParseOdsFormula(str)
 	if err != nil {
@@ -254,6 +514,17 @@ func Rebase(fm value.Formula, anchor, target layout.Position) value.Formula {
 	return other
 }
 
+// Relocate moves the cells referenced by a formula to the position given by
+// fn.
+func Relocate(fm value.Formula, fn parse.RelocateFunc) value.Formula {
+	if r, ok := fm.(interface {
+		Relocate(parse.RelocateFunc) value.Formula
+	}); ok {
+		return r.Relocate(fn)
+	}
+	return fm
+}
+
 type formula struct {
 	expr parse.Expr
 }
@@ -293,6 +564,10 @@ func (f formula) Eval(ctx value.Context) value.Value {
 	return eval(f.expr, ctx)
 }
 
+func (f formula) Relocate(fn parse.RelocateFunc) value.Formula {
+	return NewFormula(parse.Relocate(f.expr, fn))
+}
+
 func (f formula) Clone(pos layout.Position) value.Formula {
 	if c, ok := f.expr.(parse.Clonable); ok {
 		return NewFormula(c.CloneWithOffset(pos))
@@ -6,6 +6,8 @@ import (
 
 	"github.com/midbel/dockit/grid"
 	"github.com/midbel/dockit/internal/testutil"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/oxml"
 	"github.com/midbel/dockit/value"
 )
 
@@ -86,6 +88,358 @@ func TestFormula(t *testing.T) {
 	t.Run("compare-generated", testCompareGenerated)
 	t.Run("included-formula", testIncludedFormula)
 	t.Run("extended-formula", testExtendedFormula)
+	t.Run("logical", testLogical)
+	t.Run("criteria", testCriteria)
+	t.Run("arrays", testArrays)
+	t.Run("references", testReferences)
+}
+
+func testReferences(t *testing.T) {
+	tests := []FormulaTestCase{
+		{
+			Formula: "=SUM((B1:B2,sheet2!B1:B2))",
+			Want:    "22",
+		},
+		{
+			Formula: "=COUNT((B1,B2,C1))",
+			Want:    "3",
+		},
+		{
+			Formula: "=AVERAGE((B1:B2,sheet2!B1:B2))",
+			Want:    "5.5",
+		},
+		{
+			Formula: "=SUM(A1:B2 B1:C2)",
+			Want:    "7",
+		},
+		{
+			Formula: "=A1:C2 B2",
+			Want:    "5",
+		},
+		{
+			Formula: "=SUM(B1:C2 C1:C2 C2)",
+			Want:    "20",
+		},
+		{
+			Formula: "=SUM(B1:B2 C1:C2)",
+			Want:    "#NULL!",
+		},
+		{
+			Formula: "=SUM(B:B)",
+			Want:    "7",
+		},
+		{
+			Formula: "=SUM(sheet2!$B:$B)",
+			Want:    "15",
+		},
+		{
+			Formula: "=COUNT(A:B)",
+			Want:    "2",
+		},
+		{
+			Formula: "=SUM(2:2)",
+			Want:    "5",
+		},
+		{
+			Formula: "=SUM(A:B 2:2)",
+			Want:    "5",
+		},
+		{
+			Formula: "=SUM(sheet1:sheet2!B1)",
+			Want:    "12",
+		},
+		{
+			Formula: "=SUM(sheet2:sheet1!B1:B2)",
+			Want:    "22",
+		},
+		{
+			Formula: "=COUNT(sheet1:sheet3!B2)",
+			Want:    "3",
+		},
+		{
+			Formula: "=SUM('sheet1:sheet2'!B2)",
+			Want:    "10",
+		},
+		{
+			Formula: "=SUM(sheet1:other!B1)",
+			Want:    "#REF!",
+		},
+		{
+			Formula: "=OFFSET(A1, 1, 1)",
+			Want:    "5",
+		},
+		{
+			Formula: "=SUM(OFFSET(A1, 0, 1, 2, 1))",
+			Want:    "7",
+		},
+		{
+			Formula: "=SUM(OFFSET(sheet2!A1, 0, 1, 2))",
+			Want:    "15",
+		},
+		{
+			Formula: "=OFFSET(A1, -1, 0)",
+			Want:    "#REF!",
+		},
+		{
+			Formula: "=OFFSET(B:B, 1, 0)",
+			Want:    "#REF!",
+		},
+	}
+	runTests(t, tests)
+}
+
+type bookContext struct {
+	value.Context
+	books map[string]grid.File
+}
+
+func (c bookContext) Book(name string) (grid.File, error) {
+	file, ok := c.books[name]
+	if !ok {
+		return nil, grid.ErrFound
+	}
+	return file, nil
+}
+
+func TestEvalExternal(t *testing.T) {
+	tests := []FormulaTestCase{
+		{
+			Formula: "=[other.xlsx]sheet2!B1 + B1",
+			Want:    "12",
+		},
+		{
+			Formula: "=SUM([other.xlsx]sheet1!B1:B2)",
+			Want:    "7",
+		},
+		{
+			Formula: "='[other.xlsx]sheet1'!C1",
+			Want:    "24",
+		},
+		{
+			Formula: "=[missing.xlsx]sheet1!B1",
+			Want:    "#REF!",
+		},
+		{
+			Formula: "=[other.xlsx]missing!B1",
+			Want:    "#REF!",
+		},
+	}
+	var (
+		file  = testutil.CreateFile()
+		books = bookContext{
+			Context: grid.FileContext(file),
+			books: map[string]grid.File{
+				"other.xlsx": testutil.CreateFile(),
+			},
+		}
+		ctx = grid.EnclosedContext(books, grid.FileContext(file))
+	)
+	for _, c := range tests {
+		val, err := grid.EvalString(c.Formula, ctx)
+		if err != nil {
+			t.Errorf("%s: error executing formula: %s", c.Formula, err)
+			continue
+		}
+		if got := val.String(); got != c.Want {
+			t.Errorf("%s: result mismatched! want %s, got %s", c.Formula, c.Want, got)
+		}
+	}
+	val, err := grid.EvalString("=[other.xlsx]sheet1!B1", grid.FileContext(file))
+	if err != nil || val != value.ErrRef {
+		t.Errorf("reference to workbook without context: want #REF!, got %v (%v)", val, err)
+	}
+}
+
+func TestEvalTables(t *testing.T) {
+	var (
+		file  = oxml.NewFile()
+		sheet = oxml.NewSheet("data")
+	)
+	sheet.SetValue(layout.NewPosition(1, 1), value.Text("name"))
+	sheet.SetValue(layout.NewPosition(1, 2), value.Text("amount"))
+	sheet.SetValue(layout.NewPosition(2, 1), value.Text("foo"))
+	sheet.SetValue(layout.NewPosition(2, 2), value.Float(10))
+	sheet.SetValue(layout.NewPosition(3, 1), value.Text("bar"))
+	sheet.SetValue(layout.NewPosition(3, 2), value.Float(32))
+	sheet.SetValue(layout.NewPosition(4, 1), value.Text("total"))
+	fm, _ := grid.ParseOxmlFormula("=SUM(Sales[Amount])")
+	sheet.SetFormula(layout.NewPosition(4, 2), fm)
+	if err := file.AppendSheet(sheet); err != nil {
+		t.Fatalf("error adding sheet: %s", err)
+	}
+	tbl, err := sheet.AddTable("Sales", layout.NewRange(layout.NewPosition(1, 1), layout.NewPosition(4, 2)), "")
+	if err != nil {
+		t.Fatalf("error adding table: %s", err)
+	}
+	tbl.Totals = true
+	if err := file.Sync(); err != nil {
+		t.Fatalf("error evaluating formulas: %s", err)
+	}
+
+	tests := []FormulaTestCase{
+		{
+			Formula: "=Sales[[#Totals],[Amount]]",
+			Want:    "42",
+		},
+		{
+			Formula: "=COUNT(Sales[])",
+			Want:    "2",
+		},
+		{
+			Formula: "=Sales[[#Headers],[amount]]",
+			Want:    "amount",
+		},
+		{
+			Formula: "=COUNT(Sales[[#Data],[#Totals],[Name]:[Amount]])",
+			Want:    "3",
+		},
+		{
+			Formula: "=Sales[Price]",
+			Want:    "#REF!",
+		},
+		{
+			Formula: "=Orders[Amount]",
+			Want:    "#REF!",
+		},
+		{
+			Formula: "=Sales[#This Row]",
+			Want:    "#REF!",
+		},
+	}
+	ctx := grid.FileContext(file)
+	for _, c := range tests {
+		val, err := grid.EvalString(c.Formula, ctx)
+		if err != nil {
+			t.Errorf("%s: error executing formula: %s", c.Formula, err)
+			continue
+		}
+		if got := val.String(); got != c.Want {
+			t.Errorf("%s: result mismatched! want %s, got %s", c.Formula, c.Want, got)
+		}
+	}
+
+	sheet.SetValue(layout.NewPosition(2, 2), value.Float(20))
+	if err := file.Sync(); err != nil {
+		t.Fatalf("error evaluating formulas: %s", err)
+	}
+	cell, _ := sheet.Cell(layout.NewPosition(4, 2))
+	if got := cell.Value().String(); got != "52" {
+		t.Errorf("totals not updated: want 52, got %s", got)
+	}
+}
+
+func testArrays(t *testing.T) {
+	tests := []FormulaTestCase{
+		{
+			Formula: "=SUM(B1:B2*2)",
+			Want:    "14",
+		},
+		{
+			Formula: "=SUM(B1:B2*B1:B2)",
+			Want:    "29",
+		},
+		{
+			Formula: "=SUM(10-B1:B2)",
+			Want:    "13",
+		},
+		{
+			Formula: "=SUM(B1:B2/0)",
+			Want:    "#DIV/0!",
+		},
+		{
+			Formula: "=SUM(B1:B2+B1:B1)",
+			Want:    "11",
+		},
+	}
+	runTests(t, tests)
+}
+
+func testLogical(t *testing.T) {
+	tests := []FormulaTestCase{
+		{
+			Formula: "=IF(B1<B2, 'less', 1/0)",
+			Want:    "less",
+		},
+		{
+			Formula: "=IF(B1>B2, 'more')",
+			Want:    "false",
+		},
+		{
+			Formula: "=IF(1/0, 1, 2)",
+			Want:    "#DIV/0!",
+		},
+		{
+			Formula: "=AND(B1:B2)",
+			Want:    "true",
+		},
+		{
+			Formula: "=AND(A1:B2, B1>B2)",
+			Want:    "false",
+		},
+		{
+			Formula: "=AND('foo', TRUE)",
+			Want:    "#VALUE!",
+		},
+		{
+			Formula: "=OR(B1>10, B2>10, B1=2)",
+			Want:    "true",
+		},
+		{
+			Formula: "=OR(A1:A2)",
+			Want:    "#VALUE!",
+		},
+		{
+			Formula: "=NOT(B1>B2)",
+			Want:    "true",
+		},
+		{
+			Formula: "=IFERROR(1/0, 'none')",
+			Want:    "none",
+		},
+		{
+			Formula: "=IFNA(1/0, 0)",
+			Want:    "#DIV/0!",
+		},
+	}
+	runTests(t, tests)
+}
+
+func testCriteria(t *testing.T) {
+	tests := []FormulaTestCase{
+		{
+			Formula: `=SUMIF(B1:B2, ">2")`,
+			Want:    "5",
+		},
+		{
+			Formula: `=SUMIF(A1:A2, "B*", B1:B2)`,
+			Want:    "5",
+		},
+		{
+			Formula: `=SUMIFS(B1:B2, A1:A2, "*", B1:B2, "<5")`,
+			Want:    "2",
+		},
+		{
+			Formula: `=COUNTIF(A1:A2, "<>foo")`,
+			Want:    "1",
+		},
+		{
+			Formula: `=COUNTIF(B1:B2, 5)`,
+			Want:    "1",
+		},
+		{
+			Formula: `=COUNTIFS(A1:A2, "?oo", B1:B2, ">=2")`,
+			Want:    "1",
+		},
+		{
+			Formula: `=AVERAGEIF(B1:B2, ">=2")`,
+			Want:    "3.5",
+		},
+		{
+			Formula: `=AVERAGEIF(B1:B2, ">10")`,
+			Want:    "#DIV/0!",
+		},
+	}
+	runTests(t, tests)
 }
 
 func testExtendedFormula(t *testing.T) {
@@ -136,6 +490,11 @@ func testIncludedFormula(t *testing.T) {
 			Want:    "BAR",
 			Sheet:   "sheet2",
 		},
+		{
+			Formula: "=sheet1!C1",
+			Want:    "24",
+			Sheet:   "sheet3",
+		},
 		{
 			Formula: "=B1",
 			Want:    "7",
@@ -0,0 +1,304 @@
+package format
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/midbel/dockit/value"
+)
+
+// patternPart is a piece of a section: either a quoted literal text or a piece
+// of the pattern itself.
+type patternPart struct {
+	text   string
+	quoted bool
+}
+
+type compositeFormatter struct {
+	numbers  []Formatter
+	text     Formatter
+	date1904 bool
+}
+
+// ParseFormatter parses a pattern made of up to four sections separated by
+// semicolons, as used by the spreadsheets: the first section formats the
+// positive numbers, the second the negative ones, the third the zeros and the
+// last one the texts. Each section can be a number pattern with literal texts
+// around it, a date pattern, a text pattern where @ is replaced by the text
+// or a literal text. Texts between double quotes are always written as is.
+//
+// With only one section, all numbers use it. With two sections, zeros use the
+// first one. A section containing @ is the text section whatever its position.
+func ParseFormatter(pattern string) (Formatter, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("invalid pattern given")
+	}
+	sections, err := splitSections(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(sections) > 4 {
+		return nil, fmt.Errorf("too many sections in pattern")
+	}
+	var cf compositeFormatter
+	for i, parts := range sections {
+		if i == 3 || hasPlaceholder(parts, "@") {
+			if cf.text != nil {
+				return nil, fmt.Errorf("pattern has more than one text section")
+			}
+			cf.text = textSection(parts)
+			continue
+		}
+		f, err := parseSection(parts)
+		if err != nil {
+			return nil, err
+		}
+		cf.numbers = append(cf.numbers, f)
+	}
+	return cf, nil
+}
+
+// Date1904 gives a formatter using the 1904 date system to read the numbers
+// given to its date sections and to write dates as numbers. Other formatters
+// are given unchanged.
+func Date1904(f Formatter) Formatter {
+	cf, ok := f.(compositeFormatter)
+	if !ok {
+		return f
+	}
+	cf.date1904 = true
+	return cf
+}
+
+func (f compositeFormatter) Format(v value.Value) (string, error) {
+	switch x := v.(type) {
+	case value.Float:
+		return f.formatNumber(float64(x))
+	case value.Date:
+		if len(f.numbers) > 0 {
+			if df, ok := f.numbers[0].(dateFormatter); ok {
+				return df.Format(x)
+			}
+		}
+		return f.formatNumber(x.Serial(f.date1904))
+	case value.Text:
+		if n, err := value.CastToFloat(x); err == nil {
+			return f.formatNumber(float64(n))
+		}
+		if f.text != nil {
+			return f.text.Format(x)
+		}
+		return x.String(), nil
+	default:
+		return v.String(), nil
+	}
+}
+
+func (f compositeFormatter) formatNumber(num float64) (string, error) {
+	if len(f.numbers) == 0 {
+		return value.Float(num).String(), nil
+	}
+	var section Formatter
+	switch {
+	case num < 0 && len(f.numbers) >= 2:
+		num, section = math.Abs(num), f.numbers[1]
+	case num == 0 && len(f.numbers) >= 3:
+		section = f.numbers[2]
+	default:
+		section = f.numbers[0]
+	}
+	if _, ok := section.(dateFormatter); ok {
+		return section.Format(value.DateFromSerial(num, f.date1904))
+	}
+	return section.Format(value.Float(num))
+}
+
+// numberSection is a number pattern with the literal texts written around the
+// number. A % outside of quotes gives the number as a percentage.
+type numberSection struct {
+	prefix  string
+	suffix  string
+	percent bool
+	number  Formatter
+}
+
+func (s numberSection) Format(v value.Value) (string, error) {
+	vf, ok := v.(value.Float)
+	if !ok {
+		return "", fmt.Errorf("value is not a number")
+	}
+	if s.percent {
+		vf *= 100
+	}
+	str, err := s.number.Format(vf)
+	if err != nil {
+		return "", err
+	}
+	return s.prefix + str + s.suffix, nil
+}
+
+type textSection []patternPart
+
+func (s textSection) Format(v value.Value) (string, error) {
+	var str strings.Builder
+	for _, p := range s {
+		if p.quoted {
+			str.WriteString(p.text)
+			continue
+		}
+		str.WriteString(strings.ReplaceAll(p.text, "@", v.String()))
+	}
+	return str.String(), nil
+}
+
+type literalSection string
+
+func (s literalSection) Format(_ value.Value) (string, error) {
+	return string(s), nil
+}
+
+func parseSection(parts []patternPart) (Formatter, error) {
+	switch {
+	case isDateSection(parts):
+		return dateSection(parts), nil
+	case hasPlaceholder(parts, "0#"):
+		return parseNumberSection(parts)
+	default:
+		var str strings.Builder
+		for _, p := range parts {
+			str.WriteString(p.text)
+		}
+		return literalSection(str.String()), nil
+	}
+}
+
+func parseNumberSection(parts []patternPart) (Formatter, error) {
+	var (
+		all     strings.Builder
+		literal []bool
+	)
+	for _, p := range parts {
+		all.WriteString(p.text)
+		for range len(p.text) {
+			literal = append(literal, p.quoted)
+		}
+	}
+	var (
+		str   = all.String()
+		start = -1
+		end   = -1
+	)
+	for i := range str {
+		if literal[i] || (str[i] != '0' && str[i] != '#') {
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		end = i
+	}
+	for i := start; i <= end; i++ {
+		if literal[i] {
+			return nil, fmt.Errorf("unexpected literal text in number pattern")
+		}
+	}
+	var (
+		ns   numberSection
+		core = str[start : end+1]
+	)
+	ns.prefix, ns.suffix = str[:start], str[end+1:]
+	if strings.HasSuffix(ns.prefix, "+") && !literal[start-1] {
+		core = "+" + core
+		ns.prefix = ns.prefix[:len(ns.prefix)-1]
+	}
+	for i := range str {
+		if (i < start || i > end) && !literal[i] && str[i] == '%' {
+			ns.percent = true
+		}
+	}
+	nf, err := ParseNumberFormatter(core)
+	if err != nil {
+		return nil, err
+	}
+	ns.number = nf
+	return ns, nil
+}
+
+func dateSection(parts []patternPart) Formatter {
+	var df dateFormatter
+	for _, p := range parts {
+		if p.quoted {
+			for i := range len(p.text) {
+				df.writers = append(df.writers, writeLiteralDate(p.text[i]))
+			}
+			continue
+		}
+		f, _ := ParseDateFormatter(p.text)
+		df.writers = append(df.writers, f.(dateFormatter).writers...)
+	}
+	return df
+}
+
+func isDateSection(parts []patternPart) bool {
+	for _, p := range parts {
+		if p.quoted {
+			continue
+		}
+		for _, k := range dateFieldsWriter {
+			if strings.Contains(p.text, k.Pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasPlaceholder(parts []patternPart, chars string) bool {
+	for _, p := range parts {
+		if !p.quoted && strings.ContainsAny(p.text, chars) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitSections splits a pattern on the semicolons that are not inside double
+// quotes and gives the parts of each section.
+func splitSections(pattern string) ([][]patternPart, error) {
+	var (
+		sections [][]patternPart
+		parts    []patternPart
+		str      strings.Builder
+	)
+	flush := func(quoted bool) {
+		if str.Len() > 0 || quoted {
+			parts = append(parts, patternPart{
+				text:   str.String(),
+				quoted: quoted,
+			})
+		}
+		str.Reset()
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '"':
+			flush(false)
+			end := strings.IndexByte(pattern[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated literal in pattern")
+			}
+			str.WriteString(pattern[i+1 : i+1+end])
+			flush(true)
+			i += end + 1
+		case ';':
+			flush(false)
+			sections = append(sections, parts)
+			parts = nil
+		default:
+			str.WriteByte(c)
+		}
+	}
+	flush(false)
+	return append(sections, parts), nil
+}
@@ -243,12 +243,12 @@ func writeDayPadded(w *strings.Builder, t time.Time) {
 }
 
 func writeDayNameShort(w *strings.Builder, t time.Time) {
-	d := t.Weekday() - 1
+	d := (t.Weekday() + 6) % 7
 	w.WriteString(shortDayNames[d])
 }
 
 func writeDayNameLong(w *strings.Builder, t time.Time) {
-	d := t.Weekday() - 1
+	d := (t.Weekday() + 6) % 7
 	w.WriteString(longDayNames[d])
 }
 
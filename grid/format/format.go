@@ -10,7 +10,7 @@ import (
 )
 
 var PatternNames = map[string]string{
-	"ISO": "YYYY-MM-DD",
+	"ISO": "YYYY-0MM-0DD",
 }
 
 const (
@@ -108,6 +108,31 @@ func (vf *ValueFormatter) Format(v value.Value) (string, error) {
 	return v.String(), nil
 }
 
+// FormatPattern formats a single value with the given pattern. Booleans use
+// the boolean modes, other values are formatted by the composite formatter
+// returned by ParseFormatter.
+func FormatPattern(v value.Value, pattern string) (string, error) {
+	if p, ok := PatternNames[pattern]; ok {
+		pattern = p
+	}
+	var (
+		f   Formatter
+		err error
+	)
+	if _, ok := v.(value.Boolean); ok {
+		vf := FormatValue()
+		if err = vf.Bool(pattern); err == nil {
+			f = vf
+		}
+	} else {
+		f, err = ParseFormatter(pattern)
+	}
+	if err != nil {
+		return "", err
+	}
+	return f.Format(v)
+}
+
 type strFormatter struct{}
 
 func FormatString() Formatter {
@@ -54,3 +54,210 @@ func TestFormatter(t *testing.T) {
 		}
 	}
 }
+
+func TestFormatPattern(t *testing.T) {
+	tests := []struct {
+		Input   value.Value
+		Pattern string
+		Want    string
+	}{
+		{
+			Input:   value.Float(1234.5),
+			Pattern: "#,##0.00",
+			Want:    "1,234.50",
+		},
+		{
+			Input:   value.Text("42"),
+			Pattern: "000",
+			Want:    "042",
+		},
+		{
+			Input:   value.Date(time.Date(2026, 2, 22, 14, 5, 9, 0, time.UTC)),
+			Pattern: "DDDD 0DD MMMM YYYY",
+			Want:    "Sunday 22 February 2026",
+		},
+		{
+			Input:   value.Date(time.Date(2026, 2, 20, 14, 5, 9, 0, time.UTC)),
+			Pattern: "ISO",
+			Want:    "2026-02-20",
+		},
+		{
+			Input:   value.Boolean(true),
+			Pattern: "yesno",
+			Want:    "yes",
+		},
+		{
+			Input:   value.Text("foobar"),
+			Pattern: "#,##0.00",
+			Want:    "foobar",
+		},
+	}
+	for _, c := range tests {
+		got, err := FormatPattern(c.Input, c.Pattern)
+		if err != nil {
+			t.Errorf("fail to format value (%v): %s", c.Input, err)
+			continue
+		}
+		if got != c.Want {
+			t.Errorf("%v: results mismatched! want %s - got %s", c.Input, c.Want, got)
+		}
+	}
+}
+
+func TestParseFormatter(t *testing.T) {
+	tests := []struct {
+		Input   value.Value
+		Pattern string
+		Want    string
+	}{
+		{
+			Input:   value.Float(1234.5),
+			Pattern: `$#,##0.00`,
+			Want:    "$1,234.50",
+		},
+		{
+			Input:   value.Float(0.256),
+			Pattern: `0.0%`,
+			Want:    "25.6%",
+		},
+		{
+			Input:   value.Float(12),
+			Pattern: `0 "units"`,
+			Want:    "12 units",
+		},
+		{
+			Input:   value.Float(-12),
+			Pattern: `0.00`,
+			Want:    "-12.00",
+		},
+		{
+			Input:   value.Float(-12),
+			Pattern: `0.00;(0.00)`,
+			Want:    "(12.00)",
+		},
+		{
+			Input:   value.Float(-12),
+			Pattern: `0.00;"neg"`,
+			Want:    "neg",
+		},
+		{
+			Input:   value.Float(0),
+			Pattern: `0.00;(0.00)`,
+			Want:    "0.00",
+		},
+		{
+			Input:   value.Float(0),
+			Pattern: `0.00;(0.00);"zero"`,
+			Want:    "zero",
+		},
+		{
+			Input:   value.Text("foo"),
+			Pattern: `0.00;"neg";@`,
+			Want:    "foo",
+		},
+		{
+			Input:   value.Text("foo"),
+			Pattern: `0.00;(0.00);0;"<"@">"`,
+			Want:    "<foo>",
+		},
+		{
+			Input:   value.Text("-3"),
+			Pattern: `0.00;"neg";@`,
+			Want:    "neg",
+		},
+		{
+			Input:   value.Text("foo"),
+			Pattern: `0.00`,
+			Want:    "foo",
+		},
+		{
+			Input:   value.Float(3),
+			Pattern: `@`,
+			Want:    "3",
+		},
+		{
+			Input:   value.Date(time.Date(2026, 2, 20, 14, 5, 9, 0, time.UTC)),
+			Pattern: `0DD/0MM/YYYY`,
+			Want:    "20/02/2026",
+		},
+		{
+			Input:   value.Date(time.Date(2026, 2, 20, 14, 5, 9, 0, time.UTC)),
+			Pattern: `YYYY "week day" DDD`,
+			Want:    "2026 week day Fri",
+		},
+		{
+			Input:   value.Date(time.Date(2026, 2, 20, 0, 0, 0, 0, time.UTC)),
+			Pattern: `0`,
+			Want:    "46073",
+		},
+		{
+			Input:   value.Float(46073),
+			Pattern: `YYYY-0MM-0DD`,
+			Want:    "2026-02-20",
+		},
+	}
+	for _, c := range tests {
+		f, err := ParseFormatter(c.Pattern)
+		if err != nil {
+			t.Errorf("%s: fail to parse pattern: %s", c.Pattern, err)
+			continue
+		}
+		got, err := f.Format(c.Input)
+		if err != nil {
+			t.Errorf("fail to format value (%v): %s", c.Input, err)
+			continue
+		}
+		if got != c.Want {
+			t.Errorf("%v (%s): results mismatched! want %s - got %s", c.Input, c.Pattern, c.Want, got)
+		}
+	}
+}
+
+func TestDate1904(t *testing.T) {
+	date := value.Date(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC))
+	tests := []struct {
+		Input   value.Value
+		Pattern string
+		Want    string
+	}{
+		{
+			Input:   value.Float(3),
+			Pattern: `YYYY-0MM-0DD`,
+			Want:    "1904-01-04",
+		},
+		{
+			Input:   date,
+			Pattern: `0`,
+			Want:    "43894",
+		},
+	}
+	for _, c := range tests {
+		f, err := ParseFormatter(c.Pattern)
+		if err != nil {
+			t.Errorf("%s: fail to parse pattern: %s", c.Pattern, err)
+			continue
+		}
+		got, err := Date1904(f).Format(c.Input)
+		if err != nil {
+			t.Errorf("fail to format value (%v): %s", c.Input, err)
+			continue
+		}
+		if got != c.Want {
+			t.Errorf("%v (%s): results mismatched! want %s - got %s", c.Input, c.Pattern, c.Want, got)
+		}
+	}
+}
+
+func TestParseFormatterInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		`0.00;"neg`,
+		"0;0;0;@;0",
+		"@;@",
+	}
+	for _, pattern := range tests {
+		if _, err := ParseFormatter(pattern); err == nil {
+			t.Errorf("%s: expected error parsing pattern", pattern)
+		}
+	}
+}
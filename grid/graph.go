@@ -1,9 +1,17 @@
 package grid
 
 import (
+	"errors"
 	"fmt"
 	"iter"
+	"math"
 	"slices"
+	"strings"
+
+	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/grid/builtins"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
 )
 
 type Link struct {
@@ -116,7 +124,16 @@ func buildSheetGraph(file File, view View) error {
 	return nil
 }
 
+// sparseView is implemented by views that can give the cells they store
+// without going through all the positions of their bounds.
+type sparseView interface {
+	StoredCells() iter.Seq[Cell]
+}
+
 func iterCellsFromView(view View) iter.Seq[Cell] {
+	if v, ok := view.(sparseView); ok {
+		return v.StoredCells()
+	}
 	it := func(yield func(Cell) bool) {
 		bd := view.Bounds()
 		for pos := range bd.Positions() {
@@ -132,3 +149,533 @@ func iterCellsFromView(view View) iter.Seq[Cell] {
 	}
 	return it
 }
+
+var ErrCircular = errors.New("circular reference")
+
+// CircularError reports the cells of a circular reference. Each cell refers
+// to the next one and the last cell is the first one.
+type CircularError struct {
+	Cells []layout.Position
+}
+
+func (e *CircularError) Error() string {
+	var list []string
+	for _, pos := range e.Cells {
+		list = append(list, pos.String())
+	}
+	return fmt.Sprintf("%s: %s", ErrCircular, strings.Join(list, " -> "))
+}
+
+func (e *CircularError) Unwrap() error {
+	return ErrCircular
+}
+
+// Iteration gives how the formulas involved in circular references are
+// evaluated. They are evaluated again and again until no value changes by more
+// than Delta or Count iterations have been done. Without iterations, circular
+// references are errors.
+type Iteration struct {
+	Count int
+	Delta float64
+}
+
+// DefaultIteration gives the settings used by spreadsheet applications when
+// iterative calculation is enabled.
+func DefaultIteration() Iteration {
+	return Iteration{
+		Count: 100,
+		Delta: 0.001,
+	}
+}
+
+type graphNode struct {
+	cell     Cell
+	view     View
+	refs     []*layout.Range
+	users    []*graphNode
+	deps     int
+	volatile bool
+}
+
+// frozenFile is implemented by the files whose volatile formulas keep their
+// value once evaluated. They are only evaluated again when they are changed
+// or when the cells they refer to are changed.
+type frozenFile interface {
+	Frozen() bool
+}
+
+// spillingView is implemented by views where the array given by the formula
+// of a cell fills the cells next to it.
+type spillingView interface {
+	SpillArray(Cell) error
+}
+
+// spillingCell is implemented by cells whose formula gives an array. The
+// range is the one of the cells filled by the array, the cell included.
+type spillingCell interface {
+	SpillRange() *layout.Range
+}
+
+// area gives the cells whose values are given by the formula of the node.
+func (n *graphNode) area() *layout.Range {
+	sc, ok := n.cell.(spillingCell)
+	if !ok {
+		return nil
+	}
+	return sc.SpillRange()
+}
+
+// Recalc evaluates the dirty formulas of a file and the formulas depending on
+// cells that changed. Formulas are evaluated once the formulas they refer to
+// have been evaluated. Cells involved in a cycle are not evaluated and a
+// CircularError is returned.
+func Recalc(file File) error {
+	return RecalcIterative(file, Iteration{})
+}
+
+// RecalcIterative is like Recalc but the formulas involved in a cycle, and the
+// ones depending on them, are evaluated with the given iterations.
+func RecalcIterative(file File, it Iteration) error {
+	var (
+		nodes   = make(map[layout.Position]*graphNode)
+		sheets  = make(map[string][]*graphNode)
+		changed []Cell
+		frozen  bool
+	)
+	if ff, ok := file.(frozenFile); ok {
+		frozen = ff.Frozen()
+	}
+	for _, sh := range file.Sheets() {
+		for c := range iterCellsFromView(sh) {
+			f := c.Formula()
+			if f == nil {
+				if c.Dirty() {
+					changed = append(changed, c)
+				}
+				continue
+			}
+			n := graphNode{
+				cell:     c,
+				view:     sh,
+				refs:     append(References(f), tableReferences(file, f)...),
+				volatile: !frozen && Volatile(f),
+			}
+			for _, rg := range n.refs {
+				if rg.Starts.Sheet == "" {
+					rg.Starts.Sheet = sh.Name()
+					rg.Ends.Sheet = sh.Name()
+				}
+			}
+			n.refs = expandSpans(file, n.refs)
+			nodes[c.At().WithSheet(sh.Name())] = &n
+			sheets[sh.Name()] = append(sheets[sh.Name()], &n)
+		}
+	}
+	var affected []*graphNode
+	for _, n := range nodes {
+		for _, rg := range n.refs {
+			for _, u := range referencedNodes(rg, nodes, sheets[rg.Starts.Sheet]) {
+				u.users = append(u.users, n)
+			}
+		}
+		if n.cell.Dirty() || n.volatile || slices.ContainsFunc(changed, func(c Cell) bool {
+			return slices.ContainsFunc(n.refs, func(rg *layout.Range) bool {
+				return rg.Starts.Sheet == c.At().Sheet && rg.Contains(c.At())
+			})
+		}) {
+			affected = append(affected, n)
+		}
+	}
+	affected = spreadChanges(affected)
+	for _, n := range affected {
+		for _, u := range n.users {
+			u.deps++
+		}
+	}
+	var (
+		queue []*graphNode
+		ctx   = FileContext(file)
+		done  int
+	)
+	for _, n := range affected {
+		if n.deps == 0 {
+			queue = append(queue, n)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if err := syncCell(n, EnclosedContext(ctx, SheetContext(n.view))); err != nil {
+			return err
+		}
+		done++
+		for _, u := range n.users {
+			if u.deps--; u.deps == 0 {
+				queue = append(queue, u)
+			}
+		}
+	}
+	for _, c := range changed {
+		if s, ok := c.(interface{ Sync(value.Context) error }); ok {
+			s.Sync(ctx)
+		}
+	}
+	if done == len(affected) {
+		return nil
+	}
+	var pending []*graphNode
+	for _, n := range affected {
+		if n.deps > 0 {
+			pending = append(pending, n)
+		}
+	}
+	if it.Count <= 0 {
+		return &CircularError{
+			Cells: findCycle(pending),
+		}
+	}
+	return iterate(file, pending, it)
+}
+
+// findCycle gives the cells of one of the cycles found in the formulas that
+// could not be evaluated. Each of them refers to at least one of the others.
+func findCycle(pending []*graphNode) []layout.Position {
+	refers := make(map[*graphNode]*graphNode)
+	for _, n := range pending {
+		for _, u := range n.users {
+			if u.deps > 0 {
+				refers[u] = n
+			}
+		}
+	}
+	var (
+		curr = pending[0]
+		seen = make(map[*graphNode]int)
+		path []*graphNode
+	)
+	for {
+		if ix, ok := seen[curr]; ok {
+			path = append(path[ix:], curr)
+			break
+		}
+		seen[curr] = len(path)
+		path = append(path, curr)
+		curr = refers[curr]
+	}
+	var cells []layout.Position
+	for _, n := range path {
+		cells = append(cells, n.cell.At().WithSheet(n.view.Name()))
+	}
+	return cells
+}
+
+// iterate evaluates the formulas that could not be evaluated because of
+// circular references. Formulas are evaluated in the order of the sheets and
+// of their cells, using the values given by the previous iteration for the
+// cells still to be evaluated.
+func iterate(file File, pending []*graphNode, it Iteration) error {
+	order := make(map[string]int)
+	for i, sh := range file.Sheets() {
+		order[sh.Name()] = i
+	}
+	slices.SortFunc(pending, func(n1, n2 *graphNode) int {
+		if d := order[n1.view.Name()] - order[n2.view.Name()]; d != 0 {
+			return d
+		}
+		p1, p2 := n1.cell.At(), n2.cell.At()
+		if p1.Line != p2.Line {
+			return int(p1.Line - p2.Line)
+		}
+		return int(p1.Column - p2.Column)
+	})
+	values := make(map[layout.Position]value.Value)
+	for _, n := range pending {
+		// cells never computed start from zero
+		val := n.cell.Value()
+		if value.IsBlank(val) {
+			val = value.Float(0)
+		}
+		values[n.cell.At().WithSheet(n.view.Name())] = val
+	}
+	for range it.Count {
+		var delta float64
+		for _, n := range pending {
+			var (
+				pos  = n.cell.At().WithSheet(n.view.Name())
+				prev = values[pos]
+				ctx  = cycleContext{
+					Context: EnclosedContext(FileContext(file), SheetContext(n.view)),
+					sheet:   n.view.Name(),
+					values:  values,
+				}
+			)
+			if err := syncCell(n, ctx); err != nil {
+				return err
+			}
+			values[pos] = n.cell.Value()
+			delta = max(delta, valueChange(prev, values[pos]))
+		}
+		if delta <= it.Delta {
+			break
+		}
+	}
+	return nil
+}
+
+// valueChange gives how much a value changed between two iterations. Values
+// other than numbers either do not change or change infinitely.
+func valueChange(prev, curr value.Value) float64 {
+	x1, ok1 := prev.(value.Float)
+	x2, ok2 := curr.(value.Float)
+	if ok1 && ok2 {
+		return math.Abs(float64(x2 - x1))
+	}
+	if prev.String() == curr.String() {
+		return 0
+	}
+	return math.Inf(1)
+}
+
+// cycleContext gives the values of the cells involved in circular references
+// as given by the previous iteration instead of evaluating their formulas.
+type cycleContext struct {
+	value.Context
+	sheet  string
+	values map[layout.Position]value.Value
+}
+
+func (c cycleContext) At(pos layout.Position) value.Value {
+	key := pos
+	if key.Sheet == "" {
+		key.Sheet = c.sheet
+	}
+	if val, ok := c.values[key]; ok {
+		return val
+	}
+	return c.Context.At(pos)
+}
+
+// Invalidate marks the cells of a range as changed: the formulas found in the
+// range and the ones using its cells are evaluated by the next recalculation.
+// Ranges without sheet are the ones of the active sheet.
+func Invalidate(file File, rg *layout.Range) error {
+	var (
+		view View
+		err  error
+	)
+	if rg.Starts.Sheet == "" {
+		view, err = file.ActiveSheet()
+	} else {
+		view, err = file.Sheet(rg.Starts.Sheet)
+	}
+	if err != nil {
+		return err
+	}
+	area := UsedRange(view, rg.Starts.WithoutSheet(), rg.Ends.WithoutSheet()).Normalize()
+	for c := range iterCellsFromView(view) {
+		if !area.Contains(c.At()) {
+			continue
+		}
+		if mc, ok := c.(interface{ MarkDirty() }); ok {
+			mc.MarkDirty()
+		}
+	}
+	return nil
+}
+
+// Volatile reports whether a formula calls a volatile builtin, like NOW or
+// RAND. Such formulas are evaluated by each recalculation whatever the cells
+// that changed.
+func Volatile(f value.Formula) bool {
+	fx, ok := f.(formula)
+	if !ok {
+		return false
+	}
+	return isVolatile(fx.expr)
+}
+
+func isVolatile(expr parse.Expr) bool {
+	switch e := expr.(type) {
+	case parse.Binary:
+		return isVolatile(e.Left()) || isVolatile(e.Right())
+	case parse.Unary:
+		return isVolatile(e.Expr())
+	case parse.Postfix:
+		return isVolatile(e.Expr())
+	case parse.Call:
+		if id, ok := e.Name().(parse.Identifier); ok {
+			if b, err := builtins.Get(id.Ident()); err == nil && b.Volatile {
+				return true
+			}
+		}
+		return slices.ContainsFunc(e.Args(), isVolatile)
+	default:
+		return false
+	}
+}
+
+// References gives the ranges of cells used by a formula. Single cells are
+// given as a range with the same start and end.
+func References(f value.Formula) []*layout.Range {
+	fx, ok := f.(formula)
+	if !ok {
+		return nil
+	}
+	return collectReferences(fx.expr, "")
+}
+
+func collectReferences(expr parse.Expr, sheet string) []*layout.Range {
+	var list []*layout.Range
+	switch e := expr.(type) {
+	case parse.Binary:
+		list = append(list, collectReferences(e.Left(), sheet)...)
+		list = append(list, collectReferences(e.Right(), sheet)...)
+	case parse.Unary:
+		list = collectReferences(e.Expr(), sheet)
+	case parse.Postfix:
+		list = collectReferences(e.Expr(), sheet)
+	case parse.Call:
+		for _, a := range e.Args() {
+			list = append(list, collectReferences(a, sheet)...)
+		}
+	case parse.CellAccess:
+		switch x := e.Expr().(type) {
+		case parse.Identifier:
+			sheet = x.Ident()
+		case parse.Literal:
+			sheet = x.Text()
+		case parse.SheetSpan:
+			// expanded once the sheets of the file are known
+			sheet = x.String()
+		case parse.ExternalSheet:
+			// cells of other workbooks are not part of the graph
+			return nil
+		}
+		list = collectReferences(e.Addr(), sheet)
+	case parse.CellAddr:
+		pos := e.Position
+		if sheet != "" {
+			pos.Sheet = sheet
+		}
+		list = append(list, layout.NewRange(pos, pos))
+	case parse.ColumnAddr:
+		var (
+			start = layout.NewSheetPosition(sheet, 1, e.Column)
+			end   = layout.NewSheetPosition(sheet, math.MaxInt64, e.Column)
+		)
+		if sheet == "" {
+			start.Sheet = e.Sheet
+			end.Sheet = e.Sheet
+		}
+		list = append(list, layout.NewRange(start, end))
+	case parse.RangeAddr:
+		var (
+			start = e.StartAt().Position
+			end   = e.EndAt().Position
+		)
+		if sheet != "" {
+			start.Sheet = sheet
+			end.Sheet = sheet
+		}
+		rg := layout.NewRange(start, end).Normalize()
+		if rg.Starts.Line == 0 {
+			// whole columns
+			rg.Starts.Line, rg.Ends.Line = 1, math.MaxInt64
+		}
+		if rg.Starts.Column == 0 {
+			// whole rows
+			rg.Starts.Column, rg.Ends.Column = 1, math.MaxInt64
+		}
+		list = append(list, rg)
+	}
+	return list
+}
+
+// expandSpans replaces the references to a span of sheets by the references
+// to the same cells in each sheet of the span.
+func expandSpans(file File, refs []*layout.Range) []*layout.Range {
+	var list []*layout.Range
+	for _, rg := range refs {
+		first, last, ok := strings.Cut(rg.Starts.Sheet, ":")
+		if !ok {
+			list = append(list, rg)
+			continue
+		}
+		names, _ := fileContext{file: file}.SheetSpan(first, last)
+		for _, n := range names {
+			list = append(list, layout.NewRange(rg.Starts.WithSheet(n), rg.Ends.WithSheet(n)))
+		}
+	}
+	return list
+}
+
+// referencedNodes gives the formulas found in the given range and the ones
+// whose arrays fill some cells of the range.
+func referencedNodes(rg *layout.Range, nodes map[layout.Position]*graphNode, sheet []*graphNode) []*graphNode {
+	var list []*graphNode
+	if w, h := rg.Width(), rg.Height(); w > 0 && h > 0 && h <= int64(len(sheet))/w {
+		for pos := range rg.Positions() {
+			if n, ok := nodes[pos.WithSheet(rg.Starts.Sheet)]; ok {
+				list = append(list, n)
+			}
+		}
+		for _, n := range sheet {
+			if a := n.area(); a != nil && !rg.Contains(n.cell.At()) && overlaps(a, rg) {
+				list = append(list, n)
+			}
+		}
+		return list
+	}
+	for _, n := range sheet {
+		if rg.Contains(n.cell.At()) {
+			list = append(list, n)
+		} else if a := n.area(); a != nil && overlaps(a, rg) {
+			list = append(list, n)
+		}
+	}
+	return list
+}
+
+func overlaps(r1, r2 *layout.Range) bool {
+	if r1.Ends.Line < r2.Starts.Line || r2.Ends.Line < r1.Starts.Line {
+		return false
+	}
+	return r1.Ends.Column >= r2.Starts.Column && r2.Ends.Column >= r1.Starts.Column
+}
+
+// spreadChanges adds the formulas using the results of the given formulas.
+func spreadChanges(list []*graphNode) []*graphNode {
+	var (
+		seen  = make(map[*graphNode]struct{})
+		queue = slices.Clone(list)
+	)
+	list = list[:0]
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if _, ok := seen[n]; ok {
+			continue
+		}
+		seen[n] = struct{}{}
+		list = append(list, n)
+		queue = append(queue, n.users...)
+	}
+	return list
+}
+
+func syncCell(n *graphNode, ctx value.Context) error {
+	if mc, ok := n.cell.(interface{ MarkDirty() }); ok {
+		mc.MarkDirty()
+	}
+	s, ok := n.cell.(interface{ Sync(value.Context) error })
+	if !ok {
+		return nil
+	}
+	if err := s.Sync(ctx); err != nil {
+		return err
+	}
+	if sv, ok := n.view.(spillingView); ok {
+		return sv.SpillArray(n.cell)
+	}
+	return nil
+}
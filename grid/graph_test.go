@@ -0,0 +1,254 @@
+package grid_test
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/internal/testutil"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/oxml"
+	"github.com/midbel/dockit/value"
+)
+
+func TestRecalc(t *testing.T) {
+	file := testutil.CreateFile()
+	if err := grid.Recalc(file); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tests := []struct {
+		Sheet string
+		layout.Position
+		Want string
+	}{
+		{Sheet: "sheet1", Position: layout.NewPosition(1, 3), Want: "24"},
+		{Sheet: "sheet2", Position: layout.NewPosition(1, 4), Want: "FOO"},
+		{Sheet: "sheet3", Position: layout.NewPosition(1, 2), Want: "7"},
+		{Sheet: "sheet3", Position: layout.NewPosition(4, 2), Want: "10"},
+	}
+	for _, c := range tests {
+		sh, err := file.Sheet(c.Sheet)
+		if err != nil {
+			t.Errorf("%s: sheet not found", c.Sheet)
+			continue
+		}
+		cell, err := sh.Cell(c.Position)
+		if err != nil {
+			t.Errorf("%s: cell not found", c.Position)
+			continue
+		}
+		if got := cell.Value().String(); got != c.Want {
+			t.Errorf("%s!%s: result mismatched! want %s, got %s", c.Sheet, c.Position, c.Want, got)
+		}
+	}
+}
+
+func TestRecalcCircular(t *testing.T) {
+	file := testutil.CreateFile()
+	sh, _ := file.Sheet("sheet1")
+	mv, ok := sh.(grid.MutableView)
+	if !ok {
+		t.Fatalf("sheet should be mutable")
+	}
+	f1, _ := grid.ParseOxmlFormula("=B3+1")
+	f2, _ := grid.ParseOxmlFormula("=A3*2")
+	mv.SetFormula(layout.NewPosition(3, 1), f1)
+	mv.SetFormula(layout.NewPosition(3, 2), f2)
+
+	err := grid.Recalc(file)
+	if !errors.Is(err, grid.ErrCircular) {
+		t.Fatalf("circular reference expected, got %v", err)
+	}
+	var ce *grid.CircularError
+	if !errors.As(err, &ce) {
+		t.Fatalf("cells of the circular reference expected")
+	}
+	var cells []string
+	for _, pos := range ce.Cells {
+		cells = append(cells, pos.String())
+	}
+	if got := strings.Join(cells, " "); got != "sheet1!A3 sheet1!B3 sheet1!A3" && got != "sheet1!B3 sheet1!A3 sheet1!B3" {
+		t.Errorf("cells of circular reference mismatched! got %s", got)
+	}
+}
+
+func TestRecalcIterative(t *testing.T) {
+	file := testutil.CreateFile()
+	sh, _ := file.Sheet("sheet1")
+	mv := sh.(grid.MutableView)
+	for addr, str := range map[string]string{
+		"A3": "=B3+1",
+		"B3": "=A3/2",
+		"C3": "=A3*10",
+		"A4": "=A4+1",
+	} {
+		var (
+			pos  = layout.ParsePosition(addr)
+			f, _ = grid.ParseOxmlFormula(str)
+		)
+		mv.SetFormula(pos, f)
+	}
+	it := grid.Iteration{
+		Count: 20,
+		Delta: 0.001,
+	}
+	if err := grid.RecalcIterative(file, it); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tests := []struct {
+		Addr string
+		Want float64
+	}{
+		{Addr: "A3", Want: 2},
+		{Addr: "B3", Want: 1},
+		{Addr: "C3", Want: 20},
+		{Addr: "A4", Want: 20},
+	}
+	for _, c := range tests {
+		cell, _ := sh.Cell(layout.ParsePosition(c.Addr))
+		got, ok := cell.Value().(value.Float)
+		if !ok || math.Abs(float64(got)-c.Want) > 0.01 {
+			t.Errorf("%s: result mismatched! want %f, got %s", c.Addr, c.Want, cell.Value())
+		}
+	}
+}
+
+func TestRecalcVolatile(t *testing.T) {
+	var (
+		file  = oxml.NewFile()
+		sheet = oxml.NewSheet("sheet1")
+	)
+	fm, _ := grid.ParseOxmlFormula("=RAND()")
+	sheet.SetFormula(layout.NewPosition(1, 1), fm)
+	if err := file.AppendSheet(sheet); err != nil {
+		t.Fatalf("error adding sheet: %s", err)
+	}
+	valueOf := func() value.Value {
+		if err := file.Sync(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		cell, _ := sheet.Cell(layout.NewPosition(1, 1))
+		return cell.Value()
+	}
+	var (
+		first  = valueOf()
+		second = valueOf()
+	)
+	if first == second {
+		t.Errorf("volatile formula not evaluated again: got %s", second)
+	}
+	file.SetFreeze(true)
+	if got := valueOf(); got != second {
+		t.Errorf("frozen formula evaluated again: want %s, got %s", second, got)
+	}
+	if err := file.Reload(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := valueOf(); got != second {
+		t.Errorf("frozen formula evaluated by reload: want %s, got %s", second, got)
+	}
+}
+
+func TestRecalcSheetSpan(t *testing.T) {
+	file := testutil.CreateFile()
+	sh1, _ := file.Sheet("sheet2")
+	sh3, _ := file.Sheet("sheet3")
+	f1, _ := grid.ParseOxmlFormula("=SUM(sheet1:sheet2!B1)")
+	sh3.(grid.MutableView).SetFormula(layout.NewPosition(1, 3), f1)
+	check := func(want string) {
+		t.Helper()
+		if err := grid.Recalc(file); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		cell, _ := sh3.Cell(layout.NewPosition(1, 3))
+		if got := cell.Value().String(); got != want {
+			t.Errorf("result mismatched! want %s, got %s", want, got)
+		}
+	}
+	check("12")
+	sh1.(grid.MutableView).SetValue(layout.NewPosition(1, 2), value.Float(20))
+	check("22")
+}
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		Formula string
+		Want    []string
+	}{
+		{
+			Formula: "=A1+sheet2!B2",
+			Want:    []string{"A1", "sheet2!B2"},
+		},
+		{
+			Formula: "=SUM(B1:B10)*2",
+			Want:    []string{"B1:B10"},
+		},
+	}
+	for _, c := range tests {
+		f, err := grid.ParseOxmlFormula(c.Formula)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.Formula, err)
+			continue
+		}
+		refs := grid.References(f)
+		if len(refs) != len(c.Want) {
+			t.Errorf("%s: number of references mismatched! want %d, got %d", c.Formula, len(c.Want), len(refs))
+			continue
+		}
+		for i := range refs {
+			if got := refs[i].String(); got != c.Want[i] {
+				t.Errorf("%s: reference mismatched! want %s, got %s", c.Formula, c.Want[i], got)
+			}
+		}
+	}
+}
+
+func TestRecalcSpill(t *testing.T) {
+	var (
+		file  = oxml.NewFile()
+		sheet = oxml.NewSheet("sheet1")
+	)
+	for i := range int64(3) {
+		sheet.SetValue(layout.NewPosition(i+1, 2), value.Float(i+1))
+	}
+	f1, _ := grid.ParseOxmlFormula("=B1:B3*2")
+	f2, _ := grid.ParseOxmlFormula("=SUM(A2:A3)")
+	sheet.SetFormula(layout.NewPosition(1, 1), f1)
+	sheet.SetFormula(layout.NewPosition(1, 3), f2)
+	if err := file.AppendSheet(sheet); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	check := func(pos layout.Position, want string) {
+		t.Helper()
+		cell, err := sheet.Cell(pos)
+		if err != nil {
+			t.Errorf("%s: cell not found", pos)
+			return
+		}
+		if got := cell.Value().String(); got != want {
+			t.Errorf("%s: result mismatched! want %s, got %s", pos, want, got)
+		}
+	}
+	if err := grid.Recalc(file); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	check(layout.NewPosition(1, 1), "2")
+	check(layout.NewPosition(3, 1), "6")
+
+	sheet.SetValue(layout.NewPosition(2, 2), value.Float(10))
+	if err := grid.Recalc(file); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	check(layout.NewPosition(2, 1), "20")
+	check(layout.NewPosition(1, 3), "26")
+
+	sheet.SetValue(layout.NewPosition(3, 1), value.Text("blocked"))
+	if err := file.Reload(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	check(layout.NewPosition(1, 1), value.ErrSpill.String())
+	check(layout.NewPosition(2, 1), "")
+	check(layout.NewPosition(3, 1), "blocked")
+}
@@ -0,0 +1,98 @@
+package grid
+
+import (
+	"iter"
+
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+type headerView struct {
+	view  View
+	names []string
+}
+
+// NewHeaderView gives a view whose first line gives the names of its columns.
+// The lines of the view start after this line.
+func NewHeaderView(view View) View {
+	if v, ok := view.(*headerView); ok {
+		return v
+	}
+	var names []string
+	for _, row := range view.Rows() {
+		for _, v := range row {
+			if v == nil {
+				names = append(names, "")
+				continue
+			}
+			names = append(names, v.String())
+		}
+		break
+	}
+	v := headerView{
+		view:  view,
+		names: names,
+	}
+	return &v
+}
+
+// Names gives the names of the columns of the view.
+func (v *headerView) Names() []string {
+	return v.names
+}
+
+func (v *headerView) Name() string {
+	return v.view.Name()
+}
+
+func (v *headerView) Type() string {
+	return "header"
+}
+
+func (v *headerView) Bounds() *layout.Range {
+	var (
+		bd    = v.view.Bounds()
+		start = layout.NewPosition(1, 1)
+		end   = layout.NewPosition(max(bd.Height()-1, 1), bd.Width())
+	)
+	return layout.NewRange(start, end)
+}
+
+func (v *headerView) Rows() iter.Seq2[int64, []value.Value] {
+	it := func(yield func(int64, []value.Value) bool) {
+		var skip bool
+		for lino, row := range v.view.Rows() {
+			if !skip {
+				skip = true
+				continue
+			}
+			if !yield(lino-1, row) {
+				return
+			}
+		}
+	}
+	return it
+}
+
+func (v *headerView) Unwrap() View {
+	return v.view
+}
+
+func (v *headerView) Cell(pos layout.Position) (Cell, error) {
+	if pos.Line < 1 {
+		return Empty(pos), nil
+	}
+	cell, err := v.view.Cell(pos.Offset(1, 0))
+	if err != nil {
+		cell = Empty(pos)
+	}
+	return ResetAt(cell, pos), nil
+}
+
+func (v *headerView) Sync(ctx value.Context) error {
+	return v.view.Sync(ctx)
+}
+
+func (v *headerView) Cells() [][]Cell {
+	return cellsFromView(v)
+}
@@ -0,0 +1,266 @@
+// Package render draws the values of a view as a table in a PNG image or in a
+// SVG document.
+package render
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/grid/format"
+	"github.com/midbel/dockit/value"
+)
+
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+type Options struct {
+	// Header draws the first row of the view in bold on a shaded background.
+	Header bool
+	// Formatter converts the values to text. The string representation of the
+	// values is used when nil.
+	Formatter format.Formatter
+	// Scale multiplies the size of the pixels of the font used for PNG.
+	Scale int
+}
+
+var (
+	colorText   = color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xFF}
+	colorGrid   = color.RGBA{R: 0xC8, G: 0xC8, B: 0xC8, A: 0xFF}
+	colorHeader = color.RGBA{R: 0xE8, G: 0xE8, B: 0xE8, A: 0xFF}
+)
+
+const padding = 4
+
+type cell struct {
+	text  string
+	align Align
+}
+
+type table struct {
+	rows   [][]cell
+	widths []int
+	header bool
+}
+
+func createTable(view grid.View, opts Options) (*table, error) {
+	var t table
+	t.header = opts.Header
+	for _, row := range view.Rows() {
+		cs := make([]cell, 0, len(row))
+		for i, v := range row {
+			c, err := createCell(v, opts.Formatter)
+			if err != nil {
+				return nil, err
+			}
+			cs = append(cs, c)
+			if i >= len(t.widths) {
+				t.widths = append(t.widths, 0)
+			}
+			t.widths[i] = max(t.widths[i], utf8.RuneCountInString(c.text))
+		}
+		t.rows = append(t.rows, cs)
+	}
+	if len(t.rows) == 0 {
+		return nil, fmt.Errorf("%s: nothing to render", view.Name())
+	}
+	return &t, nil
+}
+
+func createCell(v value.Value, ft format.Formatter) (cell, error) {
+	var (
+		c   cell
+		err error
+	)
+	if value.IsBlank(v) {
+		return c, nil
+	}
+	if ft != nil {
+		c.text, err = ft.Format(v)
+	} else {
+		c.text = v.String()
+	}
+	switch v.Type() {
+	case value.TypeNumber, value.TypeDate:
+		c.align = AlignRight
+	case value.TypeBool, value.TypeError:
+		c.align = AlignCenter
+	default:
+		c.align = AlignLeft
+	}
+	return c, err
+}
+
+// offset gives the number of characters before the text of the cell in a
+// column of the given width.
+func (c cell) offset(width int) int {
+	size := utf8.RuneCountInString(c.text)
+	switch c.align {
+	case AlignRight:
+		return width - size
+	case AlignCenter:
+		return (width - size) / 2
+	default:
+		return 0
+	}
+}
+
+// PNG draws the view as a table in a PNG image.
+func PNG(w io.Writer, view grid.View, opts Options) error {
+	t, err := createTable(view, opts)
+	if err != nil {
+		return err
+	}
+	scale := max(opts.Scale, 1)
+	var (
+		advance = (glyphWidth + 1) * scale
+		height  = glyphHeight*scale + 2*padding*scale
+		pad     = padding * scale
+		xs      = []int{0}
+	)
+	for _, size := range t.widths {
+		xs = append(xs, xs[len(xs)-1]+size*advance+2*pad+1)
+	}
+	var (
+		width = xs[len(xs)-1] + 1
+		total = len(t.rows)*(height+1) + 1
+		img   = image.NewRGBA(image.Rect(0, 0, width, total))
+	)
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	if t.header {
+		rect := image.Rect(0, 0, width, height+1)
+		draw.Draw(img, rect, image.NewUniform(colorHeader), image.Point{}, draw.Src)
+	}
+	for i, row := range t.rows {
+		y := i * (height + 1)
+		for j, c := range row {
+			x := xs[j] + 1 + pad + c.offset(t.widths[j])*advance
+			drawText(img, c.text, x, y+1+pad, scale, t.header && i == 0)
+		}
+	}
+	lines := image.NewUniform(colorGrid)
+	for i := 0; i <= len(t.rows); i++ {
+		y := i * (height + 1)
+		draw.Draw(img, image.Rect(0, y, width, y+1), lines, image.Point{}, draw.Src)
+	}
+	for _, x := range xs {
+		draw.Draw(img, image.Rect(x, 0, x+1, total), lines, image.Point{}, draw.Src)
+	}
+	return png.Encode(w, img)
+}
+
+func drawText(img *image.RGBA, str string, x, y, scale int, bold bool) {
+	ink := image.NewUniform(colorText)
+	for _, r := range str {
+		g := glyph(r)
+		for col, bits := range g {
+			for row := range glyphHeight {
+				if bits&(1<<row) == 0 {
+					continue
+				}
+				px := x + col*scale
+				py := y + row*scale
+				size := scale
+				if bold {
+					size++
+				}
+				draw.Draw(img, image.Rect(px, py, px+size, py+scale), ink, image.Point{}, draw.Src)
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+}
+
+const (
+	svgFontSize  = 14
+	svgCharWidth = svgFontSize * 0.6
+	svgRowHeight = svgFontSize + 2*padding + 4
+)
+
+// SVG draws the view as a table in a SVG document. Texts are written with a
+// monospace font so that the size of the columns can be computed.
+func SVG(w io.Writer, view grid.View, opts Options) error {
+	t, err := createTable(view, opts)
+	if err != nil {
+		return err
+	}
+	xs := []float64{0}
+	for _, size := range t.widths {
+		xs = append(xs, xs[len(xs)-1]+float64(size)*svgCharWidth+2*padding)
+	}
+	var (
+		ws     = bufio.NewWriter(w)
+		width  = xs[len(xs)-1]
+		height = float64(len(t.rows) * svgRowHeight)
+	)
+	fmt.Fprintf(ws, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %[1]s %[2]s">`, coord(width+1), coord(height+1))
+	ws.WriteString("\n")
+	fmt.Fprintf(ws, `<rect width="100%%" height="100%%" fill="white"/>`)
+	ws.WriteString("\n")
+	if t.header {
+		fmt.Fprintf(ws, `<rect x="0" y="0" width="%s" height="%d" fill="%s"/>`, coord(width), svgRowHeight, hexColor(colorHeader))
+		ws.WriteString("\n")
+	}
+	fmt.Fprintf(ws, `<g font-family="monospace" font-size="%d" fill="%s">`, svgFontSize, hexColor(colorText))
+	ws.WriteString("\n")
+	for i, row := range t.rows {
+		y := float64(i*svgRowHeight) + svgRowHeight/2
+		for j, c := range row {
+			if c.text == "" {
+				continue
+			}
+			var (
+				x      = xs[j] + padding
+				anchor = "start"
+			)
+			switch c.align {
+			case AlignRight:
+				x, anchor = xs[j+1]-padding, "end"
+			case AlignCenter:
+				x, anchor = (xs[j]+xs[j+1])/2, "middle"
+			}
+			fmt.Fprintf(ws, `<text x="%s" y="%s" text-anchor="%s" dominant-baseline="middle"`, coord(x), coord(y), anchor)
+			if t.header && i == 0 {
+				ws.WriteString(` font-weight="bold"`)
+			}
+			ws.WriteString(">")
+			xml.EscapeText(ws, []byte(c.text))
+			ws.WriteString("</text>\n")
+		}
+	}
+	ws.WriteString("</g>\n")
+
+	var lines strings.Builder
+	for i := 0; i <= len(t.rows); i++ {
+		fmt.Fprintf(&lines, "M0 %d H%s ", i*svgRowHeight, coord(width))
+	}
+	for _, x := range xs {
+		fmt.Fprintf(&lines, "M%s 0 V%s ", coord(x), coord(height))
+	}
+	fmt.Fprintf(ws, `<path d="%s" stroke="%s" stroke-width="1" fill="none"/>`, strings.TrimSpace(lines.String()), hexColor(colorGrid))
+	ws.WriteString("\n</svg>\n")
+	return ws.Flush()
+}
+
+func coord(f float64) string {
+	return strconv.FormatFloat(math.Round(f*10)/10, 'f', -1, 64)
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,58 @@
+package render
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/midbel/dockit/flat"
+	"github.com/midbel/dockit/value"
+)
+
+func createView() *flat.Sheet {
+	rows := [][]value.Value{
+		{value.Text("name"), value.Text("salary")},
+		{value.Text("A&B"), value.Float(60)},
+		{value.Text("C"), value.Float(50)},
+	}
+	return flat.NewSheet("sheet1", rows)
+}
+
+func TestPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := PNG(&buf, createView(), Options{Header: true, Scale: 2}); err != nil {
+		t.Fatalf("fail to render view: %s", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("invalid png: %s", err)
+	}
+	var (
+		bd   = img.Bounds()
+		rows = 3*(glyphHeight*2+2*padding*2+1) + 1
+	)
+	if bd.Dy() != rows {
+		t.Errorf("height mismatched! want %d, got %d", rows, bd.Dy())
+	}
+	if bd.Dx() <= 0 {
+		t.Errorf("empty image")
+	}
+}
+
+func TestSVG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := SVG(&buf, createView(), Options{Header: true}); err != nil {
+		t.Fatalf("fail to render view: %s", err)
+	}
+	str := buf.String()
+	for _, want := range []string{
+		`font-weight="bold">name</text>`,
+		`>A&amp;B</text>`,
+		`text-anchor="end" dominant-baseline="middle">60</text>`,
+	} {
+		if !strings.Contains(str, want) {
+			t.Errorf("%s: not found in svg", want)
+		}
+	}
+}
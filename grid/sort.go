@@ -0,0 +1,180 @@
+package grid
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+	"strings"
+
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+// SortKey gives a column of a view used to sort its rows.
+type SortKey struct {
+	Column int64
+	Desc   bool
+}
+
+type sortedView struct {
+	view View
+	rows []int64
+}
+
+// NewSortedView gives a view with the rows of view ordered by the values of
+// the columns of the keys. Rows with equal keys keep their order.
+//
+// Numbers, dates and texts that can be read as numbers or dates are compared
+// by value and come before other texts, compared without case. Booleans and
+// errors come next. Blank cells always come last.
+func NewSortedView(view View, keys []SortKey) View {
+	type row struct {
+		lino   int64
+		values []value.Value
+	}
+	var rows []row
+	for lino, vs := range view.Rows() {
+		r := row{
+			lino:   lino,
+			values: make([]value.Value, len(keys)),
+		}
+		for i, k := range keys {
+			if k.Column >= 1 && k.Column <= int64(len(vs)) {
+				r.values[i] = vs[k.Column-1]
+			}
+		}
+		rows = append(rows, r)
+	}
+	slices.SortStableFunc(rows, func(a, b row) int {
+		for i, k := range keys {
+			c, blank := compareSortValues(a.values[i], b.values[i])
+			if k.Desc && !blank {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	sv := sortedView{
+		view: view,
+	}
+	for _, r := range rows {
+		sv.rows = append(sv.rows, r.lino)
+	}
+	return &sv
+}
+
+func (v *sortedView) Name() string {
+	return v.view.Name()
+}
+
+func (v *sortedView) Bounds() *layout.Range {
+	var (
+		bd    = v.view.Bounds()
+		start = layout.NewPosition(1, 1)
+		end   = layout.NewPosition(int64(len(v.rows)), bd.Width())
+	)
+	return layout.NewRange(start, end)
+}
+
+func (v *sortedView) Rows() iter.Seq2[int64, []value.Value] {
+	it := func(yield func(int64, []value.Value) bool) {
+		bd := v.view.Bounds()
+		for i, r := range v.rows {
+			out := make([]value.Value, 0, bd.Width())
+			for c := int64(1); c <= bd.Width(); c++ {
+				pos := layout.NewPosition(r, c)
+				cell, _ := v.view.Cell(pos)
+				out = append(out, cell.Value())
+			}
+			if !yield(int64(i+1), out) {
+				return
+			}
+		}
+	}
+	return it
+}
+
+func (v *sortedView) Cell(pos layout.Position) (Cell, error) {
+	lino := pos.Line - 1
+	if lino < 0 || lino >= int64(len(v.rows)) {
+		return Empty(pos), nil
+	}
+	pos.Line = v.rows[lino]
+	return v.view.Cell(pos)
+}
+
+func (v *sortedView) Sync(ctx value.Context) error {
+	return v.view.Sync(ctx)
+}
+
+func (v *sortedView) Cells() [][]Cell {
+	return cellsFromView(v)
+}
+
+const (
+	sortNumber = iota
+	sortText
+	sortBool
+	sortError
+	sortBlank
+)
+
+// compareSortValues compares two values of a column. It also reports whether
+// one of the values is blank since blanks stay last whatever the direction.
+func compareSortValues(a, b value.Value) (int, bool) {
+	var (
+		ra, fa = sortRank(a)
+		rb, fb = sortRank(b)
+	)
+	if ra != rb {
+		return cmp.Compare(ra, rb), ra == sortBlank || rb == sortBlank
+	}
+	switch ra {
+	case sortNumber:
+		return cmp.Compare(fa, fb), false
+	case sortText:
+		return strings.Compare(strings.ToLower(a.String()), strings.ToLower(b.String())), false
+	case sortBool:
+		return cmp.Compare(fa, fb), false
+	case sortError:
+		return strings.Compare(a.String(), b.String()), false
+	default:
+		return 0, true
+	}
+}
+
+func sortRank(v value.Value) (int, float64) {
+	switch x := v.(type) {
+	case nil, value.Blank:
+		return sortBlank, 0
+	case value.Float:
+		return sortNumber, float64(x)
+	case value.Date:
+		return sortNumber, x.Serial(false)
+	case value.Boolean:
+		if x {
+			return sortBool, 1
+		}
+		return sortBool, 0
+	case value.Text:
+		if strings.TrimSpace(string(x)) == "" {
+			return sortBlank, 0
+		}
+		if f, err := value.CastToFloat(x); err == nil {
+			return sortNumber, float64(f)
+		}
+		if d, err := x.ToDate(); err == nil {
+			if d, ok := d.(value.Date); ok {
+				return sortNumber, d.Serial(false)
+			}
+		}
+		return sortText, 0
+	}
+	if value.IsError(v) {
+		return sortError, 0
+	}
+	return sortText, 0
+}
@@ -0,0 +1,314 @@
+package grid
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/midbel/dockit/internal/mmap"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+var ErrSpill = errors.New("spilled view corrupted")
+
+// Budget caps the number of cells that can be kept in memory. Views that
+// do not fit in the remaining budget should be spilled to disk with Spill.
+type Budget struct {
+	Cells int64
+	Dir   string
+
+	mu   sync.Mutex
+	used int64
+}
+
+func NewBudget(cells int64, dir string) *Budget {
+	return &Budget{
+		Cells: cells,
+		Dir:   dir,
+	}
+}
+
+// Reserve tries to book n cells in the budget. It returns false if there is
+// not enough room left. A nil or unlimited budget always accepts.
+func (b *Budget) Reserve(n int64) bool {
+	if b == nil || b.Cells <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.used+n > b.Cells {
+		return false
+	}
+	b.used += n
+	return true
+}
+
+func (b *Budget) Release(n int64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used = max(0, b.used-n)
+}
+
+func (b *Budget) Used() int64 {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+const (
+	spillBlank byte = iota
+	spillNumber
+	spillText
+	spillBool
+	spillDate
+	spillError
+)
+
+type spilledRow struct {
+	line   int64
+	offset int
+	size   int
+}
+
+// SpillView is a read only view which cells values are stored in a temporary
+// file mapped in memory instead of the heap. Formulas are not kept: only the
+// values computed at the time the view was spilled are available.
+type SpillView struct {
+	name    string
+	bounds  *layout.Range
+	rows    []spilledRow
+	data    []byte
+	release func() error
+}
+
+func Spill(view View, dir string) (*SpillView, error) {
+	tmp, err := os.CreateTemp(dir, ".dockit_spill*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	sv := SpillView{
+		name:   view.Name(),
+		bounds: view.Bounds(),
+	}
+	if err := sv.writeRows(tmp, view); err != nil {
+		return nil, err
+	}
+	sv.data, sv.release, err = mmap.Map(tmp)
+	if err != nil {
+		return nil, err
+	}
+	return &sv, nil
+}
+
+func (v *SpillView) Name() string {
+	return v.name
+}
+
+func (v *SpillView) Type() string {
+	return "spilled"
+}
+
+func (v *SpillView) Bounds() *layout.Range {
+	return v.bounds.Range()
+}
+
+func (v *SpillView) Rows() iter.Seq2[int64, []value.Value] {
+	it := func(yield func(int64, []value.Value) bool) {
+		for _, r := range v.rows {
+			row, err := v.decodeRow(r)
+			if err != nil {
+				return
+			}
+			if !yield(r.line, row) {
+				return
+			}
+		}
+	}
+	return it
+}
+
+func (v *SpillView) Cell(pos layout.Position) (Cell, error) {
+	if err := CheckName(pos, v); err != nil {
+		return nil, err
+	}
+	ix, ok := slices.BinarySearchFunc(v.rows, pos.Line, func(r spilledRow, line int64) int {
+		return int(r.line - line)
+	})
+	col := pos.Column - v.bounds.Starts.Column
+	if !ok || col < 0 {
+		return Empty(pos), nil
+	}
+	row, err := v.decodeRow(v.rows[ix])
+	if err != nil {
+		return nil, err
+	}
+	if col >= int64(len(row)) {
+		return Empty(pos), nil
+	}
+	return Single(row[col], pos), nil
+}
+
+func (v *SpillView) Sync(ctx value.Context) error {
+	return ErrWritable
+}
+
+func (v *SpillView) Cells() [][]Cell {
+	return cellsFromView(v)
+}
+
+// Close releases the memory mapping backing the view.
+func (v *SpillView) Close() error {
+	if v.release == nil {
+		return nil
+	}
+	err := v.release()
+	v.data = nil
+	v.release = nil
+	return err
+}
+
+func (v *SpillView) writeRows(w io.Writer, view View) error {
+	var (
+		ws     = bufio.NewWriter(w)
+		buf    []byte
+		offset int
+	)
+	for line := v.bounds.Starts.Line; line <= v.bounds.Ends.Line; line++ {
+		buf = buf[:0]
+		for col := v.bounds.Starts.Column; col <= v.bounds.Ends.Column; col++ {
+			c, err := view.Cell(layout.NewPosition(line, col))
+			if err != nil {
+				buf = append(buf, spillBlank)
+				continue
+			}
+			buf = AppendValue(buf, c.Value())
+		}
+		if _, err := ws.Write(buf); err != nil {
+			return err
+		}
+		r := spilledRow{
+			line:   line,
+			offset: offset,
+			size:   len(buf),
+		}
+		v.rows = append(v.rows, r)
+		offset += len(buf)
+	}
+	return ws.Flush()
+}
+
+func (v *SpillView) decodeRow(r spilledRow) ([]value.Value, error) {
+	if r.offset+r.size > len(v.data) {
+		return nil, ErrSpill
+	}
+	var (
+		buf  = v.data[r.offset : r.offset+r.size]
+		list = make([]value.Value, 0, v.bounds.Width())
+	)
+	for len(buf) > 0 {
+		val, n, err := DecodeValue(buf)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, val)
+		buf = buf[n:]
+	}
+	return list, nil
+}
+
+// AppendValue appends the binary encoding of val to buf. Values other than
+// numbers, texts, booleans, dates and errors are encoded as blank.
+func AppendValue(buf []byte, val value.Value) []byte {
+	switch v := val.(type) {
+	case value.Float:
+		buf = append(buf, spillNumber)
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(float64(v)))
+	case value.Text:
+		buf = append(buf, spillText)
+		buf = binary.AppendUvarint(buf, uint64(len(v)))
+		buf = append(buf, v...)
+	case value.Boolean:
+		buf = append(buf, spillBool)
+		if v {
+			buf = append(buf, 1)
+		} else {
+			buf = append(buf, 0)
+		}
+	case value.Date:
+		buf = append(buf, spillDate)
+		buf = binary.AppendVarint(buf, time.Time(v).UnixNano())
+	case value.Error:
+		buf = append(buf, spillError)
+		buf = binary.AppendUvarint(buf, uint64(len(v.String())))
+		buf = append(buf, v.String()...)
+	default:
+		buf = append(buf, spillBlank)
+	}
+	return buf
+}
+
+// DecodeValue decodes a value encoded by AppendValue. It returns the value and
+// the number of bytes consumed.
+func DecodeValue(buf []byte) (value.Value, int, error) {
+	if len(buf) == 0 {
+		return nil, 0, ErrSpill
+	}
+	var (
+		kind = buf[0]
+		size = 1
+	)
+	buf = buf[1:]
+	switch kind {
+	case spillBlank:
+		return value.Empty(), size, nil
+	case spillNumber:
+		if len(buf) < 8 {
+			return nil, 0, ErrSpill
+		}
+		f := math.Float64frombits(binary.LittleEndian.Uint64(buf))
+		return value.Float(f), size + 8, nil
+	case spillBool:
+		if len(buf) < 1 {
+			return nil, 0, ErrSpill
+		}
+		return value.Boolean(buf[0] == 1), size + 1, nil
+	case spillDate:
+		n, z := binary.Varint(buf)
+		if z <= 0 {
+			return nil, 0, ErrSpill
+		}
+		return value.Date(time.Unix(0, n).UTC()), size + z, nil
+	case spillText, spillError:
+		n, z := binary.Uvarint(buf)
+		if z <= 0 || int(n) > len(buf)-z {
+			return nil, 0, ErrSpill
+		}
+		str := string(buf[z : z+int(n)])
+		if kind == spillError {
+			return value.NewErrorFromCode(str), size + z + int(n), nil
+		}
+		return value.Text(str), size + z + int(n), nil
+	default:
+		return nil, 0, fmt.Errorf("%w: unknown value type %d", ErrSpill, kind)
+	}
+}
@@ -0,0 +1,168 @@
+package grid
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+// TableInfo describes a table of a workbook: the range of its cells, with its
+// header and totals rows, and the names of its columns.
+type TableInfo struct {
+	Sheet   string
+	Ref     *layout.Range
+	Header  bool
+	Totals  bool
+	Columns []string
+}
+
+// tableContext is implemented by the contexts and the files knowing the tables
+// of a workbook, to resolve the structured references of formulas.
+type tableContext interface {
+	TableInfo(name string) (TableInfo, error)
+}
+
+// Resolve gives the range of the cells referenced by a structured reference
+// to the table.
+func (t TableInfo) Resolve(ref parse.TableRef) (*layout.Range, error) {
+	var (
+		first = t.Ref.Starts.Line
+		last  = t.Ref.Ends.Line
+		data  = layout.NewRange(t.Ref.Starts, t.Ref.Ends)
+	)
+	if t.Header {
+		data.Starts.Line++
+	}
+	if t.Totals {
+		data.Ends.Line--
+	}
+	rg := layout.NewRange(data.Starts, data.Ends)
+	for i, item := range ref.Items() {
+		var part *layout.Range
+		switch item {
+		case parse.TableAll:
+			part = layout.NewRange(t.Ref.Starts, t.Ref.Ends)
+		case parse.TableData:
+			part = data
+		case parse.TableHeaders:
+			if !t.Header {
+				return nil, fmt.Errorf("%s: table without header", ref.Table())
+			}
+			part = layout.NewRange(t.Ref.Starts, t.Ref.Ends)
+			part.Ends.Line = first
+		case parse.TableTotals:
+			if !t.Totals {
+				return nil, fmt.Errorf("%s: table without totals", ref.Table())
+			}
+			part = layout.NewRange(t.Ref.Starts, t.Ref.Ends)
+			part.Starts.Line = last
+		default:
+			return nil, fmt.Errorf("%s: %w", item, ErrSupported)
+		}
+		if i == 0 {
+			rg = part
+			continue
+		}
+		rg.Starts.Line = min(rg.Starts.Line, part.Starts.Line)
+		rg.Ends.Line = max(rg.Ends.Line, part.Ends.Line)
+	}
+	if rg.Starts.Line > rg.Ends.Line {
+		return nil, fmt.Errorf("%s: no rows in table", ref.Table())
+	}
+	if start, end := ref.Columns(); start != "" {
+		x1, err := t.column(start)
+		if err != nil {
+			return nil, err
+		}
+		x2, err := t.column(end)
+		if err != nil {
+			return nil, err
+		}
+		rg.Starts.Column = t.Ref.Starts.Column + int64(min(x1, x2))
+		rg.Ends.Column = t.Ref.Starts.Column + int64(max(x1, x2))
+	}
+	rg.Starts.Sheet = t.Sheet
+	rg.Ends.Sheet = t.Sheet
+	return rg, nil
+}
+
+// column gives the index of a column of the table. Names of columns are case
+// insensitive.
+func (t TableInfo) column(name string) (int, error) {
+	ix := slices.IndexFunc(t.Columns, func(c string) bool {
+		return strings.EqualFold(c, name)
+	})
+	if ix < 0 {
+		return 0, fmt.Errorf("%s: column %w", name, ErrFound)
+	}
+	return ix, nil
+}
+
+// evalTableRef gives the values of the cells referenced by a structured
+// reference. Unknown tables and columns give #REF!.
+func evalTableRef(e parse.TableRef, ctx value.Context) value.Value {
+	tc, ok := ctx.(tableContext)
+	if !ok {
+		return value.ErrRef
+	}
+	t, err := tc.TableInfo(e.Table())
+	if err != nil {
+		return value.ErrRef
+	}
+	rg, err := t.Resolve(e)
+	if err != nil {
+		return value.ErrRef
+	}
+	if rg.Starts.Equal(rg.Ends) {
+		return eval(parse.NewCellAddr(rg.Starts, false, false), ctx)
+	}
+	return ctx.Range(rg.Starts, rg.Ends)
+}
+
+// tableReferences gives the ranges of the structured references of a formula
+// to the tables of the file.
+func tableReferences(file File, f value.Formula) []*layout.Range {
+	fx, ok := f.(formula)
+	if !ok {
+		return nil
+	}
+	tc, ok := file.(tableContext)
+	if !ok {
+		return nil
+	}
+	var list []*layout.Range
+	for _, ref := range collectTables(fx.expr) {
+		t, err := tc.TableInfo(ref.Table())
+		if err != nil {
+			continue
+		}
+		if rg, err := t.Resolve(ref); err == nil {
+			list = append(list, rg)
+		}
+	}
+	return list
+}
+
+func collectTables(expr parse.Expr) []parse.TableRef {
+	var list []parse.TableRef
+	switch e := expr.(type) {
+	case parse.TableRef:
+		list = append(list, e)
+	case parse.Binary:
+		list = append(list, collectTables(e.Left())...)
+		list = append(list, collectTables(e.Right())...)
+	case parse.Unary:
+		list = collectTables(e.Expr())
+	case parse.Postfix:
+		list = collectTables(e.Expr())
+	case parse.Call:
+		for _, a := range e.Args() {
+			list = append(list, collectTables(a)...)
+		}
+	}
+	return list
+}
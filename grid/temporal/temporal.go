@@ -5,27 +5,25 @@ import (
 )
 
 func YearsBetween(dtend, dtstart time.Time) int {
-	var year int
-
 	dtstart = dtstart.UTC()
 	dtend = dtend.UTC()
-	for dtstart.Before(dtend) {
-		dtstart = dtstart.AddDate(1, 0, 0)
-		year++
+
+	year := dtend.Year() - dtstart.Year()
+	if beforeInYear(dtend, dtstart) {
+		year--
 	}
-	return year - 1
+	return year
 }
 
 func MonthsBetween(dtend, dtstart time.Time) int {
-	var month int
-
 	dtstart = dtstart.UTC()
 	dtend = dtend.UTC()
-	for dtstart.Before(dtend) {
-		dtstart = dtstart.AddDate(0, 1, 0)
-		month++
+
+	month := (dtend.Year()-dtstart.Year())*12 + int(dtend.Month()-dtstart.Month())
+	if dtend.Day() < dtstart.Day() {
+		month--
 	}
-	return month - 1
+	return month
 }
 
 func DaysBetween(dtend, dtstart time.Time) int {
@@ -36,11 +34,17 @@ func DaysBetween(dtend, dtstart time.Time) int {
 	return int(days.Abs().Hours()) / 24
 }
 
+// CountDays gives the number of days between dtend and the last anniversary of
+// dtstart.
 func CountDays(dtend, dtstart time.Time) int {
 	dtstart = dtstart.UTC()
 	dtend = dtend.UTC()
 
-	dt := time.Date(dtend.Year(), dtstart.Month(), dtstart.Day(), dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, time.UTC)
+	year := dtend.Year()
+	if beforeInYear(dtend, dtstart) {
+		year--
+	}
+	dt := time.Date(year, dtstart.Month(), dtstart.Day(), dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, time.UTC)
 	return DaysBetween(dtend, dt)
 }
 
@@ -48,10 +52,30 @@ func CountDays2(dtend, dtstart time.Time) int {
 	return 0
 }
 
+// CountMonths gives the number of months between dtend and the last
+// anniversary of dtstart.
 func CountMonths(dtend, dtstart time.Time) int {
+	return MonthsBetween(dtend, dtstart) % 12
+}
+
+// CountMonthDays gives the number of days between dtend and the last day of a
+// month equal to the day of dtstart.
+func CountMonthDays(dtend, dtstart time.Time) int {
 	dtstart = dtstart.UTC()
 	dtend = dtend.UTC()
 
-	dt := time.Date(dtend.Year(), dtstart.Month(), dtstart.Day(), dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, time.UTC)
-	return MonthsBetween(dtend, dt)
+	if dtend.Day() >= dtstart.Day() {
+		return dtend.Day() - dtstart.Day()
+	}
+	dt := time.Date(dtend.Year(), dtend.Month()-1, dtstart.Day(), 0, 0, 0, 0, time.UTC)
+	return DaysBetween(dtend.Truncate(24*time.Hour), dt)
+}
+
+// beforeInYear reports whether the month and the day of t1 are before the ones
+// of t2.
+func beforeInYear(t1, t2 time.Time) bool {
+	if t1.Month() != t2.Month() {
+		return t1.Month() < t2.Month()
+	}
+	return t1.Day() < t2.Day()
 }
@@ -11,6 +11,7 @@ import (
 var (
 	ErrFile      = errors.New("invalid spreadsheet")
 	ErrLock      = errors.New("spreadsheet locked")
+	ErrPassword  = errors.New("invalid password")
 	ErrSupported = errors.New("operation not supported")
 	ErrFound     = errors.New("not found")
 	ErrPosition  = errors.New("invalid position")
@@ -134,9 +135,11 @@ type transposedView struct {
 	view View
 }
 
+// NewTransposedView gives a view whose lines are the columns of view.
+// Transposing a transposed view gives back the original view.
 func NewTransposedView(view View) View {
 	if v, ok := view.(*transposedView); ok {
-		return v
+		return v.view
 	}
 	v := &transposedView{
 		view: view,
@@ -203,7 +206,7 @@ func (v *transposedView) Sync(ctx value.Context) error {
 }
 
 func (v *transposedView) Cells() [][]Cell {
-	return cellsFromView(v.view)
+	return cellsFromView(v)
 }
 
 type horizontalStackedView struct {
@@ -503,6 +506,30 @@ func (v *projectedView) Cells() [][]Cell {
 	return cellsFromView(v.view)
 }
 
+// UsedRange gives the range of cells of the view between start and end.
+// Whole columns and whole rows, whose bounds have no line or no column, are
+// limited to the cells used by the view.
+func UsedRange(view View, start, end layout.Position) *layout.Range {
+	rg := layout.NewRange(start, end)
+	if start.Line != 0 && end.Line != 0 && start.Column != 0 && end.Column != 0 {
+		return rg
+	}
+	bd := view.Bounds()
+	if rg.Starts.Line == 0 {
+		rg.Starts.Line = 1
+	}
+	if rg.Ends.Line == 0 {
+		rg.Ends.Line = max(bd.Ends.Line, 1)
+	}
+	if rg.Starts.Column == 0 {
+		rg.Starts.Column = 1
+	}
+	if rg.Ends.Column == 0 {
+		rg.Ends.Column = max(bd.Ends.Column, 1)
+	}
+	return rg
+}
+
 type boundedView struct {
 	view View
 	part *layout.Range
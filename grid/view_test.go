@@ -42,6 +42,156 @@ func TestViews(t *testing.T) {
 	t.Run("horizontal-stack-view", testHorizontalStackView)
 	t.Run("vertical-stack-view", testVerticalStackView)
 	t.Run("combined-view", testCombinedViews)
+	t.Run("spill-view", testSpillView)
+	t.Run("virtual-view", testVirtualView)
+	t.Run("sorted-view", testSortedView)
+	t.Run("header-view", testHeaderView)
+}
+
+func testHeaderView(t *testing.T) {
+	var (
+		sheet = getSheetFromSample(t, sample1)
+		view  = grid.NewHeaderView(sheet)
+	)
+	names := view.(interface{ Names() []string }).Names()
+	if got := strings.Join(names, ","); got != "project,star,commit,language" {
+		t.Errorf("names mismatched! want project,star,commit,language, got %s", got)
+	}
+	want := [][]string{
+		{"foo", "10", "2023", "Go"},
+		{"bar", "13", "452", "C"},
+		{"flim", "156", "892", "Rust"},
+		{"glam", "42", "1105", "TypeScript"},
+		{"zorp", "804", "342", "Go"},
+		{"munt", "424", "11127", "C"},
+	}
+	got := testutil.Collect(view)
+	testutil.AssertSize(t, view, got)
+	testutil.AssertViewEqual(t, want, got, nil)
+
+	cell, _ := view.Cell(layout.NewPosition(2, 1))
+	if str := cell.Value().String(); str != "bar" {
+		t.Errorf("value mismatched at A2! want bar, got %s", str)
+	}
+}
+
+func testSortedView(t *testing.T) {
+	var (
+		sheet = getSheetFromSample(t, sample1)
+		rg    = layout.NewRange(
+			layout.NewPosition(2, 1),
+			layout.NewPosition(7, 4),
+		)
+		data = grid.NewBoundedView(sheet, rg)
+	)
+	tests := []struct {
+		Keys []grid.SortKey
+		Want []string
+	}{
+		{
+			Keys: []grid.SortKey{{Column: 2}},
+			Want: []string{"foo", "bar", "glam", "flim", "munt", "zorp"},
+		},
+		{
+			Keys: []grid.SortKey{{Column: 2, Desc: true}},
+			Want: []string{"zorp", "munt", "flim", "glam", "bar", "foo"},
+		},
+		{
+			Keys: []grid.SortKey{{Column: 4}, {Column: 3, Desc: true}},
+			Want: []string{"munt", "bar", "foo", "zorp", "flim", "glam"},
+		},
+		{
+			Keys: []grid.SortKey{{Column: 4}},
+			Want: []string{"bar", "munt", "foo", "zorp", "flim", "glam"},
+		},
+	}
+	for _, c := range tests {
+		var (
+			view = grid.NewSortedView(data, c.Keys)
+			got  []string
+		)
+		if view.Bounds().Height() != data.Bounds().Height() {
+			t.Errorf("number of rows mismatched! want %d, got %d", data.Bounds().Height(), view.Bounds().Height())
+		}
+		for _, row := range view.Rows() {
+			got = append(got, row[0].String())
+		}
+		if strings.Join(got, ",") != strings.Join(c.Want, ",") {
+			t.Errorf("rows mismatched! want %s, got %s", c.Want, got)
+		}
+		for i, w := range c.Want {
+			cell, err := view.Cell(layout.NewPosition(int64(i+1), 1))
+			if err != nil || cell.Value().String() != w {
+				t.Errorf("cell mismatched at row %d! want %s", i+1, w)
+			}
+		}
+	}
+}
+
+func testVirtualView(t *testing.T) {
+	var (
+		sheet = getSheetFromSample(t, sample1)
+		calls int
+		src   = grid.VirtualSource{
+			Rows: func() iter.Seq2[int64, []value.Value] {
+				calls++
+				return sheet.Rows()
+			},
+		}
+		view = grid.NewVirtualView("virtual", src)
+		sbd  = sheet.Bounds()
+		vbd  = view.Bounds()
+	)
+	if vbd.Width() != sbd.Width() || vbd.Height() != sbd.Height() {
+		t.Fatalf("view bounds should match sheet bounds")
+	}
+	for pos := range vbd.Positions() {
+		var (
+			cell1, _ = view.Cell(pos)
+			cell2, _ = sheet.Cell(pos)
+			ok       = value.Eq(cell1.Value(), cell2.Value())
+		)
+		if !value.True(ok) {
+			t.Errorf("value mismatched at %s! want %s, got %s", pos, cell2.Value(), cell1.Value())
+		}
+	}
+	if calls <= 1 {
+		t.Errorf("rows should be requested each time the view is read")
+	}
+}
+
+func testSpillView(t *testing.T) {
+	sheet := getSheetFromSample(t, sample1)
+	view, err := grid.Spill(sheet, t.TempDir())
+	if err != nil {
+		t.Fatalf("fail to spill view: %s", err)
+	}
+	defer view.Close()
+
+	var (
+		sbd = sheet.Bounds()
+		vbd = view.Bounds()
+	)
+	if vbd.Width() != sbd.Width() || vbd.Height() != sbd.Height() {
+		t.Fatalf("view bounds should match sheet bounds")
+	}
+	for pos := range vbd.Positions() {
+		var (
+			cell1, _ = view.Cell(pos)
+			cell2, _ = sheet.Cell(pos)
+			ok       = value.Eq(cell1.Value(), cell2.Value())
+		)
+		if !value.True(ok) {
+			t.Errorf("value mismatched at %s! want %s, got %s", pos, cell2.Value(), cell1.Value())
+		}
+	}
+	var count int64
+	for range view.Rows() {
+		count++
+	}
+	if count != sbd.Height() {
+		t.Fatalf("number of rows mismatched! want %d, got %d", sbd.Height(), count)
+	}
 }
 
 func testCombinedViews(t *testing.T) {
@@ -139,6 +289,9 @@ func testTransposeView(t *testing.T) {
 			t.Errorf("value mismatched at %s vs %s! want %s, got %s", pos, other, cell1.Value(), cell2.Value())
 		}
 	}
+	if back := grid.NewTransposedView(view); back != sheet {
+		t.Errorf("transposing twice should give back the sheet")
+	}
 }
 
 func testHorizontalStackView(t *testing.T) {
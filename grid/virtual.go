@@ -0,0 +1,96 @@
+package grid
+
+import (
+	"iter"
+
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+// VirtualSource gives the data of a virtual view. The functions are called
+// each time the view is read so that the view always reflects the current
+// state of the data.
+type VirtualSource struct {
+	// Rows gives the rows of the view. It is required.
+	Rows func() iter.Seq2[int64, []value.Value]
+	// Cell gives the value at the given position. When nil, the rows are read
+	// until the requested one.
+	Cell func(layout.Position) (value.Value, error)
+	// Size gives the number of lines and columns of the view. When nil, it is
+	// computed from the rows.
+	Size func() layout.Dimension
+}
+
+type virtualView struct {
+	name string
+	src  VirtualSource
+}
+
+// NewVirtualView creates a read only view whose content is provided by the
+// functions of the given source. It lets applications expose their own data,
+// e.g. the result of a database query, as a sheet.
+func NewVirtualView(name string, src VirtualSource) View {
+	return &virtualView{
+		name: name,
+		src:  src,
+	}
+}
+
+func (v *virtualView) Name() string {
+	return v.name
+}
+
+func (v *virtualView) Type() string {
+	return "virtual"
+}
+
+func (v *virtualView) Bounds() *layout.Range {
+	var dim layout.Dimension
+	if v.src.Size != nil {
+		dim = v.src.Size()
+	} else {
+		for _, row := range v.Rows() {
+			dim.Lines++
+			dim.Columns = max(dim.Columns, int64(len(row)))
+		}
+	}
+	start := layout.NewPosition(1, 1)
+	if dim.Lines == 0 || dim.Columns == 0 {
+		return layout.NewRange(start, start)
+	}
+	return layout.NewRange(start, layout.NewPosition(dim.Lines, dim.Columns))
+}
+
+func (v *virtualView) Rows() iter.Seq2[int64, []value.Value] {
+	if v.src.Rows == nil {
+		return func(func(int64, []value.Value) bool) {}
+	}
+	return v.src.Rows()
+}
+
+func (v *virtualView) Cell(pos layout.Position) (Cell, error) {
+	if err := CheckName(pos, v); err != nil {
+		return nil, err
+	}
+	if v.src.Cell != nil {
+		val, err := v.src.Cell(pos)
+		if err != nil {
+			return nil, err
+		}
+		return Single(val, pos), nil
+	}
+	for lino, row := range v.Rows() {
+		if lino < pos.Line {
+			continue
+		}
+		if lino > pos.Line || pos.Column < 1 || pos.Column > int64(len(row)) {
+			break
+		}
+		return Single(row[pos.Column-1], pos), nil
+	}
+	return Empty(pos), nil
+}
+
+func (v *virtualView) Sync(_ value.Context) error {
+	return ErrSupported
+}
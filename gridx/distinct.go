@@ -0,0 +1,115 @@
+package gridx
+
+import (
+	"iter"
+
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+type distinctView struct {
+	view grid.View
+	cols layout.Selection
+
+	// lines of view kept by the view. It is only built when the view is used
+	// by Bounds or Cell. Rows reads view without it.
+	rows []int64
+	done bool
+}
+
+// Distinct gives the rows of view without the duplicate ones. When cols is not
+// nil, two rows are duplicates when they have the same values in the selected
+// columns and the first of them is kept.
+func Distinct(view grid.View, cols layout.Selection) grid.View {
+	return &distinctView{
+		view: view,
+		cols: cols,
+	}
+}
+
+func (v *distinctView) Name() string {
+	return v.view.Name()
+}
+
+func (v *distinctView) Bounds() *layout.Range {
+	var (
+		rows  = v.index()
+		width = v.view.Bounds().Width()
+		start = layout.NewPosition(1, 1)
+	)
+	if len(rows) == 0 {
+		return layout.NewRange(start, start)
+	}
+	end := layout.NewPosition(int64(len(rows)), width)
+	return layout.NewRange(start, end)
+}
+
+func (v *distinctView) Rows() iter.Seq2[int64, []value.Value] {
+	it := func(yield func(int64, []value.Value) bool) {
+		var lino int64
+		for _, rs := range v.unique() {
+			lino++
+			if !yield(lino, rs) {
+				return
+			}
+		}
+	}
+	return it
+}
+
+func (v *distinctView) Cell(pos layout.Position) (grid.Cell, error) {
+	rows := v.index()
+	if pos.Line < 1 || pos.Line > int64(len(rows)) {
+		return grid.Empty(pos), nil
+	}
+	ori := pos
+	pos.Line = rows[pos.Line-1]
+	c, _ := v.view.Cell(pos)
+	return grid.ResetAt(c, ori), nil
+}
+
+func (v *distinctView) Sync(value.Context) error {
+	return grid.ErrSupported
+}
+
+func (v *distinctView) index() []int64 {
+	if v.done {
+		return v.rows
+	}
+	for lino := range v.unique() {
+		v.rows = append(v.rows, lino)
+	}
+	v.done = true
+	return v.rows
+}
+
+// unique yields the rows of the underlying view seen for the first time with
+// their line in the view.
+func (v *distinctView) unique() iter.Seq2[int64, []value.Value] {
+	it := func(yield func(int64, []value.Value) bool) {
+		var (
+			seen = make(map[string]struct{})
+			cols []int64
+		)
+		if v.cols != nil {
+			cols = v.cols.Indices(v.view.Bounds())
+		}
+		for lino, rs := range v.view.Rows() {
+			var key string
+			if v.cols == nil {
+				key = keyFromValues(rs)
+			} else {
+				key = keyFromRow(rs, cols)
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			if !yield(lino, rs) {
+				return
+			}
+		}
+	}
+	return it
+}
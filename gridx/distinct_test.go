@@ -0,0 +1,56 @@
+package gridx
+
+import (
+	"testing"
+
+	"github.com/midbel/dockit/internal/testutil"
+	"github.com/midbel/dockit/layout"
+)
+
+func TestDistinct(t *testing.T) {
+	t.Run("all-columns", testDistinctAllColumns)
+	t.Run("selected-columns", testDistinctSelectedColumns)
+}
+
+func testDistinctAllColumns(t *testing.T) {
+	view := Distinct(getViewFrom(t, firstSample), nil)
+
+	want := [][]string{
+		{"go", "foo", "100"},
+		{"go", "bar", "50"},
+		{"js", "quz", "100"},
+	}
+	got := testutil.Collect(view)
+	testutil.AssertSize(t, view, got)
+	testutil.AssertViewEqual(t, want, got, nil)
+
+	cell, _ := view.Cell(layout.NewPosition(3, 2))
+	if str := cell.Value().String(); str != "quz" {
+		t.Errorf("value mismatched at B3! want quz, got %s", str)
+	}
+}
+
+func testDistinctSelectedColumns(t *testing.T) {
+	var (
+		sel, _ = layout.SelectionFromString("A;C")
+		view   = Distinct(getViewFrom(t, secondSample), sel)
+	)
+	want := [][]string{
+		{"go", "foo", "250"},
+		{"go", "foo", "100"},
+		{"js", "bar", "100"},
+	}
+	got := testutil.Collect(view)
+	testutil.AssertSize(t, view, got)
+	testutil.AssertViewEqual(t, want, got, nil)
+
+	sel, _ = layout.SelectionFromString("A")
+	view = Distinct(getViewFrom(t, secondSample), sel)
+	want = [][]string{
+		{"go", "foo", "250"},
+		{"js", "bar", "100"},
+	}
+	got = testutil.Collect(view)
+	testutil.AssertSize(t, view, got)
+	testutil.AssertViewEqual(t, want, got, nil)
+}
@@ -3,7 +3,8 @@
 // The package builds new read-only grid.View implementations from existing
 // views. Join creates an inner join using selected key columns. Union,
 // Intersect, and Except perform set-like row operations on views with matching
-// widths. Group collapses rows by key columns and appends aggregate columns.
+// widths. Group collapses rows by key columns and appends aggregate columns,
+// keeping the groups in the order of their first row.
 //
 // Transformations are lazy at the cell/row interface boundary: they keep enough
 // index state to map output rows back to source views, then expose the result as
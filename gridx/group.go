@@ -3,9 +3,8 @@ package gridx
 import (
 	"fmt"
 	"iter"
-	"maps"
 	"math"
-	"slices"
+	"strings"
 
 	"github.com/midbel/dockit/grid"
 	"github.com/midbel/dockit/layout"
@@ -29,14 +28,23 @@ func CreateAggr(col, aggr string) (*Aggr, error) {
 	var a Aggr
 	a.Column, _ = layout.ParseIndex(col)
 
-	fn, ok := aggrBuilder[aggr]
-	if !ok {
-		return nil, fmt.Errorf("%s: unknown aggregate function", aggr)
+	fn, err := NewAggregator(aggr)
+	if err != nil {
+		return nil, err
 	}
-	a.Aggregator = fn()
+	a.Aggregator = fn
 	return &a, nil
 }
 
+// NewAggregator gives the aggregate function with the given name.
+func NewAggregator(name string) (Aggregator, error) {
+	fn, ok := aggrBuilder[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown aggregate function", name)
+	}
+	return fn(), nil
+}
+
 func NewAggr(col int64, aggr Aggregator) *Aggr {
 	return &Aggr{
 		Column:     col,
@@ -44,7 +52,13 @@ func NewAggr(col int64, aggr Aggregator) *Aggr {
 	}
 }
 
+// Update gives the value of the column of the aggregate to its function.
+// Aggregates without column are given a blank value for each row.
 func (a *Aggr) Update(row []value.Value) {
+	if a.Column == 0 {
+		a.Aggregator.Aggr(value.Empty())
+		return
+	}
 	col := a.Column - 1
 	if col < 0 || int(col) >= len(row) {
 		return
@@ -62,14 +76,15 @@ func Group(view grid.View, keys layout.Selection, aggr []Aggr) (grid.View, error
 	if err != nil {
 		return nil, err
 	}
-	vs := maps.Values(groups)
-	return newGroupedView(view, slices.Collect(vs)), nil
+	return newGroupedView(view, groups), nil
 }
 
-func createGroups(view grid.View, keys layout.Selection, aggr []Aggr) (map[string]*groupRow, error) {
+// createGroups gives the groups of rows in the order of their first row.
+func createGroups(view grid.View, keys layout.Selection, aggr []Aggr) ([]*groupRow, error) {
 	var (
 		cols   = keys.Indices(view.Bounds())
 		groups = make(map[string]*groupRow)
+		list   []*groupRow
 	)
 	for lino, rs := range view.Rows() {
 		k := keyFromRow(rs, cols)
@@ -85,11 +100,12 @@ func createGroups(view grid.View, keys layout.Selection, aggr []Aggr) (map[strin
 			}
 			gr = row
 			groups[k] = gr
+			list = append(list, gr)
 		}
 		gr.Update(rs)
 		gr.indices = append(gr.indices, lino)
 	}
-	return groups, nil
+	return list, nil
 }
 
 type groupedView struct {
@@ -137,7 +153,7 @@ func (v *groupedView) Cell(pos layout.Position) (grid.Cell, error) {
 	if pos.Line < 1 || pos.Line > int64(len(v.groups)) {
 		return grid.Empty(pos), nil
 	}
-	return v.groups[pos.Line].At(pos), nil
+	return v.groups[pos.Line-1].At(pos), nil
 }
 
 func (v *groupedView) Sync(ctx value.Context) error {
@@ -165,14 +181,15 @@ func (r *groupRow) Columns() int64 {
 }
 
 func (r *groupRow) At(pos layout.Position) grid.Cell {
-	if pos.Column < 0 || pos.Column >= r.Columns() {
+	col := pos.Column - 1
+	if col < 0 || col >= r.Columns() {
 		return grid.Empty(pos)
 	}
 	var val value.Value
-	if pos.Column < int64(len(r.values)) {
-		val = r.values[int(pos.Column)]
+	if col < int64(len(r.values)) {
+		val = r.values[int(col)]
 	} else {
-		val = r.aggr[int(pos.Column)-len(r.values)].Result()
+		val = r.aggr[int(col)-len(r.values)].Result()
 	}
 	if !value.IsScalar(val) {
 		return grid.Single(value.ErrValue, pos)
@@ -201,12 +218,12 @@ func (r *groupRow) Values() []value.Value {
 }
 
 var aggrBuilder = map[string]func() Aggregator{
-	"min":      Min,
-	"max":      Max,
-	"avg":      Avg,
-	"avergage": Avg,
-	"sum":      Sum,
-	"count":    Count,
+	"min":     Min,
+	"max":     Max,
+	"avg":     Avg,
+	"average": Avg,
+	"sum":     Sum,
+	"count":   Count,
 }
 
 type minv struct {
@@ -252,6 +269,7 @@ func (*minv) clone() Aggregator {
 
 type maxv struct {
 	result float64
+	count  int
 }
 
 func Max() Aggregator {
@@ -271,6 +289,11 @@ func (a *maxv) Aggr(val value.Value) {
 		a.result = math.NaN()
 		return
 	}
+	a.count++
+	if a.count == 1 {
+		a.result = float64(f)
+		return
+	}
 	a.result = max(a.result, float64(f))
 }
 
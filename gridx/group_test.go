@@ -23,6 +23,33 @@ const numCol = 3
 func TestGroup(t *testing.T) {
 	t.Run("single-key", testGroupSingleKey)
 	t.Run("multi-key", testGroupMultiKey)
+	t.Run("cells", testGroupCells)
+}
+
+func testGroupCells(t *testing.T) {
+	var (
+		keys, _ = layout.SelectionFromString("A")
+		view    = createGroupView(t, keys)
+		want    = [][]string{
+			{"go", "1200", "50", "1000", "300", "4"},
+			{"ts", "1", "1", "1", "1", "1"},
+			{"js", "20", "5", "15", "10", "2"},
+			{"java", "10", "10", "10", "10", "1"},
+		}
+	)
+	for i, row := range want {
+		for j, str := range row {
+			pos := layout.NewPosition(int64(i+1), int64(j+1))
+			cell, err := view.Cell(pos)
+			if err != nil {
+				t.Errorf("%s: fail to get cell: %s", pos, err)
+				continue
+			}
+			if got := cell.Value().String(); got != str {
+				t.Errorf("%s: value mismatched! want %s, got %s", pos, str, got)
+			}
+		}
+	}
 }
 
 func testGroupMultiKey(t *testing.T) {
@@ -15,6 +15,12 @@ type joinRow struct {
 	Right int64
 }
 
+// matched reports whether the row of the left view has a row in the right
+// view. Rows of a left join without any match have no right row.
+func (j joinRow) matched() bool {
+	return j.Right > 0
+}
+
 type joinView struct {
 	left  grid.View
 	right grid.View
@@ -22,10 +28,22 @@ type joinView struct {
 	rows []joinRow
 }
 
+// Join creates an inner join of left and right: only the rows of left having
+// the same keys than a row of right are kept.
 func Join(left, right grid.View, leftcols, rightcols layout.Selection) grid.View {
+	return join(left, right, leftcols, rightcols, false)
+}
+
+// LeftJoin creates a left join of left and right: all the rows of left are
+// kept and the columns of right are blank when no row of right matches.
+func LeftJoin(left, right grid.View, leftcols, rightcols layout.Selection) grid.View {
+	return join(left, right, leftcols, rightcols, true)
+}
+
+func join(left, right grid.View, leftcols, rightcols layout.Selection, outer bool) grid.View {
 	var (
 		index = createIndex(right, rightcols)
-		rows  = createLinks(left, leftcols, index)
+		rows  = createLinks(left, leftcols, index, outer)
 	)
 
 	j := joinView{
@@ -63,7 +81,7 @@ func (v *joinView) Rows() iter.Seq2[int64, []value.Value] {
 	it := func(yield func(int64, []value.Value) bool) {
 		for lino, jr := range v.rows {
 			left := collectValues(v.left, jr.Left)
-			right := collectValues(v.right, jr.Right)
+			right := v.collectRight(jr)
 			if !yield(int64(lino), slices.Concat(left, right)) {
 				return
 			}
@@ -84,6 +102,9 @@ func (v *joinView) Cell(pos layout.Position) (grid.Cell, error) {
 	)
 
 	if pos.Column > bd.Width() {
+		if !jr.matched() {
+			return grid.Empty(ori), nil
+		}
 		pos.Line = jr.Right
 		cell, _ = v.right.Cell(pos.Offset(0, -bd.Width()))
 	} else {
@@ -97,7 +118,18 @@ func (v *joinView) Sync(value.Context) error {
 	return grid.ErrSupported
 }
 
-func createLinks(view grid.View, keys layout.Selection, index map[string][]int64) []joinRow {
+func (v *joinView) collectRight(jr joinRow) []value.Value {
+	if jr.matched() {
+		return collectValues(v.right, jr.Right)
+	}
+	vs := make([]value.Value, v.right.Bounds().Width())
+	for i := range vs {
+		vs[i] = value.Empty()
+	}
+	return vs
+}
+
+func createLinks(view grid.View, keys layout.Selection, index map[string][]int64, outer bool) []joinRow {
 	var (
 		rows []joinRow
 		cols = keys.Indices(view.Bounds())
@@ -107,6 +139,12 @@ func createLinks(view grid.View, keys layout.Selection, index map[string][]int64
 		k := keyFromRow(rs, cols)
 
 		matches := index[k]
+		if len(matches) == 0 && outer {
+			rows = append(rows, joinRow{
+				Left: lino,
+			})
+			continue
+		}
 		for _, m := range matches {
 			r := joinRow{
 				Left:  lino,
@@ -19,6 +19,28 @@ const rightJoinSample = `1,1,midbel,dockit,github.com/midbel/dockit
 func TestJoin(t *testing.T) {
 	t.Run("single-key", testJoinSingleKey)
 	t.Run("multi-key", testJoinMultiKey)
+	t.Run("left-join", testLeftJoin)
+}
+
+func testLeftJoin(t *testing.T) {
+	var (
+		v1      = getJoinView(t, leftJoinSample)
+		v2      = getJoinView(t, rightJoinSample)
+		key1, _ = layout.SelectionFromString("A")
+		key2, _ = layout.SelectionFromString("B")
+	)
+
+	view := LeftJoin(v1, v2, key1, key2)
+
+	want := [][]string{
+		{"1", "go", "100", "midbel", "1", "1", "midbel", "dockit", "github.com/midbel/dockit"},
+		{"1", "go", "100", "midbel", "2", "1", "midbel", "sweet", "github.com/midbel/sweet-ql"},
+		{"2", "js", "50", "midbel", "3", "2", "nobody", "dockit-ui", "github.com/midbel/dockit-ui"},
+		{"3", "c", "20", "midbel", "", "", "", "", ""},
+	}
+	got := testutil.Collect(view)
+	testutil.AssertSize(t, view, got)
+	testutil.AssertViewEqual(t, want, got, nil)
 }
 
 func testJoinMultiKey(t *testing.T) {
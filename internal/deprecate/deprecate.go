@@ -0,0 +1,37 @@
+package deprecate
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+var (
+	mu     sync.Mutex
+	seen             = make(map[string]struct{})
+	output io.Writer = os.Stderr
+)
+
+// SetOutput changes where the warnings are written. A nil writer disables
+// them.
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	output = w
+}
+
+// Warn reports that name is deprecated in favor of replacement. The warning is
+// only written the first time name is reported.
+func Warn(name, replacement string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if output == nil {
+		return
+	}
+	if _, ok := seen[name]; ok {
+		return
+	}
+	seen[name] = struct{}{}
+	fmt.Fprintf(output, "dockit: %s is deprecated, use %s instead\n", name, replacement)
+}
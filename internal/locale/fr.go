@@ -0,0 +1,120 @@
+package locale
+
+const helpEn = `Dockit CLI is a data processing tool designed to manipulate, transform, and export tabular data directly from your terminal. 
+
+Built to bridge the gap between raw data and spreadsheets, with Dockit CLI, you can:
+
+* Manage Sheets: Seamlessly add, remove, or reorganize sheets within your workbooks
+* Unified Format Support: Interface with .csv, .xlsx, and .ods files using a single, consistent toolset
+* Restructure Data: Transform and reshape sheet layouts to fit your specific requirements
+* Join & Merge: Combine sheets across the same workbook or consolidate data from multiple different files
+
+Finally, with Dockit, manipulating spreadsheets from the command line becomes a workflow.`
+
+const helpFr = `Dockit CLI est un outil de traitement de données conçu pour manipuler, transformer et exporter des données tabulaires directement depuis votre terminal.
+
+Pensé pour faire le lien entre les données brutes et les tableurs, Dockit CLI vous permet de :
+
+* Gérer les feuilles : ajouter, supprimer ou réorganiser les feuilles de vos classeurs
+* Supporter plusieurs formats : manipuler les fichiers .csv, .xlsx et .ods avec un seul outil cohérent
+* Restructurer les données : transformer et remodeler les feuilles selon vos besoins
+* Joindre et fusionner : combiner des feuilles d'un même classeur ou regrouper les données de plusieurs fichiers
+
+Avec Dockit, manipuler des tableurs en ligne de commande devient un flux de travail.`
+
+var fr = map[string]string{
+	// cli
+	"Dockit transforms the way you handle spreadsheets by moving manual data tasks into your terminal": "Dockit transforme votre manière de gérer les tableurs en déplaçant les tâches manuelles dans votre terminal",
+	helpEn:                    helpFr,
+	"similar command(s)":      "commande(s) similaire(s)",
+	"language of messages":    "langue des messages",
+	"unsupported language %q": "langue %q non supportée",
+
+	"Print stats information":                                        "Affiche des statistiques",
+	"Print formula information":                                      "Affiche des informations sur les formules",
+	"Print dependencies information":                                 "Affiche des informations sur les dépendances",
+	"Print dependencies graph":                                       "Affiche le graphe des dépendances",
+	"Execute given script":                                           "Exécute le script donné",
+	"Invalidate entries of the import cache":                         "Invalide les entrées du cache d'import",
+	"Open a workbook once and run commands on it":                    "Ouvre un classeur une seule fois et exécute des commandes dessus",
+	"Export the AST representation of script":                        "Exporte la représentation AST du script",
+	"Transpose rows and columns in a sheet":                          "Transpose les lignes et les colonnes d'une feuille",
+	"Perform a join on two sheets":                                   "Effectue une jointure entre deux feuilles",
+	"Lock one or more sheets from a spreadsheet":                     "Verrouille une ou plusieurs feuilles d'un classeur",
+	"Unlock one or more sheets from a spreadsheet":                   "Déverrouille une ou plusieurs feuilles d'un classeur",
+	"Import sheets from a spreadsheet into a target file":            "Importe les feuilles d'un classeur dans un fichier cible",
+	"Delete one or more sheets from a spreadsheet":                   "Supprime une ou plusieurs feuilles d'un classeur",
+	"Duplicate a sheet within its original file":                     "Duplique une feuille dans son fichier d'origine",
+	"Change the name of a specific sheet within a file":              "Renomme une feuille d'un fichier",
+	"Change the position of one or more sheets within a file":        "Change la position d'une ou plusieurs feuilles d'un fichier",
+	"Configure how a sheet is printed":                               "Configure l'impression d'une feuille",
+	"Print content of a sheet on stdout":                             "Affiche le contenu d'une feuille sur la sortie standard",
+	"Draw content of a sheet as a table in a PNG or SVG image":       "Dessine le contenu d'une feuille sous forme de tableau dans une image PNG ou SVG",
+	"Consolidate multiple spreadsheet files into a single workbooks": "Regroupe plusieurs classeurs en un seul",
+	"Export sheets of a spreadsheet file into separate files":        "Exporte les feuilles d'un classeur dans des fichiers séparés",
+	"List supported spreadsheet formats":                             "Liste les formats de classeur supportés",
+	"Display metadata, sheet names of a spreadsheet file":            "Affiche les métadonnées et les noms des feuilles d'un classeur",
+	"List the problems found in the parts of a spreadsheet file":     "Liste les problèmes trouvés dans les parties d'un classeur",
+	"Display list of supported builtins":                             "Affiche la liste des fonctions intégrées",
+	"List modules of the standard library":                           "Liste les modules de la bibliothèque standard",
+
+	// engine
+	"%s: alias already imported in parallel block":                     "%s: alias déjà importé dans le bloc parallel",
+	"%s: builtin undefined":                                            "%s: fonction intégrée non définie",
+	"%s: export depends on a file imported in the same parallel block": "%s: l'export dépend d'un fichier importé dans le même bloc parallel",
+	"%s: file already exported in parallel block":                      "%s: fichier déjà exporté dans le bloc parallel",
+	"%s: statement can not be run in parallel":                         "%s: l'instruction ne peut pas être exécutée en parallèle",
+	"%s: unsupported notification":                                     "%s: notification non supportée",
+	"%s: unsupported mode":                                             "%s: mode non supporté",
+	"%s: view can not be found":                                        "%s: vue introuvable",
+	"%s: webhook replied with %s":                                      "%s: le webhook a répondu %s",
+	"array expected":                                                   "tableau attendu",
+	"assertion failed: %s":                                             "échec de l'assertion: %s",
+	"boolean pattern should be a literal":                              "le format des booléens doit être un littéral",
+	"cache directory should be a literal":                              "le répertoire du cache doit être un littéral",
+	"cache size should be a number":                                    "la taille du cache doit être un nombre",
+	"columns should be a number":                                       "le nombre de colonnes doit être un nombre",
+	"count: number expected":                                           "count: nombre attendu",
+	"cross workbook links not allowed":                                 "les liens entre classeurs ne sont pas autorisés",
+	"date pattern should be a literal":                                 "le format des dates doit être un littéral",
+	"empty file can not be created for format %s":                      "impossible de créer un fichier vide au format %s",
+	"expected array":                                                   "tableau attendu",
+	"expected file":                                                    "fichier attendu",
+	"expected file/view":                                               "fichier ou vue attendu",
+	"expected identifier":                                              "identifiant attendu",
+	"expected view":                                                    "vue attendue",
+	"file %s can not be loaded":                                        "les fichiers %s ne peuvent pas être chargés",
+	"identifier expected":                                              "identifiant attendu",
+	"include path should be a literal":                                 "le répertoire des modules doit être un littéral",
+	"index: number expected":                                           "index: nombre attendu",
+	"invalid anchor for insert statement":                              "ancre invalide pour l'instruction insert",
+	"invalid anchor for remove statement":                              "ancre invalide pour l'instruction remove",
+	"invalid slice expression":                                         "expression de découpage invalide",
+	"invalid target":                                                   "cible invalide",
+	"memory cells should be a number":                                  "le nombre de cellules en mémoire doit être un nombre",
+	"memory directory should be a literal":                             "le répertoire de débordement doit être un littéral",
+	"not a runtime cell":                                               "la valeur n'est pas une cellule",
+	"number pattern should be a literal":                               "le format des nombres doit être un littéral",
+	"only file can be used as default":                                 "seul un fichier peut être utilisé par défaut",
+	"only one default file can be imported in parallel block":          "un seul fichier par défaut peut être importé dans un bloc parallel",
+	"range expected":                                                   "plage attendue",
+	"row/column can not be removed after last row/column":              "impossible de supprimer une ligne/colonne après la dernière ligne/colonne",
+	"row/column can not be removed before first row/column":            "impossible de supprimer une ligne/colonne avant la première ligne/colonne",
+	"rows should be a number":                                          "le nombre de lignes doit être un nombre",
+	"slice can only be used on view":                                   "le découpage ne peut être utilisé que sur une vue",
+	"table styles can only be defined in xlsx files":                   "les styles de tableau ne peuvent être définis que dans des fichiers xlsx",
+	"tables can only be added to xlsx sheets":                          "les tableaux ne peuvent être ajoutés qu'aux feuilles xlsx",
+	"target sheet should be specified":                                 "la feuille cible doit être spécifiée",
+	"target value is not assignable":                                   "la valeur cible ne peut pas être assignée",
+	"target: number expected":                                          "target: nombre attendu",
+	"unexpected property type":                                         "type de propriété inattendu",
+	"unexpected type from json array":                                  "type inattendu dans le tableau json",
+	"unnamed sheet":                                                    "feuille sans nom",
+	"unsupported csv delimiter %q":                                     "séparateur csv %q non supporté",
+	"value can not be locked":                                          "la valeur ne peut pas être verrouillée",
+	"value can not be renamed":                                         "la valeur ne peut pas être renommée",
+	"value can not be unlocked":                                        "la valeur ne peut pas être déverrouillée",
+	"view can not be combined - number of columns mismatched":          "les vues ne peuvent pas être combinées - nombre de colonnes différent",
+	"view can not be combined - number of lines mismatched":            "les vues ne peuvent pas être combinées - nombre de lignes différent",
+	"view expected":                                                    "vue attendue",
+}
@@ -0,0 +1,90 @@
+package locale
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+const Default = "en"
+
+// catalogs maps a language to the translations of the messages. Messages are
+// keyed by their english version so that a missing translation falls back to
+// the original message.
+var catalogs = map[string]map[string]string{
+	"fr": fr,
+}
+
+var current atomic.Value
+
+func init() {
+	current.Store(Default)
+}
+
+// Detect gives the language configured in the environment. DOCKIT_LANG takes
+// precedence over the usual LC_ALL, LC_MESSAGES and LANG variables.
+func Detect() string {
+	for _, env := range []string{"DOCKIT_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if lang := normalize(os.Getenv(env)); lang != "" {
+			return lang
+		}
+	}
+	return Default
+}
+
+// Set selects the language used to translate messages. It returns false if no
+// catalog exists for the given language.
+func Set(lang string) bool {
+	lang = normalize(lang)
+	if lang == "" || lang == Default {
+		current.Store(Default)
+		return true
+	}
+	if _, ok := catalogs[lang]; !ok {
+		return false
+	}
+	current.Store(lang)
+	return true
+}
+
+func Lang() string {
+	return current.Load().(string)
+}
+
+func Supported() []string {
+	list := []string{Default}
+	for lang := range catalogs {
+		list = append(list, lang)
+	}
+	return list
+}
+
+func Text(msg string) string {
+	cat, ok := catalogs[Lang()]
+	if !ok {
+		return msg
+	}
+	if str, ok := cat[msg]; ok {
+		return str
+	}
+	return msg
+}
+
+func Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(Text(format), args...)
+}
+
+func Errorf(format string, args ...any) error {
+	return fmt.Errorf(Text(format), args...)
+}
+
+func normalize(lang string) string {
+	if lang == "C" || lang == "POSIX" {
+		return Default
+	}
+	if ix := strings.IndexAny(lang, "_.@-"); ix >= 0 {
+		lang = lang[:ix]
+	}
+	return strings.ToLower(lang)
+}
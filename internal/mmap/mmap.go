@@ -0,0 +1,18 @@
+package mmap
+
+import (
+	"os"
+)
+
+// Map maps the full content of file in memory in read only mode. The
+// returned function releases the mapping.
+func Map(file *os.File) ([]byte, func() error, error) {
+	fi, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if fi.Size() == 0 {
+		return nil, func() error { return nil }, nil
+	}
+	return mapFile(file, int(fi.Size()))
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package mmap
+
+import (
+	"io"
+	"os"
+)
+
+func mapFile(file *os.File, size int) ([]byte, func() error, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(io.NewSectionReader(file, 0, int64(size)), data); err != nil {
+		return nil, nil, err
+	}
+	release := func() error {
+		return nil
+	}
+	return data, release, nil
+}
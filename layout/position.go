@@ -59,7 +59,7 @@ func (p Position) Addr() string {
 		parts = append(parts, p.Sheet)
 		parts = append(parts, "!")
 	}
-	parts = append(parts, indexToString(p.Column))
+	parts = append(parts, IndexToString(p.Column))
 	parts = append(parts, strconv.FormatInt(p.Line, 10))
 	return strings.Join(parts, "")
 }
@@ -126,7 +126,8 @@ func ParseIndex(str string) (int64, int) {
 	return int64(index), offset
 }
 
-func indexToString(ix int64) string {
+// IndexToString gives the letters of the column at index ix, eg 28 gives AB.
+func IndexToString(ix int64) string {
 	var result string
 	for ix > 0 {
 		ix--
@@ -223,6 +223,12 @@ func (s *Sheet) Cell(pos layout.Position) (grid.Cell, error) {
 }
 
 func (s *Sheet) Sync(ctx value.Context) error {
+	return s.sync(ctx, false)
+}
+
+// sync evaluates the formulas of the sheet. Frozen volatile formulas having a
+// value are not evaluated again.
+func (s *Sheet) sync(ctx value.Context, frozen bool) error {
 	ctx = grid.EnclosedContext(ctx, grid.SheetContext(s))
 	for _, r := range s.rows {
 		for _, c := range r.Cells {
@@ -230,6 +236,9 @@ func (s *Sheet) Sync(ctx value.Context) error {
 			if f == nil {
 				continue
 			}
+			if frozen && !value.IsBlank(c.Value()) && grid.Volatile(f) {
+				continue
+			}
 			val, err := grid.Eval(f, ctx)
 			if err != nil {
 				return err
@@ -458,6 +467,9 @@ func (s *Sheet) put(cell grid.Cell, mode grid.CopyMode) {
 	if mode.Value() {
 		c.raw = val.String()
 		c.parsed = val
+		if raw, ok := grid.RawNumber(cell); ok {
+			c.raw = raw
+		}
 	}
 	if f := cell.Formula(); f != nil && mode.Formula() {
 		c.formula = f
@@ -468,6 +480,7 @@ func (s *Sheet) put(cell grid.Cell, mode grid.CopyMode) {
 type File struct {
 	names  *grid.NameIndex
 	sheets []*Sheet
+	frozen bool
 }
 
 func NewFile() *File {
@@ -526,17 +539,42 @@ func (f *File) Infos() []grid.ViewInfo {
 func (f *File) Sync() error {
 	ctx := grid.FileContext(f)
 	for _, s := range f.sheets {
-		if err := s.Sync(ctx); err != nil {
+		if err := s.sync(ctx, f.frozen); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// SetFreeze makes the volatile formulas, like NOW or RAND, keep their value
+// once evaluated.
+func (f *File) SetFreeze(freeze bool) {
+	f.frozen = freeze
+}
+
+// Frozen reports whether the volatile formulas of the workbook keep their
+// value once evaluated.
+func (f *File) Frozen() bool {
+	return f.frozen
+}
+
 func (f *File) ActiveSheet() (grid.View, error) {
 	return f.activeSheet()
 }
 
+// SetActiveSheet makes the sheet with the given name the one selected when the
+// document is opened.
+func (f *File) SetActiveSheet(name string) error {
+	sh, err := f.sheetByName(name)
+	if err != nil {
+		return err
+	}
+	for i := range f.sheets {
+		f.sheets[i].Active = f.sheets[i] == sh
+	}
+	return nil
+}
+
 func (f *File) Sheet(name string) (grid.View, error) {
 	return f.sheetByName(name)
 }
@@ -553,6 +591,12 @@ func (f *File) Sheets() []grid.View {
 	return views
 }
 
+func (f *File) Lock() {
+	for i := range f.sheets {
+		f.sheets[i].Lock()
+	}
+}
+
 func (f *File) LockSheet(name string) error {
 	sh, err := f.sheetByName(name)
 	if err == nil {
@@ -629,6 +673,26 @@ func (f *File) RemoveSheet(name string) error {
 	return nil
 }
 
+// MoveSheet moves the sheet with the given name at index, the first sheet
+// being at index 0. A negative index counts from the last sheet.
+func (f *File) MoveSheet(name string, index int) error {
+	ix := slices.IndexFunc(f.sheets, func(s *Sheet) bool {
+		return s.Name() == name
+	})
+	if ix < 0 {
+		return fmt.Errorf("sheet %s %w", name, grid.ErrFound)
+	}
+	if index < 0 {
+		index += len(f.sheets)
+	}
+	if index < 0 || index >= len(f.sheets) {
+		return fmt.Errorf("%d: sheet index %w", index, grid.ErrPosition)
+	}
+	sh := f.sheets[ix]
+	f.sheets = slices.Insert(slices.Delete(f.sheets, ix, ix+1), index, sh)
+	return nil
+}
+
 // append sheets of given file to current fule
 func (f *File) Merge(other grid.File) error {
 	for _, s := range other.Sheets() {
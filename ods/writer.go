@@ -11,6 +11,7 @@ import (
 	sax "github.com/midbel/codecs/xml"
 	"github.com/midbel/dockit/formula/format"
 	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/grid"
 	"github.com/midbel/dockit/value"
 )
 
@@ -332,21 +333,25 @@ func (w *sheetWriter) writeRow(row *row, delta int64) {
 		default:
 			name = "value"
 		}
+		str := val.String()
+		if raw, ok := grid.RawNumber(cell); ok {
+			str = raw
+		}
 		if name != "string-value" {
-			a := createAttr(name, "office", val.String())
+			a := createAttr(name, "office", str)
 			attrs = append(attrs, a)
 		}
 		if e, ok := cell.formula.(interface{ Expr() parse.Expr }); ok {
-			str, _ := format.FormatOds(e.Expr())
-			a := createAttr("formula", "table", str)
-			attrs = append(attrs, a)
+			if str, err := format.FormatOds(e.Expr()); err == nil {
+				attrs = append(attrs, createAttr("formula", "table", str))
+			}
 		}
 		if repeat > 1 {
 			attrs = append(attrs, createAttr("number-columns-repeated", "table", strconv.Itoa(repeat)))
 		}
 		w.writer.Open(cellName, attrs)
 		w.writer.Open(textName, nil)
-		w.writer.Text(val.String())
+		w.writer.Text(str)
 		w.writer.Close(textName)
 		w.writer.Close(cellName)
 
@@ -0,0 +1,174 @@
+package oxml
+
+import (
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/internal/id"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+// SpillRange gives the cells filled by the array given by the formula of the
+// cell, the cell included. It is nil when the formula gives a single value.
+func (c *Cell) SpillRange() *layout.Range {
+	if c.spill.Lines == 0 || c.spill.Columns == 0 {
+		return nil
+	}
+	var (
+		start = c.WithoutSheet()
+		end   = layout.NewPosition(start.Line+c.spill.Lines-1, start.Column+c.spill.Columns-1)
+	)
+	return layout.NewRange(start, end)
+}
+
+// SpillArray writes the values of the array given by the formula of the cell
+// in the cells below and on the right of it. The formula gives #SPILL! when
+// some of these cells are not empty. Formulas entered as arrays in a
+// spreadsheet application fill the range they were entered with whatever the
+// size of their result.
+func (s *Sheet) SpillArray(gc grid.Cell) error {
+	cell, ok := gc.(*Cell)
+	if !ok || cell.formula == nil || s.spilled != nil {
+		return nil
+	}
+	if cell.fixed {
+		s.fillArray(cell, cell.spill)
+		return nil
+	}
+	var size layout.Dimension
+	if cell.array != nil {
+		size = cell.array.Dimension()
+	}
+	if s.blocked(cell, size) {
+		s.clearArray(cell, layout.Dimension{})
+		cell.parsed = value.ErrSpill
+		cell.raw = cell.parsed.String()
+		return nil
+	}
+	s.clearArray(cell, size)
+	s.fillArray(cell, size)
+	return nil
+}
+
+// blocked reports whether a cell in the range filled by the array of the
+// formula has a value or a formula of its own.
+func (s *Sheet) blocked(cell *Cell, size layout.Dimension) bool {
+	if size.Lines == 0 {
+		return false
+	}
+	for pos := range arrayRange(cell, size).Positions() {
+		c, ok := s.cells[pos]
+		if !ok || c == cell || c.anchor == cell {
+			continue
+		}
+		if c.formula != nil || !value.IsBlank(c.Value()) {
+			return true
+		}
+	}
+	return false
+}
+
+// clearArray empties the cells filled by the previous result of the formula
+// that are outside of the range of the new one.
+func (s *Sheet) clearArray(cell *Cell, size layout.Dimension) {
+	prev := cell.SpillRange()
+	if prev == nil {
+		return
+	}
+	var curr *layout.Range
+	if size.Lines > 0 {
+		curr = arrayRange(cell, size)
+	}
+	for pos := range prev.Positions() {
+		if curr != nil && curr.Contains(pos) {
+			continue
+		}
+		c, ok := s.cells[pos]
+		if !ok || c.anchor != cell {
+			continue
+		}
+		if c.style == 0 {
+			s.removeCell(pos)
+			continue
+		}
+		// the format of the cell is kept
+		c.Type, c.raw, c.parsed = "", "", value.Empty()
+		c.anchor = nil
+	}
+	cell.spill = layout.Dimension{}
+}
+
+func (s *Sheet) fillArray(cell *Cell, size layout.Dimension) {
+	cell.spill = size
+	for pos := range arrayRange(cell, size).Positions() {
+		if pos == cell.WithoutSheet() {
+			continue
+		}
+		var (
+			row = pos.Line - cell.Line
+			col = pos.Column - cell.Column
+			val = arrayValue(cell, int(row), int(col))
+		)
+		c, ok := s.cells[pos]
+		if !ok {
+			c = &Cell{
+				id:       id.Next(),
+				Position: pos,
+			}
+			s.insertOrReplaceCell(c)
+		}
+		c.Type = typeFromValue(val)
+		c.raw = rawFromValue(val)
+		c.parsed = val
+		c.runs = nil
+		c.anchor = cell
+		c.dirty = false
+	}
+}
+
+// arrayValue gives the value of the result of the formula at the given
+// position. Arrays of one line or of one column are repeated in the range of
+// formulas entered as arrays. Positions outside of the array give #N/A.
+func arrayValue(cell *Cell, row, col int) value.Value {
+	if cell.array == nil {
+		return cell.Value()
+	}
+	dim := cell.array.Dimension()
+	if dim.Lines == 1 {
+		row = 0
+	}
+	if dim.Columns == 1 {
+		col = 0
+	}
+	if int64(row) >= dim.Lines || int64(col) >= dim.Columns {
+		return value.ErrNA
+	}
+	return cell.array.At(row, col)
+}
+
+func arrayRange(cell *Cell, size layout.Dimension) *layout.Range {
+	var (
+		start = cell.WithoutSheet()
+		end   = layout.NewPosition(start.Line+size.Lines-1, start.Column+size.Columns-1)
+	)
+	return layout.NewRange(start, end)
+}
+
+// collectArray copies the values of an array. Arrays given by formulas can be
+// views of the cells of a sheet that change once the array is written.
+func collectArray(arr value.ArrayValue) value.ArrayValue {
+	var (
+		dim  = arr.Dimension()
+		data = make([][]value.Value, dim.Lines)
+	)
+	for i := range data {
+		data[i] = make([]value.Value, dim.Columns)
+		for j := range data[i] {
+			v := arr.At(i, j)
+			if !value.IsScalar(v) && !value.IsError(v) {
+				v = value.ErrValue
+			}
+			data[i][j] = v
+		}
+	}
+	return value.NewArray(data)
+}
@@ -0,0 +1,122 @@
+package oxml
+
+import (
+	"encoding/xml"
+	"slices"
+
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+const (
+	typeCalcChainUrl = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/calcChain"
+	mimeCalcChain    = "application/vnd.openxmlformats-officedocument.spreadsheetml.calcChain+xml"
+	calcChainFile    = "calcChain.xml"
+
+	// version of the calculation engine of Excel. Cached values of files
+	// written with an older version are computed again when they are opened.
+	calcEngineId = 191029
+)
+
+type xmlCalcChain struct {
+	XMLName xml.Name      `xml:"calcChain"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Cells   []xmlCalcCell `xml:"c"`
+}
+
+// xmlCalcPr gives how Excel computes the formulas of a workbook. Booleans
+// are given either as 1 or as true.
+type xmlCalcPr struct {
+	CalcId   int     `xml:"calcId,attr"`
+	FullCalc string  `xml:"fullCalcOnLoad,attr,omitempty"`
+	Iterate  string  `xml:"iterate,attr,omitempty"`
+	Count    int     `xml:"iterateCount,attr,omitempty"`
+	Delta    float64 `xml:"iterateDelta,attr,omitempty"`
+}
+
+type xmlCalcCell struct {
+	Ref   string `xml:"r,attr"`
+	Sheet int    `xml:"i,attr"`
+}
+
+// recalc computes the formulas that are not up to date so that their results
+// are written with them. Excel is asked to compute the formulas again when
+// some of them can not be computed.
+func (z *writer) recalc(file *File) {
+	if err := file.Sync(); err != nil {
+		z.fullCalc = true
+		return
+	}
+	for _, s := range file.sheets {
+		for _, c := range s.cells {
+			if c.formula != nil && c.parsed == value.ErrName {
+				// functions not supported by dockit
+				z.fullCalc = true
+				return
+			}
+		}
+	}
+}
+
+func (z *writer) writeCalcChain(file *File) {
+	if z.invalid() {
+		return
+	}
+	root := xmlCalcChain{
+		Xmlns: typeMainUrl,
+	}
+	for _, s := range file.sheets {
+		var list []layout.Position
+		for _, c := range s.cells {
+			if c.formula == nil {
+				continue
+			}
+			if _, err := formatFormula(c.formula); err != nil {
+				// formulas that can not be expressed in Excel are kept as values
+				continue
+			}
+			list = append(list, c.WithoutSheet())
+		}
+		slices.SortFunc(list, func(p1, p2 layout.Position) int {
+			if p1.Line != p2.Line {
+				return int(p1.Line - p2.Line)
+			}
+			return int(p1.Column - p2.Column)
+		})
+		for _, p := range list {
+			root.Cells = append(root.Cells, xmlCalcCell{
+				Ref:   p.Addr(),
+				Sheet: s.Index,
+			})
+		}
+	}
+	if len(root.Cells) == 0 {
+		return
+	}
+	z.calcChain = true
+	z.encodeXML(z.createTarget(calcChainFile), &root)
+}
+
+// cachedValue gives the type and the value of the result of the formula of a
+// cell as they are written in the file.
+func cachedValue(cell *Cell) (string, string) {
+	val := cell.parsed
+	if val == nil || value.IsBlank(val) {
+		return "", ""
+	}
+	switch val.Type() {
+	case value.TypeNumber:
+		return TypeNumber, val.String()
+	case value.TypeBool:
+		if value.True(val) {
+			return TypeBool, "1"
+		}
+		return TypeBool, "0"
+	case value.TypeDate:
+		return TypeDate, val.String()
+	case value.TypeError:
+		return TypeError, val.String()
+	default:
+		return TypeFormula, val.String()
+	}
+}
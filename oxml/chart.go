@@ -0,0 +1,316 @@
+package oxml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	sax "github.com/midbel/codecs/xml"
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/layout"
+)
+
+const (
+	defaultChartLines   = 15
+	defaultChartColumns = 8
+)
+
+const (
+	catAxisId = "10"
+	valAxisId = "20"
+)
+
+// AddChart places a chart on the sheet. Ranges of the series without a sheet
+// name refer to the sheet itself. When the end of the anchor is not set, the
+// chart gets a default size.
+func (s *Sheet) AddChart(ch *grid.Chart) error {
+	if s.IsLock() {
+		return grid.ErrLock
+	}
+	switch ch.Type {
+	case grid.ChartBar, grid.ChartLine, grid.ChartPie:
+	default:
+		return fmt.Errorf("%s: unsupported chart type", ch.Type)
+	}
+	if len(ch.Series) == 0 {
+		return fmt.Errorf("chart without series")
+	}
+	from := ch.Anchor.From
+	if from.Line < 1 || from.Column < 1 {
+		return fmt.Errorf("%s: invalid position for chart", from)
+	}
+	if to := ch.Anchor.To; to.Line == 0 && to.Column == 0 {
+		ch.Anchor.To = from.Offset(defaultChartLines-1, defaultChartColumns-1)
+	}
+	rg := layout.NewRange(ch.Anchor.From.WithoutSheet(), ch.Anchor.To.WithoutSheet()).Normalize()
+	ch.Anchor.From = rg.Starts
+	ch.Anchor.To = rg.Ends
+	s.Charts = append(s.Charts, ch)
+	return nil
+}
+
+func chartName(name string) sax.QName {
+	return sax.QualifiedName(name, "c")
+}
+
+// chartRef gives the absolute reference to a range used by the series of a
+// chart.
+func chartRef(sheet string, rg layout.Range) string {
+	if rg.Starts.Sheet != "" {
+		sheet = rg.Starts.Sheet
+	}
+	if strings.ContainsAny(sheet, " '!-") {
+		sheet = "'" + strings.ReplaceAll(sheet, "'", "''") + "'"
+	}
+	addr := func(p layout.Position) string {
+		var (
+			str  = p.WithoutSheet().Addr()
+			line = strconv.FormatInt(p.Line, 10)
+		)
+		return "$" + strings.TrimSuffix(str, line) + "$" + line
+	}
+	ref := sheet + "!" + addr(rg.Starts)
+	if !rg.Starts.Equal(rg.Ends) {
+		ref += ":" + addr(rg.Ends)
+	}
+	return ref
+}
+
+func (w *sheetWriter) writeVal(name, value string) {
+	w.writer.Empty(chartName(name), []sax.A{
+		createAttr("val", value),
+	})
+}
+
+func (w *sheetWriter) writeChart(sheet *Sheet, ch *grid.Chart) error {
+	w.writer.Open(chartName("chartSpace"), []sax.A{
+		createNS("c", drawingChartUrl),
+		createNS("a", drawingMainUrl),
+		createNS("r", relationsUrl),
+	})
+	w.writer.Open(chartName("chart"), nil)
+	if ch.Title != "" {
+		w.writeChartTitle(ch.Title)
+		w.writeVal("autoTitleDeleted", "0")
+	} else {
+		w.writeVal("autoTitleDeleted", "1")
+	}
+	w.writer.Open(chartName("plotArea"), nil)
+	w.writer.Empty(chartName("layout"), nil)
+
+	var elem string
+	switch ch.Type {
+	case grid.ChartBar:
+		elem = "barChart"
+	case grid.ChartLine:
+		elem = "lineChart"
+	case grid.ChartPie:
+		elem = "pieChart"
+	default:
+		return fmt.Errorf("%s: unsupported chart type", ch.Type)
+	}
+	w.writer.Open(chartName(elem), nil)
+	switch ch.Type {
+	case grid.ChartBar:
+		w.writeVal("barDir", "col")
+		if ch.Options.Stacked {
+			w.writeVal("grouping", "stacked")
+		} else {
+			w.writeVal("grouping", "clustered")
+		}
+	case grid.ChartLine:
+		if ch.Options.Stacked {
+			w.writeVal("grouping", "stacked")
+		} else {
+			w.writeVal("grouping", "standard")
+		}
+	}
+	if ch.Type == grid.ChartPie {
+		w.writeVal("varyColors", "1")
+	} else {
+		w.writeVal("varyColors", "0")
+	}
+	for i := range ch.Series {
+		w.writeSeries(sheet, ch, i)
+	}
+	w.writeDataLabels(ch.Options.DataLabels)
+	switch ch.Type {
+	case grid.ChartBar:
+		if ch.Options.Stacked {
+			w.writeVal("overlap", "100")
+		}
+	case grid.ChartLine:
+		w.writeVal("marker", "1")
+	case grid.ChartPie:
+		w.writeVal("firstSliceAng", "0")
+	}
+	if ch.Type != grid.ChartPie {
+		w.writeVal("axId", catAxisId)
+		w.writeVal("axId", valAxisId)
+	}
+	w.writer.Close(chartName(elem))
+
+	if ch.Type != grid.ChartPie {
+		w.writeAxis("catAx", catAxisId, valAxisId, ch.XAxis)
+		w.writeAxis("valAx", valAxisId, catAxisId, ch.YAxis)
+	}
+	w.writer.Close(chartName("plotArea"))
+
+	if ch.Legend.Visible {
+		pos := "r"
+		switch ch.Legend.Position {
+		case grid.LegendLeft:
+			pos = "l"
+		case grid.LegendTop:
+			pos = "t"
+		case grid.LegendBottom:
+			pos = "b"
+		}
+		w.writer.Open(chartName("legend"), nil)
+		w.writeVal("legendPos", pos)
+		w.writeVal("overlay", "0")
+		w.writer.Close(chartName("legend"))
+	}
+	w.writeVal("plotVisOnly", "1")
+	w.writer.Close(chartName("chart"))
+	w.writer.Close(chartName("chartSpace"))
+	return w.writer.Flush()
+}
+
+func (w *sheetWriter) writeChartTitle(title string) {
+	w.writer.Open(chartName("title"), nil)
+	w.writer.Open(chartName("tx"), nil)
+	w.writer.Open(chartName("rich"), nil)
+	w.writer.Empty(dmlName("bodyPr"), nil)
+	w.writer.Open(dmlName("p"), nil)
+	w.writer.Open(dmlName("r"), nil)
+	w.writeText(dmlName("t"), title)
+	w.writer.Close(dmlName("r"))
+	w.writer.Close(dmlName("p"))
+	w.writer.Close(chartName("rich"))
+	w.writer.Close(chartName("tx"))
+	w.writeVal("overlay", "0")
+	w.writer.Close(chartName("title"))
+}
+
+func (w *sheetWriter) writeSeries(sheet *Sheet, ch *grid.Chart, ix int) {
+	ser := ch.Series[ix]
+	w.writer.Open(chartName("ser"), nil)
+	w.writeVal("idx", strconv.Itoa(ix))
+	w.writeVal("order", strconv.Itoa(ix))
+	if ser.Name != "" {
+		w.writer.Open(chartName("tx"), nil)
+		w.writeText(chartName("v"), ser.Name)
+		w.writer.Close(chartName("tx"))
+	}
+	if st := ser.Style; st != nil && st.Color != "" {
+		fill := func() {
+			w.writer.Open(dmlName("solidFill"), nil)
+			w.writer.Empty(dmlName("srgbClr"), []sax.A{
+				createAttr("val", strings.ToUpper(strings.TrimPrefix(st.Color, "#"))),
+			})
+			w.writer.Close(dmlName("solidFill"))
+		}
+		w.writer.Open(chartName("spPr"), nil)
+		if ch.Type == grid.ChartLine {
+			var attrs []sax.A
+			if st.Width > 0 {
+				attrs = append(attrs, createAttr("w", strconv.Itoa(int(st.Width*12700))))
+			}
+			w.writer.Open(dmlName("ln"), attrs)
+			fill()
+			w.writer.Close(dmlName("ln"))
+		} else {
+			fill()
+		}
+		w.writer.Close(chartName("spPr"))
+	}
+	switch ch.Type {
+	case grid.ChartBar:
+		w.writeVal("invertIfNegative", "0")
+	case grid.ChartLine:
+		if st := ser.Style; st != nil && st.MarkerType != "" {
+			w.writer.Open(chartName("marker"), nil)
+			w.writeVal("symbol", string(st.MarkerType))
+			w.writer.Close(chartName("marker"))
+		}
+	}
+	if ser.Categories.Starts.Line > 0 {
+		w.writer.Open(chartName("cat"), nil)
+		w.writer.Open(chartName("strRef"), nil)
+		w.writeText(chartName("f"), chartRef(sheet.Name(), ser.Categories))
+		w.writer.Close(chartName("strRef"))
+		w.writer.Close(chartName("cat"))
+	}
+	w.writer.Open(chartName("val"), nil)
+	w.writer.Open(chartName("numRef"), nil)
+	w.writeText(chartName("f"), chartRef(sheet.Name(), ser.Values))
+	w.writer.Close(chartName("numRef"))
+	w.writer.Close(chartName("val"))
+	if ch.Type == grid.ChartLine {
+		w.writeVal("smooth", strconv.Itoa(boolToInt(ch.Options.SmoothLines)))
+	}
+	w.writer.Close(chartName("ser"))
+}
+
+func (w *sheetWriter) writeDataLabels(dl grid.DataLabel) {
+	if !dl.ShowValue && !dl.ShowName && !dl.ShowPct {
+		return
+	}
+	w.writer.Open(chartName("dLbls"), nil)
+	w.writeVal("showLegendKey", "0")
+	w.writeVal("showVal", strconv.Itoa(boolToInt(dl.ShowValue)))
+	w.writeVal("showCatName", strconv.Itoa(boolToInt(dl.ShowName)))
+	w.writeVal("showSerName", "0")
+	w.writeVal("showPercent", strconv.Itoa(boolToInt(dl.ShowPct)))
+	w.writeVal("showBubbleSize", "0")
+	w.writer.Close(chartName("dLbls"))
+}
+
+func (w *sheetWriter) writeAxis(elem, id, cross string, axis *grid.Axis) {
+	if axis == nil {
+		axis = &grid.Axis{}
+	}
+	pos := "b"
+	if elem == "valAx" {
+		pos = "l"
+	}
+	w.writer.Open(chartName(elem), nil)
+	w.writeVal("axId", id)
+
+	w.writer.Open(chartName("scaling"), nil)
+	if axis.LogScale {
+		w.writeVal("logBase", "10")
+	}
+	if axis.Reverse {
+		w.writeVal("orientation", "maxMin")
+	} else {
+		w.writeVal("orientation", "minMax")
+	}
+	if axis.Max != axis.Min {
+		w.writeVal("max", strconv.FormatFloat(axis.Max, 'f', -1, 64))
+		w.writeVal("min", strconv.FormatFloat(axis.Min, 'f', -1, 64))
+	}
+	w.writer.Close(chartName("scaling"))
+
+	w.writeVal("delete", "0")
+	w.writeVal("axPos", pos)
+	if elem == "valAx" {
+		w.writer.Empty(chartName("majorGridlines"), nil)
+	}
+	if axis.Title != "" {
+		w.writeChartTitle(axis.Title)
+	}
+	w.writeVal("crossAx", cross)
+	w.writeVal("crosses", "autoZero")
+	if elem == "valAx" {
+		w.writeVal("crossBetween", "between")
+		if axis.MajorUnit > 0 {
+			w.writeVal("majorUnit", strconv.FormatFloat(axis.MajorUnit, 'f', -1, 64))
+		}
+		if axis.MinorUnit > 0 {
+			w.writeVal("minorUnit", strconv.FormatFloat(axis.MinorUnit, 'f', -1, 64))
+		}
+	}
+	w.writer.Close(chartName(elem))
+}
@@ -0,0 +1,62 @@
+package oxml
+
+import (
+	"strconv"
+
+	sax "github.com/midbel/codecs/xml"
+)
+
+// Column holds the width and the visibility of the columns between Min and Max.
+// Width is given in number of characters.
+type Column struct {
+	Min    int64
+	Max    int64
+	Width  float64
+	Hidden bool
+
+	style int
+}
+
+func (r *sheetReader) onColumn(_ *sax.Reader, el sax.E) error {
+	var (
+		col Column
+		err error
+	)
+	if col.Min, err = strconv.ParseInt(el.GetAttributeValue("min"), 10, 64); err != nil {
+		return err
+	}
+	if col.Max, err = strconv.ParseInt(el.GetAttributeValue("max"), 10, 64); err != nil {
+		return err
+	}
+	col.Width, _ = strconv.ParseFloat(el.GetAttributeValue("width"), 64)
+	col.Hidden = el.GetAttributeValue("hidden") == "1"
+	col.style, _ = strconv.Atoi(el.GetAttributeValue("style"))
+	r.sheet.Columns = append(r.sheet.Columns, &col)
+	return nil
+}
+
+func (w *sheetWriter) writeColumns(sheet *Sheet) {
+	if len(sheet.Columns) == 0 {
+		return
+	}
+	name := sax.LocalName("cols")
+	w.writer.Open(name, nil)
+	for _, c := range sheet.Columns {
+		attrs := []sax.A{
+			createAttr("min", strconv.FormatInt(c.Min, 10)),
+			createAttr("max", strconv.FormatInt(c.Max, 10)),
+		}
+		if c.Width > 0 {
+			attrs = append(attrs, createAttr("width", strconv.FormatFloat(c.Width, 'f', -1, 64)))
+			attrs = append(attrs, createAttr("customWidth", "1"))
+		}
+		if c.style > 0 {
+			attrs = append(attrs, createAttr("style", strconv.Itoa(c.style)))
+		}
+		if c.Hidden {
+			attrs = append(attrs, createAttr("hidden", "1"))
+		}
+		w.writer.Empty(sax.LocalName("col"), attrs)
+	}
+	w.writer.Close(name)
+}
@@ -0,0 +1,241 @@
+package oxml
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	sax "github.com/midbel/codecs/xml"
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/layout"
+)
+
+const (
+	RuleCellIs     = "cellIs"
+	RuleColorScale = "colorScale"
+	RuleDataBar    = "dataBar"
+)
+
+const (
+	OpLessThan           = "lessThan"
+	OpLessThanOrEqual    = "lessThanOrEqual"
+	OpEqual              = "equal"
+	OpNotEqual           = "notEqual"
+	OpGreaterThanOrEqual = "greaterThanOrEqual"
+	OpGreaterThan        = "greaterThan"
+	OpBetween            = "between"
+	OpNotBetween         = "notBetween"
+)
+
+type RuleValue struct {
+	Type  string
+	Value string
+}
+
+// RuleColor is the color used by a rule. Colors of the theme are given by
+// Theme and Tint, Rgb being then the color resolved from the theme of the
+// workbook when the file was read. Theme takes precedence over Rgb when the
+// rule is written.
+type RuleColor struct {
+	Rgb   string
+	Theme string
+	Tint  string
+}
+
+type ConditionalRule struct {
+	Type       string
+	Priority   int
+	Operator   string
+	Style      string
+	StopIfTrue bool
+	Formulas   []string
+
+	Values []RuleValue
+	Colors []RuleColor
+}
+
+type ConditionalFormat struct {
+	Ranges []*layout.Range
+	Rules  []*ConditionalRule
+}
+
+func (c *ConditionalFormat) Ref() string {
+	var list []string
+	for _, rg := range c.Ranges {
+		list = append(list, rg.String())
+	}
+	return strings.Join(list, " ")
+}
+
+// AddCellRule adds a cellIs rule on the given range. The values are the
+// formulas compared with the value of each cell of the range.
+func (s *Sheet) AddCellRule(rg *layout.Range, operator string, values ...string) error {
+	if s.IsLock() {
+		return grid.ErrLock
+	}
+	switch operator {
+	case OpBetween, OpNotBetween:
+		if len(values) != 2 {
+			return fmt.Errorf("%s: two values expected", operator)
+		}
+	case OpLessThan, OpLessThanOrEqual, OpEqual, OpNotEqual, OpGreaterThanOrEqual, OpGreaterThan:
+		if len(values) != 1 {
+			return fmt.Errorf("%s: one value expected", operator)
+		}
+	default:
+		return fmt.Errorf("%s: unsupported operator", operator)
+	}
+	rule := ConditionalRule{
+		Type:     RuleCellIs,
+		Priority: s.nextRulePriority(),
+		Operator: operator,
+		Formulas: slices.Clone(values),
+	}
+	cf := ConditionalFormat{
+		Ranges: []*layout.Range{rg.Normalize()},
+		Rules:  []*ConditionalRule{&rule},
+	}
+	s.Conditionals = append(s.Conditionals, &cf)
+	return nil
+}
+
+func (s *Sheet) nextRulePriority() int {
+	var prio int
+	for _, cf := range s.Conditionals {
+		for _, r := range cf.Rules {
+			prio = max(prio, r.Priority)
+		}
+	}
+	return prio + 1
+}
+
+func parseRef(str string) []*layout.Range {
+	var list []*layout.Range
+	for _, ref := range strings.Fields(str) {
+		rg := layout.RangeFromString(ref)
+		if !strings.Contains(ref, ":") {
+			rg.Ends = rg.Starts
+		}
+		list = append(list, rg)
+	}
+	return list
+}
+
+func (r *sheetReader) onConditionalFormatting(rs *sax.Reader, el sax.E) error {
+	cf := ConditionalFormat{
+		Ranges: parseRef(el.GetAttributeValue("sqref")),
+	}
+	r.sheet.Conditionals = append(r.sheet.Conditionals, &cf)
+
+	rs.Element(sax.LocalName("cfRule"), func(rs *sax.Reader, el sax.E) error {
+		rule := ConditionalRule{
+			Type:       el.GetAttributeValue("type"),
+			Operator:   el.GetAttributeValue("operator"),
+			Style:      el.GetAttributeValue("dxfId"),
+			StopIfTrue: el.GetAttributeValue("stopIfTrue") == "1",
+		}
+		rule.Priority, _ = strconv.Atoi(el.GetAttributeValue("priority"))
+		cf.Rules = append(cf.Rules, &rule)
+
+		rs.Element(sax.LocalName("formula"), func(rs *sax.Reader, _ sax.E) error {
+			rs.OnText(func(_ *sax.Reader, str string) error {
+				rule.Formulas = append(rule.Formulas, str)
+				return nil
+			})
+			return nil
+		})
+		rs.Element(sax.LocalName("cfvo"), func(_ *sax.Reader, el sax.E) error {
+			v := RuleValue{
+				Type:  el.GetAttributeValue("type"),
+				Value: el.GetAttributeValue("val"),
+			}
+			rule.Values = append(rule.Values, v)
+			return nil
+		})
+		rs.Element(sax.LocalName("color"), func(_ *sax.Reader, el sax.E) error {
+			c := RuleColor{
+				Rgb:   el.GetAttributeValue("rgb"),
+				Theme: el.GetAttributeValue("theme"),
+				Tint:  el.GetAttributeValue("tint"),
+			}
+			if c.Rgb == "" && c.Theme != "" && r.theme != nil {
+				c.Rgb, _ = r.theme.Resolve(c.Theme, c.Tint)
+			}
+			rule.Colors = append(rule.Colors, c)
+			return nil
+		})
+		return nil
+	})
+	return nil
+}
+
+func (w *sheetWriter) writeConditionals(sheet *Sheet) error {
+	var (
+		cfName   = sax.LocalName("conditionalFormatting")
+		ruleName = sax.LocalName("cfRule")
+	)
+	for _, cf := range sheet.Conditionals {
+		if len(cf.Rules) == 0 {
+			continue
+		}
+		w.writer.Open(cfName, []sax.A{
+			createAttr("sqref", cf.Ref()),
+		})
+		for _, r := range cf.Rules {
+			attrs := []sax.A{
+				createAttr("type", r.Type),
+			}
+			if r.Style != "" {
+				attrs = append(attrs, createAttr("dxfId", r.Style))
+			}
+			attrs = append(attrs, createAttr("priority", strconv.Itoa(r.Priority)))
+			if r.StopIfTrue {
+				attrs = append(attrs, createAttr("stopIfTrue", "1"))
+			}
+			if r.Operator != "" {
+				attrs = append(attrs, createAttr("operator", r.Operator))
+			}
+			w.writer.Open(ruleName, attrs)
+			switch r.Type {
+			case RuleColorScale, RuleDataBar:
+				w.writeRuleScale(r)
+			}
+			for _, f := range r.Formulas {
+				w.writer.Open(sax.LocalName("formula"), nil)
+				w.writer.Text(f)
+				w.writer.Close(sax.LocalName("formula"))
+			}
+			w.writer.Close(ruleName)
+		}
+		w.writer.Close(cfName)
+	}
+	return nil
+}
+
+func (w *sheetWriter) writeRuleScale(rule *ConditionalRule) {
+	name := sax.LocalName(rule.Type)
+	w.writer.Open(name, nil)
+	for _, v := range rule.Values {
+		attrs := []sax.A{
+			createAttr("type", v.Type),
+		}
+		if v.Value != "" {
+			attrs = append(attrs, createAttr("val", v.Value))
+		}
+		w.writer.Empty(sax.LocalName("cfvo"), attrs)
+	}
+	for _, c := range rule.Colors {
+		var attrs []sax.A
+		if c.Theme != "" {
+			attrs = append(attrs, createAttr("theme", c.Theme))
+			if c.Tint != "" {
+				attrs = append(attrs, createAttr("tint", c.Tint))
+			}
+		} else if c.Rgb != "" {
+			attrs = append(attrs, createAttr("rgb", c.Rgb))
+		}
+		w.writer.Empty(sax.LocalName("color"), attrs)
+	}
+	w.writer.Close(name)
+}
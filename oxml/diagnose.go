@@ -0,0 +1,113 @@
+package oxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/midbel/dockit/grid"
+)
+
+// PartError reports a problem found while reading one of the parts of the
+// archive of a workbook. Offset is the number of bytes of the part read when
+// the problem was detected or -1 when it is not known.
+type PartError struct {
+	Part   string
+	Offset int64
+	Err    error
+}
+
+func (e *PartError) Error() string {
+	if e.Offset < 0 {
+		return fmt.Sprintf("%s: %s", e.Part, e.Err)
+	}
+	return fmt.Sprintf("%s: offset %d: %s", e.Part, e.Offset, e.Err)
+}
+
+func (e *PartError) Unwrap() []error {
+	return []error{grid.ErrFile, e.Err}
+}
+
+func partError(part string, offset int64, err error) error {
+	var pe *PartError
+	if errors.As(err, &pe) {
+		return err
+	}
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return &PartError{
+		Part:   part,
+		Offset: offset,
+		Err:    err,
+	}
+}
+
+// partReader counts the bytes read from a part and keeps the first error
+// given by the archive. The sax reader stops silently on such errors.
+type partReader struct {
+	io.Reader
+	name string
+	read int64
+	err  error
+}
+
+func (r *partReader) Read(b []byte) (int, error) {
+	n, err := r.Reader.Read(b)
+	r.read += int64(n)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// check gives the error of the archive if any or err wrapped with the name of
+// the part.
+func (r *partReader) check(err error) error {
+	if r.err != nil {
+		return partError(r.name, r.read, r.err)
+	}
+	if err != nil {
+		return partError(r.name, r.read, err)
+	}
+	return nil
+}
+
+// unmarshalPart decodes the content of a part already read from the archive.
+func unmarshalPart(name string, raw []byte, ptr any) error {
+	dec := xml.NewDecoder(bytes.NewReader(raw))
+	if err := dec.Decode(ptr); err != nil {
+		return partError(name, dec.InputOffset(), err)
+	}
+	return nil
+}
+
+// Diagnose reads the workbook in file and gives the problems found in its
+// parts. Unlike Open, it does not stop at the first part that can not be read
+// when the rest of the workbook can be read without it. The error is given
+// when the workbook itself can not be read.
+func Diagnose(file string) ([]error, error) {
+	rs, err := readFile(file)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	rs.diagnose = true
+	if _, err := rs.ReadFile(); err != nil {
+		return rs.problems, err
+	}
+	return rs.problems, nil
+}
+
+// tolerate keeps the error of a part that is not needed to read the rest of the
+// workbook as a problem when the workbook is diagnosed.
+func (r *reader) tolerate() {
+	if !r.diagnose || r.err == nil {
+		return
+	}
+	r.problems = append(r.problems, r.err)
+	r.err = nil
+}
@@ -0,0 +1,413 @@
+package oxml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	sax "github.com/midbel/codecs/xml"
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/layout"
+)
+
+// emuPerPixel is the number of English Metric Units in a pixel at 96 dpi.
+const emuPerPixel = 9525
+
+const (
+	typeDrawingUrl = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/drawing"
+	typeImageUrl   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image"
+	typeChartUrl   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart"
+
+	drawingMainUrl  = "http://schemas.openxmlformats.org/drawingml/2006/main"
+	drawingSheetUrl = "http://schemas.openxmlformats.org/drawingml/2006/spreadsheetDrawing"
+	drawingChartUrl = "http://schemas.openxmlformats.org/drawingml/2006/chart"
+	relationsUrl    = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+)
+
+type xmlDrawing struct {
+	XMLName xml.Name    `xml:"wsDr"`
+	OneCell []xmlAnchor `xml:"oneCellAnchor"`
+	TwoCell []xmlAnchor `xml:"twoCellAnchor"`
+}
+
+type xmlAnchor struct {
+	From    xmlMarker   `xml:"from"`
+	Picture *xmlPicture `xml:"pic"`
+}
+
+type xmlMarker struct {
+	Col    int64 `xml:"col"`
+	ColOff int64 `xml:"colOff"`
+	Row    int64 `xml:"row"`
+	RowOff int64 `xml:"rowOff"`
+}
+
+type xmlPicture struct {
+	Props struct {
+		Name        string `xml:"name,attr"`
+		Description string `xml:"descr,attr"`
+	} `xml:"nvPicPr>cNvPr"`
+	Blip struct {
+		Embed string `xml:"embed,attr"`
+	} `xml:"blipFill>blip"`
+	Size struct {
+		Cx int64 `xml:"cx,attr"`
+		Cy int64 `xml:"cy,attr"`
+	} `xml:"spPr>xfrm>ext"`
+}
+
+func hasDrawing(sheet *Sheet) bool {
+	return len(sheet.Images) > 0 || len(sheet.Charts) > 0
+}
+
+func drawingRelId(sheet *Sheet) string {
+	return relId(len(sheet.Tables))
+}
+
+// chartRelId gives the id of the relation between a drawing and its chart.
+// Relations of the images come first.
+func chartRelId(sheet *Sheet, ix int) string {
+	return relId(len(sheet.Images) + ix)
+}
+
+func (r *sheetReader) onDrawing(rs *sax.Reader, el sax.E) error {
+	for _, a := range el.Attrs {
+		if a.Name == "id" {
+			r.drawing = a.Value
+		}
+	}
+	return nil
+}
+
+// readDrawing reads the images placed on a sheet. Other kinds of objects of the
+// drawing are ignored.
+func (r *reader) readDrawing(sheet *Sheet, addr string, id string) {
+	if r.invalid() || id == "" {
+		return
+	}
+	target, err := r.resolveRelation(addr, id)
+	if err != nil {
+		r.err = err
+		return
+	}
+	var root xmlDrawing
+	if err := r.decodeXML(target, &root); err != nil {
+		return
+	}
+	anchors := append(root.OneCell, root.TwoCell...)
+	for _, a := range anchors {
+		if a.Picture == nil {
+			continue
+		}
+		media, err := r.resolveRelation(target, a.Picture.Blip.Embed)
+		if err != nil {
+			r.err = err
+			return
+		}
+		data, err := r.readBytes(media)
+		if err != nil {
+			r.err = err
+			return
+		}
+		format := strings.TrimPrefix(path.Ext(media), ".")
+		if format == "jpg" {
+			format = "jpeg"
+		}
+		img := Image{
+			Name:        a.Picture.Props.Name,
+			Description: a.Picture.Props.Description,
+			Format:      format,
+			Position:    layout.NewPosition(a.From.Row+1, a.From.Col+1),
+			OffsetX:     int(a.From.ColOff / emuPerPixel),
+			OffsetY:     int(a.From.RowOff / emuPerPixel),
+			Width:       int(a.Picture.Size.Cx / emuPerPixel),
+			Height:      int(a.Picture.Size.Cy / emuPerPixel),
+			Data:        data,
+		}
+		sheet.Images = append(sheet.Images, &img)
+	}
+}
+
+// resolveRelation gives the location in the archive of the part referenced by
+// the relation with the given id in the relations of the part at addr.
+func (r *reader) resolveRelation(addr, id string) (string, error) {
+	var (
+		dir  = path.Dir(addr)
+		rels xmlRelations
+	)
+	if err := r.decodeXML(path.Join(dir, "_rels", path.Base(addr)+".rels"), &rels); err != nil {
+		return "", err
+	}
+	ix := slices.IndexFunc(rels.Relations, func(r xmlRelation) bool {
+		return r.Id == id
+	})
+	if ix < 0 {
+		return "", fmt.Errorf("%w: relation with id %s not found", grid.ErrFile, id)
+	}
+	target := rels.Relations[ix].Target
+	if path.IsAbs(target) {
+		return target[1:], nil
+	}
+	return path.Join(dir, target), nil
+}
+
+func (r *reader) readBytes(name string) ([]byte, error) {
+	rs, err := r.openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := io.ReadAll(rs)
+	return raw, rs.check(err)
+}
+
+// writeDrawing writes the images and the charts of the sheet and the drawing
+// part placing them. It gives the relation between the sheet and its drawing.
+func (z *writer) writeDrawing(sheet *Sheet) []xmlRelation {
+	if z.invalid() || !hasDrawing(sheet) {
+		return nil
+	}
+	z.lastDrawingId++
+	var (
+		name = fmt.Sprintf("drawing%d.xml", z.lastDrawingId)
+		root = xmlRelations{
+			Xmlns: "http://schemas.openxmlformats.org/package/2006/relationships",
+		}
+	)
+	for i, img := range sheet.Images {
+		z.lastImageId++
+		media := fmt.Sprintf("image%d.%s", z.lastImageId, img.Format)
+		w, err := z.create(z.createTarget("media", media))
+		if err != nil {
+			z.err = err
+			return nil
+		}
+		if _, err := w.Write(img.Data); err != nil {
+			z.err = err
+			return nil
+		}
+		z.media[img.Format] = struct{}{}
+
+		rx := xmlRelation{
+			Id:     relId(i),
+			Type:   typeImageUrl,
+			Target: "../media/" + media,
+		}
+		root.Relations = append(root.Relations, rx)
+	}
+	for i, ch := range sheet.Charts {
+		z.lastChartId++
+		chart := fmt.Sprintf("chart%d.xml", z.lastChartId)
+		addr := z.createTarget("charts", chart)
+		w, err := z.create(addr)
+		if err != nil {
+			z.err = err
+			return nil
+		}
+		cw, err := writeSheet(w)
+		if err != nil {
+			z.err = err
+			return nil
+		}
+		if err := cw.writeChart(sheet, ch); err != nil {
+			z.err = err
+			return nil
+		}
+		z.charts = append(z.charts, addr)
+
+		rx := xmlRelation{
+			Id:     chartRelId(sheet, i),
+			Type:   typeChartUrl,
+			Target: "../charts/" + chart,
+		}
+		root.Relations = append(root.Relations, rx)
+	}
+	addr := z.createTarget("drawings", name)
+	w, err := z.create(addr)
+	if err != nil {
+		z.err = err
+		return nil
+	}
+	dw, err := writeSheet(w)
+	if err != nil {
+		z.err = err
+		return nil
+	}
+	if err := dw.writeDrawing(sheet); err != nil {
+		z.err = err
+		return nil
+	}
+	z.encodeXML(z.createTarget("drawings", "_rels", name+".rels"), &root)
+	z.drawings = append(z.drawings, addr)
+
+	rx := xmlRelation{
+		Id:     drawingRelId(sheet),
+		Type:   typeDrawingUrl,
+		Target: "../drawings/" + name,
+	}
+	return []xmlRelation{rx}
+}
+
+func xdrName(name string) sax.QName {
+	return sax.QualifiedName(name, "xdr")
+}
+
+func dmlName(name string) sax.QName {
+	return sax.QualifiedName(name, "a")
+}
+
+func emu(px int) string {
+	return strconv.FormatInt(int64(px)*emuPerPixel, 10)
+}
+
+func (w *sheetWriter) writeDrawing(sheet *Sheet) error {
+	w.writer.Open(xdrName("wsDr"), []sax.A{
+		createNS("xdr", drawingSheetUrl),
+		createNS("a", drawingMainUrl),
+		createNS("r", relationsUrl),
+	})
+	var id int
+	for i, img := range sheet.Images {
+		id++
+		w.writePicture(img, id, relId(i))
+	}
+	for i, ch := range sheet.Charts {
+		id++
+		w.writeGraphicFrame(ch, id, chartRelId(sheet, i))
+	}
+	w.writer.Close(xdrName("wsDr"))
+	return w.writer.Flush()
+}
+
+func (w *sheetWriter) writeMarker(name string, pos layout.Position, offX, offY int) {
+	w.writer.Open(xdrName(name), nil)
+	w.writeText(xdrName("col"), strconv.FormatInt(pos.Column-1, 10))
+	w.writeText(xdrName("colOff"), emu(offX))
+	w.writeText(xdrName("row"), strconv.FormatInt(pos.Line-1, 10))
+	w.writeText(xdrName("rowOff"), emu(offY))
+	w.writer.Close(xdrName(name))
+}
+
+func (w *sheetWriter) writeText(qn sax.QName, str string) {
+	w.writer.Open(qn, nil)
+	w.writer.Text(str)
+	w.writer.Close(qn)
+}
+
+func (w *sheetWriter) writePicture(img *Image, id int, rel string) {
+	var (
+		cx = emu(img.Width)
+		cy = emu(img.Height)
+	)
+	w.writer.Open(xdrName("oneCellAnchor"), nil)
+	w.writeMarker("from", img.Position, img.OffsetX, img.OffsetY)
+	w.writer.Empty(xdrName("ext"), []sax.A{
+		createAttr("cx", cx),
+		createAttr("cy", cy),
+	})
+
+	w.writer.Open(xdrName("pic"), nil)
+	w.writer.Open(xdrName("nvPicPr"), nil)
+	w.writer.Empty(xdrName("cNvPr"), []sax.A{
+		createAttr("id", strconv.Itoa(id+1)),
+		createAttr("name", img.Name),
+		createAttr("descr", img.Description),
+	})
+	w.writer.Open(xdrName("cNvPicPr"), nil)
+	w.writer.Empty(dmlName("picLocks"), []sax.A{
+		createAttr("noChangeAspect", "1"),
+	})
+	w.writer.Close(xdrName("cNvPicPr"))
+	w.writer.Close(xdrName("nvPicPr"))
+
+	w.writer.Open(xdrName("blipFill"), nil)
+	w.writer.Empty(dmlName("blip"), []sax.A{
+		{QName: sax.QualifiedName("embed", "r"), Value: rel},
+	})
+	w.writer.Open(dmlName("stretch"), nil)
+	w.writer.Empty(dmlName("fillRect"), nil)
+	w.writer.Close(dmlName("stretch"))
+	w.writer.Close(xdrName("blipFill"))
+
+	w.writer.Open(xdrName("spPr"), nil)
+	w.writer.Open(dmlName("xfrm"), nil)
+	w.writer.Empty(dmlName("off"), []sax.A{
+		createAttr("x", "0"),
+		createAttr("y", "0"),
+	})
+	w.writer.Empty(dmlName("ext"), []sax.A{
+		createAttr("cx", cx),
+		createAttr("cy", cy),
+	})
+	w.writer.Close(dmlName("xfrm"))
+	w.writer.Open(dmlName("prstGeom"), []sax.A{
+		createAttr("prst", "rect"),
+	})
+	w.writer.Empty(dmlName("avLst"), nil)
+	w.writer.Close(dmlName("prstGeom"))
+	w.writer.Close(xdrName("spPr"))
+	w.writer.Close(xdrName("pic"))
+
+	w.writer.Empty(xdrName("clientData"), nil)
+	w.writer.Close(xdrName("oneCellAnchor"))
+}
+
+func (w *sheetWriter) writeGraphicFrame(ch *grid.Chart, id int, rel string) {
+	name := ch.Title
+	if name == "" {
+		name = fmt.Sprintf("Chart %d", id)
+	}
+	w.writer.Open(xdrName("twoCellAnchor"), nil)
+	w.writeMarker("from", ch.Anchor.From, 0, 0)
+	// the end of the anchor is inclusive: the chart covers the last cell
+	w.writeMarker("to", ch.Anchor.To.Offset(1, 1), 0, 0)
+
+	w.writer.Open(xdrName("graphicFrame"), []sax.A{
+		createAttr("macro", ""),
+	})
+	w.writer.Open(xdrName("nvGraphicFramePr"), nil)
+	w.writer.Empty(xdrName("cNvPr"), []sax.A{
+		createAttr("id", strconv.Itoa(id+1)),
+		createAttr("name", name),
+	})
+	w.writer.Empty(xdrName("cNvGraphicFramePr"), nil)
+	w.writer.Close(xdrName("nvGraphicFramePr"))
+
+	w.writer.Open(xdrName("xfrm"), nil)
+	w.writer.Empty(dmlName("off"), []sax.A{
+		createAttr("x", "0"),
+		createAttr("y", "0"),
+	})
+	w.writer.Empty(dmlName("ext"), []sax.A{
+		createAttr("cx", "0"),
+		createAttr("cy", "0"),
+	})
+	w.writer.Close(xdrName("xfrm"))
+
+	w.writer.Open(dmlName("graphic"), nil)
+	w.writer.Open(dmlName("graphicData"), []sax.A{
+		createAttr("uri", drawingChartUrl),
+	})
+	w.writer.Empty(sax.QualifiedName("chart", "c"), []sax.A{
+		createNS("c", drawingChartUrl),
+		{QName: sax.QualifiedName("id", "r"), Value: rel},
+	})
+	w.writer.Close(dmlName("graphicData"))
+	w.writer.Close(dmlName("graphic"))
+	w.writer.Close(xdrName("graphicFrame"))
+
+	w.writer.Empty(xdrName("clientData"), nil)
+	w.writer.Close(xdrName("twoCellAnchor"))
+}
+
+func (w *sheetWriter) writeDrawingPart(sheet *Sheet) {
+	if !hasDrawing(sheet) {
+		return
+	}
+	w.writer.Empty(sax.LocalName("drawing"), []sax.A{
+		{QName: sax.QualifiedName("id", "r"), Value: drawingRelId(sheet)},
+	})
+}
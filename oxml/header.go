@@ -0,0 +1,164 @@
+package oxml
+
+import (
+	"strings"
+
+	sax "github.com/midbel/codecs/xml"
+)
+
+// HeaderText is the text of a page header or footer split in its three
+// sections. Sections keep the other codes of Excel as they are (&P for the page
+// number, &D for the date,...).
+type HeaderText struct {
+	Left   string
+	Center string
+	Right  string
+}
+
+// ParseHeaderText splits the text of a header or footer on the &L, &C and &R
+// codes. Text given before any of these codes goes to the center section.
+func ParseHeaderText(str string) HeaderText {
+	var (
+		text HeaderText
+		curr = &text.Center
+	)
+	for len(str) > 0 {
+		ix := strings.IndexByte(str, '&')
+		if ix < 0 || ix == len(str)-1 {
+			*curr += str
+			break
+		}
+		*curr += str[:ix]
+		switch code := str[ix+1]; code {
+		case 'L', 'l':
+			curr = &text.Left
+		case 'C', 'c':
+			curr = &text.Center
+		case 'R', 'r':
+			curr = &text.Right
+		default:
+			*curr += str[ix : ix+2]
+		}
+		str = str[ix+2:]
+	}
+	return text
+}
+
+func (h HeaderText) IsZero() bool {
+	return h == HeaderText{}
+}
+
+// String gives the text of the header with the codes of its sections.
+func (h HeaderText) String() string {
+	var str strings.Builder
+	for _, s := range []struct {
+		Code string
+		Text string
+	}{
+		{Code: "&L", Text: h.Left},
+		{Code: "&C", Text: h.Center},
+		{Code: "&R", Text: h.Right},
+	} {
+		if s.Text == "" {
+			continue
+		}
+		str.WriteString(s.Code)
+		str.WriteString(s.Text)
+	}
+	return str.String()
+}
+
+// HeaderFooter holds the headers and footers of the printed pages. The odd
+// header and footer are used for all pages unless DifferentOddEven or
+// DifferentFirst are set.
+type HeaderFooter struct {
+	DifferentOddEven bool
+	DifferentFirst   bool
+
+	OddHeader   HeaderText
+	OddFooter   HeaderText
+	EvenHeader  HeaderText
+	EvenFooter  HeaderText
+	FirstHeader HeaderText
+	FirstFooter HeaderText
+}
+
+func (h HeaderFooter) isDefault() bool {
+	return h == HeaderFooter{}
+}
+
+func (h *HeaderFooter) parts() []struct {
+	Name string
+	Text *HeaderText
+} {
+	return []struct {
+		Name string
+		Text *HeaderText
+	}{
+		{Name: "oddHeader", Text: &h.OddHeader},
+		{Name: "oddFooter", Text: &h.OddFooter},
+		{Name: "evenHeader", Text: &h.EvenHeader},
+		{Name: "evenFooter", Text: &h.EvenFooter},
+		{Name: "firstHeader", Text: &h.FirstHeader},
+		{Name: "firstFooter", Text: &h.FirstFooter},
+	}
+}
+
+// SetHeader uses the same header for all the printed pages.
+func (s *Sheet) SetHeader(text HeaderText) {
+	s.Page.Headers.OddHeader = text
+}
+
+// SetFooter uses the same footer for all the printed pages.
+func (s *Sheet) SetFooter(text HeaderText) {
+	s.Page.Headers.OddFooter = text
+}
+
+func (s *Sheet) SetHeaderFooter(hf HeaderFooter) {
+	s.Page.Headers = hf
+}
+
+func (r *sheetReader) onHeaderFooter(rs *sax.Reader, el sax.E) error {
+	hf := &r.sheet.Page.Headers
+	hf.DifferentOddEven = el.GetAttributeValue("differentOddEven") == "1"
+	hf.DifferentFirst = el.GetAttributeValue("differentFirst") == "1"
+	for _, p := range hf.parts() {
+		text := p.Text
+		rs.Element(sax.LocalName(p.Name), func(rs *sax.Reader, _ sax.E) error {
+			rs.OnText(func(_ *sax.Reader, str string) error {
+				*text = ParseHeaderText(str)
+				return nil
+			})
+			return nil
+		})
+	}
+	return nil
+}
+
+func (w *sheetWriter) writeHeaderFooter(sheet *Sheet) {
+	hf := sheet.Page.Headers
+	if hf.isDefault() {
+		return
+	}
+	var (
+		name  = sax.LocalName("headerFooter")
+		attrs []sax.A
+	)
+	if hf.DifferentOddEven {
+		attrs = append(attrs, createAttr("differentOddEven", "1"))
+	}
+	if hf.DifferentFirst {
+		attrs = append(attrs, createAttr("differentFirst", "1"))
+	}
+	w.writer.Open(name, attrs)
+	for _, p := range hf.parts() {
+		if p.Text.IsZero() {
+			continue
+		}
+		part := sax.LocalName(p.Name)
+		w.writer.Open(part, nil)
+		w.writer.Text(p.Text.String())
+		w.writer.Close(part)
+	}
+	w.writer.Close(name)
+}
@@ -0,0 +1,90 @@
+package oxml
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/layout"
+)
+
+var imageTypes = map[string]string{
+	"png":  "image/png",
+	"jpeg": "image/jpeg",
+}
+
+type ImageOptions struct {
+	Name        string
+	Description string
+	// Width and Height are given in pixels. When both are zero, the size of
+	// the image is used. When only one is set, the other is computed to keep
+	// the aspect ratio of the image.
+	Width   int
+	Height  int
+	OffsetX int
+	OffsetY int
+}
+
+// Image is a picture placed on a sheet. Its top left corner is anchored to a
+// cell and it keeps its size when rows and columns are resized.
+type Image struct {
+	Name        string
+	Description string
+	Format      string
+	Position    layout.Position
+	OffsetX     int
+	OffsetY     int
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// AddImage places the PNG or JPEG image read from r with its top left corner
+// on the cell at the given position.
+func (s *Sheet) AddImage(pos layout.Position, r io.Reader, opts ImageOptions) (*Image, error) {
+	if s.IsLock() {
+		return nil, grid.ErrLock
+	}
+	if pos.Line < 1 || pos.Column < 1 {
+		return nil, fmt.Errorf("%s: invalid position for image", pos)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := imageTypes[format]; !ok {
+		return nil, fmt.Errorf("%s: unsupported image format", format)
+	}
+	img := Image{
+		Name:        opts.Name,
+		Description: opts.Description,
+		Format:      format,
+		Position:    pos.WithoutSheet(),
+		OffsetX:     opts.OffsetX,
+		OffsetY:     opts.OffsetY,
+		Width:       opts.Width,
+		Height:      opts.Height,
+		Data:        data,
+	}
+	switch {
+	case img.Width <= 0 && img.Height <= 0:
+		img.Width, img.Height = cfg.Width, cfg.Height
+	case img.Height <= 0:
+		img.Height = img.Width * cfg.Height / max(cfg.Width, 1)
+	case img.Width <= 0:
+		img.Width = img.Height * cfg.Width / max(cfg.Height, 1)
+	}
+	if img.Name == "" {
+		img.Name = fmt.Sprintf("Picture %d", len(s.Images)+1)
+	}
+	s.Images = append(s.Images, &img)
+	return &img, nil
+}
@@ -35,7 +35,7 @@ func (loader) Open(file string) (grid.File, error) {
 }
 
 func (loader) IsSupportedExt(ext string) bool {
-	return ext == ".xlsx"
+	return ext == ".xlsx" || ext == ".xlsm"
 }
 
 func isOxml(file string) (bool, error) {
@@ -0,0 +1,71 @@
+package oxml
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/midbel/dockit/layout"
+)
+
+// DefineName gives a name, scoped to the workbook, to a range of cells of one
+// of its sheets. Names already defined refer to the new range.
+func (f *File) DefineName(name string, rg *layout.Range) error {
+	if name == "" || strings.HasPrefix(name, "_xlnm.") || layout.IsAddress(name) {
+		return fmt.Errorf("%s: invalid name for range", name)
+	}
+	if _, err := f.sheetByName(rg.Starts.Sheet); err != nil {
+		return err
+	}
+	if f.definedNames == nil {
+		f.definedNames = make(map[string]*layout.Range)
+	}
+	f.definedNames[name] = layout.NewRange(rg.Starts, rg.Ends)
+	return nil
+}
+
+// DefinedNames gives the names scoped to the workbook and the ranges they
+// refer to.
+func (f *File) DefinedNames() map[string]*layout.Range {
+	return maps.Clone(f.definedNames)
+}
+
+// readDefinedName keeps a name scoped to the workbook referring to a single
+// range. Names of formulas or of constants are ignored.
+func (f *File) readDefinedName(n xmlDefinedName) {
+	sheet, ref, ok := strings.Cut(n.Value, "!")
+	if n.Sheet != nil || !ok || strings.Contains(ref, ",") {
+		return
+	}
+	sheet = strings.Trim(sheet, "'")
+	sheet = strings.ReplaceAll(sheet, "''", "'")
+	if _, err := f.sheetByName(sheet); err != nil {
+		return
+	}
+	rg := parsePrintArea(ref)
+	if rg == nil {
+		return
+	}
+	rg.Starts.Sheet = sheet
+	rg.Ends.Sheet = sheet
+	f.DefineName(n.Name, rg)
+}
+
+// writeDefinedNames gives the names scoped to the workbook sorted by name.
+// Names referring to sheets removed since their definition are dropped.
+func (f *File) writeDefinedNames() []*xmlDefinedName {
+	var list []*xmlDefinedName
+	for _, name := range slices.Sorted(maps.Keys(f.definedNames)) {
+		rg := f.definedNames[name]
+		if _, err := f.sheetByName(rg.Starts.Sheet); err != nil {
+			continue
+		}
+		sheet := strings.ReplaceAll(rg.Starts.Sheet, "'", "''")
+		list = append(list, &xmlDefinedName{
+			Name:  name,
+			Value: fmt.Sprintf("'%s'!%s:%s", sheet, absoluteAddr(rg.Starts), absoluteAddr(rg.Ends)),
+		})
+	}
+	return list
+}
@@ -1,6 +1,8 @@
 package oxml
 
 import (
+	"cmp"
+	"compress/flate"
 	"encoding/xml"
 	"fmt"
 	"io"
@@ -8,8 +10,11 @@ import (
 	"maps"
 	"math"
 	"os"
+	"path/filepath"
 	"slices"
-	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/midbel/dockit/grid"
 	"github.com/midbel/dockit/internal/id"
@@ -20,6 +25,7 @@ import (
 const (
 	FormulaNormal = "normal"
 	FormulaShared = "shared"
+	FormulaArray  = "array"
 )
 
 type Cell struct {
@@ -29,10 +35,19 @@ type Cell struct {
 	layout.Position
 
 	raw     string
+	runs    RichText
 	parsed  value.Value
 	formula value.Formula
 	dirty   bool
 
+	// array given by the formula and size of the range it fills. The range of
+	// formulas entered as arrays in a spreadsheet application is fixed.
+	array value.ArrayValue
+	spill layout.Dimension
+	fixed bool
+	// formula whose array gives the value of the cell
+	anchor *Cell
+
 	link *grid.Link
 }
 
@@ -94,24 +109,38 @@ func (c *Cell) Formula() value.Formula {
 }
 
 func (c *Cell) Sync(ctx value.Context) error {
-	if c.formula == nil || !c.Dirty() {
+	if !c.Dirty() {
+		return nil
+	}
+	if c.formula == nil {
+		c.resetDirty()
 		return nil
 	}
 	val, err := grid.Eval(c.formula, ctx)
 	if err == nil {
 		c.update(val)
-		grid.MarkDirty(c)
+		c.resetDirty()
 	}
 	return err
 }
 
 func (c *Cell) update(val value.Value) {
-	if !value.IsScalar(val) {
+	c.array = nil
+	switch v := val.(type) {
+	case value.ScalarValue:
+		// errors are kept as they are
+		c.parsed = v
+	case value.ArrayValue:
+		if dim := v.Dimension(); dim.Lines == 0 || dim.Columns == 0 {
+			c.parsed = value.ErrValue
+			break
+		}
+		c.array = collectArray(v)
+		c.parsed = c.array.At(0, 0)
+	default:
 		c.parsed = value.ErrValue
-	} else {
-		c.parsed = val.(value.ScalarValue)
-		c.raw = val.String()
 	}
+	c.raw = c.parsed.String()
 }
 
 func (c *Cell) resetDirty() {
@@ -125,23 +154,38 @@ type row struct {
 }
 
 func (r *row) AppendOrReplace(cell *Cell) {
-	cx := slices.IndexFunc(r.Cells, func(other *Cell) bool {
-		return other.Position.Equal(cell.Position)
-	})
-	if cx >= 0 {
-		r.Cells[cx] = cell
+	ix, ok := r.search(cell.Column)
+	if ok {
+		r.Cells[ix] = cell
 	} else {
-		r.Append(cell)
+		r.Cells = slices.Insert(r.Cells, ix, cell)
 	}
 }
 
 func (r *row) Append(cell *Cell) {
-	r.Cells = append(r.Cells, cell)
-	slices.SortFunc(r.Cells, func(c1, c2 *Cell) int {
-		return int(c1.Column - c2.Column)
+	ix, _ := r.search(cell.Column)
+	r.Cells = slices.Insert(r.Cells, ix, cell)
+}
+
+// search gives the index of the cell of the row in the given column or the
+// index where it should be inserted. Cells are mostly appended in order so the
+// last cell is checked first.
+func (r *row) search(col int64) (int, bool) {
+	if n := len(r.Cells); n == 0 || r.Cells[n-1].Column < col {
+		return n, false
+	}
+	return slices.BinarySearchFunc(r.Cells, col, func(c *Cell, col int64) int {
+		return cmp.Compare(c.Column, col)
 	})
 }
 
+// remove drops the cell of the row in the given column if any.
+func (r *row) remove(col int64) {
+	if ix, ok := r.search(col); ok {
+		r.Cells = slices.Delete(r.Cells, ix, ix+1)
+	}
+}
+
 func (r *row) Values() []value.Value {
 	var ds []value.Value
 	for _, c := range r.Cells {
@@ -245,13 +289,27 @@ type Sheet struct {
 	Index  int
 	Size   layout.Dimension
 
-	Charts []*grid.Chart
+	Columns      []*Column
+	Charts       []*grid.Chart
+	Conditionals []*ConditionalFormat
+	AutoFilter   *layout.Range
+	Tables       []*Table
+	Images       []*Image
+	Pivots       []*PivotTable
+	Display      SheetView
+	Page         PageSetup
+
+	rows    []*row
+	cells   map[layout.Position]*Cell
+	spilled *grid.SpillView
+	owner   *File
 
-	rows  []*row
-	cells map[layout.Position]*Cell
+	// codeName is the name given to the sheet by the VBA project
+	codeName string
 
 	State     SheetState
 	Protected SheetProtection
+	Password  *Password
 }
 
 func NewSheet(name string) *Sheet {
@@ -273,7 +331,7 @@ func (s *Sheet) Rename(name string) {
 	if s.IsLock() {
 		return
 	}
-	s.Label = name
+	s.Label = cleanName(name)
 }
 
 func (s *Sheet) View(rg *layout.Range) grid.View {
@@ -291,7 +349,10 @@ func (s *Sheet) Cell(pos layout.Position) (grid.Cell, error) {
 	if err := grid.CheckName(pos, s); err != nil {
 		return nil, err
 	}
-	cell, ok := s.cells[pos]
+	if s.spilled != nil {
+		return s.spilled.Cell(pos.WithoutSheet())
+	}
+	cell, ok := s.cells[pos.WithoutSheet()]
 	if !ok {
 		return grid.Empty(pos), nil
 	}
@@ -299,20 +360,33 @@ func (s *Sheet) Cell(pos layout.Position) (grid.Cell, error) {
 }
 
 func (s *Sheet) Sync(ctx value.Context) error {
+	if s.spilled != nil {
+		return nil
+	}
 	ctx = grid.EnclosedContext(ctx, grid.SheetContext(s))
+	var list []*Cell
 	for _, r := range s.rows {
-		for i, c := range r.Cells {
-			if err := c.Sync(ctx); err != nil {
-				return err
-			}
-			r.Cells[i] = c
-			s.cells[c.Position] = c
+		list = append(list, r.Cells...)
+	}
+	for _, c := range list {
+		dirty := c.Dirty()
+		if err := c.Sync(ctx); err != nil {
+			return err
+		}
+		if !dirty {
+			continue
+		}
+		if err := s.SpillArray(c); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
 func (s *Sheet) Bounds() *layout.Range {
+	if s.spilled != nil {
+		return s.spilled.Bounds()
+	}
 	var (
 		minRow int64 = math.MaxInt64
 		maxRow int64
@@ -347,6 +421,9 @@ func (s *Sheet) Bounds() *layout.Range {
 }
 
 func (s *Sheet) Rows() iter.Seq2[int64, []value.Value] {
+	if s.spilled != nil {
+		return s.spilled.Rows()
+	}
 	it := func(yield func(int64, []value.Value) bool) {
 		for _, r := range s.rows {
 			row := r.Values()
@@ -361,16 +438,36 @@ func (s *Sheet) Rows() iter.Seq2[int64, []value.Value] {
 	return it
 }
 
+// StoredCells gives the cells kept by the sheet row by row. Empty cells are
+// skipped.
+func (s *Sheet) StoredCells() iter.Seq[grid.Cell] {
+	return func(yield func(grid.Cell) bool) {
+		if s.spilled != nil {
+			for pos := range s.Bounds().Positions() {
+				c, _ := s.Cell(pos)
+				if !yield(c) {
+					return
+				}
+			}
+			return
+		}
+		for _, r := range s.rows {
+			for _, c := range r.Cells {
+				if !yield(c) {
+					return
+				}
+			}
+		}
+	}
+}
+
 func (s *Sheet) Clone(mode grid.CopyMode) (grid.View, error) {
 	if !mode.Valid() {
 		return nil, fmt.Errorf("specify at least value to for mode")
 	}
-	var (
-		sh = NewSheet(s.Label)
-		bd = s.Bounds()
-	)
-	for pos := range bd.Positions() {
-		c, _ := s.Cell(pos)
+	sh := NewSheet(s.Label)
+	sh.owner = s.owner
+	for c := range s.StoredCells() {
 		sh.put(c, mode)
 	}
 	return sh, nil
@@ -394,16 +491,76 @@ func (s *Sheet) Lock() {
 
 func (s *Sheet) Unlock() {
 	s.Protected = 0
+	s.Password = nil
+}
+
+// LockWithPassword locks the sheet and protects it with the given password.
+func (s *Sheet) LockWithPassword(password string) error {
+	pwd, err := createPassword(password)
+	if err != nil {
+		return err
+	}
+	s.Lock()
+	s.Password = pwd
+	return nil
+}
+
+// Protect locks the sheet with only the given protections. The sheet is also
+// protected by the password when not empty.
+func (s *Sheet) Protect(flags SheetProtection, password string) error {
+	var pwd *Password
+	if password != "" {
+		p, err := createPassword(password)
+		if err != nil {
+			return err
+		}
+		pwd = p
+	}
+	s.Protected = flags | ProtectedSheet
+	s.Password = pwd
+	return nil
+}
+
+// UnlockWithPassword unlocks the sheet if the password matches the one
+// protecting the sheet.
+func (s *Sheet) UnlockWithPassword(password string) error {
+	if s.Password != nil {
+		if err := s.Password.Verify(password); err != nil {
+			return err
+		}
+	}
+	s.Unlock()
+	return nil
 }
 
 func (s *Sheet) IsLock() bool {
 	return s.Protected != 0
 }
 
+// SetAutoFilter enables the filter dropdowns on the first row of the given
+// range. A nil range removes the filter of the sheet.
+func (s *Sheet) SetAutoFilter(rg *layout.Range) error {
+	if s.IsLock() {
+		return grid.ErrLock
+	}
+	if rg == nil {
+		s.AutoFilter = nil
+		return nil
+	}
+	rg = rg.Normalize()
+	rg.Starts.Sheet = ""
+	rg.Ends.Sheet = ""
+	s.AutoFilter = rg
+	return nil
+}
+
 func (s *Sheet) SetValue(pos layout.Position, val value.Value) error {
 	if err := grid.CheckName(pos, s); err != nil {
 		return err
 	}
+	if s.spilled != nil {
+		return grid.ErrWritable
+	}
 	c, ok := s.cells[pos.WithoutSheet()]
 	if !ok {
 		c = &Cell{
@@ -417,9 +574,12 @@ func (s *Sheet) SetValue(pos layout.Position, val value.Value) error {
 	}
 	if c.formula == nil {
 		c.Type = typeFromValue(val)
-		c.raw = val.String()
+		c.raw = rawFromValue(val)
 	}
+	c.anchor = nil
 	c.parsed = val
+	c.runs = nil
+	c.dirty = true
 	s.insertOrReplaceCell(c)
 	return nil
 }
@@ -428,6 +588,9 @@ func (s *Sheet) SetFormula(pos layout.Position, expr value.Formula) error {
 	if err := grid.CheckName(pos, s); err != nil {
 		return err
 	}
+	if s.spilled != nil {
+		return grid.ErrWritable
+	}
 	c, ok := s.cells[pos.WithoutSheet()]
 	if !ok {
 		c = &Cell{
@@ -441,6 +604,8 @@ func (s *Sheet) SetFormula(pos layout.Position, expr value.Formula) error {
 	c.raw = expr.String()
 	c.parsed = value.Empty()
 	c.dirty = true
+	c.anchor = nil
+	c.fixed = false
 
 	s.insertOrReplaceCell(c)
 	return nil
@@ -472,36 +637,252 @@ func (s *Sheet) ClearFormula(pos layout.Position) error {
 	return nil
 }
 
+// InsertRows adds count empty rows after the row at offset. The cells below are
+// moved down and the formulas of the workbook referencing them are updated.
 func (s *Sheet) InsertRows(offset, count int64) error {
+	if s.spilled != nil {
+		return grid.ErrWritable
+	}
+	if s.Protected.RowsLocked() {
+		return grid.ErrLock
+	}
+	if offset < 0 || count <= 0 {
+		return grid.ErrPosition
+	}
+	for _, r := range s.rows {
+		if r.Line <= offset {
+			continue
+		}
+		r.Line += count
+		for _, c := range r.Cells {
+			c.Line += count
+		}
+	}
+	s.reindex()
+	s.relocate(insertRows(offset, count))
 	return nil
 }
 
+// DeleteRows removes count rows starting at the row from. The cells below are
+// moved up. References to the removed cells become #REF! errors and ranges
+// overlapping them are shrunk.
+func (s *Sheet) DeleteRows(from, count int64) error {
+	if s.spilled != nil {
+		return grid.ErrWritable
+	}
+	if s.Protected.RowsLocked() {
+		return grid.ErrLock
+	}
+	if from <= 0 || count <= 0 {
+		return grid.ErrPosition
+	}
+	last := from + count - 1
+	s.rows = slices.DeleteFunc(s.rows, func(r *row) bool {
+		return r.Line >= from && r.Line <= last
+	})
+	for _, r := range s.rows {
+		if r.Line < from {
+			continue
+		}
+		r.Line -= count
+		for _, c := range r.Cells {
+			c.Line -= count
+		}
+	}
+	s.reindex()
+	s.relocate(deleteRows(from, last))
+	return nil
+}
+
+// RemoveRows is the same as DeleteRows.
+func (s *Sheet) RemoveRows(offset, count int64) error {
+	return s.DeleteRows(offset, count)
+}
+
+// DeleteRange removes the cells of the given range. The cells below it are
+// moved up in the columns of the range. Formulas are updated like with
+// DeleteRows for the references contained in these columns.
+func (s *Sheet) DeleteRange(rg *layout.Range) error {
+	if err := grid.CheckName(rg.Starts, s); err != nil {
+		return err
+	}
+	if s.spilled != nil {
+		return grid.ErrWritable
+	}
+	if s.Protected.Locked() {
+		return grid.ErrLock
+	}
+	rg = rg.Normalize()
+	if rg.Open() {
+		return grid.ErrPosition
+	}
+	var (
+		height = rg.Height()
+		moved  []*Cell
+	)
+	for _, r := range s.rows {
+		r.Cells = slices.DeleteFunc(r.Cells, func(c *Cell) bool {
+			if c.Column < rg.Starts.Column || c.Column > rg.Ends.Column || c.Line < rg.Starts.Line {
+				return false
+			}
+			if c.Line > rg.Ends.Line {
+				c.Line -= height
+				moved = append(moved, c)
+			}
+			return true
+		})
+	}
+	for _, c := range moved {
+		s.insertOrReplaceCell(c)
+	}
+	s.reindex()
+
+	shift := deleteRows(rg.Starts.Line, rg.Ends.Line)
+	s.relocate(func(start, end layout.Position) (layout.Position, layout.Position, bool) {
+		if start.Column < rg.Starts.Column || end.Column > rg.Ends.Column {
+			return start, end, true
+		}
+		return shift(start, end)
+	})
+	return nil
+}
+
+// InsertColumns adds count empty columns after the column at offset. The cells
+// on the right are moved and the formulas of the workbook referencing them are
+// updated. The new columns have the width of the columns around them.
 func (s *Sheet) InsertColumns(offset, count int64) error {
+	if s.spilled != nil {
+		return grid.ErrWritable
+	}
+	if s.Protected.ColumnsLocked() {
+		return grid.ErrLock
+	}
+	if offset < 0 || count <= 0 {
+		return grid.ErrPosition
+	}
+	for _, r := range s.rows {
+		for _, c := range r.Cells {
+			if c.Column > offset {
+				c.Column += count
+			}
+		}
+	}
+	for _, c := range s.Columns {
+		c.Min, c.Max = expand(c.Min, c.Max, offset, count)
+	}
+	s.reindex()
+	s.relocate(insertColumns(offset, count))
+	return nil
+}
+
+// DeleteColumns removes count columns starting at the column from. The cells
+// on the right are moved to the left. References to the removed cells become
+// #REF! errors and ranges overlapping them are shrunk.
+func (s *Sheet) DeleteColumns(from, count int64) error {
+	if s.spilled != nil {
+		return grid.ErrWritable
+	}
+	if s.Protected.ColumnsLocked() {
+		return grid.ErrLock
+	}
+	if from <= 0 || count <= 0 {
+		return grid.ErrPosition
+	}
+	last := from + count - 1
+	for _, r := range s.rows {
+		r.Cells = slices.DeleteFunc(r.Cells, func(c *Cell) bool {
+			return c.Column >= from && c.Column <= last
+		})
+		for _, c := range r.Cells {
+			if c.Column > last {
+				c.Column -= count
+			}
+		}
+	}
+	s.Columns = slices.DeleteFunc(s.Columns, func(c *Column) bool {
+		c.Min, c.Max = shrink(c.Min, c.Max, from, last)
+		return c.Min > c.Max
+	})
+	s.reindex()
+	s.relocate(deleteColumns(from, last))
 	return nil
 }
 
+// RemoveColumns is the same as DeleteColumns.
+func (s *Sheet) RemoveColumns(offset, count int64) error {
+	return s.DeleteColumns(offset, count)
+}
+
+// spillFrom stores the cells of the given view into a temporary file and drops
+// the cells kept in memory. The sheet becomes read only once spilled.
+func (s *Sheet) spillFrom(view grid.View, dir string) error {
+	sv, err := grid.Spill(view, dir)
+	if err != nil {
+		return err
+	}
+	bd := sv.Bounds()
+	s.Size = layout.Dimension{
+		Lines:   bd.Ends.Line,
+		Columns: bd.Ends.Column,
+	}
+	s.rows = nil
+	s.cells = make(map[layout.Position]*Cell)
+	s.spilled = sv
+	return nil
+}
+
+func (s *Sheet) cellCount() int64 {
+	if s.spilled != nil {
+		return 0
+	}
+	return int64(len(s.cells))
+}
+
 func (s *Sheet) insertOrReplaceCell(cell *Cell) {
 	s.cells[cell.At().WithoutSheet()] = cell
 
-	ix := slices.IndexFunc(s.rows, func(r *row) bool {
-		return r.Line == cell.Line
-	})
-	if ix < 0 {
+	ix, ok := s.searchRow(cell.Line)
+	if !ok {
 		r := row{
 			Line: cell.Line,
 		}
-		r.Append(cell)
-		s.rows = append(s.rows, &r)
-		slices.SortFunc(s.rows, func(r1, r2 *row) int {
-			return int(r1.Line) - int(r2.Line)
-		})
-	} else {
-		s.rows[ix].AppendOrReplace(cell)
+		s.rows = slices.Insert(s.rows, ix, &r)
 	}
+	s.rows[ix].AppendOrReplace(cell)
 	cell.Position = cell.WithSheet(s.Label)
 	s.updateSize(cell)
 }
 
+// removeCell drops the cell at the given position. Rows are only kept while
+// they have cells or are hidden.
+func (s *Sheet) removeCell(pos layout.Position) {
+	pos = pos.WithoutSheet()
+	if _, ok := s.cells[pos]; !ok {
+		return
+	}
+	delete(s.cells, pos)
+	ix, ok := s.searchRow(pos.Line)
+	if !ok {
+		return
+	}
+	r := s.rows[ix]
+	r.remove(pos.Column)
+	if r.Len() == 0 && !r.Hidden {
+		s.rows = slices.Delete(s.rows, ix, ix+1)
+	}
+}
+
+// searchRow gives the index of the row with the given line or the index where
+// it should be inserted.
+func (s *Sheet) searchRow(line int64) (int, bool) {
+	if n := len(s.rows); n == 0 || s.rows[n-1].Line < line {
+		return n, false
+	}
+	return slices.BinarySearchFunc(s.rows, line, func(r *row, line int64) int {
+		return cmp.Compare(r.Line, line)
+	})
+}
+
 func (s *Sheet) updateSize(cell *Cell) {
 	s.Size.Columns = max(s.Size.Columns, cell.Column)
 	s.Size.Lines = max(s.Size.Lines, cell.Line)
@@ -512,52 +893,97 @@ func (s *Sheet) put(cell grid.Cell, mode grid.CopyMode) {
 		pos = cell.At()
 		val = cell.Value()
 	)
+	if isEmptyCell(cell, mode) {
+		// empty cells are not stored so that sparse sheets stay small
+		s.removeCell(pos)
+		return
+	}
 	c := &Cell{
 		id:       id.Next(),
-		Type:     typeFromValue(val),
 		Position: pos,
 	}
 	if mode.Value() {
-		c.raw = val.String()
+		c.Type = typeFromValue(val)
+		c.raw = rawFromValue(val)
 		c.parsed = val
+		if raw, ok := grid.RawNumber(cell); ok {
+			c.raw = raw
+		}
+		if x, ok := cell.(*Cell); ok {
+			c.runs = x.runs.clone()
+		}
 	}
 	if f := cell.Formula(); f != nil && mode.Formula() {
 		c.formula = f
 		c.Type = TypeFormula
 	}
+	if x, ok := cell.(*Cell); ok && mode.Style() {
+		c.style = x.style
+	}
 	s.insertOrReplaceCell(c)
 }
 
-func (s *Sheet) resetSharedIndex(ix map[int]int) {
-	for _, r := range s.rows {
-		for _, c := range r.Cells {
-			if c.Type != TypeSharedStr {
-				continue
-			}
-			x, _ := strconv.Atoi(c.raw)
-			if n, ok := ix[x]; ok {
-				c.raw = strconv.Itoa(n)
-			}
-		}
+// isEmptyCell reports whether nothing would be copied from the cell with the
+// given mode.
+func isEmptyCell(cell grid.Cell, mode grid.CopyMode) bool {
+	if mode.Formula() && cell.Formula() != nil {
+		return false
+	}
+	if x, ok := cell.(*Cell); ok && mode.Style() && x.style != 0 {
+		return false
 	}
+	return !mode.Value() || value.IsBlank(cell.Value())
 }
 
 type File struct {
 	locked   bool
+	password *Password
 	date1904 bool
+	budget   *grid.Budget
+	iterate  grid.Iteration
+	frozen   bool
 
 	names         *grid.NameIndex
+	definedNames  map[string]*layout.Range
 	sheets        []*Sheet
 	sharedStrings []string
+	sharedRuns    map[int]RichText
+	pivotCaches   []*pivotCache
+	theme         *Theme
+	styles        *styleSheet
+	vba           []byte
+	codeName      string
+
+	// level of compression of the parts of the archive
+	compression int
 }
 
 func NewFile() *File {
 	file := &File{
-		names: grid.NewNameIndex(),
+		names:       grid.NewNameIndex(),
+		compression: flate.BestCompression,
 	}
 	return file
 }
 
+// SetCompression changes the level of compression used when the workbook is
+// written, from flate.BestSpeed to flate.BestCompression. With
+// flate.NoCompression, the parts of the archive are stored as they are.
+func (f *File) SetCompression(level int) error {
+	if err := checkCompression(level); err != nil {
+		return err
+	}
+	f.compression = level
+	return nil
+}
+
+func checkCompression(level int) error {
+	if level != flate.DefaultCompression && (level < flate.NoCompression || level > flate.BestCompression) {
+		return fmt.Errorf("%d: invalid compression level", level)
+	}
+	return nil
+}
+
 func Open(file string) (*File, error) {
 	rs, err := readFile(file)
 	if err != nil {
@@ -572,6 +998,9 @@ func Open(file string) (*File, error) {
 }
 
 func (f *File) WriteFile(file string) error {
+	if f.HasMacros() && strings.EqualFold(filepath.Ext(file), ".xlsx") {
+		return fmt.Errorf("%w: workbook with macros should be saved in a .xlsm file", grid.ErrFile)
+	}
 	w, err := os.Create(file)
 	if err == nil {
 		defer w.Close()
@@ -581,7 +1010,7 @@ func (f *File) WriteFile(file string) error {
 }
 
 func (f *File) WriteTo(w io.Writer) error {
-	ws, err := writeFile(w)
+	ws, err := writeFile(w, f.compression)
 	if err != nil {
 		return err
 	}
@@ -590,11 +1019,14 @@ func (f *File) WriteTo(w io.Writer) error {
 }
 
 func (f *File) Infos() []grid.ViewInfo {
-	var infos []grid.ViewInfo
+	var (
+		infos     []grid.ViewInfo
+		active, _ = f.activeSheet()
+	)
 	for _, s := range f.sheets {
 		i := grid.ViewInfo{
 			Name:      s.Name(),
-			Active:    s.Active,
+			Active:    s == active,
 			Protected: s.IsLock(),
 			Hidden:    s.State != StateVisible,
 			Size:      s.Size,
@@ -605,20 +1037,72 @@ func (f *File) Infos() []grid.ViewInfo {
 	return infos
 }
 
+// Sync evaluates the formulas of the workbook using cells that changed since
+// the last evaluation.
 func (f *File) Sync() error {
-	ctx := grid.FileContext(f)
+	return grid.RecalcIterative(f, f.iterate)
+}
+
+// SetIteration enables the iterative calculation of the formulas involved in
+// circular references. A zero count disables it.
+func (f *File) SetIteration(it grid.Iteration) {
+	f.iterate = it
+}
+
+// Iteration gives the settings of the iterative calculation of the workbook.
+func (f *File) Iteration() grid.Iteration {
+	return f.iterate
+}
+
+// SetFreeze makes the volatile formulas, like NOW or RAND, keep their value
+// once evaluated. Files written after a recalculation are then the same.
+func (f *File) SetFreeze(freeze bool) {
+	f.frozen = freeze
+}
+
+// Frozen reports whether the volatile formulas of the workbook keep their
+// value once evaluated.
+func (f *File) Frozen() bool {
+	return f.frozen
+}
+
+// Reload recalculates all the formulas of the workbook. Volatile formulas
+// having a value are kept when they are frozen.
+func (f *File) Reload() error {
 	for _, s := range f.sheets {
-		if err := s.Sync(ctx); err != nil {
-			return err
+		for _, c := range s.cells {
+			if c.formula == nil {
+				continue
+			}
+			if f.frozen && !value.IsBlank(c.Value()) && grid.Volatile(c.formula) {
+				continue
+			}
+			c.dirty = true
 		}
 	}
-	return nil
+	return f.Sync()
 }
 
 func (f *File) ActiveSheet() (grid.View, error) {
 	return f.activeSheet()
 }
 
+// SetActiveSheet makes the sheet with the given name the one selected when the
+// workbook is opened. Hidden sheets can not be active.
+func (f *File) SetActiveSheet(name string) error {
+	sh, err := f.sheetByName(name)
+	if err != nil {
+		return err
+	}
+	if sh.State != StateVisible {
+		return fmt.Errorf("%s: hidden sheet can not be active", name)
+	}
+	for i := range f.sheets {
+		f.sheets[i].Active = f.sheets[i] == sh
+	}
+	return nil
+}
+
 func (f *File) Sheet(name string) (grid.View, error) {
 	return f.sheetByName(name)
 }
@@ -655,18 +1139,88 @@ func (f *File) LockSheet(name string) error {
 }
 
 func (f *File) Unlock() {
-	f.locked = false
+	f.UnlockStructure()
 	for i := range f.sheets {
 		f.sheets[i].Unlock()
 	}
 }
 
 func (f *File) UnlockSheet(name string) error {
+	return f.UnlockSheetWithPassword(name, "")
+}
+
+func (f *File) LockWithPassword(password string) error {
+	for i := range f.sheets {
+		if err := f.sheets[i].LockWithPassword(password); err != nil {
+			return err
+		}
+	}
+	return f.LockStructure(password)
+}
+
+func (f *File) LockSheetWithPassword(name, password string) error {
 	sh, err := f.sheetByName(name)
-	if err == nil {
-		sh.Unlock()
+	if err != nil {
+		return err
 	}
-	return err
+	return sh.LockWithPassword(password)
+}
+
+func (f *File) UnlockWithPassword(password string) error {
+	if err := f.UnlockStructureWithPassword(password); err != nil {
+		return err
+	}
+	for i := range f.sheets {
+		if err := f.sheets[i].UnlockWithPassword(password); err != nil {
+			return fmt.Errorf("%s: %w", f.sheets[i].Name(), err)
+		}
+	}
+	return nil
+}
+
+func (f *File) UnlockSheetWithPassword(name, password string) error {
+	sh, err := f.sheetByName(name)
+	if err != nil {
+		return err
+	}
+	return sh.UnlockWithPassword(password)
+}
+
+// LockStructure prevents sheets from being added, removed, renamed or moved
+// without locking the content of the sheets. The password is optional.
+func (f *File) LockStructure(password string) error {
+	if password != "" {
+		pwd, err := createPassword(password)
+		if err != nil {
+			return err
+		}
+		f.password = pwd
+	}
+	f.locked = true
+	return nil
+}
+
+func (f *File) UnlockStructure() {
+	f.locked = false
+	f.password = nil
+}
+
+// UnlockStructureWithPassword unlocks the structure of the workbook if the
+// password matches the one protecting it.
+func (f *File) UnlockStructureWithPassword(password string) error {
+	if f.password != nil {
+		if err := f.password.Verify(password); err != nil {
+			return err
+		}
+	}
+	f.UnlockStructure()
+	return nil
+}
+
+// HasPassword tells whether the structure of the workbook is protected by a
+// password.
+func (f *File) HasPassword() bool {
+	return f.password != nil
 }
 
 // rename a sheet
@@ -678,15 +1232,25 @@ func (f *File) Rename(oldName, newName string) error {
 	if err != nil {
 		return err
 	}
-	if err := f.RemoveSheet(oldName); err != nil {
+	if err := ValidateName(newName); err != nil {
 		return err
 	}
-	sh.Label = cleanName(newName)
-	return f.AppendSheet(sh)
+	if other, err := f.sheetByName(newName); err == nil && other != sh {
+		return fmt.Errorf("%s: sheet already exists", newName)
+	}
+	f.names.Delete(sh.Label)
+	sh.Label = f.names.Next(newName)
+	return nil
 }
 
 // copy a sheet
 func (f *File) Copy(oldName, newName string) error {
+	return f.CopySheet(oldName, newName, grid.CopyAll)
+}
+
+// CopySheet copies the values, the formulas and/or the formats of a sheet,
+// as given by mode, into a new sheet.
+func (f *File) CopySheet(oldName, newName string, mode grid.CopyMode) error {
 	if f.locked {
 		return grid.ErrLock
 	}
@@ -697,13 +1261,22 @@ func (f *File) Copy(oldName, newName string) error {
 	if newName == "" {
 		newName = oldName
 	}
-	target, _ := source.Clone(grid.CopyAll)
+	target, err := source.Clone(mode)
+	if err != nil {
+		return err
+	}
 	if sh, ok := target.(*Sheet); ok {
 		sh.Label = newName
 	}
 	return f.AppendSheet(target)
 }
 
+// SetBudget caps the number of cells kept in memory. Sheets appended once
+// the budget is exhausted are spilled to disk.
+func (f *File) SetBudget(budget *grid.Budget) {
+	f.budget = budget
+}
+
 func (f *File) AppendSheet(sheet grid.View) error {
 	if f.locked {
 		return grid.ErrLock
@@ -712,9 +1285,28 @@ func (f *File) AppendSheet(sheet grid.View) error {
 	if !ok {
 		sh = NewSheet(sheet.Name())
 		sh.Label = cleanName(sheet.Name())
-		if err := sh.FillWith(sheet); err != nil {
+		var err error
+		if bd := sheet.Bounds(); f.budget.Reserve(bd.Width() * bd.Height()) {
+			err = sh.FillWith(sheet)
+		} else {
+			err = sh.spillFrom(sheet, f.budget.Dir)
+		}
+		if err != nil {
 			return err
 		}
+	} else {
+		if sh.owner != nil && sh.owner != f {
+			f.importSheet(sh)
+		}
+		if !f.budget.Reserve(sh.cellCount()) {
+			if err := sh.spillFrom(sh, f.budget.Dir); err != nil {
+				return err
+			}
+		}
+	}
+	sh.owner = f
+	if sh.Active && slices.ContainsFunc(f.sheets, func(s *Sheet) bool { return s.Active }) {
+		sh.Active = false
 	}
 	sh.Label = f.names.Next(sh.Label)
 	sh.Index = len(f.sheets) + 1
@@ -745,6 +1337,33 @@ func (f *File) RemoveSheet(name string) error {
 	return nil
 }
 
+// MoveSheet moves the sheet with the given name at index, the first sheet
+// being at index 0. A negative index counts from the last sheet.
+func (f *File) MoveSheet(name string, index int) error {
+	if f.locked {
+		return grid.ErrLock
+	}
+	ix := slices.IndexFunc(f.sheets, func(s *Sheet) bool {
+		return s.Name() == name
+	})
+	if ix < 0 {
+		return fmt.Errorf("sheet %s %w", name, grid.ErrFound)
+	}
+	if index < 0 {
+		index += len(f.sheets)
+	}
+	if index < 0 || index >= len(f.sheets) {
+		return fmt.Errorf("%d: sheet index %w", index, grid.ErrPosition)
+	}
+	sh := f.sheets[ix]
+	f.sheets = slices.Insert(slices.Delete(f.sheets, ix, ix+1), index, sh)
+	for i, s := range f.sheets {
+		s.Index = i + 1
+		s.Id = sheetId(s.Index)
+	}
+	return nil
+}
+
 // append sheets of given file to current fule
 func (f *File) Merge(other grid.File) error {
 	if f.locked {
@@ -760,20 +1379,11 @@ func (f *File) Merge(other grid.File) error {
 }
 
 func (f *File) mergeFile(other *File) error {
-	ix := make(map[int]int)
-	for i, s := range other.sharedStrings {
-		ok := slices.Contains(f.sharedStrings, s)
-		if ok {
-			continue
-		}
-		ix[i] = len(f.sharedStrings)
-		f.sharedStrings = append(f.sharedStrings, s)
-	}
 	for _, s := range other.sheets {
+		// shared strings and formats are imported by AppendSheet
 		if err := f.AppendSheet(s); err != nil {
 			return err
 		}
-		s.resetSharedIndex(ix)
 	}
 	return nil
 }
@@ -788,18 +1398,28 @@ func (f *File) mergeSheetsFromFile(other grid.File) error {
 }
 
 func (f *File) activeSheet() (*Sheet, error) {
-	if len(f.sheets) == 1 {
-		return f.sheets[0], nil
+	if len(f.sheets) == 0 {
+		return nil, fmt.Errorf("missing active sheet")
 	}
 	ix := slices.IndexFunc(f.sheets, func(s *Sheet) bool {
 		return s.Active
 	})
 	if ix < 0 {
-		return nil, fmt.Errorf("missing active sheet")
+		// Excel opens the first sheet when none is selected
+		ix = 0
 	}
 	return f.sheets[ix], nil
 }
 
+// selectActive makes sure that only one sheet is selected when the workbook is
+// opened.
+func (f *File) selectActive() {
+	active, _ := f.activeSheet()
+	for i := range f.sheets {
+		f.sheets[i].Active = f.sheets[i] == active
+	}
+}
+
 func (f *File) sheetByName(name string) (*Sheet, error) {
 	ix := slices.IndexFunc(f.sheets, func(s *Sheet) bool {
 		return s.Name() == name
@@ -810,14 +1430,49 @@ func (f *File) sheetByName(name string) (*Sheet, error) {
 	return f.sheets[ix], nil
 }
 
-const maxSheetNameLen = 31
+const (
+	maxSheetNameLen  = 31
+	defaultSheetName = "Sheet"
 
+	// characters not allowed in the names of sheets
+	invalidSheetChars = `:\/?*[]`
+)
+
+// ValidateName checks that name can be used as the name of a sheet. Names have
+// at most 31 characters, can not contain any of : \ / ? * [ ] and can not
+// start or end with an apostrophe.
+func ValidateName(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return fmt.Errorf("sheet name should not be empty")
+	case utf8.RuneCountInString(name) > maxSheetNameLen:
+		return fmt.Errorf("%s: sheet name should have at most %d characters", name, maxSheetNameLen)
+	case strings.ContainsAny(name, invalidSheetChars):
+		return fmt.Errorf("%s: sheet name should not contain any of %s", name, invalidSheetChars)
+	case strings.HasPrefix(name, "'") || strings.HasSuffix(name, "'"):
+		return fmt.Errorf("%s: sheet name should not start or end with an apostrophe", name)
+	default:
+		return nil
+	}
+}
+
+// cleanName replaces the characters not allowed in the names of sheets and
+// truncates name if it is too long.
 func cleanName(str string) string {
-	ret := grid.CleanName(str)
-	if len(ret) > maxSheetNameLen {
-		ret = ret[:maxSheetNameLen]
+	str = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(invalidSheetChars, r) || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, str)
+	str = strings.TrimSpace(strings.Trim(str, "'"))
+	if runes := []rune(str); len(runes) > maxSheetNameLen {
+		str = strings.TrimSpace(string(runes[:maxSheetNameLen]))
+	}
+	if str == "" {
+		str = defaultSheetName
 	}
-	return ret
+	return str
 }
 
 func sheetId(id int) string {
@@ -0,0 +1,260 @@
+package oxml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	sax "github.com/midbel/codecs/xml"
+	"github.com/midbel/dockit/layout"
+)
+
+const printAreaName = "_xlnm.Print_Area"
+
+type Orientation string
+
+const (
+	OrientationDefault   Orientation = ""
+	OrientationPortrait  Orientation = "portrait"
+	OrientationLandscape Orientation = "landscape"
+)
+
+func ParseOrientation(str string) (Orientation, error) {
+	switch o := Orientation(strings.ToLower(str)); o {
+	case OrientationDefault, OrientationPortrait, OrientationLandscape:
+		return o, nil
+	default:
+		return "", fmt.Errorf("%s: invalid orientation", str)
+	}
+}
+
+var paperSizes = map[string]int{
+	"letter":    1,
+	"tabloid":   3,
+	"legal":     5,
+	"executive": 7,
+	"a3":        8,
+	"a4":        9,
+	"a5":        11,
+	"b4":        12,
+	"b5":        13,
+}
+
+// ParsePaperSize gives the code of a paper size from its name (a4, letter,...)
+// or from its code.
+func ParsePaperSize(str string) (int, error) {
+	if n, ok := paperSizes[strings.ToLower(str)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(str)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s: invalid paper size", str)
+	}
+	return n, nil
+}
+
+// PageMargins holds the margins of the printed pages in inches.
+type PageMargins struct {
+	Left   float64
+	Right  float64
+	Top    float64
+	Bottom float64
+	Header float64
+	Footer float64
+}
+
+// DefaultMargins gives the margins used by Excel for a new sheet.
+func DefaultMargins() PageMargins {
+	return PageMargins{
+		Left:   0.7,
+		Right:  0.7,
+		Top:    0.75,
+		Bottom: 0.75,
+		Header: 0.3,
+		Footer: 0.3,
+	}
+}
+
+// PageSetup holds the settings used when a sheet is printed. Its zero value
+// gives the default settings of the printer. PaperSize is given as the code
+// used by ECMA-376 (1 for letter, 9 for A4,...).
+type PageSetup struct {
+	PaperSize   int
+	Orientation Orientation
+	Scale       int
+	Margins     *PageMargins
+	PrintArea   *layout.Range
+	Headers     HeaderFooter
+}
+
+func (p PageSetup) isDefault() bool {
+	return p.PaperSize == 0 && p.Orientation == OrientationDefault && p.Scale == 0
+}
+
+func (s *Sheet) SetPaperSize(size int) error {
+	if size < 0 {
+		return fmt.Errorf("%d: invalid paper size", size)
+	}
+	s.Page.PaperSize = size
+	return nil
+}
+
+func (s *Sheet) SetOrientation(orient Orientation) {
+	s.Page.Orientation = orient
+}
+
+// SetPrintScale changes the scale of the printed pages. The scale is given in
+// percent.
+func (s *Sheet) SetPrintScale(scale int) error {
+	if scale < minZoom || scale > maxZoom {
+		return fmt.Errorf("scale should be between %d and %d", minZoom, maxZoom)
+	}
+	if scale == 100 {
+		scale = 0
+	}
+	s.Page.Scale = scale
+	return nil
+}
+
+func (s *Sheet) SetMargins(margins PageMargins) error {
+	for _, m := range []float64{margins.Left, margins.Right, margins.Top, margins.Bottom, margins.Header, margins.Footer} {
+		if m < 0 {
+			return fmt.Errorf("margins should be positive")
+		}
+	}
+	s.Page.Margins = &margins
+	return nil
+}
+
+// SetPrintArea limits the printed cells to the given range. A nil range
+// prints the whole sheet.
+func (s *Sheet) SetPrintArea(rg *layout.Range) error {
+	if rg == nil {
+		s.Page.PrintArea = nil
+		return nil
+	}
+	rg = rg.Normalize()
+	if rg.Open() {
+		return fmt.Errorf("%s: invalid print area", rg)
+	}
+	rg.Starts = rg.Starts.WithoutSheet()
+	rg.Ends = rg.Ends.WithoutSheet()
+	s.Page.PrintArea = rg
+	return nil
+}
+
+func (r *sheetReader) onPageMargins(_ *sax.Reader, el sax.E) error {
+	var m PageMargins
+	for _, x := range []struct {
+		Name  string
+		Value *float64
+	}{
+		{Name: "left", Value: &m.Left},
+		{Name: "right", Value: &m.Right},
+		{Name: "top", Value: &m.Top},
+		{Name: "bottom", Value: &m.Bottom},
+		{Name: "header", Value: &m.Header},
+		{Name: "footer", Value: &m.Footer},
+	} {
+		*x.Value, _ = strconv.ParseFloat(el.GetAttributeValue(x.Name), 64)
+	}
+	r.sheet.Page.Margins = &m
+	return nil
+}
+
+func (r *sheetReader) onPageSetup(_ *sax.Reader, el sax.E) error {
+	page := &r.sheet.Page
+	page.PaperSize, _ = strconv.Atoi(el.GetAttributeValue("paperSize"))
+	page.Orientation, _ = ParseOrientation(el.GetAttributeValue("orientation"))
+	if z, err := strconv.Atoi(el.GetAttributeValue("scale")); err == nil && z != 100 {
+		page.Scale = z
+	}
+	return nil
+}
+
+func (w *sheetWriter) writePageSetup(sheet *Sheet) {
+	page := sheet.Page
+	if m := page.Margins; m != nil {
+		w.writer.Empty(sax.LocalName("pageMargins"), []sax.A{
+			createAttr("left", formatInches(m.Left)),
+			createAttr("right", formatInches(m.Right)),
+			createAttr("top", formatInches(m.Top)),
+			createAttr("bottom", formatInches(m.Bottom)),
+			createAttr("header", formatInches(m.Header)),
+			createAttr("footer", formatInches(m.Footer)),
+		})
+	}
+	if !page.isDefault() {
+		w.writeSetup(page)
+	}
+	w.writeHeaderFooter(sheet)
+}
+
+func (w *sheetWriter) writeSetup(page PageSetup) {
+	var attrs []sax.A
+	if page.PaperSize > 0 {
+		attrs = append(attrs, createAttr("paperSize", strconv.Itoa(page.PaperSize)))
+	}
+	if page.Scale > 0 {
+		attrs = append(attrs, createAttr("scale", strconv.Itoa(page.Scale)))
+	}
+	if page.Orientation != OrientationDefault {
+		attrs = append(attrs, createAttr("orientation", string(page.Orientation)))
+	}
+	w.writer.Empty(sax.LocalName("pageSetup"), attrs)
+}
+
+func formatInches(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+type xmlDefinedName struct {
+	XMLName xml.Name `xml:"definedName"`
+	Name    string   `xml:"name,attr"`
+	Sheet   *int     `xml:"localSheetId,attr"`
+	Value   string   `xml:",chardata"`
+}
+
+// printArea gives the defined name used to store the print area of the sheet
+// at the given index of the workbook.
+func printArea(sheet *Sheet, index int) *xmlDefinedName {
+	if sheet.Page.PrintArea == nil {
+		return nil
+	}
+	var (
+		rg   = sheet.Page.PrintArea
+		name = strings.ReplaceAll(sheet.Name(), "'", "''")
+	)
+	return &xmlDefinedName{
+		Name:  printAreaName,
+		Sheet: &index,
+		Value: fmt.Sprintf("'%s'!%s:%s", name, absoluteAddr(rg.Starts), absoluteAddr(rg.Ends)),
+	}
+}
+
+func absoluteAddr(pos layout.Position) string {
+	addr := pos.WithoutSheet().Addr()
+	ix := strings.IndexFunc(addr, func(r rune) bool {
+		return r >= '0' && r <= '9'
+	})
+	return "$" + addr[:ix] + "$" + addr[ix:]
+}
+
+// parsePrintArea gives the first range of the value of a print area. The name
+// of the sheet and the markers of absolute references are removed.
+func parsePrintArea(str string) *layout.Range {
+	str, _, _ = strings.Cut(str, ",")
+	if ix := strings.LastIndex(str, "!"); ix >= 0 {
+		str = str[ix+1:]
+	}
+	str = strings.ReplaceAll(str, "$", "")
+	if str == "" {
+		return nil
+	}
+	list := parseRef(str)
+	if len(list) == 0 || list[0].Open() {
+		return nil
+	}
+	return list[0]
+}
@@ -0,0 +1,163 @@
+package oxml
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	sax "github.com/midbel/codecs/xml"
+	"github.com/midbel/dockit/grid"
+)
+
+const (
+	defaultHashAlgorithm = "SHA-512"
+	defaultSpinCount     = 100000
+	saltSize             = 16
+)
+
+// Password holds the hashes of the password protecting a sheet or the
+// structure of a workbook. Legacy is the 16 bits hash written by older
+// versions of Excel as hexadecimal string.
+type Password struct {
+	Algorithm string
+	Hash      string
+	Salt      string
+	SpinCount int
+	Legacy    string
+}
+
+func createPassword(password string) (*Password, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	pwd := Password{
+		Algorithm: defaultHashAlgorithm,
+		Salt:      base64.StdEncoding.EncodeToString(salt),
+		SpinCount: defaultSpinCount,
+		Legacy:    legacyHash(password),
+	}
+	sum, err := hashPassword(password, salt, pwd.Algorithm, pwd.SpinCount)
+	if err != nil {
+		return nil, err
+	}
+	pwd.Hash = base64.StdEncoding.EncodeToString(sum)
+	return &pwd, nil
+}
+
+// Verify checks the given password against the hashes. The legacy hash is only
+// used when the password has no other hash.
+func (p *Password) Verify(password string) error {
+	if p.Hash == "" {
+		if p.Legacy == "" || strings.EqualFold(p.Legacy, legacyHash(password)) {
+			return nil
+		}
+		return grid.ErrPassword
+	}
+	salt, err := base64.StdEncoding.DecodeString(p.Salt)
+	if err != nil {
+		return fmt.Errorf("invalid salt: %w", err)
+	}
+	want, err := base64.StdEncoding.DecodeString(p.Hash)
+	if err != nil {
+		return fmt.Errorf("invalid hash: %w", err)
+	}
+	got, err := hashPassword(password, salt, p.Algorithm, p.SpinCount)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		return grid.ErrPassword
+	}
+	return nil
+}
+
+func (p *Password) attrs() []sax.A {
+	var attrs []sax.A
+	if p.Legacy != "" {
+		attrs = append(attrs, createAttr("password", p.Legacy))
+	}
+	if p.Hash != "" {
+		attrs = append(attrs, createAttr("algorithmName", p.Algorithm))
+		attrs = append(attrs, createAttr("hashValue", p.Hash))
+		attrs = append(attrs, createAttr("saltValue", p.Salt))
+		attrs = append(attrs, createAttr("spinCount", strconv.Itoa(p.SpinCount)))
+	}
+	return attrs
+}
+
+// readPassword gives the hashes found in the attributes of a protection
+// element. It gives nil if the element has none.
+func readPassword(el sax.E) *Password {
+	pwd := Password{
+		Algorithm: el.GetAttributeValue("algorithmName"),
+		Hash:      el.GetAttributeValue("hashValue"),
+		Salt:      el.GetAttributeValue("saltValue"),
+		Legacy:    el.GetAttributeValue("password"),
+	}
+	pwd.SpinCount, _ = strconv.Atoi(el.GetAttributeValue("spinCount"))
+	if pwd.Hash == "" && pwd.Legacy == "" {
+		return nil
+	}
+	return &pwd
+}
+
+// hashPassword computes the hash of a password as described by ECMA-376: the
+// salt followed by the UTF-16LE password is hashed first, then the hash
+// followed by the iteration number is hashed spin times.
+func hashPassword(password string, salt []byte, algorithm string, spin int) ([]byte, error) {
+	var h hash.Hash
+	switch strings.ToUpper(algorithm) {
+	case "SHA-1":
+		h = sha1.New()
+	case "SHA-256":
+		h = sha256.New()
+	case "SHA-384":
+		h = sha512.New384()
+	case "SHA-512":
+		h = sha512.New()
+	default:
+		return nil, fmt.Errorf("%s: unsupported hash algorithm", algorithm)
+	}
+	h.Write(salt)
+	for _, c := range utf16.Encode([]rune(password)) {
+		h.Write([]byte{byte(c), byte(c >> 8)})
+	}
+	sum := h.Sum(nil)
+
+	iter := make([]byte, 4)
+	for i := range spin {
+		binary.LittleEndian.PutUint32(iter, uint32(i))
+		h.Reset()
+		h.Write(sum)
+		h.Write(iter)
+		sum = h.Sum(sum[:0])
+	}
+	return sum, nil
+}
+
+// legacyHash computes the 16 bits hash of a password used by the password
+// attribute of the protection elements.
+func legacyHash(password string) string {
+	var (
+		str = []byte(password)
+		sum uint16
+	)
+	rotate := func(v uint16) uint16 {
+		return ((v >> 14) & 0x01) | ((v << 1) & 0x7FFF)
+	}
+	for i := len(str) - 1; i >= 0; i-- {
+		sum = rotate(sum) ^ uint16(str[i])
+	}
+	sum = rotate(sum) ^ uint16(len(str)) ^ 0xCE4B
+	return fmt.Sprintf("%04X", sum)
+}
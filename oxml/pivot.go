@@ -0,0 +1,348 @@
+package oxml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path"
+	"slices"
+
+	"github.com/midbel/dockit/layout"
+)
+
+const (
+	typePivotTableUrl   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/pivotTable"
+	typePivotCacheUrl   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/pivotCacheDefinition"
+	typePivotRecordsUrl = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/pivotCacheRecords"
+)
+
+const (
+	mimePivotTable   = "application/vnd.openxmlformats-officedocument.spreadsheetml.pivotTable+xml"
+	mimePivotCache   = "application/vnd.openxmlformats-officedocument.spreadsheetml.pivotCacheDefinition+xml"
+	mimePivotRecords = "application/vnd.openxmlformats-officedocument.spreadsheetml.pivotCacheRecords+xml"
+)
+
+// valuesField is the index used in rowFields and colFields for the pseudo field
+// holding the data fields.
+const valuesField = -2
+
+type PivotDataField struct {
+	Name     string
+	Field    string
+	Function string
+}
+
+// PivotTable describes a pivot table found in a file. Pivot tables can not be
+// modified: their definitions are written back as they were read.
+type PivotTable struct {
+	Name     string
+	Location *layout.Range
+	// Source is the range of the data summarized by the pivot table. It is nil
+	// when the data come from a named range or a table given by SourceName.
+	Source     *layout.Range
+	SourceName string
+	Rows       []string
+	Columns    []string
+	Data       []PivotDataField
+
+	cacheId int
+	raw     []byte
+}
+
+// pivotCache holds the parts shared by the pivot tables built on the same
+// data.
+type pivotCache struct {
+	id         int
+	relId      string
+	name       string
+	definition []byte
+	records    []byte
+	recordsRel string
+	fields     []string
+	source     *layout.Range
+	sourceName string
+}
+
+// PivotTables gives the pivot tables of all the sheets of the file.
+func (f *File) PivotTables() []*PivotTable {
+	var list []*PivotTable
+	for _, s := range f.sheets {
+		list = append(list, s.Pivots...)
+	}
+	return list
+}
+
+type xmlPivotCacheRef struct {
+	CacheId int    `xml:"cacheId,attr"`
+	Id      string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+}
+
+type xmlPivotTable struct {
+	XMLName  xml.Name `xml:"pivotTableDefinition"`
+	Name     string   `xml:"name,attr"`
+	CacheId  int      `xml:"cacheId,attr"`
+	Location struct {
+		Ref string `xml:"ref,attr"`
+	} `xml:"location"`
+	Rows []struct {
+		Index int `xml:"x,attr"`
+	} `xml:"rowFields>field"`
+	Columns []struct {
+		Index int `xml:"x,attr"`
+	} `xml:"colFields>field"`
+	Data []struct {
+		Name     string `xml:"name,attr"`
+		Field    int    `xml:"fld,attr"`
+		Function string `xml:"subtotal,attr"`
+	} `xml:"dataFields>dataField"`
+}
+
+type xmlPivotCache struct {
+	XMLName xml.Name `xml:"pivotCacheDefinition"`
+	Id      string   `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	Source  struct {
+		Ref   string `xml:"ref,attr"`
+		Name  string `xml:"name,attr"`
+		Sheet string `xml:"sheet,attr"`
+	} `xml:"cacheSource>worksheetSource"`
+	Fields []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"cacheFields>cacheField"`
+}
+
+func (p *pivotCache) field(ix int) string {
+	if ix < 0 || ix >= len(p.fields) {
+		return ""
+	}
+	return p.fields[ix]
+}
+
+func pivotFromXML(x xmlPivotTable, cache *pivotCache) *PivotTable {
+	pt := PivotTable{
+		Name:    x.Name,
+		cacheId: x.CacheId,
+	}
+	if list := parseRef(x.Location.Ref); len(list) > 0 {
+		pt.Location = list[0]
+	}
+	if cache == nil {
+		return &pt
+	}
+	pt.Source = cache.source
+	pt.SourceName = cache.sourceName
+	for _, f := range x.Rows {
+		if f.Index != valuesField {
+			pt.Rows = append(pt.Rows, cache.field(f.Index))
+		}
+	}
+	for _, f := range x.Columns {
+		if f.Index != valuesField {
+			pt.Columns = append(pt.Columns, cache.field(f.Index))
+		}
+	}
+	for _, f := range x.Data {
+		df := PivotDataField{
+			Name:     f.Name,
+			Field:    cache.field(f.Field),
+			Function: f.Function,
+		}
+		if df.Function == "" {
+			df.Function = "sum"
+		}
+		pt.Data = append(pt.Data, df)
+	}
+	return &pt
+}
+
+// readPivotCaches reads the caches of the pivot tables listed in the workbook.
+func (r *reader) readPivotCaches(file *File, addr string, caches []xmlPivotCacheRef) {
+	if r.invalid() || len(caches) == 0 {
+		return
+	}
+	for _, c := range caches {
+		target, err := r.resolveRelation(addr, c.Id)
+		if err != nil {
+			r.err = err
+			return
+		}
+		pc := pivotCache{
+			id: c.CacheId,
+		}
+		if pc.definition, err = r.readBytes(target); err != nil {
+			r.err = err
+			return
+		}
+		var x xmlPivotCache
+		if err := unmarshalPart(target, pc.definition, &x); err != nil {
+			r.err = err
+			return
+		}
+		for _, f := range x.Fields {
+			pc.fields = append(pc.fields, f.Name)
+		}
+		pc.sourceName = x.Source.Name
+		if list := parseRef(x.Source.Ref); len(list) > 0 {
+			pc.source = list[0]
+			pc.source.Starts.Sheet = x.Source.Sheet
+			pc.source.Ends.Sheet = x.Source.Sheet
+		}
+		if x.Id != "" {
+			records, err := r.resolveRelation(target, x.Id)
+			if err != nil {
+				r.err = err
+				return
+			}
+			if pc.records, err = r.readBytes(records); err != nil {
+				r.err = err
+				return
+			}
+			pc.recordsRel = x.Id
+		}
+		file.pivotCaches = append(file.pivotCaches, &pc)
+	}
+}
+
+// readPivotTables reads the pivot tables of a sheet. Pivot tables are only
+// referenced by the relations of the sheet.
+func (r *reader) readPivotTables(file *File, sheet *Sheet, addr string) {
+	if r.invalid() {
+		return
+	}
+	rels := path.Join(path.Dir(addr), "_rels", path.Base(addr)+".rels")
+	if !r.exists(rels) {
+		return
+	}
+	var root xmlRelations
+	if err := r.decodeXML(rels, &root); err != nil {
+		return
+	}
+	for _, rx := range root.Relations {
+		if rx.Type != typePivotTableUrl {
+			continue
+		}
+		target, err := r.resolveRelation(addr, rx.Id)
+		if err != nil {
+			r.err = err
+			return
+		}
+		raw, err := r.readBytes(target)
+		if err != nil {
+			r.err = err
+			return
+		}
+		var x xmlPivotTable
+		if err := unmarshalPart(target, raw, &x); err != nil {
+			r.err = err
+			return
+		}
+		ix := slices.IndexFunc(file.pivotCaches, func(c *pivotCache) bool {
+			return c.id == x.CacheId
+		})
+		var cache *pivotCache
+		if ix >= 0 {
+			cache = file.pivotCaches[ix]
+		}
+		pt := pivotFromXML(x, cache)
+		pt.raw = raw
+		sheet.Pivots = append(sheet.Pivots, pt)
+	}
+}
+
+// pivotRelId gives the id of the relation between a sheet and its pivot table.
+// Relations of the tables and of the drawing come first.
+func pivotRelId(sheet *Sheet, ix int) string {
+	if hasDrawing(sheet) {
+		ix++
+	}
+	return relId(len(sheet.Tables) + ix)
+}
+
+// writePivotCaches writes the caches of the pivot tables unchanged. The
+// relations between the workbook and the caches are created when the workbook
+// is written.
+func (z *writer) writePivotCaches(file *File) {
+	for i, c := range file.pivotCaches {
+		if z.invalid() {
+			return
+		}
+		c.name = fmt.Sprintf("pivotCacheDefinition%d.xml", i+1)
+		addr := z.createTarget("pivotCache", c.name)
+		z.writeRaw(addr, c.definition)
+		z.pivotCaches = append(z.pivotCaches, addr)
+		if len(c.records) == 0 {
+			continue
+		}
+		records := fmt.Sprintf("pivotCacheRecords%d.xml", i+1)
+		addr = z.createTarget("pivotCache", records)
+		z.writeRaw(addr, c.records)
+		z.pivotRecords = append(z.pivotRecords, addr)
+
+		root := xmlRelations{
+			Xmlns: "http://schemas.openxmlformats.org/package/2006/relationships",
+			Relations: []xmlRelation{
+				{
+					Id:     c.recordsRel,
+					Type:   typePivotRecordsUrl,
+					Target: records,
+				},
+			},
+		}
+		z.encodeXML(z.createTarget("pivotCache", "_rels", c.name+".rels"), &root)
+	}
+}
+
+// writePivotTables writes the pivot tables of the sheet unchanged and gives
+// the relations between the sheet and its pivot tables.
+func (z *writer) writePivotTables(file *File, sheet *Sheet) []xmlRelation {
+	if z.invalid() || len(sheet.Pivots) == 0 {
+		return nil
+	}
+	var list []xmlRelation
+	for i, pt := range sheet.Pivots {
+		ix := slices.IndexFunc(file.pivotCaches, func(c *pivotCache) bool {
+			return c.id == pt.cacheId
+		})
+		if ix < 0 {
+			z.err = fmt.Errorf("%s: cache %d of pivot table not found", pt.Name, pt.cacheId)
+			return nil
+		}
+		z.lastPivotId++
+		name := fmt.Sprintf("pivotTable%d.xml", z.lastPivotId)
+		addr := z.createTarget("pivotTables", name)
+		z.writeRaw(addr, pt.raw)
+		z.pivotTables = append(z.pivotTables, addr)
+
+		root := xmlRelations{
+			Xmlns: "http://schemas.openxmlformats.org/package/2006/relationships",
+			Relations: []xmlRelation{
+				{
+					Id:     relId(0),
+					Type:   typePivotCacheUrl,
+					Target: "../pivotCache/" + file.pivotCaches[ix].name,
+				},
+			},
+		}
+		z.encodeXML(z.createTarget("pivotTables", "_rels", name+".rels"), &root)
+
+		rx := xmlRelation{
+			Id:     pivotRelId(sheet, i),
+			Type:   typePivotTableUrl,
+			Target: "../pivotTables/" + name,
+		}
+		list = append(list, rx)
+	}
+	return list
+}
+
+func (z *writer) writeRaw(name string, data []byte) {
+	if z.invalid() {
+		return
+	}
+	w, err := z.create(name)
+	if err != nil {
+		z.err = err
+		return
+	}
+	if _, err := w.Write(data); err != nil {
+		z.err = err
+	}
+}
@@ -0,0 +1,88 @@
+package oxml
+
+import (
+	"strconv"
+)
+
+// stringPool collects the shared strings of a workbook while its sheets are
+// written. Plain strings are stored once whatever the number of cells using
+// them. Formatted strings are never merged.
+type stringPool struct {
+	values []string
+	runs   map[int]RichText
+	index  map[string]int
+	count  int
+
+	// shared strings of the workbook and their index in the pool
+	shared     []string
+	sharedRuns map[int]RichText
+	remap      map[int]int
+}
+
+func newStringPool(file *File) *stringPool {
+	p := stringPool{
+		runs:       make(map[int]RichText),
+		index:      make(map[string]int),
+		shared:     file.sharedStrings,
+		sharedRuns: file.sharedRuns,
+		remap:      make(map[int]int),
+	}
+	return &p
+}
+
+// add gives the index of the string in the pool.
+func (p *stringPool) add(str string, runs RichText) int {
+	p.count++
+	if len(runs) == 0 {
+		if ix, ok := p.index[str]; ok {
+			return ix
+		}
+		p.index[str] = len(p.values)
+	} else {
+		p.runs[len(p.values)] = runs
+	}
+	p.values = append(p.values, str)
+	return len(p.values) - 1
+}
+
+// reuse gives the index in the pool of the shared string of the workbook at
+// the given index.
+func (p *stringPool) reuse(index int) (int, bool) {
+	if index < 0 || index >= len(p.shared) {
+		return 0, false
+	}
+	if ix, ok := p.remap[index]; ok {
+		p.count++
+		return ix, true
+	}
+	ix := p.add(p.shared[index], p.sharedRuns[index])
+	p.remap[index] = ix
+	return ix, true
+}
+
+func (p *stringPool) empty() bool {
+	return p == nil || len(p.values) == 0
+}
+
+// writeSharedStrCell writes a cell with a string as a reference to the shared
+// strings of the workbook. Cells referring to unknown strings are written as
+// they are.
+func (w *sheetWriter) writeSharedStrCell(cell *Cell) error {
+	var ix int
+	if cell.Type == TypeSharedStr {
+		n, err := strconv.Atoi(cell.raw)
+		if err != nil {
+			return w.writeDefaultCell(cell)
+		}
+		var ok bool
+		if ix, ok = w.strings.reuse(n); !ok {
+			return w.writeDefaultCell(cell)
+		}
+	} else {
+		ix = w.strings.add(cell.raw, cell.runs)
+	}
+	c := *cell
+	c.Type = TypeSharedStr
+	c.raw = strconv.Itoa(ix)
+	return w.writeDefaultCell(&c)
+}
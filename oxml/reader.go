@@ -29,6 +29,10 @@ type reader struct {
 	base   string
 
 	err error
+
+	// problems found in the parts skipped when the workbook is diagnosed
+	diagnose bool
+	problems []error
 }
 
 func readFile(name string) (*reader, error) {
@@ -53,9 +57,16 @@ func (r *reader) Close() error {
 func (r *reader) ReadFile() (*File, error) {
 	file := NewFile()
 	r.readContentFile(file)
+	r.readTheme(file)
+	r.tolerate()
+	r.readStyles(file)
+	r.tolerate()
 	r.readSharedStrings(file)
+	r.tolerate()
 	r.readWorkbook(file)
 	r.readWorksheets(file)
+	r.readVbaProject(file)
+	r.tolerate()
 	return file, r.err
 }
 
@@ -75,15 +86,29 @@ func (r *reader) readSharedStrings(file *File) {
 	if r.invalid() {
 		return
 	}
-	root := struct {
-		XMLName xml.Name `xml:"sst"`
-		Shared  []string `xml:"si>t"`
-	}{}
-	if err := r.decodeXML(r.fromBase("sharedStrings.xml"), &root); err != nil {
-		r.err = nil
+	name := r.fromBase("sharedStrings.xml")
+	if !r.exists(name) {
+		return
+	}
+	var root xmlSharedStrings
+	if err := r.decodeXML(name, &root); err != nil {
+		if !r.diagnose {
+			// cells referring to missing strings are read as empty strings
+			r.err = nil
+		}
 		return
 	}
-	file.sharedStrings = root.Shared
+	file.sharedStrings = make([]string, 0, len(root.Values))
+	for i, v := range root.Values {
+		file.sharedStrings = append(file.sharedStrings, v.String())
+		if rt := v.RichText(); !rt.isPlain() {
+			rt.resolveColors(file.Theme())
+			if file.sharedRuns == nil {
+				file.sharedRuns = make(map[int]RichText)
+			}
+			file.sharedRuns[i] = rt
+		}
+	}
 }
 
 func (r *reader) readWorkbook(file *File) {
@@ -95,10 +120,36 @@ func (r *reader) readWorkbook(file *File) {
 	if err := r.decodeXML(addr, &root); err != nil {
 		return
 	}
+	file.codeName = root.Properties.CodeName
+	file.date1904, _ = strconv.ParseBool(root.Properties.Date1904)
+	if c := root.Calc; c != nil {
+		if ok, _ := strconv.ParseBool(c.Iterate); ok {
+			file.iterate = grid.DefaultIteration()
+			if c.Count > 0 {
+				file.iterate.Count = c.Count
+			}
+			if c.Delta > 0 {
+				file.iterate.Delta = c.Delta
+			}
+		}
+	}
+	if p := root.Protection; p.Locked {
+		file.locked = true
+		if p.Hash != "" || p.Password != "" {
+			file.password = &Password{
+				Algorithm: p.Algorithm,
+				Hash:      p.Hash,
+				Salt:      p.Salt,
+				SpinCount: p.SpinCount,
+				Legacy:    p.Password,
+			}
+		}
+	}
 	for i, xs := range root.Sheets {
 		s := Sheet{
 			Id:    xs.Id,
 			Label: file.names.Next(xs.Name),
+			owner: file,
 			Index: xs.Index,
 			State: xs.State,
 			cells: make(map[layout.Position]*Cell),
@@ -111,6 +162,17 @@ func (r *reader) readWorkbook(file *File) {
 		}
 		file.sheets = append(file.sheets, &s)
 	}
+	for _, n := range root.DefinedNames {
+		if !strings.HasPrefix(n.Name, "_xlnm.") {
+			file.readDefinedName(n)
+			continue
+		}
+		if n.Name != printAreaName || n.Sheet == nil || *n.Sheet < 0 || *n.Sheet >= len(file.sheets) {
+			continue
+		}
+		file.sheets[*n.Sheet].Page.PrintArea = parsePrintArea(n.Value)
+	}
+	r.readPivotCaches(file, addr, root.PivotCaches)
 }
 
 func (r *reader) readWorksheets(file *File) {
@@ -127,28 +189,40 @@ func (r *reader) readWorksheets(file *File) {
 		})
 		if ix < 0 {
 			r.err = fmt.Errorf("%w: file with id %s not found", grid.ErrFile, s.Id)
-			return
+		} else {
+			r.readWorksheet(s, file, relations[ix].Target)
+			r.readPivotTables(file, s, r.fromBase(relations[ix].Target))
 		}
-		r.readWorksheet(s, file.sharedStrings, relations[ix].Target)
+		r.tolerate()
 		if r.invalid() {
 			break
 		}
 	}
 }
 
-func (r *reader) readWorksheet(sheet *Sheet, sharedStrings []string, addr string) {
+func (r *reader) readWorksheet(sheet *Sheet, file *File, addr string) {
 	if r.invalid() {
 		return
 	}
-	z, err := r.openFile(r.fromBase(addr))
+	addr = r.fromBase(addr)
+	z, err := r.openFile(addr)
 	if err != nil {
 		r.err = err
 		return
 	}
-	rs := updateSheet(z, sheet, sharedStrings)
-	if err := rs.Update(); err != nil {
+	rs := updateSheet(z, sheet, file)
+	if err := z.check(rs.Update()); err != nil {
 		r.err = err
+		return
 	}
+	if sheet.Size.Lines == 0 && sheet.Size.Columns == 0 {
+		// the dimension element is optional and omitted by streaming writers
+		for _, c := range sheet.cells {
+			sheet.updateSize(c)
+		}
+	}
+	r.readTables(sheet, addr, rs.tableParts)
+	r.readDrawing(sheet, addr, rs.drawing)
 }
 
 func (r *reader) readWorkbookLocation() string {
@@ -189,20 +263,35 @@ func (r *reader) decodeXML(name string, ptr any) error {
 		r.err = err
 		return r.err
 	}
-	if err := xml.NewDecoder(rs).Decode(ptr); err != nil {
-		r.err = fmt.Errorf("%w: fail to read data from %s", grid.ErrFile, name)
+	dec := xml.NewDecoder(rs)
+	if err := dec.Decode(ptr); err != nil {
+		r.err = rs.check(partError(name, dec.InputOffset(), err))
 	}
 	return r.err
 }
 
-func (r *reader) openFile(name string) (io.Reader, error) {
+func (r *reader) exists(name string) bool {
+	return slices.ContainsFunc(r.reader.File, func(f *zip.File) bool {
+		return f.Name == name
+	})
+}
+
+func (r *reader) openFile(name string) (*partReader, error) {
 	ix := slices.IndexFunc(r.reader.File, func(f *zip.File) bool {
 		return f.Name == name
 	})
 	if ix < 0 {
 		return nil, fmt.Errorf("%w: file %s not found in archive", grid.ErrFile, name)
 	}
-	return r.reader.File[ix].Open()
+	rs, err := r.reader.File[ix].Open()
+	if err != nil {
+		return nil, partError(name, -1, err)
+	}
+	pr := partReader{
+		Reader: rs,
+		name:   name,
+	}
+	return &pr, nil
 }
 
 func (r *reader) fromBase(name string) string {
@@ -226,27 +315,54 @@ type sheetReader struct {
 	reader         *sax.Reader
 	sheet          *Sheet
 	sharedStrings  []string
+	sharedRuns     map[int]RichText
+	theme          *Theme
 	sharedFormulas map[string]sharedFormula
+	arrays         []*Cell
+	metadata       string
+	tableParts     []string
+	drawing        string
+
+	// formats of cells giving numbers as dates
+	dates    map[int]bool
+	date1904 bool
+
+	// row whose cells are being read
+	line *row
 }
 
-func updateSheet(r io.Reader, sheet *Sheet, shared []string) *sheetReader {
+func updateSheet(r io.Reader, sheet *Sheet, file *File) *sheetReader {
 	if sheet.cells == nil {
 		sheet.cells = make(map[layout.Position]*Cell)
 	}
 	rs := sheetReader{
 		reader:         sax.NewReader(r),
 		sheet:          sheet,
-		sharedStrings:  shared,
+		sharedStrings:  file.sharedStrings,
+		sharedRuns:     file.sharedRuns,
+		theme:          file.Theme(),
 		sharedFormulas: make(map[string]sharedFormula),
+		dates:          file.getStyles().dateFormats(),
+		date1904:       file.date1904,
 	}
 	return &rs
 }
 
 func (r *sheetReader) Update() error {
+	r.reader.Element(sax.LocalName("sheetPr"), r.onSheetProperties)
 	r.reader.Element(sax.LocalName("dimension"), r.onDimension)
+	r.reader.Element(sax.LocalName("sheetView"), r.onSheetView)
 	r.reader.Element(sax.LocalName("sheetProtection"), r.onProtection)
+	r.reader.Element(sax.LocalName("autoFilter"), r.onAutoFilter)
+	r.reader.Element(sax.LocalName("col"), r.onColumn)
 	r.reader.Element(sax.LocalName("row"), r.onRow)
 	r.reader.Element(sax.LocalName("c"), r.onCell)
+	r.reader.Element(sax.LocalName("conditionalFormatting"), r.onConditionalFormatting)
+	r.reader.Element(sax.LocalName("pageMargins"), r.onPageMargins)
+	r.reader.Element(sax.LocalName("pageSetup"), r.onPageSetup)
+	r.reader.Element(sax.LocalName("headerFooter"), r.onHeaderFooter)
+	r.reader.Element(sax.LocalName("drawing"), r.onDrawing)
+	r.reader.Element(sax.LocalName("tablePart"), r.onTablePart)
 	err := r.reader.Start()
 	if err == nil {
 		slices.SortFunc(r.sheet.rows, func(r1, r2 *row) int {
@@ -268,11 +384,13 @@ func (r *sheetReader) parseCellValue(cell *Cell, str string) error {
 			return fmt.Errorf("shared string index out of bounds")
 		}
 		cell.parsed = value.Text(r.sharedStrings[n])
+		cell.runs = r.sharedRuns[n]
 	case TypeDate:
 		for _, f := range dateFormats {
 			when, err := time.Parse(f, str)
 			if err == nil {
 				cell.parsed = value.Date(when)
+				break
 			}
 		}
 	case TypeInlineStr:
@@ -289,6 +407,8 @@ func (r *sheetReader) parseCellValue(cell *Cell, str string) error {
 		n, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
 		if err != nil {
 			cell.parsed = value.Text(str)
+		} else if r.dates[cell.style] && n >= 0 {
+			cell.parsed = value.DateFromSerial(n, r.date1904)
 		} else {
 			cell.parsed = value.Float(n)
 		}
@@ -314,6 +434,9 @@ func (r *sheetReader) parseCellFormula(cell *Cell, el sax.E, rs *sax.Reader) err
 			cell.formula = sf.Expr
 		}
 	}
+	if shared == FormulaArray {
+		r.parseArrayFormula(cell, el)
+	}
 	if el.SelfClosed {
 		return nil
 	}
@@ -336,37 +459,75 @@ func (r *sheetReader) parseCellFormula(cell *Cell, el sax.E, rs *sax.Reader) err
 	return nil
 }
 
+// parseArrayFormula keeps the range filled by the array of the formula. The
+// values of the cells of the range are read as the other values of the sheet.
+func (r *sheetReader) parseArrayFormula(cell *Cell, el sax.E) {
+	rg := layout.RangeFromString(el.GetAttributeValue("ref"))
+	if rg.Ends.Line == 0 {
+		rg.Ends = rg.Starts
+	}
+	cell.spill = rg.Normalize().Dimension()
+	// formulas of dynamic arrays refer to the metadata of their cell
+	cell.fixed = r.metadata == ""
+	if cell.spill.Lines*cell.spill.Columns > 1 {
+		r.arrays = append(r.arrays, cell)
+	}
+}
+
+// anchorOf gives the formula whose array fills the cell at the given position.
+// Cells are read line by line so the arrays ending above are dropped.
+func (r *sheetReader) anchorOf(pos layout.Position) *Cell {
+	r.arrays = slices.DeleteFunc(r.arrays, func(c *Cell) bool {
+		return c.SpillRange().Ends.Line < pos.Line
+	})
+	for _, c := range r.arrays {
+		if c.Position != pos && c.SpillRange().Contains(pos) {
+			return c
+		}
+	}
+	return nil
+}
+
 func (r *sheetReader) onCell(rs *sax.Reader, el sax.E) error {
-	if len(r.sheet.rows) == 0 {
+	if r.line == nil {
 		return fmt.Errorf("no row in worksheet")
 	}
+	if r.line.Len() == 0 && !r.line.Hidden {
+		r.sheet.rows = append(r.sheet.rows, r.line)
+	}
 
 	var (
 		kind  = el.GetAttributeValue("t")
 		index = el.GetAttributeValue("r")
-		local sax.QName
-		pos   = len(r.sheet.rows) - 1
 		cell  = &Cell{
 			Position: layout.ParsePosition(index),
 			Type:     kind,
 			id:       id.Next(),
 		}
 	)
+	cell.style, _ = strconv.Atoi(el.GetAttributeValue("s"))
+	cell.anchor = r.anchorOf(cell.Position)
+	r.metadata = el.GetAttributeValue("cm")
 	cell.MarkDirty()
-	if kind == TypeInlineStr {
-		local = sax.LocalName("is")
-	} else {
-		local = sax.LocalName("v")
-	}
-	r.sheet.rows[pos].Append(cell)
+	r.line.Append(cell)
 	r.sheet.cells[cell.At()] = cell
 
-	rs.Element(local, func(rs *sax.Reader, _ sax.E) error {
-		rs.OnText(func(_ *sax.Reader, str string) error {
-			return r.parseCellValue(cell, str)
+	if kind == TypeInlineStr {
+		onInlineString(rs, func(text RichText) error {
+			if !text.isPlain() {
+				text.resolveColors(r.theme)
+				cell.runs = text
+			}
+			return r.parseCellValue(cell, text.String())
 		})
-		return nil
-	})
+	} else {
+		rs.Element(sax.LocalName("v"), func(rs *sax.Reader, _ sax.E) error {
+			rs.OnText(func(_ *sax.Reader, str string) error {
+				return r.parseCellValue(cell, str)
+			})
+			return nil
+		})
+	}
 	rs.Element(sax.LocalName("f"), func(rs *sax.Reader, el sax.E) error {
 		return r.parseCellFormula(cell, el, rs)
 	})
@@ -379,11 +540,16 @@ func (r *sheetReader) onRow(rs *sax.Reader, el sax.E) error {
 		err error
 	)
 	oxr.Line, err = strconv.ParseInt(el.GetAttributeValue("r"), 10, 64)
+	if err != nil {
+		return err
+	}
+	// rows are only kept once they have a cell unless they are hidden
 	oxr.Hidden = el.GetAttributeValue("hidden") == "1"
-	if err == nil {
+	if oxr.Hidden {
 		r.sheet.rows = append(r.sheet.rows, &oxr)
 	}
-	return err
+	r.line = &oxr
+	return nil
 }
 
 func (r *sheetReader) onDimension(rs *sax.Reader, el sax.E) error {
@@ -402,7 +568,15 @@ func (r *sheetReader) onDimension(rs *sax.Reader, el sax.E) error {
 	return nil
 }
 
+func (r *sheetReader) onAutoFilter(rs *sax.Reader, el sax.E) error {
+	if list := parseRef(el.GetAttributeValue("ref")); len(list) > 0 {
+		r.sheet.AutoFilter = list[0]
+	}
+	return nil
+}
+
 func (r *sheetReader) onProtection(rs *sax.Reader, el sax.E) error {
+	r.sheet.Password = readPassword(el)
 	if el.GetAttributeValue("sheet") == "1" {
 		r.sheet.Protected |= ProtectedSheet
 	}
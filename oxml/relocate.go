@@ -0,0 +1,154 @@
+package oxml
+
+import (
+	"slices"
+
+	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/layout"
+)
+
+func insertRows(offset, count int64) parse.RelocateFunc {
+	return func(start, end layout.Position) (layout.Position, layout.Position, bool) {
+		start.Line, end.Line = expand(start.Line, end.Line, offset, count)
+		return start, end, true
+	}
+}
+
+func insertColumns(offset, count int64) parse.RelocateFunc {
+	return func(start, end layout.Position) (layout.Position, layout.Position, bool) {
+		start.Column, end.Column = expand(start.Column, end.Column, offset, count)
+		return start, end, true
+	}
+}
+
+// deleteRows gives the positions of the cells once the rows between from and
+// last are removed. Ranges starting or ending in these rows are shrunk.
+func deleteRows(from, last int64) parse.RelocateFunc {
+	return func(start, end layout.Position) (layout.Position, layout.Position, bool) {
+		if start.Line == 0 && end.Line == 0 {
+			// whole columns are not changed
+			return start, end, true
+		}
+		start.Line, end.Line = shrink(start.Line, end.Line, from, last)
+		return start, end, start.Line <= end.Line
+	}
+}
+
+func deleteColumns(from, last int64) parse.RelocateFunc {
+	return func(start, end layout.Position) (layout.Position, layout.Position, bool) {
+		if start.Column == 0 && end.Column == 0 {
+			return start, end, true
+		}
+		start.Column, end.Column = shrink(start.Column, end.Column, from, last)
+		return start, end, start.Column <= end.Column
+	}
+}
+
+// expand moves the indices after offset by count.
+func expand(start, end, offset, count int64) (int64, int64) {
+	if start > offset {
+		start += count
+	}
+	if end > offset {
+		end += count
+	}
+	return start, end
+}
+
+// shrink removes the indices between from and last. The start is greater than
+// the end when all the indices are removed.
+func shrink(start, end, from, last int64) (int64, int64) {
+	count := last - from + 1
+	if start > last {
+		start -= count
+	} else if start >= from {
+		start = from
+	}
+	if end > last {
+		end -= count
+	} else if end >= from {
+		end = from - 1
+	}
+	return start, end
+}
+
+// reindex updates the index of the cells and the size of the sheet once cells
+// have been moved.
+func (s *Sheet) reindex() {
+	s.cells = make(map[layout.Position]*Cell)
+	s.Size = layout.Dimension{}
+	for _, r := range s.rows {
+		for _, c := range r.Cells {
+			s.cells[c.At().WithoutSheet()] = c
+			s.updateSize(c)
+		}
+	}
+}
+
+// relocate moves the ranges of the sheet and the references to its cells found
+// in the formulas of the workbook to the positions given by fn.
+func (s *Sheet) relocate(fn parse.RelocateFunc) {
+	move := func(start, end layout.Position) (layout.Position, layout.Position, bool) {
+		if start.Sheet != "" && start.Sheet != s.Label {
+			return start, end, true
+		}
+		return fn(start, end)
+	}
+	sheets := []*Sheet{s}
+	if s.owner != nil {
+		sheets = s.owner.sheets
+	}
+	for _, sh := range sheets {
+		for _, c := range sh.cells {
+			if c.formula == nil {
+				continue
+			}
+			c.dirty = true
+			if sh == s {
+				c.formula = grid.Relocate(c.formula, move)
+				continue
+			}
+			c.formula = grid.Relocate(c.formula, func(start, end layout.Position) (layout.Position, layout.Position, bool) {
+				if start.Sheet != s.Label {
+					return start, end, true
+				}
+				return fn(start, end)
+			})
+		}
+	}
+	if s.AutoFilter != nil {
+		s.AutoFilter = relocateRange(s.AutoFilter, fn)
+	}
+	for _, cf := range s.Conditionals {
+		for i := range cf.Ranges {
+			cf.Ranges[i] = relocateRange(cf.Ranges[i], fn)
+		}
+		cf.Ranges = slices.DeleteFunc(cf.Ranges, func(rg *layout.Range) bool {
+			return rg == nil
+		})
+	}
+	s.Conditionals = slices.DeleteFunc(s.Conditionals, func(cf *ConditionalFormat) bool {
+		return len(cf.Ranges) == 0
+	})
+	s.Tables = slices.DeleteFunc(s.Tables, func(t *Table) bool {
+		if t.Ref == nil {
+			return false
+		}
+		t.Ref = relocateRange(t.Ref, fn)
+		return t.Ref == nil
+	})
+	if s.Page.PrintArea != nil {
+		s.Page.PrintArea = relocateRange(s.Page.PrintArea, fn)
+	}
+}
+
+// relocateRange gives the range moved by fn or nil if its cells no longer
+// exist.
+func relocateRange(rg *layout.Range, fn parse.RelocateFunc) *layout.Range {
+	start, end, ok := fn(rg.Starts, rg.Ends)
+	if !ok {
+		return nil
+	}
+	return layout.NewRange(start, end)
+}
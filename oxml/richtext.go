@@ -0,0 +1,427 @@
+package oxml
+
+import (
+	"strconv"
+	"strings"
+
+	sax "github.com/midbel/codecs/xml"
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+// RunFont holds the formatting of a fragment of a rich text. Fields left to
+// their zero value are inherited from the style of the cell. Colors of the
+// theme are given by Theme and Tint, like RuleColor.
+type RunFont struct {
+	Name      string
+	Size      float64
+	Color     string
+	Theme     string
+	Tint      string
+	Bold      bool
+	Italic    bool
+	Strike    bool
+	Underline string
+}
+
+// TextRun is a fragment of a rich text. A run without font uses the style of
+// the cell.
+type TextRun struct {
+	Text string
+	Font *RunFont
+}
+
+// RichText is a text made of runs having each their own formatting.
+type RichText []TextRun
+
+// String gives the text without its formatting.
+func (r RichText) String() string {
+	var str strings.Builder
+	for i := range r {
+		str.WriteString(r[i].Text)
+	}
+	return str.String()
+}
+
+func (r RichText) clone() RichText {
+	if len(r) == 0 {
+		return nil
+	}
+	list := make(RichText, len(r))
+	for i := range r {
+		list[i].Text = r[i].Text
+		if r[i].Font != nil {
+			f := *r[i].Font
+			list[i].Font = &f
+		}
+	}
+	return list
+}
+
+func (r RichText) resolveColors(theme *Theme) {
+	for i := range r {
+		f := r[i].Font
+		if f == nil || f.Theme == "" || f.Color != "" {
+			continue
+		}
+		f.Color, _ = theme.Resolve(f.Theme, f.Tint)
+	}
+}
+
+func (r RichText) isPlain() bool {
+	for i := range r {
+		if r[i].Font != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Runs gives the runs of the text of the cell. It is nil when the cell does
+// not hold a formatted text.
+func (c *Cell) Runs() RichText {
+	return c.runs
+}
+
+// Text gives the text of the cell without its formatting.
+func (c *Cell) Text() string {
+	if len(c.runs) > 0 {
+		return c.runs.String()
+	}
+	return c.Value().String()
+}
+
+// SetRichText writes a formatted text into the cell at the given position.
+// The value of the cell is the text without its formatting.
+func (s *Sheet) SetRichText(pos layout.Position, text RichText) error {
+	if err := grid.CheckName(pos, s); err != nil {
+		return err
+	}
+	if s.spilled != nil {
+		return grid.ErrWritable
+	}
+	str := text.String()
+	if err := s.SetValue(pos, value.Text(str)); err != nil {
+		return err
+	}
+	c := s.cells[pos.WithoutSheet()]
+	c.Type = TypeInlineStr
+	if !text.isPlain() {
+		c.runs = text.clone()
+	}
+	return nil
+}
+
+type xmlRichText struct {
+	Text *xmlText     `xml:"t"`
+	Runs []xmlTextRun `xml:"r"`
+}
+
+func createRichText(str string, runs RichText) xmlRichText {
+	var rt xmlRichText
+	if len(runs) == 0 {
+		rt.Text = createText(str)
+		return rt
+	}
+	for _, r := range runs {
+		x := xmlTextRun{
+			Text: createText(r.Text),
+		}
+		if f := r.Font; f != nil {
+			x.Font = &xmlRunFont{
+				Name:      createVal(f.Name),
+				Color:     createColor(f),
+				Bold:      createFlag(f.Bold),
+				Italic:    createFlag(f.Italic),
+				Strike:    createFlag(f.Strike),
+				Underline: createVal(f.Underline),
+			}
+			if f.Size > 0 {
+				x.Font.Size = createVal(strconv.FormatFloat(f.Size, 'f', -1, 64))
+			}
+		}
+		rt.Runs = append(rt.Runs, x)
+	}
+	return rt
+}
+
+func (x xmlRichText) RichText() RichText {
+	if len(x.Runs) == 0 {
+		return nil
+	}
+	var list RichText
+	if x.Text != nil {
+		list = append(list, TextRun{Text: x.Text.value()})
+	}
+	for _, r := range x.Runs {
+		run := TextRun{
+			Text: r.Text.value(),
+		}
+		if f := r.Font; f != nil {
+			run.Font = &RunFont{
+				Name:      f.Name.value(),
+				Color:     f.Color.value(),
+				Theme:     f.Color.theme(),
+				Tint:      f.Color.tint(),
+				Bold:      f.Bold.flag(),
+				Italic:    f.Italic.flag(),
+				Strike:    f.Strike.flag(),
+				Underline: f.Underline.value(),
+			}
+			if f.Underline != nil && run.Font.Underline == "" {
+				run.Font.Underline = "single"
+			}
+			run.Font.Size, _ = strconv.ParseFloat(f.Size.value(), 64)
+		}
+		list = append(list, run)
+	}
+	return list
+}
+
+func (x xmlRichText) String() string {
+	if rt := x.RichText(); len(rt) > 0 {
+		return rt.String()
+	}
+	return x.Text.value()
+}
+
+type xmlText struct {
+	Space string `xml:"xml:space,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+func createText(str string) *xmlText {
+	t := xmlText{
+		Value: str,
+	}
+	if strings.TrimSpace(str) != str {
+		t.Space = "preserve"
+	}
+	return &t
+}
+
+func (x *xmlText) value() string {
+	if x == nil {
+		return ""
+	}
+	return x.Value
+}
+
+type xmlTextRun struct {
+	Font *xmlRunFont `xml:"rPr"`
+	Text *xmlText    `xml:"t"`
+}
+
+type xmlRunFont struct {
+	Name      *xmlVal   `xml:"rFont"`
+	Bold      *xmlVal   `xml:"b"`
+	Italic    *xmlVal   `xml:"i"`
+	Strike    *xmlVal   `xml:"strike"`
+	Color     *xmlColor `xml:"color"`
+	Size      *xmlVal   `xml:"sz"`
+	Underline *xmlVal   `xml:"u"`
+}
+
+type xmlVal struct {
+	Value string `xml:"val,attr,omitempty"`
+}
+
+func createVal(str string) *xmlVal {
+	if str == "" {
+		return nil
+	}
+	return &xmlVal{Value: str}
+}
+
+func createFlag(set bool) *xmlVal {
+	if !set {
+		return nil
+	}
+	return &xmlVal{}
+}
+
+func (x *xmlVal) value() string {
+	if x == nil {
+		return ""
+	}
+	return x.Value
+}
+
+func (x *xmlVal) flag() bool {
+	return x != nil && x.Value != "0" && x.Value != "false"
+}
+
+type xmlColor struct {
+	Rgb   string `xml:"rgb,attr,omitempty"`
+	Theme string `xml:"theme,attr,omitempty"`
+	Tint  string `xml:"tint,attr,omitempty"`
+}
+
+func createColor(f *RunFont) *xmlColor {
+	switch {
+	case f.Theme != "":
+		return &xmlColor{Theme: f.Theme, Tint: f.Tint}
+	case f.Color != "":
+		return &xmlColor{Rgb: f.Color}
+	default:
+		return nil
+	}
+}
+
+func (x *xmlColor) value() string {
+	if x == nil {
+		return ""
+	}
+	return x.Rgb
+}
+
+func (x *xmlColor) theme() string {
+	if x == nil {
+		return ""
+	}
+	return x.Theme
+}
+
+func (x *xmlColor) tint() string {
+	if x == nil {
+		return ""
+	}
+	return x.Tint
+}
+
+// onInlineString reads the runs of an inline string and gives them to fn once
+// the string is fully read. Phonetic runs are skipped.
+func onInlineString(rs *sax.Reader, fn func(RichText) error) {
+	var (
+		name = sax.LocalName("is")
+		text RichText
+	)
+	rs.OnOpen(name, func(rs *sax.Reader, _ sax.E) error {
+		rs.Push()
+		text = nil
+		rs.Element(sax.LocalName("t"), func(rs *sax.Reader, _ sax.E) error {
+			rs.OnText(func(_ *sax.Reader, str string) error {
+				text = append(text, TextRun{Text: str})
+				return nil
+			})
+			return nil
+		})
+		rs.Element(sax.LocalName("r"), func(rs *sax.Reader, _ sax.E) error {
+			text = append(text, TextRun{})
+			run := &text[len(text)-1]
+			rs.Element(sax.LocalName("rPr"), func(rs *sax.Reader, _ sax.E) error {
+				run.Font = new(RunFont)
+				rs.OnOpenAny(func(_ *sax.Reader, el sax.E) error {
+					run.Font.update(el)
+					return nil
+				})
+				return nil
+			})
+			rs.Element(sax.LocalName("t"), func(rs *sax.Reader, _ sax.E) error {
+				rs.OnText(func(_ *sax.Reader, str string) error {
+					run.Text += str
+					return nil
+				})
+				return nil
+			})
+			return nil
+		})
+		rs.OnOpen(sax.LocalName("rPh"), func(_ *sax.Reader, _ sax.E) error {
+			return sax.ErrDiscard
+		})
+		return nil
+	})
+	rs.OnClose(name, func(rs *sax.Reader, _ sax.E) error {
+		rs.Pop()
+		return fn(text)
+	})
+}
+
+func (f *RunFont) update(el sax.E) {
+	var (
+		val = el.GetAttributeValue("val")
+		set = val != "0" && val != "false"
+	)
+	switch el.Name {
+	case "rFont":
+		f.Name = val
+	case "sz":
+		f.Size, _ = strconv.ParseFloat(val, 64)
+	case "color":
+		f.Color = el.GetAttributeValue("rgb")
+		f.Theme = el.GetAttributeValue("theme")
+		f.Tint = el.GetAttributeValue("tint")
+	case "b":
+		f.Bold = set
+	case "i":
+		f.Italic = set
+	case "strike":
+		f.Strike = set
+	case "u":
+		f.Underline = val
+		if f.Underline == "" {
+			f.Underline = "single"
+		}
+	}
+}
+
+func (w *sheetWriter) writeRichText(text RichText) {
+	var (
+		runName  = sax.LocalName("r")
+		fontName = sax.LocalName("rPr")
+	)
+	for _, r := range text {
+		w.writer.Open(runName, nil)
+		if f := r.Font; f != nil {
+			w.writer.Open(fontName, nil)
+			if f.Name != "" {
+				w.writer.Empty(sax.LocalName("rFont"), []sax.A{createAttr("val", f.Name)})
+			}
+			if f.Bold {
+				w.writer.Empty(sax.LocalName("b"), nil)
+			}
+			if f.Italic {
+				w.writer.Empty(sax.LocalName("i"), nil)
+			}
+			if f.Strike {
+				w.writer.Empty(sax.LocalName("strike"), nil)
+			}
+			if c := createColor(f); c != nil {
+				var attrs []sax.A
+				if c.Theme != "" {
+					attrs = append(attrs, createAttr("theme", c.Theme))
+					if c.Tint != "" {
+						attrs = append(attrs, createAttr("tint", c.Tint))
+					}
+				} else {
+					attrs = append(attrs, createAttr("rgb", c.Rgb))
+				}
+				w.writer.Empty(sax.LocalName("color"), attrs)
+			}
+			if f.Size > 0 {
+				sz := strconv.FormatFloat(f.Size, 'f', -1, 64)
+				w.writer.Empty(sax.LocalName("sz"), []sax.A{createAttr("val", sz)})
+			}
+			if f.Underline != "" {
+				w.writer.Empty(sax.LocalName("u"), []sax.A{createAttr("val", f.Underline)})
+			}
+			w.writer.Close(fontName)
+		}
+		w.writeStringText(r.Text)
+		w.writer.Close(runName)
+	}
+}
+
+func (w *sheetWriter) writeStringText(str string) {
+	var (
+		name  = sax.LocalName("t")
+		attrs []sax.A
+	)
+	if strings.TrimSpace(str) != str {
+		attrs = append(attrs, createAttr("xml:space", "preserve"))
+	}
+	w.writer.Open(name, attrs)
+	w.writer.Text(str)
+	w.writer.Close(name)
+}
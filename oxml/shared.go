@@ -0,0 +1,131 @@
+package oxml
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	sax "github.com/midbel/codecs/xml"
+	"github.com/midbel/dockit/formula/format"
+	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+// sharedRef tells how the formula of a cell is written when it belongs to a
+// group of shared formulas. Only the first cell of the group, the master,
+// has the text of the formula and the range of the group.
+type sharedRef struct {
+	Index  int
+	Master bool
+	Ref    *layout.Range
+}
+
+// groupSharedFormulas finds the cells of a column whose formulas are the one
+// of the cell above shifted by one line, as given by a fill down in a
+// spreadsheet application. Each group of at least two cells is written as a
+// shared formula.
+func groupSharedFormulas(sheet *Sheet) map[layout.Position]sharedRef {
+	var cells []*Cell
+	for _, r := range sheet.rows {
+		for _, c := range r.Cells {
+			if c.formula != nil && c.SpillRange() == nil {
+				cells = append(cells, c)
+			}
+		}
+	}
+	slices.SortFunc(cells, func(c1, c2 *Cell) int {
+		if c1.Column != c2.Column {
+			return int(c1.Column - c2.Column)
+		}
+		return int(c1.Line - c2.Line)
+	})
+	var (
+		groups = make(map[layout.Position]sharedRef)
+		index  int
+	)
+	for i := 0; i < len(cells); {
+		master := cells[i]
+		j := i + 1
+		for ; j < len(cells); j++ {
+			curr := cells[j]
+			if curr.Column != master.Column || curr.Line != cells[j-1].Line+1 {
+				break
+			}
+			if !sameSharedFormula(master, curr) {
+				break
+			}
+		}
+		if j-i > 1 {
+			end := cells[j-1].WithoutSheet()
+			groups[master.WithoutSheet()] = sharedRef{
+				Index:  index,
+				Master: true,
+				Ref:    layout.NewRange(master.WithoutSheet(), end),
+			}
+			for _, c := range cells[i+1 : j] {
+				groups[c.WithoutSheet()] = sharedRef{
+					Index: index,
+				}
+			}
+			index++
+		}
+		i = j
+	}
+	return groups
+}
+
+func sameSharedFormula(master, cell *Cell) bool {
+	want, err := formatFormula(cell.formula)
+	if err != nil {
+		return false
+	}
+	other := grid.Rebase(master.formula, master.WithoutSheet(), cell.WithoutSheet())
+	got, err := formatFormula(other)
+	return err == nil && got == want
+}
+
+func formatFormula(fm value.Formula) (string, error) {
+	e, ok := fm.(interface{ Expr() parse.Expr })
+	if !ok {
+		return "", format.ErrFormat
+	}
+	str, err := format.FormatOxml(e.Expr())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(str, "="), nil
+}
+
+func (w *sheetWriter) writeFormula(cell *Cell) {
+	formName := sax.LocalName("f")
+	ref, ok := w.shared[cell.WithoutSheet()]
+	if ok && !ref.Master {
+		w.writer.Empty(formName, []sax.A{
+			createAttr("t", FormulaShared),
+			createAttr("si", strconv.Itoa(ref.Index)),
+		})
+		return
+	}
+	// formulas that can not be expressed in Excel are kept as values
+	str, err := formatFormula(cell.formula)
+	if err != nil {
+		return
+	}
+	var attrs []sax.A
+	if ok {
+		attrs = append(attrs, createAttr("t", FormulaShared))
+		attrs = append(attrs, createAttr("ref", ref.Ref.String()))
+		attrs = append(attrs, createAttr("si", strconv.Itoa(ref.Index)))
+	} else if rg := cell.SpillRange(); rg != nil {
+		// arrays are written as formulas entered as arrays: spreadsheet
+		// applications only spill dynamic arrays described in the metadata of
+		// the workbook
+		attrs = append(attrs, createAttr("t", FormulaArray))
+		attrs = append(attrs, createAttr("ref", rg.String()))
+	}
+	w.writer.Open(formName, attrs)
+	w.writer.Text(str)
+	w.writer.Close(formName)
+}
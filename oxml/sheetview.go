@@ -0,0 +1,139 @@
+package oxml
+
+import (
+	"fmt"
+	"strconv"
+
+	sax "github.com/midbel/codecs/xml"
+	"github.com/midbel/dockit/layout"
+)
+
+const (
+	minZoom = 10
+	maxZoom = 400
+)
+
+// SheetView holds the display settings of a sheet. Its zero value gives the
+// default settings used by spreadsheet applications.
+type SheetView struct {
+	Zoom          int
+	HideGridlines bool
+	RightToLeft   bool
+	FreezeRows    int64
+	FreezeColumns int64
+}
+
+func (v SheetView) isDefault() bool {
+	return v == SheetView{}
+}
+
+// FreezePanes keeps the given number of rows and columns visible while
+// scrolling. Using zero for both removes the frozen panes.
+func (s *Sheet) FreezePanes(rows, cols int64) error {
+	if rows < 0 || cols < 0 {
+		return fmt.Errorf("number of rows/columns to freeze should be positive")
+	}
+	s.Display.FreezeRows = rows
+	s.Display.FreezeColumns = cols
+	return nil
+}
+
+// SetZoom changes the zoom level of the sheet. The zoom is given in percent.
+func (s *Sheet) SetZoom(zoom int) error {
+	if zoom < minZoom || zoom > maxZoom {
+		return fmt.Errorf("zoom should be between %d and %d", minZoom, maxZoom)
+	}
+	if zoom == 100 {
+		zoom = 0
+	}
+	s.Display.Zoom = zoom
+	return nil
+}
+
+func (s *Sheet) ShowGridlines(show bool) {
+	s.Display.HideGridlines = !show
+}
+
+func (s *Sheet) SetRightToLeft(rtl bool) {
+	s.Display.RightToLeft = rtl
+}
+
+func (r *sheetReader) onSheetView(rs *sax.Reader, el sax.E) error {
+	view := &r.sheet.Display
+	view.HideGridlines = el.GetAttributeValue("showGridLines") == "0"
+	view.RightToLeft = el.GetAttributeValue("rightToLeft") == "1"
+	if z, err := strconv.Atoi(el.GetAttributeValue("zoomScale")); err == nil && z != 100 {
+		view.Zoom = z
+	}
+	rs.Element(sax.LocalName("pane"), func(_ *sax.Reader, el sax.E) error {
+		switch el.GetAttributeValue("state") {
+		case "frozen", "frozenSplit":
+		default:
+			return nil
+		}
+		view.FreezeColumns, _ = strconv.ParseInt(el.GetAttributeValue("xSplit"), 10, 64)
+		view.FreezeRows, _ = strconv.ParseInt(el.GetAttributeValue("ySplit"), 10, 64)
+		return nil
+	})
+	return nil
+}
+
+func (w *sheetWriter) writeSheetViews(sheet *Sheet) {
+	view := sheet.Display
+	if view.isDefault() && !sheet.Active {
+		return
+	}
+	var (
+		viewsName = sax.LocalName("sheetViews")
+		viewName  = sax.LocalName("sheetView")
+		attrs     []sax.A
+	)
+	if sheet.Active {
+		attrs = append(attrs, createAttr("tabSelected", "1"))
+	}
+	if view.HideGridlines {
+		attrs = append(attrs, createAttr("showGridLines", "0"))
+	}
+	if view.RightToLeft {
+		attrs = append(attrs, createAttr("rightToLeft", "1"))
+	}
+	if view.Zoom > 0 {
+		attrs = append(attrs, createAttr("zoomScale", strconv.Itoa(view.Zoom)))
+	}
+	attrs = append(attrs, createAttr("workbookViewId", "0"))
+
+	w.writer.Open(viewsName, nil)
+	if view.FreezeRows == 0 && view.FreezeColumns == 0 {
+		w.writer.Empty(viewName, attrs)
+		w.writer.Close(viewsName)
+		return
+	}
+	w.writer.Open(viewName, attrs)
+
+	var (
+		topLeft = layout.NewPosition(view.FreezeRows+1, view.FreezeColumns+1)
+		active  string
+	)
+	attrs = attrs[:0]
+	if view.FreezeColumns > 0 {
+		attrs = append(attrs, createAttr("xSplit", strconv.FormatInt(view.FreezeColumns, 10)))
+	}
+	if view.FreezeRows > 0 {
+		attrs = append(attrs, createAttr("ySplit", strconv.FormatInt(view.FreezeRows, 10)))
+	}
+	switch {
+	case view.FreezeRows > 0 && view.FreezeColumns > 0:
+		active = "bottomRight"
+	case view.FreezeRows > 0:
+		active = "bottomLeft"
+	default:
+		active = "topRight"
+	}
+	attrs = append(attrs, createAttr("topLeftCell", topLeft.Addr()))
+	attrs = append(attrs, createAttr("activePane", active))
+	attrs = append(attrs, createAttr("state", "frozen"))
+	w.writer.Empty(sax.LocalName("pane"), attrs)
+
+	w.writer.Close(viewName)
+	w.writer.Close(viewsName)
+}
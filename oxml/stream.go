@@ -0,0 +1,350 @@
+package oxml
+
+import (
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"slices"
+	"strconv"
+
+	sax "github.com/midbel/codecs/xml"
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+var errStopStream = errors.New("stop")
+
+// Stream reads the rows of the sheets of a workbook on demand. Unlike Open,
+// cells are decoded while the rows are iterated and are not kept in memory.
+// Formulas are not evaluated: the value cached in the file is given instead.
+type Stream struct {
+	reader *reader
+	file   *File
+	parts  map[string]string
+	err    error
+}
+
+// OpenStream opens a workbook to read its sheets row by row. The stream should
+// be closed when done.
+func OpenStream(file string) (*Stream, error) {
+	rs, err := readFile(file)
+	if err != nil {
+		return nil, err
+	}
+	s := Stream{
+		reader: rs,
+		file:   NewFile(),
+		parts:  make(map[string]string),
+	}
+	rs.readStyles(s.file)
+	rs.readSharedStrings(s.file)
+	rs.readWorkbook(s.file)
+	relations := rs.readRelationsForSheets()
+	if rs.invalid() {
+		rs.Close()
+		return nil, rs.err
+	}
+	for _, sh := range s.file.sheets {
+		ix := slices.IndexFunc(relations, func(r xmlRelation) bool {
+			return r.Id == sh.Id
+		})
+		if ix < 0 {
+			rs.Close()
+			return nil, fmt.Errorf("%w: file with id %s not found", grid.ErrFile, sh.Id)
+		}
+		s.parts[sh.Name()] = rs.fromBase(relations[ix].Target)
+	}
+	return &s, nil
+}
+
+func (s *Stream) Close() error {
+	return s.reader.Close()
+}
+
+// Sheets gives the names of the sheets of the workbook.
+func (s *Stream) Sheets() []string {
+	var list []string
+	for _, sh := range s.file.sheets {
+		list = append(list, sh.Name())
+	}
+	return list
+}
+
+// ActiveSheet gives the name of the active sheet of the workbook.
+func (s *Stream) ActiveSheet() string {
+	for _, sh := range s.file.sheets {
+		if sh.Active {
+			return sh.Name()
+		}
+	}
+	if len(s.file.sheets) > 0 {
+		return s.file.sheets[0].Name()
+	}
+	return ""
+}
+
+// Err gives the error that has stopped the last iteration, if any.
+func (s *Stream) Err() error {
+	return s.err
+}
+
+// Rows gives an iterator over the rows of the given sheet. Lines missing from
+// the sheet are given as empty rows. Errors stop the iteration and are
+// reported by Err.
+func (s *Stream) Rows(name string) iter.Seq[[]value.ScalarValue] {
+	return func(yield func([]value.ScalarValue) bool) {
+		s.err = nil
+		addr, ok := s.parts[name]
+		if !ok {
+			s.err = fmt.Errorf("sheet %s %w", name, grid.ErrFound)
+			return
+		}
+		z, err := s.reader.openFile(addr)
+		if err != nil {
+			s.err = err
+			return
+		}
+		var (
+			rs = sax.NewReader(z)
+			sr = sheetReader{
+				sharedStrings: s.file.sharedStrings,
+				dates:         s.file.getStyles().dateFormats(),
+				date1904:      s.file.date1904,
+			}
+			curr []value.ScalarValue
+			line int64
+			open bool
+		)
+		flush := func() bool {
+			if !open {
+				return true
+			}
+			open = false
+			return yield(curr)
+		}
+		rs.Element(sax.LocalName("row"), func(_ *sax.Reader, el sax.E) error {
+			if !flush() {
+				return errStopStream
+			}
+			next := line + 1
+			if str := el.GetAttributeValue("r"); str != "" {
+				n, err := strconv.ParseInt(str, 10, 64)
+				if err != nil {
+					return err
+				}
+				next = n
+			}
+			for line++; line < next; line++ {
+				if !yield(nil) {
+					return errStopStream
+				}
+			}
+			curr, open = nil, true
+			return nil
+		})
+		rs.Element(sax.LocalName("c"), func(rs *sax.Reader, el sax.E) error {
+			if !open {
+				return fmt.Errorf("no row in worksheet")
+			}
+			var (
+				kind  = el.GetAttributeValue("t")
+				index = el.GetAttributeValue("r")
+				col   = int64(len(curr)) + 1
+				cell  = Cell{Type: kind}
+			)
+			cell.style, _ = strconv.Atoi(el.GetAttributeValue("s"))
+			if index != "" {
+				col = layout.ParsePosition(index).Column
+			}
+			for int64(len(curr)) < col {
+				curr = append(curr, value.Empty())
+			}
+			parse := func(str string) error {
+				if err := sr.parseCellValue(&cell, str); err != nil {
+					return err
+				}
+				if v, ok := cell.parsed.(value.ScalarValue); ok {
+					curr[col-1] = v
+				}
+				return nil
+			}
+			if kind == TypeInlineStr {
+				onInlineString(rs, func(text RichText) error {
+					return parse(text.String())
+				})
+				return nil
+			}
+			rs.Element(sax.LocalName("v"), func(rs *sax.Reader, _ sax.E) error {
+				rs.OnText(func(_ *sax.Reader, str string) error {
+					return parse(str)
+				})
+				return nil
+			})
+			return nil
+		})
+		err = rs.Start()
+		if errors.Is(err, errStopStream) {
+			return
+		}
+		if err = z.check(err); err != nil {
+			s.err = err
+			return
+		}
+		flush()
+	}
+}
+
+// SheetStreamWriter writes a workbook whose sheets are filled one row at a
+// time. Rows are written to the archive as soon as they are appended so that
+// the memory used does not depend on the size of the sheets. The parts
+// describing the workbook are only written when the writer is closed.
+type SheetStreamWriter struct {
+	out   io.Closer
+	zip   *writer
+	file  *File
+	sheet *sheetWriter
+	line  int64
+}
+
+// CreateStream creates the given file and returns a writer to fill it.
+func CreateStream(file string) (*SheetStreamWriter, error) {
+	w, err := os.Create(file)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewSheetStreamWriter(w)
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	s.out = w
+	return s, nil
+}
+
+func NewSheetStreamWriter(w io.Writer) (*SheetStreamWriter, error) {
+	z, err := writeFile(w, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	s := SheetStreamWriter{
+		zip:  z,
+		file: NewFile(),
+	}
+	return &s, nil
+}
+
+// SetCompression changes the level of compression of the sheets added
+// afterwards and of the parts written when the writer is closed. See
+// File.SetCompression for the accepted levels.
+func (s *SheetStreamWriter) SetCompression(level int) error {
+	if err := checkCompression(level); err != nil {
+		return err
+	}
+	s.zip.setCompression(level)
+	return nil
+}
+
+// AddSheet terminates the sheet being written and starts a new one. Rows
+// written afterwards are appended to the new sheet.
+func (s *SheetStreamWriter) AddSheet(name string) error {
+	if err := s.closeSheet(); err != nil {
+		return err
+	}
+	sh := NewSheet(name)
+	if _, err := s.file.sheetByName(sh.Name()); err == nil {
+		return fmt.Errorf("%s: sheet already exists", sh.Name())
+	}
+	sh.Active = len(s.file.sheets) == 0
+	s.file.sheets = append(s.file.sheets, sh)
+
+	name = s.zip.createTarget("worksheets", sheetFile(len(s.file.sheets)-1))
+	w, err := s.zip.create(name)
+	if err != nil {
+		return err
+	}
+	if s.sheet, err = writeSheet(w); err != nil {
+		return err
+	}
+	s.line = 0
+	s.sheet.writer.Open(sax.LocalName("worksheet"), []sax.A{
+		createNS("", typeMainUrl),
+		createNS("r", "http://schemas.openxmlformats.org/officeDocument/2006/relationships"),
+	})
+	s.sheet.writeSheetViews(sh)
+	return s.sheet.writer.Open(sax.LocalName("sheetData"), nil)
+}
+
+// WriteRow appends a row to the sheet being written. Blank values are not
+// written but still occupy their line and column.
+func (s *SheetStreamWriter) WriteRow(row []value.ScalarValue) error {
+	if s.sheet == nil {
+		return fmt.Errorf("no sheet to write row to")
+	}
+	s.line++
+	blank := func(v value.ScalarValue) bool {
+		return v == nil || value.IsBlank(v)
+	}
+	if !slices.ContainsFunc(row, func(v value.ScalarValue) bool { return !blank(v) }) {
+		return nil
+	}
+	rowName := sax.LocalName("row")
+	s.sheet.writer.Open(rowName, []sax.A{
+		createAttr("r", strconv.FormatInt(s.line, 10)),
+	})
+	for i, v := range row {
+		if blank(v) {
+			continue
+		}
+		cell := Cell{
+			Type:     typeFromValue(v),
+			Position: layout.NewPosition(s.line, int64(i)+1),
+			raw:      rawFromValue(v),
+			parsed:   v,
+		}
+		if err := s.sheet.writeCell(&cell); err != nil {
+			return err
+		}
+	}
+	return s.sheet.writer.Close(rowName)
+}
+
+// Close terminates the last sheet and writes the parts of the workbook.
+func (s *SheetStreamWriter) Close() error {
+	err := s.closeSheet()
+	if err == nil && len(s.file.sheets) == 0 {
+		err = fmt.Errorf("no sheet in workbook")
+	}
+	if err == nil {
+		s.zip.writeWorkbook(s.file)
+		s.zip.writeRelationForSheets(s.file)
+		s.zip.writeRelations()
+		s.zip.writeStyles(s.file)
+		s.zip.writeContentTypes(s.file)
+		err = s.zip.err
+	}
+	if e := s.zip.Close(); err == nil {
+		err = e
+	}
+	if s.out != nil {
+		if e := s.out.Close(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (s *SheetStreamWriter) closeSheet() error {
+	if s.sheet == nil {
+		return nil
+	}
+	defer func() {
+		s.sheet = nil
+	}()
+	s.sheet.writer.Close(sax.LocalName("sheetData"))
+	s.sheet.writer.Close(sax.LocalName("worksheet"))
+	return s.sheet.writer.Flush()
+}
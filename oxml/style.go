@@ -0,0 +1,357 @@
+package oxml
+
+import (
+	"encoding/xml"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	typeStyleUrl = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles"
+	styleFile    = "styles.xml"
+
+	// identifiers of custom number formats start after the built-in ones
+	firstNumFmt = 164
+)
+
+// xmlStyleElement keeps a font, a fill, a border or a differential format as
+// it is written in the styles of a workbook.
+type xmlStyleElement struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+	Inner string     `xml:",innerxml"`
+}
+
+func (e xmlStyleElement) equal(other xmlStyleElement) bool {
+	return e.Inner == other.Inner && slices.Equal(e.Attrs, other.Attrs)
+}
+
+type xmlNumFmt struct {
+	Id   int    `xml:"numFmtId,attr"`
+	Code string `xml:"formatCode,attr"`
+}
+
+type xmlXf struct {
+	NumFmt int        `xml:"numFmtId,attr"`
+	Font   int        `xml:"fontId,attr"`
+	Fill   int        `xml:"fillId,attr"`
+	Border int        `xml:"borderId,attr"`
+	Style  *int       `xml:"xfId,attr"`
+	Attrs  []xml.Attr `xml:",any,attr"`
+	Inner  string     `xml:",innerxml"`
+}
+
+func (x xmlXf) equal(other xmlXf) bool {
+	ok := x.NumFmt == other.NumFmt && x.Font == other.Font && x.Fill == other.Fill && x.Border == other.Border
+	if !ok || x.Inner != other.Inner || !slices.Equal(x.Attrs, other.Attrs) {
+		return false
+	}
+	if x.Style == nil || other.Style == nil {
+		return x.Style == other.Style
+	}
+	return *x.Style == *other.Style
+}
+
+// styleSheet holds the formats of the cells of a workbook. Cells refer to
+// them with the index of their format in CellXfs.
+type styleSheet struct {
+	XMLName     xml.Name          `xml:"styleSheet"`
+	Xmlns       string            `xml:"xmlns,attr"`
+	XmlnsMc     string            `xml:"xmlns:mc,attr"`
+	XmlnsX14ac  string            `xml:"xmlns:x14ac,attr"`
+	Ignorable   string            `xml:"mc:Ignorable,attr"`
+	NumFmts     []xmlNumFmt       `xml:"numFmts>numFmt"`
+	Fonts       []xmlStyleElement `xml:"fonts>font"`
+	Fills       []xmlStyleElement `xml:"fills>fill"`
+	Borders     []xmlStyleElement `xml:"borders>border"`
+	StyleXfs    []xmlXf           `xml:"cellStyleXfs>xf"`
+	CellXfs     []xmlXf           `xml:"cellXfs>xf"`
+	CellStyles  []xmlStyleElement `xml:"cellStyles>cellStyle"`
+	Dxfs        []xmlStyleElement `xml:"dxfs>dxf"`
+	TableStyles *xmlTableStyles   `xml:"tableStyles"`
+}
+
+// defaultStyles gives the formats that spreadsheet applications expect to
+// find in every workbook.
+func defaultStyles() *styleSheet {
+	var (
+		zero   = 0
+		styles = styleSheet{
+			Fonts: []xmlStyleElement{
+				{Inner: `<sz val="11"/><name val="Calibri"/><family val="2"/>`},
+			},
+			Fills: []xmlStyleElement{
+				{Inner: `<patternFill patternType="none"/>`},
+				{Inner: `<patternFill patternType="gray125"/>`},
+			},
+			Borders: []xmlStyleElement{
+				{Inner: `<left/><right/><top/><bottom/><diagonal/>`},
+			},
+			StyleXfs: []xmlXf{{}},
+			CellXfs:  []xmlXf{{Style: &zero}},
+			CellStyles: []xmlStyleElement{
+				{
+					Attrs: []xml.Attr{
+						{Name: xml.Name{Local: "name"}, Value: "Normal"},
+						{Name: xml.Name{Local: "xfId"}, Value: "0"},
+						{Name: xml.Name{Local: "builtinId"}, Value: "0"},
+					},
+				},
+			},
+		}
+	)
+	return &styles
+}
+
+// dateFormats gives the indices of the cell formats displaying numbers as
+// dates or times.
+func (s *styleSheet) dateFormats() map[int]bool {
+	dates := make(map[int]bool)
+	for i, x := range s.CellXfs {
+		if isDateFormat(x.NumFmt) {
+			dates[i] = true
+			continue
+		}
+		ix := slices.IndexFunc(s.NumFmts, func(n xmlNumFmt) bool {
+			return n.Id == x.NumFmt
+		})
+		if ix >= 0 && isDatePattern(s.NumFmts[ix].Code) {
+			dates[i] = true
+		}
+	}
+	return dates
+}
+
+// isDateFormat reports whether id is one of the built-in number formats for
+// dates and times.
+func isDateFormat(id int) bool {
+	return (id >= 14 && id <= 22) || (id >= 45 && id <= 47)
+}
+
+// isDatePattern reports whether a custom number format displays dates or
+// times. Texts between quotes, escaped characters and colors are ignored.
+func isDatePattern(code string) bool {
+	code, _, _ = strings.Cut(code, ";")
+	for i := 0; i < len(code); i++ {
+		switch c := code[i]; c {
+		case '"':
+			if n := strings.IndexByte(code[i+1:], '"'); n >= 0 {
+				i += n + 1
+			} else {
+				return false
+			}
+		case '\\', '_', '*':
+			i++
+		case '[':
+			n := strings.IndexByte(code[i+1:], ']')
+			if n < 0 {
+				return false
+			}
+			// elapsed times are given as [h], [mm] or [ss]
+			if inner := strings.ToLower(code[i+1 : i+1+n]); inner != "" && strings.Trim(inner, inner[:1]) == "" && strings.Contains("hms", inner[:1]) {
+				return true
+			}
+			i += n + 1
+		case 'y', 'Y', 'm', 'M', 'd', 'D', 'h', 'H', 's', 'S':
+			return true
+		}
+	}
+	return false
+}
+
+func (f *File) getStyles() *styleSheet {
+	if f.styles == nil {
+		f.styles = defaultStyles()
+	}
+	return f.styles
+}
+
+// merge adds the formats of other that are not yet defined. It gives the new
+// indices of the cell formats and of the differential formats of other.
+func (s *styleSheet) merge(other *styleSheet) (map[int]int, map[int]int) {
+	var (
+		numFmts = make(map[int]int)
+		fonts   = mergeElements(&s.Fonts, other.Fonts)
+		fills   = mergeElements(&s.Fills, other.Fills)
+		borders = mergeElements(&s.Borders, other.Borders)
+		dxfs    = mergeElements(&s.Dxfs, other.Dxfs)
+		xfs     = make(map[int]int)
+	)
+	for _, n := range other.NumFmts {
+		ix := slices.IndexFunc(s.NumFmts, func(x xmlNumFmt) bool {
+			return x.Code == n.Code
+		})
+		if ix >= 0 {
+			numFmts[n.Id] = s.NumFmts[ix].Id
+			continue
+		}
+		next := firstNumFmt
+		for _, x := range s.NumFmts {
+			next = max(next, x.Id+1)
+		}
+		numFmts[n.Id] = next
+		s.NumFmts = append(s.NumFmts, xmlNumFmt{Id: next, Code: n.Code})
+	}
+	for i, x := range other.CellXfs {
+		if n, ok := numFmts[x.NumFmt]; ok {
+			x.NumFmt = n
+		}
+		x.Font = fonts[x.Font]
+		x.Fill = fills[x.Fill]
+		x.Border = borders[x.Border]
+		if x.Style != nil {
+			// named styles are not merged: the format uses the normal style
+			zero := 0
+			x.Style = &zero
+		}
+		ix := slices.IndexFunc(s.CellXfs, x.equal)
+		if ix < 0 {
+			ix = len(s.CellXfs)
+			s.CellXfs = append(s.CellXfs, x)
+		}
+		xfs[i] = ix
+	}
+	s.mergeTableStyles(other, dxfs)
+	return xfs, dxfs
+}
+
+// mergeTableStyles adds the custom table styles of other whose names are not
+// yet used.
+func (s *styleSheet) mergeTableStyles(other *styleSheet, dxfs map[int]int) {
+	if other.TableStyles == nil {
+		return
+	}
+	if s.TableStyles == nil {
+		s.TableStyles = &xmlTableStyles{}
+	}
+	for _, t := range other.TableStyles.Styles {
+		exists := slices.ContainsFunc(s.TableStyles.Styles, func(x xmlTableStyleDef) bool {
+			return x.Name == t.Name
+		})
+		if exists {
+			continue
+		}
+		t.Elements = slices.Clone(t.Elements)
+		for i := range t.Elements {
+			t.Elements[i].Dxf = dxfs[t.Elements[i].Dxf]
+		}
+		s.TableStyles.Styles = append(s.TableStyles.Styles, t)
+	}
+	s.TableStyles.Count = len(s.TableStyles.Styles)
+}
+
+func mergeElements(list *[]xmlStyleElement, others []xmlStyleElement) map[int]int {
+	ix := make(map[int]int)
+	for i, e := range others {
+		x := slices.IndexFunc(*list, e.equal)
+		if x < 0 {
+			x = len(*list)
+			*list = append(*list, e)
+		}
+		ix[i] = x
+	}
+	return ix
+}
+
+// importSheet makes the shared strings and the formats used by a sheet read
+// from another workbook refer to the ones of f.
+func (f *File) importSheet(sh *Sheet) {
+	var (
+		other    = sh.owner
+		strs     = make(map[int]int)
+		xfs, dxf = f.getStyles().merge(other.getStyles())
+	)
+	for _, r := range sh.rows {
+		for _, c := range r.Cells {
+			if n, ok := xfs[c.style]; ok {
+				c.style = n
+			}
+			if c.Type != TypeSharedStr {
+				continue
+			}
+			x, _ := strconv.Atoi(c.raw)
+			n, ok := strs[x]
+			if !ok {
+				n = f.importString(other, x)
+				strs[x] = n
+			}
+			c.raw = strconv.Itoa(n)
+		}
+	}
+	for _, c := range sh.Columns {
+		if n, ok := xfs[c.style]; ok {
+			c.style = n
+		}
+	}
+	for _, cf := range sh.Conditionals {
+		for i := range cf.Rules {
+			x, err := strconv.Atoi(cf.Rules[i].Style)
+			if err != nil {
+				continue
+			}
+			if n, ok := dxf[x]; ok {
+				cf.Rules[i].Style = strconv.Itoa(n)
+			}
+		}
+	}
+}
+
+// importString adds the shared string of other at the given index to the
+// shared strings of f and gives its new index. Formatted strings are never
+// merged with existing ones.
+func (f *File) importString(other *File, index int) int {
+	if index < 0 || index >= len(other.sharedStrings) {
+		return index
+	}
+	var (
+		str        = other.sharedStrings[index]
+		runs, rich = other.sharedRuns[index]
+	)
+	if x := slices.Index(f.sharedStrings, str); x >= 0 && !rich && f.sharedRuns[x] == nil {
+		return x
+	}
+	ix := len(f.sharedStrings)
+	if rich {
+		if f.sharedRuns == nil {
+			f.sharedRuns = make(map[int]RichText)
+		}
+		f.sharedRuns[ix] = runs.clone()
+	}
+	f.sharedStrings = append(f.sharedStrings, str)
+	return ix
+}
+
+func (r *reader) readStyles(file *File) {
+	if r.invalid() {
+		return
+	}
+	name := r.fromBase(styleFile)
+	if !r.exists(name) {
+		// the styles are optional
+		return
+	}
+	raw, err := r.readBytes(name)
+	if err != nil {
+		r.err = err
+		return
+	}
+	var styles styleSheet
+	if err := unmarshalPart(name, raw, &styles); err != nil {
+		r.err = err
+		return
+	}
+	file.styles = &styles
+}
+
+func (z *writer) writeStyles(file *File) {
+	if z.invalid() {
+		return
+	}
+	root := *file.getStyles()
+	root.XMLName = xml.Name{}
+	root.Xmlns = typeMainUrl
+	root.XmlnsMc = "http://schemas.openxmlformats.org/markup-compatibility/2006"
+	root.XmlnsX14ac = "http://schemas.microsoft.com/office/spreadsheetml/2009/9/ac"
+	root.Ignorable = "x14ac"
+	z.encodeXML(z.createTarget(styleFile), &root)
+}
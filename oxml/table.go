@@ -0,0 +1,355 @@
+package oxml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	sax "github.com/midbel/codecs/xml"
+	"github.com/midbel/dockit/grid"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
+)
+
+const DefaultTableStyle = "TableStyleMedium2"
+
+var tableName = regexp.MustCompile(`^[\pL_\\][\pL\pN_.]*$`)
+
+type TableColumn struct {
+	Id   int
+	Name string
+}
+
+type TableStyle struct {
+	Name              string
+	ShowFirstColumn   bool
+	ShowLastColumn    bool
+	ShowRowStripes    bool
+	ShowColumnStripes bool
+}
+
+// Table is a range of a sheet with named columns (a ListObject). Names of the
+// table and of its columns are used by structured references in formulas.
+type Table struct {
+	Id          int
+	Name        string
+	DisplayName string
+	Ref         *layout.Range
+	Header      bool
+	Totals      bool
+	Columns     []TableColumn
+	Style       TableStyle
+}
+
+// Data gives the range of the table without its header and totals rows.
+func (t *Table) Data() *layout.Range {
+	rg := layout.NewRange(t.Ref.Starts, t.Ref.Ends)
+	if t.Header && rg.Starts.Line < rg.Ends.Line {
+		rg.Starts.Line++
+	}
+	if t.Totals && rg.Starts.Line < rg.Ends.Line {
+		rg.Ends.Line--
+	}
+	return rg
+}
+
+// Column gives the range of the data of the column with the given name. Names
+// of columns are case insensitive.
+func (t *Table) Column(name string) (*layout.Range, error) {
+	ix := slices.IndexFunc(t.Columns, func(c TableColumn) bool {
+		return strings.EqualFold(c.Name, name)
+	})
+	if ix < 0 {
+		return nil, fmt.Errorf("%s: column not found in table %s", name, t.Name)
+	}
+	rg := t.Data()
+	rg.Starts.Column += int64(ix)
+	rg.Ends.Column = rg.Starts.Column
+	return rg, nil
+}
+
+// AddTable creates a table on the given range. Names of the columns are taken
+// from the first row of the range.
+func (s *Sheet) AddTable(name string, rg *layout.Range, style string) (*Table, error) {
+	if s.IsLock() {
+		return nil, grid.ErrLock
+	}
+	if !tableName.MatchString(name) {
+		return nil, fmt.Errorf("%s: invalid table name", name)
+	}
+	if _, err := s.Table(name); err == nil {
+		return nil, fmt.Errorf("%s: table already exists", name)
+	}
+	if style == "" {
+		style = DefaultTableStyle
+	}
+	if !s.owner.hasTableStyle(style) {
+		return nil, fmt.Errorf("%s: unknown table style", style)
+	}
+	rg = rg.Normalize()
+	rg.Starts.Sheet = ""
+	rg.Ends.Sheet = ""
+
+	t := Table{
+		Name:        name,
+		DisplayName: name,
+		Ref:         rg,
+		Header:      true,
+		Style: TableStyle{
+			Name:           style,
+			ShowRowStripes: true,
+		},
+	}
+	seen := make(map[string]int)
+	for col := rg.Starts.Column; col <= rg.Ends.Column; col++ {
+		var (
+			ix  = int(col-rg.Starts.Column) + 1
+			str string
+		)
+		if c, err := s.Cell(layout.NewPosition(rg.Starts.Line, col)); err == nil && !value.IsBlank(c.Value()) {
+			str = c.Value().String()
+		}
+		if str == "" {
+			str = fmt.Sprintf("Column%d", ix)
+		}
+		key := strings.ToLower(str)
+		if n := seen[key]; n > 0 {
+			str = fmt.Sprintf("%s%d", str, n+1)
+		}
+		seen[key]++
+		t.Columns = append(t.Columns, TableColumn{
+			Id:   ix,
+			Name: str,
+		})
+	}
+	s.Tables = append(s.Tables, &t)
+	return &t, nil
+}
+
+func (s *Sheet) Table(name string) (*Table, error) {
+	ix := slices.IndexFunc(s.Tables, func(t *Table) bool {
+		return strings.EqualFold(t.Name, name)
+	})
+	if ix < 0 {
+		return nil, fmt.Errorf("%s: table not found", name)
+	}
+	return s.Tables[ix], nil
+}
+
+// TableInfo gives the table with the given name to resolve the structured
+// references of formulas.
+func (f *File) TableInfo(name string) (grid.TableInfo, error) {
+	t, sh, err := f.Table(name)
+	if err != nil {
+		return grid.TableInfo{}, err
+	}
+	info := grid.TableInfo{
+		Sheet:  sh.Name(),
+		Ref:    layout.NewRange(t.Ref.Starts, t.Ref.Ends),
+		Header: t.Header,
+		Totals: t.Totals,
+	}
+	for _, c := range t.Columns {
+		info.Columns = append(info.Columns, c.Name)
+	}
+	return info, nil
+}
+
+// Table searches a table by its name in all the sheets of the file.
+func (f *File) Table(name string) (*Table, *Sheet, error) {
+	for _, s := range f.sheets {
+		if t, err := s.Table(name); err == nil {
+			return t, s, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%s: table not found", name)
+}
+
+type xmlTable struct {
+	XMLName     xml.Name  `xml:"table"`
+	Xmlns       string    `xml:"xmlns,attr,omitempty"`
+	Id          int       `xml:"id,attr"`
+	Name        string    `xml:"name,attr"`
+	DisplayName string    `xml:"displayName,attr"`
+	Ref         string    `xml:"ref,attr"`
+	HeaderCount *int      `xml:"headerRowCount,attr"`
+	TotalsCount int       `xml:"totalsRowCount,attr,omitempty"`
+	TotalsShown int       `xml:"totalsRowShown,attr"`
+	AutoFilter  *xmlRange `xml:"autoFilter"`
+	Columns     struct {
+		Count int              `xml:"count,attr"`
+		List  []xmlTableColumn `xml:"tableColumn"`
+	} `xml:"tableColumns"`
+	Style *xmlTableStyle `xml:"tableStyleInfo"`
+}
+
+type xmlRange struct {
+	Ref string `xml:"ref,attr"`
+}
+
+type xmlTableColumn struct {
+	Id   int    `xml:"id,attr"`
+	Name string `xml:"name,attr"`
+}
+
+type xmlTableStyle struct {
+	Name              string `xml:"name,attr"`
+	ShowFirstColumn   int    `xml:"showFirstColumn,attr"`
+	ShowLastColumn    int    `xml:"showLastColumn,attr"`
+	ShowRowStripes    int    `xml:"showRowStripes,attr"`
+	ShowColumnStripes int    `xml:"showColumnStripes,attr"`
+}
+
+func (t *Table) toXML() xmlTable {
+	x := xmlTable{
+		Xmlns:       typeMainUrl,
+		Id:          t.Id,
+		Name:        t.Name,
+		DisplayName: t.DisplayName,
+		Ref:         t.Ref.String(),
+	}
+	if t.Totals {
+		x.TotalsCount = 1
+	}
+	if t.Header {
+		rg := layout.NewRange(t.Ref.Starts, t.Ref.Ends)
+		if t.Totals {
+			// the totals row is not filtered
+			rg.Ends.Line--
+		}
+		x.AutoFilter = &xmlRange{
+			Ref: rg.String(),
+		}
+	} else {
+		var count int
+		x.HeaderCount = &count
+	}
+	x.Columns.Count = len(t.Columns)
+	for _, c := range t.Columns {
+		x.Columns.List = append(x.Columns.List, xmlTableColumn{
+			Id:   c.Id,
+			Name: c.Name,
+		})
+	}
+	if t.Style.Name != "" {
+		x.Style = &xmlTableStyle{
+			Name:              t.Style.Name,
+			ShowFirstColumn:   boolToInt(t.Style.ShowFirstColumn),
+			ShowLastColumn:    boolToInt(t.Style.ShowLastColumn),
+			ShowRowStripes:    boolToInt(t.Style.ShowRowStripes),
+			ShowColumnStripes: boolToInt(t.Style.ShowColumnStripes),
+		}
+	}
+	return x
+}
+
+func tableFromXML(x xmlTable) *Table {
+	t := Table{
+		Id:          x.Id,
+		Name:        x.Name,
+		DisplayName: x.DisplayName,
+		Header:      x.HeaderCount == nil || *x.HeaderCount > 0,
+		Totals:      x.TotalsCount > 0,
+	}
+	if list := parseRef(x.Ref); len(list) > 0 {
+		t.Ref = list[0]
+	}
+	for _, c := range x.Columns.List {
+		t.Columns = append(t.Columns, TableColumn{
+			Id:   c.Id,
+			Name: c.Name,
+		})
+	}
+	if x.Style != nil {
+		t.Style = TableStyle{
+			Name:              x.Style.Name,
+			ShowFirstColumn:   x.Style.ShowFirstColumn == 1,
+			ShowLastColumn:    x.Style.ShowLastColumn == 1,
+			ShowRowStripes:    x.Style.ShowRowStripes == 1,
+			ShowColumnStripes: x.Style.ShowColumnStripes == 1,
+		}
+	}
+	return &t
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func relId(ix int) string {
+	return "rId" + strconv.Itoa(ix+1)
+}
+
+func (r *sheetReader) onTablePart(rs *sax.Reader, el sax.E) error {
+	for _, a := range el.Attrs {
+		if a.Name == "id" {
+			r.tableParts = append(r.tableParts, a.Value)
+		}
+	}
+	return nil
+}
+
+func (r *reader) readTables(sheet *Sheet, addr string, ids []string) {
+	if r.invalid() || len(ids) == 0 {
+		return
+	}
+	for _, id := range ids {
+		target, err := r.resolveRelation(addr, id)
+		if err != nil {
+			r.err = err
+			return
+		}
+		var x xmlTable
+		if err := r.decodeXML(target, &x); err != nil {
+			return
+		}
+		sheet.Tables = append(sheet.Tables, tableFromXML(x))
+	}
+}
+
+// writeTables writes the tables of the sheet and gives the relations between
+// the sheet and its tables.
+func (z *writer) writeTables(sheet *Sheet) []xmlRelation {
+	if z.invalid() || len(sheet.Tables) == 0 {
+		return nil
+	}
+	var list []xmlRelation
+	for i, t := range sheet.Tables {
+		z.lastTableId++
+		t.Id = z.lastTableId
+
+		name := fmt.Sprintf("table%d.xml", t.Id)
+		x := t.toXML()
+		z.encodeXML(z.createTarget("tables", name), &x)
+
+		rx := xmlRelation{
+			Id:     relId(i),
+			Type:   typeTableUrl,
+			Target: "../tables/" + name,
+		}
+		list = append(list, rx)
+	}
+	return list
+}
+
+func (w *sheetWriter) writeTableParts(sheet *Sheet) {
+	if len(sheet.Tables) == 0 {
+		return
+	}
+	name := sax.LocalName("tableParts")
+	w.writer.Open(name, []sax.A{
+		createAttr("count", strconv.Itoa(len(sheet.Tables))),
+	})
+	for i := range sheet.Tables {
+		w.writer.Empty(sax.LocalName("tablePart"), []sax.A{
+			{QName: sax.QualifiedName("id", "r"), Value: relId(i)},
+		})
+	}
+	w.writer.Close(name)
+}
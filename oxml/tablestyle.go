@@ -0,0 +1,243 @@
+package oxml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/midbel/dockit/grid"
+)
+
+// elements of a table that can be formatted by a table style
+const (
+	TableWhole              = "wholeTable"
+	TableHeaderRow          = "headerRow"
+	TableTotalRow           = "totalRow"
+	TableFirstColumn        = "firstColumn"
+	TableLastColumn         = "lastColumn"
+	TableFirstRowStripe     = "firstRowStripe"
+	TableSecondRowStripe    = "secondRowStripe"
+	TableFirstColumnStripe  = "firstColumnStripe"
+	TableSecondColumnStripe = "secondColumnStripe"
+)
+
+var tableElements = []string{
+	TableWhole,
+	TableHeaderRow,
+	TableTotalRow,
+	TableFirstColumn,
+	TableLastColumn,
+	TableFirstRowStripe,
+	TableSecondRowStripe,
+	TableFirstColumnStripe,
+	TableSecondColumnStripe,
+}
+
+// number of variants of the built-in table styles
+var builtinTableStyles = map[string]int{
+	"TableStyleLight":  21,
+	"TableStyleMedium": 28,
+	"TableStyleDark":   11,
+}
+
+// IsBuiltinTableStyle reports whether name is one of the table styles known by
+// spreadsheet applications, eg TableStyleMedium2.
+func IsBuiltinTableStyle(name string) bool {
+	for prefix, count := range builtinTableStyles {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(rest)
+		return err == nil && n >= 1 && n <= count && rest[0] != '0'
+	}
+	return false
+}
+
+// DiffStyle is a differential format: it gives only the properties of the
+// format of a cell that are changed by a table style or a conditional format.
+// Colors are given in hexadecimal as RRGGBB or AARRGGBB.
+type DiffStyle struct {
+	Bold      bool
+	Italic    bool
+	Underline bool
+	Color     string
+	Fill      string
+	Border    string
+}
+
+// ParseDiffStyle gives the differential format described by a list of
+// properties separated by blanks: bold, italic, underline, color=#RRGGBB,
+// fill=#RRGGBB and border=#RRGGBB.
+func ParseDiffStyle(str string) (DiffStyle, error) {
+	var ds DiffStyle
+	for _, prop := range strings.Fields(str) {
+		key, val, _ := strings.Cut(prop, "=")
+		switch strings.ToLower(key) {
+		case "bold":
+			ds.Bold = true
+		case "italic":
+			ds.Italic = true
+		case "underline":
+			ds.Underline = true
+		case "color":
+			ds.Color = val
+		case "fill":
+			ds.Fill = val
+		case "border":
+			ds.Border = val
+		default:
+			return ds, fmt.Errorf("%s: unknown style property", key)
+		}
+	}
+	return ds, nil
+}
+
+func (d DiffStyle) normalize() (DiffStyle, error) {
+	var err error
+	for _, c := range []*string{&d.Color, &d.Fill, &d.Border} {
+		if *c == "" {
+			continue
+		}
+		if *c, err = applyTint(strings.TrimPrefix(*c, "#"), 0); err != nil {
+			return d, err
+		}
+	}
+	return d, nil
+}
+
+func (d DiffStyle) toXML() xmlStyleElement {
+	var str strings.Builder
+	if d.Bold || d.Italic || d.Underline || d.Color != "" {
+		str.WriteString("<font>")
+		if d.Bold {
+			str.WriteString("<b/>")
+		}
+		if d.Italic {
+			str.WriteString("<i/>")
+		}
+		if d.Underline {
+			str.WriteString("<u/>")
+		}
+		if d.Color != "" {
+			fmt.Fprintf(&str, `<color rgb="%s"/>`, d.Color)
+		}
+		str.WriteString("</font>")
+	}
+	if d.Fill != "" {
+		// differential formats use the background color of solid fills
+		fmt.Fprintf(&str, `<fill><patternFill patternType="solid"><fgColor rgb="%[1]s"/><bgColor rgb="%[1]s"/></patternFill></fill>`, d.Fill)
+	}
+	if d.Border != "" {
+		str.WriteString("<border>")
+		for _, side := range []string{"left", "right", "top", "bottom"} {
+			fmt.Fprintf(&str, `<%[1]s style="thin"><color rgb="%[2]s"/></%[1]s>`, side, d.Border)
+		}
+		str.WriteString("</border>")
+	}
+	return xmlStyleElement{
+		Inner: str.String(),
+	}
+}
+
+// AddDiffStyle adds a differential format to the styles of the workbook and
+// gives its index. The index is the one used by the rules of conditional
+// formats to refer to their format.
+func (f *File) AddDiffStyle(style DiffStyle) (int, error) {
+	style, err := style.normalize()
+	if err != nil {
+		return 0, err
+	}
+	styles := f.getStyles()
+	ix := mergeElements(&styles.Dxfs, []xmlStyleElement{style.toXML()})
+	return ix[0], nil
+}
+
+// DefineTableStyle adds a custom table style to the workbook or replaces the
+// one with the same name. Elements are the parts of the table (see
+// TableHeaderRow, TableFirstRowStripe,...) with their format.
+func (f *File) DefineTableStyle(name string, elements map[string]DiffStyle) error {
+	if f.locked {
+		return grid.ErrLock
+	}
+	if name == "" || IsBuiltinTableStyle(name) {
+		return fmt.Errorf("%s: invalid name for table style", name)
+	}
+	for el := range elements {
+		if !slices.Contains(tableElements, el) {
+			return fmt.Errorf("%s: unknown table element", el)
+		}
+	}
+	def := xmlTableStyleDef{
+		Name: name,
+		Attrs: []xml.Attr{
+			{Name: xml.Name{Local: "pivot"}, Value: "0"},
+		},
+	}
+	for _, el := range tableElements {
+		ds, ok := elements[el]
+		if !ok {
+			continue
+		}
+		ix, err := f.AddDiffStyle(ds)
+		if err != nil {
+			return err
+		}
+		def.Elements = append(def.Elements, xmlTableStyleElement{
+			Type: el,
+			Dxf:  ix,
+		})
+	}
+	def.Count = len(def.Elements)
+
+	styles := f.getStyles()
+	if styles.TableStyles == nil {
+		styles.TableStyles = &xmlTableStyles{}
+	}
+	list := styles.TableStyles.Styles
+	if ix := slices.IndexFunc(list, func(s xmlTableStyleDef) bool {
+		return s.Name == name
+	}); ix >= 0 {
+		list[ix] = def
+	} else {
+		list = append(list, def)
+	}
+	styles.TableStyles.Styles = list
+	styles.TableStyles.Count = len(list)
+	return nil
+}
+
+// hasTableStyle reports whether name is a built-in table style or a custom
+// table style defined in the workbook.
+func (f *File) hasTableStyle(name string) bool {
+	if IsBuiltinTableStyle(name) {
+		return true
+	}
+	if f == nil || f.styles == nil || f.styles.TableStyles == nil {
+		return false
+	}
+	return slices.ContainsFunc(f.styles.TableStyles.Styles, func(s xmlTableStyleDef) bool {
+		return s.Name == name
+	})
+}
+
+type xmlTableStyles struct {
+	Count        int                `xml:"count,attr"`
+	DefaultTable string             `xml:"defaultTableStyle,attr,omitempty"`
+	DefaultPivot string             `xml:"defaultPivotStyle,attr,omitempty"`
+	Styles       []xmlTableStyleDef `xml:"tableStyle"`
+}
+
+type xmlTableStyleDef struct {
+	Name     string                 `xml:"name,attr"`
+	Count    int                    `xml:"count,attr"`
+	Attrs    []xml.Attr             `xml:",any,attr"`
+	Elements []xmlTableStyleElement `xml:"tableStyleElement"`
+}
+
+type xmlTableStyleElement struct {
+	Type string `xml:"type,attr"`
+	Dxf  int    `xml:"dxfId,attr"`
+}
@@ -0,0 +1,276 @@
+package oxml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	typeThemeUrl = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/theme"
+	mimeTheme    = "application/vnd.openxmlformats-officedocument.theme+xml"
+	themeFile    = "theme/theme1.xml"
+)
+
+// Theme holds the colors of the theme of a workbook. Colors are given as ARGB
+// values in the order used by the theme attribute of the colors found in the
+// styles: light 1, dark 1, light 2, dark 2, accent 1 to 6, hyperlink and
+// followed hyperlink.
+type Theme struct {
+	Name   string
+	Colors []string
+
+	raw []byte
+}
+
+// DefaultTheme gives the colors of the default theme of Excel. It is used when
+// a workbook has no theme.
+func DefaultTheme() *Theme {
+	return &Theme{
+		Name: "Office Theme",
+		Colors: []string{
+			"FFFFFFFF",
+			"FF000000",
+			"FFE7E6E6",
+			"FF44546A",
+			"FF4472C4",
+			"FFED7D31",
+			"FFA5A5A5",
+			"FFFFC000",
+			"FF5B9BD5",
+			"FF70AD47",
+			"FF0563C1",
+			"FF954F72",
+		},
+	}
+}
+
+// Color gives the ARGB value of the color of the theme at the given index once
+// lightened or darkened by tint. tint is between -1 (black) and 1 (white).
+func (t *Theme) Color(index int, tint float64) (string, error) {
+	if index < 0 || index >= len(t.Colors) {
+		return "", fmt.Errorf("%d: theme color not defined", index)
+	}
+	return applyTint(t.Colors[index], tint)
+}
+
+// Resolve gives the ARGB value of a color given by its index in the theme and
+// its tint as they are written in the styles.
+func (t *Theme) Resolve(theme, tint string) (string, error) {
+	index, err := strconv.Atoi(theme)
+	if err != nil {
+		return "", fmt.Errorf("%s: invalid theme color", theme)
+	}
+	var x float64
+	if tint != "" {
+		x, err = strconv.ParseFloat(tint, 64)
+		if err != nil {
+			return "", fmt.Errorf("%s: invalid tint", tint)
+		}
+	}
+	return t.Color(index, x)
+}
+
+// Theme gives the theme of the workbook or the default theme when the
+// workbook has none.
+func (f *File) Theme() *Theme {
+	if f.theme == nil {
+		return DefaultTheme()
+	}
+	return f.theme
+}
+
+type xmlThemeColor struct {
+	Rgb *struct {
+		Value string `xml:"val,attr"`
+	} `xml:"srgbClr"`
+	Sys *struct {
+		Value string `xml:"lastClr,attr"`
+	} `xml:"sysClr"`
+}
+
+func (c xmlThemeColor) argb() string {
+	var str string
+	switch {
+	case c.Rgb != nil:
+		str = c.Rgb.Value
+	case c.Sys != nil:
+		str = c.Sys.Value
+	}
+	if len(str) == 6 {
+		str = "FF" + str
+	}
+	return strings.ToUpper(str)
+}
+
+type xmlTheme struct {
+	XMLName xml.Name `xml:"theme"`
+	Name    string   `xml:"name,attr"`
+	Scheme  struct {
+		Dark1    xmlThemeColor `xml:"dk1"`
+		Light1   xmlThemeColor `xml:"lt1"`
+		Dark2    xmlThemeColor `xml:"dk2"`
+		Light2   xmlThemeColor `xml:"lt2"`
+		Accent1  xmlThemeColor `xml:"accent1"`
+		Accent2  xmlThemeColor `xml:"accent2"`
+		Accent3  xmlThemeColor `xml:"accent3"`
+		Accent4  xmlThemeColor `xml:"accent4"`
+		Accent5  xmlThemeColor `xml:"accent5"`
+		Accent6  xmlThemeColor `xml:"accent6"`
+		Link     xmlThemeColor `xml:"hlink"`
+		Followed xmlThemeColor `xml:"folHlink"`
+	} `xml:"themeElements>clrScheme"`
+}
+
+func parseTheme(raw []byte) (*Theme, error) {
+	var root xmlTheme
+	if err := xml.Unmarshal(raw, &root); err != nil {
+		return nil, err
+	}
+	s := root.Scheme
+	theme := Theme{
+		Name: root.Name,
+		raw:  raw,
+	}
+	// the first two pairs are swapped compared to the order of the scheme
+	for _, c := range []xmlThemeColor{
+		s.Light1, s.Dark1, s.Light2, s.Dark2,
+		s.Accent1, s.Accent2, s.Accent3, s.Accent4, s.Accent5, s.Accent6,
+		s.Link, s.Followed,
+	} {
+		theme.Colors = append(theme.Colors, c.argb())
+	}
+	return &theme, nil
+}
+
+// applyTint changes the luminance of a color as described in the section
+// 18.8.19 of ECMA-376.
+func applyTint(argb string, tint float64) (string, error) {
+	if len(argb) == 6 {
+		argb = "FF" + argb
+	}
+	n, err := strconv.ParseUint(argb, 16, 32)
+	if err != nil || len(argb) != 8 {
+		return "", fmt.Errorf("%s: invalid color", argb)
+	}
+	if tint == 0 {
+		return strings.ToUpper(argb), nil
+	}
+	var (
+		alpha = uint8(n >> 24)
+		red   = float64(uint8(n>>16)) / 255
+		green = float64(uint8(n>>8)) / 255
+		blue  = float64(uint8(n)) / 255
+	)
+	h, l, s := rgbToHls(red, green, blue)
+	if tint < 0 {
+		l = l * (1 + tint)
+	} else {
+		l = l*(1-tint) + tint
+	}
+	red, green, blue = hlsToRgb(h, l, s)
+	return fmt.Sprintf("%02X%02X%02X%02X", alpha, toByte(red), toByte(green), toByte(blue)), nil
+}
+
+func toByte(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
+
+func rgbToHls(r, g, b float64) (float64, float64, float64) {
+	var (
+		hi = max(r, g, b)
+		lo = min(r, g, b)
+		l  = (hi + lo) / 2
+	)
+	if hi == lo {
+		return 0, l, 0
+	}
+	var (
+		d = hi - lo
+		s float64
+		h float64
+	)
+	if l > 0.5 {
+		s = d / (2 - hi - lo)
+	} else {
+		s = d / (hi + lo)
+	}
+	switch hi {
+	case r:
+		h = (g - b) / d
+		if g < b {
+			h += 6
+		}
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h / 6, l, s
+}
+
+func hlsToRgb(h, l, s float64) (float64, float64, float64) {
+	if s == 0 {
+		return l, l, l
+	}
+	var q float64
+	if l < 0.5 {
+		q = l * (1 + s)
+	} else {
+		q = l + s - l*s
+	}
+	p := 2*l - q
+	return hueToRgb(p, q, h+1.0/3), hueToRgb(p, q, h), hueToRgb(p, q, h-1.0/3)
+}
+
+func hueToRgb(p, q, t float64) float64 {
+	if t < 0 {
+		t++
+	}
+	if t > 1 {
+		t--
+	}
+	switch {
+	case t < 1.0/6:
+		return p + (q-p)*6*t
+	case t < 1.0/2:
+		return q
+	case t < 2.0/3:
+		return p + (q-p)*(2.0/3-t)*6
+	default:
+		return p
+	}
+}
+
+func (r *reader) readTheme(file *File) {
+	if r.invalid() {
+		return
+	}
+	name := r.fromBase(themeFile)
+	if !r.exists(name) {
+		// the theme is optional
+		return
+	}
+	raw, err := r.readBytes(name)
+	if err != nil {
+		r.err = err
+		return
+	}
+	if file.theme, err = parseTheme(raw); err != nil {
+		r.err = partError(name, -1, err)
+	}
+}
+
+func (z *writer) writeTheme(file *File) {
+	if z.invalid() || file.theme == nil || len(file.theme.raw) == 0 {
+		return
+	}
+	w, err := z.create(z.createTarget(themeFile))
+	if err != nil {
+		z.err = err
+		return
+	}
+	_, z.err = w.Write(file.theme.raw)
+}
@@ -14,7 +14,20 @@ func typeFromValue(val value.Value) string {
 		return TypeBool
 	case value.TypeDate:
 		return TypeDate
+	case value.TypeError:
+		return TypeError
 	default:
 		return TypeInlineStr
 	}
 }
+
+// rawFromValue gives the value of a cell as it is written in the file.
+func rawFromValue(val value.Value) string {
+	if val.Type() == value.TypeBool {
+		if value.True(val) {
+			return "1"
+		}
+		return "0"
+	}
+	return val.String()
+}
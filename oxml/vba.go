@@ -0,0 +1,68 @@
+package oxml
+
+import (
+	"slices"
+
+	sax "github.com/midbel/codecs/xml"
+)
+
+const (
+	typeVbaUrl        = "http://schemas.microsoft.com/office/2006/relationships/vbaProject"
+	mimeVba           = "application/vnd.ms-office.vbaProject"
+	mimeMacroWorkbook = "application/vnd.ms-excel.sheet.macroEnabled.main+xml"
+	vbaFile           = "vbaProject.bin"
+)
+
+// HasMacros reports whether the workbook comes with a VBA project. Such
+// workbooks should be saved in files with the .xlsm extension.
+func (f *File) HasMacros() bool {
+	return len(f.vba) > 0
+}
+
+// RemoveMacros drops the VBA project of the workbook so that it can be saved
+// in a file with the .xlsx extension.
+func (f *File) RemoveMacros() {
+	f.vba = nil
+}
+
+// readVbaProject keeps the VBA project of a macro enabled workbook. The
+// project is not decoded: it is written back as it was read.
+func (r *reader) readVbaProject(file *File) {
+	if r.invalid() {
+		return
+	}
+	relations := r.readRelationsForSheets()
+	ix := slices.IndexFunc(relations, func(r xmlRelation) bool {
+		return r.Type == typeVbaUrl
+	})
+	if ix < 0 {
+		return
+	}
+	file.vba, r.err = r.readBytes(r.fromBase(relations[ix].Target))
+}
+
+func (z *writer) writeVbaProject(file *File) {
+	if z.invalid() || !file.HasMacros() {
+		return
+	}
+	w, err := z.create(z.createTarget(vbaFile))
+	if err != nil {
+		z.err = err
+		return
+	}
+	_, z.err = w.Write(file.vba)
+}
+
+func (r *sheetReader) onSheetProperties(_ *sax.Reader, el sax.E) error {
+	r.sheet.codeName = el.GetAttributeValue("codeName")
+	return nil
+}
+
+func (w *sheetWriter) writeSheetProperties(sheet *Sheet) {
+	if sheet.codeName == "" {
+		return
+	}
+	w.writer.Empty(sax.LocalName("sheetPr"), []sax.A{
+		createAttr("codeName", sheet.codeName),
+	})
+}
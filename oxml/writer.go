@@ -6,13 +6,14 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"maps"
 	"slices"
 	"strconv"
 	"strings"
 
 	sax "github.com/midbel/codecs/xml"
-	"github.com/midbel/dockit/formula/format"
-	"github.com/midbel/dockit/formula/parse"
+	"github.com/midbel/dockit/layout"
+	"github.com/midbel/dockit/value"
 )
 
 const startIx = 1000
@@ -20,35 +21,78 @@ const startIx = 1000
 type writer struct {
 	base   string
 	writer *zip.Writer
-
-	lastUsedId int
-	err        error
+	method uint16
+
+	lastUsedId    int
+	lastTableId   int
+	lastDrawingId int
+	lastImageId   int
+	lastChartId   int
+	lastPivotId   int
+	drawings      []string
+	charts        []string
+	pivotTables   []string
+	pivotCaches   []string
+	pivotRecords  []string
+	media         map[string]struct{}
+	strings       *stringPool
+	fullCalc      bool
+	calcChain     bool
+	err           error
 }
 
-func writeFile(w io.Writer) (*writer, error) {
+func writeFile(w io.Writer, level int) (*writer, error) {
 	z := writer{
 		base:       wbBaseDir,
 		writer:     zip.NewWriter(w),
 		lastUsedId: startIx,
+		media:      make(map[string]struct{}),
 	}
+	z.setCompression(level)
+	return &z, nil
+}
+
+// setCompression changes the compression of the parts added afterwards to the
+// archive. Parts are stored without compression with flate.NoCompression.
+func (z *writer) setCompression(level int) {
+	if level == flate.NoCompression {
+		z.method = zip.Store
+		return
+	}
+	z.method = zip.Deflate
 	z.writer.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
-		return flate.NewWriter(out, flate.BestCompression)
+		return flate.NewWriter(out, level)
 	})
-	return &z, nil
+}
+
+// create adds a part to the archive.
+func (z *writer) create(name string) (io.Writer, error) {
+	hdr := zip.FileHeader{
+		Name:   name,
+		Method: z.method,
+	}
+	return z.writer.CreateHeader(&hdr)
 }
 
 func (z *writer) WriteFile(file *File) error {
-	for _, s := range file.sheets {
-		z.writeWorksheet(s)
+	file.selectActive()
+	z.strings = newStringPool(file)
+	z.recalc(file)
+	z.writePivotCaches(file)
+	for i, s := range file.sheets {
+		z.writeWorksheet(file, s, sheetFile(i))
 		if z.invalid() {
 			return z.err
 		}
 	}
 	z.writeWorkbook(file)
-	z.writeSharedStrings(file)
+	z.writeCalcChain(file)
+	z.writeSharedStrings()
+	z.writeTheme(file)
+	z.writeVbaProject(file)
 	z.writeRelationForSheets(file)
 	z.writeRelations()
-	z.writeStyles()
+	z.writeStyles(file)
 	z.writeContentTypes(file)
 	return z.err
 }
@@ -95,57 +139,112 @@ func (z *writer) writeContentTypes(file *File) {
 				PartName:    "/xl/workbook.xml",
 				ContentType: mimeWorkbook,
 			},
-			{
-				PartName:    "/xl/sharedStrings.xml",
-				ContentType: mimeSharedString,
-			},
 			{
 				PartName:    "/xl/styles.xml",
 				ContentType: mimeStyle,
 			},
 		},
 	}
-	for _, s := range file.sheets {
-		addr := z.createTarget("worksheets", fmt.Sprintf("%s.xml", s.Name()))
+	if file.HasMacros() {
+		root.Overrides[0].ContentType = mimeMacroWorkbook
+		xd := xmlDefault{
+			Extension:   "bin",
+			ContentType: mimeVba,
+		}
+		root.Defaults = append(root.Defaults, xd)
+	}
+	if !z.strings.empty() {
+		ox := xmlOverride{
+			PartName:    "/xl/sharedStrings.xml",
+			ContentType: mimeSharedString,
+		}
+		root.Overrides = append(root.Overrides, ox)
+	}
+	if file.theme != nil && len(file.theme.raw) > 0 {
 		ox := xmlOverride{
-			PartName:    "/" + z.fromBase(addr),
+			PartName:    "/" + z.createTarget(themeFile),
+			ContentType: mimeTheme,
+		}
+		root.Overrides = append(root.Overrides, ox)
+	}
+	if z.calcChain {
+		ox := xmlOverride{
+			PartName:    "/" + z.createTarget(calcChainFile),
+			ContentType: mimeCalcChain,
+		}
+		root.Overrides = append(root.Overrides, ox)
+	}
+	for i, s := range file.sheets {
+		addr := z.createTarget("worksheets", sheetFile(i))
+		ox := xmlOverride{
+			PartName:    "/" + addr,
 			ContentType: mimeWorksheet,
 		}
 		root.Overrides = append(root.Overrides, ox)
+		for _, t := range s.Tables {
+			ox := xmlOverride{
+				PartName:    "/" + z.createTarget("tables", fmt.Sprintf("table%d.xml", t.Id)),
+				ContentType: mimeTable,
+			}
+			root.Overrides = append(root.Overrides, ox)
+		}
 	}
-	z.encodeXML("[Content_Types].xml", &root)
-}
-
-func (z *writer) writeStyles() {
-	if z.invalid() {
-		return
+	for _, addr := range z.drawings {
+		ox := xmlOverride{
+			PartName:    "/" + addr,
+			ContentType: mimeDrawing,
+		}
+		root.Overrides = append(root.Overrides, ox)
 	}
-	root := struct {
-		XMLName xml.Name `xml:"styleSheet"`
-		Xmlns   string   `xml:"xmlns,attr"`
-	}{
-		Xmlns: typeMainUrl,
+	for _, addr := range z.charts {
+		ox := xmlOverride{
+			PartName:    "/" + addr,
+			ContentType: mimeChart,
+		}
+		root.Overrides = append(root.Overrides, ox)
+	}
+	for _, addr := range z.pivotTables {
+		ox := xmlOverride{
+			PartName:    "/" + addr,
+			ContentType: mimePivotTable,
+		}
+		root.Overrides = append(root.Overrides, ox)
+	}
+	for _, addr := range z.pivotCaches {
+		ox := xmlOverride{
+			PartName:    "/" + addr,
+			ContentType: mimePivotCache,
+		}
+		root.Overrides = append(root.Overrides, ox)
+	}
+	for _, addr := range z.pivotRecords {
+		ox := xmlOverride{
+			PartName:    "/" + addr,
+			ContentType: mimePivotRecords,
+		}
+		root.Overrides = append(root.Overrides, ox)
+	}
+	for _, ext := range slices.Sorted(maps.Keys(z.media)) {
+		xd := xmlDefault{
+			Extension:   ext,
+			ContentType: imageTypes[ext],
+		}
+		root.Defaults = append(root.Defaults, xd)
 	}
-	z.encodeXML("styles.xml", root)
+	z.encodeXML("[Content_Types].xml", &root)
 }
 
-func (z *writer) writeSharedStrings(file *File) {
-	if len(file.sharedStrings) == 0 {
-		return
-	}
-	if z.invalid() {
+func (z *writer) writeSharedStrings() {
+	if z.invalid() || z.strings.empty() {
 		return
 	}
 	root := xmlSharedStrings{
 		Xmlns:     typeMainUrl,
-		Count:     len(file.sharedStrings),
-		UniqCount: len(file.sharedStrings),
+		Count:     z.strings.count,
+		UniqCount: len(z.strings.values),
 	}
-	for _, s := range file.sharedStrings {
-		shared := xmlSharedString{
-			Value: s,
-		}
-		root.Values = append(root.Values, shared)
+	for i, s := range z.strings.values {
+		root.Values = append(root.Values, createRichText(s, z.strings.runs[i]))
 	}
 	z.encodeXML("xl/sharedStrings.xml", &root)
 }
@@ -175,8 +274,8 @@ func (z *writer) writeRelationForSheets(file *File) {
 	root := xmlRelations{
 		Xmlns: "http://schemas.openxmlformats.org/package/2006/relationships",
 	}
-	for _, sh := range file.sheets {
-		addr := z.createTarget("worksheets", fmt.Sprintf("%s.xml", sh.Name()))
+	for i, sh := range file.sheets {
+		addr := z.createTarget("worksheets", sheetFile(i))
 		rx := xmlRelation{
 			Id:     sh.Id,
 			Type:   typeSheetUrl,
@@ -184,7 +283,20 @@ func (z *writer) writeRelationForSheets(file *File) {
 		}
 		root.Relations = append(root.Relations, rx)
 	}
-	if len(file.sharedStrings) > 0 {
+	for _, c := range file.pivotCaches {
+		rx := xmlRelation{
+			Id:     c.relId,
+			Type:   typePivotCacheUrl,
+			Target: "pivotCache/" + c.name,
+		}
+		root.Relations = append(root.Relations, rx)
+	}
+	root.Relations = append(root.Relations, xmlRelation{
+		Id:     z.createFileID(),
+		Type:   typeStyleUrl,
+		Target: styleFile,
+	})
+	if !z.strings.empty() {
 		rx := xmlRelation{
 			Id:     z.createFileID(),
 			Type:   typeSharedUrl,
@@ -192,16 +304,47 @@ func (z *writer) writeRelationForSheets(file *File) {
 		}
 		root.Relations = append(root.Relations, rx)
 	}
+	if file.theme != nil && len(file.theme.raw) > 0 {
+		rx := xmlRelation{
+			Id:     z.createFileID(),
+			Type:   typeThemeUrl,
+			Target: themeFile,
+		}
+		root.Relations = append(root.Relations, rx)
+	}
+	if z.calcChain {
+		rx := xmlRelation{
+			Id:     z.createFileID(),
+			Type:   typeCalcChainUrl,
+			Target: calcChainFile,
+		}
+		root.Relations = append(root.Relations, rx)
+	}
+	if file.HasMacros() {
+		rx := xmlRelation{
+			Id:     z.createFileID(),
+			Type:   typeVbaUrl,
+			Target: vbaFile,
+		}
+		root.Relations = append(root.Relations, rx)
+	}
 	addr := z.createTarget("_rels", "workbook.xml.rels")
 	z.encodeXML(addr, &root)
 }
 
-func (z *writer) writeWorksheet(sheet *Sheet) {
+// sheetFile gives the name of the part of the sheet at the given index. The
+// names of the sheets are not used as they can contain characters that are
+// not allowed in the names of parts.
+func sheetFile(index int) string {
+	return fmt.Sprintf("sheet%d.xml", index+1)
+}
+
+func (z *writer) writeWorksheet(file *File, sheet *Sheet, part string) {
 	if z.invalid() {
 		return
 	}
-	name := z.createTarget("worksheets", fmt.Sprintf("%s.xml", sheet.Name()))
-	writer, err := z.writer.Create(name)
+	name := z.createTarget("worksheets", part)
+	writer, err := z.create(name)
 	if err != nil {
 		z.err = err
 		return
@@ -211,9 +354,28 @@ func (z *writer) writeWorksheet(sheet *Sheet) {
 		z.err = err
 		return
 	}
+	sw.strings = z.strings
 	if err := sw.WriteSheet(sheet); err != nil {
 		z.err = err
+		return
 	}
+	var rels []xmlRelation
+	rels = append(rels, z.writeTables(sheet)...)
+	rels = append(rels, z.writeDrawing(sheet)...)
+	rels = append(rels, z.writePivotTables(file, sheet)...)
+	z.writeSheetRelations(part, rels)
+}
+
+func (z *writer) writeSheetRelations(part string, rels []xmlRelation) {
+	if z.invalid() || len(rels) == 0 {
+		return
+	}
+	root := xmlRelations{
+		Xmlns:     "http://schemas.openxmlformats.org/package/2006/relationships",
+		Relations: rels,
+	}
+	addr := z.createTarget("worksheets", "_rels", part+".rels")
+	z.encodeXML(addr, &root)
 }
 
 func (z *writer) writeWorkbook(f *File) {
@@ -229,33 +391,63 @@ func (z *writer) writeWorkbook(f *File) {
 		State   SheetState `xml:"state,attr"`
 	}
 
+	type xmlPivotCache struct {
+		XMLName xml.Name `xml:"pivotCache"`
+		CacheId int      `xml:"cacheId,attr"`
+		Id      string   `xml:"r:id,attr"`
+	}
+
 	root := struct {
 		XMLName    xml.Name `xml:"workbook"`
 		Xmlns      string   `xml:"xmlns,attr"`
 		RelXmlns   string   `xml:"xmlns:r,attr"`
 		Properties struct {
-			Date int `xml:"date1904,attr"`
-		} `xml:"workbookProperties"`
+			Date     int    `xml:"date1904,attr"`
+			CodeName string `xml:"codeName,attr,omitempty"`
+		} `xml:"workbookPr"`
 		Protection struct {
-			Locked int `xml:"lockStructure,attr"`
+			Locked    int    `xml:"lockStructure,attr"`
+			Password  string `xml:"workbookPassword,attr,omitempty"`
+			Algorithm string `xml:"workbookAlgorithmName,attr,omitempty"`
+			Hash      string `xml:"workbookHashValue,attr,omitempty"`
+			Salt      string `xml:"workbookSaltValue,attr,omitempty"`
+			SpinCount int    `xml:"workbookSpinCount,attr,omitempty"`
 		} `xml:"workbookProtection"`
 		Views struct {
 			View struct {
 				ActiveTab int `xml:"activeTab,attr"`
 			} `xml:"workbookView"`
-		} `xml:"workbookViews"`
+		} `xml:"bookViews"`
 		Sheets []xmlSheet `xml:"sheets>sheet"`
+		Names  *struct {
+			Names []*xmlDefinedName `xml:"definedName"`
+		} `xml:"definedNames"`
+		Calc   *xmlCalcPr      `xml:"calcPr"`
+		Pivots []xmlPivotCache `xml:"pivotCaches>pivotCache"`
 	}{
 		Xmlns:    typeMainUrl,
 		RelXmlns: "http://schemas.openxmlformats.org/officeDocument/2006/relationships",
 	}
 	if f.locked {
 		root.Protection.Locked++
+		if p := f.password; p != nil {
+			root.Protection.Password = p.Legacy
+			root.Protection.Algorithm = p.Algorithm
+			root.Protection.Hash = p.Hash
+			root.Protection.Salt = p.Salt
+			root.Protection.SpinCount = p.SpinCount
+		}
 	}
 	if f.date1904 {
 		root.Properties.Date++
 	}
-	for _, s := range f.sheets {
+	if f.HasMacros() {
+		root.Properties.CodeName = f.codeName
+	}
+	for i, s := range f.sheets {
+		if s.Active {
+			root.Views.View.ActiveTab = i
+		}
 		s.Id = z.createFileID()
 		s.Index = z.getFileIndex()
 		xs := xmlSheet{
@@ -265,12 +457,56 @@ func (z *writer) writeWorkbook(f *File) {
 			State: s.State,
 		}
 		root.Sheets = append(root.Sheets, xs)
+		if n := printArea(s, i); n != nil {
+			if root.Names == nil {
+				root.Names = &struct {
+					Names []*xmlDefinedName `xml:"definedName"`
+				}{}
+			}
+			root.Names.Names = append(root.Names.Names, n)
+		}
+		if root.Calc == nil && hasFormula(s) {
+			root.Calc = &xmlCalcPr{CalcId: calcEngineId}
+			if z.fullCalc {
+				root.Calc.FullCalc = "1"
+			}
+			if it := f.iterate; it.Count > 0 {
+				root.Calc.Iterate = "1"
+				root.Calc.Count = it.Count
+				root.Calc.Delta = it.Delta
+			}
+		}
+	}
+	if names := f.writeDefinedNames(); len(names) > 0 {
+		if root.Names == nil {
+			root.Names = &struct {
+				Names []*xmlDefinedName `xml:"definedName"`
+			}{}
+		}
+		root.Names.Names = append(root.Names.Names, names...)
+	}
+	for _, c := range f.pivotCaches {
+		c.relId = z.createFileID()
+		xc := xmlPivotCache{
+			CacheId: c.id,
+			Id:      c.relId,
+		}
+		root.Pivots = append(root.Pivots, xc)
 	}
 	z.encodeXML(z.createTarget("workbook.xml"), root)
 }
 
+func hasFormula(s *Sheet) bool {
+	for _, c := range s.cells {
+		if c.formula != nil {
+			return true
+		}
+	}
+	return false
+}
+
 func (z *writer) encodeXML(name string, ptr any) {
-	w, err := z.writer.Create(name)
+	w, err := z.create(name)
 	if err != nil {
 		z.err = err
 		return
@@ -309,6 +545,9 @@ func (z *writer) getFileIndex() int {
 
 type sheetWriter struct {
 	writer *sax.StreamWriter
+	shared map[layout.Position]sharedRef
+	// strings are written inline when no pool is given
+	strings *stringPool
 }
 
 func writeSheet(w io.Writer) (*sheetWriter, error) {
@@ -328,9 +567,12 @@ func (w *sheetWriter) WriteSheet(sheet *Sheet) error {
 		createNS("", typeMainUrl),
 		createNS("r", "http://schemas.openxmlformats.org/officeDocument/2006/relationships"),
 	})
+	w.writeSheetProperties(sheet)
 	w.writer.Empty(sax.LocalName("dimension"), []sax.A{
 		createAttr("ref", sheet.Bounds().String()),
 	})
+	w.writeSheetViews(sheet)
+	w.writeColumns(sheet)
 	if err := w.writeRows(sheet); err != nil {
 		return err
 	}
@@ -339,12 +581,26 @@ func (w *sheetWriter) WriteSheet(sheet *Sheet) error {
 			return err
 		}
 	}
+	if sheet.AutoFilter != nil {
+		w.writer.Empty(sax.LocalName("autoFilter"), []sax.A{
+			createAttr("ref", sheet.AutoFilter.String()),
+		})
+	}
+	if err := w.writeConditionals(sheet); err != nil {
+		return err
+	}
+	w.writePageSetup(sheet)
+	w.writeDrawingPart(sheet)
+	w.writeTableParts(sheet)
 	w.writer.Close(wshName)
 	return w.writer.Flush()
 }
 
 func (w *sheetWriter) writeProtection(sheet *Sheet) error {
 	var attrs []sax.A
+	if sheet.Password != nil {
+		attrs = append(attrs, sheet.Password.attrs()...)
+	}
 	if sheet.Protected&ProtectedSheet != 0 {
 		attrs = append(attrs, createAttr("sheet", "1"))
 	}
@@ -388,6 +644,14 @@ func (w *sheetWriter) writeRows(sheet *Sheet) error {
 		rowName = sax.LocalName("row")
 	)
 	w.writer.Open(dshName, nil)
+	if sheet.spilled != nil {
+		if err := w.writeSpilledRows(sheet); err != nil {
+			return err
+		}
+		w.writer.Close(dshName)
+		return nil
+	}
+	w.shared = groupSharedFormulas(sheet)
 	for _, r := range sheet.rows {
 		attrs := []sax.A{
 			createAttr("r", strconv.FormatInt(r.Line, 10)),
@@ -407,28 +671,65 @@ func (w *sheetWriter) writeRows(sheet *Sheet) error {
 	return nil
 }
 
+func (w *sheetWriter) writeSpilledRows(sheet *Sheet) error {
+	var (
+		rowName = sax.LocalName("row")
+		bd      = sheet.Bounds()
+	)
+	for lino, values := range sheet.spilled.Rows() {
+		attrs := []sax.A{
+			createAttr("r", strconv.FormatInt(lino, 10)),
+		}
+		w.writer.Open(rowName, attrs)
+		for i, v := range values {
+			if value.IsBlank(v) {
+				continue
+			}
+			cell := Cell{
+				Type:     typeFromValue(v),
+				Position: layout.NewPosition(lino, bd.Starts.Column+int64(i)),
+				raw:      rawFromValue(v),
+				parsed:   v,
+			}
+			if err := w.writeCell(&cell); err != nil {
+				return err
+			}
+		}
+		w.writer.Close(rowName)
+	}
+	return nil
+}
+
 func (w *sheetWriter) writeCell(cell *Cell) error {
-	if cell.Type == TypeInlineStr {
+	switch {
+	case w.strings != nil && (cell.Type == TypeInlineStr || cell.Type == TypeSharedStr):
+		return w.writeSharedStrCell(cell)
+	case cell.Type == TypeInlineStr:
 		return w.writeInlineStrCell(cell)
+	default:
+		return w.writeDefaultCell(cell)
 	}
-	return w.writeDefaultCell(cell)
 }
 
 func (w *sheetWriter) writeInlineStrCell(cell *Cell) error {
 	var (
 		cellName = sax.LocalName("c")
 		isName   = sax.LocalName("is")
-		valName  = sax.LocalName("t")
 	)
 	attrs := []sax.A{
-		createAttr("r", cell.Position.Addr()),
+		createAttr("r", cell.WithoutSheet().Addr()),
 		createAttr("t", cell.Type),
 	}
+	if cell.style > 0 {
+		attrs = append(attrs, createAttr("s", strconv.Itoa(cell.style)))
+	}
 	w.writer.Open(cellName, attrs)
 	w.writer.Open(isName, nil)
-	w.writer.Open(valName, nil)
-	w.writer.Text(cell.raw)
-	w.writer.Close(valName)
+	if len(cell.runs) > 0 {
+		w.writeRichText(cell.runs)
+	} else {
+		w.writeStringText(cell.raw)
+	}
 	w.writer.Close(isName)
 	w.writer.Close(cellName)
 	return nil
@@ -438,24 +739,35 @@ func (w *sheetWriter) writeDefaultCell(cell *Cell) error {
 	var (
 		cellName = sax.LocalName("c")
 		valName  = sax.LocalName("v")
-		formName = sax.LocalName("f")
 	)
 	attrs := []sax.A{
-		createAttr("r", cell.Position.Addr()),
+		createAttr("r", cell.WithoutSheet().Addr()),
+	}
+	if cell.style > 0 {
+		attrs = append(attrs, createAttr("s", strconv.Itoa(cell.style)))
 	}
-	if cell.Type != "" {
-		attrs = append(attrs, createAttr("t", cell.Type))
+	kind, raw := cell.Type, cell.raw
+	if cell.formula != nil {
+		kind, raw = cachedValue(cell)
+	}
+	if kind != "" {
+		attrs = append(attrs, createAttr("t", kind))
+	}
+	if cell.formula == nil && kind == "" && raw == "" {
+		// blank cell only keeping its format
+		w.writer.Empty(cellName, attrs)
+		return nil
 	}
 	w.writer.Open(cellName, attrs)
-	if e, ok := cell.formula.(interface{ Expr() parse.Expr }); ok {
-		str, _ := format.FormatOxml(e.Expr())
-		w.writer.Open(formName, nil)
-		w.writer.Text(str)
-		w.writer.Close(formName)
-	}
-	w.writer.Open(valName, nil)
-	w.writer.Text(cell.raw)
-	w.writer.Close(valName)
+	if cell.formula != nil {
+		w.writeFormula(cell)
+	}
+	if kind != "" || raw != "" {
+		// numbers are written without type
+		w.writer.Open(valName, nil)
+		w.writer.Text(raw)
+		w.writer.Close(valName)
+	}
 	w.writer.Close(cellName)
 	return nil
 }
@@ -21,6 +21,7 @@ const (
 	typeDocUrl    = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument"
 	typeMainUrl   = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
 	typeSharedUrl = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings"
+	typeTableUrl  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/table"
 )
 
 const (
@@ -30,12 +31,34 @@ const (
 	mimeWorksheet    = "application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"
 	mimeStyle        = "application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"
 	mimeSharedString = "application/vnd.openxmlformats-officedocument.spreadsheetml.sharedStrings+xml"
+	mimeTable        = "application/vnd.openxmlformats-officedocument.spreadsheetml.table+xml"
+	mimeDrawing      = "application/vnd.openxmlformats-officedocument.drawing+xml"
+	mimeChart        = "application/vnd.openxmlformats-officedocument.drawingml.chart+xml"
 )
 
 type xmlWorkbook struct {
-	XMLName xml.Name        `xml:"workbook"`
-	Sheets  []xmlSheet      `xml:"sheets>sheet"`
-	View    xmlWorkbookView `xml:"bookViews>workbookView"`
+	XMLName      xml.Name              `xml:"workbook"`
+	Sheets       []xmlSheet            `xml:"sheets>sheet"`
+	View         xmlWorkbookView       `xml:"bookViews>workbookView"`
+	DefinedNames []xmlDefinedName      `xml:"definedNames>definedName"`
+	PivotCaches  []xmlPivotCacheRef    `xml:"pivotCaches>pivotCache"`
+	Protection   xmlWorkbookProtection `xml:"workbookProtection"`
+	Properties   xmlWorkbookProperties `xml:"workbookPr"`
+	Calc         *xmlCalcPr            `xml:"calcPr"`
+}
+
+type xmlWorkbookProperties struct {
+	CodeName string `xml:"codeName,attr"`
+	Date1904 string `xml:"date1904,attr"`
+}
+
+type xmlWorkbookProtection struct {
+	Locked    bool   `xml:"lockStructure,attr"`
+	Password  string `xml:"workbookPassword,attr"`
+	Algorithm string `xml:"workbookAlgorithmName,attr"`
+	Hash      string `xml:"workbookHashValue,attr"`
+	Salt      string `xml:"workbookSaltValue,attr"`
+	SpinCount int    `xml:"workbookSpinCount,attr"`
 }
 
 type xmlWorkbookView struct {
@@ -65,15 +88,11 @@ type xmlRelation struct {
 }
 
 type xmlSharedStrings struct {
-	XMLName   xml.Name          `xml:"sst"`
-	Xmlns     string            `xml:"xmlns,attr"`
-	Count     int               `xml:"count,attr"`
-	UniqCount int               `xml:"uniqueCount,attr"`
-	Values    []xmlSharedString `xml:"si"`
-}
-
-type xmlSharedString struct {
-	Value string `xml:"t"`
+	XMLName   xml.Name      `xml:"sst"`
+	Xmlns     string        `xml:"xmlns,attr"`
+	Count     int           `xml:"count,attr"`
+	UniqCount int           `xml:"uniqueCount,attr"`
+	Values    []xmlRichText `xml:"si"`
 }
 
 type xmlRow struct {
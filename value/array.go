@@ -150,3 +150,94 @@ func (a Array) ApplyArray(other Array, do func(Value, Value) Value) Value {
 	}
 	return NewArray(data)
 }
+
+// Composite is the union of several areas of cells, eg (A1:A3,C1:C3). Its
+// values are the values of its areas given one after the other.
+type Composite struct {
+	Areas []Value
+}
+
+// NewComposite gives the union of the values given. Areas of composites are
+// added to the union instead of the composites themselves.
+func NewComposite(areas ...Value) Composite {
+	var c Composite
+	for _, a := range areas {
+		if x, ok := a.(Composite); ok {
+			c.Areas = append(c.Areas, x.Areas...)
+			continue
+		}
+		c.Areas = append(c.Areas, a)
+	}
+	return c
+}
+
+func (c Composite) Type() string {
+	return fmt.Sprintf("composite(%d)", len(c.Areas))
+}
+
+func (Composite) Kind() ValueKind {
+	return KindArray
+}
+
+func (Composite) String() string {
+	return TypeArray
+}
+
+// Dimension gives the size of the areas put one below the other.
+func (c Composite) Dimension() layout.Dimension {
+	var d layout.Dimension
+	for _, a := range c.Areas {
+		x := areaDimension(a)
+		d.Lines += x.Lines
+		d.Columns = max(d.Columns, x.Columns)
+	}
+	return d
+}
+
+func (c Composite) At(row, col int) Value {
+	for _, a := range c.Areas {
+		d := areaDimension(a)
+		if int64(row) >= d.Lines {
+			row -= int(d.Lines)
+			continue
+		}
+		if int64(col) >= d.Columns {
+			return ErrNA
+		}
+		if arr, ok := a.(ArrayValue); ok {
+			return arr.At(row, col)
+		}
+		return a
+	}
+	return ErrNA
+}
+
+func (c Composite) Values() iter.Seq[Value] {
+	it := func(yield func(Value) bool) {
+		for _, a := range c.Areas {
+			arr, ok := a.(ArrayValue)
+			if !ok {
+				if !yield(a) {
+					return
+				}
+				continue
+			}
+			dim := arr.Dimension()
+			for row := range dim.Lines {
+				for col := range dim.Columns {
+					if !yield(arr.At(int(row), int(col))) {
+						return
+					}
+				}
+			}
+		}
+	}
+	return it
+}
+
+func areaDimension(v Value) layout.Dimension {
+	if arr, ok := v.(ArrayValue); ok {
+		return arr.Dimension()
+	}
+	return layout.Dimension{Lines: 1, Columns: 1}
+}
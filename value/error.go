@@ -10,6 +10,7 @@ var (
 	ErrName  = createError("#NAME?")
 	ErrNum   = createError("#NUM!")
 	ErrNA    = createError("#N/A")
+	ErrSpill = createError("#SPILL!")
 )
 
 func HasErrors(vals ...Value) Value {
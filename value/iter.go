@@ -5,7 +5,7 @@ import (
 )
 
 type ValueIterator interface {
-	Values() iter.Seq[ScalarValue]
+	Values() iter.Seq[Value]
 }
 
 func Each(args []Value, fn func(Value)) Value {
@@ -15,16 +15,33 @@ func Each(args []Value, fn func(Value)) Value {
 		}
 		if IsScalar(a) {
 			fn(a)
-		} else if IsArray(a) {
-			it, ok := a.(ValueIterator)
-			if !ok {
-				continue
-			}
-			var dat []Value
-			for v := range it.Values() {
+			continue
+		}
+		if x, ok := a.(interface{ AsArray() ArrayValue }); ok && !IsArray(a) {
+			// views are iterated as the array of their values
+			a = x.AsArray()
+		}
+		if !IsArray(a) {
+			continue
+		}
+		var dat []Value
+		switch x := a.(type) {
+		case ValueIterator:
+			for v := range x.Values() {
 				dat = append(dat, v)
 			}
-			Each(dat, fn)
+		case ArrayValue:
+			dim := x.Dimension()
+			for i := range dim.Lines {
+				for j := range dim.Columns {
+					dat = append(dat, x.At(int(i), int(j)))
+				}
+			}
+		default:
+			continue
+		}
+		if err := Each(dat, fn); err != nil {
+			return err
 		}
 	}
 	return nil
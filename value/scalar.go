@@ -3,6 +3,7 @@ package value
 import (
 	"math"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -30,6 +31,52 @@ func (Blank) Scalar() any {
 
 type Date time.Time
 
+// epochs of the serial numbers of dates used by spreadsheets. In the 1900
+// date system, serial numbers after 60 are one day ahead since 1900 is
+// considered as a leap year.
+var (
+	epoch1900 = time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC)
+	epoch1904 = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+const (
+	leapBug1900 = 60
+	secondsDay  = 24 * 60 * 60
+)
+
+// DateFromSerial gives the date of a serial number of the 1900 date system or
+// of the 1904 date system.
+func DateFromSerial(serial float64, date1904 bool) Date {
+	epoch := epoch1900
+	if date1904 {
+		epoch = epoch1904
+	} else if serial >= leapBug1900 {
+		serial--
+	}
+	var (
+		days = math.Floor(serial)
+		secs = math.Round((serial - days) * secondsDay)
+	)
+	t := epoch.AddDate(0, 0, int(days)).Add(time.Duration(secs) * time.Second)
+	return Date(t)
+}
+
+// Serial gives the serial number of the date in the 1900 date system or in the
+// 1904 date system.
+func (d Date) Serial(date1904 bool) float64 {
+	t := time.Time(d)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	epoch := epoch1900
+	if date1904 {
+		epoch = epoch1904
+	}
+	serial := t.Sub(epoch).Seconds() / secondsDay
+	if !date1904 && serial >= leapBug1900 {
+		serial++
+	}
+	return serial
+}
+
 func (Date) Type() string {
 	return TypeDate
 }
@@ -39,29 +86,38 @@ func (Date) Kind() ValueKind {
 }
 
 func (d Date) String() string {
-	return time.Time(d).Format("2006-01-02")
+	t := time.Time(d)
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02T15:04:05")
 }
 
 func (d Date) Scalar() any {
 	return time.Time(d)
 }
 
+// Add gives the date a number of days after d. Fractions of days are kept as
+// times.
 func (d Date) Add(other Value) ScalarValue {
 	f, err := CastToFloat(other)
-	if err != nil {
+	if err != nil || other.Type() == TypeDate {
 		return ErrValue
 	}
-	t := time.Time(d).Add(time.Duration(f) * time.Second)
-	return Date(t)
+	return DateFromSerial(d.Serial(false)+float64(f), false)
 }
 
+// Sub gives the date a number of days before d or the number of days between
+// two dates.
 func (d Date) Sub(other Value) ScalarValue {
+	if x, ok := other.(Date); ok {
+		return Float(d.Serial(false) - x.Serial(false))
+	}
 	f, err := CastToFloat(other)
 	if err != nil {
 		return ErrValue
 	}
-	t := time.Time(d).Add(-time.Duration(f) * time.Second)
-	return Date(t)
+	return DateFromSerial(d.Serial(false)-float64(f), false)
 }
 
 func (d Date) ToString() ScalarValue {
@@ -72,25 +128,42 @@ func (d Date) ToBool() ScalarValue {
 	return Boolean(!time.Time(d).IsZero())
 }
 
-func (d Date) ToFloat() ScalarValue {
-	unix := time.Time(d).Unix()
-	return Float(float64(unix))
+func (d Date) ToFloat() (ScalarValue, error) {
+	return Float(d.Serial(false)), nil
 }
 
 func (d Date) Equal(other Value) (bool, error) {
-	x, ok := other.(Date)
-	if !ok {
+	switch x := other.(type) {
+	case Date:
+		return time.Time(d).Equal(time.Time(x)), nil
+	case Float:
+		return d.Serial(false) == float64(x), nil
+	case Text:
+		when, err := CastToDate(x)
+		if err != nil {
+			return false, ErrCompatible
+		}
+		return d.Equal(when)
+	default:
 		return false, ErrCompatible
 	}
-	return time.Time(d).Equal(time.Time(x)), nil
 }
 
 func (d Date) Less(other Value) (bool, error) {
-	x, ok := other.(Date)
-	if !ok {
+	switch x := other.(type) {
+	case Date:
+		return time.Time(d).Before(time.Time(x)), nil
+	case Float:
+		return d.Serial(false) < float64(x), nil
+	case Text:
+		when, err := CastToDate(x)
+		if err != nil {
+			return false, ErrCompatible
+		}
+		return d.Less(when)
+	default:
 		return false, ErrCompatible
 	}
-	return time.Time(d).Before(time.Time(x)), nil
 }
 
 type Float float64
@@ -111,7 +184,12 @@ func (f Float) Scalar() any {
 	return float64(f)
 }
 
+// Add gives the sum of the numbers. A number added to a date is a number of
+// days and gives a date.
 func (f Float) Add(other Value) ScalarValue {
+	if d, ok := other.(Date); ok {
+		return d.Add(f)
+	}
 	x, err := CastToFloat(other)
 	if err != nil {
 		return ErrValue
@@ -166,7 +244,17 @@ func (f Float) ToFloat() (ScalarValue, error) {
 	return f, nil
 }
 
+func (f Float) ToDate() (ScalarValue, error) {
+	if f < 0 {
+		return ErrNum, nil
+	}
+	return DateFromSerial(float64(f), false), nil
+}
+
 func (f Float) Equal(other Value) (bool, error) {
+	if d, ok := other.(Date); ok {
+		return float64(f) == d.Serial(false), nil
+	}
 	x, ok := other.(Float)
 	if !ok {
 		return false, ErrCompatible
@@ -175,6 +263,9 @@ func (f Float) Equal(other Value) (bool, error) {
 }
 
 func (f Float) Less(other Value) (bool, error) {
+	if d, ok := other.(Date); ok {
+		return float64(f) < d.Serial(false), nil
+	}
 	x, ok := other.(Float)
 	if !ok {
 		return false, ErrCompatible
@@ -287,7 +378,20 @@ func (t Text) ToFloat() (ScalarValue, error) {
 	return Float(n), nil
 }
 
+func (t Text) ToDate() (ScalarValue, error) {
+	for _, f := range []string{time.DateOnly, "2006-01-02T15:04:05", time.DateTime, time.RFC3339} {
+		when, err := time.Parse(f, strings.TrimSpace(string(t)))
+		if err == nil {
+			return Date(when), nil
+		}
+	}
+	return ErrValue, nil
+}
+
 func (t Text) Equal(other Value) (bool, error) {
+	if d, ok := other.(Date); ok {
+		return compareTextDate(t, d, Date.Equal)
+	}
 	x, ok := other.(Text)
 	if !ok {
 		return false, ErrCompatible
@@ -296,6 +400,9 @@ func (t Text) Equal(other Value) (bool, error) {
 }
 
 func (t Text) Less(other Value) (bool, error) {
+	if d, ok := other.(Date); ok {
+		return compareTextDate(t, d, Date.Less)
+	}
 	x, ok := other.(Text)
 	if !ok {
 		return false, ErrCompatible
@@ -303,6 +410,16 @@ func (t Text) Less(other Value) (bool, error) {
 	return string(t) < string(x), nil
 }
 
+// compareTextDate compares a text holding a date, as read from a csv file, with
+// a date.
+func compareTextDate(t Text, d Date, cmp func(Date, Value) (bool, error)) (bool, error) {
+	when, err := CastToDate(t)
+	if err != nil {
+		return false, ErrCompatible
+	}
+	return cmp(when, d)
+}
+
 type Boolean bool
 
 func (Boolean) Type() string {
@@ -81,6 +81,15 @@ func IsBlank(v Value) bool {
 	return ok
 }
 
+// IsEmpty reports whether v is blank or an empty text, as the empty cells of
+// the files read as text.
+func IsEmpty(v Value) bool {
+	if t, ok := v.(Text); ok {
+		return t == ""
+	}
+	return IsBlank(v)
+}
+
 func Rows(rs ...[]Value) [][]Value {
 	return rs
 }
@@ -130,6 +139,9 @@ type Predicate interface {
 }
 
 func Add(left, right Value) Value {
+	if err := HasErrors(left, right); err != nil {
+		return err
+	}
 	a, ok := left.(interface {
 		Add(Value) ScalarValue
 	})
@@ -140,6 +152,9 @@ func Add(left, right Value) Value {
 }
 
 func Sub(left, right Value) Value {
+	if err := HasErrors(left, right); err != nil {
+		return err
+	}
 	a, ok := left.(interface {
 		Sub(Value) ScalarValue
 	})
@@ -150,6 +165,9 @@ func Sub(left, right Value) Value {
 }
 
 func Mul(left, right Value) Value {
+	if err := HasErrors(left, right); err != nil {
+		return err
+	}
 	a, ok := left.(interface {
 		Mul(Value) ScalarValue
 	})
@@ -160,6 +178,9 @@ func Mul(left, right Value) Value {
 }
 
 func Div(left, right Value) Value {
+	if err := HasErrors(left, right); err != nil {
+		return err
+	}
 	a, ok := left.(interface {
 		Div(Value) ScalarValue
 	})
@@ -170,6 +191,9 @@ func Div(left, right Value) Value {
 }
 
 func Pow(left, right Value) Value {
+	if err := HasErrors(left, right); err != nil {
+		return err
+	}
 	a, ok := left.(interface {
 		Pow(Value) ScalarValue
 	})
@@ -180,17 +204,39 @@ func Pow(left, right Value) Value {
 }
 
 func Concat(left, right Value) Value {
-	ls, err := CastToText(left)
-	if err != nil {
+	if err := HasErrors(left, right); err != nil {
+		return err
+	}
+	ls, ok := concatText(left)
+	if !ok {
 		return ErrValue
 	}
-	rs, err := CastToText(right)
-	if err != nil {
+	rs, ok := concatText(right)
+	if !ok {
 		return ErrValue
 	}
 	return Text(ls + rs)
 }
 
+// concatText gives the text of a scalar as it is displayed in a cell.
+func concatText(val Value) (string, bool) {
+	switch v := val.(type) {
+	case Text:
+		return string(v), true
+	case Blank:
+		return "", true
+	case Boolean:
+		if v {
+			return "TRUE", true
+		}
+		return "FALSE", true
+	case Float, Date:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
+
 func Eq(left, right Value) Value {
 	if IsBlank(left) && IsBlank(right) {
 		return Boolean(true)
@@ -198,6 +244,9 @@ func Eq(left, right Value) Value {
 	if IsError(left) && IsError(right) {
 		return Boolean(left == right)
 	}
+	if err := HasErrors(left, right); err != nil {
+		return err
+	}
 	cmp, ok := left.(Comparable)
 	if !ok {
 		return ErrValue
@@ -210,6 +259,9 @@ func Eq(left, right Value) Value {
 }
 
 func Ne(left, right Value) Value {
+	if err := HasErrors(left, right); err != nil {
+		return err
+	}
 	cmp, ok := left.(Comparable)
 	if !ok {
 		return ErrValue
@@ -222,6 +274,9 @@ func Ne(left, right Value) Value {
 }
 
 func Lt(left, right Value) Value {
+	if err := HasErrors(left, right); err != nil {
+		return err
+	}
 	cmp, ok := left.(Comparable)
 	if !ok {
 		return ErrValue
@@ -234,6 +289,9 @@ func Lt(left, right Value) Value {
 }
 
 func Le(left, right Value) Value {
+	if err := HasErrors(left, right); err != nil {
+		return err
+	}
 	cmp, ok := left.(Comparable)
 	if !ok {
 		return ErrValue
@@ -250,6 +308,9 @@ func Le(left, right Value) Value {
 }
 
 func Gt(left, right Value) Value {
+	if err := HasErrors(left, right); err != nil {
+		return err
+	}
 	cmp, ok := left.(Comparable)
 	if !ok {
 		return ErrValue
@@ -266,6 +327,9 @@ func Gt(left, right Value) Value {
 }
 
 func Ge(left, right Value) Value {
+	if err := HasErrors(left, right); err != nil {
+		return err
+	}
 	cmp, ok := left.(Comparable)
 	if !ok {
 		return ErrValue
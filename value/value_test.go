@@ -3,6 +3,7 @@ package value
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/midbel/dockit/layout"
 )
@@ -115,6 +116,22 @@ func TestArithmeticAndComparison(t *testing.T) {
 		{Name: "less", Got: Lt(Float(1), Float(2)), Want: Boolean(true), Check: sameValue},
 		{Name: "greater", Got: Gt(Float(3), Float(2)), Want: Boolean(true), Check: sameValue},
 		{Name: "incompatible add", Got: Add(Boolean(true), Float(1)), Want: ErrValue, Check: sameValue},
+		{Name: "concat number", Got: Concat(Text("v"), Float(2)), Want: Text("v2"), Check: sameValue},
+		{Name: "error in add", Got: Add(Float(1), ErrNA), Want: ErrNA, Check: sameValue},
+		{Name: "error in divide", Got: Div(ErrRef, Float(0)), Want: ErrRef, Check: sameValue},
+		{Name: "error in concat", Got: Concat(Text("v"), ErrName), Want: ErrName, Check: sameValue},
+		{Name: "error in compare", Got: Lt(ErrDiv0, Float(2)), Want: ErrDiv0, Check: sameValue},
+		{Name: "error in equal", Got: Eq(Float(2), ErrNum), Want: ErrNum, Check: sameValue},
+		{Name: "days between dates", Got: Sub(day(2024, 3, 1), day(2024, 1, 31)), Want: Float(30), Check: sameValue},
+		{Name: "date plus days", Got: Add(day(2024, 1, 31), Float(30)), Want: day(2024, 3, 1), Check: sameValue},
+		{Name: "days plus date", Got: Add(Float(30), day(2024, 1, 31)), Want: day(2024, 3, 1), Check: sameValue},
+		{Name: "date minus days", Got: Sub(day(2024, 3, 1), Float(30)), Want: day(2024, 1, 31), Check: sameValue},
+		{Name: "add dates", Got: Add(day(2024, 3, 1), day(2024, 1, 31)), Want: ErrValue, Check: sameValue},
+		{Name: "serial equal date", Got: Eq(Float(45322), day(2024, 1, 31)), Want: Boolean(true), Check: sameValue},
+		{Name: "serial less date", Got: Lt(Float(45322), day(2024, 3, 1)), Want: Boolean(true), Check: sameValue},
+		{Name: "text less date", Got: Lt(Text("2024-01-31"), day(2024, 3, 1)), Want: Boolean(true), Check: sameValue},
+		{Name: "date greater text", Got: Gt(day(2024, 3, 1), Text("2024-01-31")), Want: Boolean(true), Check: sameValue},
+		{Name: "text not a date", Got: Lt(Text("foo"), day(2024, 3, 1)), Want: ErrValue, Check: sameValue},
 	}
 	for _, tt := range tests {
 		t.Run(tt.Name, func(t *testing.T) {
@@ -125,6 +142,10 @@ func TestArithmeticAndComparison(t *testing.T) {
 	}
 }
 
+func day(year, month, day int) Date {
+	return Date(time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC))
+}
+
 func TestErrors(t *testing.T) {
 	if got := HasErrors(Float(1), nil, ErrName, ErrRef); got != ErrName {
 		t.Fatalf("expected first error %s, got %s", ErrName, got)
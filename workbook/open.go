@@ -94,10 +94,19 @@ func Formats() []string {
 }
 
 func Merge(format string, sources []string) (grid.File, error) {
+	return MergeWithBudget(format, sources, nil)
+}
+
+// MergeWithBudget merges the sources into a new file. Sheets that do not fit
+// in the given budget are spilled to disk if the format supports it.
+func MergeWithBudget(format string, sources []string, budget *grid.Budget) (grid.File, error) {
 	wb, err := createEmpty(format)
 	if err != nil {
 		return nil, err
 	}
+	if sp, ok := wb.(driver.Spiller); ok && budget != nil {
+		sp.SetBudget(budget)
+	}
 	mg, ok := wb.(driver.Merger)
 	if !ok {
 		return nil, fmt.Errorf("%s does not support merging files", format)